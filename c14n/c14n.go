@@ -0,0 +1,445 @@
+//	Canonicalizes XML documents according to Canonical XML 1.0 and 1.1 and Exclusive XML Canonicalization 1.0 (with or without comments), as required
+//	for computing and verifying XML-DSig signatures over them. Canonicalization adds the default attributes that a DTD declares for elements omitting them:
+//	CanonicalizeWithSchema does the same for the default and fixed attributes declared by an XML Schema (see xsd.Schema.DefaultAttributes), so that documents
+//	described by schemas canonicalize (and verify) alike whether or not they spell out these attributes.
+//
+//	DTDs are not processed: document type declarations are dropped, and documents referring to entities other than the predefined ones fail to decode.
+//	Literal tab and newline characters in attribute values are kept rather than normalized to spaces, as encoding/xml does not tell them apart from
+//	character references. Documents must be encoded in UTF-8.
+package c14n
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/url"
+	"sort"
+	"strings"
+)
+
+//	The XML-DSig algorithm URIs of the supported canonicalization methods, see Options.Algorithm.
+const (
+	C14N10                = "http://www.w3.org/TR/2001/REC-xml-c14n-20010315"
+	C14N10WithComments    = C14N10 + "#WithComments"
+	C14N11                = "http://www.w3.org/2006/12/xml-c14n11"
+	C14N11WithComments    = C14N11 + "#WithComments"
+	ExcC14N10             = "http://www.w3.org/2001/10/xml-exc-c14n#"
+	ExcC14N10WithComments = ExcC14N10 + "WithComments"
+)
+
+const xmlNamespace = "http://www.w3.org/XML/1998/namespace"
+
+const (
+	kindDocument = iota
+	kindElement
+	kindText
+	kindComment
+	kindProcInst
+)
+
+var (
+	textEscaper = strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;", "\r", "&#xD;")
+	attrEscaper = strings.NewReplacer("&", "&amp;", "<", "&lt;", "\"", "&quot;", "\t", "&#x9;", "\n", "&#xA;", "\r", "&#xD;")
+)
+
+//	Configures Canonicalize and CanonicalizeWithSchema.
+type Options struct {
+	//	The canonicalization method, identified by its XML-DSig algorithm URI (one of the C14N* and ExcC14N* constants). Defaults to ExcC14N10 if empty.
+	Algorithm string
+
+	//	For exclusive canonicalization only: the prefixes of the InclusiveNamespaces PrefixList, whose namespace declarations are rendered as by
+	//	inclusive canonicalization, even where not visibly utilized. "#default" denotes the default namespace.
+	InclusivePrefixes []string
+
+	//	If not empty, only the element that has an ID attribute (an unqualified Id, ID or id attribute, or xml:id) of this value is canonicalized,
+	//	along with its descendants, as for a same-document XML-DSig reference such as URI="#abc". Otherwise, the whole document is.
+	ID string
+
+	//	For every element of the document (in document order), the attributes to add to it unless it has them already, such as the default and fixed
+	//	attributes returned by xsd.Schema.DefaultAttributes. Attributes in namespaces other than that of xml:* attributes are rendered with a prefix
+	//	declared for their namespace in scope of the element, or else with a prefix (such as "ns1") declared on the element for this purpose.
+	Defaults [][]xml.Attr
+}
+
+//	The schema knowledge used by CanonicalizeWithSchema, implemented by *xsd.Schema and *xsd.SchemaSet.
+type Schema interface {
+	//	Returns, for every element of the XML instance document read from r in document order, the attributes absent from it that have default or fixed values.
+	DefaultAttributes(r io.Reader) ([][]xml.Attr, error)
+}
+
+type attr struct {
+	prefix, local, space, value string
+}
+
+type node struct {
+	kind          int
+	parent        *node
+	kids          []*node
+	prefix, local string
+	atts          []attr
+	ns            map[string]string
+	data          string
+}
+
+type canonicalizer struct {
+	buf                      bytes.Buffer
+	exclusive, v11, comments bool
+	inclusive                map[string]bool
+}
+
+//	Writes the canonical form of the XML document read from r to w, according to opts.
+func Canonicalize(w io.Writer, r io.Reader, opts Options) (err error) {
+	var doc, apex *node
+	var me = &canonicalizer{inclusive: map[string]bool{}}
+	switch opts.Algorithm {
+	case C14N10, C14N10WithComments:
+	case C14N11, C14N11WithComments:
+		me.v11 = true
+	case "", ExcC14N10, ExcC14N10WithComments:
+		me.exclusive = true
+	default:
+		return fmt.Errorf("unsupported canonicalization algorithm %q", opts.Algorithm)
+	}
+	me.comments = strings.HasSuffix(opts.Algorithm, "WithComments")
+	for _, prefix := range opts.InclusivePrefixes {
+		me.inclusive[strings.TrimPrefix(prefix, "#default")] = true
+	}
+	if doc, err = parse(r, opts.Defaults); err != nil {
+		return
+	}
+	if len(opts.ID) > 0 {
+		if apex = doc.find(opts.ID); apex == nil {
+			return fmt.Errorf("no element found with the ID %q", opts.ID)
+		}
+		me.element(apex, map[string]string{}, me.apexAtts(apex))
+	} else {
+		me.document(doc)
+	}
+	_, err = w.Write(me.buf.Bytes())
+	return
+}
+
+//	Like Canonicalize, but adding the default and fixed attributes that schema assigns to the elements of the document read from r
+//	(replacing opts.Defaults), as canonicalization requires of attributes whose defaults are declared in a schema rather than a DTD.
+func CanonicalizeWithSchema(w io.Writer, r io.Reader, schema Schema, opts Options) (err error) {
+	var raw []byte
+	if raw, err = ioutil.ReadAll(r); err == nil {
+		if opts.Defaults, err = schema.DefaultAttributes(bytes.NewReader(raw)); err == nil {
+			err = Canonicalize(w, bytes.NewReader(raw), opts)
+		}
+	}
+	return
+}
+
+//	Decodes the XML document read from r into a tree of nodes rooted in a document node, resolving the namespaces of element and attribute
+//	prefixes, and adding defaults to the elements (see Options.Defaults).
+func parse(r io.Reader, defaults [][]xml.Attr) (doc *node, err error) {
+	var (
+		tok   xml.Token
+		ok    bool
+		index int
+		xd    = xml.NewDecoder(r)
+	)
+	doc = &node{kind: kindDocument}
+	cur := doc
+	for {
+		if tok, err = xd.RawToken(); err != nil {
+			if err == io.EOF {
+				err = nil
+			}
+			break
+		}
+		switch t := tok.(type) {
+		case xml.StartElement:
+			n := &node{kind: kindElement, parent: cur, prefix: t.Name.Space, local: t.Name.Local, ns: map[string]string{}}
+			for _, a := range t.Attr {
+				if a.Name.Space == "xmlns" {
+					n.ns[a.Name.Local] = a.Value
+				} else if (len(a.Name.Space) == 0) && (a.Name.Local == "xmlns") {
+					n.ns[""] = a.Value
+				} else {
+					n.atts = append(n.atts, attr{prefix: a.Name.Space, local: a.Name.Local, value: a.Value})
+				}
+			}
+			if _, ok = n.lookup(n.prefix); !ok {
+				return nil, fmt.Errorf("undeclared namespace prefix %q of element <%s:%s>", n.prefix, n.prefix, n.local)
+			}
+			for i, a := range n.atts {
+				if len(a.prefix) > 0 {
+					if n.atts[i].space, ok = n.lookup(a.prefix); !ok {
+						return nil, fmt.Errorf("undeclared namespace prefix %q of attribute %s:%s", a.prefix, a.prefix, a.local)
+					}
+				}
+			}
+			if index < len(defaults) {
+				n.addDefaults(defaults[index])
+			}
+			index++
+			cur.kids = append(cur.kids, n)
+			cur = n
+		case xml.EndElement:
+			if (cur.kind != kindElement) || (t.Name.Space != cur.prefix) || (t.Name.Local != cur.local) {
+				return nil, fmt.Errorf("unexpected end tag </%s>", qualified(t.Name.Space, t.Name.Local))
+			}
+			cur = cur.parent
+		case xml.CharData:
+			if cur != doc {
+				cur.kids = append(cur.kids, &node{kind: kindText, parent: cur, data: string(t)})
+			}
+		case xml.Comment:
+			cur.kids = append(cur.kids, &node{kind: kindComment, parent: cur, data: string(t)})
+		case xml.ProcInst:
+			if t.Target != "xml" {
+				cur.kids = append(cur.kids, &node{kind: kindProcInst, parent: cur, local: t.Target, data: string(t.Inst)})
+			}
+		}
+	}
+	if err == nil {
+		var roots int
+		for _, kid := range doc.kids {
+			if kid.kind == kindElement {
+				roots++
+			}
+		}
+		if cur != doc {
+			err = fmt.Errorf("unexpected end of document in element <%s>", qualified(cur.prefix, cur.local))
+		} else if roots != 1 {
+			err = fmt.Errorf("expected exactly one root element, found %d", roots)
+		}
+	}
+	if err != nil {
+		doc = nil
+	}
+	return
+}
+
+//	Returns local, qualified by prefix if not empty.
+func qualified(prefix, local string) string {
+	if len(prefix) > 0 {
+		return prefix + ":" + local
+	}
+	return local
+}
+
+//	Returns the namespace URI that prefix (empty for the default namespace) is bound to in scope of this node, and whether it is bound at all.
+func (me *node) lookup(prefix string) (uri string, ok bool) {
+	if prefix == "xml" {
+		return xmlNamespace, true
+	}
+	for n := me; n != nil; n = n.parent {
+		if uri, ok = n.ns[prefix]; ok {
+			return
+		}
+	}
+	return "", len(prefix) == 0
+}
+
+//	Returns all namespace prefixes (empty for the default namespace) bound in scope of this node, except "xml", and their namespace URIs.
+func (me *node) inScope() (scope map[string]string) {
+	scope = map[string]string{}
+	for n := me; n != nil; n = n.parent {
+		for prefix, uri := range n.ns {
+			if _, ok := scope[prefix]; !ok {
+				scope[prefix] = uri
+			}
+		}
+	}
+	return
+}
+
+//	Returns the attribute of atts with the namespace space and the local name local, or nil if none.
+func findAttr(atts []attr, space, local string) *attr {
+	for i, a := range atts {
+		if (a.space == space) && (a.local == local) {
+			return &atts[i]
+		}
+	}
+	return nil
+}
+
+//	Adds those of atts that this element does not have, see Options.Defaults.
+func (me *node) addDefaults(atts []xml.Attr) {
+	for _, a := range atts {
+		if findAttr(me.atts, a.Name.Space, a.Name.Local) == nil {
+			me.atts = append(me.atts, attr{prefix: me.prefixFor(a.Name.Space), local: a.Name.Local, space: a.Name.Space, value: a.Value})
+		}
+	}
+}
+
+//	Returns the prefix of attributes of this element in the namespace uri: none if uri is empty, or else the first (by name) of those bound to uri
+//	in scope of this element, or else one newly declared on this element.
+func (me *node) prefixFor(uri string) (prefix string) {
+	if len(uri) == 0 {
+		return
+	} else if uri == xmlNamespace {
+		return "xml"
+	}
+	scope := me.inScope()
+	for p, u := range scope {
+		if (len(p) > 0) && (u == uri) && ((len(prefix) == 0) || (p < prefix)) {
+			prefix = p
+		}
+	}
+	for i := 1; len(prefix) == 0; i++ {
+		if _, taken := scope[fmt.Sprintf("ns%d", i)]; !taken {
+			prefix = fmt.Sprintf("ns%d", i)
+			me.ns[prefix] = uri
+		}
+	}
+	return
+}
+
+//	Returns the first element (in document order) of the subtree rooted at this node that has an ID attribute of the value id (see Options.ID), if any.
+func (me *node) find(id string) *node {
+	for _, a := range me.atts {
+		if (a.value == id) && (((len(a.prefix) == 0) && ((a.local == "Id") || (a.local == "ID") || (a.local == "id"))) || ((a.space == xmlNamespace) && (a.local == "id"))) {
+			return me
+		}
+	}
+	for _, kid := range me.kids {
+		if n := kid.find(id); n != nil {
+			return n
+		}
+	}
+	return nil
+}
+
+//	Renders the document node doc: its root element, and the comments and processing instructions before and after it, each on a line of its own.
+func (me *canonicalizer) document(doc *node) {
+	var afterRoot bool
+	for _, n := range doc.kids {
+		if (n.kind == kindText) || ((n.kind == kindComment) && !me.comments) {
+			continue
+		} else if afterRoot {
+			me.buf.WriteString("\n")
+		}
+		if n.kind == kindElement {
+			me.element(n, map[string]string{}, n.atts)
+			afterRoot = true
+		} else {
+			me.node(n, nil)
+		}
+		if !afterRoot {
+			me.buf.WriteString("\n")
+		}
+	}
+}
+
+//	Renders the element n with the attributes atts, where rendered holds the namespace declarations rendered by its output ancestors.
+func (me *canonicalizer) element(n *node, rendered map[string]string, atts []attr) {
+	var prefixes []string
+	var visible = map[string]bool{n.prefix: true}
+	for _, a := range atts {
+		if len(a.prefix) > 0 {
+			visible[a.prefix] = true
+		}
+	}
+	kidRendered := map[string]string{}
+	for prefix, uri := range rendered {
+		kidRendered[prefix] = uri
+	}
+	for prefix, uri := range n.inScope() {
+		if me.exclusive && !(visible[prefix] || me.inclusive[prefix]) {
+			continue
+		} else if prev, ok := rendered[prefix]; (ok && (prev == uri)) || ((!ok) && (len(prefix) == 0) && (len(uri) == 0)) {
+			continue
+		}
+		prefixes, kidRendered[prefix] = append(prefixes, prefix), uri
+	}
+	sort.Strings(prefixes)
+	sorted := append([]attr{}, atts...)
+	sort.Sort(attrsByName(sorted))
+	me.buf.WriteString("<" + qualified(n.prefix, n.local))
+	for _, prefix := range prefixes {
+		if me.buf.WriteString(" xmlns"); len(prefix) > 0 {
+			me.buf.WriteString(":" + prefix)
+		}
+		me.buf.WriteString("=\"" + attrEscaper.Replace(kidRendered[prefix]) + "\"")
+	}
+	for _, a := range sorted {
+		me.buf.WriteString(" " + qualified(a.prefix, a.local) + "=\"" + attrEscaper.Replace(a.value) + "\"")
+	}
+	me.buf.WriteString(">")
+	for _, kid := range n.kids {
+		me.node(kid, kidRendered)
+	}
+	me.buf.WriteString("</" + qualified(n.prefix, n.local) + ">")
+}
+
+//	Renders the node n (other than a document node), where rendered holds the namespace declarations rendered by its output ancestors.
+func (me *canonicalizer) node(n *node, rendered map[string]string) {
+	switch n.kind {
+	case kindElement:
+		me.element(n, rendered, n.atts)
+	case kindText:
+		me.buf.WriteString(textEscaper.Replace(n.data))
+	case kindComment:
+		if me.comments {
+			me.buf.WriteString("<!--" + n.data + "-->")
+		}
+	case kindProcInst:
+		me.buf.WriteString("<?" + n.local)
+		if len(n.data) > 0 {
+			me.buf.WriteString(" " + n.data)
+		}
+		me.buf.WriteString("?>")
+	}
+}
+
+//	Returns the attributes of the element apex, the root of the canonicalized subtree, including those xml:* attributes of its ancestors that inclusive
+//	canonicalization has it inherit: all of them for Canonical XML 1.0, and xml:lang and xml:space for Canonical XML 1.1, which also joins the xml:base
+//	values of its ancestors (and its own) into its xml:base value.
+func (me *canonicalizer) apexAtts(apex *node) (atts []attr) {
+	var bases []string
+	atts = append(atts, apex.atts...)
+	if me.exclusive {
+		return
+	}
+	for n := apex.parent; (n != nil) && (n.kind == kindElement); n = n.parent {
+		for _, a := range n.atts {
+			if a.space != xmlNamespace {
+				continue
+			} else if me.v11 && (a.local == "base") {
+				bases = append(bases, a.value)
+			} else if (!me.v11) || (a.local == "lang") || (a.local == "space") {
+				if findAttr(atts, xmlNamespace, a.local) == nil {
+					atts = append(atts, a)
+				}
+			}
+		}
+	}
+	if len(bases) > 0 {
+		base := bases[len(bases)-1]
+		for i := len(bases) - 2; i >= 0; i-- {
+			base = joinBase(base, bases[i])
+		}
+		if own := findAttr(atts, xmlNamespace, "base"); own != nil {
+			own.value = joinBase(base, own.value)
+		} else {
+			atts = append(atts, attr{prefix: "xml", local: "base", space: xmlNamespace, value: base})
+		}
+	}
+	return
+}
+
+//	Returns the URI reference ref resolved against the URI reference base, or ref if either is invalid.
+func joinBase(base, ref string) string {
+	b, err := url.Parse(base)
+	if err == nil {
+		var r *url.URL
+		if r, err = url.Parse(ref); err == nil {
+			return b.ResolveReference(r).String()
+		}
+	}
+	return ref
+}
+
+type attrsByName []attr
+
+func (me attrsByName) Len() int      { return len(me) }
+func (me attrsByName) Swap(i, j int) { me[i], me[j] = me[j], me[i] }
+func (me attrsByName) Less(i, j int) bool {
+	return (me[i].space < me[j].space) || ((me[i].space == me[j].space) && (me[i].local < me[j].local))
+}
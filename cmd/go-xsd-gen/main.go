@@ -0,0 +1,441 @@
+//	A command-line tool generating Go wrapper packages from XML Schema Definition files.
+//	Usage:
+//		go-xsd-gen [flags] schema-uri...
+//	For each schema, a Go package is generated (by default next to the local copy of the XSD file), as well as for every schema it xs:imports.
+//	For a WSDL 1.1 document (a schema-uri ending in ".wsdl" or "?wsdl"), this is done for every schema embedded in its wsdl:types
+//	(see xsd.LoadWSDL), and the -pkg flag only applies if it embeds a single schema. Likewise for a local directory, whose .xsd files are
+//	loaded as a schema set (see xsd.LoadSchemaDir). A schema-uri of - reads the schema from standard input (see the -stdinuri flag).
+//	As the -out flag names the one directory that a package is written to, it is rejected for several schema-uris, or a schema-uri
+//	yielding several schemas, unless -module (or any other flag not generating packages, such as -lint) is set.
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"os/exec"
+	"strings"
+
+	"github.com/metaleap/go-util-misc"
+	"github.com/metaleap/go-util-slice"
+	"github.com/metaleap/go-util-str"
+
+	xsd "github.com/metaleap/go-xsd"
+)
+
+//	Collects the -import flags, each of the form namespace=importpath.
+type importPaths map[string]string
+
+func (me importPaths) Set(s string) error {
+	pos := strings.LastIndex(s, "=")
+	if (pos <= 0) || (pos == len(s)-1) {
+		return fmt.Errorf("expected namespace=importpath, got %q", s)
+	}
+	me[s[:pos]] = s[pos+1:]
+	return nil
+}
+
+func (me importPaths) String() string {
+	var pairs []string
+	for ns, imp := range me {
+		pairs = append(pairs, ns+"="+imp)
+	}
+	return strings.Join(pairs, " ")
+}
+
+type groupModes map[string]string
+
+func (me groupModes) Set(s string) (err error) {
+	pos := strings.LastIndex(s, "=")
+	if pos <= 0 {
+		return fmt.Errorf("expected name=embed or name=flatten, got %q", s)
+	}
+	me[s[:pos]], err = groupMode(s[pos+1:])
+	return
+}
+
+func (me groupModes) String() string {
+	var pairs []string
+	for name, mode := range me {
+		pairs = append(pairs, name+"="+ustr.Ifs(mode == xsd.GroupsFlatten, "flatten", "embed"))
+	}
+	return strings.Join(pairs, " ")
+}
+
+//	Collects the -entity flags, each of the form name=text.
+type entities map[string]string
+
+func (me entities) Set(s string) error {
+	pos := strings.Index(s, "=")
+	if pos <= 0 {
+		return fmt.Errorf("expected name=text, got %q", s)
+	}
+	me[s[:pos]] = s[pos+1:]
+	return nil
+}
+
+func (me entities) String() string {
+	var pairs []string
+	for name, text := range me {
+		pairs = append(pairs, name+"="+text)
+	}
+	return strings.Join(pairs, " ")
+}
+
+//	Collects the -type flags, each of the form {namespace}local=importpath.GoType (see xsd.ParseTypeOverride).
+type typeOverrides map[string]*xsd.TypeOverride
+
+func (me typeOverrides) Set(s string) (err error) {
+	var qname string
+	var override *xsd.TypeOverride
+	if qname, override, err = xsd.ParseTypeOverride(s); err == nil {
+		me[qname] = override
+	}
+	return
+}
+
+func (me typeOverrides) String() string {
+	var pairs []string
+	for qname, override := range me {
+		pairs = append(pairs, qname+"="+override.ImportPath+"."+override.GoType)
+	}
+	return strings.Join(pairs, " ")
+}
+
+//	Returns the xsd.DocTypes* constant for the -doctypes flag value s.
+func docTypes(s string) (mode string, err error) {
+	switch s {
+	case "", "keep":
+		mode = xsd.DocTypesKeep
+	case "resolve":
+		mode = xsd.DocTypesResolve
+	case "strip":
+		mode = xsd.DocTypesStrip
+	default:
+		err = fmt.Errorf("expected keep, resolve or strip, got %q", s)
+	}
+	return
+}
+
+//	Returns the xsd.Groups* constant for the -groups or -group flag value s.
+func groupMode(s string) (mode string, err error) {
+	switch s {
+	case "", "embed":
+		mode = xsd.GroupsEmbed
+	case "flatten":
+		mode = xsd.GroupsFlatten
+	default:
+		err = fmt.Errorf("expected embed or flatten, got %q", s)
+	}
+	return
+}
+
+var (
+	flagOutDir     = flag.String("out", "", "The directory to write the Go package generated for the specified schema to. Defaults to a directory next to the local copy of its XSD file. (Packages for imported schemas are always written next to their XSD files.) Only allowed for a single schema, unless -module is set.")
+	flagPkgName    = flag.String("pkg", "", "The package name of the Go packages generated for the specified schemas. Defaults to a name derived from each XSD file name.")
+	flagLocalCopy  = flag.Bool("local", true, "Local copy: only downloads if file does not exist locally")
+	flagImports    = flag.Bool("imports", true, "Also generate Go packages for all (not remapped) schemas imported by the specified schemas?")
+	flagGoFmt      = flag.Bool("gofmt", true, "Run 'gofmt' against the generated Go source files?")
+	flagSplit      = flag.Bool("split", false, "Split each generated Go package into one source file per top-level XSD component (see xsd.PkgGen.SplitFiles)?")
+	flagCatalog    = flag.String("catalog", "", "OASIS XML Catalog file paths, whitespace-separated, to remap schema URIs to local files (or other URIs) before downloading.")
+	flagBasePath   = flag.String("basepath", "", "Defaults to "+xsd.PkgGen.BasePath+". A $GOPATH/src/-relative path (always a slash-style path, even on Windows) where XSD files are downloaded to / loaded from and generated Go wrapper packages are created.")
+	flagJsonTags   = flag.String("json", xsd.JsonTagsNone, "If not empty, generated struct fields get json tags alongside their xml tags, with names cased as either camelCase, snake_case or as-is.")
+	flagJsonSchema = flag.Bool("jsonschema", false, "Also write a JSON Schema (draft 2020-12) document derived from each specified schema (and the schemas it includes and imports) into the -out directory, or else next to the local copy of its XSD file?")
+	flagOpenAPI    = flag.Bool("openapi", false, "Also write an OpenAPI 3.1 document holding the JSON Schema definitions derived from each specified schema (and the schemas it includes and imports) as components/schemas into the -out directory, or else next to the local copy of its XSD file?")
+	flagFlatten    = flag.Bool("flatten", false, "Also write a single, self-contained XSD file merging each specified schema with all the schema documents it includes, redefines and overrides into the -out directory, or else next to the local copy of its XSD file (see xsd.Schema.Flatten)?")
+	flagProto      = flag.Bool("proto", false, "Also write a protobuf (proto3) definition file derived from each specified schema (and the schemas it includes and imports) into the -out directory, or else next to the local copy of its XSD file?")
+	flagChecks     = flag.Bool("checks", false, "Generate a CheckBeforeMarshal() method for every struct type of a complex type, verifying required attributes and elements, occurrence bounds and xs:choice exclusivity (see xsd.PkgGen.AddMarshalChecks)?")
+	flagUnions     = flag.Bool("unions", false, "Model every xs:choice of single elements as a struct type holding exactly one alternative, named by its Which field (see xsd.PkgGen.ChoiceUnions)?")
+	flagTyped      = flag.Bool("typed", false, "Generate xs:date, xs:dateTime, xs:time, xs:gYear, xs:gYearMonth, xs:gMonth, xs:gMonthDay, xs:gDay, xs:duration, xs:decimal, xs:base64Binary, xs:hexBinary, xs:anyURI and xs:QName as typed values (wrapping time.Time, *big.Rat, []byte, url.URL or a qualified name) rather than strings (see xsd.PkgGen.TypedBuiltins)?")
+	flagLexical    = flag.Bool("lexical", false, "Keep the lexical forms of numbers and bools verbatim, embed attributes and elements in schema order and apply no defaults, so that decoding and re-encoding a document reproduces it as faithfully as possible (see xsd.PkgGen.LexicalFidelity)?")
+	flagLists      = flag.Bool("listsunions", false, "Generate xs:list simple types as slices of their item type and xs:union simple types as structs holding a field per member type, rather than strings (see xsd.PkgGen.TypedListsAndUnions)?")
+	flagPresence   = flag.Bool("presence", false, "Generate the fields of optional attributes and single optional elements of simple types as pointers that are nil if absent, with HasXyz(), GetXyz(), SetXyz() and ClearXyz() accessors (see xsd.PkgGen.PresenceAccessors)?")
+	flagDTOs       = flag.Bool("dto", false, "Generate a flat XyzDTO struct type of plain Go types with ToDTO() and FromDTO() converter methods for every struct type of a complex type (see xsd.PkgGen.AddDTOs)?")
+	flagCSV        = flag.Bool("csv", false, "Generate MarshalCSV() and UnmarshalCSV() methods mapping the records of every global element repeating a flat record type to CSV columns, checking their values against the facets of their types (see xsd.PkgGen.AddCSV)?")
+	flagSrcComment = flag.Bool("sourcecomments", false, "Note the schema document, line and column of the schema construct that every generated type, struct field and embedded type was generated from in its doc comment (see xsd.PkgGen.AddSourceComments)?")
+	flagSourceMap  = flag.Bool("sourcemap", false, "Write a JSON sourcemap linking the generated types, struct fields and embedded types to the locations of their schema constructs next to every generated Go source file (see xsd.PkgGen.AddSourceMap)?")
+	flagAppInfo    = flag.Bool("appinfo", false, "Record the contents of the xs:appinfo annotations of the schema constructs that the types, struct fields and embedded types are generated from in a generated XsdGoPkgAppInfo variable (see xsd.PkgGen.AddAppInfo)?")
+	flagNamespaces = flag.Bool("namespaces", false, "Generate constants for the namespaces declared in the schema, an XsdGoPkgNamespaces variable of its namespace declarations and XsdGoPkgName() / XsdGoPkgQName() functions (see xsd.PkgGen.AddNamespaces)?")
+	flagFuzz       = flag.Bool("fuzz", false, "Generate a Go fuzz test file per package checking that decoding and re-encoding instances of every global element is stable, seeded with sample instances (see xsd.PkgGen.AddFuzzTests)?")
+	flagClone      = flag.Bool("clone", false, "Generate a Clone() method returning a deep copy and an Equal() method comparing two instances for every struct type, covering slices, pointers and wildcard-captured content (see xsd.PkgGen.AddCloneAndEqual)?")
+	flagIdRules    = flag.Bool("idrules", false, "Derive Go identifiers by xsd.DefaultIdentifierRules: camel case with common initialisms in all caps and numbering for names that would otherwise collide, each reported as a diagnostic (see xsd.PkgGen.Identifiers)?")
+	flagModule     = flag.String("module", "", "If not empty, the module path of a Go module to generate into the -out directory (which is then required) for all specified schemas together: a go.mod file, a doc.go file and one subpackage per target namespace (see xsd.SchemaSet.MakeGoModule).")
+	flagPkgMap     = flag.String("pkgmap", "", "If not empty, the path of a JSON or YAML file mapping XML namespaces to the import paths, names and directories of the Go packages generated for them, such as within the layout of one's own Go module (see xsd.LoadPackageMap).")
+	flagCache      = flag.String("cache", "", "If not empty, the path of a JSON file recording hashes of the schemas (and options) that each Go source file was generated from, so that up-to-date Go source files are not generated again (see xsd.PkgGen.Cache).")
+	flagGroups     = flag.String("groups", "embed", "Either embed, to have the struct types referring to an xs:group or xs:attributeGroup embed a shared struct type generated for it, or flatten, to have them embed its members directly (see xsd.PkgGen.Groups).")
+	flagRoots      = flag.String("roots", "", "If not empty, the global elements and types (whitespace-separated, each as local name, prefix:local or {namespace}local) to generate Go code for, along with all they depend on, rather than for every global component of the specified schemas (see xsd.PkgGen.Roots).")
+	flagAnonNames  = flag.String("anonnames", xsd.AnonTypeNamesConstructPath, "If not empty, how the Go types of anonymous complex and simple types are named: path after the enclosing elements and attributes only, hash additionally suffixed with a hash of their location for names that stay stable across regenerations, or sequential (see xsd.PkgGen.AnonTypeNames).")
+	flagAnyTypes   = flag.String("anytypes", xsd.AnyTypesString, "If not empty, the Go type of the fields for elements of xs:anyType (and of elements declaring no type): rawxml for xsdt.AnyElement capturing their raw XML, interface for interface{}, or node for the generic tree xsdt.Node (see xsd.PkgGen.AnyTypes).")
+	flagAnySimple  = flag.String("anysimpletypes", xsd.AnySimpleTypesString, "If not empty, the Go type of the fields for attributes and elements of xs:anySimpleType (and of attributes declaring no type): variant for xsdt.AnySimpleValue, accessible as any built-in type its lexical form is valid for (see xsd.PkgGen.AnySimpleTypes).")
+	flagDocLang    = flag.String("doclang", "", "If not empty, the language (such as en) of the xs:documentation elements (by their xml:lang) to use for doc comments and descriptions where annotations document in several languages (see xsd.PkgGen.DocLanguage).")
+	flagStdinUri   = flag.String("stdinuri", "stdin.xsd", "The URI that the schema read from standard input (specified as the schema-uri -) is loaded as: its Go package is named after it, and the schemaLocations of its includes and imports are resolved relative to it (see xsd.LoadSchemaReader).")
+	flagLint       = flag.Bool("lint", false, "Rather than generating Go packages, check the specified schemas for problematic patterns (such as anonymous type overuse, unbounded wildcards, deprecated constructs, missing documentation, names that are not camel case and deeply nested declarations) by xsd.DefaultLintRules, reporting each and failing if any is found (see xsd.Lint)?")
+	flagUnsupp     = flag.Bool("unsupported", false, "Rather than generating Go packages, list every use of a construct in the specified schemas that the generated Go code (with the other flags given) or the validator do not fully support, with its position (see xsd.PkgGen.UnsupportedFeatures)?")
+	flagVendor     = flag.String("vendor", "", "If not empty, rather than generating Go packages, write all schema documents that the specified schemas include, import, redefine or override (transitively) into this directory, laid out by their URIs, along with an OASIS XML Catalog for later loads to use via -catalog without network access (see xsd.VendorSchema).")
+	flagVendorRel  = flag.Bool("vendorrewrite", false, "With -vendor, rewrite the absolute schemaLocations of the vendored schema documents to the relative paths of the vendored documents they refer to (see xsd.VendorSchema)?")
+	flagStrict     = flag.Bool("strict", false, "Fail on unknown elements and attributes, unresolvable QName references and unloadable includes or imports in the schema documents, rather than ignoring them (see xsd.LoadOptions)?")
+	flagTTL        = flag.Duration("ttl", 0, "If greater than zero, local copies of downloaded schemas last downloaded or revalidated longer ago than this (such as 24h) are revalidated with a conditional request, rather than used as-is (see xsd.PkgGen.DownloadTTL).")
+	flagOffline    = flag.Bool("offline", false, "Never access the network: fail if a schema would have to be downloaded, or the local copy of one revalidated (see xsd.PkgGen.Offline)?")
+	flagChecksums  = flag.String("checksums", "", "If not empty, the path of a JSON lock file of the SHA-256 digests expected for downloaded schemas by their URIs, which they (and their local copies) are verified against (see xsd.PkgGen.Checksums).")
+	flagPin        = flag.Bool("pin", false, "Record the digests of downloaded schemas not pinned yet in the -checksums lock file, rather than leaving them unverified?")
+	flagHtmlEnts   = flag.Bool("htmlentities", false, "Allow schema documents to refer to the entities of HTML (such as &nbsp; or &eacute;) besides those predefined by XML (see xsd.PkgGen.HTMLEntities)?")
+	flagDocTypes   = flag.String("doctypes", "keep", "Either keep, to ignore DOCTYPE declarations and fail on references to unknown entities, resolve, to also resolve the general entities declared in the internal subset of a DOCTYPE declaration, or strip, to ignore DOCTYPE declarations and keep references to unknown entities verbatim (see xsd.PkgGen.DocTypes).")
+	flagVerbose    = flag.Int("v", 1, "Verbosity: 0 only reports errors, 1 also reports warnings and generated files, 2 also reports loaded schemas, 3 also traces every schema document fetched (and how long that took), every reference resolved and every Go type generated (see xsd.PkgGen.Hooks).")
+	flagImportMap  = importPaths{}
+	flagGroupModes = groupModes{}
+	flagEntities   = entities{}
+	flagTypes      = typeOverrides{}
+)
+
+func main() {
+	var (
+		sd           *xsd.Schema
+		err          error
+		outFilePaths []string
+		diags        xsd.Diagnostics
+		failed       bool
+		module       = &xsd.SchemaSet{Packages: map[string]*xsd.GoPkgOptions{}}
+	)
+	flag.Var(flagImportMap, "import", "Maps the XML namespace of xs:imported schemas to the Go import path of an existing package, as namespace=importpath. Can be repeated.")
+	flag.Var(flagGroupModes, "group", "Overrides -groups for the xs:group or xs:attributeGroup of the specified name, as name=embed or name=flatten. Can be repeated.")
+	flag.Var(flagEntities, "entity", "Declares the replacement text of an entity that schema documents may refer to, as name=text (such as org=ACME for &org;). Can be repeated.")
+	flag.Var(flagTypes, "type", "Generates the XSD built-in type or named schema type of the specified namespace-qualified name as an existing Go type implementing encoding.TextMarshaler and encoding.TextUnmarshaler (or xml.Marshaler and xml.Unmarshaler for complex types), as {namespace}local=importpath.GoType (see xsd.PkgGen.TypeOverrides). Can be repeated.")
+	flag.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: %s [flags] schema-uri...\n(For each schema URI, the protocol prefix can be omitted, it then defaults to http://. A schema URI of - reads the schema from standard input.)\n\n", os.Args[0])
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+	if flag.NArg() == 0 {
+		flag.Usage()
+		os.Exit(2)
+	}
+	if (len(*flagModule) > 0) && (len(*flagOutDir) == 0) {
+		log.Fatalf("MODULE:\t%v\n", "the -module flag requires the -out flag")
+	}
+	if (flag.NArg() > 1) && (len(*flagOutDir) > 0) && (len(*flagModule) == 0) && !(*flagLint || *flagUnsupp || (len(*flagVendor) > 0)) {
+		log.Fatalf("OUT:\t%v\n", "the -out flag requires a single schema-uri (unless -module is set), as all packages would be written into the same directory")
+	}
+	if len(*flagBasePath) > 0 {
+		xsd.PkgGen.BasePath, xsd.PkgGen.BaseCodePath = *flagBasePath, ugo.GopathSrc(strings.Split(*flagBasePath, "/")...)
+	}
+	if len(*flagCatalog) > 0 {
+		if xsd.PkgGen.Catalog, err = xsd.LoadCatalog(strings.Fields(*flagCatalog)...); err != nil {
+			log.Fatalf("CATALOG:\t%v\n", err)
+		}
+	}
+	if xsd.PkgGen.Groups, err = groupMode(*flagGroups); err != nil {
+		log.Fatalf("GROUPS:\t%v\n", err)
+	}
+	if xsd.PkgGen.DocTypes, err = docTypes(*flagDocTypes); err != nil {
+		log.Fatalf("DOCTYPES:\t%v\n", err)
+	}
+	if len(flagEntities) > 0 {
+		xsd.PkgGen.Entities = flagEntities
+	}
+	if len(flagTypes) > 0 {
+		xsd.PkgGen.TypeOverrides = flagTypes
+	}
+	if len(flagGroupModes) > 0 {
+		xsd.PkgGen.GroupModes = flagGroupModes
+	}
+	if *flagIdRules {
+		xsd.PkgGen.Identifiers = xsd.DefaultIdentifierRules()
+	}
+	if len(*flagRoots) > 0 {
+		xsd.PkgGen.Roots = strings.Fields(*flagRoots)
+	}
+	if len(*flagChecksums) > 0 {
+		if xsd.PkgGen.Checksums, err = xsd.LoadChecksums(*flagChecksums); err != nil {
+			log.Fatalf("CHECKSUMS:\t%v\n", err)
+		}
+		xsd.PkgGen.Checksums.Record = *flagPin
+	} else if *flagPin {
+		log.Fatalf("CHECKSUMS:\t%v\n", "the -pin flag requires the -checksums flag")
+	}
+	if len(*flagPkgMap) > 0 {
+		if xsd.PkgGen.Packages, err = xsd.LoadPackageMap(*flagPkgMap); err != nil {
+			log.Fatalf("PKGMAP:\t%v\n", err)
+		}
+	}
+	if len(*flagCache) > 0 {
+		if xsd.PkgGen.Cache, err = xsd.LoadGenCache(*flagCache); err != nil {
+			log.Fatalf("CACHE:\t%v\n", err)
+		}
+	}
+	xsd.PkgGen.ImportPaths, xsd.PkgGen.JsonTags, xsd.PkgGen.SplitFiles, xsd.PkgGen.AddMarshalChecks, xsd.PkgGen.ChoiceUnions, xsd.PkgGen.TypedBuiltins, xsd.PkgGen.LexicalFidelity, xsd.PkgGen.TypedListsAndUnions = flagImportMap, *flagJsonTags, *flagSplit, *flagChecks, *flagUnions, *flagTyped, *flagLexical, *flagLists
+	xsd.PkgGen.AnonTypeNames, xsd.PkgGen.DocLanguage, xsd.PkgGen.DownloadTTL, xsd.PkgGen.Offline = *flagAnonNames, *flagDocLang, *flagTTL, *flagOffline
+	xsd.PkgGen.HTMLEntities, xsd.PkgGen.PresenceAccessors, xsd.PkgGen.AddCloneAndEqual, xsd.PkgGen.AddDTOs, xsd.PkgGen.AddCSV = *flagHtmlEnts, *flagPresence, *flagClone, *flagDTOs, *flagCSV
+	xsd.PkgGen.AnyTypes, xsd.PkgGen.AnySimpleTypes, xsd.PkgGen.AddSourceComments, xsd.PkgGen.AddSourceMap, xsd.PkgGen.AddAppInfo, xsd.PkgGen.AddNamespaces, xsd.PkgGen.AddFuzzTests = *flagAnyTypes, *flagAnySimple, *flagSrcComment, *flagSourceMap, *flagAppInfo, *flagNamespaces, *flagFuzz
+	if *flagVerbose >= 3 {
+		xsd.PkgGen.Hooks = xsd.LogHooks(log.Printf)
+	}
+	for _, uri := range flag.Args() {
+		if *flagVerbose >= 2 {
+			log.Printf("LOAD:\t%v\n", uri)
+		}
+		var sds []*xsd.Schema
+		var opts = xsd.LoadOptions{Strict: *flagStrict}
+		if len(*flagVendor) > 0 {
+			var vendored []string
+			if vendored, err = xsd.VendorSchema(context.Background(), uri, *flagVendor, *flagVendorRel); err != nil {
+				failed = true
+				log.Printf("ERROR:\t%v: %v\n", uri, err)
+			} else if *flagVerbose >= 1 {
+				for _, filePath := range vendored {
+					log.Printf("VENDOR:\t%v\n", filePath)
+				}
+			}
+			continue
+		}
+		if info, statErr := os.Stat(uri); (statErr == nil) && info.IsDir() {
+			var set *xsd.SchemaSet
+			if set, err = xsd.DefaultSchemaCache.LoadSchemaDir(context.Background(), uri, opts); err == nil {
+				sds = set.Schemas
+			}
+		} else if uri == "-" {
+			if sd, err = xsd.DefaultSchemaCache.LoadSchemaReader(context.Background(), os.Stdin, *flagStdinUri, opts); err == nil {
+				sds = []*xsd.Schema{sd}
+			}
+		} else if isWsdl(uri) {
+			sds, err = xsd.LoadWSDLWithOptions(context.Background(), uri, *flagLocalCopy, opts)
+		} else if sd, err = xsd.LoadSchemaWithOptions(context.Background(), uri, *flagLocalCopy, opts); err == nil {
+			sds = []*xsd.Schema{sd}
+		}
+		if (err == nil) && (len(sds) > 1) && (len(*flagOutDir) > 0) && (len(*flagModule) == 0) && !(*flagLint || *flagUnsupp) {
+			err = fmt.Errorf("the -out flag requires a single schema (unless -module is set), as the packages of all %d schemas would be written into the same directory", len(sds))
+		}
+		for i := 0; (err == nil) && (i < len(sds)); i++ {
+			if sd = sds[i]; *flagLint {
+				failed = reportLint(xsd.Lint(sd, nil)) || failed
+				continue
+			} else if *flagUnsupp {
+				for _, f := range xsd.PkgGen.UnsupportedFeatures(sd) {
+					log.Printf("UNSUPPORTED:\t%v\n", f)
+				}
+				continue
+			} else if len(*flagModule) > 0 {
+				module.Add(sd)
+			} else {
+				outFilePaths, diags, err = makePkgs(sd, *flagOutDir, ustr.Ifs(len(sds) == 1, *flagPkgName, ""))
+				failed = reportDiags(diags) || failed
+			}
+			if err == nil {
+				if *flagJsonSchema {
+					var jsonFilePath string
+					if jsonFilePath, err = sd.MakeJSONSchemaFileAt(*flagOutDir); (err == nil) && (*flagVerbose >= 1) {
+						log.Printf("MKJSON:\t%v\n", jsonFilePath)
+					}
+				}
+				if (err == nil) && *flagOpenAPI {
+					var openApiFilePath string
+					if openApiFilePath, err = sd.MakeOpenAPIFileAt(*flagOutDir); (err == nil) && (*flagVerbose >= 1) {
+						log.Printf("MKOPENAPI:\t%v\n", openApiFilePath)
+					}
+				}
+				if (err == nil) && *flagProto {
+					var protoFilePath string
+					if protoFilePath, err = sd.MakeProtoFileAt(*flagOutDir, ""); (err == nil) && (*flagVerbose >= 1) {
+						log.Printf("MKPROTO:\t%v\n", protoFilePath)
+					}
+				}
+				if (err == nil) && *flagFlatten {
+					var flatFilePath string
+					if flatFilePath, err = sd.MakeFlatXSDFileAt(*flagOutDir); (err == nil) && (*flagVerbose >= 1) {
+						log.Printf("MKFLAT:\t%v\n", flatFilePath)
+					}
+				}
+				if len(*flagModule) == 0 {
+					failed = reportPkgs(outFilePaths) || failed
+				}
+			}
+		}
+		if err != nil {
+			failed = true
+			log.Printf("ERROR:\t%v: %v\n", uri, err)
+		}
+	}
+	if (len(*flagModule) > 0) && (len(module.Schemas) > 0) {
+		outFilePaths, diags, err = module.MakeGoModule(*flagOutDir, xsd.GoModuleOptions{Path: *flagModule})
+		if failed = reportDiags(diags) || failed; err == nil {
+			failed = reportPkgs(outFilePaths) || failed
+		} else {
+			failed = true
+			log.Printf("ERROR:\t%v: %v\n", *flagModule, err)
+		}
+	}
+	if xsd.PkgGen.Cache != nil {
+		if err = xsd.PkgGen.Cache.Save(); err != nil {
+			failed = true
+			log.Printf("CACHE:\t%v\n", err)
+		} else if *flagVerbose >= 1 {
+			log.Printf("CACHE:\t%d Go source file(s) regenerated\n", len(xsd.PkgGen.Cache.Regenerated()))
+		}
+	}
+	if xsd.PkgGen.Checksums != nil {
+		if err = xsd.PkgGen.Checksums.Save(); err != nil {
+			failed = true
+			log.Printf("CHECKSUMS:\t%v\n", err)
+		}
+	}
+	if failed {
+		os.Exit(1)
+	}
+}
+
+//	Logs diags (those of severity warning or info only if -v is at least 1), returning whether any is of severity error.
+func reportDiags(diags xsd.Diagnostics) (failed bool) {
+	for _, d := range diags {
+		if d.Severity == xsd.SeverityError {
+			failed = true
+		}
+		if (d.Severity == xsd.SeverityError) || (*flagVerbose >= 1) {
+			log.Printf("DIAG:\t%v\n", d)
+		}
+	}
+	return
+}
+
+//	Logs the lint findings diags, returning whether there are any.
+func reportLint(diags xsd.Diagnostics) (failed bool) {
+	for _, d := range diags {
+		log.Printf("LINT:\t%v [%s]\n", d, d.Rule)
+	}
+	return len(diags) > 0
+}
+
+//	Logs the generated outFilePaths (if -v is at least 1) and runs gofmt on the Go source files among them that were written (if -gofmt is set), returning whether it failed.
+func reportPkgs(outFilePaths []string) (failed bool) {
+	for _, outFilePath := range outFilePaths {
+		unchanged := (xsd.PkgGen.Cache != nil) && !uslice.StrHas(xsd.PkgGen.Cache.Regenerated(), outFilePath)
+		if *flagVerbose >= 1 {
+			log.Printf("%s:\t%v\n", ustr.Ifs(unchanged, "UNCHANGED", "MKPKG"), outFilePath)
+		}
+		if *flagGoFmt && (!unchanged) && strings.HasSuffix(outFilePath, ".go") {
+			if raw, fmtErr := exec.Command("gofmt", "-w=true", "-s=true", "-e=true", outFilePath).CombinedOutput(); fmtErr != nil {
+				failed = true
+				log.Printf("GOFMT:\t%v\n%s", fmtErr, raw)
+			}
+		}
+	}
+	return
+}
+
+//	Generates the Go package for sd into outDir, named pkgName (see the -out and -pkg flags) and, if -imports is set, those for all schemas it (or any of its includes) imports.
+func makePkgs(sd *xsd.Schema, outDir, pkgName string) (outFilePaths []string, diags xsd.Diagnostics, err error) {
+	var outFilePath string
+	if *flagImports {
+		return sd.MakeGoPkgSrcFilesAt(outDir, pkgName)
+	}
+	if outFilePath, diags, err = sd.MakeGoPkgSrcFileAt(outDir, pkgName); err == nil {
+		outFilePaths = append(outFilePaths, outFilePath)
+	}
+	return
+}
+
+//	Returns whether uri denotes a WSDL document (by its ".wsdl" extension or "?wsdl" query) rather than a schema document.
+func isWsdl(uri string) bool {
+	uri = strings.ToLower(uri)
+	return strings.HasSuffix(uri, ".wsdl") || strings.HasSuffix(uri, "?wsdl")
+}
@@ -0,0 +1,85 @@
+package main
+
+import (
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestMain(m *testing.M) {
+	if os.Getenv("GO_XSD_GEN_TEST_MAIN") == "1" {
+		main()
+		os.Exit(0)
+	}
+	os.Exit(m.Run())
+}
+
+//	Runs this command (via the test binary, see TestMain) with args, returning its combined output, and whether it exited with a non-zero status.
+func runMain(t *testing.T, args ...string) (out string, failed bool) {
+	return runMainStdin(t, nil, args...)
+}
+
+//	Like runMain, but with stdin (if not nil) as the standard input of the command.
+func runMainStdin(t *testing.T, stdin io.Reader, args ...string) (out string, failed bool) {
+	cmd := exec.Command(os.Args[0], args...)
+	cmd.Env, cmd.Stdin = append(os.Environ(), "GO_XSD_GEN_TEST_MAIN=1"), stdin
+	raw, err := cmd.CombinedOutput()
+	if _, failed = err.(*exec.ExitError); (err != nil) && !failed {
+		t.Fatal(err)
+	}
+	return string(raw), failed
+}
+
+func TestOutRejectsSeveralSchemas(t *testing.T) {
+	outDir := t.TempDir()
+	dir := filepath.Join("..", "..", "testdata", "imported")
+	for _, args := range [][]string{
+		{"-out", outDir, filepath.Join(dir, "lib.xsd"), filepath.Join(dir, "main.xsd")},
+		{"-out", outDir, dir},
+	} {
+		if out, failed := runMain(t, args...); !(failed && strings.Contains(out, "the -out flag requires a single schema")) {
+			t.Errorf("%v: expected the -out flag to be rejected, got:\n%s", args, out)
+		}
+	}
+	if entries, _ := os.ReadDir(outDir); len(entries) > 0 {
+		t.Errorf("expected nothing written to %s, got %d entries", outDir, len(entries))
+	}
+	if out, failed := runMain(t, "-out", outDir, filepath.Join("..", "..", "testdata", "sequence")); failed {
+		t.Errorf("expected the package of a single schema to be generated, got:\n%s", out)
+	} else if _, err := os.Stat(filepath.Join(outDir, "seq.xsd.go")); err != nil {
+		t.Error(err)
+	}
+}
+
+//	Tests that the schema-uri - generates the package of the schema read from standard input, named after the -stdinuri flag.
+func TestStdinSchema(t *testing.T) {
+	outDir := t.TempDir()
+	stdin, err := os.Open(filepath.Join("..", "..", "testdata", "reader", "common", "line.xsd"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer stdin.Close()
+	if out, failed := runMainStdin(t, stdin, "-out", outDir, "-stdinuri", "reader.example.com/lines.xsd", "-"); failed {
+		t.Fatalf("expected the package of the schema read from standard input to be generated, got:\n%s", out)
+	}
+	if src, err := os.ReadFile(filepath.Join(outDir, "lines.xsd.go")); err != nil {
+		t.Error(err)
+	} else if !strings.Contains(string(src), "type TLine struct {") {
+		t.Errorf("expected TLine in\n%s", src)
+	}
+}
+
+//	Tests that -unsupported lists the unsupported constructs of the schemas in a directory rather than generating their packages.
+func TestUnsupportedFlag(t *testing.T) {
+	outDir := t.TempDir()
+	out, failed := runMain(t, "-unsupported", "-out", outDir, filepath.Join("..", "..", "testdata", "unsupported"))
+	if failed || !strings.Contains(out, "UNSUPPORTED:\t") || !strings.Contains(out, `doc.xsd:12:35: xs:assert (generator, validator): the assertion "@min le @max"`) {
+		t.Errorf("expected the unsupported constructs to be listed, got:\n%s", out)
+	}
+	if entries, _ := os.ReadDir(outDir); len(entries) > 0 {
+		t.Errorf("expected nothing written to %s, got %d entries", outDir, len(entries))
+	}
+}
@@ -1,6 +1,10 @@
 package xsd
 
 import (
+	"encoding/xml"
+	"io"
+	"strings"
+
 	xsdt "github.com/metaleap/go-xsd/types"
 )
 
@@ -15,9 +19,13 @@ type elemBase struct {
 	parent, self element // self is the struct that embeds elemBase, rather than the elemBase pseudo-field
 	xsdName      xsdt.NCName
 	hasNameAttr  bool
+	path         string         // such as "/complextype[2]/sequence[0]", the key into Schema.elemPositions
+	kidCounts    map[string]int // per lower-cased xsdName, the number of child elements initialized so far
+	kids         []element      // the child elements initialized so far, as walked by Schema.Walk
 }
 
 func (me *elemBase) afterMakePkg(bag *PkgBag) {
+	bag.elemsMaking = bag.elemsMaking[:len(bag.elemsMaking)-1]
 	if !me.hasNameAttr {
 		bag.Stacks.Name.Pop()
 	}
@@ -27,18 +35,30 @@ func (me *elemBase) afterMakePkg(bag *PkgBag) {
 }
 
 func (me *elemBase) beforeMakePkg(bag *PkgBag) {
+	bag.elemsMaking = append(bag.elemsMaking, me.self)
 	if !me.hasNameAttr {
 		bag.Stacks.Name.Push(me.xsdName)
 	}
 	for _, a := range me.atts {
-		a.beforeMakePkg(bag)
+		if an, isName := a.(*hasAttrName); isName && (len(an.Name) == 0) {
+			//	an anonymous type goes by the name that an earlier makePkg in this bag gave it, if any
+			bag.Stacks.Name.Push(bag.anonNames[me.self])
+		} else {
+			a.beforeMakePkg(bag)
+		}
 	}
 }
 
 func (me *elemBase) base() *elemBase { return me }
 
 func (me *elemBase) init(parent, self element, xsdName xsdt.NCName, atts ...beforeAfterMake) {
-	me.parent, me.self, me.xsdName, me.atts = parent, self, xsdName, atts
+	me.parent, me.self, me.xsdName, me.atts, me.path, me.kidCounts, me.kids = parent, self, xsdName, atts, "", map[string]int{}, nil
+	if parent != nil {
+		pb, name := parent.base(), strings.ToLower(xsdName.String())
+		me.path = sfmt("%s/%s[%d]", pb.path, name, pb.kidCounts[name])
+		pb.kidCounts[name]++
+		pb.kids = append(pb.kids, self)
+	}
 	for _, a := range atts {
 		if _, me.hasNameAttr = a.(*hasAttrName); me.hasNameAttr {
 			break
@@ -52,7 +72,7 @@ func (me *elemBase) longSafeName(bag *PkgBag) (ln string) {
 		els = append(els, el)
 	}
 	for i := len(els) - 1; i >= 0; i-- {
-		ln += bag.safeName(els[i].base().selfName().String())
+		ln += bag.safeName(bag.selfName(els[i]).String())
 	}
 	return
 }
@@ -70,6 +90,19 @@ func (me *elemBase) selfName() xsdt.NCName {
 
 func (me *elemBase) Parent() element { return me.parent }
 
+//	Returns the schema document declaring this element, and the position in it right after its start tag (or 0, 0 if unknown).
+func (me *elemBase) position() (sd *Schema, line, col int) {
+	var el element = me.self
+	for (el != nil) && (el.Parent() != nil) {
+		el = el.Parent()
+	}
+	if sd, _ = el.(*Schema); sd != nil {
+		pos := sd.elemPositions[me.path]
+		line, col = pos[0], pos[1]
+	}
+	return
+}
+
 type All struct {
 	elemBase
 	//	XMLName xml.Name `xml:"all"`
@@ -80,6 +113,20 @@ type All struct {
 	hasElemsElement
 }
 
+//	An XSD 1.1 type alternative of an element declaration: if its XPath Test is satisfied by an element instance, that instance is assigned the
+//	alternative's type (from Type, or its inline simple or complex type) instead of the declared type. An alternative without Test applies by default.
+type Alternative struct {
+	elemBase
+	//	XMLName xml.Name `xml:"alternative"`
+	hasAttrId
+	hasAttrTest
+	hasAttrType
+	hasAttrXpathDefaultNamespace
+	hasElemAnnotation
+	hasElemComplexType
+	hasElemsSimpleType
+}
+
 type Annotation struct {
 	elemBase
 	//	XMLName xml.Name `xml:"annotation"`
@@ -112,6 +159,20 @@ type AppInfo struct {
 	//	XMLName xml.Name `xml:"appinfo"`
 	hasAttrSource
 	hasCdata
+
+	//	The elements within the xs:appinfo, such as the code list URIs or UI hints that schema authors annotate schema constructs with
+	//	for applications to consume (see AppInfo.XML and SchemaNode.AppInfos).
+	Nodes []xsdt.Node `xml:",any"`
+}
+
+//	An XSD 1.1 assertion: an XPath 2.0 Test that all instances of the complex type (in xs:assert) or values of the simple type (in xs:assertion) must satisfy.
+type Assert struct {
+	elemBase
+	//	XMLName xml.Name `xml:"assert"`
+	hasAttrId
+	hasAttrTest
+	hasAttrXpathDefaultNamespace
+	hasElemAnnotation
 }
 
 type Attribute struct {
@@ -177,6 +238,7 @@ type ComplexType struct {
 	hasElemAll
 	hasElemAnnotation
 	hasElemsAnyAttribute
+	hasElemsAssert
 	hasElemsAttribute
 	hasElemsAttributeGroup
 	hasElemChoice
@@ -186,6 +248,28 @@ type ComplexType struct {
 	hasElemSimpleContent
 }
 
+//	Returns all xs:assert children of this complex type, including those of its complexContent or simpleContent derivation.
+func (me *ComplexType) AllAsserts() (asserts []*Assert) {
+	asserts = append(asserts, me.Asserts...)
+	if cc := me.ComplexContent; cc != nil {
+		if cc.ExtensionComplexContent != nil {
+			asserts = append(asserts, cc.ExtensionComplexContent.Asserts...)
+		}
+		if cc.RestrictionComplexContent != nil {
+			asserts = append(asserts, cc.RestrictionComplexContent.Asserts...)
+		}
+	}
+	if sc := me.SimpleContent; sc != nil {
+		if sc.ExtensionSimpleContent != nil {
+			asserts = append(asserts, sc.ExtensionSimpleContent.Asserts...)
+		}
+		if sc.RestrictionSimpleContent != nil {
+			asserts = append(asserts, sc.RestrictionSimpleContent.Asserts...)
+		}
+	}
+	return
+}
+
 type Documentation struct {
 	elemBase
 	//	XMLName xml.Name `xml:"documentation"`
@@ -194,6 +278,38 @@ type Documentation struct {
 	hasCdata
 }
 
+//	Decodes the xs:documentation as usual, except that CDATA then holds all of its text content, including that of any child markup
+//	(such as XHTML or the documentation elements of standards bodies), with a line break after each block-level child element.
+func (me *Documentation) UnmarshalXML(d *xml.Decoder, start xml.StartElement) (err error) {
+	type documentation Documentation
+	var toks = []xml.Token{start.Copy()}
+	var tok xml.Token
+	var text []string
+	for depth := 0; depth >= 0; {
+		if tok, err = d.Token(); err != nil {
+			return
+		}
+		switch t := tok.(type) {
+		case xml.CharData:
+			text = append(text, string(t))
+		case xml.StartElement:
+			depth++
+		case xml.EndElement:
+			if depth--; (depth >= 0) && !docInlineMarkup[t.Name.Local] {
+				text = append(text, "\n")
+			}
+		}
+		toks = append(toks, xml.CopyToken(tok))
+	}
+	if err = xml.NewTokenDecoder(&tokenReplay{toks: toks}).Decode((*documentation)(me)); err == nil {
+		me.CDATA = strings.Join(text, "")
+	}
+	return
+}
+
+//	The local names of the (XHTML) elements within xs:documentation whose text continues the current line, rather than ending it.
+var docInlineMarkup = map[string]bool{"a": true, "abbr": true, "b": true, "cite": true, "code": true, "em": true, "i": true, "kbd": true, "q": true, "samp": true, "small": true, "span": true, "strong": true, "sub": true, "sup": true, "tt": true, "u": true, "var": true}
+
 type Element struct {
 	elemBase
 	//	XMLName xml.Name `xml:"element"`
@@ -211,12 +327,13 @@ type Element struct {
 	hasAttrRef
 	hasAttrSubstitutionGroup
 	hasAttrType
+	hasElemsAlternative
 	hasElemAnnotation
 	hasElemComplexType
 	hasElemsKey
-	hasElemKeyRef
+	hasElemsKeyRef
 	hasElemsSimpleType
-	hasElemUnique
+	hasElemsUnique
 }
 
 type ExtensionComplexContent struct {
@@ -227,6 +344,7 @@ type ExtensionComplexContent struct {
 	hasElemAll
 	hasElemAnnotation
 	hasElemsAnyAttribute
+	hasElemsAssert
 	hasElemsAttribute
 	hasElemsAttributeGroup
 	hasElemsChoice
@@ -241,6 +359,7 @@ type ExtensionSimpleContent struct {
 	hasAttrId
 	hasElemAnnotation
 	hasElemsAnyAttribute
+	hasElemsAssert
 	hasElemsAttribute
 	hasElemsAttributeGroup
 }
@@ -272,6 +391,7 @@ type Include struct {
 	//	XMLName xml.Name `xml:"include"`
 	hasAttrId
 	hasAttrSchemaLocation
+	hasAttrXmlBase
 	hasElemAnnotation
 }
 
@@ -281,7 +401,10 @@ type Import struct {
 	hasAttrId
 	hasAttrNamespace
 	hasAttrSchemaLocation
+	hasAttrXmlBase
 	hasElemAnnotation
+
+	schema *Schema
 }
 
 type Key struct {
@@ -290,7 +413,7 @@ type Key struct {
 	hasAttrId
 	hasAttrName
 	hasElemAnnotation
-	hasElemField
+	hasElemsField
 	hasElemSelector
 }
 
@@ -301,7 +424,7 @@ type KeyRef struct {
 	hasAttrName
 	hasAttrRefer
 	hasElemAnnotation
-	hasElemField
+	hasElemsField
 	hasElemSelector
 }
 
@@ -324,11 +447,28 @@ type Notation struct {
 	hasElemAnnotation
 }
 
+type Override struct {
+	elemBase
+	//	XMLName xml.Name `xml:"override"`
+	hasAttrId
+	hasAttrSchemaLocation
+	hasAttrXmlBase
+	hasElemAnnotation
+	hasElemsAttribute
+	hasElemsAttributeGroup
+	hasElemsComplexType
+	hasElemsElement
+	hasElemsGroup
+	hasElemsNotation
+	hasElemsSimpleType
+}
+
 type Redefine struct {
 	elemBase
 	//	XMLName xml.Name `xml:"redefine"`
 	hasAttrId
 	hasAttrSchemaLocation
+	hasAttrXmlBase
 	hasElemAnnotation
 	hasElemsAttributeGroup
 	hasElemsComplexType
@@ -344,6 +484,7 @@ type RestrictionComplexContent struct {
 	hasElemAll
 	hasElemAnnotation
 	hasElemsAnyAttribute
+	hasElemsAssert
 	hasElemsAttribute
 	hasElemsAttributeGroup
 	hasElemsChoice
@@ -357,6 +498,7 @@ type RestrictionSimpleContent struct {
 	hasAttrId
 	hasElemAnnotation
 	hasElemsAnyAttribute
+	hasElemsAssert
 	hasElemsAttribute
 	hasElemsAttributeGroup
 	hasElemsEnumeration
@@ -378,6 +520,7 @@ type RestrictionSimpleEnumeration struct {
 	elemBase
 	//	XMLName xml.Name `xml:"enumeration"`
 	hasAttrValue
+	hasElemAnnotation
 }
 
 type RestrictionSimpleFractionDigits struct {
@@ -446,6 +589,7 @@ type RestrictionSimpleType struct {
 	hasAttrBase
 	hasAttrId
 	hasElemAnnotation
+	hasElemsAssertion
 	hasElemsEnumeration
 	hasElemFractionDigits
 	hasElemLength
@@ -487,6 +631,66 @@ type Sequence struct {
 	hasElemsElement
 	hasElemsGroup
 	hasElemsSequence
+
+	childOrder []string
+}
+
+// Decodes the xs:sequence as usual, but also records the document order of its (differently-typed) child particles, which matters for a sequence.
+func (me *Sequence) UnmarshalXML(d *xml.Decoder, start xml.StartElement) (err error) {
+	type sequence Sequence
+	var toks = []xml.Token{start.Copy()}
+	var tok xml.Token
+	for depth := 0; depth >= 0; {
+		if tok, err = d.Token(); err != nil {
+			return
+		}
+		switch t := tok.(type) {
+		case xml.StartElement:
+			if depth == 0 {
+				me.childOrder = append(me.childOrder, t.Name.Local)
+			}
+			depth++
+		case xml.EndElement:
+			depth--
+		}
+		toks = append(toks, xml.CopyToken(tok))
+	}
+	return xml.NewTokenDecoder(&tokenReplay{toks: toks}).Decode((*sequence)(me))
+}
+
+// Returns the child particles of this xs:sequence in document order.
+func (me *Sequence) Particles() (particles []element) {
+	var ie, ia, ic, ig, is int
+	for _, n := range me.childOrder {
+		switch {
+		case (n == "element") && (ie < len(me.Elements)):
+			particles, ie = append(particles, me.Elements[ie]), ie+1
+		case (n == "any") && (ia < len(me.Anys)):
+			particles, ia = append(particles, me.Anys[ia]), ia+1
+		case (n == "choice") && (ic < len(me.Choices)):
+			particles, ic = append(particles, me.Choices[ic]), ic+1
+		case (n == "group") && (ig < len(me.Groups)):
+			particles, ig = append(particles, me.Groups[ig]), ig+1
+		case (n == "sequence") && (is < len(me.Sequences)):
+			particles, is = append(particles, me.Sequences[is]), is+1
+		}
+	}
+	for ; ie < len(me.Elements); ie++ {
+		particles = append(particles, me.Elements[ie])
+	}
+	for ; ia < len(me.Anys); ia++ {
+		particles = append(particles, me.Anys[ia])
+	}
+	for ; ic < len(me.Choices); ic++ {
+		particles = append(particles, me.Choices[ic])
+	}
+	for ; ig < len(me.Groups); ig++ {
+		particles = append(particles, me.Groups[ig])
+	}
+	for ; is < len(me.Sequences); is++ {
+		particles = append(particles, me.Sequences[is])
+	}
+	return
 }
 
 type SimpleContent struct {
@@ -525,10 +729,23 @@ type Unique struct {
 	hasAttrId
 	hasAttrName
 	hasElemAnnotation
-	hasElemField
+	hasElemsField
 	hasElemSelector
 }
 
+type tokenReplay struct {
+	toks []xml.Token
+}
+
+func (me *tokenReplay) Token() (tok xml.Token, err error) {
+	if len(me.toks) == 0 {
+		err = io.EOF
+	} else {
+		tok, me.toks = me.toks[0], me.toks[1:]
+	}
+	return
+}
+
 func Flattened(choices []*Choice, seqs []*Sequence) (allChoices []*Choice, allSeqs []*Sequence) {
 	var tmpChoices []*Choice
 	var tmpSeqs []*Sequence
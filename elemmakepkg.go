@@ -1,8 +1,10 @@
 package xsd
 
 import (
+	"encoding/xml"
 	"fmt"
 	"path"
+	"path/filepath"
 	"strings"
 	"unicode"
 
@@ -13,6 +15,9 @@ import (
 
 const (
 	idPrefix = "XsdGoPkg"
+
+	//	About the number of bytes of Go source generated per declared type (see PkgBag.declTypes), used to pre-size the buffers of the source.
+	srcBytesPerType = 768
 )
 
 func (me *All) makePkg(bag *PkgBag) {
@@ -21,6 +26,26 @@ func (me *All) makePkg(bag *PkgBag) {
 	me.elemBase.afterMakePkg(bag)
 }
 
+func (me *Alternative) makePkg(bag *PkgBag) {
+	me.elemBase.beforeMakePkg(bag)
+	me.hasElemsSimpleType.makePkg(bag)
+	me.hasElemComplexType.makePkg(bag)
+	me.elemBase.afterMakePkg(bag)
+}
+
+//	Returns the annotations documenting this type alternative on the Go field of its element, as it has no other Go representation.
+func (me *Alternative) docAnnotations(bag *PkgBag) []*Annotation {
+	var typeName string
+	if len(me.Type) > 0 {
+		typeName = bag.resolveQnameRef(me.Type.String(), "T", nil)
+	} else if me.ComplexType != nil {
+		typeName = bag.selfName(me.ComplexType).String()
+	} else if len(me.SimpleTypes) > 0 {
+		typeName = bag.selfName(me.SimpleTypes[0]).String()
+	}
+	return []*Annotation{me.Annotation, docAnnotation(sfmt("XSD 1.1 type alternative: %s, the type is %s.", ustr.Ifs(len(me.Test) > 0, "if "+me.Test, "by default"), typeName))}
+}
+
 func (me *Annotation) makePkg(bag *PkgBag) {
 	me.elemBase.beforeMakePkg(bag)
 	me.hasElemsAppInfo.makePkg(bag)
@@ -43,24 +68,34 @@ func (me *AppInfo) makePkg(bag *PkgBag) {
 	me.elemBase.afterMakePkg(bag)
 }
 
+func (me *Assert) makePkg(bag *PkgBag) {
+	me.elemBase.beforeMakePkg(bag)
+	me.elemBase.afterMakePkg(bag)
+}
+
+//	Returns the annotations documenting this assertion on the Go type it constrains, as it has no other Go representation.
+func (me *Assert) docAnnotations() []*Annotation {
+	return []*Annotation{me.Annotation, docAnnotation(sfmt("XSD 1.1 assertion: %s", me.Test))}
+}
+
 func (me *Attribute) makePkg(bag *PkgBag) {
 	var safeName, typeName, tmp, key, defVal, impName string
 	var defName = "Default"
+	var form = ustr.Ifs(len(me.Form) > 0, me.Form, bag.Schema.AttributeFormDefault)
 	me.elemBase.beforeMakePkg(bag)
-	if len(me.Form) == 0 {
-		me.Form = bag.Schema.AttributeFormDefault
-	}
 	me.hasElemsSimpleType.makePkg(bag)
 	if len(me.Ref) > 0 {
 		key = bag.resolveQnameRef(me.Ref.String(), "", &impName)
-		tmp = ustr.PrefixWithSep(impName, ".", idPrefix+"HasAttr_"+bag.safeName(me.Ref.String()[(strings.Index(me.Ref.String(), ":")+1):]))
+		tmp = ustr.PrefixWithSep(impName, ".", idPrefix+"HasAttr_"+bag.safeRefName(me.Ref.String()))
 		if bag.attRefImps[me], bag.attsKeys[me] = impName, key; len(bag.attsCache[key]) == 0 {
 			bag.attsCache[key] = tmp
 		}
 	} else {
 		safeName = bag.safeName(me.Name.String())
 		if typeName = me.Type.String(); (len(typeName) == 0) && (len(me.SimpleTypes) > 0) {
-			typeName = me.SimpleTypes[0].Name.String()
+			typeName = bag.selfName(me.SimpleTypes[0]).String()
+		} else if anyRef := bag.anyTypeRef(typeName, true); len(anyRef) > 0 {
+			typeName = anyRef
 		} else {
 			if len(typeName) == 0 {
 				typeName = bag.xsdStringTypeRef()
@@ -70,7 +105,7 @@ func (me *Attribute) makePkg(bag *PkgBag) {
 		if defVal = me.Default; len(defVal) == 0 {
 			defName, defVal = "Fixed", me.Fixed
 		}
-		if me.Parent() == bag.Schema {
+		if isGlobal(me) {
 			key = safeName
 		} else {
 			key = safeName + "_" + bag.safeName(typeName) + "_" + bag.safeName(defVal)
@@ -80,18 +115,22 @@ func (me *Attribute) makePkg(bag *PkgBag) {
 			bag.attsKeys[me] = key
 			bag.attsCache[key] = tmp
 			var td = bag.addType(me, tmp, "", me.Annotation)
-			td.addField(me, safeName, typeName, ustr.Ifs(len(bag.Schema.TargetNamespace) > 0, bag.Schema.TargetNamespace.String()+" ", "")+me.Name.String()+",attr", me.Annotation)
-			if isPt := bag.isParseType(typeName); len(defVal) > 0 {
+			f := td.addField(me, safeName, typeName, ustr.Ifs((len(bag.Schema.TargetNamespace) > 0) && (isGlobal(me) || (form == "qualified")), bag.Schema.TargetNamespace.String()+" ", "")+me.Name.String()+",attr", me.Annotation)
+			f.trackPresence(bag)
+			if isPt := bag.isParseType(typeName) || bag.textTypes[typeName]; len(defVal) > 0 {
 				doc := sfmt("Returns the %v value for %v -- "+ustr.Ifs(isPt, "%v", "%#v"), strings.ToLower(defName), safeName, defVal)
 				if isPt {
-					if PkgGen.ForceParseForDefaults {
-						td.addMethod(nil, tmp, safeName+defName, typeName, sfmt("var x = new(%v); x.Set(%#v); return *x", typeName, defVal), doc)
+					if bag.gen.ForceParseForDefaults || bag.textTypes[typeName] {
+						td.addMethod(nil, tmp, safeName+defName, typeName, sfmt("var x = new(%v); %v; return *x", typeName, bag.setCall(typeName, "x", sfmt("%#v", defVal))), doc)
 					} else {
 						td.addMethod(nil, tmp, safeName+defName, typeName, sfmt("return %v(%v)", typeName, defVal), doc)
 					}
 				} else {
 					td.addMethod(nil, tmp, safeName+defName, typeName, sfmt("return %v(%#v)", typeName, defVal), doc)
 				}
+				if !f.presence {
+					td.addDefault(bag, "me."+safeName, safeName+defName, defName == "Fixed")
+				}
 			}
 		} else {
 			bag.attsKeys[me] = key
@@ -118,10 +157,10 @@ func (me *AttributeGroup) makePkg(bag *PkgBag) {
 		var td = bag.addType(me, tmp, "", me.Annotation)
 		bag.attGroups[me] = tmp
 		for _, ag := range me.AttributeGroups {
-			if len(ag.Ref) == 0 {
-				ag.Ref.Set(ag.Name.String())
+			if bag.circularRefs()[ag] {
+				continue
 			}
-			if refName = bag.resolveQnameRef(ag.Ref.String(), "", &refImp); len(refImp) > 0 {
+			if refName = bag.resolveQnameRef(ustr.Ifs(len(ag.Ref) > 0, ag.Ref.String(), ag.Name.String()), "", &refImp); len(refImp) > 0 {
 				td.addEmbed(ag, refImp+"."+idPrefix+"HasAtts_"+refName[(len(refImp)+1):], ag.Annotation)
 			} else {
 				td.addEmbed(ag, idPrefix+"HasAtts_"+refName, ag.Annotation)
@@ -157,45 +196,52 @@ func (me *ComplexType) makePkg(bag *PkgBag) {
 	var att *Attribute
 	var attGroup *AttributeGroup
 	var ctBaseType, ctValueType, typeSafeName string
-	var allAtts = map[*Attribute]bool{}
-	var allAttGroups = map[*AttributeGroup]bool{}
-	var allElems = map[*Element]bool{}
-	var allElemGroups = map[*Group]bool{}
+	var allAtts []*Attribute
+	var allAttGroups []*AttributeGroup
+	var allElems []*Element
+	var allElemGroups []*Group
 	var elsDone, grsDone = map[string]bool{}, map[string]bool{}
 	var allChoices, tmpChoices = []*Choice{}, []*Choice{me.Choice}
 	var allSeqs, tmpSeqs = []*Sequence{}, []*Sequence{me.Sequence}
 	var el *Element
 	var elGr *Group
 	var mixed = false
+	if bag.isOverridden(me.Name.String()) {
+		return
+	}
 	me.elemBase.beforeMakePkg(bag)
 	me.hasElemsAttribute.makePkg(bag)
 	me.hasElemsAnyAttribute.makePkg(bag)
 	me.hasElemsAttributeGroup.makePkg(bag)
+	me.hasElemsAssert.makePkg(bag)
 	me.hasElemAll.makePkg(bag)
 	me.hasElemChoice.makePkg(bag)
 	me.hasElemGroup.makePkg(bag)
 	me.hasElemSequence.makePkg(bag)
 	me.hasElemComplexContent.makePkg(bag)
 	me.hasElemSimpleContent.makePkg(bag)
-	if len(me.Name) == 0 {
-		me.Name = bag.AnonName(me.longSafeName(bag))
+	if len(bag.selfName(me)) == 0 {
+		bag.anonNames[me] = me.anonName(bag)
 	}
-	typeSafeName = bag.safeName(ustr.PrependIf(me.Name.String(), "T"))
+	typeSafeName = bag.safeName(ustr.PrependIf(bag.selfName(me).String(), "T"))
 	var td = bag.addType(me, typeSafeName, "", me.Annotation)
+	for _, as := range me.AllAsserts() {
+		td.addAnnotations(as.docAnnotations()...)
+	}
 	for _, att = range me.Attributes {
-		allAtts[att] = true
+		allAtts = append(allAtts, att)
 	}
 	for _, attGroup = range me.AttributeGroups {
-		allAttGroups[attGroup] = true
+		allAttGroups = append(allAttGroups, attGroup)
 	}
 	allChoices, allSeqs = Flattened(tmpChoices, tmpSeqs)
 	if me.All != nil {
 		for _, el = range me.All.Elements {
-			allElems[el] = true
+			allElems = append(allElems, el)
 		}
 	}
 	if me.Group != nil {
-		allElemGroups[me.Group] = true
+		allElemGroups = append(allElemGroups, me.Group)
 	}
 	if mixed = me.Mixed; me.ComplexContent != nil {
 		mixed = mixed || me.ComplexContent.Mixed
@@ -204,19 +250,19 @@ func (me *ComplexType) makePkg(bag *PkgBag) {
 			td.addAnnotations(me.ComplexContent.ExtensionComplexContent.Annotation)
 			if me.ComplexContent.ExtensionComplexContent.All != nil {
 				for _, el = range me.ComplexContent.ExtensionComplexContent.All.Elements {
-					allElems[el] = true
+					allElems = append(allElems, el)
 				}
 			}
 			for _, elGr = range me.ComplexContent.ExtensionComplexContent.Groups {
-				allElemGroups[elGr] = true
+				allElemGroups = append(allElemGroups, elGr)
 			}
 			tmpChoices, tmpSeqs = Flattened(me.ComplexContent.ExtensionComplexContent.Choices, me.ComplexContent.ExtensionComplexContent.Sequences)
 			allChoices, allSeqs = append(allChoices, tmpChoices...), append(allSeqs, tmpSeqs...)
 			for _, att = range me.ComplexContent.ExtensionComplexContent.Attributes {
-				allAtts[att] = true
+				allAtts = append(allAtts, att)
 			}
 			for _, attGroup = range me.ComplexContent.ExtensionComplexContent.AttributeGroups {
-				allAttGroups[attGroup] = true
+				allAttGroups = append(allAttGroups, attGroup)
 			}
 			if len(me.ComplexContent.ExtensionComplexContent.Base) > 0 {
 				ctBaseType = me.ComplexContent.ExtensionComplexContent.Base.String()
@@ -226,16 +272,16 @@ func (me *ComplexType) makePkg(bag *PkgBag) {
 			td.addAnnotations(me.ComplexContent.RestrictionComplexContent.Annotation)
 			if me.ComplexContent.RestrictionComplexContent.All != nil {
 				for _, el = range me.ComplexContent.RestrictionComplexContent.All.Elements {
-					allElems[el] = true
+					allElems = append(allElems, el)
 				}
 			}
 			tmpChoices, tmpSeqs = Flattened(me.ComplexContent.RestrictionComplexContent.Choices, me.ComplexContent.RestrictionComplexContent.Sequences)
 			allChoices, allSeqs = append(allChoices, tmpChoices...), append(allSeqs, tmpSeqs...)
 			for _, att = range me.ComplexContent.RestrictionComplexContent.Attributes {
-				allAtts[att] = true
+				allAtts = append(allAtts, att)
 			}
 			for _, attGroup = range me.ComplexContent.RestrictionComplexContent.AttributeGroups {
-				allAttGroups[attGroup] = true
+				allAttGroups = append(allAttGroups, attGroup)
 			}
 			if len(me.ComplexContent.RestrictionComplexContent.Base) > 0 {
 				ctBaseType = me.ComplexContent.RestrictionComplexContent.Base.String()
@@ -250,10 +296,10 @@ func (me *ComplexType) makePkg(bag *PkgBag) {
 			}
 			td.addAnnotations(me.SimpleContent.ExtensionSimpleContent.Annotation)
 			for _, att = range me.SimpleContent.ExtensionSimpleContent.Attributes {
-				allAtts[att] = true
+				allAtts = append(allAtts, att)
 			}
 			for _, attGroup = range me.SimpleContent.ExtensionSimpleContent.AttributeGroups {
-				allAttGroups[attGroup] = true
+				allAttGroups = append(allAttGroups, attGroup)
 			}
 			if (len(ctValueType) == 0) && (len(me.SimpleContent.ExtensionSimpleContent.Base) > 0) {
 				ctValueType = me.SimpleContent.ExtensionSimpleContent.Base.String()
@@ -265,24 +311,40 @@ func (me *ComplexType) makePkg(bag *PkgBag) {
 			}
 			td.addAnnotations(me.SimpleContent.RestrictionSimpleContent.Annotation)
 			for _, att = range me.SimpleContent.RestrictionSimpleContent.Attributes {
-				allAtts[att] = true
+				allAtts = append(allAtts, att)
 			}
 			for _, attGroup = range me.SimpleContent.RestrictionSimpleContent.AttributeGroups {
-				allAttGroups[attGroup] = true
+				allAttGroups = append(allAttGroups, attGroup)
 			}
 			if (len(ctValueType) == 0) && (len(me.SimpleContent.RestrictionSimpleContent.Base) > 0) {
 				ctValueType = me.SimpleContent.RestrictionSimpleContent.Base.String()
 			}
 			if (len(ctValueType) == 0) && (len(me.SimpleContent.RestrictionSimpleContent.SimpleTypes) > 0) {
-				ctValueType = me.SimpleContent.RestrictionSimpleContent.SimpleTypes[0].Name.String()
-			}
-			for _, enum := range me.SimpleContent.RestrictionSimpleContent.Enumerations {
-				println("ENUMTODO!?! Whoever sees this message, please post an issue at github.com/metaleap/go-xsd with a link to the XSD..." + enum.selfName().String())
+				ctValueType = bag.selfName(me.SimpleContent.RestrictionSimpleContent.SimpleTypes[0]).String()
 			}
 		}
 	}
-	if ctBaseType = bag.resolveQnameRef(ctBaseType, "T", nil); len(ctBaseType) > 0 {
+	if (me.SimpleContent != nil) && (me.SimpleContent.ExtensionSimpleContent != nil) {
+		//	encoding/xml decodes no character data into an embedded simple type, so only complex base types are embedded, simple ones held in XsdGoPkgValue
+		if def := bag.compile().TypeDefOf(me); (def == nil) || (def.Base == nil) || (def.Base.Complex == nil) {
+			ctBaseType = ""
+		}
+	}
+	if bag.circularRefs()[me] {
+		ctBaseType, ctValueType = "", ""
+	}
+	if isGlobal(me) {
+		bag.ctXsiNames[typeSafeName] = xml.Name{Space: bag.Schema.TargetNamespace.String(), Local: me.Name.String()}
+	}
+	var ctBaseRef = ctBaseType
+	if ctBaseType = bag.resolveQnameRef(ctBaseType, "T", nil); (len(ctBaseType) > 0) && me.narrowed(bag, typeSafeName, ctBaseType) {
+		ctBaseType = ""
+	}
+	if len(ctBaseType) > 0 {
 		td.addEmbed(nil, bag.safeName(ctBaseType))
+		if ((me.ComplexContent != nil) && !strings.HasPrefix(ctBaseType, bag.impName+".")) || (bag.globalComplexType(ctBaseRef) != nil) {
+			bag.ctBases[typeSafeName] = bag.safeName(ctBaseType)
+		}
 	} else if ctValueType = bag.resolveQnameRef(ctValueType, "T", nil); len(ctValueType) > 0 {
 		bag.simpleContentValueTypes[typeSafeName] = ctValueType
 		td.addField(nil, idPrefix+"Value", ctValueType, ",chardata")
@@ -300,16 +362,17 @@ func (me *ComplexType) makePkg(bag *PkgBag) {
 				break
 			}
 		}
-		if (!strings.HasPrefix(ctValueType, "xsdt.")) && (bag.declTypes[ctValueType] == nil) {
-			println("NOTFOUND: " + ctValueType)
-		}
 	} else if mixed {
 		td.addEmbed(nil, idPrefix+"HasCdata")
+		if bag.gen.PreserveMixedContent {
+			bag.mixedTypes[typeSafeName] = true
+			td.addField(nil, idPrefix+"Mixed", bag.impName+".MixedContent", "-", docAnnotation(sfmt("The character data and child elements of this mixed-content element in document order, as decoded. If not empty, %s encodes them in this order.", typeSafeName)))
+		}
 	}
-	for elGr, _ = range allElemGroups {
+	for _, elGr = range allElemGroups {
 		subMakeElemGroup(bag, td, elGr, grsDone, anns(nil, me.ComplexContent)...)
 	}
-	for el, _ = range allElems {
+	for _, el = range allElems {
 		subMakeElem(bag, td, el, elsDone, 1, anns(me.All, nil)...)
 	}
 	for _, ch := range allChoices {
@@ -328,47 +391,86 @@ func (me *ComplexType) makePkg(bag *PkgBag) {
 			subMakeElemGroup(bag, td, elGr, grsDone, seq.Annotation)
 		}
 	}
-	for attGroup, _ = range allAttGroups {
+	for _, attGroup = range allAttGroups {
 		td.addEmbed(attGroup, ustr.PrefixWithSep(bag.attGroupRefImps[attGroup], ".", bag.attGroups[attGroup][(strings.Index(bag.attGroups[attGroup], ".")+1):]), attGroup.Annotation)
 	}
 
-	for att, _ = range allAtts {
-		if key := bag.attsKeys[att]; len(key) > 0 {
+	for _, att = range allAtts {
+		if key := bag.attsKeys[att]; (len(key) > 0) && (att.Use != "prohibited") {
 			td.addEmbed(att, ustr.PrefixWithSep(bag.attRefImps[att], ".", bag.attsCache[key][(strings.Index(bag.attsCache[key], ".")+1):]), att.Annotation)
 		}
 	}
+	if anys, anyAtts := bag.components().wildcards(me); (len(anys) > 0) || (len(anyAtts) > 0) {
+		var anyAnns, anyAttAnns []*Annotation
+		for _, any := range anys {
+			anyAnns = append(anyAnns, any.Annotation, wildcardAnnotation("xs:any", any.Namespace, any.ProcessContents))
+		}
+		for _, aa := range anyAtts {
+			anyAttAnns = append(anyAttAnns, aa.Annotation, wildcardAnnotation("xs:anyAttribute", aa.Namespace, aa.ProcessContents))
+		}
+		if len(anys) > 0 {
+			td.addField(nil, idPrefix+"Any", "[]"+bag.impName+".AnyElement", ",any", anyAnns...)
+		}
+		if len(anyAtts) > 0 {
+			td.addField(nil, idPrefix+"AnyAttrs", bag.impName+".AnyAttrs", ",any,attr", anyAttAnns...)
+		}
+	}
 	me.elemBase.afterMakePkg(bag)
 }
 
 func (me *Documentation) makePkg(bag *PkgBag) {
 	me.elemBase.beforeMakePkg(bag)
 	if len(me.CDATA) > 0 {
-		var s, ln string
-		for _, ln = range ustr.Split(me.CDATA, "\n") {
-			if s = strings.Trim(ln, " \t\r\n"); len(s) > 0 {
-				bag.appendFmt(false, "//\t%s", s)
-			}
+		for _, ln := range docCommentLines(me.CDATA, docCommentWidth) {
+			bag.appendFmt(false, "//\t%s", ln)
 		}
 	}
 	me.elemBase.afterMakePkg(bag)
 }
 
+//	The number of characters after which docCommentLines wraps the lines of xs:documentation text.
+const docCommentWidth = 100
+
+//	Returns the non-empty lines of the specified xs:documentation text, sanitized for use in a Go comment: control and other
+//	non-printable characters become spaces, runs of spaces are collapsed, and lines longer than width are wrapped at word boundaries.
+func docCommentLines(text string, width int) (lines []string) {
+	text = strings.Map(func(r rune) rune {
+		if (r != '\n') && !unicode.IsPrint(r) {
+			return ' '
+		}
+		return r
+	}, text)
+	for _, ln := range ustr.Split(text, "\n") {
+		var cur string
+		for _, word := range strings.Fields(ln) {
+			if (len(cur) > 0) && (len([]rune(cur))+1+len([]rune(word)) > width) {
+				lines, cur = append(lines, cur), ""
+			}
+			cur = ustr.Ifs(len(cur) > 0, cur+" "+word, word)
+		}
+		if len(cur) > 0 {
+			lines = append(lines, cur)
+		}
+	}
+	return
+}
+
 func (me *Element) makePkg(bag *PkgBag) {
 	var (
-		safeName, typeName, valueType, tmp, key, defVal, impName string
-		subEl                                                    *Element
+		safeName, typeName, valueType, tmp, key, defVal, impName, anyRef string
+		subEl                                                            *Element
 	)
 	asterisk, defName, doc := "", "Default", ""
+	form := ustr.Ifs(len(me.Form) > 0, me.Form, bag.Schema.ElementFormDefault)
 	me.elemBase.beforeMakePkg(bag)
-	if len(me.Form) == 0 {
-		me.Form = bag.Schema.ElementFormDefault
-	}
 	me.hasElemsSimpleType.makePkg(bag)
 	me.hasElemComplexType.makePkg(bag)
+	me.hasElemsAlternative.makePkg(bag)
 	if len(me.Ref) > 0 {
 		key = bag.resolveQnameRef(me.Ref.String(), "", &impName)
-		for pref, cache := range map[string]map[string]string{"HasElem_": bag.elemsCacheOnce, "HasElems_": bag.elemsCacheMult} {
-			tmp = ustr.PrefixWithSep(impName, ".", idPrefix+pref+bag.safeName(me.Ref.String()[(strings.Index(me.Ref.String(), ":")+1):]))
+		for _, pref := range []string{"HasElem_", "HasElems_"} {
+			cache := ustr.Ifm(pref == "HasElem_", bag.elemsCacheOnce, bag.elemsCacheMult)
+			tmp = ustr.PrefixWithSep(impName, ".", idPrefix+pref+bag.safeRefName(me.Ref.String()))
 			if bag.elemRefImps[me], bag.elemKeys[me] = impName, key; len(cache[key]) == 0 {
 				cache[key] = tmp
 			}
@@ -377,57 +479,83 @@ func (me *Element) makePkg(bag *PkgBag) {
 		safeName = bag.safeName(me.Name.String())
 		if typeName = me.Type.String(); (len(typeName) == 0) && ((me.ComplexType != nil) || (len(me.SimpleTypes) > 0)) {
 			if me.ComplexType != nil {
-				asterisk, typeName = "*", me.ComplexType.Name.String()
+				asterisk, typeName = "*", bag.selfName(me.ComplexType).String()
 			} else {
-				typeName = me.SimpleTypes[0].Name.String()
+				typeName = bag.selfName(me.SimpleTypes[0]).String()
 			}
+		} else if anyRef = ustr.Ifs((len(typeName) > 0) || (len(me.SubstitutionGroup) == 0), bag.anyTypeRef(typeName, false), ""); len(anyRef) > 0 {
+			typeName = anyRef
 		} else {
 			if len(typeName) == 0 {
 				typeName = bag.xsdStringTypeRef()
 			}
-			loadedSchemas := make(map[string]bool)
-			if typeName = bag.resolveQnameRef(typeName, "T", &impName); bag.Schema.RootSchema([]string{bag.Schema.loadUri}).globalComplexType(bag, typeName, loadedSchemas) != nil {
+			if bag.globalComplexType(typeName) != nil {
 				asterisk = "*"
 			}
+			typeName = bag.resolveQnameRef(typeName, "T", &impName)
 		}
 		if defVal = me.Default; len(defVal) == 0 {
 			defName, defVal = "Fixed", me.Fixed
 		}
-		if me.Parent() == bag.Schema {
+		if (len(anyRef) > 0) && !bag.textTypes[anyRef] {
+			defVal = ""
+		}
+		if isGlobal(me) {
 			key = safeName
 		} else {
-			key = bag.safeName(bag.Stacks.FullName()) + "_" + safeName + "_" + bag.safeName(typeName) + "_" + bag.safeName(defVal)
+			key = bag.safeName(bag.Stacks.FullName()) + "_" + safeName + "_" + bag.safeName(typeName) + "_" + bag.safeName(defVal) + ustr.Ifs(me.Nillable, "_Nillable", "")
 		}
 		if valueType = bag.simpleContentValueTypes[typeName]; len(valueType) == 0 {
 			valueType = typeName
 		}
-		isPt := bag.isParseType(valueType)
+		isPt := bag.isParseType(valueType) || bag.textTypes[valueType]
+		isCt := len(asterisk) > 0
 		if _, isChoice := me.Parent().(*Choice); isChoice && isPt {
 			asterisk = "*"
 		}
-		for pref, cache := range map[string]map[string]string{"HasElem_": bag.elemsCacheOnce, "HasElems_": bag.elemsCacheMult} {
+		fieldType := asterisk + typeName
+		if me.Nillable {
+			fieldType = "*" + bag.nillableType(typeName)
+		}
+		for _, pref := range []string{"HasElem_", "HasElems_"} {
+			cache := ustr.Ifm(pref == "HasElem_", bag.elemsCacheOnce, bag.elemsCacheMult)
 			if tmp = idPrefix + pref + key; !bag.elemsWritten[tmp] {
 				bag.elemsWritten[tmp], bag.elemKeys[me] = true, key
 				cache[key] = tmp
 				var td = bag.addType(me, tmp, "", me.Annotation)
-				td.addField(me, ustr.Ifs(pref == "HasElems_", pluralize(safeName), safeName), ustr.Ifs(pref == "HasElems_", "[]"+asterisk+typeName, asterisk+typeName), ustr.Ifs(len(bag.Schema.TargetNamespace) > 0, bag.Schema.TargetNamespace.String()+" ", "")+me.Name.String(), me.Annotation)
-				if me.parent == bag.Schema {
-					loadedSchemas := make(map[string]bool)
-					for _, subEl = range bag.Schema.RootSchema([]string{bag.Schema.loadUri}).globalSubstitutionElems(me, loadedSchemas) {
+				var fieldAnns = []*Annotation{me.Annotation}
+				for _, alt := range me.Alternatives {
+					fieldAnns = append(fieldAnns, alt.docAnnotations(bag)...)
+				}
+				f := td.addField(me, ustr.Ifs(pref == "HasElems_", bag.gen.pluralize(safeName), safeName), ustr.Ifs(pref == "HasElems_", "[]"+fieldType, fieldType), ustr.Ifs((len(bag.Schema.TargetNamespace) > 0) && (isGlobal(me) || (form == "qualified")), bag.Schema.TargetNamespace.String()+" ", "")+me.Name.String(), fieldAnns...)
+				if (pref == "HasElem_") && !(me.Nillable || isCt) {
+					f.trackPresence(bag)
+				}
+				if isGlobal(me) {
+					for _, subEl = range bag.globalSubstitutionElems(me) {
 						td.addEmbed(subEl, idPrefix+pref+bag.safeName(subEl.Name.String()), subEl.Annotation)
 					}
+					if bag.gen.AddSubstitutionGroups && (len(td.Embeds) > 0) {
+						bag.substHeads[tmp] = me
+					}
 				}
 				if len(defVal) > 0 {
 					doc = sfmt("Returns the %v value for %v -- "+ustr.Ifs(isPt, "%v", "%#v"), strings.ToLower(defName), safeName, defVal)
 					if isPt {
-						if PkgGen.ForceParseForDefaults {
-							td.addMethod(nil, tmp, safeName+defName, valueType, sfmt("var x = new(%v); x.Set(%#v); return *x", valueType, defVal), doc)
+						if bag.gen.ForceParseForDefaults || bag.textTypes[valueType] {
+							td.addMethod(nil, tmp, safeName+defName, valueType, sfmt("var x = new(%v); %v; return *x", valueType, bag.setCall(valueType, "x", sfmt("%#v", defVal))), doc)
 						} else {
 							td.addMethod(nil, tmp, safeName+defName, valueType, sfmt("return %v(%v)", valueType, defVal), doc)
 						}
 					} else {
 						td.addMethod(nil, tmp, safeName+defName, valueType, sfmt("return %v(%#v)", valueType, defVal), doc)
 					}
+					if (len(asterisk) == 0) && (valueType == typeName) && !(me.Nillable || f.presence) {
+						td.addDefault(bag, "me."+ustr.Ifs(pref == "HasElems_", bag.gen.pluralize(safeName)+"[i]", safeName), safeName+defName, defName == "Fixed")
+					}
+				}
+				if bag.gen.AddKeyIndexes && (pref == "HasElem_") && (asterisk == "*") && !strings.Contains(typeName, ".") {
+					me.addKeyIndexes(bag, td, typeName)
 				}
 			}
 		}
@@ -435,11 +563,85 @@ func (me *Element) makePkg(bag *PkgBag) {
 	me.elemBase.afterMakePkg(bag)
 }
 
+//	Adds to td an index method on the struct type typeName of this element for each of its xs:key and xs:unique constraints
+//	whose selector is a single step selecting child elements of a complex type, and whose single field is an attribute of that type, see PkgGen.AddKeyIndexes.
+func (me *Element) addKeyIndexes(bag *PkgBag, td *declType, typeName string) {
+	var comps = bag.components()
+	var ct = bag.compile().TypeOf(me).Complex
+	if ct == nil {
+		return
+	}
+	var idEls []element
+	for _, k := range me.Keys {
+		idEls = append(idEls, k)
+	}
+	for _, u := range me.Uniques {
+		idEls = append(idEls, u)
+	}
+	keys, uniques, _ := identitiesOf(me)
+	for i, id := range append(keys, uniques...) {
+		if (!id.xpathsOk) || (len(id.selector) != 1) || (len(id.selector[0].steps) != 1) || id.selector[0].descendants || (len(id.fields) != 1) || (len(id.fields[0]) != 1) || (len(id.fields[0][0].steps) != 1) {
+			continue
+		}
+		sel, fld := id.selector[0].steps[0], id.fields[0][0].steps[0]
+		if sel.self || sel.anyLocal || sel.anySpace || (!fld.attr) || fld.anyLocal || fld.anySpace {
+			continue
+		}
+		for _, item := range comps.contentFields(ct) {
+			if (item.particle == nil) || (comps.elementName(item.particle) != sel.name) || item.particle.Nillable {
+				continue
+			}
+			var itemType string
+			var itemCt = item.Decl.(*Element).ComplexType
+			if itemCt != nil {
+				itemType = bag.selfName(itemCt).String()
+			} else if qn := ownerSchema(item.Decl).qname(item.Decl.(*Element).Type.String()); comps.complexTypes[qn] != nil {
+				itemCt, itemType = comps.complexTypes[qn], bag.resolveQnameRef(item.Decl.(*Element).Type.String(), "T", nil)
+			}
+			if len(itemType) == 0 {
+				break
+			}
+			parentMax := xsdt.Long(1)
+			switch p := item.particle.Parent().(type) {
+			case *Sequence:
+				parentMax = p.hasAttrMaxOccurs.Value()
+			case *Choice:
+				parentMax = p.hasAttrMaxOccurs.Value()
+			}
+			items := sfmt("[]*%s{me.%s}", itemType, bag.safeName(item.Name))
+			if (parentMax != 1) || (item.particle.hasAttrMaxOccurs.Value() != 1) {
+				items = "me." + bag.gen.pluralize(bag.safeName(item.Name))
+			}
+			for _, att := range comps.contentFields(itemCt) {
+				if decl, isAtt := att.Decl.(*Attribute); isAtt && (comps.attributeName(decl) == fld.name) {
+					var keyType = decl.Type.String()
+					if (len(keyType) == 0) && (len(decl.SimpleTypes) > 0) {
+						keyType = bag.selfName(decl.SimpleTypes[0]).String()
+					} else if len(keyType) == 0 {
+						keyType = bag.resolveQnameRef(bag.xsdStringTypeRef(), "T", nil)
+					} else {
+						keyType = bag.resolveQnameRef(keyType, "T", nil)
+					}
+					if len(keyType) > 0 {
+						mapType := sfmt("map[%s]*%s", keyType, itemType)
+						body := sfmt("index = %s{}; for _, item := range %s { if item != nil { index[item.%s] = item } }; return", mapType, items, ustr.Ifs(bag.gen.PresenceAccessors && (decl.Use != "required"), "Get"+bag.safeName(att.Name)+"()", bag.safeName(att.Name)))
+						td.addMethod(nil, "*"+typeName, bag.safeName(id.name.Local)+"Index() (index "+mapType+")", "", body, sfmt("Returns the %s elements keyed by their %s attribute, as constrained by the xs:%s %q.", item.Name, att.Name, id.kind, id.name.Local))
+						bag.keyIndexed[idEls[i]] = true
+					}
+					break
+				}
+			}
+			break
+		}
+	}
+}
+
 func (me *ExtensionComplexContent) makePkg(bag *PkgBag) {
 	me.elemBase.beforeMakePkg(bag)
 	me.hasElemsAttribute.makePkg(bag)
 	me.hasElemsAnyAttribute.makePkg(bag)
 	me.hasElemsAttributeGroup.makePkg(bag)
+	me.hasElemsAssert.makePkg(bag)
 	me.hasElemAll.makePkg(bag)
 	me.hasElemsChoice.makePkg(bag)
 	me.hasElemsGroup.makePkg(bag)
@@ -452,6 +654,7 @@ func (me *ExtensionSimpleContent) makePkg(bag *PkgBag) {
 	me.hasElemsAttribute.makePkg(bag)
 	me.hasElemsAnyAttribute.makePkg(bag)
 	me.hasElemsAttributeGroup.makePkg(bag)
+	me.hasElemsAssert.makePkg(bag)
 	me.elemBase.afterMakePkg(bag)
 }
 
@@ -473,12 +676,14 @@ func (me *Group) makePkg(bag *PkgBag) {
 	me.hasElemSequence.makePkg(bag)
 	if len(me.Ref) > 0 {
 		if len(bag.elemGroups[me]) == 0 {
+			if qn := bag.Schema.qname(me.Ref.String()); bag.compile().Resolve(me) == nil {
+				bag.report(me, SeverityError, "unresolvable group reference %s: no global xs:group %s is declared", me.Ref, diffName(qn))
+			}
 			refName = bag.resolveQnameRef(me.Ref.String(), "", &refImp)
 			bag.elemGroups[me] = idPrefix + "HasGroup_" + refName
 			bag.elemGroupRefImps[me] = refImp
 		}
 	} else {
-		me.Ref.Set(me.Name.String())
 		safeName := bag.safeName(me.Name.String())
 		tmp := idPrefix + "HasGroup_" + safeName
 		bag.elemGroups[me] = tmp
@@ -515,34 +720,38 @@ func (me *Import) makePkg(bag *PkgBag) {
 	var impName, impPath string
 	var pos int
 	me.hasElemAnnotation.makePkg(bag)
-	for k, v := range bag.Schema.XMLNamespaces {
-		if v == me.Namespace {
-			impName = safeIdentifier(k)
-			break
-		}
-	}
-	if len(impName) > 0 {
-		if pos, impPath = strings.Index(me.SchemaLocation.String(), protSep), me.SchemaLocation.String(); pos > 0 {
+	if impName = bag.nsImps[me.Namespace]; len(impName) > 0 {
+		if me.schema != nil {
+			impPath = me.schema.loadUri
+		} else if pos, impPath = strings.Index(me.SchemaLocation.String(), protSep), me.SchemaLocation.String(); pos > 0 {
 			impPath = impPath[pos+len(protSep):]
 		} else {
 			impPath = path.Join(path.Dir(bag.Schema.loadUri), impPath)
 		}
 		impPath = path.Join(path.Dir(impPath), goPkgPrefix+path.Base(impPath)+goPkgSuffix)
-		bag.imports[impName] = path.Join(PkgGen.BasePath, impPath)
+		if bag.imports[impName] = path.Join(bag.gen.BasePath, impPath); len(bag.gen.ImportPaths[me.Namespace]) > 0 {
+			bag.imports[impName] = bag.gen.ImportPaths[me.Namespace]
+		} else if opts := bag.gen.Packages[me.Namespace]; opts != nil {
+			if len(opts.ImportPath) > 0 {
+				bag.imports[impName] = opts.ImportPath
+			} else if rel, err := filepath.Rel(bag.gen.BaseCodePath, opts.Dir); (len(opts.Dir) > 0) && (err == nil) && !strings.HasPrefix(rel, "..") {
+				bag.imports[impName] = path.Join(bag.gen.BasePath, filepath.ToSlash(rel))
+			}
+		}
 	}
 	me.elemBase.afterMakePkg(bag)
 }
 
 func (me *Key) makePkg(bag *PkgBag) {
 	me.elemBase.beforeMakePkg(bag)
-	me.hasElemField.makePkg(bag)
+	me.hasElemsField.makePkg(bag)
 	me.hasElemSelector.makePkg(bag)
 	me.elemBase.afterMakePkg(bag)
 }
 
 func (me *KeyRef) makePkg(bag *PkgBag) {
 	me.elemBase.beforeMakePkg(bag)
-	me.hasElemField.makePkg(bag)
+	me.hasElemsField.makePkg(bag)
 	me.hasElemSelector.makePkg(bag)
 	me.elemBase.afterMakePkg(bag)
 }
@@ -553,20 +762,40 @@ func (me *List) makePkg(bag *PkgBag) {
 	me.hasElemsSimpleType.makePkg(bag)
 	rtr := bag.resolveQnameRef(me.ItemType.String(), "T", nil)
 	if len(rtr) == 0 {
-		rtr = me.SimpleTypes[0].Name.String()
+		rtr = bag.selfName(me.SimpleTypes[0]).String()
 	}
 	st := bag.Stacks.CurSimpleType()
-	safeName = bag.safeName(ustr.PrependIf(st.Name.String(), "T"))
+	safeName = bag.safeName(ustr.PrependIf(bag.selfName(st).String(), "T"))
+	if bag.gen.TypedListsAndUnions {
+		me.makeSliceType(bag, safeName, rtr)
+		me.elemBase.afterMakePkg(bag)
+		return
+	}
 	body, doc := "", sfmt("%v declares a String containing a whitespace-separated list of %v values. This Values() method creates and returns a slice of all elements in that list", safeName, rtr)
-	body = sfmt("svals := %v.ListValues(string(me)); list = make([]%v, len(svals)); for i, s := range svals { list[i].Set(s) }; return", bag.impName, rtr)
+	body = sfmt("svals := %v.ListValues(string(me)); list = make([]%v, len(svals)); for i, s := range svals { %v }; return", bag.impName, rtr, bag.setCall(rtr, "&list[i]", "s"))
 	bag.ctd.addMethod(me, safeName, "Values", sfmt("(list []%v)", rtr), body, doc+".", me.Annotation)
 	for baseType := bag.simpleBaseTypes[rtr]; len(baseType) > 0; baseType = bag.simpleBaseTypes[baseType] {
-		body = sfmt("svals := %v.ListValues(string(me)); list = make([]%v, len(svals)); for i, s := range svals { list[i].Set(s) }; return", bag.impName, baseType)
+		body = sfmt("svals := %v.ListValues(string(me)); list = make([]%v, len(svals)); for i, s := range svals { %v }; return", bag.impName, baseType, bag.setCall(baseType, "&list[i]", "s"))
 		bag.ctd.addMethod(me, safeName, "Values"+bag.safeName(baseType), sfmt("(list []%v)", baseType), body, sfmt("%s, typed as %s.", doc, baseType), me.Annotation)
 	}
 	me.elemBase.afterMakePkg(bag)
 }
 
+//	Turns the string type tn of the simple type declaring this xs:list into a slice of its item type rtr, see PkgGen.TypedListsAndUnions.
+func (me *List) makeSliceType(bag *PkgBag, tn, rtr string) {
+	td := bag.declTypes[tn]
+	delete(td.Methods, "To"+bag.safeName(td.Type))
+	td.Type, bag.simpleBaseTypes[tn], bag.textTypes[tn] = "[]"+rtr, "", true
+	td.addMethod(nil, "*"+tn, "Set (s string)", "", "me.UnmarshalText([]byte(s))", sfmt("Since %v is a list of %v values, sets the current items obtained from parsing the specified whitespace-separated string (or none if any of them is invalid).", tn, rtr))
+	td.addMethod(nil, tn, "String", "(s string)", sfmt("for i, x := range me {\n\t\tif i > 0 {\n\t\t\ts += \" \"\n\t\t}\n\t\ts += %v\n\t}\n\treturn", ustr.Ifs(bag.overrideTypes[rtr], bag.impName+".Text(&x)", "x.String()")), sfmt("Returns the lexical representation of this %v: its items, separated by spaces.", tn))
+	td.addMethod(nil, tn, "MarshalText ()", "([]byte, error)", "return []byte(me.String()), nil", sfmt("Implements encoding.TextMarshaler by encoding the items of this %v, separated by spaces.", tn))
+	td.addMethod(nil, "*"+tn, "UnmarshalText (text []byte)", "(err error)", sfmt("svals := %v.ListValues(string(text))\n\tlist := make(%v, len(svals))\n\tfor i, s := range svals {\n\t\tif err = %v.ParseLexical(&list[i], %#v, s); err != nil {\n\t\t\t*me = nil\n\t\t\treturn\n\t\t}\n\t}\n\t*me = list\n\treturn", bag.impName, tn, bag.impName, bag.builtinBaseType(rtr)), sfmt("Implements encoding.TextUnmarshaler by decoding the whitespace-separated items of this %v, returning an error for the first one that is not a valid %v value.", tn, rtr))
+	td.addMethod(me, tn, "Values", sfmt("(list []%v)", rtr), sfmt("return []%v(me)", rtr), sfmt("Returns the items of this %v as a []%v.", tn, rtr), me.Annotation)
+	for baseType := bag.simpleBaseTypes[rtr]; len(baseType) > 0; baseType = bag.simpleBaseTypes[baseType] {
+		td.addMethod(me, tn, "Values"+bag.safeName(baseType), sfmt("(list []%v)", baseType), sfmt("list = make([]%v, len(me))\n\tfor i, x := range me {\n\t\tlist[i] = %v(x)\n\t}\n\treturn", baseType, baseType), sfmt("Returns the items of this %v, typed as %v.", tn, baseType), me.Annotation)
+	}
+}
+
 func (me *Notation) makePkg(bag *PkgBag) {
 	me.elemBase.beforeMakePkg(bag)
 	me.hasElemAnnotation.makePkg(bag)
@@ -574,6 +803,17 @@ func (me *Notation) makePkg(bag *PkgBag) {
 	me.elemBase.afterMakePkg(bag)
 }
 
+func (me *Override) makePkg(bag *PkgBag) {
+	me.elemBase.beforeMakePkg(bag)
+	me.hasElemsSimpleType.makePkg(bag)
+	me.hasElemsAttribute.makePkg(bag)
+	me.hasElemsAttributeGroup.makePkg(bag)
+	me.hasElemsComplexType.makePkg(bag)
+	me.hasElemsElement.makePkg(bag)
+	me.hasElemsGroup.makePkg(bag)
+	me.elemBase.afterMakePkg(bag)
+}
+
 func (me *Redefine) makePkg(bag *PkgBag) {
 	me.elemBase.beforeMakePkg(bag)
 	me.hasElemsSimpleType.makePkg(bag)
@@ -588,6 +828,7 @@ func (me *RestrictionComplexContent) makePkg(bag *PkgBag) {
 	me.hasElemsAttribute.makePkg(bag)
 	me.hasElemsAnyAttribute.makePkg(bag)
 	me.hasElemsAttributeGroup.makePkg(bag)
+	me.hasElemsAssert.makePkg(bag)
 	me.hasElemAll.makePkg(bag)
 	me.hasElemsChoice.makePkg(bag)
 	me.hasElemsSequence.makePkg(bag)
@@ -600,6 +841,7 @@ func (me *RestrictionSimpleContent) makePkg(bag *PkgBag) {
 	me.hasElemsAttribute.makePkg(bag)
 	me.hasElemsAnyAttribute.makePkg(bag)
 	me.hasElemsAttributeGroup.makePkg(bag)
+	me.hasElemsAssert.makePkg(bag)
 	me.hasElemLength.makePkg(bag)
 	me.hasElemPattern.makePkg(bag)
 	me.hasElemsEnumeration.makePkg(bag)
@@ -617,9 +859,11 @@ func (me *RestrictionSimpleContent) makePkg(bag *PkgBag) {
 
 func (me *RestrictionSimpleEnumeration) makePkg(bag *PkgBag) {
 	me.elemBase.beforeMakePkg(bag)
-	safeName := bag.safeName(ustr.PrependIf(bag.Stacks.CurSimpleType().Name.String(), "T"))
-	var doc = sfmt("Returns true if the value of this enumerated %v is %#v.", safeName, me.Value)
-	bag.ctd.addMethod(me, safeName, "Is"+bag.safeName(me.Value), "bool", sfmt("return me.String() == %#v", me.Value), doc)
+	if st := bag.Stacks.CurSimpleType(); st != nil { // else, in a simpleContent restriction: reported as unsupported by ComplexType.makePkg
+		safeName := bag.safeName(ustr.PrependIf(bag.selfName(st).String(), "T"))
+		var doc = sfmt("Returns true if the value of this enumerated %v is %#v.", safeName, me.Value)
+		bag.ctd.addMethod(me, safeName, "Is"+bag.safeName(me.Value), "bool", sfmt("return me.String() == %#v", me.Value), doc, me.Annotation)
+	}
 	me.elemBase.afterMakePkg(bag)
 }
 
@@ -676,6 +920,7 @@ func (me *RestrictionSimpleTotalDigits) makePkg(bag *PkgBag) {
 func (me *RestrictionSimpleType) makePkg(bag *PkgBag) {
 	me.elemBase.beforeMakePkg(bag)
 	me.hasElemsSimpleType.makePkg(bag)
+	me.hasElemsAssertion.makePkg(bag)
 	me.hasElemLength.makePkg(bag)
 	me.hasElemPattern.makePkg(bag)
 	me.hasElemsEnumeration.makePkg(bag)
@@ -706,6 +951,7 @@ func (me *Schema) makePkg(bag *PkgBag) {
 	me.hasElemsElement.makePkg(bag)
 	me.hasElemsGroup.makePkg(bag)
 	me.hasElemsRedefine.makePkg(bag)
+	me.hasElemsOverride.makePkg(bag)
 	me.elemBase.afterMakePkg(bag)
 }
 
@@ -737,11 +983,14 @@ func (me *SimpleType) makePkg(bag *PkgBag) {
 	var baseType, safeName = "", ""
 	var resolve = true
 	var isPt bool
+	if bag.isOverridden(typeName.String()) {
+		return
+	}
 	if len(typeName) == 0 {
-		typeName = bag.AnonName(me.longSafeName(bag))
-		me.Name = typeName
-	} else {
-		me.Name = typeName
+		if typeName = bag.anonNames[me]; len(typeName) == 0 {
+			typeName = me.anonName(bag)
+			bag.anonNames[me] = typeName
+		}
 	}
 	typeName = xsdt.NCName(ustr.PrependIf(typeName.String(), "T"))
 	me.elemBase.beforeMakePkg(bag)
@@ -749,9 +998,12 @@ func (me *SimpleType) makePkg(bag *PkgBag) {
 	safeName = bag.safeName(typeName.String())
 	if me.RestrictionSimpleType != nil {
 		if baseType = me.RestrictionSimpleType.Base.String(); (len(baseType) == 0) && (len(me.RestrictionSimpleType.SimpleTypes) > 0) {
-			resolve, baseType = false, me.RestrictionSimpleType.SimpleTypes[0].Name.String()
+			resolve, baseType = false, bag.selfName(me.RestrictionSimpleType.SimpleTypes[0]).String()
 		}
 	}
+	if bag.circularRefs()[me] {
+		baseType = ""
+	}
 	if len(baseType) == 0 {
 		baseType = bag.xsdStringTypeRef()
 	}
@@ -762,22 +1014,47 @@ func (me *SimpleType) makePkg(bag *PkgBag) {
 	if isPt = bag.isParseType(baseType); isPt {
 		bag.parseTypes[safeName] = true
 	}
+	isText := bag.textTypes[baseType]
+	bag.textTypes[safeName] = isText
 	var td = bag.addType(me, safeName, baseType, me.Annotation)
+	if me.RestrictionSimpleType != nil {
+		for _, as := range me.RestrictionSimpleType.Assertions {
+			td.addAnnotations(as.docAnnotations()...)
+		}
+	}
+	if me.RestrictionSimpleType != nil {
+		bag.stFacets[safeName] = me.RestrictionSimpleType.facets()
+		for _, enum := range me.RestrictionSimpleType.Enumerations {
+			if enum.Annotation != nil {
+				bag.enumAnns[safeName+"\x00"+enum.Value] = enum.Annotation
+			}
+		}
+	} else if me.List != nil {
+		bag.stLists[safeName] = true
+	}
 	var doc string
 	if isPt {
 		doc = sfmt("Since %v is a non-string scalar type (either boolean or numeric), sets the current value obtained from parsing the specified string.", safeName)
+	} else if isText {
+		doc = sfmt("Since %v is a typed %v value, sets the current value obtained from parsing the specified string.", safeName, baseType)
 	} else {
 		doc = sfmt("Since %v is just a simple String type, this merely sets the current value from the specified string.", safeName)
 	}
-	td.addMethod(nil, "*"+safeName, "Set (s string)", "", sfmt("(*%v)(me).Set(s)", baseType), doc)
+	td.addMethod(nil, "*"+safeName, "Set (s string)", "", sfmt(ustr.Ifs(bag.overrideTypes[baseType], bag.impName+".SetText((*%v)(me), s)", "(*%v)(me).Set(s)"), baseType), doc)
 	if isPt {
 		doc = sfmt("Returns a string representation of this %v's current non-string scalar value.", safeName)
+	} else if isText {
+		doc = sfmt("Returns the lexical representation of this %v's current typed value.", safeName)
 	} else {
 		doc = sfmt("Since %v is just a simple String type, this merely returns the current string value.", safeName)
 	}
-	td.addMethod(nil, safeName, "String", "string", sfmt("return %v(me).String()", baseType), doc)
+	td.addMethod(nil, safeName, "String", "string", sfmt(ustr.Ifs(bag.overrideTypes[baseType], "return "+bag.impName+".Text((*%v)(&me))", "return %v(me).String()"), baseType), doc)
 	doc = sfmt("This convenience method just performs a simple type conversion to %v's alias type %v.", safeName, baseType)
 	td.addMethod(nil, safeName, "To"+bag.safeName(baseType), baseType, sfmt("return %v(me)", baseType), doc)
+	if isText {
+		td.addMethod(nil, safeName, "MarshalText ()", "([]byte, error)", sfmt(ustr.Ifs(bag.overrideTypes[baseType], "return (*%v)(&me).MarshalText()", "return %v(me).MarshalText()"), baseType), sfmt("Implements encoding.TextMarshaler by encoding this %v as its typed %v value.", safeName, baseType))
+		td.addMethod(nil, "*"+safeName, "UnmarshalText (text []byte)", "error", sfmt("return (*%v)(me).UnmarshalText(text)", baseType), sfmt("Implements encoding.TextUnmarshaler by decoding this %v as its typed %v value.", safeName, baseType))
+	}
 	me.hasElemRestrictionSimpleType.makePkg(bag)
 	me.hasElemList.makePkg(bag)
 	me.hasElemUnion.makePkg(bag)
@@ -792,19 +1069,49 @@ func (me *Union) makePkg(bag *PkgBag) {
 	me.hasElemsSimpleType.makePkg(bag)
 	memberTypes = ustr.Split(me.MemberTypes, " ")
 	for _, st := range me.SimpleTypes {
-		memberTypes = append(memberTypes, st.Name.String())
+		memberTypes = append(memberTypes, bag.selfName(st).String())
+	}
+	if bag.gen.TypedListsAndUnions {
+		me.makeSumType(bag, bag.safeName(ustr.PrependIf(bag.selfName(bag.Stacks.CurSimpleType()).String(), "T")), memberTypes)
+		me.elemBase.afterMakePkg(bag)
+		return
 	}
 	for _, mt := range memberTypes {
 		rtn = bag.resolveQnameRef(mt, "T", nil)
-		safeName, rtnSafeName = bag.safeName(ustr.PrependIf(bag.Stacks.CurSimpleType().Name.String(), "T")), bag.safeName(rtn)
-		bag.ctd.addMethod(me, safeName, "To"+rtnSafeName, rtn, sfmt(ustr.Ifs(bag.isParseType(rtn), "var x = new(%v); x.Set(me.String()); return *x", "return %v(me)"), rtn), sfmt("%v is an XSD union-type of several types. This is a simple type conversion to %v, but keep in mind the actual value may or may not be a valid %v value.", safeName, rtnSafeName, rtnSafeName), me.Annotation)
+		safeName, rtnSafeName = bag.safeName(ustr.PrependIf(bag.selfName(bag.Stacks.CurSimpleType()).String(), "T")), bag.safeName(rtn)
+		bag.ctd.addMethod(me, safeName, "To"+rtnSafeName, rtn, ustr.Ifs(bag.isParseType(rtn) || bag.textTypes[rtn], sfmt("var x = new(%v); %v; return *x", rtn, bag.setCall(rtn, "x", "me.String()")), sfmt("return %v(me)", rtn)), sfmt("%v is an XSD union-type of several types. This is a simple type conversion to %v, but keep in mind the actual value may or may not be a valid %v value.", safeName, rtnSafeName, rtnSafeName), me.Annotation)
 	}
 	me.elemBase.afterMakePkg(bag)
 }
 
+//	Turns the string type tn of the simple type declaring this xs:union into a struct type holding a field for each of its memberTypes
+//	and a Which field naming the one holding the value, see PkgGen.TypedListsAndUnions.
+func (me *Union) makeSumType(bag *PkgBag, tn string, memberTypes []string) {
+	var names []string
+	var cases, marshal string
+	td := bag.declTypes[tn]
+	delete(td.Methods, "To"+bag.safeName(td.Type))
+	td.Type, bag.simpleBaseTypes[tn], bag.textTypes[tn], bag.stUnions[tn] = "", "", true, true
+	for _, mt := range memberTypes {
+		rtn := bag.resolveQnameRef(mt, "T", nil)
+		if rtnSafeName := bag.safeName(rtn); td.Fields[rtnSafeName] == nil {
+			names = append(names, rtnSafeName)
+			td.addField(nil, rtnSafeName, rtn, "-", docAnnotation(sfmt("The value if Which is %#v.", rtnSafeName)))
+			cases += sfmt("\n\tcase %v.ParseLexical(&me.%v, %#v, s) == nil:\n\t\tme.Which = %#v", bag.impName, rtnSafeName, bag.builtinBaseType(rtn), rtnSafeName)
+			marshal += sfmt("\n\tcase %#v:\n\t\treturn %v", rtnSafeName, sfmt(ustr.Ifs(bag.textTypes[rtn], "me.%v.MarshalText()", "[]byte(me.%v.String()), nil"), rtnSafeName))
+			td.addMethod(me, tn, "To"+rtnSafeName, rtn, sfmt("return me.%v", rtnSafeName), sfmt("%v is an XSD union-type of several types. This returns its %v value, which is only set if Which is %#v.", tn, rtnSafeName, rtnSafeName), me.Annotation)
+		}
+	}
+	td.addField(nil, "Which", "string", "-", docAnnotation(sfmt("The name of the field holding the value (%v), or empty if none does.", strings.Join(names, ", "))))
+	td.addMethod(nil, "*"+tn, "Set (s string)", "", "me.UnmarshalText([]byte(s))", sfmt("Since %v is an XSD union-type of several types, sets the current value obtained from parsing the specified string as the first of these that it is valid for (or none).", tn))
+	td.addMethod(nil, tn, "String", "string", "text, _ := me.MarshalText()\n\treturn string(text)", sfmt("Returns the lexical representation of this %v's current value.", tn))
+	td.addMethod(nil, tn, "MarshalText ()", "([]byte, error)", sfmt("switch me.Which {%v\n\t}\n\treturn nil, nil", marshal), sfmt("Implements encoding.TextMarshaler by encoding the field named by Which (if any) of this %v.", tn))
+	td.addMethod(nil, "*"+tn, "UnmarshalText (text []byte)", "(err error)", sfmt("*me = %v{}\n\tswitch s := string(text); {%v\n\tdefault:\n\t\terr = &%v.LexicalError{Type: %#v, Value: s}\n\t}\n\treturn", tn, cases, bag.impName, tn), sfmt("Implements encoding.TextUnmarshaler by parsing text as each member type of this %v in turn (%v), setting the field of the first one it is valid for and Which to its name, or else returning an *%v.LexicalError.", tn, strings.Join(names, ", "), bag.impName))
+}
+
 func (me *Unique) makePkg(bag *PkgBag) {
 	me.elemBase.beforeMakePkg(bag)
-	me.hasElemField.makePkg(bag)
+	me.hasElemsField.makePkg(bag)
 	me.hasElemSelector.makePkg(bag)
 	me.elemBase.afterMakePkg(bag)
 }
@@ -824,8 +1131,8 @@ func anns(a *All, cc *ComplexContent) (anns []*Annotation) {
 	return
 }
 
-func pluralize(s string) string {
-	for _, psp := range PkgGen.PluralizeSpecialPrefixes {
+func (me *Generator) pluralize(s string) string {
+	for _, psp := range me.PluralizeSpecialPrefixes {
 		if strings.HasPrefix(s, psp) {
 			return ustr.Pluralize(s[len(psp):] + s[:len(psp)])
 		}
@@ -867,6 +1174,9 @@ func subMakeElem(bag *PkgBag, td *declType, el *Element, done map[string]bool, p
 
 func subMakeElemGroup(bag *PkgBag, td *declType, gr *Group, done map[string]bool, anns ...*Annotation) {
 	var refImp string
+	if bag.circularRefs()[gr] {
+		return
+	}
 	anns = append(anns, gr.Annotation)
 	if refName := bag.resolveQnameRef(gr.Ref.String(), "", &refImp); !done[refName] {
 		if done[refName] = true; len(refImp) > 0 {
@@ -878,3 +1188,17 @@ func subMakeElemGroup(bag *PkgBag, td *declType, gr *Group, done map[string]bool
 		}
 	}
 }
+
+//	Returns a synthetic Annotation describing the namespace constraint and processContents mode of an xs:any or xs:anyAttribute wildcard.
+func wildcardAnnotation(kind, namespace, processContents string) *Annotation {
+	return docAnnotation(sfmt("Captures the content matched by the %s wildcard (namespace %s, processContents %s).", kind, ustr.Ifs(len(namespace) > 0, namespace, "##any"), ustr.Ifs(len(processContents) > 0, processContents, "strict")))
+}
+
+//	Returns a synthetic Annotation holding the specified documentation lines, for XSD constructs that are only represented as Go comments.
+func docAnnotation(lines ...string) (ann *Annotation) {
+	ann = &Annotation{}
+	for _, ln := range lines {
+		ann.Documentations = append(ann.Documentations, &Documentation{hasCdata: hasCdata{CDATA: ln}})
+	}
+	return
+}
@@ -1,11 +1,22 @@
 package xsd
 
+import (
+	xsdt "github.com/metaleap/go-xsd/types"
+)
+
 func (me *All) initElement(parent element) {
 	me.elemBase.init(parent, me, "all", &me.hasAttrId, &me.hasAttrMaxOccurs, &me.hasAttrMinOccurs)
 	me.hasElemAnnotation.initChildren(me)
 	me.hasElemsElement.initChildren(me)
 }
 
+func (me *Alternative) initElement(parent element) {
+	me.elemBase.init(parent, me, "alternative", &me.hasAttrId, &me.hasAttrTest, &me.hasAttrType, &me.hasAttrXpathDefaultNamespace)
+	me.hasElemAnnotation.initChildren(me)
+	me.hasElemComplexType.initChildren(me)
+	me.hasElemsSimpleType.initChildren(me)
+}
+
 func (me *Annotation) initElement(parent element) {
 	me.elemBase.init(parent, me, "annotation")
 	me.hasElemsAppInfo.initChildren(me)
@@ -26,6 +37,16 @@ func (me *AppInfo) initElement(parent element) {
 	me.elemBase.init(parent, me, "appInfo", &me.hasAttrSource)
 }
 
+func (me *Assert) initElement(parent element) {
+	me.initElementAs(parent, "assert")
+}
+
+//	Initializes this xs:assert, or (if xsdName is "assertion") this xs:assertion facet of a simple type restriction.
+func (me *Assert) initElementAs(parent element, xsdName xsdt.NCName) {
+	me.elemBase.init(parent, me, xsdName, &me.hasAttrId, &me.hasAttrTest, &me.hasAttrXpathDefaultNamespace)
+	me.hasElemAnnotation.initChildren(me)
+}
+
 func (me *Attribute) initElement(parent element) {
 	me.elemBase.init(parent, me, "attribute", &me.hasAttrDefault, &me.hasAttrFixed, &me.hasAttrForm, &me.hasAttrId, &me.hasAttrName, &me.hasAttrRef, &me.hasAttrType, &me.hasAttrUse)
 	me.hasElemAnnotation.initChildren(me)
@@ -69,6 +90,7 @@ func (me *ComplexType) initElement(parent element) {
 	me.hasElemSimpleContent.initChildren(me)
 	me.hasElemsAnyAttribute.initChildren(me)
 	me.hasElemsAttributeGroup.initChildren(me)
+	me.hasElemsAssert.initChildren(me)
 }
 
 func (me *Documentation) initElement(parent element) {
@@ -78,11 +100,12 @@ func (me *Documentation) initElement(parent element) {
 func (me *Element) initElement(parent element) {
 	me.elemBase.init(parent, me, "element", &me.hasAttrAbstract, &me.hasAttrBlock, &me.hasAttrDefault, &me.hasAttrFinal, &me.hasAttrFixed, &me.hasAttrForm, &me.hasAttrId, &me.hasAttrName, &me.hasAttrNillable, &me.hasAttrRef, &me.hasAttrType, &me.hasAttrMaxOccurs, &me.hasAttrMinOccurs, &me.hasAttrSubstitutionGroup)
 	me.hasElemAnnotation.initChildren(me)
-	me.hasElemUnique.initChildren(me)
+	me.hasElemsUnique.initChildren(me)
 	me.hasElemsKey.initChildren(me)
 	me.hasElemComplexType.initChildren(me)
-	me.hasElemKeyRef.initChildren(me)
+	me.hasElemsKeyRef.initChildren(me)
 	me.hasElemsSimpleType.initChildren(me)
+	me.hasElemsAlternative.initChildren(me)
 }
 
 func (me *ExtensionComplexContent) initElement(parent element) {
@@ -95,6 +118,7 @@ func (me *ExtensionComplexContent) initElement(parent element) {
 	me.hasElemsSequence.initChildren(me)
 	me.hasElemsAnyAttribute.initChildren(me)
 	me.hasElemsAttributeGroup.initChildren(me)
+	me.hasElemsAssert.initChildren(me)
 }
 
 func (me *ExtensionSimpleContent) initElement(parent element) {
@@ -103,6 +127,7 @@ func (me *ExtensionSimpleContent) initElement(parent element) {
 	me.hasElemsAttribute.initChildren(me)
 	me.hasElemsAnyAttribute.initChildren(me)
 	me.hasElemsAttributeGroup.initChildren(me)
+	me.hasElemsAssert.initChildren(me)
 }
 
 func (me *Field) initElement(parent element) {
@@ -123,17 +148,22 @@ func (me *Import) initElement(parent element) {
 	me.hasElemAnnotation.initChildren(me)
 }
 
+func (me *Include) initElement(parent element) {
+	me.elemBase.init(parent, me, "include", &me.hasAttrId, &me.hasAttrSchemaLocation)
+	me.hasElemAnnotation.initChildren(me)
+}
+
 func (me *Key) initElement(parent element) {
 	me.elemBase.init(parent, me, "key", &me.hasAttrId, &me.hasAttrName)
 	me.hasElemAnnotation.initChildren(me)
-	me.hasElemField.initChildren(me)
+	me.hasElemsField.initChildren(me)
 	me.hasElemSelector.initChildren(me)
 }
 
 func (me *KeyRef) initElement(parent element) {
 	me.elemBase.init(parent, me, "keyref", &me.hasAttrId, &me.hasAttrName, &me.hasAttrRefer)
 	me.hasElemAnnotation.initChildren(me)
-	me.hasElemField.initChildren(me)
+	me.hasElemsField.initChildren(me)
 	me.hasElemSelector.initChildren(me)
 }
 
@@ -148,6 +178,18 @@ func (me *Notation) initElement(parent element) {
 	me.hasElemAnnotation.initChildren(me)
 }
 
+func (me *Override) initElement(parent element) {
+	me.elemBase.init(parent, me, "override", &me.hasAttrId, &me.hasAttrSchemaLocation)
+	me.hasElemAnnotation.initChildren(me)
+	me.hasElemsAttribute.initChildren(me)
+	me.hasElemsElement.initChildren(me)
+	me.hasElemsGroup.initChildren(me)
+	me.hasElemsNotation.initChildren(me)
+	me.hasElemsAttributeGroup.initChildren(me)
+	me.hasElemsComplexType.initChildren(me)
+	me.hasElemsSimpleType.initChildren(me)
+}
+
 func (me *Redefine) initElement(parent element) {
 	me.elemBase.init(parent, me, "redefine", &me.hasAttrId, &me.hasAttrSchemaLocation)
 	me.hasElemAnnotation.initChildren(me)
@@ -166,6 +208,7 @@ func (me *RestrictionComplexContent) initElement(parent element) {
 	me.hasElemsSequence.initChildren(me)
 	me.hasElemsAnyAttribute.initChildren(me)
 	me.hasElemsAttributeGroup.initChildren(me)
+	me.hasElemsAssert.initChildren(me)
 }
 
 func (me *RestrictionSimpleContent) initElement(parent element) {
@@ -187,10 +230,12 @@ func (me *RestrictionSimpleContent) initElement(parent element) {
 	me.hasElemsAnyAttribute.initChildren(me)
 	me.hasElemsAttributeGroup.initChildren(me)
 	me.hasElemsSimpleType.initChildren(me)
+	me.hasElemsAssert.initChildren(me)
 }
 
 func (me *RestrictionSimpleEnumeration) initElement(parent element) {
 	me.elemBase.init(parent, me, "enumeration", &me.hasAttrValue)
+	me.hasElemAnnotation.initChildren(me)
 }
 
 func (me *RestrictionSimpleFractionDigits) initElement(parent element) {
@@ -249,6 +294,7 @@ func (me *RestrictionSimpleType) initElement(parent element) {
 	me.hasElemTotalDigits.initChildren(me)
 	me.hasElemWhiteSpace.initChildren(me)
 	me.hasElemsSimpleType.initChildren(me)
+	me.hasElemsAssertion.initChildren(me)
 }
 
 func (me *RestrictionSimpleWhiteSpace) initElement(parent element) {
@@ -262,7 +308,9 @@ func (me *Schema) initElement(parent element) {
 	me.hasElemsElement.initChildren(me)
 	me.hasElemsGroup.initChildren(me)
 	me.hasElemsImport.initChildren(me)
+	me.hasElemsInclude.initChildren(me)
 	me.hasElemsNotation.initChildren(me)
+	me.hasElemsOverride.initChildren(me)
 	me.hasElemsRedefine.initChildren(me)
 	me.hasElemsAttributeGroup.initChildren(me)
 	me.hasElemsComplexType.initChildren(me)
@@ -308,6 +356,6 @@ func (me *Union) initElement(parent element) {
 func (me *Unique) initElement(parent element) {
 	me.elemBase.init(parent, me, "unique", &me.hasAttrId, &me.hasAttrName)
 	me.hasElemAnnotation.initChildren(me)
-	me.hasElemField.initChildren(me)
+	me.hasElemsField.initChildren(me)
 	me.hasElemSelector.initChildren(me)
 }
@@ -80,7 +80,16 @@ type hasAttrMemberTypes struct {
 }
 
 type hasAttrMinOccurs struct {
-	MinOccurs uint64 `xml:"minOccurs,attr"`
+	MinOccurs string `xml:"minOccurs,attr"`
+}
+
+func (me *hasAttrMinOccurs) Value() (l xsdt.Long) {
+	if len(me.MinOccurs) == 0 {
+		l = 1
+	} else {
+		l.Set(me.MinOccurs)
+	}
+	return
 }
 
 type hasAttrMixed struct {
@@ -119,6 +128,12 @@ type hasAttrSchemaLocation struct {
 	SchemaLocation xsdt.AnyURI `xml:"schemaLocation,attr"`
 }
 
+//	The xml:base attribute: on loading a schema document, its xml:base attributes are applied to the schemaLocations of its xs:include, xs:import,
+//	xs:redefine and xs:override elements (resolving them relative to the xml:base of the element, and to that of the xs:schema element), and cleared.
+type hasAttrXmlBase struct {
+	XmlBase xsdt.AnyURI `xml:"http://www.w3.org/XML/1998/namespace base,attr"`
+}
+
 type hasAttrSource struct {
 	Source xsdt.AnyURI `xml:"source,attr"`
 }
@@ -135,6 +150,10 @@ type hasAttrTargetNamespace struct {
 	TargetNamespace xsdt.AnyURI `xml:"targetNamespace,attr"`
 }
 
+type hasAttrTest struct {
+	Test string `xml:"test,attr"`
+}
+
 type hasAttrType struct {
 	Type xsdt.Qname `xml:"type,attr"`
 }
@@ -154,3 +173,7 @@ type hasAttrVersion struct {
 type hasAttrXpath struct {
 	Xpath string `xml:"xpath,attr"`
 }
+
+type hasAttrXpathDefaultNamespace struct {
+	XpathDefaultNamespace string `xml:"xpathDefaultNamespace,attr"`
+}
@@ -8,6 +8,10 @@ type hasElemAll struct {
 	All *All `xml:"all"`
 }
 
+type hasElemsAlternative struct {
+	Alternatives []*Alternative `xml:"alternative"`
+}
+
 type hasElemAnnotation struct {
 	Annotation *Annotation `xml:"annotation"`
 }
@@ -24,6 +28,14 @@ type hasElemsAppInfo struct {
 	AppInfos []*AppInfo `xml:"appinfo"`
 }
 
+type hasElemsAssert struct {
+	Asserts []*Assert `xml:"assert"`
+}
+
+type hasElemsAssertion struct {
+	Assertions []*Assert `xml:"assertion"`
+}
+
 type hasElemsAttribute struct {
 	Attributes []*Attribute `xml:"attribute"`
 }
@@ -72,8 +84,8 @@ type hasElemExtensionSimpleContent struct {
 	ExtensionSimpleContent *ExtensionSimpleContent `xml:"extension"`
 }
 
-type hasElemField struct {
-	Field *Field `xml:"field"`
+type hasElemsField struct {
+	Fields []*Field `xml:"field"`
 }
 
 type hasElemFractionDigits struct {
@@ -100,8 +112,8 @@ type hasElemsKey struct {
 	Keys []*Key `xml:"key"`
 }
 
-type hasElemKeyRef struct {
-	KeyRef *KeyRef `xml:"keyref"`
+type hasElemsKeyRef struct {
+	KeyRefs []*KeyRef `xml:"keyref"`
 }
 
 type hasElemLength struct {
@@ -144,6 +156,10 @@ type hasElemPattern struct {
 	Pattern *RestrictionSimplePattern `xml:"pattern"`
 }
 
+type hasElemsOverride struct {
+	Overrides []*Override `xml:"override"`
+}
+
 type hasElemsRedefine struct {
 	Redefines []*Redefine `xml:"redefine"`
 }
@@ -188,8 +204,8 @@ type hasElemUnion struct {
 	Union *Union `xml:"union"`
 }
 
-type hasElemUnique struct {
-	Unique *Unique `xml:"unique"`
+type hasElemsUnique struct {
+	Uniques []*Unique `xml:"unique"`
 }
 
 type hasElemWhiteSpace struct {
@@ -6,6 +6,12 @@ func (me *hasElemAll) makePkg(bag *PkgBag) {
 	}
 }
 
+func (me *hasElemsAlternative) makePkg(bag *PkgBag) {
+	for _, alt := range me.Alternatives {
+		alt.makePkg(bag)
+	}
+}
+
 func (me *hasElemAnnotation) makePkg(bag *PkgBag) {
 	if me.Annotation != nil {
 		me.Annotation.makePkg(bag)
@@ -30,15 +36,31 @@ func (me *hasElemsAppInfo) makePkg(bag *PkgBag) {
 	}
 }
 
+func (me *hasElemsAssert) makePkg(bag *PkgBag) {
+	for _, as := range me.Asserts {
+		as.makePkg(bag)
+	}
+}
+
+func (me *hasElemsAssertion) makePkg(bag *PkgBag) {
+	for _, as := range me.Assertions {
+		as.makePkg(bag)
+	}
+}
+
 func (me *hasElemsAttribute) makePkg(bag *PkgBag) {
 	for _, ea := range me.Attributes {
-		ea.makePkg(bag)
+		if !bag.skips(ea) {
+			ea.makePkg(bag)
+		}
 	}
 }
 
 func (me *hasElemsAttributeGroup) makePkg(bag *PkgBag) {
 	for _, ag := range me.AttributeGroups {
-		ag.makePkg(bag)
+		if !bag.skips(ag) {
+			ag.makePkg(bag)
+		}
 	}
 }
 
@@ -68,19 +90,23 @@ func (me *hasElemComplexType) makePkg(bag *PkgBag) {
 
 func (me *hasElemsComplexType) makePkg(bag *PkgBag) {
 	for _, ct := range me.ComplexTypes {
-		ct.makePkg(bag)
+		if !bag.skips(ct) {
+			ct.makePkg(bag)
+		}
 	}
 }
 
 func (me *hasElemsDocumentation) makePkg(bag *PkgBag) {
-	for _, doc := range me.Documentations {
+	for _, doc := range bag.gen.selectDocumentations(me.Documentations) {
 		doc.makePkg(bag)
 	}
 }
 
 func (me *hasElemsElement) makePkg(bag *PkgBag) {
 	for _, el := range me.Elements {
-		el.makePkg(bag)
+		if !bag.skips(el) {
+			el.makePkg(bag)
+		}
 	}
 }
 
@@ -102,9 +128,9 @@ func (me *hasElemExtensionSimpleContent) makePkg(bag *PkgBag) {
 	}
 }
 
-func (me *hasElemField) makePkg(bag *PkgBag) {
-	if me.Field != nil {
-		me.Field.makePkg(bag)
+func (me *hasElemsField) makePkg(bag *PkgBag) {
+	for _, f := range me.Fields {
+		f.makePkg(bag)
 	}
 }
 
@@ -122,7 +148,9 @@ func (me *hasElemGroup) makePkg(bag *PkgBag) {
 
 func (me *hasElemsGroup) makePkg(bag *PkgBag) {
 	for _, gr := range me.Groups {
-		gr.makePkg(bag)
+		if !bag.skips(gr) {
+			gr.makePkg(bag)
+		}
 	}
 }
 
@@ -138,9 +166,9 @@ func (me *hasElemsKey) makePkg(bag *PkgBag) {
 	}
 }
 
-func (me *hasElemKeyRef) makePkg(bag *PkgBag) {
-	if me.KeyRef != nil {
-		me.KeyRef.makePkg(bag)
+func (me *hasElemsKeyRef) makePkg(bag *PkgBag) {
+	for _, kr := range me.KeyRefs {
+		kr.makePkg(bag)
 	}
 }
 
@@ -204,6 +232,12 @@ func (me *hasElemPattern) makePkg(bag *PkgBag) {
 	}
 }
 
+func (me *hasElemsOverride) makePkg(bag *PkgBag) {
+	for _, ov := range me.Overrides {
+		ov.makePkg(bag)
+	}
+}
+
 func (me *hasElemsRedefine) makePkg(bag *PkgBag) {
 	for _, rd := range me.Redefines {
 		rd.makePkg(bag)
@@ -254,7 +288,9 @@ func (me *hasElemSimpleContent) makePkg(bag *PkgBag) {
 
 func (me *hasElemsSimpleType) makePkg(bag *PkgBag) {
 	for _, st := range me.SimpleTypes {
-		st.makePkg(bag)
+		if !bag.skips(st) {
+			st.makePkg(bag)
+		}
 	}
 }
 
@@ -270,9 +306,9 @@ func (me *hasElemUnion) makePkg(bag *PkgBag) {
 	}
 }
 
-func (me *hasElemUnique) makePkg(bag *PkgBag) {
-	if me.Unique != nil {
-		me.Unique.makePkg(bag)
+func (me *hasElemsUnique) makePkg(bag *PkgBag) {
+	for _, u := range me.Uniques {
+		u.makePkg(bag)
 	}
 }
 
@@ -337,6 +373,9 @@ func (me *hasAttrSource) beforeMakePkg(bag *PkgBag) {
 func (me *hasAttrSystem) beforeMakePkg(bag *PkgBag) {
 }
 
+func (me *hasAttrTest) beforeMakePkg(bag *PkgBag) {
+}
+
 func (me *hasAttrType) beforeMakePkg(bag *PkgBag) {
 }
 
@@ -352,6 +391,9 @@ func (me *hasAttrVersion) beforeMakePkg(bag *PkgBag) {
 func (me *hasAttrXpath) beforeMakePkg(bag *PkgBag) {
 }
 
+func (me *hasAttrXpathDefaultNamespace) beforeMakePkg(bag *PkgBag) {
+}
+
 func (me *hasAttrBlockDefault) beforeMakePkg(bag *PkgBag) {
 }
 
@@ -443,6 +485,9 @@ func (me *hasAttrSource) afterMakePkg(bag *PkgBag) {
 func (me *hasAttrSystem) afterMakePkg(bag *PkgBag) {
 }
 
+func (me *hasAttrTest) afterMakePkg(bag *PkgBag) {
+}
+
 func (me *hasAttrType) afterMakePkg(bag *PkgBag) {
 }
 
@@ -458,6 +503,9 @@ func (me *hasAttrVersion) afterMakePkg(bag *PkgBag) {
 func (me *hasAttrXpath) afterMakePkg(bag *PkgBag) {
 }
 
+func (me *hasAttrXpathDefaultNamespace) afterMakePkg(bag *PkgBag) {
+}
+
 func (me *hasAttrBlockDefault) afterMakePkg(bag *PkgBag) {
 }
 
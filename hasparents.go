@@ -6,6 +6,12 @@ func (me *hasElemAll) initChildren(p element) {
 	}
 }
 
+func (me *hasElemsAlternative) initChildren(p element) {
+	for _, alt := range me.Alternatives {
+		alt.initElement(p)
+	}
+}
+
 func (me *hasElemAnnotation) initChildren(p element) {
 	if me.Annotation != nil {
 		me.Annotation.initElement(p)
@@ -30,6 +36,18 @@ func (me *hasElemsAppInfo) initChildren(p element) {
 	}
 }
 
+func (me *hasElemsAssert) initChildren(p element) {
+	for _, as := range me.Asserts {
+		as.initElement(p)
+	}
+}
+
+func (me *hasElemsAssertion) initChildren(p element) {
+	for _, as := range me.Assertions {
+		as.initElementAs(p, "assertion")
+	}
+}
+
 func (me *hasElemsAttribute) initChildren(p element) {
 	for _, ea := range me.Attributes {
 		ea.initElement(p)
@@ -102,9 +120,9 @@ func (me *hasElemExtensionSimpleContent) initChildren(p element) {
 	}
 }
 
-func (me *hasElemField) initChildren(p element) {
-	if me.Field != nil {
-		me.Field.initElement(p)
+func (me *hasElemsField) initChildren(p element) {
+	for _, f := range me.Fields {
+		f.initElement(p)
 	}
 }
 
@@ -132,15 +150,21 @@ func (me *hasElemsImport) initChildren(p element) {
 	}
 }
 
+func (me *hasElemsInclude) initChildren(p element) {
+	for _, inc := range me.Includes {
+		inc.initElement(p)
+	}
+}
+
 func (me *hasElemsKey) initChildren(p element) {
 	for _, k := range me.Keys {
 		k.initElement(p)
 	}
 }
 
-func (me *hasElemKeyRef) initChildren(p element) {
-	if me.KeyRef != nil {
-		me.KeyRef.initElement(p)
+func (me *hasElemsKeyRef) initChildren(p element) {
+	for _, kr := range me.KeyRefs {
+		kr.initElement(p)
 	}
 }
 
@@ -204,6 +228,12 @@ func (me *hasElemPattern) initChildren(p element) {
 	}
 }
 
+func (me *hasElemsOverride) initChildren(p element) {
+	for _, ov := range me.Overrides {
+		ov.initElement(p)
+	}
+}
+
 func (me *hasElemsRedefine) initChildren(p element) {
 	for _, rd := range me.Redefines {
 		rd.initElement(p)
@@ -270,9 +300,9 @@ func (me *hasElemUnion) initChildren(p element) {
 	}
 }
 
-func (me *hasElemUnique) initChildren(p element) {
-	if me.Unique != nil {
-		me.Unique.initElement(p)
+func (me *hasElemsUnique) initChildren(p element) {
+	for _, u := range me.Uniques {
+		u.initElement(p)
 	}
 }
 
@@ -1,33 +1,366 @@
 package xsd
 
 import (
+	"bytes"
+	"context"
+	"encoding/xml"
 	"fmt"
+	"go/ast"
+	"go/format"
+	"go/parser"
+	"go/token"
+	"io"
+	"math"
+	"net/http"
 	"path"
+	"runtime"
+	"sort"
+	"strconv"
 	"strings"
+	"time"
+	"unicode"
 
 	"github.com/metaleap/go-util-misc"
-	"github.com/metaleap/go-util-slice"
 	"github.com/metaleap/go-util-str"
 
 	xsdt "github.com/metaleap/go-xsd/types"
 )
 
 var (
-	PkgGen = &pkgGen{
+	//	The Generator used by all methods of Schema and SchemaSet generating Go code (such as Schema.MakeGoPkgSrcFiles), whose settings are
+	//	also the ones governing how schemas are loaded and downloaded, unless LoadOptions.Generator specifies another one.
+	PkgGen = NewGenerator(DefaultGenOptions())
+
+	typeRenderRepls = map[string]string{"*": "", "[": "", "]": "", "(list ": "", ")": ""}
+
+	//	The typed xsdt types (see PkgGen.TypedBuiltins) of the XSD built-in types, keyed by their XSD names.
+	typedBuiltins = map[string]string{"anyURI": "AnyURIValue", "base64Binary": "Base64BinaryValue", "date": "DateValue", "dateTime": "DateTimeValue", "decimal": "DecimalValue", "duration": "DurationValue", "gDay": "GDayValue", "gMonth": "GMonthValue", "gMonthDay": "GMonthDayValue", "gYear": "GYearValue", "gYearMonth": "GYearMonthValue", "hexBinary": "HexBinaryValue", "QName": "QNameValue", "time": "TimeValue"}
+)
+
+//	The values of PkgGen.JsonTags, denoting how json struct tags are derived from XSD element and attribute names.
+const (
+	//	No json struct tags are generated.
+	JsonTagsNone = ""
+
+	//	Such as "shipTo" for "ShipTo", "ship-to" or "ship_to".
+	JsonTagsCamelCase = "camelCase"
+
+	//	Such as "ship_to" for "ShipTo", "shipTo" or "ship-to".
+	JsonTagsSnakeCase = "snake_case"
+
+	//	The XSD name verbatim.
+	JsonTagsAsIs = "as-is"
+)
+
+//	Generates Go code from schemas, as configured by its GenOptions: all methods of Schema and SchemaSet generating Go code (such as
+//	Schema.MakeGoPkgSrcFiles) use PkgGen, and are also methods of Generator taking the Schema (or SchemaSet) as their first argument,
+//	so that generations with differing settings can run in one process, or even concurrently, without modifying PkgGen.
+//	A Generator must not be modified while generating, nor should the same Schema (or the schemas it includes) be generated concurrently.
+type Generator struct {
+	GenOptions
+}
+
+//	Returns a new Generator with the specified settings, such as those returned by DefaultGenOptions and then modified as desired.
+//	The settings governing how schemas are loaded and downloaded (such as Resolver, Catalog, HttpClient, Fetch, DownloadTTL, Offline,
+//	Checksums, Entities and DocTypes) take effect for the loads of its GenerateAll and of its SchemaSets (see SchemaSet.Generator),
+//	and for any load passing it as LoadOptions.Generator. The package-level LoadSchema etc. load with those of PkgGen.
+func NewGenerator(opts GenOptions) *Generator {
+	return &Generator{GenOptions: opts}
+}
+
+//	Returns the default settings that PkgGen is initialized with.
+func DefaultGenOptions() GenOptions {
+	return GenOptions{
 		BaseCodePath:             ugo.GopathSrcGithub("metaleap", "go-xsd-pkg"),
 		BasePath:                 "github.com/metaleap/go-xsd-pkg",
 		ForceParseForDefaults:    false,
 		PluralizeSpecialPrefixes: []string{"Library", "Instance"},
 		AddWalkers:               true,
+		AddXsiTypeMethods:        true,
+		AddSubstitutionGroups:    true,
+		PreserveMixedContent:     true,
+		NarrowRestrictions:       true,
+		ApplyDefaults:            true,
+		AddValidators:            true,
+		AddConstructors:          true,
+		AddSoapOperations:        true,
+		MaxConcurrentLoads:       8,
+		MaxConcurrentGens:        runtime.NumCPU(),
+		PruneImports:             true,
+		Templates:                DefaultTemplates,
 	}
-	typeRenderRepls = map[string]string{"*": "", "[": "", "]": "", "(list ": "", ")": ""}
-)
+}
 
-type pkgGen struct {
+//	The settings of a Generator, see NewGenerator.
+type GenOptions struct {
 	BaseCodePath, BasePath   string
 	ForceParseForDefaults    bool
 	PluralizeSpecialPrefixes []string
 	AddWalkers               bool
+
+	//	If true, complex types that derive from, or are derived from, other complex types get MarshalXML() / UnmarshalXML() methods honoring xsi:type.
+	AddXsiTypeMethods bool
+
+	//	If true, every global element heading a substitution group gets an interface implemented by the types of all its (direct or indirect) member elements,
+	//	and the fields for references to it hold a slice of that interface (decoding and encoding each member under its own element name) rather than
+	//	a field of the head's type alongside a separately embedded struct per member element (see addSubstitutionGroups).
+	AddSubstitutionGroups bool
+
+	//	If true, the struct types of mixed complex types get an XsdGoPkgMixed field (an xsdt.MixedContent) recording their character data and child elements
+	//	in document order, which the UnmarshalXML() methods of these (and of all struct types deriving from them) fill in and their MarshalXML() methods
+	//	encode the text segments and child elements in, so that text interleaved with child elements (such as in HTML-like markup) is not lost.
+	//	Otherwise, only their concatenated character data is held (in XsdGoPkgCDATA), and encoded before all child elements.
+	PreserveMixedContent bool
+
+	//	If true, the struct types of complex types derived by complexContent restriction from complex types of the same package (or from xs:anyType)
+	//	do not embed their base type, but hold just the elements (with the occurrences) that the restriction declares, along with the attributes of their
+	//	base types that they neither re-declare nor prohibit. They implement the XsdGoPkgRestriction_<base> interface generated for their base type, and
+	//	remain xsi:type-derived types of it (see AddXsiTypeMethods). Otherwise, they embed their base type, as do types derived by extension.
+	NarrowRestrictions bool
+
+	//	If true, struct types with fields for attributes or elements that have default or fixed values (be it directly or in their embeds) get an ApplyDefaults() method setting those
+	//	fields that hold zero values to these, as well as an ApplyFixed() method if any fixed values exist. Their UnmarshalXML() / MarshalXML() methods call these,
+	//	so that absent attributes and elements decode to their default values and fixed values are always encoded.
+	ApplyDefaults bool
+
+	//	If true, all struct types and all simple types restricted by facets get Validate() methods enforcing those facets. The struct types of complex types
+	//	whose elements may only occur a bounded number of times (maxOccurs="5"), or at least more than once (minOccurs="2"), also get a CheckOccurrences()
+	//	method called by Validate(), which checks the lengths of their slice fields against exported constants of these bounds (such as TOrder_Items_MaxOccurs).
+	AddValidators bool
+
+	//	If true, the struct types of complex types that have required attributes (use="required") or elements (that must occur in every instance, see
+	//	particleRequired), or fields with default or fixed values, get a NewXyz() constructor function taking the values of the former as parameters
+	//	and applying the latter (see ApplyDefaults).
+	AddConstructors bool
+
+	//	If true, the struct types of complex types get a CheckBeforeMarshal() method to be called before encoding an instance, returning an *xsdt.ContentError
+	//	naming the path of the first attribute or element (in it or, recursively, in the instances of its element fields) violating the content model of its
+	//	XSD type: a required attribute or element that is missing, an element occurring more often than its maxOccurs permits, or an element of another
+	//	alternative of an xs:choice than one already set.
+	AddMarshalChecks bool
+
+	//	If true, every xs:choice that occurs at most once and whose alternatives are all elements occurring at most once gets a struct type holding
+	//	these alternatives along with a Which field naming the one that is present, and the struct types declaring the choice get a single field
+	//	of that type (such as EmailOrPhone) rather than embedding every alternative separately. Only the alternative named by Which is encoded,
+	//	and decoding records in Which the alternative that was present (see addChoiceUnions).
+	ChoiceUnions bool
+
+	//	If true, the struct types of elements declaring xs:key or xs:unique constraints get a method (such as ProductSkuIndex() for a key named "productSku")
+	//	returning a map from the key values to the child elements selected by each such constraint, if its selector is a single child step and its field a single attribute step.
+	AddKeyIndexes bool
+
+	//	If true, the XSD built-in types xs:date, xs:dateTime, xs:time, xs:gYear, xs:gYearMonth, xs:gMonth, xs:gMonthDay, xs:gDay, xs:duration, xs:decimal,
+	//	xs:base64Binary, xs:hexBinary, xs:anyURI and xs:QName (and all simple types derived from them) are generated as the typed xsdt types DateValue,
+	//	DateTimeValue, TimeValue, GYearValue etc., DurationValue, DecimalValue, Base64BinaryValue, HexBinaryValue, AnyURIValue and QNameValue (wrapping time.Time,
+	//	*big.Rat, []byte, url.URL and a namespace-qualified name) rather than the string-based xsdt types, decoding and encoding their lexical forms via their
+	//	UnmarshalText() / MarshalText() methods. The date and time types keep whether a time zone was given, its offset (and whether a zero offset was "Z",
+	//	"+00:00" or "-00:00") and the number of digits of fractional seconds, so that their lexical forms survive decoding and re-encoding.
+	TypedBuiltins bool
+
+	//	If true, decoding and then encoding an XML document reproduces it as faithfully as encoding/xml allows, such as for signed XML documents:
+	//	the numeric and boolean XSD built-in types (and all simple types derived from them) are generated as the string-based xsdt types LexicalInt,
+	//	LexicalBoolean etc. holding their lexical forms verbatim (taking precedence over TypedBuiltins), optional attributes and elements get omitempty
+	//	xml tags (so those that are empty are not encoded), and no default or fixed values are applied when decoding or encoding (see ApplyDefaults).
+	LexicalFidelity bool
+
+	//	If true, simple types declaring an xs:list are generated as slices of their item type rather than as strings, and simple types declaring
+	//	an xs:union as struct types holding a field for each member type along with a Which field naming the member type of the value. Both decode
+	//	and encode their lexical forms via their UnmarshalText() / MarshalText() methods: list items are separated by whitespace, and union values
+	//	are parsed as each member type in turn (in the order of their declaration) until one is valid, failing with an *xsdt.LexicalError otherwise.
+	TypedListsAndUnions bool
+
+	//	Custom Go types (such as those of a decimal package, or ID types of one's own) that the XSD built-in types and named simple and complex types
+	//	keyed by their namespace-qualified names (such as "{http://www.w3.org/2001/XMLSchema}decimal" or "{urn:acme:orders}SkuType") are generated as,
+	//	rather than their default mapping (taking precedence over TypedBuiltins and LexicalFidelity). Overridden schema types get no Go type declaration
+	//	of their own, and simple types derived from them get Set(), String(), MarshalText() and UnmarshalText() methods converting to and from these types
+	//	(see TypeOverride).
+	TypeOverrides map[string]*TypeOverride
+
+	//	If true, the fields of optional attributes and of optional elements of simple types that occur at most once (see declField.optional) are pointers
+	//	that are nil if the attribute or element is absent, so that "absent" is distinguishable from "present with a zero value", and get protobuf-style
+	//	HasXyz(), GetXyz(), SetXyz() and ClearXyz() accessors (see addAccessors). GetXyz() returns the default or fixed value of an absent attribute
+	//	or element (or else the zero value of its type), and ApplyDefaults does not set these fields. The fields stay exported, as encoding/xml
+	//	only decodes into exported fields, but should be accessed through these methods.
+	PresenceAccessors bool
+
+	//	If true, every struct type gets a Clone() method returning a deep copy of an instance, and an Equal() method comparing two instances (see addCloneAndEqual),
+	//	including their slices, pointers, wildcard-captured content (xsdt.AnyElement, xsdt.AnyAttrs, xsdt.MixedContent) and xsi:type instances.
+	//	Typed built-in types are compared by their lexical forms and unexported fields are not compared, unlike with reflect.DeepEqual (see xsdt.EqualValues).
+	AddCloneAndEqual bool
+
+	//	If true, every struct type of a complex type (such as TOrderType) gets a flat XyzDTO struct type (such as TOrderTypeDTO) holding the values of its attributes
+	//	and elements, including those of its base types, in plain Go types: strings, numbers and bools rather than simple types, pointers to the DTOs of nested
+	//	struct types, and plain values for nillable elements and for complex types of nothing but simple content. ToDTO() and FromDTO() methods convert between
+	//	the two (see addDTOs), for gRPC, JSON or database layers that do not want to deal with the faithful XML structs.
+	AddDTOs bool
+
+	//	If true, the struct type of every global element whose only attribute or element is a repeating element of a flat record type (a complex type of
+	//	nothing but attributes and elements of simple types occurring at most once), such as a batch file of order lines, gets MarshalCSV() and UnmarshalCSV()
+	//	methods encoding and decoding its records as CSV data, one column per attribute or element of the record type (see addCSV). Decoding checks the values
+	//	against the lexical forms and facets of their types, and encoding validates the records (if AddValidators is set).
+	AddCSV bool
+
+	//	If true, the Go packages of schemas loaded by LoadWSDL get request and response wrapper types, SOAP (de)serialization methods and a CallXyz()
+	//	function for every operation of the document/literal SOAP bindings of the WSDL whose request element the schema declares (see Schema.SoapOperations).
+	AddSoapOperations bool
+
+	//	If set, the rules for deriving Go identifiers from the names of schema components, such as to write initialisms in all caps, to suffix names
+	//	that clash with generated methods and to number names that would otherwise collide (see DefaultIdentifierRules). If nil, identifiers are the
+	//	names with their first letters upper-cased and all characters not allowed in Go identifiers dropped, and those that would clash with generated
+	//	methods (such as Validate) get "_" appended. Every name whose identifier differs from the former is reported as a Diagnostic of SeverityInfo.
+	Identifiers *IdentifierRules
+
+	//	The maximum number of schema documents referenced by xs:includes and xs:imports that LoadSchema fetches and decodes concurrently.
+	//	If 1 or less, they are loaded strictly one after another. Otherwise, Resolver and Fetch (if set) must be safe for concurrent use.
+	MaxConcurrentLoads int `json:"-"`
+
+	//	The maximum number of schemas that GenerateAll loads, and generates Go packages for, concurrently. If 1 or less, one after another.
+	MaxConcurrentGens int `json:"-"`
+
+	//	If set, consulted by LoadSchema for every schema document before any file-system or HTTP access.
+	Resolver SchemaResolver `json:"-"`
+
+	//	If set, consulted by LoadSchema for every schema document (after Resolver, if any) to remap its URI to a local file or another URI before any download.
+	Catalog *Catalog `json:"-"`
+
+	//	If set, used by LoadSchema for all downloads instead of http.DefaultClient, such as to go through a proxy, trust custom TLS roots or present client certificates.
+	HttpClient *http.Client `json:"-"`
+
+	//	If set, used by LoadSchema for all downloads instead of any HTTP client, such as to add authentication headers or retry failed requests.
+	//	Fetch must honor ctx, and return an error (rather than an error page) for unsuccessful responses.
+	Fetch func(ctx context.Context, uri string) (io.ReadCloser, error) `json:"-"`
+
+	//	If greater than zero, the local copies of downloaded schema documents (see LoadSchema with localCopy) are revalidated once they were last
+	//	downloaded or revalidated longer ago than this: by a conditional request carrying the ETag and Last-Modified values recorded in a manifest
+	//	file next to the local copy (named after it, with a ".download.json" suffix), so that they are only downloaded again if modified since.
+	//	(With Fetch, or without such a manifest, they are downloaded again unconditionally.) If zero, existing local copies are never revalidated.
+	DownloadTTL time.Duration `json:"-"`
+
+	//	If true, LoadSchema never downloads anything, but fails fast with an *OfflineError for any schema document that has no local copy yet,
+	//	whose local copy would have to be revalidated (see DownloadTTL), or that is to be loaded without a local copy.
+	Offline bool `json:"-"`
+
+	//	If set, every downloaded schema document whose URI (after remapping by Catalog, if any) has a SHA-256 digest pinned in Checksums is verified
+	//	against it, right after its download (so that a mismatching download does not replace an existing local copy) as well as on every load of its local
+	//	copy, and LoadSchema fails with a *ChecksumError on a mismatch. Digests of other downloaded documents are recorded if Checksums.Record is set.
+	Checksums *Checksums `json:"-"`
+
+	//	If set, its callbacks are called to report the progress of loading schemas and generating Go code from them.
+	Hooks *Hooks `json:"-"`
+
+	//	The callbacks emitting additional Go code into the generated packages, called in this order (see EmitHooks).
+	//	As Cache cannot tell whether they changed, call Cache.Clear() after changing them.
+	EmitHooks []*EmitHooks `json:"-"`
+
+	//	The replacement texts of entities (by name, such as "nbsp") that schema documents may refer to (such as "&nbsp;") besides those predefined by XML.
+	//	These are not parsed for markup. (Go's XML decoder does not process DTDs, so that otherwise only the entities predefined by XML are known.)
+	Entities map[string]string `json:"-"`
+
+	//	If true, schema documents may also refer to the entities of HTML (such as "&nbsp;" or "&eacute;", see xml.HTMLEntity), unless Entities redefines them.
+	HTMLEntities bool `json:"-"`
+
+	//	How LoadSchema treats the DOCTYPE declarations of schema documents, and references to unknown entities: either DocTypesKeep (the default),
+	//	DocTypesResolve or DocTypesStrip.
+	DocTypes string `json:"-"`
+
+	//	If true, imports that are not referenced by the generated Go source are removed from it before it is gofmt-formatted and written.
+	PruneImports bool
+
+	//	If true, MakeGoPkgSrcFile splits the generated package into multiple source files: all declarations generated for a top-level XSD component
+	//	(such as a complex type or element, including its anonymous types) go into a file of their own, named after the XSD file and that component
+	//	(such as "order.xsd.complextype.ordertype.go" next to "order.xsd.go", which keeps all other declarations). Unreferenced imports are always pruned from these files.
+	SplitFiles bool
+
+	//	The text/template sources that the generated Go code is rendered from, initially DefaultTemplates.
+	Templates Templates
+
+	//	One of the JsonTags* constants: if not JsonTagsNone, generated struct fields get json tags alongside their xml tags.
+	JsonTags string
+
+	//	Maps XML namespaces to the Go import paths of existing packages, to be used for xs:imports of these namespaces
+	//	instead of the import paths derived from BasePath and the imported schemaLocation.
+	ImportPaths map[string]string
+
+	//	One of the Groups* constants: whether the struct types generated for complex types embed a shared struct type for every xs:group and
+	//	xs:attributeGroup they refer to (GroupsEmbed, the default), or embed the members of these groups directly (GroupsFlatten).
+	Groups string
+
+	//	Maps the names of xs:groups and xs:attributeGroups to the Groups* constants, overriding Groups for these groups only.
+	GroupModes map[string]string
+
+	//	If not empty, Go code is generated only for the global elements, types, groups and attributes named here (each either as "{namespace}local",
+	//	as "prefix:local" resolved against the namespace declarations of the schema generated for, or as "local" in its target namespace), for all
+	//	the global components these depend on (directly or indirectly, also across xs:imports, see Schema.DependencyGraph), and for all members of
+	//	the substitution groups headed by any of these elements, rather than for every global component of the schema (such as for big standard
+	//	schemas defining thousands of types that an application never uses). Types derived from these are not included unless named here too.
+	//	MakeGoPkgSrcFiles generates the packages of imported schemas for the global components that the schema it was called on depends on.
+	Roots []string
+
+	//	One of the AnonTypeNames* constants: how the Go types generated for anonymous xs:complexTypes and xs:simpleTypes are named, by the path
+	//	of all constructs enclosing them (AnonTypeNamesConstructPath, the default), by that of the enclosing elements and attributes only, additionally
+	//	suffixed with a hash of their location for names that stay stable across regenerations, or sequentially.
+	AnonTypeNames string
+
+	//	One of the AnyTypes* constants: the Go type of the fields for elements of xs:anyType, a string of their character data (AnyTypesString, the default),
+	//	their raw XML (AnyTypesRawXML), interface{} (AnyTypesInterface) or a generic tree (AnyTypesNode). Default or fixed values of these elements are not applied
+	//	unless AnyTypesString.
+	AnyTypes string
+
+	//	One of the AnySimpleTypes* constants: the Go type of the fields for attributes and elements of xs:anySimpleType, a string (AnySimpleTypesString,
+	//	the default) or a variant holding their lexical form that is accessible as any built-in type it is valid for (AnySimpleTypesVariant).
+	AnySimpleTypes string
+
+	//	If true, the doc comment of every generated type, struct field and embedded type notes the location (schema document, line and column)
+	//	of the schema construct it was generated from, such as "Schema source: example.com/order.xsd:12:3 (xs:complexType OrderType)".
+	AddSourceComments bool
+
+	//	If true, a SourceMap linking the generated types, struct fields and embedded types to the locations of the schema constructs they were
+	//	generated from is written as JSON alongside the Go source files, named after the main one with ".map.json" appended.
+	AddSourceMap bool
+
+	//	If true, the contents of the xs:appinfo annotations of the schema constructs that the types, struct fields and embedded types are generated from
+	//	are recorded in a generated XsdGoPkgAppInfo variable, keyed by the names of the types or "Type.Field", for applications to consume at runtime.
+	AddAppInfo bool
+
+	//	If true, a constant is generated for the target namespace (XsdGoPkgTargetNamespace) and for every other namespace declared in the schema,
+	//	along with an XsdGoPkgNamespaces variable of its namespace declarations (see xsdt.Namespaces) and XsdGoPkgName() / XsdGoPkgQName() functions
+	//	returning the names of components, so that code comparing element names or constructing QNames need not hard-code namespace URIs.
+	AddNamespaces bool
+
+	//	If true, a Go fuzz test file is generated alongside the Go source files, named after the main one with ".fuzz_test.go" replacing ".go", holding
+	//	a FuzzXyz() function per (non-abstract) global element of the package. Each checks that decoding its input as an instance of the element and
+	//	re-encoding it, then decoding and re-encoding that, yields the same encoding twice, catching (un)marshaling asymmetries of the generated code
+	//	(with "go test -fuzz"). Sample instances of the elements (see Schema.GenerateInstance) seed the fuzzing. Requires Go 1.18 or later.
+	AddFuzzTests bool
+
+	//	If not empty, the language (such as "en", also matching "en-US") of the xs:documentation elements used for doc comments (and for descriptions in
+	//	JSON Schema, OpenAPI and protobuf output), if an annotation has several: by their xml:lang attribute, or else that of their schema document.
+	//	If none of them is in this language, those without a language are used, or else all of them, as they always are if DocLanguage is empty.
+	DocLanguage string
+
+	//	Maps the target namespaces of schemas to the names, directories and import paths of the Go packages generated for them,
+	//	overriding the defaults derived from their XSD files. The options for "" apply to schemas without a target namespace.
+	Packages map[string]*GoPkgOptions
+
+	//	If set, MakeGoPkgSrcFileAt skips generating (and writing) a Go source file that Cache records as generated from the very same schema documents
+	//	(by a hash of their contents and those of all schemas they include or import) and PkgGen options (other than those only affecting how schemas are loaded).
+	//	Either way, Go source files whose contents did not change are not rewritten, and those that were are recorded in Cache.Regenerated.
+	Cache *GenCache `json:"-"`
+}
+
+//	Overrides the defaults for naming and placing the Go package generated for a schema, see PkgGen.Packages.
+//	Empty fields keep their defaults, and the parameters of MakeGoPkgSrcFileAt (if not empty) take precedence over Name and Dir.
+type GoPkgOptions struct {
+	//	The package name, defaulting to one derived from the XSD file name.
+	Name string
+
+	//	The directory that the package is written to, defaulting to one next to the local copy of the XSD file (named after it with a "_go" suffix).
+	Dir string
+
+	//	The import path that packages generated for xs:importing schemas import the package by, such as a path within your own Go module.
+	//	Defaults to the path of Dir relative to PkgGen.BaseCodePath appended to PkgGen.BasePath (if Dir is inside BaseCodePath),
+	//	or else to PkgGen.BasePath joined with the XSD URI and the "_go" suffix. PkgGen.ImportPaths takes precedence.
+	ImportPath string
 }
 
 type beforeAfterMake interface {
@@ -70,33 +403,63 @@ type PkgBag struct {
 	Schema *Schema
 	Stacks pkgStacks
 
+	gen *Generator
+
 	allAtts       []*Attribute
 	allAttGroups  []*AttributeGroup
 	allElems      []*Element
 	allElemGroups []*Group
 	allNotations  []*Notation
 
+	compiled                                                                                     *Compiled
 	ctd                                                                                          *declType
-	lines                                                                                        []string
-	impName                                                                                      string
+	tmpls                                                                                        *pkgTemplates
+	tmplErr                                                                                      error
+	src                                                                                          *bytes.Buffer
+	splitSrcs                                                                                    map[string]*bytes.Buffer
+	impName, pkgName                                                                             string
 	debug                                                                                        bool
 	imports, attsCache, elemsCacheOnce, elemsCacheMult, simpleBaseTypes, simpleContentValueTypes map[string]string
-	impsUsed, elemsWritten, parseTypes, walkerTypes, declConvs                                   map[string]bool
+	impsUsed, elemsWritten, parseTypes, textTypes, walkerTypes, declConvs                        map[string]bool
 	anonCounts                                                                                   map[string]uint64
+	anonNames                                                                                    map[element]xsdt.NCName // the names given to the anonymous types of the schema by anonName
+	keyIndexed                                                                                   map[element]bool        // the xs:keys and xs:uniques that index methods were generated for (see Element.addKeyIndexes)
+	circular                                                                                     map[element]bool        // the references closing circular definitions, which are not generated (see circularRefs)
+	ctBases                                                                                      map[string]string
+	ctXsiNames                                                                                   map[string]xml.Name
+	stFacets                                                                                     map[string]*xsdt.Facets
+	stLists, stUnions, overrideTypes                                                             map[string]bool
+	nillables                                                                                    map[string]string
+	substHeads                                                                                   map[string]*Element     // the global elements heading substitution groups, keyed by the names of their HasElem_ and HasElems_ types
+	enumAnns                                                                                     map[string]*Annotation  // keyed by the simple-type name and the enumerated value, separated by a NUL
+	mixedTypes                                                                                   map[string]bool         // the names of the struct types declaring an XsdGoPkgMixed field (see PkgGen.PreserveMixedContent)
+	restrictions                                                                                 map[string]*ComplexType // the complex types narrowed by addRestrictions, keyed by the names of their struct types
+	keep                                                                                         map[string]bool         // the DependencyNode IDs of the global components to generate code for (see Schema.rootsClosure), or nil for all
+	dupDecls                                                                                     map[element]element     // the redeclarations of global components, mapped to their first declarations (see Schema.collectDuplicates)
+	nsImps                                                                                       map[string]string       // the Go import names of the packages generated for xs:imports, keyed by their XML namespaces
+	ownSchemas                                                                                   map[*Schema]bool        // the schema documents this package is generated from: its root schema and those it includes
 	attGroups, attGroupRefImps                                                                   map[*AttributeGroup]string
 	attsKeys, attRefImps                                                                         map[*Attribute]string
 	declTypes                                                                                    map[string]*declType
 	declElemTypes                                                                                map[element][]*declType
+	declNames                                                                                    map[string]element
 	declWrittenTypes                                                                             []*declType
+	declEquivalents                                                                              map[string]*declType // the last of the declWrittenTypes with each equivalenceKey
 	elemGroups, elemGroupRefImps                                                                 map[*Group]string
 	elemChoices, elemChoiceRefImps                                                               map[*Choice]string
 	elemSeqs, elemSeqRefImps                                                                     map[*Sequence]string
 	elemKeys, elemRefImps                                                                        map[*Element]string
+	diags                                                                                        Diagnostics
+	diagsReported                                                                                map[string]bool
+	renames                                                                                      map[string]map[string]string // the numbered identifiers of the names of the packages of XML namespaces (see PkgBag.identifiers)
+	elemsMaking                                                                                  []element
+	appInfos                                                                                     map[string][]*AppInfo
+	sources                                                                                      map[string]element // the schema constructs of the generated declarations, keyed by "Type" or "Type.Field" (see sourceOf)
 }
 
-func newPkgBag(schema *Schema) (bag *PkgBag) {
+func newPkgBag(gen *Generator, schema *Schema, pkgName string) (bag *PkgBag) {
 	var newImpname = true
-	bag = &PkgBag{Schema: schema}
+	bag = &PkgBag{Schema: schema, gen: gen}
 	bag.impName = "xsdt"
 	for i := 0; newImpname; i++ {
 		newImpname = false
@@ -110,26 +473,60 @@ func newPkgBag(schema *Schema) (bag *PkgBag) {
 			}
 		}
 		if newImpname {
-			bag.impName = sfmt("xsdt", i)
+			bag.impName = sfmt("xsdt%v", i)
 		}
 	}
-	bag.imports, bag.impsUsed, bag.lines = map[string]string{}, map[string]bool{}, []string{"//\tAuto-generated by the \"go-xsd\" package located at:", "//\t\tgithub.com/metaleap/go-xsd", "//\tComments on types and fields (if any) are from the XSD file located at:", "//\t\t" + bag.Schema.loadUri, "package go_" + bag.safeName(ustr.Replace(path.Base(bag.Schema.RootSchema([]string{bag.Schema.loadUri}).loadUri), map[string]string{"xsd": "", "schema": ""})), ""}
+	bag.imports, bag.impsUsed, bag.src = map[string]string{}, map[string]bool{}, &bytes.Buffer{}
+	bag.nsImps, bag.diagsReported, bag.ownSchemas = map[string]string{}, map[string]bool{}, map[*Schema]bool{}
+	for _, s := range schema.allSchemas(map[string]bool{}) {
+		bag.ownSchemas[s] = true
+		for _, imp := range s.Imports {
+			for _, k := range sortedKeys(s.XMLNamespaces) {
+				if (len(k) > 0) && (len(bag.nsImps[imp.Namespace]) == 0) && (s.XMLNamespaces[k] == imp.Namespace) {
+					bag.nsImps[imp.Namespace] = safeIdentifier(k)
+				}
+			}
+		}
+	}
+	if len(pkgName) == 0 {
+		pkgName = "go_" + bag.safeName(ustr.Replace(path.Base(bag.Schema.RootSchema([]string{bag.Schema.loadUri}).loadUri), map[string]string{"xsd": "", "schema": ""}))
+	}
+	if bag.tmpls, bag.tmplErr = bag.gen.Templates.parse(); bag.tmplErr == nil {
+		bag.appendTmpl(bag.tmpls.fileHeader, &TmplFileHeader{SchemaUri: bag.Schema.loadUri, PkgName: pkgName})
+	}
+	bag.pkgName = pkgName
 	bag.imports[bag.impName] = "github.com/metaleap/go-xsd/types"
-	bag.anonCounts, bag.declTypes, bag.declElemTypes = map[string]uint64{}, map[string]*declType{}, map[element][]*declType{}
+	bag.anonCounts, bag.declTypes, bag.declElemTypes, bag.declEquivalents = map[string]uint64{}, map[string]*declType{}, map[element][]*declType{}, map[string]*declType{}
+	bag.ctBases, bag.ctXsiNames = map[string]string{}, map[string]xml.Name{}
+	bag.stFacets, bag.stLists, bag.nillables, bag.enumAnns = map[string]*xsdt.Facets{}, map[string]bool{}, map[string]string{}, map[string]*Annotation{}
+	bag.substHeads, bag.mixedTypes, bag.restrictions = map[string]*Element{}, map[string]bool{}, map[string]*ComplexType{}
 	bag.simpleContentValueTypes, bag.attsCache, bag.elemsCacheOnce, bag.elemsCacheMult, bag.simpleBaseTypes = map[string]string{}, map[string]string{}, map[string]string{}, map[string]string{}, map[string]string{}
 	bag.attGroups, bag.attGroupRefImps = map[*AttributeGroup]string{}, map[*AttributeGroup]string{}
 	bag.attsKeys, bag.attRefImps = map[*Attribute]string{}, map[*Attribute]string{}
 	bag.elemGroups, bag.elemGroupRefImps = map[*Group]string{}, map[*Group]string{}
 	bag.elemKeys, bag.elemRefImps = map[*Element]string{}, map[*Element]string{}
 	bag.elemsWritten, bag.parseTypes, bag.walkerTypes, bag.declConvs = map[string]bool{}, map[string]bool{}, map[string]bool{}, map[string]bool{}
+	bag.textTypes, bag.stUnions, bag.overrideTypes = map[string]bool{}, map[string]bool{}, map[string]bool{}
+	bag.declNames, bag.splitSrcs = map[string]element{}, map[string]*bytes.Buffer{}
+	bag.anonNames, bag.keyIndexed = map[element]xsdt.NCName{}, map[element]bool{}
+	bag.sources, bag.appInfos = map[string]element{}, map[string][]*AppInfo{}
 	for _, pt := range []string{"Boolean", "Byte", "Double", "Float", "Int", "Integer", "Long", "NegativeInteger", "NonNegativeInteger", "NonPositiveInteger", "PositiveInteger", "Short", "UnsignedByte", "UnsignedInt", "UnsignedLong", "UnsignedShort"} {
 		bag.parseTypes[bag.impName+"."+pt] = true
 	}
+	for _, tt := range typedBuiltins {
+		bag.textTypes[bag.impName+"."+tt] = true
+	}
+	bag.textTypes[bag.impName+".AnySimpleValue"] = true
 	bag.addType(nil, idPrefix+"HasCdata", "").addField(nil, idPrefix+"CDATA", "string", ",chardata")
 	return
 }
 
 func (me *PkgBag) addType(elem element, n, t string, a ...*Annotation) (dt *declType) {
+	if first := me.declNames[n]; (first == nil) && (elem != nil) {
+		me.declNames[n] = elem
+	} else if (first != nil) && (elem != nil) && (first != elem) {
+		me.report(elem, SeverityWarning, "duplicate name: %s is declared more than once, only one declaration is generated", n)
+	}
 	dt = &declType{elem: elem, Name: n, Type: t, Annotations: a}
 	dt.Embeds, dt.Fields, dt.Methods, dt.memberWritten = map[string]*declEmbed{}, map[string]*declField{}, map[string]*declMethod{}, map[string]bool{}
 	me.ctd, me.declTypes[n] = dt, dt
@@ -139,6 +536,48 @@ func (me *PkgBag) addType(elem element, n, t string, a ...*Annotation) (dt *decl
 	return
 }
 
+//	Returns the compiled component model (see Schema.Compile) of the schema set this package is generated from, compiling it on first use.
+func (me *PkgBag) compile() *Compiled {
+	if me.compiled == nil {
+		me.compiled = compileSchemas(me.Schema.RootSchema([]string{me.Schema.loadUri}))
+	}
+	return me.compiled
+}
+
+//	Returns the global components of the schema set this package is generated from.
+func (me *PkgBag) components() *schemaComponents {
+	return me.compile().comps
+}
+
+//	Records a Diagnostic for the schema construct el or, if nil, for the one currently being processed by makePkg.
+//	Identical diagnostics for the same construct are recorded only once.
+func (me *PkgBag) report(el element, severity Severity, format string, fmtArgs ...interface{}) {
+	me.addDiagnostic(el, &Diagnostic{Severity: severity, Message: sfmt(format, fmtArgs...)})
+}
+
+//	Reports the construct el as ignored, see Diagnostics.Ignored.
+func (me *PkgBag) reportIgnored(el element, format string, fmtArgs ...interface{}) {
+	me.addDiagnostic(el, &Diagnostic{Severity: SeverityWarning, Message: "ignored construct: " + sfmt(format, fmtArgs...), Ignored: el.base().xsdName.String()})
+}
+
+//	Records d, positioned at el (or else at the construct being generated), unless an identical Diagnostic was recorded before.
+func (me *PkgBag) addDiagnostic(el element, d *Diagnostic) {
+	if (el == nil) && (len(me.elemsMaking) > 0) {
+		el = me.elemsMaking[len(me.elemsMaking)-1]
+	}
+	if sd := me.Schema; el != nil {
+		if sd, d.Line, d.Column = el.base().position(); sd == nil {
+			sd = me.Schema
+		}
+		d.File = ustr.Ifs(len(sd.loadLocalPath) > 0, sd.loadLocalPath, sd.loadUri)
+	} else if sd != nil {
+		d.File = ustr.Ifs(len(sd.loadLocalPath) > 0, sd.loadLocalPath, sd.loadUri)
+	}
+	if key := d.Error(); !me.diagsReported[key] {
+		me.diagsReported[key], me.diags = true, append(me.diags, d)
+	}
+}
+
 func (me *PkgBag) AnonName(n string) (an xsdt.NCName) {
 	var c uint64
 	n = "Txsd" + n
@@ -150,14 +589,519 @@ func (me *PkgBag) AnonName(n string) (an xsdt.NCName) {
 	return
 }
 
+//	Adds MarshalXML() / UnmarshalXML() methods to all complex types recorded in ctBases (be it as deriving or as derived-from types) if PkgGen.AddXsiTypeMethods is set,
+//	and to all struct types having default or fixed values (see hasDefaults) if PkgGen.ApplyDefaults is set. Also declares the wrapper types recorded by nillableType.
+//	For a type having derived types in this package, these dispatch on xsi:type to the derived type (kept in its XsdGoPkgXsiType field) and back.
+//	All other derivation-related types need these methods only so as not to inherit those of their base type via embedding.
+func (me *PkgBag) addMarshalMethods() {
+	var derived = map[string][]string{}
+	for tn, _ := range me.ctBases {
+		for bn, depth := me.ctBases[tn], 0; (len(bn) > 0) && (depth < 64); bn, depth = me.ctBases[bn], depth+1 {
+			if me.declTypes[bn] != nil {
+				derived[bn] = append(derived[bn], tn)
+			}
+		}
+	}
+	var xmlImp = me.xmlImpName()
+	for _, tn := range sortedKeys(me.nillables) {
+		me.imports[xmlImp], me.impsUsed[xmlImp], me.impsUsed[me.impName] = "encoding/xml", true, true
+		dt := me.addType(nil, tn, "")
+		dt.addField(nil, "Value", me.nillables[tn], ",chardata")
+		dt.addField(nil, "Nil", "bool", xsdt.XsiNamespace+" nil,attr")
+		dt.addMethod(nil, "*"+tn, sfmt("MarshalXML (enc *%s.Encoder, start %s.StartElement)", xmlImp, xmlImp), "(err error)", sfmt("\n\tif me.Nil {\n\t\treturn enc.EncodeElement(struct{}{}, %s.XsiNilStart(start))\n\t}\n\treturn enc.EncodeElement(&me.Value, start)\n", me.impName), "Implements xml.Marshaler: encodes an empty element with xsi:nil=\"true\" if Nil is set, or else Value.")
+		dt.addMethod(nil, "*"+tn, sfmt("UnmarshalXML (dec *%s.Decoder, start %s.StartElement)", xmlImp, xmlImp), "(err error)", sfmt("\n\tif me.Nil = %s.XsiNil(start); me.Nil {\n\t\treturn dec.Skip()\n\t}\n\treturn dec.DecodeElement(&me.Value, &start)\n", me.impName), "Implements xml.Unmarshaler: sets Nil if start has xsi:nil=\"true\", or else decodes Value.")
+	}
+	for tn, dt := range me.declTypes {
+		_, isBase := derived[tn]
+		isXsi := me.gen.AddXsiTypeMethods && (isBase || (len(me.ctBases[tn]) > 0))
+		isPart := strings.HasPrefix(tn, idPrefix+"Has") || strings.HasPrefix(tn, idPrefix+"Choice_")
+		dflt := me.gen.ApplyDefaults && !me.gen.LexicalFidelity && !isPart && me.hasDefaults(tn, false, 0)
+		fixed := dflt && me.hasDefaults(tn, true, 0)
+		var substs []string
+		if !isPart {
+			substs = me.substDecoders(tn, 0)
+		}
+		mixed := me.isMixed(tn)
+		if (len(dt.Type) == 0) && (isXsi || dflt || mixed || (len(substs) > 0)) {
+			me.imports[xmlImp], me.impsUsed[xmlImp], me.impsUsed[me.impName] = "encoding/xml", true, true
+			marshal, unmarshal, marshalDoc, unmarshalDoc, restricted := "", "", "", "", false
+			if isXsi {
+				sort.Strings(derived[tn])
+				for _, dn := range derived[tn] {
+					if xn := me.ctXsiNames[dn]; len(xn.Local) > 0 {
+						marshal += sfmt("\n\tcase *%s:\n\t\treturn enc.EncodeElement(x, %s.XsiTypeStart(start, %#v, %#v))", dn, me.impName, xn.Space, xn.Local)
+						if me.embedsBase(dn, tn) {
+							unmarshal += sfmt("\n\tcase %#v:\n\t\tvar x %s\n\t\tif err = dec.DecodeElement(&x, &start); err == nil {\n\t\t\t*me = x.%s\n\t\t\tme.%sXsiType = &x\n\t\t}\n\t\treturn", xn.Local, dn, tn, idPrefix)
+						} else {
+							restricted = true
+							unmarshal += sfmt("\n\tcase %#v:\n\t\tvar x %s\n\t\tif err = dec.DecodeElement(&x, &start); err == nil {\n\t\t\tme.%sXsiType = &x\n\t\t}\n\t\treturn", xn.Local, dn, idPrefix)
+						}
+					}
+				}
+			}
+			plain := sfmt("struct { *%s; %s.XsiShadow }{%s: me}", tn, me.impName, tn)
+			decode := sfmt("dec.DecodeElement(&%s, &start)", plain)
+			if len(substs) > 0 {
+				decode = sfmt("%s.DecodeSubstitutes(dec, start, &%s, %s)", me.impName, plain, strings.Join(substs, ", "))
+			}
+			if mixed {
+				decode = sfmt("%s.DecodeMixed(dec, start, &me.%sMixed, func(dec *%s.Decoder, start %s.StartElement) error {\n\t\treturn %s\n\t})", me.impName, idPrefix, xmlImp, xmlImp, decode)
+			}
+			if len(marshal) > 0 {
+				dt.addField(nil, idPrefix+"XsiType", "interface{}", "-")
+				marshal = sfmt("\n\tswitch x := me.%sXsiType.(type) {%s\n\t}", idPrefix, marshal)
+				unmarshal = sfmt("\n\tswitch %s.XsiType(start) {%s\n\t}", me.impName, unmarshal)
+				marshalDoc = sfmt("Implements xml.Marshaler: if its %sXsiType field is set (ie. to a derived-type instance, such as by UnmarshalXML), encodes that instance instead along with the corresponding xsi:type attribute. Otherwise, encodes this %s instance as usual.", idPrefix, tn)
+				unmarshalDoc = sfmt("Implements xml.Unmarshaler: if the xsi:type attribute of start denotes a type derived from %s, decodes into an instance of that type, stores it in the %sXsiType field and copies over its %s portion%s. Otherwise, decodes this %s instance as usual.", tn, idPrefix, tn, ustr.Ifs(restricted, " (if it embeds one, unlike types narrowed by restriction)", ""), tn)
+			} else {
+				marshalDoc = sfmt("Implements xml.Marshaler by encoding this %s instance as usual%s.", tn, ustr.Ifs(isXsi, " (rather than as its base type would)", ""))
+				unmarshalDoc = sfmt("Implements xml.Unmarshaler by decoding this %s instance as usual%s.", tn, ustr.Ifs(isXsi, " (rather than as its base type would)", ""))
+			}
+			if fixed {
+				marshal += "\n\tx := *me\n\tif err = x.ApplyFixed(); err != nil {\n\t\treturn\n\t}\n\tme = &x"
+				marshalDoc += " Fields having fixed values are encoded with these (see ApplyFixed), without modifying this instance."
+			}
+			if decoders := strings.Join(substs, " "); strings.Contains(decoders, ".DecodeSubstitute") {
+				unmarshalDoc += " Child elements of substitution groups are decoded in document order into their (interface-typed) fields, each as the type of its own element."
+			}
+			if decoders := strings.Join(substs, " "); strings.Contains(decoders, ".DecodeAlternative") {
+				unmarshalDoc += " Child elements of xs:choice alternatives are decoded into their choice union fields, which record the alternative present."
+			}
+			if mixed {
+				marshalDoc += sfmt(" Its text segments and child elements are encoded in the order recorded by its %sMixed field, if any (see xsdt.EncodeMixed).", idPrefix)
+				unmarshalDoc += sfmt(" Its character data and child elements are recorded in its %sMixed field, in document order.", idPrefix)
+			}
+			if dflt {
+				unmarshal += sfmt("\n\tif err = %s; err == nil {\n\t\tme.ApplyDefaults()\n\t}\n\treturn", decode)
+				unmarshalDoc += " Then sets all fields still holding zero values to their default or fixed values (see ApplyDefaults)."
+			} else {
+				unmarshal += sfmt("\n\treturn %s", decode)
+			}
+			encode := sfmt("enc.EncodeElement(%s, start)", plain)
+			if mixed {
+				encode = sfmt("%s.EncodeMixed(enc, start, %s, me.%sMixed)", me.impName, plain, idPrefix)
+			}
+			dt.addMethod(nil, "*"+tn, sfmt("MarshalXML (enc *%s.Encoder, start %s.StartElement)", xmlImp, xmlImp), "(err error)", sfmt("%s\n\treturn %s\n", marshal, encode), marshalDoc)
+			dt.addMethod(nil, "*"+tn, sfmt("UnmarshalXML (dec *%s.Decoder, start %s.StartElement)", xmlImp, xmlImp), "(err error)", unmarshal+"\n", unmarshalDoc)
+		}
+	}
+}
+
+//	Returns whether the struct type of the specified name declares (or embeds, via the complex types it derives from by extension) an XsdGoPkgMixed field.
+func (me *PkgBag) isMixed(tn string) bool {
+	for depth := 0; (len(tn) > 0) && (depth < 64); tn, depth = me.ctBases[tn], depth+1 {
+		if me.mixedTypes[tn] {
+			return true
+		} else if me.restrictions[tn] != nil {
+			break
+		}
+	}
+	return false
+}
+
+//	Returns the import name of encoding/xml in the generated package: "xml", unless a schema namespace prefix already takes that name.
+func (me *PkgBag) xmlImpName() (xmlImp string) {
+	xmlImp = "xml"
+	for i := 0; (len(me.imports[xmlImp]) > 0) && (me.imports[xmlImp] != "encoding/xml"); i++ {
+		xmlImp = sfmt("xml%v", i)
+	}
+	return
+}
+
+//	Rewrites the HasElem_ and HasElems_ types recorded in substHeads if all non-abstract elements of their substitution groups have types of this package:
+//	instead of a field of the head element's type and an embedded struct per member element, they then get a single field of the XsdGoPkgSubsts_<head> type,
+//	a slice of the XsdGoPkgSubst_<head> interface that the types of all these elements implement. Its MarshalXML() method encodes every instance
+//	as the element of its type, and its DecodeSubstitute() method is called by the UnmarshalXML() methods of the struct types embedding the field (see substDecoders).
+//	Should several elements of a group share a type, instances of that type are encoded as the first of these elements.
+func (me *PkgBag) addSubstitutionGroups() {
+	var tns []string
+	for tn, _ := range me.substHeads {
+		tns = append(tns, tn)
+	}
+	sort.Strings(tns)
+	var groupNames = map[string][]xml.Name{}
+	var groupTypes = map[string][]string{}
+	for _, tn := range tns {
+		var elNames []xml.Name
+		var elTypes []string
+		for _, el := range me.substGroup(me.substHeads[tn]) {
+			var f *declField
+			if cont := me.declTypes[idPrefix+"HasElem_"+me.safeName(el.Name.String())]; cont != nil {
+				f = cont.Fields[me.safeName(el.Name.String())]
+			}
+			if f == nil {
+				elTypes = nil
+				break
+			}
+			if t := strings.TrimPrefix(f.Type, "*"); strings.Contains(t, ".") || strings.HasPrefix(t, idPrefix) || (me.declTypes[t] == nil) {
+				elTypes = nil
+				break
+			} else if pos := strings.LastIndex(f.XmlTag, " "); pos >= 0 {
+				elNames, elTypes = append(elNames, xml.Name{Space: f.XmlTag[:pos], Local: f.XmlTag[pos+1:]}), append(elTypes, t)
+			} else {
+				elNames, elTypes = append(elNames, xml.Name{Local: f.XmlTag}), append(elTypes, t)
+			}
+		}
+		groupNames[tn], groupTypes[tn] = elNames, elTypes
+	}
+	for _, tn := range tns {
+		var elNames, elTypes, head = groupNames[tn], groupTypes[tn], me.substHeads[tn]
+		if len(elTypes) == 0 {
+			continue
+		}
+		var xmlImp = me.xmlImpName()
+		me.imports[xmlImp], me.impsUsed[xmlImp], me.impsUsed[me.impName] = "encoding/xml", true, true
+		name := me.safeName(head.Name.String())
+		iface, list, marker := idPrefix+"Subst_"+name, idPrefix+"Substs_"+name, idPrefix+"Substitutes_"+name
+		if me.declTypes[list] == nil {
+			var cases string
+			var elLocals []string
+			for i, t := range elTypes {
+				if dt := me.declTypes[t]; dt.Methods[marker] == nil {
+					dt.addMethod(nil, "*"+t, marker, xmlImp+".Name", sfmt("return %s.Name{Space: %#v, Local: %#v}", xmlImp, elNames[i].Space, elNames[i].Local), sfmt("Implements %s: returns the name of the %s element, which instances of %s are encoded as.", iface, elNames[i].Local, t))
+				}
+				cases += sfmt("\n\tcase %s.Name{Space: %#v, Local: %#v}:\n\t\tv = new(%s)", xmlImp, elNames[i].Space, elNames[i].Local, t)
+				elLocals = append(elLocals, elNames[i].Local)
+			}
+			me.addType(nil, iface, sfmt("interface { %s () %s.Name }", marker, xmlImp), docAnnotation(sfmt("Implemented by the types of the elements of the substitution group headed by %s: %s.", head.Name, strings.Join(elLocals, ", "))))
+			lt := me.addType(nil, list, "[]"+iface, docAnnotation(sfmt("Holds the elements of the substitution group headed by %s in document order, each as an instance of the type of its own element.", head.Name)))
+			lt.addMethod(nil, "*"+list, sfmt("DecodeSubstitute (dec *%s.Decoder, start %s.StartElement)", xmlImp, xmlImp), "(ok bool, err error)", sfmt("\n\tvar v %s\n\tswitch start.Name {%s\n\tdefault:\n\t\treturn\n\t}\n\tif err = dec.DecodeElement(v, &start); err == nil {\n\t\t*me = append(*me, v)\n\t}\n\treturn true, err\n", iface, cases), sfmt("If start is an element of the substitution group headed by %s, decodes it into a new instance of the type of that element and appends it. Called by the UnmarshalXML() methods of the struct types holding a %s.", head.Name, list))
+			lt.addMethod(nil, list, sfmt("MarshalXML (enc *%s.Encoder, start %s.StartElement)", xmlImp, xmlImp), "(err error)", sfmt("\n\tfor _, v := range me {\n\t\tif v != nil {\n\t\t\tif err = enc.EncodeElement(v, %s.StartElement{Name: v.%s()}); err != nil {\n\t\t\t\treturn\n\t\t\t}\n\t\t}\n\t}\n\treturn\n", xmlImp, marker), "Implements xml.Marshaler: encodes every instance as the element of its type (disregarding start).")
+			if me.gen.AddValidators {
+				me.impsUsed[me.impName] = true
+				lt.addMethod(nil, list, "Validate", "(err error)", sfmt("\n\tfor _, v := range me {\n\t\tif err = %s.ValidateValue(v); err != nil {\n\t\t\treturn\n\t\t}\n\t}\n\treturn\n", me.impName), "Calls the Validate() method (if any) on all instances, returning the first error encountered.")
+			}
+			if me.gen.AddWalkers {
+				errCheck := sfmt("%s.OnWalkError(&err, &WalkErrors, WalkContinueOnError, WalkOnError) { return }", me.impName)
+				me.walkerTypes[list], me.impsUsed[me.impName] = true, true
+				lt.addMethod(nil, "*"+list, "Walk", "(err error)", sfmt("\n\tif fn := WalkHandlers.%s; me != nil {\n\t\tif fn != nil { if err = fn(me, true); %s }\n\t\tfor _, v := range *me { if w, ok := v.(interface{ Walk() error }); ok { if err = w.Walk(); %s } }\n\t\tif fn != nil { if err = fn(me, false); %s }\n\t}\n\treturn\n", list, errCheck, errCheck, errCheck), sfmt("If the WalkHandlers.%v function is not nil (ie. was set by outside code), calls it with this %v instance as the single argument. Then calls the Walk() method (if any) on all instances.", list, list))
+			}
+		}
+		td := me.declTypes[tn]
+		fields := td.sortedFields()
+		td.Fields, td.Embeds, td.defaults, td.fixeds = map[string]*declField{}, map[string]*declEmbed{}, nil, nil
+		for _, f := range fields {
+			td.addField(f.elem, f.Name, list, f.XmlTag, f.Annotations...)
+		}
+	}
+}
+
+//	Returns the non-abstract elements of the substitution group headed by head (including head itself), in breadth-first order.
+func (me *PkgBag) substGroup(head *Element) (els []*Element) {
+	var seen = map[*Element]bool{head: true}
+	for todo := []*Element{head}; len(todo) > 0; todo = todo[1:] {
+		if !todo[0].Abstract {
+			els = append(els, todo[0])
+		}
+		for _, el := range me.globalSubstitutionElems(todo[0]) {
+			if !seen[el] {
+				seen[el], todo = true, append(todo, el)
+			}
+		}
+	}
+	return
+}
+
+//	Returns the DecodeSubstitute() methods (see addSubstitutionGroups) of the substitution group fields and the DecodeAlternative() methods (see addChoiceUnions)
+//	of the choice union fields of the declared struct type tn and of its embeds, to be called by its UnmarshalXML() method.
+func (me *PkgBag) substDecoders(tn string, depth int) (decoders []string) {
+	if dt := me.declTypes[tn]; (dt != nil) && (depth < 64) {
+		for _, f := range dt.sortedFields() {
+			if strings.HasPrefix(f.Type, idPrefix+"Substs_") {
+				decoders = append(decoders, "me."+f.Name+".DecodeSubstitute")
+			} else if strings.HasPrefix(f.Type, idPrefix+"Choice_") {
+				decoders = append(decoders, "me."+f.Name+".DecodeAlternative")
+			}
+		}
+		for _, e := range dt.sortedEmbeds() {
+			decoders = append(decoders, me.substDecoders(e.Name, depth+1)...)
+		}
+	}
+	return
+}
+
+//	Returns the name of the struct type wrapping a value of the type typeName for nillable elements: a nil pointer to it denotes an absent element,
+//	its Nil field an element with xsi:nil="true", its Value field the value of any other element. It is declared by addMarshalMethods.
+func (me *PkgBag) nillableType(typeName string) (tn string) {
+	tn = idPrefix + "Nillable_" + me.safeName(typeName)
+	me.nillables[tn] = typeName
+	return
+}
+
+//	Renders a NewXyz() constructor function (see PkgGen.AddConstructors) for every struct type rendered for a complex type, after all types are rendered
+//	so that the names of the embeds that its fields are set through are final. Its parameters are in the document order of the XSD components declaring them.
+func (me *PkgBag) addConstructors() {
+	for _, dt := range me.declWrittenTypes {
+		if _, isCt := dt.elem.(*ComplexType); isCt && (len(dt.Type) == 0) {
+			var params, assigns []string
+			me.ctorParams(dt, "me", map[string]bool{"me": true}, &params, &assigns, 0)
+			if dflt := me.gen.ApplyDefaults && me.hasDefaults(dt.Name, false, 0); dflt || (len(params) > 0) {
+				me.renderSplit(dt.elem, func() {
+					me.appendFmt(false, "//\tReturns a new %s%s%s.", dt.Name, ustr.Ifs(len(params) > 0, " with its required XSD attributes and elements set to the specified values", ""), ustr.Ifs(dflt, ustr.Ifs(len(params) > 0, " and", "")+" with all other fields having default or fixed values set to these (see ApplyDefaults)", ""))
+					me.appendFmt(true, "func New%s (%s) (me *%s) {\n\tme = &%s{}%s%s\n\treturn\n}", dt.Name, strings.Join(params, ", "), dt.Name, dt.Name, ustr.Ifs(len(assigns) > 0, "\n\t"+strings.Join(assigns, "\n\t"), ""), ustr.Ifs(dflt, "\n\tme.ApplyDefaults()", ""))
+				})
+			}
+		}
+	}
+}
+
+//	Calls render, which appends lines after all types are rendered, and moves these lines to the split file of el (see splitFileName) if PkgGen.SplitFiles is set.
+func (me *PkgBag) renderSplit(el element, render func()) {
+	var src = me.src
+	me.src = &bytes.Buffer{}
+	render()
+	if fileName := me.splitFileName(el); me.gen.SplitFiles && (len(fileName) > 0) {
+		me.splitSrc(fileName).Write(me.src.Bytes())
+	} else {
+		src.Write(me.src.Bytes())
+	}
+	me.src = src
+}
+
+//	Returns the source generated so far for the split file fileName (see splitFileName), pre-sized for the declarations of a few types.
+func (me *PkgBag) splitSrc(fileName string) (src *bytes.Buffer) {
+	if src = me.splitSrcs[fileName]; src == nil {
+		src = bytes.NewBuffer(make([]byte, 0, 8*srcBytesPerType))
+		me.splitSrcs[fileName] = src
+	}
+	return
+}
+
+//	Appends the constructor parameters (and the statements setting the fields denoted by path to them) for the required attributes and elements declared by the embeds of the
+//	struct type dt, including those of its base type and of the attribute groups and required element groups it refers to. Types of other packages are not looked into.
+func (me *PkgBag) ctorParams(dt *declType, path string, used map[string]bool, params, assigns *[]string, depth int) {
+	for _, e := range dt.positionalEmbeds() {
+		var required, recurse bool
+		etn := e.typeName(me)
+		edt := me.declTypes[etn]
+		if (edt == nil) || (depth > 64) {
+			continue
+		}
+		switch el := e.elem.(type) {
+		case nil, *AttributeGroup:
+			recurse = true
+		case *Group:
+			recurse = particleRequired(el)
+		case *Attribute:
+			required = el.Use == "required"
+		case *Element:
+			required = particleRequired(el)
+			for _, ref := range groupRefs(nil, e.via) {
+				required = required && particleRequired(ref)
+			}
+		}
+		if recurse {
+			me.ctorParams(edt, path+"."+etn, used, params, assigns, depth+1)
+		} else if required {
+			for _, f := range edt.sortedFields() {
+				name := ctorParamName(f.Name, used)
+				if f.presence {
+					*params, *assigns = append(*params, name+" "+strings.TrimPrefix(f.typeName(me), "*")), append(*assigns, sfmt("%s.%s.%s = &%s", path, etn, f.Name, name))
+				} else {
+					*params, *assigns = append(*params, name+" "+f.typeName(me)), append(*assigns, sfmt("%s.%s.%s = %s", path, etn, f.Name, name))
+				}
+			}
+		}
+	}
+}
+
+//	Returns whether the specified element (or element group reference) must occur in every instance of the complex type (or named element group) it is declared in:
+//	that is, whether neither it nor any of its enclosing compositors has a minOccurs of 0, and none of these is an xs:choice.
+func particleRequired(particle element) bool {
+	return particlesRequired(particleChain(particle, nil))
+}
+
+//	Returns the constructor parameter name for the field fieldName, such as "shipTo" for "ShipTo" or "id" for "ID", made distinct from Go keywords and from the names already used.
+func ctorParamName(fieldName string, used map[string]bool) (name string) {
+	words := jsonTagWords(strings.TrimPrefix(fieldName, idPrefix))
+	if name = strings.ToLower(words[0]) + strings.Join(words[1:], ""); token.Lookup(name).IsKeyword() {
+		name += "_"
+	}
+	for base, i := name, 2; used[name]; i++ {
+		name = sfmt("%s%d", base, i)
+	}
+	used[name] = true
+	return
+}
+
+//	Returns whether the declared type tn, or any type of this package embedded in it (directly or indirectly), has fields with default or fixed values (if fixed, only the latter).
+func (me *PkgBag) hasDefaults(tn string, fixed bool, depth int) bool {
+	if dt := me.declTypes[tn]; (dt != nil) && (depth < 64) {
+		if (fixed && (len(dt.fixeds) > 0)) || ((!fixed) && (len(dt.defaults) > 0)) {
+			return true
+		}
+		for _, e := range dt.Embeds {
+			if me.hasDefaults(e.Name, fixed, depth+1) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+//	Adds Validate() methods to all simple types restricted by facets, be it directly or via their base types.
+//	(Struct types get their Validate() methods as they are rendered, see declType.render.)
+func (me *PkgBag) addSimpleTypeValidators() {
+	for tn, dt := range me.declTypes {
+		if (len(dt.Type) > 0) && me.isFacetedType(tn) {
+			body, bt := "", me.simpleBaseTypes[tn]
+			if me.isFacetedType(bt) || me.isForeignType(bt) {
+				body, me.impsUsed[me.impName] = sfmt("\n\tif err = %s.ValidateValue(%s(me)); err != nil {\n\t\treturn\n\t}", me.impName, bt), true
+			}
+			if lit := me.facetsLiteral(tn); len(lit) > 0 {
+				body += sfmt("\n\terr = (&%s).Check(me.String())", lit)
+			}
+			dt.addMethod(nil, tn, "Validate", "(err error)", body+"\n\treturn\n", sfmt("Returns a *%s.FacetError if this %s value violates any of the XSD facets restricting it or its base type %s.", me.impName, tn, bt))
+		}
+	}
+}
+
+//	Returns the Go composite literal of the xsdt.Facets recorded for the specified simple type, or "" if it has no facets.
+func (me *PkgBag) facetsLiteral(tn string) (lit string) {
+	var fields []string
+	if f := me.stFacets[tn]; f != nil {
+		if len(f.Enumerations) > 0 {
+			fields = append(fields, sfmt("Enumerations: %#v", f.Enumerations))
+		}
+		for _, facet := range [][2]string{{"Pattern", f.Pattern}, {"Length", f.Length}, {"MinLength", f.MinLength}, {"MaxLength", f.MaxLength}, {"TotalDigits", f.TotalDigits}, {"FractionDigits", f.FractionDigits}, {"MinInclusive", f.MinInclusive}, {"MaxInclusive", f.MaxInclusive}, {"MinExclusive", f.MinExclusive}, {"MaxExclusive", f.MaxExclusive}} {
+			if len(facet[1]) > 0 {
+				fields = append(fields, sfmt("%s: %#v", facet[0], facet[1]))
+			}
+		}
+		if len(fields) > 0 {
+			whiteSpace, bt := f.WhiteSpace, me.simpleBaseTypes[tn]
+			for depth := 0; me.isLocalType(bt) && (depth < 64); depth++ {
+				bt = me.simpleBaseTypes[bt]
+			}
+			if strings.HasPrefix(bt, me.impName+".") && (bt != me.impName+".String") && (bt != me.impName+".NormalizedString") {
+				whiteSpace = "collapse"
+			}
+			if len(whiteSpace) > 0 {
+				fields = append(fields, sfmt("WhiteSpace: %#v", whiteSpace))
+			}
+			lit = sfmt("%s.Facets{%s}", me.impName, strings.Join(fields, ", "))
+		}
+	}
+	return
+}
+
+//	Returns whether the specified type, or any of its base types declared in this package, is a simple type restricted by facets (other than whiteSpace).
+//	Types derived from lists are not, as their length facets denote the number of list items.
+func (me *PkgBag) isFacetedType(tn string) (faceted bool) {
+	for depth := 0; me.isLocalType(tn) && (depth < 64); tn, depth = me.simpleBaseTypes[tn], depth+1 {
+		if me.stLists[tn] {
+			return false
+		}
+		faceted = faceted || (len(me.facetsLiteral(tn)) > 0)
+	}
+	return
+}
+
+//	Returns whether the specified type name is qualified by the import name of another generated wrapper package.
+func (me *PkgBag) isForeignType(tn string) bool {
+	return strings.Contains(tn, ".") && !strings.HasPrefix(tn, me.impName+".")
+}
+
+//	Returns the TmplEnumType for the specified simple type if it is restricted by enumeration facets, or nil if it is not (or if any of its enumerated values cannot be expressed as a Go constant of its underlying type).
+func (me *PkgBag) enumType(tn string) (enumType *TmplEnumType) {
+	var f *xsdt.Facets
+	if f = me.stFacets[tn]; (f == nil) || (len(f.Enumerations) == 0) {
+		return
+	}
+	bt := me.simpleBaseTypes[tn]
+	for depth := 0; me.isLocalType(bt) && (depth < 64); depth++ {
+		bt = me.simpleBaseTypes[bt]
+	}
+	if me.isForeignType(bt) || me.textTypes[bt] {
+		return
+	}
+	enumType = &TmplEnumType{TypeName: tn, XsdtPkg: me.impName, Constraint: sfmt("%q", f.Enumerations)}
+	literals, names := map[string]bool{}, map[string]bool{}
+	for _, value := range f.Enumerations {
+		lit, ok := enumLiteral(strings.TrimPrefix(bt, me.impName+"."), value)
+		if !ok {
+			return nil
+		}
+		if !literals[lit] {
+			base := tn + ustr.Ifs(len(me.safeName(value)) > 0, me.safeName(value), "Empty")
+			name := base
+			for i := 2; names[name] || (me.declTypes[name] != nil); i++ {
+				name = sfmt("%s_%d", base, i)
+			}
+			literals[lit], names[name] = true, true
+			enumType.Values = append(enumType.Values, TmplEnumValue{Doc: me.docLines([]*Annotation{me.enumAnns[tn+"\x00"+value]}), Name: name, Literal: lit, Value: value})
+		}
+	}
+	return
+}
+
+//	Returns the Go literal for the specified enumerated value of a simple type ultimately based on the specified xsdt type, or false if it has no valid one (such as "INF" for a xs:double).
+func enumLiteral(xsdtType, value string) (lit string, ok bool) {
+	var bits = map[string]int{"Byte": 8, "Short": 16, "Int": 32, "UnsignedByte": 8, "UnsignedShort": 16, "UnsignedInt": 32, "Float": 32}[xsdtType]
+	if bits == 0 {
+		bits = 64
+	}
+	switch xsdtType {
+	case "Boolean":
+		switch value {
+		case "true", "1":
+			lit, ok = "true", true
+		case "false", "0":
+			lit, ok = "false", true
+		}
+	case "Byte", "Int", "Integer", "Long", "NegativeInteger", "NonPositiveInteger", "Short":
+		if i, err := strconv.ParseInt(value, 10, bits); err == nil {
+			lit, ok = strconv.FormatInt(i, 10), true
+		}
+	case "NonNegativeInteger", "PositiveInteger", "UnsignedByte", "UnsignedInt", "UnsignedLong", "UnsignedShort":
+		if u, err := strconv.ParseUint(strings.TrimPrefix(value, "+"), 10, bits); err == nil {
+			lit, ok = strconv.FormatUint(u, 10), true
+		}
+	case "Double", "Float":
+		if f, err := strconv.ParseFloat(value, bits); (err == nil) && !math.IsInf(f, 0) && !math.IsNaN(f) {
+			lit, ok = strconv.FormatFloat(f, 'g', -1, bits), true
+		}
+	default:
+		lit, ok = strconv.Quote(value), true
+	}
+	return
+}
+
+//	Returns the name of the xsdt type (such as "Int") that the specified type ultimately derives from, or "" if it is not known (such as for list and union types, or types of other packages).
+func (me *PkgBag) builtinBaseType(tn string) string {
+	for depth := 0; me.isLocalType(tn) && (depth < 64); depth++ {
+		tn = me.simpleBaseTypes[tn]
+	}
+	if strings.HasPrefix(tn, me.impName+".") {
+		return strings.TrimPrefix(tn, me.impName+".")
+	}
+	return ""
+}
+
+func (me *PkgBag) isLocalType(tn string) bool {
+	return me.declTypes[tn] != nil
+}
+
+//	Returns whether values of the specified type (as found in a struct field) may have a Validate() method.
+func (me *PkgBag) isValidatorType(tn string) bool {
+	if dt := me.declTypes[tn]; dt != nil {
+		return (len(dt.Type) == 0) || (dt.Methods["Validate"] != nil)
+	}
+	return me.isForeignType(tn) || (tn == "interface{}")
+}
+
 func (me *PkgBag) append(lines ...string) {
-	me.lines = append(me.lines, lines...)
+	for _, ln := range lines {
+		me.src.WriteString(ln)
+		me.src.WriteByte('\n')
+	}
 }
 
 func (me *PkgBag) appendFmt(addLineAfter bool, format string, fmtArgs ...interface{}) {
-	me.append(fmt.Sprintf(format, fmtArgs...))
+	fmt.Fprintf(me.src, format, fmtArgs...)
+	me.src.WriteByte('\n')
 	if addLineAfter {
-		me.append("")
+		me.src.WriteByte('\n')
 	}
 }
 
@@ -171,15 +1115,27 @@ func (me *PkgBag) assembleSource() string {
 				}
 			}
 		}
-		initLines = me.lines
-		snConv    string
+		header = me.src
+		snConv string
 	)
-	me.lines = []string{}
+	me.src = bytes.NewBuffer(make([]byte, 0, (len(me.declTypes)+1)*srcBytesPerType))
 	loadedSchemas := make(map[string]bool)
 	me.Schema.collectGlobals(me, loadedSchemas)
+	me.addRestrictions()
+	me.addSubstitutionGroups()
+	me.flattenGroups()
+	if me.gen.ChoiceUnions {
+		me.addChoiceUnions()
+	}
+	if me.gen.AddXsiTypeMethods || me.gen.ApplyDefaults || me.gen.ChoiceUnions || (len(me.nillables) > 0) || (len(me.substHeads) > 0) || (len(me.mixedTypes) > 0) {
+		me.addMarshalMethods()
+	}
+	if me.gen.AddValidators {
+		me.addSimpleTypeValidators()
+	}
 	if len(me.allNotations) > 0 {
 		me.impsUsed[me.impName] = true
-		me.appendFmt(false, "var %sNotations = new(%s.Notations)\n\nfunc init () {", idPrefix, me.impName)
+		me.appendFmt(false, "var %sNotations = %s.Notations{}\n\nfunc init () {", idPrefix, me.impName)
 		for _, not := range me.allNotations {
 			not.makePkg(me)
 		}
@@ -198,9 +1154,40 @@ func (me *PkgBag) assembleSource() string {
 		render(gr)
 	}
 
-  for _, dt := range me.declTypes {
-    dt.render(me)
-  }
+	for _, tn := range me.sortedTypeNames() {
+		me.declTypes[tn].render(me)
+	}
+	if me.gen.AddConstructors {
+		me.addConstructors()
+	}
+	if me.gen.AddValidators {
+		me.addOccursChecks()
+	}
+	if me.gen.PresenceAccessors {
+		me.addAccessors()
+	}
+	if me.gen.AddCloneAndEqual {
+		me.addCloneAndEqual()
+	}
+	if me.gen.AddDTOs {
+		me.addDTOs()
+	}
+	if me.gen.AddCSV {
+		me.addCSV()
+	}
+	if me.gen.AddSoapOperations {
+		me.addSoapOperations()
+	}
+	if me.gen.AddMarshalChecks {
+		me.addMarshalChecks()
+	}
+	if me.gen.AddAppInfo {
+		me.addAppInfoVar()
+	}
+	if me.gen.AddNamespaces {
+		me.addNamespaces()
+	}
+	me.emitAfterPackage()
 
 	if len(me.walkerTypes) > 0 {
 		doc := sfmt("//\tProvides %v strong-typed hooks for your own custom handler functions to be invoked when the Walk() method is called on any instance of any (non-attribute-related) struct type defined in this package.\n//\tIf your custom handler does get called at all for a given struct instance, then it always gets called twice, first with the 'enter' bool argument set to true, then (after having Walk()ed all subordinate struct instances, if any) once again with it set to false.", len(me.walkerTypes))
@@ -215,31 +1202,182 @@ func (me *PkgBag) assembleSource() string {
 	%s
 	WalkHandlers        = &%sWalkHandlers {}
 )`, doc, idPrefix)
-		me.appendFmt(false, doc)
+		me.appendFmt(false, "%s", doc)
 		me.appendFmt(false, "type %vWalkHandlers struct {", idPrefix)
-		for wt, _ := range me.walkerTypes {
+		for _, wt := range sortedFlags(me.walkerTypes) {
 			me.appendFmt(false, "\t%s func (*%s, bool) (error)", wt, wt)
 		}
 		me.appendFmt(true, "}")
 	}
-	for conv, _ := range me.declConvs {
+	for _, conv := range sortedFlags(me.declConvs) {
 		snConv = me.safeName(conv)
 		me.appendFmt(false, "//\tA convenience interface that declares a type conversion to %v.", conv)
 		me.appendFmt(true, "type To%v interface { To%v () %v }", snConv, snConv, conv)
 	}
 
-	initLines = append(initLines, "import (")
-	for impName, impPath := range me.imports {
-		if me.impsUsed[impName] {
+	var src strings.Builder
+	body := me.src
+	me.src = header
+	me.append(me.importLines()...)
+	src.Grow(header.Len() + body.Len())
+	src.Write(header.Bytes())
+	src.Write(body.Bytes())
+	me.src = body
+	return src.String()
+}
+
+//	Returns the import declaration for all imports used anywhere in the package.
+func (me *PkgBag) importLines() (lines []string) {
+	lines = append(lines, "import (")
+	for _, impName := range sortedKeys(me.imports) {
+		if impPath := me.imports[impName]; me.impsUsed[impName] {
 			if len(impPath) > 0 {
-				initLines = append(initLines, sfmt("\t%s \"%s\"", impName, impPath))
+				lines = append(lines, sfmt("\t%s \"%s\"", impName, impPath))
 			} else {
-				initLines = append(initLines, sfmt("\t\"%s\"", impName))
+				lines = append(lines, sfmt("\t\"%s\"", impName))
 			}
 		}
 	}
-	initLines = append(initLines, ")", "")
-	return strings.Join(append(initLines, me.lines...), "\n")
+	return append(lines, ")", "")
+}
+
+//	Returns the sources of the additional files that the package is split into if PkgGen.SplitFiles is set, keyed by the file names returned by splitFileName.
+//	Must be called after assembleSource.
+func (me *PkgBag) assembleSplitSources() (srcs map[string]string) {
+	var src = me.src
+	srcs = map[string]string{}
+	me.src = &bytes.Buffer{}
+	me.appendTmpl(me.tmpls.fileHeader, &TmplFileHeader{SchemaUri: me.Schema.loadUri, PkgName: me.pkgName})
+	me.append(me.importLines()...)
+	header := me.src.Bytes()
+	for fileName, splitSrc := range me.splitSrcs {
+		var src strings.Builder
+		src.Grow(len(header) + splitSrc.Len())
+		src.Write(header)
+		src.Write(splitSrc.Bytes())
+		srcs[fileName] = src.String()
+	}
+	me.src = src
+	return
+}
+
+//	Returns the name of the file that the declarations generated for el go into if PkgGen.SplitFiles is set, or "" for the main file.
+//	The name is all lower-case and free of underscores, so that neither case-insensitive file systems nor build constraints (such as for a "_linux" suffix) get in the way.
+func (me *PkgBag) splitFileName(el element) string {
+	for (el != nil) && (el.Parent() != nil) {
+		switch el.Parent().(type) {
+		case *Schema, *Redefine, *Override:
+			kind, name := el.base().xsdName.String(), el.base().selfName().String()
+			return strings.ToLower(sfmt("%s.%s.%s.go", path.Base(me.Schema.loadUri), kind, strings.Map(func(r rune) rune {
+				if unicode.IsLetter(r) || unicode.IsDigit(r) {
+					return r
+				}
+				return '-'
+			}, name)))
+		}
+		el = el.Parent()
+	}
+	return ""
+}
+
+//	Returns the names of all declared types in sorted order, so that they are always rendered in the same order.
+func (me *PkgBag) sortedTypeNames() (names []string) {
+	for tn, _ := range me.declTypes {
+		names = append(names, tn)
+	}
+	sort.Strings(names)
+	return
+}
+
+//	Formats the assembled source as gofmt would, first removing unreferenced imports if prune is set.
+//	Only if imports were removed is the result formatted once more, to close the gaps they leave, as that is costly for large packages.
+func formatSource(src string, prune bool) (formatted string, err error) {
+	var file *ast.File
+	var raw []byte
+	fset := token.NewFileSet()
+	if file, err = parser.ParseFile(fset, "", src, parser.ParseComments); err == nil {
+		var pruned bool
+		if prune {
+			pruned = pruneImports(file)
+		}
+		var buf bytes.Buffer
+		buf.Grow(len(src))
+		if err = format.Node(&buf, fset, file); (err == nil) && pruned {
+			if raw, err = format.Source(buf.Bytes()); err == nil {
+				formatted = string(raw)
+			}
+		} else if err == nil {
+			formatted = buf.String()
+		}
+	}
+	return
+}
+
+//	Removes from file all import specs whose package name is not referenced by any selector expression, returning whether any were.
+func pruneImports(file *ast.File) (pruned bool) {
+	used := map[string]bool{}
+	ast.Inspect(file, func(n ast.Node) bool {
+		if sel, ok := n.(*ast.SelectorExpr); ok {
+			if id, ok := sel.X.(*ast.Ident); ok {
+				used[id.Name] = true
+			}
+		}
+		return true
+	})
+	var decls []ast.Decl
+	for _, decl := range file.Decls {
+		if gd, ok := decl.(*ast.GenDecl); ok && (gd.Tok == token.IMPORT) {
+			var specs []ast.Spec
+			for _, spec := range gd.Specs {
+				if name := importName(spec.(*ast.ImportSpec)); (len(name) == 0) || used[name] {
+					specs = append(specs, spec)
+				}
+			}
+			if gd.Specs = specs; len(specs) == 0 {
+				continue
+			}
+		}
+		decls = append(decls, decl)
+	}
+	var imps []*ast.ImportSpec
+	for _, imp := range file.Imports {
+		if name := importName(imp); (len(name) == 0) || used[name] {
+			imps = append(imps, imp)
+		}
+	}
+	pruned = len(imps) < len(file.Imports)
+	file.Decls, file.Imports = decls, imps
+	return
+}
+
+//	Returns the package name that imp is referred to by, or "" if it cannot be told without loading the imported package (or is a blank or dot import).
+func importName(imp *ast.ImportSpec) string {
+	if imp.Name != nil {
+		return ustr.Ifs((imp.Name.Name == "_") || (imp.Name.Name == "."), "", imp.Name.Name)
+	}
+	impPath, _ := strconv.Unquote(imp.Path.Value)
+	if name := path.Base(impPath); !strings.ContainsAny(name, ".-") {
+		return name
+	}
+	return ""
+}
+
+//	Returns the keys of m in sorted order.
+func sortedKeys(m map[string]string) (keys []string) {
+	for k, _ := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return
+}
+
+//	Returns the keys of m in sorted order.
+func sortedFlags(m map[string]bool) (keys []string) {
+	for k, _ := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return
 }
 
 func (me *PkgBag) checkType(typeSpec string) {
@@ -257,6 +1395,16 @@ func (me *PkgBag) checkType(typeSpec string) {
 	} // else if (tn != "string") && (tn != "bool") && (len(tn) > 0) && !strings.Contains(tn, ".") { println("TYPE NOT FOUND: " + tn) }
 }
 
+//	Reports typeSpec (as used by dt for the schema construct el, or else for that of dt) as unresolvable if it refers to a type of this package that was never declared, such as for a misspelled XSD type or element reference.
+func (me *PkgBag) checkTypeDeclared(dt *declType, el element, typeSpec string) {
+	if el == nil {
+		el = dt.elem
+	}
+	if tn := ustr.Replace(typeSpec, typeRenderRepls); (strings.HasPrefix(tn, "T") || strings.HasPrefix(tn, idPrefix)) && (me.declTypes[tn] == nil) {
+		me.report(el, SeverityError, "unresolvable reference: no Go type %s is declared for it", tn)
+	}
+}
+
 func (me *PkgBag) isParseType(typeRef string) bool {
 	for pt, _ := range me.parseTypes {
 		if typeRef == pt {
@@ -274,20 +1422,35 @@ func (me *PkgBag) resolveQnameRef(ref, pref string, noUsageRec *string) string {
 	}
 	if pos := strings.Index(ref, ":"); pos > 0 {
 		impName, ns = ref[:pos], me.Schema.XMLNamespaces[ref[:pos]]
+		if _, ok := me.Schema.XMLNamespaces[impName]; !ok {
+			me.report(nil, SeverityError, "unresolvable QName %s: the namespace prefix %s is not declared", ref, impName)
+		}
 		impName = safeIdentifier(impName)
 		ref = ref[(pos + 1):]
 	}
+	if tn := me.overrideType(ns, ref, pref); len(tn) > 0 {
+		if noUsageRec != nil {
+			*noUsageRec = tn[:strings.Index(tn, ".")]
+		}
+		return tn
+	}
 	if ns == xsdNamespaceUri {
-		impName, pref = me.impName, ""
+		if impName, pref = me.impName, ""; me.gen.LexicalFidelity && me.parseTypes[me.impName+"."+me.safeName(ref)] {
+			ref = "Lexical" + me.safeName(ref)
+		} else if me.gen.TypedBuiltins && (len(typedBuiltins[ref]) > 0) {
+			ref = typedBuiltins[ref]
+		}
 	}
 	if ns == me.Schema.TargetNamespace.String() {
 		impName = ""
+	} else if imp := me.nsImps[ns]; (len(imp) > 0) && (ns != xsdNamespaceUri) {
+		impName = imp
 	}
 	if noUsageRec == nil { /*me.impsUsed[impName] = true*/
 	} else {
 		*noUsageRec = impName
 	}
-	return ustr.PrefixWithSep(impName, ".", me.safeName(ustr.PrependIf(ref, pref)))
+	return ustr.PrefixWithSep(impName, ".", me.safeNsName(ns, ustr.PrependIf(ref, pref)))
 }
 
 func (me *PkgBag) rewriteTypeSpec(typeSpec string) (tn string) {
@@ -301,7 +1464,7 @@ func (me *PkgBag) rewriteTypeSpec(typeSpec string) (tn string) {
 }
 
 func (me *PkgBag) safeName(name string) string {
-	return ustr.SafeIdentifier(name)
+	return me.safeNsName(me.Schema.TargetNamespace.String(), name)
 }
 
 func (me *PkgBag) xsdStringTypeRef() string {
@@ -312,19 +1475,68 @@ type declEmbed struct {
 	Name          string
 	Annotations   []*Annotation
 	elem          element
+	via           []element // the group references this embed was flattened from (outermost first), see flattenGroups
 	finalTypeName string
 }
 
-func (me *declEmbed) render(bag *PkgBag, dt *declType) {
+//	Sorts embeds by the positions of the attributes and elements (or group references) declaring them, those of base types (without any) first.
+//	Embeds flattened from a group (see flattenGroups) are sorted by the position of the group reference, and then by their own positions within the group.
+type declEmbedsByPosition []*declEmbed
+
+func (me declEmbedsByPosition) Len() int      { return len(me) }
+func (me declEmbedsByPosition) Swap(i, j int) { me[i], me[j] = me[j], me[i] }
+func (me declEmbedsByPosition) Less(i, j int) bool {
+	return positionLess(me[i].positionPath(), me[j].positionPath())
+}
+
+//	Sorts fields (such as those of choice unions, see addChoiceUnion) by the positions of the elements declaring them, like declEmbedsByPosition.
+type declFieldsByPosition []*declField
+
+func (me declFieldsByPosition) Len() int      { return len(me) }
+func (me declFieldsByPosition) Swap(i, j int) { me[i], me[j] = me[j], me[i] }
+func (me declFieldsByPosition) Less(i, j int) bool {
+	return positionLess(me[i].positionPath(), me[j].positionPath())
+}
+
+//	Returns whether the element reached via the group references pi (outermost first, the element itself last) precedes that reached via pj,
+//	comparing the positions at the first level at which they differ, such that those without any (as of base types) come first.
+func positionLess(pi, pj []element) bool {
+	var li, ci, lj, cj int
+	for k := 0; (k < len(pi)) && (k < len(pj)); k++ {
+		li, ci, lj, cj = 0, 0, 0, 0
+		if pi[k] != nil {
+			_, li, ci = pi[k].base().position()
+		}
+		if pj[k] != nil {
+			_, lj, cj = pj[k].base().position()
+		}
+		if (li != lj) || (ci != cj) {
+			break
+		}
+	}
+	return (li < lj) || ((li == lj) && (ci < cj))
+}
+
+//	Returns the group references this embed was flattened from (outermost first), followed by the element declaring it, see positionLess.
+func (me *declEmbed) positionPath() []element {
+	return append(append([]element{}, me.via...), me.elem)
+}
+
+//	Returns the final type name of this embed, even if it was not rendered itself (such as in a type rendered as another one of the same name).
+func (me *declEmbed) typeName(bag *PkgBag) string {
+	if len(me.finalTypeName) > 0 {
+		return me.finalTypeName
+	}
+	return bag.rewriteTypeSpec(me.Name)
+}
+
+func (me *declEmbed) render(bag *PkgBag, dt *declType, tmpl *TmplStruct) {
 	if n := bag.rewriteTypeSpec(me.Name); !dt.memberWritten["E_"+n] {
 		dt.memberWritten["E_"+n] = true
-		for _, ann := range me.Annotations {
-			if ann != nil {
-				ann.makePkg(bag)
-			}
-		}
 		me.finalTypeName = bag.rewriteTypeSpec(n)
-		bag.appendFmt(true, "\t%s", me.finalTypeName)
+		bag.appInfoOf(dt.Name+"."+embedFieldName(me.finalTypeName), me.Annotations)
+		embed := TmplStructEmbed{Doc: bag.docLines(me.Annotations) + bag.sourceOf(dt.Name+"."+embedFieldName(me.finalTypeName), me.elem), Type: me.finalTypeName}
+		tmpl.Embeds, tmpl.Members = append(tmpl.Embeds, embed), append(tmpl.Members, TmplStructMember{Embed: &embed})
 	}
 }
 
@@ -332,17 +1544,105 @@ type declField struct {
 	Name, Type, XmlTag string
 	Annotations        []*Annotation
 	elem               element
+	via                []element // the group references this field was flattened from (outermost first), see flattenGroups
 	finalTypeName      string
+	presence           bool // whether this field is a pointer tracking the presence of its attribute or element, see trackPresence
+	positional         bool // whether this field is declared amid the embeds in document order (as choice unions are, see addChoiceUnion)
 }
 
-func (me *declField) render(bag *PkgBag, dt *declType) {
-	for _, ann := range me.Annotations {
-		if ann != nil {
-			ann.makePkg(bag)
-		}
+//	Returns the group references this field was flattened from (outermost first), followed by the element declaring it, see positionLess.
+func (me *declField) positionPath() []element {
+	return append(append([]element{}, me.via...), me.elem)
+}
+
+//	Returns the final type name of this field, even if it was not rendered itself (see declEmbed.typeName).
+func (me *declField) typeName(bag *PkgBag) string {
+	if len(me.finalTypeName) > 0 {
+		return me.finalTypeName
 	}
+	return bag.rewriteTypeSpec(me.Type)
+}
+
+func (me *declField) render(bag *PkgBag, dt *declType, tmpl *TmplStruct) {
+	var xmlTag = me.XmlTag
 	me.finalTypeName = bag.rewriteTypeSpec(me.Type)
-	bag.appendFmt(true, "\t%s %s `xml:\"%s\"`", me.Name, me.finalTypeName, me.XmlTag)
+	if bag.gen.LexicalFidelity && me.optional() {
+		xmlTag += ",omitempty"
+	}
+	bag.appInfoOf(dt.Name+"."+me.Name, me.Annotations)
+	field := TmplStructField{Doc: bag.docLines(me.Annotations) + bag.sourceOf(dt.Name+"."+me.Name, me.elem), Name: me.Name, Type: me.finalTypeName, XmlTag: xmlTag, JsonTag: me.jsonTag(bag)}
+	tmpl.Fields, tmpl.Members = append(tmpl.Fields, field), append(tmpl.Members, TmplStructMember{Field: &field})
+}
+
+//	Returns whether this field holds an attribute that is not required, or an element that is global (and so may be referenced optionally)
+//	or declared by particles of which any may be absent (see particlesRequired).
+func (me *declField) optional() bool {
+	switch el := me.elem.(type) {
+	case *Attribute:
+		return el.Use != "required"
+	case *Element:
+		return isGlobal(el) || !particlesRequired(particleChain(el, nil))
+	}
+	return false
+}
+
+//	Returns the json tag for this field according to PkgGen.JsonTags, derived from the XML name in its xml tag (or else from its Go name).
+func (me *declField) jsonTag(bag *PkgBag) string {
+	var name = me.XmlTag
+	if (bag.gen.JsonTags == JsonTagsNone) || (name == "-") {
+		return ustr.Ifs(bag.gen.JsonTags == JsonTagsNone, "", "-")
+	}
+	if pos := strings.Index(name, ","); pos >= 0 {
+		name = name[:pos]
+	}
+	if pos := strings.LastIndex(name, " "); pos >= 0 {
+		name = name[pos+1:]
+	}
+	if len(name) == 0 {
+		name = strings.TrimPrefix(me.Name, idPrefix)
+	}
+	return bag.gen.jsonName(name)
+}
+
+//	Returns the JSON name for the specified XML name, cased according to PkgGen.JsonTags.
+func (me *Generator) jsonName(name string) string {
+	switch me.JsonTags {
+	case JsonTagsCamelCase:
+		words := jsonTagWords(name)
+		for i, w := range words {
+			if words[i] = strings.ToLower(w); i > 0 {
+				runes := []rune(words[i])
+				runes[0] = unicode.ToUpper(runes[0])
+				words[i] = string(runes)
+			}
+		}
+		name = strings.Join(words, "")
+	case JsonTagsSnakeCase:
+		name = strings.ToLower(strings.Join(jsonTagWords(name), "_"))
+	}
+	return name
+}
+
+//	Splits name into words at non-alphanumeric characters and at lower-to-upper-case transitions (keeping acronyms such as "ID" or "URL" together).
+func jsonTagWords(name string) (words []string) {
+	var word []rune
+	var runes = []rune(name)
+	for i, r := range runes {
+		if !(unicode.IsLetter(r) || unicode.IsDigit(r)) {
+			if len(word) > 0 {
+				words, word = append(words, string(word)), nil
+			}
+			continue
+		}
+		if (len(word) > 0) && unicode.IsUpper(r) && (unicode.IsLower(word[len(word)-1]) || unicode.IsDigit(word[len(word)-1]) || ((i+1 < len(runes)) && unicode.IsLower(runes[i+1]))) {
+			words, word = append(words, string(word)), nil
+		}
+		word = append(word, r)
+	}
+	if len(word) > 0 {
+		words = append(words, string(word))
+	}
+	return
 }
 
 type declMethod struct {
@@ -357,6 +1657,10 @@ func (me *declMethod) render(bag *PkgBag, dt *declType) {
 			ann.makePkg(bag)
 		}
 	}
+	if enum, isEnum := me.elem.(*RestrictionSimpleEnumeration); isEnum {
+		bag.appendTmpl(bag.tmpls.enum, &TmplEnum{Doc: me.Doc, TypeName: bag.rewriteTypeSpec(me.ReceiverType), MethodName: me.Name, Value: enum.Value})
+		return
+	}
 	bag.appendFmt(false, "//\t%s", me.Doc)
 	rt := bag.rewriteTypeSpec(me.ReturnType)
 	bag.appendFmt(true, "func (me %s) %s %s { %s }", bag.rewriteTypeSpec(me.ReceiverType), ustr.Ifs(strings.Contains(me.Name, "("), me.Name, me.Name+" ()"), rt, strings.Replace(me.Body, me.ReturnType, rt, -1))
@@ -369,10 +1673,23 @@ type declType struct {
 	Fields                   map[string]*declField
 	Methods                  map[string]*declMethod
 	elem                     element
+	defaults, fixeds         []string // the statements applying the default and fixed values of the fields, see addDefault
 	memberWritten            map[string]bool
 	rendered                 bool
 }
 
+//	Records the statements applying the default (or fixed) value returned by the method defMethod to the field (or, for a slice field, its item) denoted by field, such as "me.Currency" or "me.Sizes[i]".
+func (me *declType) addDefault(bag *PkgBag, field, defMethod string, fixed bool) {
+	var loop = ""
+	if strings.HasSuffix(field, "[i]") {
+		loop = sfmt("for i := range %s {", strings.TrimSuffix(field, "[i]"))
+	}
+	me.defaults = append(me.defaults, sfmt("%s%s.ApplyDefault(&%s, me.%s())%s", loop, bag.impName, field, defMethod, ustr.Ifs(len(loop) > 0, "}", "")))
+	if fixed {
+		me.fixeds = append(me.fixeds, sfmt("%sif err = %s.ApplyFixed(&%s, me.%s()); err != nil {\n\t\treturn\n\t}%s", loop, bag.impName, field, defMethod, ustr.Ifs(len(loop) > 0, "}", "")))
+	}
+}
+
 func (me *declType) addAnnotations(a ...*Annotation) {
 	me.Annotations = append(me.Annotations, a...)
 }
@@ -395,108 +1712,162 @@ func (me *declType) addMethod(elem element, recType, name, retType, body, doc st
 	return
 }
 
-func (me *declType) checkForEquivalents(bag *PkgBag) {
-	if (len(me.EquivalentTo) == 0) && (strings.HasPrefix(me.Name, "Txsd") || strings.HasPrefix(me.Name, idPrefix)) {
-		for _, dt := range bag.declWrittenTypes {
-			if (dt != me) && (len(dt.EquivalentTo) == 0) && me.equivalentTo(dt) {
-				me.EquivalentTo = dt.Name
-			}
-		}
+func (me *declType) sortedEmbeds() (embeds []*declEmbed) {
+	var names []string
+	for n, _ := range me.Embeds {
+		names = append(names, n)
+	}
+	sort.Strings(names)
+	for _, n := range names {
+		embeds = append(embeds, me.Embeds[n])
 	}
+	return
 }
 
-func (me *declType) equivalentTo(dt *declType) bool {
-	var sme, sdt []string
-	if me.Type != dt.Type {
-		return false
+//	Returns the embeds of this type in the document order of the attributes and elements (or group references) declaring them, preceded by its base type (if any).
+func (me *declType) positionalEmbeds() (embeds []*declEmbed) {
+	embeds = me.sortedEmbeds()
+	sort.Stable(declEmbedsByPosition(embeds))
+	return
+}
+
+func (me *declType) sortedFields() (fields []*declField) {
+	var names []string
+	for n, _ := range me.Fields {
+		names = append(names, n)
 	}
-	if len(me.Embeds) != len(dt.Embeds) {
-		return false
+	sort.Strings(names)
+	for _, n := range names {
+		fields = append(fields, me.Fields[n])
 	}
-	if len(me.Fields) != len(dt.Fields) {
-		return false
+	return
+}
+
+func (me *declType) sortedMethods() (methods []*declMethod) {
+	var names []string
+	for n, _ := range me.Methods {
+		names = append(names, n)
 	}
-	sme, sdt = []string{}, []string{}
-	for e, _ := range me.Embeds {
-		sme = append(sme, e)
+	sort.Strings(names)
+	for _, n := range names {
+		methods = append(methods, me.Methods[n])
 	}
-	for e, _ := range dt.Embeds {
-		sdt = append(sdt, e)
+	return
+}
+
+//	Sets EquivalentTo to the name of the (last) written type that this type is equivalent to (see equivalenceKey), if any, for generated types.
+func (me *declType) checkForEquivalents(bag *PkgBag) {
+	if (len(me.EquivalentTo) == 0) && (strings.HasPrefix(me.Name, "Txsd") || strings.HasPrefix(me.Name, idPrefix)) {
+		if dt := bag.declEquivalents[me.equivalenceKey()]; (dt != nil) && (dt != me) && (len(dt.EquivalentTo) == 0) {
+			me.EquivalentTo = dt.Name
+		}
 	}
-	if !uslice.StrEquivalent(sme, sdt) {
-		return false
+}
+
+//	Returns a string that is the same for two types if and only if they have the same underlying type, embeds, fields and methods (but for
+//	the methods added when rendering struct types), so that written types can be looked up by it rather than compared one by one.
+func (me *declType) equivalenceKey() string {
+	var embeds, fields, methods []string
+	for e, _ := range me.Embeds {
+		embeds = append(embeds, e)
 	}
-	sme, sdt = []string{}, []string{}
 	for _, f := range me.Fields {
-		sme = append(sme, f.Name+f.Type+f.XmlTag)
-	}
-	for _, f := range dt.Fields {
-		sdt = append(sdt, f.Name+f.Type+f.XmlTag)
+		fields = append(fields, f.Name+f.Type+f.XmlTag)
 	}
-	if !uslice.StrEquivalent(sme, sdt) {
-		return false
-	}
-	sme, sdt = []string{}, []string{}
 	for _, m := range me.Methods {
-		if m.Name != "Walk" {
-			sme = append(sme, m.Name+m.ReturnType+m.Body)
+		if !((m.Name == "Walk") || ((len(me.Type) == 0) && ((m.Name == "Validate") || (m.Name == "ApplyDefaults") || (m.Name == "ApplyFixed")))) {
+			methods = append(methods, m.Name+m.ReturnType+m.Body)
 		}
 	}
-	for _, m := range dt.Methods {
-		if m.Name != "Walk" {
-			sdt = append(sdt, m.Name+m.ReturnType+m.Body)
+	sort.Strings(embeds)
+	sort.Strings(fields)
+	sort.Strings(methods)
+	var buf bytes.Buffer
+	buf.WriteString(me.Type)
+	for _, part := range [][]string{embeds, fields, methods} {
+		buf.WriteString("\x01")
+		for _, item := range part {
+			buf.WriteString("\x00" + item)
 		}
 	}
-	if !uslice.StrEquivalent(sme, sdt) {
-		return false
-	}
-	return true
+	return buf.String()
 }
 
 func (me *declType) render(bag *PkgBag) {
 	if !me.rendered {
 		me.rendered = true
+		if fileName := bag.splitFileName(me.elem); bag.gen.SplitFiles && (len(fileName) > 0) {
+			src := bag.src
+			bag.src = &bytes.Buffer{}
+			defer func() {
+				bag.splitSrc(fileName).Write(bag.src.Bytes())
+				bag.src = src
+			}()
+		}
 		if me.checkForEquivalents(bag); len(me.EquivalentTo) == 0 {
 			var myName = me.Name
-			for _, ann := range me.Annotations {
-				if ann != nil {
-					ann.makePkg(bag)
-				}
+			bag.gen.hookTypeGenerated(bag.Schema, me.Name)
+			var doc = bag.docLines(me.Annotations) + bag.sourceOf(myName, me.elem)
+			bag.appInfoOf(myName, me.Annotations)
+			if (len(me.Type) == 0) && bag.gen.AddWalkers && !strings.HasPrefix(myName, idPrefix+"HasAtt") && !bag.stUnions[myName] {
+				//	Before rendering the types of its embeds and fields, so that the Walk() methods of those referring back to it (as recursive types do) walk it, too.
+				bag.walkerTypes[myName] = true
 			}
-			for _, e := range me.Embeds {
+			for _, e := range me.sortedEmbeds() {
 				bag.checkType(e.Name)
+				bag.checkTypeDeclared(me, e.elem, e.Name)
 			}
-			for _, f := range me.Fields {
+			for _, f := range me.sortedFields() {
 				bag.checkType(f.Type)
+				bag.checkTypeDeclared(me, f.elem, f.Type)
 			}
-			for _, m := range me.Methods {
+			for _, m := range me.sortedMethods() {
 				bag.checkType(m.ReturnType)
 			}
 			if len(me.Type) > 0 {
 				bag.checkType(me.Type)
-				bag.appendFmt(true, "type %s %s", myName, me.Type)
+				bag.checkTypeDeclared(me, nil, me.Type)
+				bag.appendTmpl(bag.tmpls.simpleType, &TmplSimpleType{Doc: doc, Name: myName, Type: me.Type})
+				if enumType := bag.enumType(myName); enumType != nil {
+					bag.impsUsed[bag.impName] = true
+					bag.appendTmpl(bag.tmpls.enumType, enumType)
+				}
 			} else {
-				bag.appendFmt(false, "type %s struct {", myName)
-				for _, f := range me.Fields {
-					f.render(bag, me)
+				tmpl := &TmplStruct{Doc: doc, Name: myName}
+				var positional []*declField
+				for _, f := range me.sortedFields() {
+					if f.positional {
+						positional = append(positional, f)
+					} else {
+						f.render(bag, me, tmpl)
+					}
 				}
-				for _, e := range me.Embeds {
-					e.render(bag, me)
+				//	In document order, as encoding/xml encodes the elements of embeds (and fields) in the order of these, which must be that of their xs:sequence.
+				sort.Stable(declFieldsByPosition(positional))
+				for _, e := range me.positionalEmbeds() {
+					for (len(positional) > 0) && positionLess(positional[0].positionPath(), e.positionPath()) {
+						positional[0].render(bag, me, tmpl)
+						positional = positional[1:]
+					}
+					e.render(bag, me, tmpl)
+				}
+				for _, f := range positional {
+					f.render(bag, me, tmpl)
 				}
-				bag.appendFmt(true, "}")
-				if PkgGen.AddWalkers && !strings.HasPrefix(myName, idPrefix+"HasAtt") {
+				bag.appendTmpl(bag.tmpls.structType, tmpl)
+				if bag.gen.AddWalkers && !strings.HasPrefix(myName, idPrefix+"HasAtt") && !bag.stUnions[myName] {
 					errCheck := sfmt("%s.OnWalkError(&err, &WalkErrors, WalkContinueOnError, WalkOnError) { return }", bag.impName)
 					fnCall := "\t\tif fn != nil { if err = fn(me, %v); %s }"
 					walkBody := sfmt("\n\tif fn := WalkHandlers.%s; me != nil {\n%s\n", myName, sfmt(fnCall, true, errCheck))
 					ec, fc := 0, 0
-					bag.walkerTypes[myName] = true
-					for _, e := range me.Embeds {
+					bag.walkerTypes[myName], bag.impsUsed[bag.impName] = true, true
+					for _, e := range me.sortedEmbeds() {
 						if bag.walkerTypes[e.finalTypeName] {
 							ec++
 							walkBody += sfmt("\t\tif err = me.%s.Walk(); %s\n", e.finalTypeName, errCheck)
 						}
 					}
-					for _, f := range me.Fields {
+					for _, f := range me.sortedFields() {
 						if bag.walkerTypes[strings.Replace(f.finalTypeName, "*", "", -1)] {
 							fc++
 							walkBody += sfmt("\t\tif err = me.%v.Walk(); %s\n", f.Name, errCheck)
@@ -507,11 +1878,70 @@ func (me *declType) render(bag *PkgBag) {
 					walkBody += sfmt("%s\n}\n\treturn\n", sfmt(fnCall, false, errCheck))
 					me.addMethod(nil, "*"+myName, "Walk", "(err error)", walkBody, sfmt("If the WalkHandlers.%v function is not nil (ie. was set by outside code), calls it with this %v instance as the single argument. Then calls the Walk() method on %v/%v embed(s) and %v/%v field(s) belonging to this %v instance.", myName, myName, ec, len(me.Embeds), fc, len(me.Fields), myName))
 				}
+				if bag.gen.ApplyDefaults && bag.hasDefaults(myName, false, 0) {
+					dfltBody, fixedBody := "", ""
+					for _, e := range me.sortedEmbeds() {
+						if tn := e.finalTypeName; !strings.Contains(tn, ".") && bag.hasDefaults(tn, false, 0) {
+							dfltBody += sfmt("\n\tme.%s.ApplyDefaults()", tn)
+							if bag.hasDefaults(tn, true, 0) {
+								fixedBody += sfmt("\n\tif err = me.%s.ApplyFixed(); err != nil {\n\t\treturn\n\t}", tn)
+							}
+						}
+					}
+					if len(me.defaults) > 0 {
+						bag.impsUsed[bag.impName] = true
+						dfltBody += "\n\t" + strings.Join(me.defaults, "\n\t")
+					}
+					if len(me.fixeds) > 0 {
+						fixedBody += "\n\t" + strings.Join(me.fixeds, "\n\t")
+					}
+					me.addMethod(nil, "*"+myName, "ApplyDefaults", "", dfltBody+"\n", sfmt("Sets all fields of this %v instance (and of its embeds) that hold zero values to the default or fixed values of their XSD attributes or elements.", myName))
+					if len(fixedBody) > 0 {
+						me.addMethod(nil, "*"+myName, "ApplyFixed", "(err error)", fixedBody+"\n\treturn\n", sfmt("Sets all fields of this %v instance (and of its embeds) that hold zero values to the fixed values of their XSD attributes or elements, returning a *%s.FacetError for the first one holding another value.", myName, bag.impName))
+					}
+				}
+				if bag.gen.AddValidators {
+					var names []string
+					valBody := ""
+					for _, e := range me.sortedEmbeds() {
+						if (len(e.finalTypeName) > 0) && bag.isValidatorType(e.finalTypeName) {
+							names = append(names, e.finalTypeName[strings.LastIndex(e.finalTypeName, ".")+1:])
+						}
+					}
+					sort.Strings(names)
+					for _, n := range names {
+						valBody += sfmt("\n\tif err = %s.ValidateValue(&me.%s); err != nil {\n\t\treturn\n\t}", bag.impName, n)
+						bag.impsUsed[bag.impName] = true
+					}
+					names = nil
+					for _, f := range me.sortedFields() {
+						names = append(names, f.Name)
+					}
+					sort.Strings(names)
+					for _, n := range names {
+						ft := strings.TrimPrefix(me.Fields[n].finalTypeName, "[]")
+						if bag.isValidatorType(strings.TrimPrefix(ft, "*")) {
+							bag.impsUsed[bag.impName] = true
+							if x := ustr.Ifs(strings.HasPrefix(ft, "*") || (ft == "interface{}"), "me.%s", "&me.%s"); strings.HasPrefix(me.Fields[n].finalTypeName, "[]") {
+								valBody += sfmt("\n\tfor i := range me.%s {\n\t\tif err = %s.ValidateValue(%s); err != nil {\n\t\t\treturn\n\t\t}\n\t}", n, bag.impName, sfmt(x, n+"[i]"))
+							} else {
+								valBody += sfmt("\n\tif err = %s.ValidateValue(%s); err != nil {\n\t\treturn\n\t}", bag.impName, sfmt(x, n))
+							}
+						}
+					}
+					var checks []*occursCheck
+					if bag.occursChecks(me, "me", nil, &checks, 0); len(checks) > 0 {
+						valBody = "\n\tif err = me.CheckOccurrences(); err != nil {\n\t\treturn\n\t}" + valBody
+					}
+					me.addMethod(nil, "*"+myName, "Validate", "(err error)", valBody+"\n\treturn\n", sfmt("Calls the Validate() method (if any) on all embeds and fields belonging to this %v instance, returning the first error encountered.%s", myName, ustr.Ifs(len(checks) > 0, " Before that, checks the number of occurrences of its elements (see CheckOccurrences).", "")))
+				}
 			}
 			bag.declWrittenTypes = append(bag.declWrittenTypes, me)
-			for _, m := range me.Methods {
+			bag.declEquivalents[me.equivalenceKey()] = me
+			for _, m := range me.sortedMethods() {
 				m.render(bag, me)
 			}
+			bag.emitAfterType(me)
 		}
 	}
 }
@@ -0,0 +1,958 @@
+package xsd
+
+import (
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestImportedTypeOnlyPkgBuilds(t *testing.T) {
+	gopath, goOutFilePaths := genTestPkgs(t, "imported", nil)
+	for _, goOutFilePath := range goOutFilePaths {
+		goTool(t, gopath, goOutFilePath, "build")
+	}
+}
+
+func TestImportedGroupRefPkgBuilds(t *testing.T) {
+	gopath, goOutFilePaths := genTestPkgs(t, "groupimport", nil)
+	for _, goOutFilePath := range goOutFilePaths {
+		if src, err := ioutil.ReadFile(goOutFilePath); err != nil {
+			t.Fatal(err)
+		} else if (filepath.Base(goOutFilePath) == "main.xsd.go") && !strings.Contains(string(src), "gl.XsdGoPkgHasGroup_Contact") {
+			t.Errorf("the Person type does not embed the group of the imported package:\n%s", src)
+		}
+		goTool(t, gopath, goOutFilePath, "build")
+	}
+}
+
+func TestStructEmbedsInSequenceOrder(t *testing.T) {
+	gopath, goOutFilePaths := genTestPkgs(t, "sequence", nil)
+	goTestPkg(t, gopath, goOutFilePaths[0], `package go_Seq
+
+import (
+	"encoding/xml"
+	"strings"
+	"testing"
+)
+
+func TestOrder(t *testing.T) {
+	var doc XsdGoPkgHasElem_Shipment
+	if err := xml.Unmarshal([]byte("<doc><shipment xmlns=\"urn:example:seq\"><zone>1</zone><carrier>2</carrier><address>3</address></shipment></doc>"), &doc); err != nil {
+		t.Fatal(err)
+	}
+	raw, err := xml.Marshal(doc.Shipment)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s := string(raw); !((strings.Index(s, "<zone") < strings.Index(s, "<carrier")) && (strings.Index(s, "<carrier") < strings.Index(s, "<address"))) {
+		t.Fatalf("elements not encoded in sequence order: %s", s)
+	}
+}
+`)
+}
+
+func BenchmarkMakeGoPkgSrc(b *testing.B) {
+	sd, gen := loadTestSchema(b, "large", "large.xsd"), NewGenerator(DefaultGenOptions())
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, _, err := gen.GenerateGoSourceAs(sd, "go_Large"); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func TestXsiTypeRoundTrip(t *testing.T) {
+	gopath, goOutFilePaths := genTestPkgs(t, "xsitype", nil)
+	goTestPkg(t, gopath, goOutFilePaths[0], `package go_Shapes
+
+import (
+	"encoding/xml"
+	"strings"
+	"testing"
+)
+
+func TestXsiType(t *testing.T) {
+	var doc XsdGoPkgHasElem_Shape
+	if err := xml.Unmarshal([]byte("<doc><shape xmlns=\"urn:example:xsitype\" xmlns:xsi=\"http://www.w3.org/2001/XMLSchema-instance\" xsi:type=\"Circle\"><name>c</name><radius>2</radius></shape></doc>"), &doc); err != nil {
+		t.Fatal(err)
+	}
+	circle, ok := doc.Shape.XsdGoPkgXsiType.(*TCircle)
+	if !ok || (circle.Radius != 2) || (circle.Name != "c") {
+		t.Fatalf("expected a *TCircle of radius 2, got %#v", doc.Shape.XsdGoPkgXsiType)
+	}
+	raw, err := xml.Marshal(doc.Shape)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s := string(raw); !(strings.Contains(s, "Circle\"") && strings.Contains(s, ">2</radius>")) {
+		t.Fatalf("the derived type was not encoded with its xsi:type: %s", s)
+	}
+}
+`)
+}
+
+func TestJsonTags(t *testing.T) {
+	for casing, tags := range map[string][]string{
+		JsonTagsNone:      {"`xml:\"birth-date,attr\"`", "`xml:\"urn:example:jsontags homePageURL\"`"},
+		JsonTagsCamelCase: {"`xml:\"birth-date,attr\" json:\"birthDate\"`", "`xml:\"urn:example:jsontags homePageURL\" json:\"homePageUrl\"`"},
+		JsonTagsSnakeCase: {"`xml:\"birth-date,attr\" json:\"birth_date\"`", "`xml:\"urn:example:jsontags homePageURL\" json:\"home_page_url\"`"},
+		JsonTagsAsIs:      {"`xml:\"birth-date,attr\" json:\"birth-date\"`", "`xml:\"urn:example:jsontags homePageURL\" json:\"homePageURL\"`"},
+	} {
+		src, _ := genTestSrc(t, "jsontags", "person.xsd", func(opts *GenOptions) { opts.JsonTags = casing })
+		for _, tag := range tags {
+			if !strings.Contains(src, tag+"\n") {
+				t.Errorf("JsonTags %q: no field tagged %s in\n%s", casing, tag, src)
+			}
+		}
+	}
+}
+
+func TestEnumConstants(t *testing.T) {
+	gopath, goOutFilePaths := genTestPkgs(t, "enum", nil)
+	goTestPkg(t, gopath, goOutFilePaths[0], `package go_Colors
+
+import "testing"
+
+func TestEnum(t *testing.T) {
+	if (TColorRed.String() != "red") || (TColorDarkblue.String() != "dark blue") {
+		t.Fatalf("unexpected constants %q and %q", TColorRed, TColorDarkblue)
+	}
+	if v, err := ParseTColor("dark blue"); (err != nil) || (v != TColorDarkblue) || !v.IsValid() {
+		t.Fatalf("ParseTColor(\"dark blue\") = %q, %v", v, err)
+	}
+	if (TColorEmpty.String() != "") || (TColorEmpty_2.String() != "-") {
+		t.Fatalf("unexpected constants %q and %q for the values without identifier characters", TColorEmpty, TColorEmpty_2)
+	}
+	if v, err := ParseTColor("green"); (err == nil) || TColor("green").IsValid() {
+		t.Fatalf("ParseTColor(\"green\") = %q, %v", v, err)
+	}
+}
+`)
+}
+
+func TestSplitFilesBuild(t *testing.T) {
+	gopath, goOutFilePaths := genTestPkgs(t, "validate", func(opts *GenOptions) { opts.SplitFiles = true })
+	dirPath := filepath.Dir(goOutFilePaths[0])
+	for fileName, typeName := range map[string]string{"order.xsd.simpletype.sku.go": "TSku", "order.xsd.complextype.item.go": "TItem", "order.xsd.element.order.go": "XsdGoPkgHasElem_Order"} {
+		if raw, err := ioutil.ReadFile(filepath.Join(dirPath, fileName)); err != nil {
+			t.Error(err)
+		} else if !strings.Contains(string(raw), "\ntype "+typeName+" ") {
+			t.Errorf("%s does not declare %s", fileName, typeName)
+		}
+	}
+	if raw, err := ioutil.ReadFile(goOutFilePaths[0]); (err != nil) || strings.Contains(string(raw), "\ntype TItem ") {
+		t.Errorf("the main file %s still declares TItem (%v)", goOutFilePaths[0], err)
+	}
+	goTool(t, gopath, goOutFilePaths[0], "vet")
+}
+
+func TestPackagesNameAndPlacePkgs(t *testing.T) {
+	var libDirPath string
+	gopath, goOutFilePaths := genTestPkgs(t, "imported", func(opts *GenOptions) {
+		libDirPath = filepath.Join(opts.BaseCodePath, "pkgs", "lib")
+		opts.Packages = map[string]*GoPkgOptions{"urn:example:lib": {Name: "lib", Dir: libDirPath}, "urn:example:main": {Name: "shop"}}
+	})
+	if len(goOutFilePaths) != 2 {
+		t.Fatalf("expected 2 packages, got %v", goOutFilePaths)
+	}
+	for _, goOutFilePath := range goOutFilePaths {
+		src, err := ioutil.ReadFile(goOutFilePath)
+		if err != nil {
+			t.Fatal(err)
+		}
+		switch filepath.Base(goOutFilePath) {
+		case "lib.xsd.go":
+			if (filepath.Dir(goOutFilePath) != libDirPath) || !strings.Contains(string(src), "\npackage lib\n") {
+				t.Errorf("the package of lib.xsd is not named lib in %s:\n%s", libDirPath, src)
+			}
+		case "main.xsd.go":
+			if !(strings.Contains(string(src), "\npackage shop\n") && strings.Contains(string(src), "\"xsdtest/pkgs/lib\"")) {
+				t.Errorf("the package of main.xsd is not named shop or does not import xsdtest/pkgs/lib:\n%s", src)
+			}
+		}
+		goTool(t, gopath, goOutFilePath, "build")
+	}
+}
+
+func TestDefaultAndFixedValues(t *testing.T) {
+	gopath, goOutFilePaths := genTestPkgs(t, "defaults", nil)
+	goTestPkg(t, gopath, goOutFilePaths[0], `package go_Item
+
+import (
+	"encoding/xml"
+	"strings"
+	"testing"
+)
+
+func TestDefaults(t *testing.T) {
+	var doc XsdGoPkgHasElem_Item
+	if err := xml.Unmarshal([]byte("<doc><item xmlns=\"urn:example:defaults\"/></doc>"), &doc); err != nil {
+		t.Fatal(err)
+	}
+	if (doc.Item.Qty != 1) || (doc.Item.Unit != "kg") || (doc.Item.Version != "2.0") {
+		t.Fatalf("the default and fixed values were not applied: %#v", doc.Item)
+	}
+	var item TItem
+	raw, err := xml.Marshal(&item)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s := string(raw); !strings.Contains(s, "version=\"2.0\"") || (item.Version != "") {
+		t.Fatalf("the fixed value was not encoded, or was applied to the instance itself: %s", s)
+	}
+	item.Version = "3.0"
+	if _, err = xml.Marshal(&item); err == nil {
+		t.Fatal("expected an error encoding a value other than the fixed one")
+	}
+}
+`)
+}
+
+func TestNillableElements(t *testing.T) {
+	gopath, goOutFilePaths := genTestPkgs(t, "nillable", nil)
+	goTestPkg(t, gopath, goOutFilePaths[0], `package go_Person
+
+import (
+	"encoding/xml"
+	"strings"
+	"testing"
+)
+
+func TestNil(t *testing.T) {
+	for doc, expected := range map[string]string{
+		"<name>a</name>": "absent",
+		"<name>a</name><birthDate xsi:nil=\"true\"/>": "nil",
+		"<name>a</name><birthDate>2000-01-31</birthDate>": "2000-01-31",
+	} {
+		var p XsdGoPkgHasElem_Person
+		if err := xml.Unmarshal([]byte("<doc><person xmlns=\"urn:example:nillable\" xmlns:xsi=\"http://www.w3.org/2001/XMLSchema-instance\">"+doc+"</person></doc>"), &p); err != nil {
+			t.Fatal(err)
+		}
+		var actual = "absent"
+		if bd := p.Person.BirthDate; (bd != nil) && bd.Nil {
+			actual = "nil"
+		} else if bd != nil {
+			actual = bd.Value.String()
+		}
+		if actual != expected {
+			t.Errorf("%s: expected birthDate %s, got %s", doc, expected, actual)
+		}
+		raw, err := xml.Marshal(p.Person)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if s := string(raw); (expected == "nil") != (strings.Contains(s, "<birthDate") && strings.Contains(s, "nil=\"true\"")) || ((expected == "absent") == strings.Contains(s, "<birthDate")) {
+			t.Errorf("%s: unexpected encoding %s", doc, s)
+		}
+	}
+}
+`)
+}
+
+func TestSubstitutionGroupMembersRoundTrip(t *testing.T) {
+	gopath, goOutFilePaths := genTestPkgs(t, "substgroup", nil)
+	goTestPkg(t, gopath, goOutFilePaths[0], `package go_Drawing
+
+import (
+	"encoding/xml"
+	"strings"
+	"testing"
+)
+
+func TestMembers(t *testing.T) {
+	const shapes = "<circle id=\"a\" radius=\"2\"></circle><square id=\"b\"></square><circle id=\"c\" radius=\"3\"></circle>"
+	var doc XsdGoPkgHasElem_Drawing
+	if err := xml.Unmarshal([]byte("<doc><drawing xmlns=\"urn:example:substgroup\">"+shapes+"</drawing></doc>"), &doc); err != nil {
+		t.Fatal(err)
+	}
+	if len(doc.Drawing.Shapes) != 3 {
+		t.Fatalf("expected 3 shapes, got %#v", doc.Drawing.Shapes)
+	}
+	c1, ok1 := doc.Drawing.Shapes[0].(*TCircle)
+	sq, ok2 := doc.Drawing.Shapes[1].(*TShape)
+	c2, ok3 := doc.Drawing.Shapes[2].(*TCircle)
+	if !(ok1 && ok2 && ok3) || (c1.Radius != 2) || (sq.Id != "b") || (c2.Radius != 3) {
+		t.Fatalf("the shapes were not decoded in document order as their own types: %#v", doc.Drawing.Shapes)
+	}
+	raw, err := xml.Marshal(doc.Drawing)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s := strings.Replace(string(raw), " xmlns=\"urn:example:substgroup\"", "", -1); !strings.Contains(s, shapes) {
+		t.Fatalf("the shapes were not encoded in order as their own elements: %s", s)
+	}
+}
+`)
+}
+
+func TestConstructors(t *testing.T) {
+	gopath, goOutFilePaths := genTestPkgs(t, "constructors", nil)
+	goTestPkg(t, gopath, goOutFilePaths[0], `package go_Booking
+
+import "testing"
+
+func TestNewBooking(t *testing.T) {
+	var booking *TBooking = NewTBooking("concert", 12)
+	if (booking.Event != "concert") || (booking.Seat != 12) || (booking.Class != "economy") || (booking.Note != "") {
+		t.Fatalf("unexpected %#v", booking)
+	}
+}
+`)
+}
+
+func TestMarshalChecks(t *testing.T) {
+	gopath, goOutFilePaths := genTestPkgs(t, "marshalchecks", func(opts *GenOptions) { opts.AddMarshalChecks = true })
+	goTestPkg(t, gopath, goOutFilePaths[0], `package go_Contact
+
+import (
+	"strings"
+	"testing"
+
+	xsdt "github.com/metaleap/go-xsd/types"
+)
+
+func TestCheckBeforeMarshal(t *testing.T) {
+	for _, c := range []struct {
+		path, problem string
+		modify        func(c *TContact)
+	}{
+		{"", "", func(c *TContact) {}},
+		{"@id", "required", func(c *TContact) { c.Id = "" }},
+		{"name", "required", func(c *TContact) { c.Name = "" }},
+		{"phone", "cannot occur along with email", func(c *TContact) { c.Phone = "555-0100" }},
+		{"tag", "at most 2", func(c *TContact) { c.Tags = []xsdt.String{"a", "b", "c"} }},
+		{"", "requires one of the xs:choice alternatives email, phone", func(c *TContact) { c.Email = "" }},
+	} {
+		contact := &TContact{}
+		contact.Id, contact.Name, contact.Email = "c1", "Ann", "ann@example.com"
+		c.modify(contact)
+		err := contact.CheckBeforeMarshal()
+		if cerr, _ := err.(*xsdt.ContentError); (len(c.problem) == 0) && (err != nil) {
+			t.Errorf("unexpected %v", err)
+		} else if (len(c.problem) > 0) && ((cerr == nil) || (cerr.Path != c.path) || !strings.Contains(cerr.Problem, c.problem)) {
+			t.Errorf("expected a *xsdt.ContentError at %q that %s, got %v", c.path, c.problem, err)
+		}
+	}
+}
+`)
+}
+
+//	Tests that with ChoiceUnions, the alternatives of an xs:choice are held by a union field that records which one was decoded,
+//	encodes only that one, and does so at the position of its xs:choice within the xs:sequence.
+func TestChoiceUnions(t *testing.T) {
+	gopath, goOutFilePaths := genTestPkgs(t, "marshalchecks", func(opts *GenOptions) { opts.ChoiceUnions = true })
+	goTestPkg(t, gopath, goOutFilePaths[0], `package go_Contact
+
+import (
+	"encoding/xml"
+	"strings"
+	"testing"
+)
+
+func TestUnionRoundTrip(t *testing.T) {
+	var doc XsdGoPkgHasElem_Contact
+	if err := xml.Unmarshal([]byte(`+"`"+`<doc><contact xmlns="urn:example:marshalchecks" id="c1"><name>Ann</name><phone>555</phone><tag>x</tag></contact></doc>`+"`"+`), &doc); err != nil {
+		t.Fatal(err)
+	}
+	if doc.Contact.EmailOrPhone.Which != "phone" {
+		t.Fatalf("expected the phone alternative, got %q", doc.Contact.EmailOrPhone.Which)
+	}
+	doc.Contact.EmailOrPhone.Email = "ann@example.com"
+	if raw, err := xml.Marshal(doc.Contact); err != nil {
+		t.Fatal(err)
+	} else if s := string(raw); strings.Contains(s, "<email") || !((strings.Index(s, "<name") < strings.Index(s, "<phone")) && (strings.Index(s, "<phone") < strings.Index(s, "<tag"))) {
+		t.Errorf("expected only the phone alternative, between name and tag, got %s", s)
+	}
+}
+`)
+}
+
+//	Tests that with TypedBuiltins, XSD built-in types (and simple types derived from them) are decoded into typed xsdt values
+//	and encoded back in their original lexical forms, and that invalid values fail decoding.
+func TestTypedBuiltins(t *testing.T) {
+	gopath, goOutFilePaths := genTestPkgs(t, "typed", func(opts *GenOptions) { opts.TypedBuiltins = true })
+	goTestPkg(t, gopath, goOutFilePaths[0], `package go_Invoice
+
+import (
+	"encoding/xml"
+	"math/big"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestTypedRoundTrip(t *testing.T) {
+	var doc XsdGoPkgHasElem_Invoice
+	src := `+"`"+`<invoice xmlns="urn:example:typed" href="http://example.com/i/1"><issued>2002-05-30T09:30:10.50+02:00</issued><total>+100.10</total><term>P1M15D</term><scan>aGVsbG8=</scan></invoice>`+"`"+`
+	if err := xml.Unmarshal([]byte("<doc>"+src+"</doc>"), &doc); err != nil {
+		t.Fatal(err)
+	}
+	inv := doc.Invoice
+	if !inv.Issued.Equal(time.Date(2002, 5, 30, 7, 30, 10, 5e8, time.UTC)) || (inv.Total.ToXsdtDecimalValue().Cmp(big.NewRat(1001, 10)) != 0) || (inv.Term.Days != 15) || (string(inv.Scan) != "hello") || (inv.Href.Host != "example.com") {
+		t.Fatalf("unexpected values %#v", inv)
+	}
+	raw, err := xml.Marshal(inv)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, lex := range []string{`+"`"+`href="http://example.com/i/1"`+"`"+`, ">2002-05-30T09:30:10.50+02:00<", ">100.1<", ">P1M15D<", ">aGVsbG8=<"} {
+		if !strings.Contains(string(raw), lex) {
+			t.Errorf("expected %s in %s", lex, raw)
+		}
+	}
+	if err = xml.Unmarshal([]byte("<doc>"+strings.Replace(src, "2002-05-30", "2002-13-30", 1)+"</doc>"), &doc); err == nil {
+		t.Error("expected an error for an invalid xs:dateTime")
+	}
+}
+`)
+}
+
+//	Tests that with TypedBuiltins, xs:gYear, xs:gYearMonth, xs:gMonthDay, xs:gDay and xs:gMonth (and simple types derived from them) are decoded into
+//	their typed xsdt values and encoded back with their time zone indicators as given.
+func TestTypedDateParts(t *testing.T) {
+	gopath, goOutFilePaths := genTestPkgs(t, "gtypes", func(opts *GenOptions) { opts.TypedBuiltins = true })
+	goTestPkg(t, gopath, goOutFilePaths[0], `package go_Period
+
+import (
+	"encoding/xml"
+	"strings"
+	"testing"
+	"time"
+
+	xsdt "github.com/metaleap/go-xsd/types"
+)
+
+func TestDatePartsRoundTrip(t *testing.T) {
+	var doc XsdGoPkgHasElem_Period
+	atts := `+"`"+` year="2024" month="2024-02+01:00" due="--02-29" payday="---15Z" season="--07-00:00"`+"`"+`
+	if err := xml.Unmarshal([]byte(`+"`"+`<doc><period xmlns="urn:example:gtypes"`+"`"+`+atts+"/></doc>"), &doc); err != nil {
+		t.Fatal(err)
+	}
+	p := doc.Period
+	var year xsdt.GYearValue = p.Year.ToXsdtGYearValue()
+	if (year.Year() != 2024) || (p.Month.Month() != time.February) || (p.Due.Day() != 29) || (p.Payday.Day() != 15) || (p.Season.Month() != time.July) {
+		t.Fatalf("unexpected values %#v", p)
+	}
+	if raw, err := xml.Marshal(p); err != nil {
+		t.Fatal(err)
+	} else if !strings.Contains(string(raw), atts+">") {
+		t.Errorf("expected%s in %s", atts, raw)
+	}
+}
+`)
+}
+
+//	Tests that with LexicalFidelity, numbers and bools keep their lexical forms when decoding and re-encoding, while still denoting
+//	their values, and that absent optional attributes and elements stay absent rather than being encoded empty or with their defaults.
+func TestLexicalFidelity(t *testing.T) {
+	gopath, goOutFilePaths := genTestPkgs(t, "lexical", func(opts *GenOptions) { opts.LexicalFidelity = true })
+	goTestPkg(t, gopath, goOutFilePaths[0], `package go_Reading
+
+import (
+	"encoding/xml"
+	"strings"
+	"testing"
+)
+
+func TestLexicalRoundTrip(t *testing.T) {
+	var doc XsdGoPkgHasElem_Reading
+	if err := xml.Unmarshal([]byte(`+"`"+`<doc><reading xmlns="urn:example:lexical" valid="1"><count>+007</count><value> 1.50E2 </value></reading></doc>`+"`"+`), &doc); err != nil {
+		t.Fatal(err)
+	}
+	if r := doc.Reading; (r.Count.Value() != 7) || (r.Value.Value() != 150) || !r.Valid.Value() {
+		t.Fatalf("unexpected values %#v", r)
+	}
+	raw, err := xml.Marshal(doc.Reading)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := string(raw)
+	for _, lex := range []string{`+"`"+`valid="1"`+"`"+`, ">+007<", "> 1.50E2 <"} {
+		if !strings.Contains(s, lex) {
+			t.Errorf("expected %s in %s", lex, s)
+		}
+	}
+	if strings.Contains(s, "note=") || strings.Contains(s, "unit") {
+		t.Errorf("expected the absent note and unit to stay absent in %s", s)
+	}
+}
+`)
+}
+
+//	Tests that with TypedListsAndUnions, xs:list types decode into slices of their item type and xs:union types into the first member type
+//	(in schema order) that a value is valid for, that both encode back into their lexical forms, and that invalid values fail decoding.
+func TestTypedListsAndUnions(t *testing.T) {
+	gopath, goOutFilePaths := genTestPkgs(t, "listsunions", func(opts *GenOptions) { opts.TypedListsAndUnions, opts.AddValidators = true, true })
+	goTestPkg(t, gopath, goOutFilePaths[0], `package go_Sizes
+
+import (
+	"encoding/xml"
+	"strings"
+	"testing"
+)
+
+func TestListsAndUnionsRoundTrip(t *testing.T) {
+	var doc XsdGoPkgHasElem_Shirt
+	src := `+"`"+`<shirt xmlns="urn:example:listsunions"><sizes> 38  40 42 </sizes><fit>40</fit><fit>large</fit></shirt>`+"`"+`
+	if err := xml.Unmarshal([]byte("<doc>"+src+"</doc>"), &doc); err != nil {
+		t.Fatal(err)
+	}
+	s := doc.Shirt
+	if (len(s.Sizes) != 3) || (s.Sizes[2] != 42) {
+		t.Errorf("expected the sizes 38, 40 and 42, got %v", s.Sizes)
+	}
+	if (len(s.Fits) != 2) || (s.Fits[0].Which != "XsdtInt") || (s.Fits[0].XsdtInt != 40) || (s.Fits[1].Which != "TNamed") || (s.Fits[1].TNamed != "large") {
+		t.Errorf("expected an int and a named size, got %#v", s.Fits)
+	}
+	if raw, err := xml.Marshal(s); err != nil {
+		t.Fatal(err)
+	} else if !strings.Contains(string(raw), ">38 40 42<") || !strings.Contains(string(raw), ">40<") || !strings.Contains(string(raw), ">large<") {
+		t.Errorf("unexpected encoding %s", raw)
+	}
+	for _, invalid := range []string{strings.Replace(src, "40 42", "40 XL", 1), strings.Replace(src, "large", "huge", 1)} {
+		if err := xml.Unmarshal([]byte("<doc>"+invalid+"</doc>"), &doc); err == nil {
+			t.Errorf("expected an error for %s", invalid)
+		}
+	}
+}
+`)
+}
+
+//	Tests that local elements and attributes are (un)qualified in xml tags as per their form attributes or else the form defaults of their schema,
+//	so that documents conforming to the schema decode and encode with the namespaces they declare.
+func TestFormDefaults(t *testing.T) {
+	gopath, goOutFilePaths := genTestPkgs(t, "forms", nil)
+	goTestPkg(t, gopath, goOutFilePaths[0], `package go_Person
+
+import (
+	"encoding/xml"
+	"strings"
+	"testing"
+)
+
+func TestFormsRoundTrip(t *testing.T) {
+	var doc XsdGoPkgHasElem_Person
+	if err := xml.Unmarshal([]byte(`+"`"+`<doc><p:person xmlns:p="urn:example:forms" p:id="1" lang="en"><name>Ann</name><p:email>ann@example.com</p:email></p:person></doc>`+"`"+`), &doc); err != nil {
+		t.Fatal(err)
+	}
+	p := doc.Person
+	if (p.Name != "Ann") || (p.Email != "ann@example.com") || (p.Id != "1") || (p.Lang != "en") {
+		t.Fatalf("expected all values decoded, got %#v", p)
+	}
+	raw, err := xml.Marshal(p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var decoded struct {
+		Id    string `+"`"+`xml:"urn:example:forms id,attr"`+"`"+`
+		Lang  string `+"`"+`xml:"lang,attr"`+"`"+`
+		Name  string `+"`"+`xml:"name"`+"`"+`
+		Email string `+"`"+`xml:"urn:example:forms email"`+"`"+`
+	}
+	if err = xml.Unmarshal(raw, &decoded); (err != nil) || (decoded.Id != "1") || (decoded.Lang != "en") || (decoded.Email != "ann@example.com") || strings.Contains(string(raw), "<name xmlns=") {
+		t.Errorf("expected name and lang unqualified, email and id qualified in %s", raw)
+	}
+}
+`)
+}
+
+//	Tests that the text segments and child elements of a mixed complex type are re-encoded in their original order.
+func TestMixedContent(t *testing.T) {
+	gopath, goOutFilePaths := genTestPkgs(t, "mixed", nil)
+	goTestPkg(t, gopath, goOutFilePaths[0], `package go_Para
+
+import (
+	"encoding/xml"
+	"regexp"
+	"testing"
+)
+
+func TestMixedRoundTrip(t *testing.T) {
+	var doc XsdGoPkgHasElem_Para
+	if err := xml.Unmarshal([]byte(`+"`"+`<doc><para xmlns="urn:example:mixed">Hello <b>bold</b> and <i>italic</i>, <b>twice</b> bold.</para></doc>`+"`"+`), &doc); err != nil {
+		t.Fatal(err)
+	}
+	if (len(doc.Para.Bs) != 2) || (len(doc.Para.Is) != 1) {
+		t.Fatalf("expected 2 b and 1 i elements, got %#v", doc.Para)
+	}
+	raw, err := xml.Marshal(doc.Para)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s := regexp.MustCompile(`+"`"+` xmlns="[^"]*"`+"`"+`).ReplaceAllString(string(raw), ""); s != "<TPara>Hello <b>bold</b> and <i>italic</i>, <b>twice</b> bold.</TPara>" {
+		t.Errorf("expected the original order of text and elements, got %s", s)
+	}
+}
+`)
+}
+
+//	Tests that a complex type derived by restriction holds just the elements it declares, with their narrowed occurrences, and the attributes
+//	of its base type that it does not prohibit, rather than embedding its base type, while still implementing the interface recording its derivation.
+func TestNarrowRestrictions(t *testing.T) {
+	gopath, goOutFilePaths := genTestPkgs(t, "restriction", nil)
+	goTestPkg(t, gopath, goOutFilePaths[0], `package go_Order
+
+import (
+	"encoding/xml"
+	"reflect"
+	"testing"
+)
+
+var _ XsdGoPkgRestriction_TItems = &TSingleItem{}
+
+func TestRestrictionRoundTrip(t *testing.T) {
+	var doc XsdGoPkgHasElem_Order
+	if err := xml.Unmarshal([]byte(`+"`"+`<doc><order xmlns="urn:example:restriction" currency="EUR"><item>pen</item></order></doc>`+"`"+`), &doc); err != nil {
+		t.Fatal(err)
+	}
+	if (doc.Order.Item != "pen") || (doc.Order.Currency != "EUR") {
+		t.Errorf("expected a single item and the currency, got %#v", doc.Order)
+	}
+	if _, ok := reflect.TypeOf(TSingleItem{}).FieldByName("Note"); ok {
+		t.Error("expected no field for the prohibited note attribute")
+	}
+	if _, ok := reflect.TypeOf(TSingleItem{}).FieldByName("TItems"); ok {
+		t.Error("expected TItems not to be embedded")
+	}
+}
+`)
+}
+
+//	Tests that Validate() checks the number of occurrences of elements of bounded (or a minimum greater than 1) occurrences against the exported
+//	minOccurs and maxOccurs constants, but not those of optional unbounded elements.
+func TestOccurrenceChecks(t *testing.T) {
+	gopath, goOutFilePaths := genTestPkgs(t, "occurs", func(opts *GenOptions) { opts.AddValidators = true })
+	goTestPkg(t, gopath, goOutFilePaths[0], `package go_Cart
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestCartOccurrences(t *testing.T) {
+	if (TCart_Items_MinOccurs != 2) || (TCart_Items_MaxOccurs != 3) {
+		t.Errorf("expected the item bounds 2 and 3, got %d and %d", TCart_Items_MinOccurs, TCart_Items_MaxOccurs)
+	}
+	if _, ok := reflect.TypeOf(&TCart{}).MethodByName("CheckOccurrences"); !ok {
+		t.Fatal("expected a CheckOccurrences method")
+	}
+	var cart TCart
+	for n, valid := range []bool{false, false, true, true, false} {
+		cart.Items = nil
+		for i := 0; i < n; i++ {
+			cart.Items = append(cart.Items, "pen")
+		}
+		for len(cart.Notes) < 10 {
+			cart.Notes = append(cart.Notes, "gift")
+		}
+		if err := cart.Validate(); (err == nil) != valid {
+			t.Errorf("%d items: expected valid=%v, got %v", n, valid, err)
+		}
+	}
+}
+`)
+}
+
+//	Tests that TypeOverrides generate an XSD built-in type and a named simple type as custom Go types (the latter getting no declaration of its own),
+//	that simple types derived from them convert via their text methods, and that default values are decoded via UnmarshalText.
+func TestTypeOverrides(t *testing.T) {
+	gopath, goOutFilePaths := genTestPkgs(t, "overrides", func(opts *GenOptions) {
+		opts.ApplyDefaults = true
+		opts.TypeOverrides = map[string]*TypeOverride{
+			"{http://www.w3.org/2001/XMLSchema}decimal": {ImportPath: "example.com/money", GoType: "Amount"},
+			"{urn:example:overrides}Sku":                {ImportPath: "example.com/ids", GoType: "Sku"},
+		}
+	})
+	if src, err := ioutil.ReadFile(goOutFilePaths[0]); err != nil {
+		t.Fatal(err)
+	} else if strings.Contains(string(src), "type TSku ") || !strings.Contains(string(src), "type TPrice money.Amount") {
+		t.Errorf("expected no TSku declaration and TPrice to be a money.Amount:\n%s", src)
+	}
+	for impPath, src := range map[string]string{
+		"example.com/money": `package money
+
+import "fmt"
+
+type Amount struct{ Cents int64 }
+
+func (me *Amount) UnmarshalText(text []byte) error {
+	var units, cents int64
+	if _, err := fmt.Sscanf(string(text), "%d.%d", &units, &cents); err != nil {
+		return err
+	}
+	me.Cents = units*100 + cents
+	return nil
+}
+
+func (me *Amount) MarshalText() ([]byte, error) {
+	return []byte(fmt.Sprintf("%d.%02d", me.Cents/100, me.Cents%100)), nil
+}
+`,
+		"example.com/ids": `package ids
+
+import "strings"
+
+type Sku string
+
+func (me *Sku) UnmarshalText(text []byte) error { *me = Sku(strings.ToUpper(string(text))); return nil }
+
+func (me *Sku) MarshalText() ([]byte, error) { return []byte(*me), nil }
+`,
+	} {
+		dirPath := filepath.Join(gopath, "src", filepath.FromSlash(impPath))
+		if err := os.MkdirAll(dirPath, 0755); err != nil {
+			t.Fatal(err)
+		} else if err = ioutil.WriteFile(filepath.Join(dirPath, path.Base(impPath)+".go"), []byte(src), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	goTestPkg(t, gopath, goOutFilePaths[0], `package go_Product
+
+import (
+	"encoding/xml"
+	"strings"
+	"testing"
+)
+
+func TestOverridesRoundTrip(t *testing.T) {
+	var doc XsdGoPkgHasElem_Product
+	if err := xml.Unmarshal([]byte(`+"`"+`<doc><product xmlns="urn:example:overrides"><sku>abc-123</sku><price>12.34</price></product></doc>`+"`"+`), &doc); err != nil {
+		t.Fatal(err)
+	}
+	p := doc.Product
+	if (p.Sku != "ABC-123") || (p.Price.ToMoneyAmount().Cents != 1234) || (p.Weight.Cents != 150) {
+		t.Errorf("expected the custom types decoded and the default weight applied, got %#v", p)
+	}
+	if p.Price.String() != "12.34" {
+		t.Errorf("expected the price as text, got %q", p.Price.String())
+	}
+	if raw, err := xml.Marshal(p); (err != nil) || !strings.Contains(string(raw), ">12.34<") || !strings.Contains(string(raw), ">1.50<") {
+		t.Errorf("unexpected encoding %s %v", raw, err)
+	}
+}
+`)
+}
+
+//	Tests that with PresenceAccessors, optional attributes and elements present with zero values are told apart from absent ones,
+//	and that their accessors return default values for absent ones and make them present or absent again.
+func TestPresenceAccessors(t *testing.T) {
+	gopath, goOutFilePaths := genTestPkgs(t, "presence", func(opts *GenOptions) { opts.PresenceAccessors = true })
+	goTestPkg(t, gopath, goOutFilePaths[0], `package go_Item
+
+import (
+	"encoding/xml"
+	"strings"
+	"testing"
+)
+
+func TestPresenceRoundTrip(t *testing.T) {
+	var doc XsdGoPkgHasElem_Item
+	if err := xml.Unmarshal([]byte(`+"`"+`<doc><item xmlns="urn:example:presence" count="0"><color></color></item></doc>`+"`"+`), &doc); err != nil {
+		t.Fatal(err)
+	}
+	item := doc.Item
+	if !item.HasCount() || (item.GetCount() != 0) || !item.HasColor() || (item.GetColor() != "") {
+		t.Errorf("expected a zero count and an empty color to be present, got %#v", item)
+	}
+	if item.ClearCount(); item.HasCount() || (item.GetCount() != 5) {
+		t.Errorf("expected an absent count to get its default value, got %v", item.GetCount())
+	}
+	item.ClearColor()
+	if raw, err := xml.Marshal(item); (err != nil) || strings.Contains(string(raw), "count=") || strings.Contains(string(raw), "color") {
+		t.Errorf("expected absent count and color not to be encoded, got %s %v", raw, err)
+	}
+	if item.SetCount(0); !item.HasCount() {
+		t.Error("expected a count set to 0 to be present")
+	} else if raw, err := xml.Marshal(item); (err != nil) || !strings.Contains(string(raw), `+"`"+`count="0"`+"`"+`) {
+		t.Errorf("expected count=\"0\" to be encoded, got %s %v", raw, err)
+	}
+}
+`)
+}
+
+//	Tests that with AddCloneAndEqual, Clone() copies element slices and wildcard-captured content deeply, and that Equal() compares them,
+//	taking wildcard attributes in any order as equal.
+func TestCloneAndEqual(t *testing.T) {
+	gopath, goOutFilePaths := genTestPkgs(t, "clone", func(opts *GenOptions) { opts.AddCloneAndEqual = true })
+	goTestPkg(t, gopath, goOutFilePaths[0], `package go_Order
+
+import (
+	"encoding/xml"
+	"testing"
+)
+
+func TestCloneAndEqual(t *testing.T) {
+	var doc, reordered XsdGoPkgHasElem_Order
+	src := `+"`"+`<order xmlns="urn:example:clone" xmlns:x="urn:example:x" x:a="1" x:b="2"><item sku="pen" qty="1"/><item sku="ink" qty="2"/><x:gift>box</x:gift></order>`+"`"+`
+	if err := xml.Unmarshal([]byte("<doc>"+src+"</doc>"), &doc); err != nil {
+		t.Fatal(err)
+	} else if err = xml.Unmarshal([]byte(`+"`"+`<doc><order xmlns="urn:example:clone" xmlns:x="urn:example:x" x:b="2" x:a="1"><item sku="pen" qty="1"/><item sku="ink" qty="2"/><x:gift>box</x:gift></order></doc>`+"`"+`), &reordered); err != nil {
+		t.Fatal(err)
+	}
+	order := doc.Order
+	if (len(order.Items) != 2) || (len(order.XsdGoPkgAny) != 1) || (len(order.XsdGoPkgAnyAttrs) != 2) {
+		t.Fatalf("expected 2 items, a wildcard element and 2 wildcard attributes, got %#v", order)
+	}
+	if !order.Equal(reordered.Order) {
+		t.Error("expected orders differing in the order of their wildcard attributes to be equal")
+	}
+	c := order.Clone()
+	if (c == order) || !c.Equal(order) || !order.Equal(c) {
+		t.Fatal("expected an equal copy")
+	}
+	c.Items[1].Qty = 3
+	c.XsdGoPkgAny[0].InnerXML = "bag"
+	c.XsdGoPkgAnyAttrs[0].Value = "0"
+	if (order.Items[1].Qty != 2) || (order.XsdGoPkgAny[0].InnerXML != "box") || (order.XsdGoPkgAnyAttrs[0].Value != "1") {
+		t.Errorf("expected changes of the copy not to affect the original, got %#v", order)
+	}
+	if c.Equal(order) {
+		t.Error("expected a changed copy not to be equal")
+	}
+	var none *TxsdOrder
+	if (none.Clone() != nil) || !none.Equal(nil) || none.Equal(order) {
+		t.Error("expected nil to be cloned as nil and equal only nil")
+	}
+}
+`)
+}
+
+//	Tests that PkgGen.AddDTOs generates flat DTO types of plain Go types, collapsing complex types of nothing but simple content and nillable
+//	elements into plain values and promoting the fields of base types, and that FromDTO restores what ToDTO captured.
+func TestDTOs(t *testing.T) {
+	setup := func(opts *GenOptions) { opts.AddDTOs = true }
+	src, _ := genTestSrc(t, "dto", "order.xsd", setup)
+	for typeName, fields := range map[string][]string{
+		"TItemDTO":     []string{"Sku   string", "Qty   uint16", "Price *string"},
+		"TxsdOrderDTO": []string{"Items []*TItemDTO", "Note  *string", "Id    int32", "Rush  bool"},
+	} {
+		if decl := goTypeDecl(t, src, typeName); decl != "type "+typeName+" struct {\n\t"+strings.Join(fields, "\n\t")+"\n}" {
+			t.Errorf("expected the fields %v in\n%s", fields, decl)
+		}
+	}
+	gopath, goOutFilePaths := genTestPkgs(t, "dto", setup)
+	goTestPkg(t, gopath, goOutFilePaths[0], `package go_Order
+
+import (
+	"encoding/xml"
+	"testing"
+)
+
+func TestDTOs(t *testing.T) {
+	var doc, restored XsdGoPkgHasElem_Order
+	src := `+"`"+`<order xmlns="urn:example:dto" xmlns:xsi="http://www.w3.org/2001/XMLSchema-instance" id="7" rush="true"><item sku="pen"><qty>2</qty><price>1.50</price></item><item sku="ink"><qty>1</qty><price>3.25</price></item><note xsi:nil="true"></note></order>`+"`"+`
+	if err := xml.Unmarshal([]byte("<doc>"+src+"</doc>"), &doc); err != nil {
+		t.Fatal(err)
+	}
+	d := doc.Order.ToDTO()
+	if (d.Id != 7) || !d.Rush || (d.Note != nil) || (len(d.Items) != 2) {
+		t.Fatalf("expected the DTO of order 7, got %#v", d)
+	}
+	if item := d.Items[1]; (item.Sku != "ink") || (item.Qty != 1) || (item.Price == nil) || (*item.Price != "3.25") {
+		t.Errorf("expected the DTO of the ink item, got %#v", item)
+	}
+	restored.Order = &TxsdOrder{}
+	restored.Order.FromDTO(d)
+	if (len(restored.Order.Items) != 2) || (restored.Order.Items[0].Sku != "pen") || (restored.Order.Items[0].Qty != 2) || (restored.Order.Items[0].Price.XsdGoPkgValue != "1.50") || (restored.Order.Note != nil) || (restored.Order.Id != 7) {
+		t.Errorf("expected the order restored from its DTO, got %#v", restored.Order)
+	}
+	note := "gift"
+	d.Note, d.Items[0].Sku = &note, "pencil"
+	if restored.Order.FromDTO(d); (restored.Order.Note == nil) || (restored.Order.Note.Value != "gift") || (doc.Order.Items[0].Sku != "pen") {
+		t.Errorf("expected the changed DTO to set the note of the restored order only, got %#v", restored.Order)
+	}
+	var none *TxsdOrder
+	if none.ToDTO() != nil {
+		t.Error("expected no DTO of nil")
+	}
+}
+`)
+}
+
+//	Tests that xsdt.XmlGoLiteral turns an instance document into the Go composite literal of its decoded value, omitting zero fields, eliding the
+//	types of elements and constructing typed built-in values via xsdt.FromText, and that the literal compiles to a value equal to the decoded one.
+func TestXmlGoLiteral(t *testing.T) {
+	expected := `&TxsdOrder{
+	XsdGoPkgHasElems_ItemsequenceTxsdOrderorderschema_Item_TItem_: XsdGoPkgHasElems_ItemsequenceTxsdOrderorderschema_Item_TItem_{
+		Items: []*TItem{
+			{
+				XsdGoPkgHasAttr_Sku_XsdtString_: XsdGoPkgHasAttr_Sku_XsdtString_{
+					Sku: "pen",
+				},
+				XsdGoPkgHasAttr_Qty_XsdtInt_: XsdGoPkgHasAttr_Qty_XsdtInt_{
+					Qty: 2,
+				},
+			},
+			{
+				XsdGoPkgHasAttr_Sku_XsdtString_: XsdGoPkgHasAttr_Sku_XsdtString_{
+					Sku: "ink",
+				},
+			},
+		},
+	},
+	XsdGoPkgHasAttr_Status_TStatus_: XsdGoPkgHasAttr_Status_TStatus_{
+		Status: "open",
+	},
+	XsdGoPkgHasAttr_Rush_XsdtBoolean_: XsdGoPkgHasAttr_Rush_XsdtBoolean_{
+		Rush: true,
+	},
+	XsdGoPkgHasAttr_Placed_XsdtDateTimeValue_: XsdGoPkgHasAttr_Placed_XsdtDateTimeValue_{
+		Placed: *xsdt.FromText(new(xsdt.DateTimeValue), "2024-02-29T12:00:00Z").(*xsdt.DateTimeValue),
+	},
+}`
+	gopath, goOutFilePaths := genTestPkgs(t, "literal", func(opts *GenOptions) { opts.TypedBuiltins = true })
+	goTestPkg(t, gopath, goOutFilePaths[0], `package go_Order
+
+import (
+	"strings"
+	"testing"
+
+	xsdt "github.com/metaleap/go-xsd/types"
+)
+
+func TestXmlGoLiteral(t *testing.T) {
+	var doc TxsdOrder
+	src := `+"`"+`<order xmlns="urn:example:literal" status="open" rush="true" placed="2024-02-29T12:00:00Z"><item sku="pen" qty="2"/><item sku="ink"/></order>`+"`"+`
+	lit, err := xsdt.XmlGoLiteral(strings.NewReader(src), &doc, "xsdtest/literal/order.xsd_go")
+	if err != nil {
+		t.Fatal(err)
+	} else if lit != `+"`"+expected+"`"+` {
+		t.Errorf("unexpected literal\n%s", lit)
+	}
+	if expected := (`+expected+`); !xsdt.EqualValues(expected, &doc) {
+		t.Errorf("expected the literal to construct the decoded value %#v", doc)
+	}
+	if lit = xsdt.GoLiteral(&doc, ""); !strings.HasPrefix(lit, "&go_Order.TxsdOrder{\n\tXsdGoPkgHasElems_ItemsequenceTxsdOrderorderschema_Item_TItem_: go_Order.XsdGoPkgHasElems_") {
+		t.Errorf("expected the types to be qualified by their package names, got\n%s", lit)
+	}
+}
+`)
+}
+
+//	Tests that with the default options, a field named after a method generated for its struct type (here an element named "validate") gets "_"
+//	appended, so that the generated package builds and the field and method can both be used.
+func TestReservedFieldNames(t *testing.T) {
+	gopath, goOutFilePaths := genTestPkgs(t, "identifiers", nil)
+	goTestPkg(t, gopath, goOutFilePaths[0], `package go_Names
+
+import (
+	"encoding/xml"
+	"testing"
+)
+
+func TestValidateField(t *testing.T) {
+	var doc Titem
+	if err := xml.Unmarshal([]byte(`+"`"+`<order xmlns="urn:example:identifiers"><customer-id>c1</customer-id><validate>true</validate><type sku="pen"/></order>`+"`"+`), &doc); err != nil {
+		t.Fatal(err)
+	} else if !doc.Validate_ {
+		t.Errorf("expected the validate element to be decoded into the Validate_ field")
+	} else if err = doc.Validate(); err != nil {
+		t.Error(err)
+	}
+}
+`)
+}
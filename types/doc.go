@@ -3,4 +3,14 @@
 //	Maps all XSD built-in simple-types to Go types, which affords us easy mapping of any XSD type references in the schema to Go imports: every xs:string and xs:boolean automatically becomes xsdt.String and xsdt.Boolean etc.
 //	Types are mapped to Go types depending on how encoding/xml.Unmarshal() can handle them: ie. it parses bools and numbers, but dates/durations have too many format mismatches and thus are just declared string types.
 //	Same for base64- and hex-encoded binary data: since Unmarshal() won't decode them, we leave them as strings. If you need their binary data, your code needs to import Go's base64/hex codec packages and use them as necessary.
+//	Alternatively, the *Value types (such as DateTimeValue, DecimalValue or Base64BinaryValue) hold these as typed values (wrapping time.Time, *big.Rat, []byte etc.),
+//	decoding and encoding their lexical forms via their UnmarshalText() / MarshalText() methods. Generated packages use them if xsd.PkgGen.TypedBuiltins is set.
+//	The Lexical* types (such as LexicalInt or LexicalBoolean) hold the lexical forms of numbers and bools verbatim, their Value() methods returning the values they denote.
+//	Generated packages use them if xsd.PkgGen.LexicalFidelity is set. The Value() methods of the string-based Date, Decimal etc. types return their *Value counterparts.
+//	ParseLexical parses list items and union members for the list and union types of generated packages if xsd.PkgGen.TypedListsAndUnions is set.
+//	Prefixes (and MarshalPrefixed, using the prefixes registered via RegisterPrefix) marshals values of generated types with all namespaces declared on the root element, using preferred prefixes.
+//	Namespaces maps the prefixes declared in a schema to their namespaces, resolving and constructing QNameValues with them.
+//	Pattern and CompilePattern compile xs:pattern facet values, translated from the XSD regular-expression dialect by TranslatePattern.
+//	ReadCSV and WriteCSV read and write the CSV records of generated packages if xsd.PkgGen.AddCSV is set, reporting invalid data as CSVErrors.
+//	GoLiteral (and XmlGoLiteral, decoding an XML instance document first) returns the Go expression constructing a value of generated types, such as for test fixtures.
 package xsdt
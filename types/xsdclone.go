@@ -0,0 +1,193 @@
+package xsdt
+
+import (
+	"bytes"
+	"encoding"
+	"math/big"
+	"reflect"
+)
+
+var (
+	typeBigRat        = reflect.TypeOf((*big.Rat)(nil))
+	typeTextMarshaler = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+)
+
+//	A helper function for the Clone() methods of generated wrapper packages: sets the value that ptr points to to a deep copy of v, for fields of types
+//	that have no Clone() method of their own (such as typed built-in types, wildcard-captured content, xsi:type instances and the types of other packages).
+//	Pointers, interfaces, slices, maps and the exported fields of structs are copied recursively, except that pointers of types having a Clone() method
+//	that returns their type are copied by calling it, and *big.Rats by copying the number they denote. Unexported struct fields are copied as they are.
+func CloneValue(ptr, v interface{}) {
+	if v != nil {
+		reflect.ValueOf(ptr).Elem().Set(cloneValue(reflect.ValueOf(v)))
+	}
+}
+
+func cloneValue(v reflect.Value) (c reflect.Value) {
+	switch v.Kind() {
+	case reflect.Ptr:
+		if v.IsNil() {
+			return v
+		} else if v.Type() == typeBigRat {
+			return reflect.ValueOf(new(big.Rat).Set(v.Interface().(*big.Rat)))
+		} else if m := v.MethodByName("Clone"); m.IsValid() && (m.Type().NumIn() == 0) && (m.Type().NumOut() == 1) && (m.Type().Out(0) == v.Type()) {
+			return m.Call(nil)[0]
+		}
+		c = reflect.New(v.Type().Elem())
+		c.Elem().Set(cloneValue(v.Elem()))
+	case reflect.Interface:
+		if v.IsNil() {
+			return v
+		}
+		c = reflect.New(v.Type()).Elem()
+		c.Set(cloneValue(v.Elem()))
+	case reflect.Slice:
+		if v.IsNil() {
+			return v
+		}
+		c = reflect.MakeSlice(v.Type(), v.Len(), v.Len())
+		for i := 0; i < v.Len(); i++ {
+			c.Index(i).Set(cloneValue(v.Index(i)))
+		}
+	case reflect.Map:
+		if v.IsNil() {
+			return v
+		}
+		c = reflect.MakeMap(v.Type())
+		for _, k := range v.MapKeys() {
+			c.SetMapIndex(k, cloneValue(v.MapIndex(k)))
+		}
+	case reflect.Struct:
+		c = reflect.New(v.Type()).Elem()
+		c.Set(v)
+		for i := 0; i < v.NumField(); i++ {
+			if len(v.Type().Field(i).PkgPath) == 0 {
+				c.Field(i).Set(cloneValue(v.Field(i)))
+			}
+		}
+	default:
+		c = v
+	}
+	return
+}
+
+//	A helper function for the Equal() methods of generated wrapper packages: returns whether the values that a and b point to are equal, for fields of types
+//	that are not compared with == or by an Equal() method of their own. Unlike reflect.DeepEqual, values of types having an Equal() method taking their type
+//	(such as the struct types of generated wrapper packages, time.Time, AnyElement, AnyAttrs and Node) are compared by calling it, values of other types implementing
+//	encoding.TextMarshaler (such as the typed built-in types) by their texts, *big.Rats by the numbers they denote, nil slices and maps equal empty ones,
+//	and unexported struct fields are not compared.
+func EqualValues(a, b interface{}) bool {
+	if (a == nil) || (b == nil) {
+		return a == b
+	}
+	va, vb := reflect.ValueOf(a), reflect.ValueOf(b)
+	return (va.Type() == vb.Type()) && equalValues(va, vb)
+}
+
+func equalValues(a, b reflect.Value) bool {
+	t := a.Type()
+	if m, ok := t.MethodByName("Equal"); ok && isEqualMethod(m, t) {
+		return a.Method(m.Index).Call([]reflect.Value{b})[0].Bool()
+	} else if pt := reflect.PtrTo(t); a.CanAddr() && b.CanAddr() {
+		if m, ok := pt.MethodByName("Equal"); ok && isEqualMethod(m, pt) {
+			return a.Addr().Method(m.Index).Call([]reflect.Value{b.Addr()})[0].Bool()
+		}
+	}
+	switch t.Kind() {
+	case reflect.Ptr, reflect.Interface:
+		if a.IsNil() || b.IsNil() {
+			return a.IsNil() && b.IsNil()
+		} else if t == typeBigRat {
+			return a.Interface().(*big.Rat).Cmp(b.Interface().(*big.Rat)) == 0
+		}
+		return (a.Elem().Type() == b.Elem().Type()) && equalValues(a.Elem(), b.Elem())
+	}
+	if t.Implements(typeTextMarshaler) {
+		return equalTexts(a.Interface().(encoding.TextMarshaler), b.Interface().(encoding.TextMarshaler))
+	} else if a.CanAddr() && b.CanAddr() && reflect.PtrTo(t).Implements(typeTextMarshaler) {
+		return equalTexts(a.Addr().Interface().(encoding.TextMarshaler), b.Addr().Interface().(encoding.TextMarshaler))
+	}
+	switch t.Kind() {
+	case reflect.Slice, reflect.Array:
+		if a.Len() != b.Len() {
+			return false
+		}
+		for i := 0; i < a.Len(); i++ {
+			if !equalValues(a.Index(i), b.Index(i)) {
+				return false
+			}
+		}
+	case reflect.Map:
+		if a.Len() != b.Len() {
+			return false
+		}
+		for _, k := range a.MapKeys() {
+			if bv := b.MapIndex(k); (!bv.IsValid()) || !equalValues(a.MapIndex(k), bv) {
+				return false
+			}
+		}
+	case reflect.Struct:
+		for i := 0; i < a.NumField(); i++ {
+			if (len(t.Field(i).PkgPath) == 0) && !equalValues(a.Field(i), b.Field(i)) {
+				return false
+			}
+		}
+	case reflect.String:
+		return a.String() == b.String()
+	case reflect.Bool:
+		return a.Bool() == b.Bool()
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return a.Int() == b.Int()
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return a.Uint() == b.Uint()
+	case reflect.Float32, reflect.Float64:
+		return a.Float() == b.Float()
+	case reflect.Complex64, reflect.Complex128:
+		return a.Complex() == b.Complex()
+	default:
+		return a.Pointer() == b.Pointer()
+	}
+	return true
+}
+
+//	Returns whether m (a method of t) is an Equal() method taking a t and returning a bool.
+func isEqualMethod(m reflect.Method, t reflect.Type) bool {
+	return (m.Type.NumIn() == 2) && (m.Type.In(1) == t) && (m.Type.NumOut() == 1) && (m.Type.Out(0).Kind() == reflect.Bool)
+}
+
+func equalTexts(a, b encoding.TextMarshaler) bool {
+	ta, erra := a.MarshalText()
+	tb, errb := b.MarshalText()
+	return (erra == nil) && (errb == nil) && bytes.Equal(ta, tb)
+}
+
+//	Returns whether this AnyElement and other have the same name, the same attributes (see AnyAttrs.Equal) and the same inner XML.
+func (me AnyElement) Equal(other AnyElement) bool {
+	return (me.XMLName == other.XMLName) && AnyAttrs(me.Attrs).Equal(AnyAttrs(other.Attrs)) && (me.InnerXML == other.InnerXML)
+}
+
+//	Returns whether this Node and other have the same name, the same attributes (see AnyAttrs.Equal), the same character data and equal child elements.
+func (me Node) Equal(other Node) bool {
+	if (me.XMLName != other.XMLName) || (me.Text != other.Text) || (len(me.Nodes) != len(other.Nodes)) || !AnyAttrs(me.Attrs).Equal(AnyAttrs(other.Attrs)) {
+		return false
+	}
+	for i := range me.Nodes {
+		if !me.Nodes[i].Equal(other.Nodes[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+//	Returns whether these AnyAttrs and other hold the same attributes (other than namespace declarations) with the same values, in any order.
+func (me AnyAttrs) Equal(other AnyAttrs) bool {
+	ma, mb := me.Map(), other.Map()
+	if len(ma) != len(mb) {
+		return false
+	}
+	for name, value := range ma {
+		if v, ok := mb[name]; (!ok) || (v != value) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,120 @@
+package xsdt
+
+import (
+	"encoding"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+)
+
+//	Returned by the UnmarshalCSV() and MarshalCSV() methods of generated wrapper packages if CSV data does not map to their records, or a record is invalid.
+type CSVError struct {
+	//	The 1-based number of the offending row, the header row being row 1.
+	Row int
+
+	//	The name of the offending column, or empty if the row as a whole is at fault.
+	Column string
+
+	//	The underlying error, such as a *LexicalError or *FacetError.
+	Err error
+}
+
+//	Returns the row and column (if any) at fault, followed by the underlying error.
+func (me *CSVError) Error() string {
+	if len(me.Column) == 0 {
+		return fmt.Sprintf("CSV row %d: %v", me.Row, me.Err)
+	}
+	return fmt.Sprintf("CSV row %d, column %s: %v", me.Row, me.Column, me.Err)
+}
+
+//	A helper function for the UnmarshalCSV() methods of generated wrapper packages: reads the CSV data r, whose first row names the columns of the
+//	records, and calls add with these names and the values of every subsequent row. A header row naming a column not among columns (or one twice), or
+//	lacking any of the required ones, is rejected. Errors returned by add are returned as a *CSVError of the row (if one already, with its Row set),
+//	those of reading the CSV data as they are.
+func ReadCSV(r io.Reader, columns, required []string, add func(header, values []string) error) (err error) {
+	var header, values []string
+	var known, seen = map[string]bool{}, map[string]bool{}
+	var cr = csv.NewReader(r)
+	if header, err = cr.Read(); err == io.EOF {
+		return &CSVError{Row: 1, Err: errors.New("no header row")}
+	} else if err != nil {
+		return
+	}
+	for _, col := range columns {
+		known[col] = true
+	}
+	for _, col := range header {
+		if !known[col] {
+			return &CSVError{Row: 1, Column: col, Err: errors.New("unknown column")}
+		} else if seen[col] {
+			return &CSVError{Row: 1, Column: col, Err: errors.New("duplicate column")}
+		}
+		seen[col] = true
+	}
+	for _, col := range required {
+		if !seen[col] {
+			return &CSVError{Row: 1, Column: col, Err: errors.New("missing required column")}
+		}
+	}
+	for row := 2; ; row++ {
+		if values, err = cr.Read(); err == io.EOF {
+			return nil
+		} else if err != nil {
+			return
+		}
+		if err = add(header, values); err != nil {
+			if ce, ok := err.(*CSVError); ok {
+				ce.Row = row
+			} else {
+				err = &CSVError{Row: row, Err: err}
+			}
+			return
+		}
+	}
+}
+
+//	A helper function for the MarshalCSV() methods of generated wrapper packages: writes a header row naming the columns to w, followed by the records.
+func WriteCSV(w io.Writer, columns []string, records [][]string) (err error) {
+	cw := csv.NewWriter(w)
+	if err = cw.Write(columns); err == nil {
+		err = cw.WriteAll(records)
+	}
+	return
+}
+
+//	A helper function for the MarshalCSVRecord() methods of generated wrapper packages: returns the text of the value that ptr points to (via its
+//	MarshalText() or String() method), or "" if ptr points to a nil pointer.
+func CSVValue(ptr interface{}) string {
+	if rv := reflect.ValueOf(ptr).Elem(); rv.Kind() == reflect.Ptr {
+		if rv.IsNil() {
+			return ""
+		}
+		ptr = rv.Interface()
+	}
+	if tm, ok := ptr.(encoding.TextMarshaler); ok {
+		return Text(tm)
+	} else if s, ok := ptr.(fmt.Stringer); ok {
+		return s.String()
+	}
+	return fmt.Sprint(reflect.ValueOf(ptr).Elem().Interface())
+}
+
+//	A helper function for the UnmarshalCSVRecord() methods of generated wrapper packages: sets the value that ptr points to from s as ParseLexical does,
+//	allocating it first if ptr points to a pointer. If s is empty and optional is set, the value is set to its zero value (or the pointer to nil) instead,
+//	as for an absent attribute or element.
+func ParseCSVValue(ptr interface{}, builtin, s string, optional bool) (err error) {
+	rv := reflect.ValueOf(ptr).Elem()
+	if rv.Set(reflect.Zero(rv.Type())); (len(s) == 0) && optional {
+		return
+	}
+	if rv.Kind() == reflect.Ptr {
+		v := reflect.New(rv.Type().Elem())
+		if err = ParseLexical(v.Interface(), builtin, s); err == nil {
+			rv.Set(v)
+		}
+		return
+	}
+	return ParseLexical(ptr, builtin, s)
+}
@@ -0,0 +1,600 @@
+package xsdt
+
+import (
+	"encoding"
+	"fmt"
+	"math/big"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+//	The lexical counterparts of the non-string XSD built-in types, as used by generated wrapper packages if xsd.PkgGen.LexicalFidelity is set.
+//	Unlike Boolean, Int etc., they hold values verbatim (including leading zeroes, signs, exponents and whitespace), so that decoding and then
+//	encoding them reproduces their original lexical forms. Their Value() methods return the values they denote.
+
+//	Returns the canonical form of the lexical integer s, that is s without leading and trailing whitespace, a leading plus sign and leading zeroes.
+func lexicalInteger(s string) string {
+	var sign string
+	if s = strings.TrimSpace(s); strings.HasPrefix(s, "-") || strings.HasPrefix(s, "+") {
+		sign, s = strings.TrimPrefix(s[:1], "+"), s[1:]
+	}
+	digits := strings.TrimLeft(s, "0")
+	if (len(digits) == 0) && (len(s) > 0) {
+		return "0"
+	}
+	return sign + digits
+}
+
+//	Holds the lexical form of an xs:boolean value verbatim.
+type LexicalBoolean string
+
+//	Since this is just a simple String type, this merely sets the current value from the specified string.
+func (me *LexicalBoolean) Set(v string) {
+	*me = LexicalBoolean(v)
+}
+
+//	Since this is just a simple String type, this merely returns its current string value.
+func (me LexicalBoolean) String() string {
+	return string(me)
+}
+
+//	Returns the Boolean value denoted by its lexical form (disregarding leading and trailing whitespace).
+func (me LexicalBoolean) Value() (v Boolean) {
+	v.Set(strings.TrimSpace(string(me)))
+	return
+}
+
+//	A convenience interface that declares a type conversion to LexicalBoolean.
+type ToXsdtLexicalBoolean interface {
+	ToXsdtLexicalBoolean() LexicalBoolean
+}
+
+//	Holds the lexical form of an xs:byte value verbatim.
+type LexicalByte string
+
+//	Since this is just a simple String type, this merely sets the current value from the specified string.
+func (me *LexicalByte) Set(v string) {
+	*me = LexicalByte(v)
+}
+
+//	Since this is just a simple String type, this merely returns its current string value.
+func (me LexicalByte) String() string {
+	return string(me)
+}
+
+//	Returns the Byte value denoted by its lexical form (disregarding leading and trailing whitespace, a leading plus sign and leading zeroes).
+func (me LexicalByte) Value() (v Byte) {
+	v.Set(lexicalInteger(string(me)))
+	return
+}
+
+//	A convenience interface that declares a type conversion to LexicalByte.
+type ToXsdtLexicalByte interface {
+	ToXsdtLexicalByte() LexicalByte
+}
+
+//	Holds the lexical form of an xs:double value verbatim.
+type LexicalDouble string
+
+//	Since this is just a simple String type, this merely sets the current value from the specified string.
+func (me *LexicalDouble) Set(v string) {
+	*me = LexicalDouble(v)
+}
+
+//	Since this is just a simple String type, this merely returns its current string value.
+func (me LexicalDouble) String() string {
+	return string(me)
+}
+
+//	Returns the Double value denoted by its lexical form (disregarding leading and trailing whitespace).
+func (me LexicalDouble) Value() (v Double) {
+	v.Set(strings.TrimSpace(string(me)))
+	return
+}
+
+//	A convenience interface that declares a type conversion to LexicalDouble.
+type ToXsdtLexicalDouble interface {
+	ToXsdtLexicalDouble() LexicalDouble
+}
+
+//	Holds the lexical form of an xs:float value verbatim.
+type LexicalFloat string
+
+//	Since this is just a simple String type, this merely sets the current value from the specified string.
+func (me *LexicalFloat) Set(v string) {
+	*me = LexicalFloat(v)
+}
+
+//	Since this is just a simple String type, this merely returns its current string value.
+func (me LexicalFloat) String() string {
+	return string(me)
+}
+
+//	Returns the Float value denoted by its lexical form (disregarding leading and trailing whitespace).
+func (me LexicalFloat) Value() (v Float) {
+	v.Set(strings.TrimSpace(string(me)))
+	return
+}
+
+//	A convenience interface that declares a type conversion to LexicalFloat.
+type ToXsdtLexicalFloat interface {
+	ToXsdtLexicalFloat() LexicalFloat
+}
+
+//	Holds the lexical form of an xs:int value verbatim.
+type LexicalInt string
+
+//	Since this is just a simple String type, this merely sets the current value from the specified string.
+func (me *LexicalInt) Set(v string) {
+	*me = LexicalInt(v)
+}
+
+//	Since this is just a simple String type, this merely returns its current string value.
+func (me LexicalInt) String() string {
+	return string(me)
+}
+
+//	Returns the Int value denoted by its lexical form (disregarding leading and trailing whitespace, a leading plus sign and leading zeroes).
+func (me LexicalInt) Value() (v Int) {
+	v.Set(lexicalInteger(string(me)))
+	return
+}
+
+//	A convenience interface that declares a type conversion to LexicalInt.
+type ToXsdtLexicalInt interface {
+	ToXsdtLexicalInt() LexicalInt
+}
+
+//	Holds the lexical form of an xs:integer value verbatim.
+type LexicalInteger string
+
+//	Since this is just a simple String type, this merely sets the current value from the specified string.
+func (me *LexicalInteger) Set(v string) {
+	*me = LexicalInteger(v)
+}
+
+//	Since this is just a simple String type, this merely returns its current string value.
+func (me LexicalInteger) String() string {
+	return string(me)
+}
+
+//	Returns the Integer value denoted by its lexical form (disregarding leading and trailing whitespace, a leading plus sign and leading zeroes).
+func (me LexicalInteger) Value() (v Integer) {
+	v.Set(lexicalInteger(string(me)))
+	return
+}
+
+//	A convenience interface that declares a type conversion to LexicalInteger.
+type ToXsdtLexicalInteger interface {
+	ToXsdtLexicalInteger() LexicalInteger
+}
+
+//	Holds the lexical form of an xs:long value verbatim.
+type LexicalLong string
+
+//	Since this is just a simple String type, this merely sets the current value from the specified string.
+func (me *LexicalLong) Set(v string) {
+	*me = LexicalLong(v)
+}
+
+//	Since this is just a simple String type, this merely returns its current string value.
+func (me LexicalLong) String() string {
+	return string(me)
+}
+
+//	Returns the Long value denoted by its lexical form (disregarding leading and trailing whitespace, a leading plus sign and leading zeroes).
+func (me LexicalLong) Value() (v Long) {
+	v.Set(lexicalInteger(string(me)))
+	return
+}
+
+//	A convenience interface that declares a type conversion to LexicalLong.
+type ToXsdtLexicalLong interface {
+	ToXsdtLexicalLong() LexicalLong
+}
+
+//	Holds the lexical form of an xs:negativeInteger value verbatim.
+type LexicalNegativeInteger string
+
+//	Since this is just a simple String type, this merely sets the current value from the specified string.
+func (me *LexicalNegativeInteger) Set(v string) {
+	*me = LexicalNegativeInteger(v)
+}
+
+//	Since this is just a simple String type, this merely returns its current string value.
+func (me LexicalNegativeInteger) String() string {
+	return string(me)
+}
+
+//	Returns the NegativeInteger value denoted by its lexical form (disregarding leading and trailing whitespace, a leading plus sign and leading zeroes).
+func (me LexicalNegativeInteger) Value() (v NegativeInteger) {
+	v.Set(lexicalInteger(string(me)))
+	return
+}
+
+//	A convenience interface that declares a type conversion to LexicalNegativeInteger.
+type ToXsdtLexicalNegativeInteger interface {
+	ToXsdtLexicalNegativeInteger() LexicalNegativeInteger
+}
+
+//	Holds the lexical form of an xs:nonNegativeInteger value verbatim.
+type LexicalNonNegativeInteger string
+
+//	Since this is just a simple String type, this merely sets the current value from the specified string.
+func (me *LexicalNonNegativeInteger) Set(v string) {
+	*me = LexicalNonNegativeInteger(v)
+}
+
+//	Since this is just a simple String type, this merely returns its current string value.
+func (me LexicalNonNegativeInteger) String() string {
+	return string(me)
+}
+
+//	Returns the NonNegativeInteger value denoted by its lexical form (disregarding leading and trailing whitespace, a leading plus sign and leading zeroes).
+func (me LexicalNonNegativeInteger) Value() (v NonNegativeInteger) {
+	v.Set(lexicalInteger(string(me)))
+	return
+}
+
+//	A convenience interface that declares a type conversion to LexicalNonNegativeInteger.
+type ToXsdtLexicalNonNegativeInteger interface {
+	ToXsdtLexicalNonNegativeInteger() LexicalNonNegativeInteger
+}
+
+//	Holds the lexical form of an xs:nonPositiveInteger value verbatim.
+type LexicalNonPositiveInteger string
+
+//	Since this is just a simple String type, this merely sets the current value from the specified string.
+func (me *LexicalNonPositiveInteger) Set(v string) {
+	*me = LexicalNonPositiveInteger(v)
+}
+
+//	Since this is just a simple String type, this merely returns its current string value.
+func (me LexicalNonPositiveInteger) String() string {
+	return string(me)
+}
+
+//	Returns the NonPositiveInteger value denoted by its lexical form (disregarding leading and trailing whitespace, a leading plus sign and leading zeroes).
+func (me LexicalNonPositiveInteger) Value() (v NonPositiveInteger) {
+	v.Set(lexicalInteger(string(me)))
+	return
+}
+
+//	A convenience interface that declares a type conversion to LexicalNonPositiveInteger.
+type ToXsdtLexicalNonPositiveInteger interface {
+	ToXsdtLexicalNonPositiveInteger() LexicalNonPositiveInteger
+}
+
+//	Holds the lexical form of an xs:positiveInteger value verbatim.
+type LexicalPositiveInteger string
+
+//	Since this is just a simple String type, this merely sets the current value from the specified string.
+func (me *LexicalPositiveInteger) Set(v string) {
+	*me = LexicalPositiveInteger(v)
+}
+
+//	Since this is just a simple String type, this merely returns its current string value.
+func (me LexicalPositiveInteger) String() string {
+	return string(me)
+}
+
+//	Returns the PositiveInteger value denoted by its lexical form (disregarding leading and trailing whitespace, a leading plus sign and leading zeroes).
+func (me LexicalPositiveInteger) Value() (v PositiveInteger) {
+	v.Set(lexicalInteger(string(me)))
+	return
+}
+
+//	A convenience interface that declares a type conversion to LexicalPositiveInteger.
+type ToXsdtLexicalPositiveInteger interface {
+	ToXsdtLexicalPositiveInteger() LexicalPositiveInteger
+}
+
+//	Holds the lexical form of an xs:short value verbatim.
+type LexicalShort string
+
+//	Since this is just a simple String type, this merely sets the current value from the specified string.
+func (me *LexicalShort) Set(v string) {
+	*me = LexicalShort(v)
+}
+
+//	Since this is just a simple String type, this merely returns its current string value.
+func (me LexicalShort) String() string {
+	return string(me)
+}
+
+//	Returns the Short value denoted by its lexical form (disregarding leading and trailing whitespace, a leading plus sign and leading zeroes).
+func (me LexicalShort) Value() (v Short) {
+	v.Set(lexicalInteger(string(me)))
+	return
+}
+
+//	A convenience interface that declares a type conversion to LexicalShort.
+type ToXsdtLexicalShort interface {
+	ToXsdtLexicalShort() LexicalShort
+}
+
+//	Holds the lexical form of an xs:unsignedByte value verbatim.
+type LexicalUnsignedByte string
+
+//	Since this is just a simple String type, this merely sets the current value from the specified string.
+func (me *LexicalUnsignedByte) Set(v string) {
+	*me = LexicalUnsignedByte(v)
+}
+
+//	Since this is just a simple String type, this merely returns its current string value.
+func (me LexicalUnsignedByte) String() string {
+	return string(me)
+}
+
+//	Returns the UnsignedByte value denoted by its lexical form (disregarding leading and trailing whitespace, a leading plus sign and leading zeroes).
+func (me LexicalUnsignedByte) Value() (v UnsignedByte) {
+	v.Set(lexicalInteger(string(me)))
+	return
+}
+
+//	A convenience interface that declares a type conversion to LexicalUnsignedByte.
+type ToXsdtLexicalUnsignedByte interface {
+	ToXsdtLexicalUnsignedByte() LexicalUnsignedByte
+}
+
+//	Holds the lexical form of an xs:unsignedInt value verbatim.
+type LexicalUnsignedInt string
+
+//	Since this is just a simple String type, this merely sets the current value from the specified string.
+func (me *LexicalUnsignedInt) Set(v string) {
+	*me = LexicalUnsignedInt(v)
+}
+
+//	Since this is just a simple String type, this merely returns its current string value.
+func (me LexicalUnsignedInt) String() string {
+	return string(me)
+}
+
+//	Returns the UnsignedInt value denoted by its lexical form (disregarding leading and trailing whitespace, a leading plus sign and leading zeroes).
+func (me LexicalUnsignedInt) Value() (v UnsignedInt) {
+	v.Set(lexicalInteger(string(me)))
+	return
+}
+
+//	A convenience interface that declares a type conversion to LexicalUnsignedInt.
+type ToXsdtLexicalUnsignedInt interface {
+	ToXsdtLexicalUnsignedInt() LexicalUnsignedInt
+}
+
+//	Holds the lexical form of an xs:unsignedLong value verbatim.
+type LexicalUnsignedLong string
+
+//	Since this is just a simple String type, this merely sets the current value from the specified string.
+func (me *LexicalUnsignedLong) Set(v string) {
+	*me = LexicalUnsignedLong(v)
+}
+
+//	Since this is just a simple String type, this merely returns its current string value.
+func (me LexicalUnsignedLong) String() string {
+	return string(me)
+}
+
+//	Returns the UnsignedLong value denoted by its lexical form (disregarding leading and trailing whitespace, a leading plus sign and leading zeroes).
+func (me LexicalUnsignedLong) Value() (v UnsignedLong) {
+	v.Set(lexicalInteger(string(me)))
+	return
+}
+
+//	A convenience interface that declares a type conversion to LexicalUnsignedLong.
+type ToXsdtLexicalUnsignedLong interface {
+	ToXsdtLexicalUnsignedLong() LexicalUnsignedLong
+}
+
+//	Holds the lexical form of an xs:unsignedShort value verbatim.
+type LexicalUnsignedShort string
+
+//	Since this is just a simple String type, this merely sets the current value from the specified string.
+func (me *LexicalUnsignedShort) Set(v string) {
+	*me = LexicalUnsignedShort(v)
+}
+
+//	Since this is just a simple String type, this merely returns its current string value.
+func (me LexicalUnsignedShort) String() string {
+	return string(me)
+}
+
+//	Returns the UnsignedShort value denoted by its lexical form (disregarding leading and trailing whitespace, a leading plus sign and leading zeroes).
+func (me LexicalUnsignedShort) Value() (v UnsignedShort) {
+	v.Set(lexicalInteger(string(me)))
+	return
+}
+
+//	A convenience interface that declares a type conversion to LexicalUnsignedShort.
+type ToXsdtLexicalUnsignedShort interface {
+	ToXsdtLexicalUnsignedShort() LexicalUnsignedShort
+}
+
+//	Returns the AnyURIValue denoted by its lexical form, or the zero AnyURIValue if it is not valid.
+func (me AnyURI) Value() (v AnyURIValue) {
+	v.Set(string(me))
+	return
+}
+
+//	Returns the Base64BinaryValue denoted by its lexical form, or the zero Base64BinaryValue if it is not valid.
+func (me Base64Binary) Value() (v Base64BinaryValue) {
+	v.Set(string(me))
+	return
+}
+
+//	Returns the DateValue denoted by its lexical form, or the zero DateValue if it is not valid.
+func (me Date) Value() (v DateValue) {
+	v.Set(string(me))
+	return
+}
+
+//	Returns the DateTimeValue denoted by its lexical form, or the zero DateTimeValue if it is not valid.
+func (me DateTime) Value() (v DateTimeValue) {
+	v.Set(string(me))
+	return
+}
+
+//	Returns the DecimalValue denoted by its lexical form, or the zero DecimalValue if it is not valid.
+func (me Decimal) Value() (v DecimalValue) {
+	v.Set(string(me))
+	return
+}
+
+//	Returns the DurationValue denoted by its lexical form, or the zero DurationValue if it is not valid.
+func (me Duration) Value() (v DurationValue) {
+	v.Set(string(me))
+	return
+}
+
+//	Returns the GDayValue denoted by its lexical form, or the zero GDayValue if it is not valid.
+func (me GDay) Value() (v GDayValue) {
+	v.Set(string(me))
+	return
+}
+
+//	Returns the GMonthValue denoted by its lexical form, or the zero GMonthValue if it is not valid.
+func (me GMonth) Value() (v GMonthValue) {
+	v.Set(string(me))
+	return
+}
+
+//	Returns the GMonthDayValue denoted by its lexical form, or the zero GMonthDayValue if it is not valid.
+func (me GMonthDay) Value() (v GMonthDayValue) {
+	v.Set(string(me))
+	return
+}
+
+//	Returns the GYearValue denoted by its lexical form, or the zero GYearValue if it is not valid.
+func (me GYear) Value() (v GYearValue) {
+	v.Set(string(me))
+	return
+}
+
+//	Returns the GYearMonthValue denoted by its lexical form, or the zero GYearMonthValue if it is not valid.
+func (me GYearMonth) Value() (v GYearMonthValue) {
+	v.Set(string(me))
+	return
+}
+
+//	Returns the HexBinaryValue denoted by its lexical form, or the zero HexBinaryValue if it is not valid.
+func (me HexBinary) Value() (v HexBinaryValue) {
+	v.Set(string(me))
+	return
+}
+
+//	Returns the QNameValue denoted by its lexical form, or the zero QNameValue if it is not valid.
+func (me Qname) Value() (v QNameValue) {
+	v.Set(string(me))
+	return
+}
+
+//	Returns the TimeValue denoted by its lexical form, or the zero TimeValue if it is not valid.
+func (me Time) Value() (v TimeValue) {
+	v.Set(string(me))
+	return
+}
+
+//	Returned by ParseLexical if a value is not a valid lexical form of its XSD built-in type, and by the UnmarshalText() methods of the union types
+//	of generated wrapper packages if a value is not valid for any of their member types.
+type LexicalError struct {
+	//	The name of the type, such as "Int" or "TSizeOrAuto".
+	Type string
+
+	//	The offending value.
+	Value string
+}
+
+//	Returns a description of the invalid value.
+func (me *LexicalError) Error() string {
+	return fmt.Sprintf("%q is not a valid %s value", me.Value, me.Type)
+}
+
+var (
+	lexicalIntBits  = map[string]int{"Byte": 8, "Short": 16, "Int": 32, "Long": 64}
+	lexicalUintBits = map[string]int{"UnsignedByte": 8, "UnsignedShort": 16, "UnsignedInt": 32, "UnsignedLong": 64}
+	lexicalIntSigns = map[string][]int{"Integer": {-1, 0, 1}, "NegativeInteger": {-1}, "NonNegativeInteger": {0, 1}, "NonPositiveInteger": {-1, 0}, "PositiveInteger": {1}}
+	lexicalTexts    = map[string]func() encoding.TextUnmarshaler{
+		"AnyURI":       func() encoding.TextUnmarshaler { return new(AnyURIValue) },
+		"Base64Binary": func() encoding.TextUnmarshaler { return new(Base64BinaryValue) },
+		"Date":         func() encoding.TextUnmarshaler { return new(DateValue) },
+		"DateTime":     func() encoding.TextUnmarshaler { return new(DateTimeValue) },
+		"Decimal":      func() encoding.TextUnmarshaler { return new(DecimalValue) },
+		"Duration":     func() encoding.TextUnmarshaler { return new(DurationValue) },
+		"GDay":         func() encoding.TextUnmarshaler { return new(GDayValue) },
+		"GMonth":       func() encoding.TextUnmarshaler { return new(GMonthValue) },
+		"GMonthDay":    func() encoding.TextUnmarshaler { return new(GMonthDayValue) },
+		"GYear":        func() encoding.TextUnmarshaler { return new(GYearValue) },
+		"GYearMonth":   func() encoding.TextUnmarshaler { return new(GYearMonthValue) },
+		"HexBinary":    func() encoding.TextUnmarshaler { return new(HexBinaryValue) },
+		"Qname":        func() encoding.TextUnmarshaler { return new(QNameValue) },
+		"Time":         func() encoding.TextUnmarshaler { return new(TimeValue) },
+	}
+)
+
+//	A helper function for the UnmarshalText() methods of the list and union types of generated wrapper packages: sets *ptr from s if s is a valid
+//	lexical form of builtin (the name of the xsdt type that the type of *ptr ultimately derives from, such as "Int", or empty if not known)
+//	and the resulting value satisfies the facets of its type (if it has a Validate() method). Otherwise, leaves *ptr zero and returns the error.
+//	Integers are set from their canonical forms, so that leading zeroes are not mistaken for octal notation.
+func ParseLexical(ptr interface{}, builtin, s string) (err error) {
+	var norm string
+	var rv = reflect.ValueOf(ptr).Elem()
+	rv.Set(reflect.Zero(rv.Type()))
+	if norm, err = lexicalForm(strings.TrimPrefix(builtin, "Lexical"), s); err == nil {
+		if strings.HasPrefix(builtin, "Lexical") {
+			norm = s
+		}
+		if tu, ok := ptr.(encoding.TextUnmarshaler); ok {
+			err = tu.UnmarshalText([]byte(norm))
+		} else if setter, ok := ptr.(interface {
+			Set(string)
+		}); ok {
+			setter.Set(norm)
+		}
+	}
+	if val, ok := ptr.(interface {
+		Validate() error
+	}); ok && (err == nil) {
+		err = val.Validate()
+	}
+	if err != nil {
+		rv.Set(reflect.Zero(rv.Type()))
+	}
+	return
+}
+
+//	Returns s with leading and trailing whitespace removed (and, for integers, in canonical form) if builtin is a non-string XSD built-in type
+//	and s a valid lexical form of it, or else s unchanged. Returns a *LexicalError if s is not valid.
+func lexicalForm(builtin, s string) (norm string, err error) {
+	var ok = true
+	if norm = s; (builtin == "Boolean") || (lexicalIntBits[builtin] > 0) || (lexicalUintBits[builtin] > 0) || (lexicalIntSigns[builtin] != nil) || (lexicalTexts[builtin] != nil) || (builtin == "Double") || (builtin == "Float") {
+		norm = strings.TrimSpace(s)
+	}
+	switch {
+	case builtin == "Boolean":
+		ok = (norm == "true") || (norm == "false") || (norm == "1") || (norm == "0")
+	case lexicalIntBits[builtin] > 0:
+		norm = lexicalInteger(norm)
+		_, e := strconv.ParseInt(norm, 10, lexicalIntBits[builtin])
+		ok = e == nil
+	case lexicalUintBits[builtin] > 0:
+		norm = lexicalInteger(norm)
+		_, e := strconv.ParseUint(norm, 10, lexicalUintBits[builtin])
+		ok = e == nil
+	case lexicalIntSigns[builtin] != nil:
+		norm, ok = lexicalInteger(norm), false
+		if i, isInt := new(big.Int).SetString(norm, 10); isInt {
+			for _, sign := range lexicalIntSigns[builtin] {
+				ok = ok || (i.Sign() == sign)
+			}
+		}
+	case (builtin == "Double") || (builtin == "Float"):
+		_, e := strconv.ParseFloat(norm, map[string]int{"Double": 64, "Float": 32}[builtin])
+		ok = (e == nil) || (norm == "INF") || (norm == "-INF") || (norm == "NaN")
+	case lexicalTexts[builtin] != nil:
+		ok = lexicalTexts[builtin]().UnmarshalText([]byte(norm)) == nil
+	}
+	if !ok {
+		norm, err = s, &LexicalError{Type: builtin, Value: s}
+	}
+	return
+}
@@ -0,0 +1,266 @@
+package xsdt
+
+import (
+	"bytes"
+	"encoding"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"math"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+var typeTextUnmarshaler = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+
+//	A helper function for the Go literals returned by GoLiteral: sets the value that ptr points to by decoding text via its UnmarshalText() method
+//	(see SetText), then returns ptr, to be type-asserted back to its type, such as *xsdt.FromText(new(xsdt.DateTimeValue), "2002-10-10T12:00:00Z").(*xsdt.DateTimeValue).
+func FromText(ptr encoding.TextUnmarshaler, text string) encoding.TextUnmarshaler {
+	SetText(ptr, text)
+	return ptr
+}
+
+//	Decodes the XML document read from r into the value that ptr points to (such as a new instance of a struct type of a generated package
+//	for the root element of the document), then returns the Go expression constructing that value (see GoLiteral), such as for turning
+//	sample messages into test fixtures.
+func XmlGoLiteral(r io.Reader, ptr interface{}, localPkgPath string) (lit string, err error) {
+	if err = xml.NewDecoder(r).Decode(ptr); err == nil {
+		lit = GoLiteral(ptr, localPkgPath)
+	}
+	return
+}
+
+//	Returns a Go expression (formatted as by gofmt, but for its indentation) constructing a value equal to v (see EqualValues), such as a
+//	composite literal of a struct type of a generated package. Named types are qualified by the names of their packages, except those of the package
+//	at localPkgPath (if not empty), for expressions used within that package. Struct fields holding zero values are omitted, as are unexported ones.
+//	Values of structs, slices and pointers implementing encoding.TextMarshaler (such as the typed built-in types, time.Time and *big.Rat) are
+//	constructed from their texts via FromText.
+func GoLiteral(v interface{}, localPkgPath string) string {
+	if v == nil {
+		return "nil"
+	}
+	var buf bytes.Buffer
+	lit := &goLiteral{buf: &buf, localPkgPath: localPkgPath}
+	lit.value(reflect.ValueOf(v), 0, false)
+	return buf.String()
+}
+
+type goLiteral struct {
+	buf          *bytes.Buffer
+	localPkgPath string
+}
+
+//	Writes the expression of v, at the specified indentation depth. If elide is true, v is an element, key or value of a composite literal whose
+//	type (or for pointers, the & and type) may be omitted, or a struct field of a bool, number or string type, which an untyped constant is assignable to.
+func (me *goLiteral) value(v reflect.Value, depth int, elide bool) {
+	var t = v.Type()
+	if text, ok := textOf(v); ok {
+		if t.Kind() == reflect.Ptr {
+			fmt.Fprintf(me.buf, "xsdt.FromText(new(%s), %s).(%s)", me.typeName(t.Elem()), strconv.Quote(text), me.typeName(t))
+		} else {
+			fmt.Fprintf(me.buf, "*xsdt.FromText(new(%s), %s).(*%s)", me.typeName(t), strconv.Quote(text), me.typeName(t))
+		}
+		return
+	}
+	switch t.Kind() {
+	case reflect.Ptr:
+		if v.IsNil() {
+			me.buf.WriteString("nil")
+		} else if k := t.Elem().Kind(); (k == reflect.Struct) || (k == reflect.Slice) || (k == reflect.Array) || (k == reflect.Map) {
+			if _, isText := textOf(v.Elem()); !elide || isText || (k != reflect.Struct) {
+				me.buf.WriteString("&")
+				elide = false
+			}
+			me.value(v.Elem(), depth, elide && (k == reflect.Struct))
+		} else {
+			//	pointers to simple values: there is no literal of their address, so take that of a one-element slice
+			fmt.Fprintf(me.buf, "&[]%s{", me.typeName(t.Elem()))
+			me.value(v.Elem(), depth, true)
+			me.buf.WriteString("}[0]")
+		}
+	case reflect.Interface:
+		if v.IsNil() {
+			me.buf.WriteString("nil")
+		} else {
+			me.value(v.Elem(), depth, false)
+		}
+	case reflect.Struct:
+		if !elide {
+			me.buf.WriteString(me.typeName(t))
+		}
+		me.buf.WriteString("{")
+		var written bool
+		for i := 0; i < t.NumField(); i++ {
+			if f := v.Field(i); (len(t.Field(i).PkgPath) == 0) && !isZeroField(f) {
+				me.newLine(depth + 1)
+				me.buf.WriteString(t.Field(i).Name + ": ")
+				me.value(f, depth+1, ((f.Kind() >= reflect.Bool) && (f.Kind() <= reflect.Complex128)) || (f.Kind() == reflect.String))
+				me.buf.WriteString(",")
+				written = true
+			}
+		}
+		if written {
+			me.newLine(depth)
+		}
+		me.buf.WriteString("}")
+	case reflect.Slice, reflect.Array:
+		if (t.Kind() == reflect.Slice) && v.IsNil() {
+			me.buf.WriteString("nil")
+			return
+		}
+		me.buf.WriteString(me.typeName(t) + "{")
+		for i := 0; i < v.Len(); i++ {
+			me.newLine(depth + 1)
+			me.value(v.Index(i), depth+1, true)
+			me.buf.WriteString(",")
+		}
+		if v.Len() > 0 {
+			me.newLine(depth)
+		}
+		me.buf.WriteString("}")
+	case reflect.Map:
+		if v.IsNil() {
+			me.buf.WriteString("nil")
+			return
+		}
+		var keys = make([]string, 0, v.Len())
+		var byKey = map[string]reflect.Value{}
+		for _, k := range v.MapKeys() {
+			var key bytes.Buffer
+			(&goLiteral{buf: &key, localPkgPath: me.localPkgPath}).value(k, depth+1, true)
+			keys, byKey[key.String()] = append(keys, key.String()), k
+		}
+		sort.Strings(keys)
+		me.buf.WriteString(me.typeName(t) + "{")
+		for _, key := range keys {
+			me.newLine(depth + 1)
+			me.buf.WriteString(key + ": ")
+			me.value(v.MapIndex(byKey[key]), depth+1, true)
+			me.buf.WriteString(",")
+		}
+		if len(keys) > 0 {
+			me.newLine(depth)
+		}
+		me.buf.WriteString("}")
+	default:
+		me.basicValue(v, elide)
+	}
+}
+
+//	Writes the expression of v, a bool, number or string, converted to its type unless that is unnamed or, if elide is true, the expression is an untyped constant.
+func (me *goLiteral) basicValue(v reflect.Value, elide bool) {
+	var lit string
+	switch v.Kind() {
+	case reflect.Bool:
+		lit = strconv.FormatBool(v.Bool())
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		lit = strconv.FormatInt(v.Int(), 10)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		lit = strconv.FormatUint(v.Uint(), 10)
+	case reflect.Float32, reflect.Float64:
+		switch f := v.Float(); {
+		case math.IsNaN(f):
+			lit = "math.NaN()"
+		case math.IsInf(f, 1):
+			lit = "math.Inf(1)"
+		case math.IsInf(f, -1):
+			lit = "math.Inf(-1)"
+		default:
+			if lit = strconv.FormatFloat(f, 'g', -1, v.Type().Bits()); !strings.ContainsAny(lit, ".eEn") {
+				lit += ".0"
+			}
+		}
+	case reflect.Complex64, reflect.Complex128:
+		lit = fmt.Sprintf("complex(%v, %v)", real(v.Complex()), imag(v.Complex()))
+	case reflect.String:
+		lit = strconv.Quote(v.String())
+	default:
+		lit = "nil"
+	}
+	if (len(v.Type().Name()) > 0) && ((!elide) || strings.HasPrefix(lit, "math.") || strings.HasPrefix(lit, "complex(")) {
+		lit = me.typeName(v.Type()) + "(" + lit + ")"
+	}
+	me.buf.WriteString(lit)
+}
+
+func (me *goLiteral) newLine(depth int) {
+	me.buf.WriteString("\n" + strings.Repeat("\t", depth))
+}
+
+//	Returns the Go type expression of t, qualifying named types by the names of their packages unless these are at localPkgPath.
+func (me *goLiteral) typeName(t reflect.Type) string {
+	if len(t.Name()) > 0 {
+		if pkgPath := t.PkgPath(); (len(pkgPath) == 0) || (pkgPath == me.localPkgPath) {
+			return t.Name()
+		}
+		return t.String()
+	}
+	switch t.Kind() {
+	case reflect.Ptr:
+		return "*" + me.typeName(t.Elem())
+	case reflect.Slice:
+		return "[]" + me.typeName(t.Elem())
+	case reflect.Array:
+		return "[" + strconv.Itoa(t.Len()) + "]" + me.typeName(t.Elem())
+	case reflect.Map:
+		return "map[" + me.typeName(t.Key()) + "]" + me.typeName(t.Elem())
+	}
+	return t.String()
+}
+
+//	Returns the text of v if it is a struct or slice implementing encoding.TextMarshaler (by itself or, if addressable, by its pointer) and encoding.TextUnmarshaler
+//	by its pointer, or a pointer to a struct implementing both, so that FromText can construct it.
+func textOf(v reflect.Value) (text string, ok bool) {
+	var t = v.Type()
+	var m = v
+	switch t.Kind() {
+	case reflect.Struct, reflect.Slice:
+		if (!t.Implements(typeTextMarshaler)) && v.CanAddr() {
+			m = v.Addr()
+		}
+		ok = m.Type().Implements(typeTextMarshaler) && reflect.PtrTo(t).Implements(typeTextUnmarshaler) && ((t.Kind() != reflect.Slice) || !v.IsNil())
+	case reflect.Ptr:
+		ok = (t.Elem().Kind() == reflect.Struct) && !v.IsNil() && t.Implements(typeTextMarshaler) && t.Implements(typeTextUnmarshaler)
+	}
+	if ok = ok && m.CanInterface(); ok {
+		var raw []byte
+		raw, err := m.Interface().(encoding.TextMarshaler).MarshalText()
+		text, ok = string(raw), err == nil
+	}
+	return
+}
+
+//	Returns whether v is the zero value of its type, including its unexported fields (if a struct), so that GoLiteral may omit it as a struct field.
+func isZeroField(v reflect.Value) bool {
+	switch v.Kind() {
+	case reflect.Ptr, reflect.Interface, reflect.Slice, reflect.Map, reflect.Chan, reflect.Func:
+		return v.IsNil()
+	case reflect.Array:
+		for i := 0; i < v.Len(); i++ {
+			if !isZeroField(v.Index(i)) {
+				return false
+			}
+		}
+	case reflect.Struct:
+		for i := 0; i < v.NumField(); i++ {
+			if !isZeroField(v.Field(i)) {
+				return false
+			}
+		}
+	case reflect.Bool:
+		return !v.Bool()
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return v.Int() == 0
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return v.Uint() == 0
+	case reflect.Float32, reflect.Float64:
+		return (v.Float() == 0) && !math.Signbit(v.Float())
+	case reflect.Complex64, reflect.Complex128:
+		return v.Complex() == 0
+	case reflect.String:
+		return v.Len() == 0
+	}
+	return true
+}
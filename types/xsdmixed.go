@@ -0,0 +1,189 @@
+package xsdt
+
+import (
+	"bytes"
+	"encoding/xml"
+	"io"
+)
+
+//	The character data and child elements of an element of a mixed complex type in document order, as held by the XsdGoPkgMixed field of its
+//	generated struct type (if xsd.PkgGen.PreserveMixedContent is set). The values of the child elements are held by the other fields of that
+//	struct type, just as for types that are not mixed, so that MixedContent only records where they go: the n-th MixedItem naming an element
+//	stands for the n-th child element of that name held by these fields.
+type MixedContent []MixedItem
+
+//	A text segment or child element of MixedContent.
+type MixedItem struct {
+	//	The character data of a text segment, if Name.Local is empty.
+	Text string
+
+	//	The name of a child element. Only Name.Local is compared when encoding.
+	Name xml.Name
+}
+
+//	Returns all text segments of this MixedContent, concatenated.
+func (me MixedContent) Text() string {
+	var buf bytes.Buffer
+	for _, item := range me {
+		if len(item.Name.Local) == 0 {
+			buf.WriteString(item.Text)
+		}
+	}
+	return buf.String()
+}
+
+//	Appends a text segment of the specified character data, or adds it to the last item if that is a text segment, too.
+func (me *MixedContent) AddText(text string) {
+	if l := len(*me); (l > 0) && (len((*me)[l-1].Name.Local) == 0) {
+		(*me)[l-1].Text += text
+	} else {
+		*me = append(*me, MixedItem{Text: text})
+	}
+}
+
+//	Appends a child element of the specified name.
+func (me *MixedContent) AddElement(namespace, local string) {
+	*me = append(*me, MixedItem{Name: xml.Name{Space: namespace, Local: local}})
+}
+
+//	A helper function for the UnmarshalXML() methods of generated wrapper packages: records the character data and child elements of the element
+//	start in content, in document order, then has decode decode that element as usual (from a Decoder replaying it, past start).
+func DecodeMixed(dec *xml.Decoder, start xml.StartElement, content *MixedContent, decode func(*xml.Decoder, xml.StartElement) error) (err error) {
+	var tok xml.Token
+	toks := []xml.Token{start.Copy()}
+	*content = nil
+	for depth := 0; depth >= 0; {
+		if tok, err = dec.Token(); err != nil {
+			return
+		}
+		switch t := tok.(type) {
+		case xml.StartElement:
+			if depth == 0 {
+				content.AddElement(t.Name.Space, t.Name.Local)
+			}
+			depth++
+		case xml.EndElement:
+			depth--
+		case xml.CharData:
+			if depth == 0 {
+				content.AddText(string(t))
+			}
+		}
+		toks = append(toks, xml.CopyToken(tok))
+	}
+	dec = xml.NewTokenDecoder(&tokenReplay{toks: toks})
+	if _, err = dec.Token(); err == nil {
+		err = decode(dec, start)
+	}
+	return
+}
+
+//	A helper function for the MarshalXML() methods of generated wrapper packages: encodes v as the element start, as usual if content is empty.
+//	Otherwise, its text segments and child elements are encoded in the order of content instead: the character data of v itself is replaced by
+//	the text segments of content, and child elements of v not named by content are encoded after all others.
+func EncodeMixed(enc *xml.Encoder, start xml.StartElement, v interface{}, content MixedContent) (err error) {
+	var buf bytes.Buffer
+	var tok xml.Token
+	var root []xml.Token
+	var kids []*mixedKid
+	if len(content) == 0 {
+		return enc.EncodeElement(v, start)
+	}
+	sub := xml.NewEncoder(&buf)
+	if err = sub.EncodeElement(v, start); err == nil {
+		err = sub.Flush()
+	}
+	if err != nil {
+		return
+	}
+	dec, depth, level := xml.NewDecoder(&buf), 0, 0
+	for tok, err = dec.RawToken(); err == nil; tok, err = dec.RawToken() {
+		switch t := tok.(type) {
+		case xml.StartElement:
+			if level, depth = depth, depth+1; level == 1 {
+				kids = append(kids, &mixedKid{local: t.Name.Local})
+			}
+		case xml.EndElement:
+			depth--
+			level = depth
+		default:
+			level = depth
+		}
+		if tok = literalToken(tok); level == 0 {
+			root = append(root, tok)
+		} else if _, isText := tok.(xml.CharData); (level > 1) || ((!isText) && (len(kids) > 0)) {
+			kids[len(kids)-1].toks = append(kids[len(kids)-1].toks, tok)
+		}
+	}
+	if err != io.EOF {
+		return
+	} else if err = enc.EncodeToken(root[0]); err != nil {
+		return
+	}
+	for _, item := range content {
+		if len(item.Name.Local) == 0 {
+			err = enc.EncodeToken(xml.CharData(item.Text))
+		} else {
+			for _, kid := range kids {
+				if (!kid.done) && (kid.local == item.Name.Local) {
+					err = kid.encode(enc)
+					break
+				}
+			}
+		}
+		if err != nil {
+			return
+		}
+	}
+	for _, kid := range kids {
+		if err = kid.encode(enc); err != nil {
+			return
+		}
+	}
+	return enc.EncodeToken(root[len(root)-1])
+}
+
+//	The tokens of a child element being encoded by EncodeMixed.
+type mixedKid struct {
+	local string
+	toks  []xml.Token
+	done  bool
+}
+
+//	Encodes the tokens of this child element, unless already done.
+func (me *mixedKid) encode(enc *xml.Encoder) (err error) {
+	if !me.done {
+		me.done = true
+		for _, tok := range me.toks {
+			if err = enc.EncodeToken(tok); err != nil {
+				break
+			}
+		}
+	}
+	return
+}
+
+//	Returns a copy of tok (as read by xml.Decoder.RawToken) with the namespace prefixes of its names made part of their local names,
+//	so that xml.Encoder writes these names (and namespace declarations) verbatim.
+func literalToken(tok xml.Token) xml.Token {
+	switch t := tok.(type) {
+	case xml.StartElement:
+		t = t.Copy()
+		for i, _ := range t.Attr {
+			t.Attr[i].Name = literalName(t.Attr[i].Name)
+		}
+		t.Name = literalName(t.Name)
+		return t
+	case xml.EndElement:
+		t.Name = literalName(t.Name)
+		return t
+	}
+	return xml.CopyToken(tok)
+}
+
+func literalName(name xml.Name) xml.Name {
+	if len(name.Space) > 0 {
+		name.Local, name.Space = name.Space+":"+name.Local, ""
+	}
+	return name
+}
@@ -0,0 +1,461 @@
+package xsdt
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+//	The regular expressions of xs:pattern facets use a dialect of their own (see XML Schema Part 2, Appendix F): they are implicitly anchored,
+//	^ and $ are ordinary characters, \d, \w and . differ from their Go counterparts, \i, \c and their complements denote XML name characters,
+//	\p{IsBlock} denotes Unicode blocks, and character classes may be subtracted from others (such as [a-z-[aeiou]]). TranslatePattern rewrites
+//	them in the RE2 syntax of package regexp.
+
+//	Returned by TranslatePattern and CompilePattern if an xs:pattern facet value is not a valid XSD regular expression or cannot be expressed in the RE2 syntax.
+type PatternError struct {
+	//	The offending xs:pattern facet value.
+	Pattern string
+
+	//	The index (in characters, from 0) in Pattern at which the problem was found.
+	Offset int
+
+	//	A description of the problem.
+	Message string
+}
+
+//	Returns a description of the problem and where in the pattern it was found.
+func (me *PatternError) Error() string {
+	return fmt.Sprintf("xs:pattern %q at offset %d: %s", me.Pattern, me.Offset, me.Message)
+}
+
+type runeRange struct {
+	lo, hi rune
+}
+
+var (
+	//	The XSD names (without "Is") of the Unicode blocks supported in \p{IsBlock} escapes, with their code points.
+	patternBlocks = map[string]runeRange{
+		"BasicLatin": {0x0000, 0x007F}, "Latin-1Supplement": {0x0080, 0x00FF}, "LatinExtended-A": {0x0100, 0x017F}, "LatinExtended-B": {0x0180, 0x024F},
+		"IPAExtensions": {0x0250, 0x02AF}, "SpacingModifierLetters": {0x02B0, 0x02FF}, "CombiningDiacriticalMarks": {0x0300, 0x036F}, "Greek": {0x0370, 0x03FF},
+		"Cyrillic": {0x0400, 0x04FF}, "Armenian": {0x0530, 0x058F}, "Hebrew": {0x0590, 0x05FF}, "Arabic": {0x0600, 0x06FF}, "Devanagari": {0x0900, 0x097F},
+		"Thai": {0x0E00, 0x0E7F}, "LatinExtendedAdditional": {0x1E00, 0x1EFF}, "GreekExtended": {0x1F00, 0x1FFF}, "GeneralPunctuation": {0x2000, 0x206F},
+		"CurrencySymbols": {0x20A0, 0x20CF}, "LetterlikeSymbols": {0x2100, 0x214F}, "NumberForms": {0x2150, 0x218F}, "Arrows": {0x2190, 0x21FF},
+		"MathematicalOperators": {0x2200, 0x22FF}, "BoxDrawing": {0x2500, 0x257F}, "CJKSymbolsandPunctuation": {0x3000, 0x303F}, "Hiragana": {0x3040, 0x309F},
+		"Katakana": {0x30A0, 0x30FF}, "CJKUnifiedIdeographs": {0x4E00, 0x9FFF}, "HangulSyllables": {0xAC00, 0xD7AF}, "PrivateUse": {0xE000, 0xF8FF},
+		"AlphabeticPresentationForms": {0xFB00, 0xFB4F}, "HalfwidthandFullwidthForms": {0xFF00, 0xFFEF}, "Specials": {0xFFF0, 0xFFFF},
+	}
+
+	//	The characters that XML names may start with (\i), and those they may contain (\c), as per XML 1.0 (Fifth Edition).
+	patternNameStart = []runeRange{{':', ':'}, {'A', 'Z'}, {'_', '_'}, {'a', 'z'}, {0xC0, 0xD6}, {0xD8, 0xF6}, {0xF8, 0x2FF}, {0x370, 0x37D}, {0x37F, 0x1FFF},
+		{0x200C, 0x200D}, {0x2070, 0x218F}, {0x2C00, 0x2FEF}, {0x3001, 0xD7FF}, {0xF900, 0xFDCF}, {0xFDF0, 0xFFFD}, {0x10000, 0xEFFFF}}
+	patternNameChar = append([]runeRange{{'-', '.'}, {'0', '9'}, {0xB7, 0xB7}, {0x300, 0x36F}, {0x203F, 0x2040}}, patternNameStart...)
+
+	//	The RE2 equivalents of the multi-character escapes other than \i, \I, \c and \C outside of character classes.
+	patternEscapes = map[rune]string{'d': `\p{Nd}`, 'D': `\P{Nd}`, 's': `[\t\n\r ]`, 'S': `[^\t\n\r ]`, 'w': `[^\p{P}\p{Z}\p{C}]`, 'W': `[\p{P}\p{Z}\p{C}]`}
+)
+
+//	Returns the RE2 syntax (see package regexp) of the XSD regular expression pattern (the value of an xs:pattern facet), anchored at both ends,
+//	or a *PatternError if pattern is not valid or uses a construct that cannot be translated (such as an unsupported Unicode block, or a
+//	repetition count exceeding 1000).
+func TranslatePattern(pattern string) (re string, err error) {
+	var pt = &patternTranslator{pattern: pattern, src: []rune(pattern)}
+	if err = pt.regExp(0); err == nil {
+		re = "^(?:" + pt.buf.String() + ")$"
+	}
+	return
+}
+
+type patternTranslator struct {
+	pattern string
+	src     []rune
+	pos     int
+	buf     bytes.Buffer
+}
+
+func (me *patternTranslator) fail(format string, args ...interface{}) error {
+	return &PatternError{Pattern: me.pattern, Offset: me.pos, Message: fmt.Sprintf(format, args...)}
+}
+
+//	Translates branches separated by | up to the end of the pattern or, if depth is greater than 0, the ) closing the enclosing group.
+func (me *patternTranslator) regExp(depth int) (err error) {
+	for me.pos < len(me.src) {
+		var atom string
+		switch c := me.src[me.pos]; c {
+		case '|':
+			me.buf.WriteRune(c)
+			me.pos++
+			continue
+		case ')':
+			if depth == 0 {
+				return me.fail("unbalanced )")
+			}
+			return
+		case '(':
+			me.pos++
+			me.buf.WriteString("(")
+			if err = me.regExp(depth + 1); err != nil {
+				return
+			} else if me.pos >= len(me.src) {
+				return me.fail("missing )")
+			}
+			me.pos++
+			me.buf.WriteString(")")
+		case '[':
+			var set []runeRange
+			if set, err = me.charClass(); err != nil {
+				return
+			}
+			atom = rangesSyntax(set)
+		case '\\':
+			if atom, err = me.escape(); err != nil {
+				return
+			}
+		case '.':
+			me.pos++
+			atom = `[^\n\r]`
+		case '?', '*', '+', '{':
+			return me.fail("quantifier %c without an atom to repeat", c)
+		case ']', '}':
+			return me.fail("unescaped %c", c)
+		case '^', '$':
+			me.pos++
+			atom = `\` + string(c)
+		default:
+			me.pos++
+			atom = string(c)
+		}
+		me.buf.WriteString(atom)
+		if err = me.quantifier(); err != nil {
+			return
+		}
+	}
+	if depth > 0 {
+		err = me.fail("missing )")
+	}
+	return
+}
+
+//	Translates the quantifier (?, *, +, {n}, {n,} or {n,m}) following an atom, if any.
+func (me *patternTranslator) quantifier() (err error) {
+	if me.pos >= len(me.src) {
+		return
+	}
+	switch me.src[me.pos] {
+	case '?', '*', '+':
+		me.buf.WriteRune(me.src[me.pos])
+		me.pos++
+	case '{':
+		var start, end = me.pos, me.pos + 1
+		for (end < len(me.src)) && (me.src[end] != '}') {
+			end++
+		}
+		if end >= len(me.src) {
+			return me.fail("missing } of the quantifier")
+		}
+		var bounds = strings.SplitN(string(me.src[start+1:end]), ",", 2)
+		for i, bound := range bounds {
+			if ((i == 0) || (len(bound) > 0)) && ((len(bound) == 0) || (len(strings.Trim(bound, "0123456789")) > 0)) {
+				return me.fail("invalid quantifier %s", string(me.src[start:end+1]))
+			} else if n, _ := strconv.Atoi(bound); n > 1000 {
+				return me.fail("the repetition count %d exceeds 1000, the maximum supported by package regexp", n)
+			}
+		}
+		if lo, _ := strconv.Atoi(bounds[0]); (len(bounds) == 2) && (len(bounds[1]) > 0) {
+			if hi, _ := strconv.Atoi(bounds[1]); hi < lo {
+				return me.fail("invalid quantifier %s: the maximum is less than the minimum", string(me.src[start:end+1]))
+			}
+		}
+		me.pos = end + 1
+		me.buf.WriteString(string(me.src[start:me.pos]))
+	default:
+		return
+	}
+	if (me.pos < len(me.src)) && ((me.src[me.pos] == '?') || (me.src[me.pos] == '*') || (me.src[me.pos] == '+') || (me.src[me.pos] == '{')) {
+		err = me.fail("quantifier %c follows another quantifier", me.src[me.pos])
+	}
+	return
+}
+
+//	Translates the escape starting at the current \ outside of a character class.
+func (me *patternTranslator) escape() (atom string, err error) {
+	var start = me.pos
+	var c, set, e = me.escapeSet()
+	if err = e; err == nil {
+		if set == nil {
+			atom = regexpQuote(c)
+		} else if esc := patternEscapes[me.src[start+1]]; len(esc) > 0 {
+			atom = esc
+		} else if cat := me.src[start+1]; ((cat == 'p') || (cat == 'P')) && (unicode.Categories[string(me.src[start+3:me.pos-1])] != nil) {
+			atom = string(me.src[start:me.pos])
+		} else {
+			atom = rangesSyntax(set)
+		}
+	}
+	return
+}
+
+//	Parses the escape starting at the current \, returning either the character c of a single-character escape (with a nil set)
+//	or the characters of a multi-character or category escape.
+func (me *patternTranslator) escapeSet() (c rune, set []runeRange, err error) {
+	if me.pos++; me.pos >= len(me.src) {
+		return 0, nil, me.fail("incomplete escape \\")
+	}
+	c = me.src[me.pos]
+	me.pos++
+	switch c {
+	case 'n':
+		return '\n', nil, nil
+	case 'r':
+		return '\r', nil, nil
+	case 't':
+		return '\t', nil, nil
+	case '\\', '|', '.', '?', '*', '+', '(', ')', '{', '}', '-', '[', ']', '^':
+		return c, nil, nil
+	case 'd', 'D':
+		set = tableRanges(unicode.Nd)
+	case 's', 'S':
+		set = []runeRange{{'\t', '\n'}, {'\r', '\r'}, {' ', ' '}}
+	case 'w', 'W':
+		set = complementRanges(unionRanges(tableRanges(unicode.P), tableRanges(unicode.Z), tableRanges(unicode.C)))
+	case 'i', 'I':
+		set = unionRanges(patternNameStart)
+	case 'c', 'C':
+		set = unionRanges(patternNameChar)
+	case 'p', 'P':
+		var start = me.pos
+		for (me.pos < len(me.src)) && (me.src[me.pos] != '}') {
+			me.pos++
+		}
+		if (start >= len(me.src)) || (me.src[start] != '{') || (me.pos >= len(me.src)) {
+			me.pos = start - 2
+			return 0, nil, me.fail("\\%c must be followed by a category or block name in braces", c)
+		}
+		name := string(me.src[start+1 : me.pos])
+		me.pos++
+		if table := unicode.Categories[name]; table != nil {
+			set = tableRanges(table)
+		} else if block, ok := patternBlocks[strings.TrimPrefix(name, "Is")]; ok && strings.HasPrefix(name, "Is") {
+			set = []runeRange{block}
+		} else if strings.HasPrefix(name, "Is") {
+			me.pos = start - 2
+			return 0, nil, me.fail("the Unicode block %s is not supported", name[2:])
+		} else {
+			me.pos = start - 2
+			return 0, nil, me.fail("unknown character category %s", name)
+		}
+	default:
+		me.pos -= 2
+		return 0, nil, me.fail("invalid escape \\%c", c)
+	}
+	if (c >= 'A') && (c <= 'Z') {
+		set = complementRanges(set)
+	}
+	return
+}
+
+//	Parses the character class expression starting at the current [, including negation and subtraction, returning the characters it matches.
+func (me *patternTranslator) charClass() (set []runeRange, err error) {
+	var start, negated = me.pos, false
+	if me.pos++; (me.pos < len(me.src)) && (me.src[me.pos] == '^') {
+		negated = true
+		me.pos++
+	}
+	var items, subtracted []runeRange
+	var empty = true
+	for me.pos < len(me.src) {
+		c := me.src[me.pos]
+		if (c == ']') && empty {
+			return nil, me.fail("empty character class")
+		} else if c == ']' {
+			break
+		} else if (c == '-') && (me.pos+1 < len(me.src)) && (me.src[me.pos+1] == '[') && !empty {
+			me.pos++
+			if subtracted, err = me.charClass(); err != nil {
+				return
+			} else if (me.pos < len(me.src)) && (me.src[me.pos] != ']') {
+				return nil, me.fail("a subtracted character class must end its enclosing one")
+			}
+			break
+		}
+		var lo rune
+		var escSet []runeRange
+		switch c {
+		case '[':
+			return nil, me.fail("unescaped [ in a character class")
+		case '\\':
+			if lo, escSet, err = me.escapeSet(); err != nil {
+				return
+			}
+		default:
+			lo = c
+			me.pos++
+		}
+		empty = false
+		if escSet != nil {
+			items = append(items, escSet...)
+			continue
+		}
+		hi := lo
+		if (me.pos+1 < len(me.src)) && (me.src[me.pos] == '-') && (me.src[me.pos+1] != ']') && (me.src[me.pos+1] != '[') {
+			me.pos++
+			if me.src[me.pos] == '\\' {
+				var hiSet []runeRange
+				if hi, hiSet, err = me.escapeSet(); err != nil {
+					return
+				} else if hiSet != nil {
+					return nil, me.fail("a multi-character escape cannot end a character range")
+				}
+			} else {
+				hi = me.src[me.pos]
+				me.pos++
+			}
+			if hi < lo {
+				return nil, me.fail("invalid character range %c-%c", lo, hi)
+			}
+		}
+		items = append(items, runeRange{lo, hi})
+	}
+	if me.pos >= len(me.src) {
+		me.pos = start
+		return nil, me.fail("missing ] of the character class")
+	}
+	me.pos++
+	if set = unionRanges(items); negated {
+		set = complementRanges(set)
+	}
+	if subtracted != nil {
+		set = differenceRanges(set, subtracted)
+	}
+	return
+}
+
+//	Returns the RE2 syntax of the single character c, escaped if it is a metacharacter.
+func regexpQuote(c rune) string {
+	switch c {
+	case '\n':
+		return `\n`
+	case '\r':
+		return `\r`
+	case '\t':
+		return `\t`
+	case '\\', '.', '+', '*', '?', '(', ')', '|', '[', ']', '{', '}', '^', '$', '-':
+		return `\` + string(c)
+	}
+	return string(c)
+}
+
+//	Returns the RE2 character class matching exactly the characters of the normalized set.
+func rangesSyntax(set []runeRange) string {
+	if len(set) == 0 {
+		return `[^\x{0}-\x{10FFFF}]`
+	}
+	var buf bytes.Buffer
+	buf.WriteString("[")
+	for _, r := range set {
+		buf.WriteString(classChar(r.lo))
+		if r.hi > r.lo {
+			buf.WriteString("-" + classChar(r.hi))
+		}
+	}
+	buf.WriteString("]")
+	return buf.String()
+}
+
+func classChar(c rune) string {
+	if ((c >= '0') && (c <= '9')) || ((c >= 'A') && (c <= 'Z')) || ((c >= 'a') && (c <= 'z')) {
+		return string(c)
+	}
+	return fmt.Sprintf(`\x{%X}`, c)
+}
+
+//	Returns the code points of table as ranges.
+func tableRanges(table *unicode.RangeTable) (set []runeRange) {
+	for _, r := range table.R16 {
+		set = appendStrided(set, rune(r.Lo), rune(r.Hi), rune(r.Stride))
+	}
+	for _, r := range table.R32 {
+		set = appendStrided(set, rune(r.Lo), rune(r.Hi), rune(r.Stride))
+	}
+	return unionRanges(set)
+}
+
+func appendStrided(set []runeRange, lo, hi, stride rune) []runeRange {
+	if stride == 1 {
+		return append(set, runeRange{lo, hi})
+	}
+	for c := lo; c <= hi; c += stride {
+		set = append(set, runeRange{c, c})
+	}
+	return set
+}
+
+//	Returns the union of all sets as sorted, non-overlapping and non-adjacent ranges.
+func unionRanges(sets ...[]runeRange) (set []runeRange) {
+	var all []runeRange
+	for _, s := range sets {
+		all = append(all, s...)
+	}
+	sort.Sort(runeRanges(all))
+	for _, r := range all {
+		if n := len(set); (n > 0) && (r.lo <= set[n-1].hi+1) {
+			if r.hi > set[n-1].hi {
+				set[n-1].hi = r.hi
+			}
+		} else {
+			set = append(set, r)
+		}
+	}
+	return
+}
+
+//	Returns the characters (up to unicode.MaxRune) not in the normalized set.
+func complementRanges(set []runeRange) (comp []runeRange) {
+	var next rune
+	for _, r := range set {
+		if r.lo > next {
+			comp = append(comp, runeRange{next, r.lo - 1})
+		}
+		next = r.hi + 1
+	}
+	if next <= unicode.MaxRune {
+		comp = append(comp, runeRange{next, unicode.MaxRune})
+	}
+	return
+}
+
+//	Returns the characters of the normalized set that are not in the normalized set minus.
+func differenceRanges(set, minus []runeRange) []runeRange {
+	var comp = complementRanges(minus)
+	var diff []runeRange
+	for _, a := range set {
+		for _, b := range comp {
+			if lo, hi := maxRune(a.lo, b.lo), minRune(a.hi, b.hi); lo <= hi {
+				diff = append(diff, runeRange{lo, hi})
+			}
+		}
+	}
+	return unionRanges(diff)
+}
+
+type runeRanges []runeRange
+
+func (me runeRanges) Len() int           { return len(me) }
+func (me runeRanges) Less(i, j int) bool { return me[i].lo < me[j].lo }
+func (me runeRanges) Swap(i, j int)      { me[i], me[j] = me[j], me[i] }
+
+func maxRune(a, b rune) rune {
+	if a > b {
+		return a
+	}
+	return b
+}
+
+func minRune(a, b rune) rune {
+	if a < b {
+		return a
+	}
+	return b
+}
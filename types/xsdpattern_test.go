@@ -0,0 +1,42 @@
+package xsdt
+
+import (
+	"io/ioutil"
+	"strings"
+	"testing"
+)
+
+//	Tests the XSD regular expressions in testdata/patterns.txt, one per line followed by tab-separated values that must (+) or must not (-)
+//	match it, or by the message (after !) of the PatternError expected instead.
+func TestCompilePattern(t *testing.T) {
+	data, err := ioutil.ReadFile("testdata/patterns.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, line := range strings.Split(strings.TrimSpace(string(data)), "\n") {
+		fields := strings.Split(line, "\t")
+		re, err := CompilePattern(fields[0])
+		for _, field := range fields[1:] {
+			value := strings.Replace(field[1:], `\n`, "\n", -1)
+			switch field[0] {
+			case '!':
+				if perr, ok := err.(*PatternError); !ok || (perr.Pattern != fields[0]) || !strings.Contains(perr.Message, value) {
+					t.Errorf("%s: expected a PatternError containing %q, got %v", fields[0], value, err)
+				} else if re != nil {
+					t.Errorf("%s: expected no regular expression", fields[0])
+				}
+			case '+', '-':
+				if err != nil {
+					t.Errorf("%s: %v", fields[0], err)
+				} else if matched := re.MatchString(value); matched != (field[0] == '+') {
+					t.Errorf("%s (%s): expected matching %q to be %v", fields[0], re, value, !matched)
+				}
+			}
+		}
+	}
+	if err = (&Facets{Pattern: "[a-"}).Check("x"); err != nil {
+		t.Errorf("expected a pattern that cannot be translated to be disregarded, got %v", err)
+	} else if err = (&Facets{Pattern: `\d+`}).Check("1x"); err == nil {
+		t.Error("expected 1x to violate the pattern \\d+")
+	}
+}
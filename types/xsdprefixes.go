@@ -0,0 +1,356 @@
+package xsdt
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"sync"
+)
+
+//	Maps namespace URIs to the prefixes preferred for them in XML documents marshaled via its Marshal() / MarshalIndent() / Rewrite() methods,
+//	an empty prefix denoting the default namespace. encoding/xml declares namespaces on every element using them, either as the default namespace
+//	or with arbitrary prefixes (such as "_"). These methods rewrite its output so that all namespaces are declared on the root element only, with
+//	the preferred prefixes (or else generated ones, such as "ns1"), and xsi:type values refer to these prefixes.
+//	Prefixes in other QName values (such as those of QNameValue fields) are not rewritten.
+type Prefixes map[string]string
+
+var (
+	//	The Prefixes used by MarshalPrefixed and MarshalIndentPrefixed, initially mapping XsiNamespace to "xsi". See RegisterPrefix.
+	DefaultPrefixes = Prefixes{XsiNamespace: "xsi"}
+
+	defaultPrefixesMutex sync.RWMutex
+)
+
+const xmlNamespace = "http://www.w3.org/XML/1998/namespace"
+
+//	Registers prefix as the preferred prefix of namespace in DefaultPrefixes (an empty prefix denoting the default namespace). Safe for concurrent use.
+func RegisterPrefix(namespace, prefix string) {
+	defaultPrefixesMutex.Lock()
+	defer defaultPrefixesMutex.Unlock()
+	DefaultPrefixes[namespace] = prefix
+}
+
+//	Like xml.Marshal, but declaring all namespaces on the root element with the prefixes registered in DefaultPrefixes (see Prefixes).
+func MarshalPrefixed(v interface{}) ([]byte, error) {
+	return defaultPrefixes().Marshal(v)
+}
+
+//	Like xml.MarshalIndent, but declaring all namespaces on the root element with the prefixes registered in DefaultPrefixes (see Prefixes).
+func MarshalIndentPrefixed(v interface{}, prefix, indent string) ([]byte, error) {
+	return defaultPrefixes().MarshalIndent(v, prefix, indent)
+}
+
+//	Returns a copy of DefaultPrefixes.
+func defaultPrefixes() (prefixes Prefixes) {
+	defaultPrefixesMutex.RLock()
+	defer defaultPrefixesMutex.RUnlock()
+	prefixes = Prefixes{}
+	for ns, prefix := range DefaultPrefixes {
+		prefixes[ns] = prefix
+	}
+	return
+}
+
+//	Like xml.Marshal, but declaring all namespaces on the root element with the prefixes of this Prefixes.
+//	Elements in no namespace (such as those of unqualified local element declarations) stay in no namespace, unlike with xml.Marshal.
+func (me Prefixes) Marshal(v interface{}) ([]byte, error) {
+	return me.rewriteBytes(xml.Marshal(v))
+}
+
+//	Like xml.MarshalIndent, but declaring all namespaces on the root element with the prefixes of this Prefixes.
+func (me Prefixes) MarshalIndent(v interface{}, prefix, indent string) ([]byte, error) {
+	return me.rewriteBytes(xml.MarshalIndent(v, prefix, indent))
+}
+
+func (me Prefixes) rewriteBytes(data []byte, err error) ([]byte, error) {
+	var buf bytes.Buffer
+	if err == nil {
+		err = me.rewrite(&buf, bytes.NewReader(data), true)
+	}
+	return buf.Bytes(), err
+}
+
+//	Reads the XML document r (such as the output of xml.Marshal) and writes it to w with all its namespaces declared on its root element,
+//	using the prefixes of this Prefixes. The namespace of the root element becomes the default namespace unless this Prefixes maps it (or another
+//	namespace) otherwise. The default namespace is only used at all if no element is in no namespace.
+func (me Prefixes) Rewrite(w io.Writer, r io.Reader) error {
+	return me.rewrite(w, r, false)
+}
+
+//	Implements Rewrite. If marshaled, r is the output of xml.Marshal, which declares the namespace of every element in a namespace as the default
+//	namespace on that very element: elements declaring none (such as those of unqualified local element declarations) are in no namespace then,
+//	rather than in the default namespace declared by their parent element.
+func (me Prefixes) rewrite(w io.Writer, r io.Reader, marshaled bool) (err error) {
+	var tok xml.Token
+	var toks []xml.Token
+	var depth int
+	var scopes []map[string]string
+	var names []xml.Name
+	var rw = &prefixRewriter{elemPrefixes: map[string]string{}, attrPrefixes: map[string]string{}, taken: map[string]bool{}, xsiTypes: map[*xml.Attr]xml.Name{}}
+	dec := xml.NewDecoder(r)
+	for tok, err = dec.Token(); err == nil; tok, err = dec.Token() {
+		tok = xml.CopyToken(tok)
+		switch t := tok.(type) {
+		case xml.StartElement:
+			scope := map[string]string{}
+			if len(scopes) > 0 {
+				for prefix, ns := range scopes[len(scopes)-1] {
+					scope[prefix] = ns
+				}
+			}
+			var declaresDefault bool
+			for _, att := range t.Attr {
+				if att.Name.Space == "xmlns" {
+					scope[att.Name.Local] = att.Value
+				} else if (len(att.Name.Space) == 0) && (att.Name.Local == "xmlns") {
+					scope[""], declaresDefault = att.Value, true
+				}
+			}
+			if marshaled && (!declaresDefault) && (len(scopes) > 0) && (t.Name.Space == scopes[len(scopes)-1][""]) {
+				t.Name.Space = ""
+			}
+			names = append(names, t.Name)
+			if scopes = append(scopes, scope); (len(scopes) == 1) && (len(rw.order) == 0) {
+				rw.root = t.Name.Space
+			}
+			rw.use(t.Name.Space, false)
+			for i, att := range t.Attr {
+				if (att.Name.Space == XsiNamespace) && (att.Name.Local == "type") {
+					name, prefix := xml.Name{Local: strings.TrimSpace(att.Value)}, ""
+					if pos := strings.Index(name.Local, ":"); pos > 0 {
+						prefix, name.Local = name.Local[:pos], name.Local[pos+1:]
+					}
+					name.Space = scope[prefix]
+					rw.xsiTypes[&t.Attr[i]] = name
+					rw.use(name.Space, false)
+				}
+				if !isNamespaceDecl(att.Name) {
+					rw.use(att.Name.Space, true)
+				}
+			}
+			tok = t
+		case xml.EndElement:
+			t.Name, scopes, names = names[len(names)-1], scopes[:len(scopes)-1], names[:len(names)-1]
+			tok = t
+		}
+		toks = append(toks, tok)
+	}
+	if err != io.EOF {
+		return
+	}
+	err = nil
+	rw.assign(me)
+	for _, tok = range toks {
+		switch t := tok.(type) {
+		case xml.StartElement:
+			s := "<" + rw.qname(t.Name, false)
+			if depth == 0 {
+				for _, ns := range rw.order {
+					if prefix := rw.elemPrefixes[ns]; (len(prefix) == 0) && rw.isElemNs[ns] {
+						s += fmt.Sprintf(" xmlns=\"%s\"", escapeXml(ns))
+					}
+				}
+				for _, ns := range rw.order {
+					for _, prefix := range rw.declsOf(ns) {
+						s += fmt.Sprintf(" xmlns:%s=\"%s\"", prefix, escapeXml(ns))
+					}
+				}
+			}
+			for i, att := range t.Attr {
+				if !isNamespaceDecl(att.Name) {
+					value := att.Value
+					if name, ok := rw.xsiTypes[&t.Attr[i]]; ok {
+						value = rw.qname(name, false)
+					}
+					s += fmt.Sprintf(" %s=\"%s\"", rw.qname(att.Name, true), escapeXml(value))
+				}
+			}
+			_, err = io.WriteString(w, s+">")
+			depth++
+		case xml.EndElement:
+			depth--
+			_, err = io.WriteString(w, "</"+rw.qname(t.Name, false)+">")
+		case xml.CharData:
+			err = xml.EscapeText(w, t)
+		case xml.Comment:
+			_, err = fmt.Fprintf(w, "<!--%s-->", t)
+		case xml.ProcInst:
+			_, err = fmt.Fprintf(w, "<?%s %s?>", t.Target, t.Inst)
+		case xml.Directive:
+			_, err = fmt.Fprintf(w, "<!%s>", t)
+		}
+		if err != nil {
+			return
+		}
+	}
+	return
+}
+
+//	Maps namespace prefixes to the namespace URIs bound to them, an empty prefix denoting the default namespace. Generated wrapper packages
+//	record the namespace declarations of their schema documents in such an XsdGoPkgNamespaces variable (see xsd.PkgGen.AddNamespaces),
+//	so that QNames using the prefixes of the schema can be resolved (and constructed) without hard-coding namespace URIs.
+type Namespaces map[string]string
+
+//	Returns the QNameValue denoted by the lexical QName qname ("prefix:local" or just "local"), with its Space resolved from the namespace
+//	bound to its prefix (or the default namespace, if unprefixed and declared). The "xml" prefix is always bound to the XML namespace.
+//	Returns false if qname is not a valid QName or its prefix is not bound.
+func (me Namespaces) Resolve(qname string) (v QNameValue, ok bool) {
+	if v.UnmarshalText([]byte(qname)) == nil {
+		if v.Space, ok = me[v.Prefix]; !ok {
+			switch v.Prefix {
+			case "":
+				ok = true
+			case "xml":
+				v.Space, ok = xmlNamespace, true
+			}
+		}
+	}
+	return
+}
+
+//	Returns the QNameValue of local in the namespace space, with a Prefix bound to space: the empty prefix if space is the default namespace,
+//	otherwise the alphabetically first prefix bound to it. Returns false if no prefix is bound to space (and space is not empty).
+func (me Namespaces) QName(space, local string) (v QNameValue, ok bool) {
+	v.Space, v.Local = space, local
+	if len(space) == 0 {
+		_, hasDefault := me[""]
+		return v, !hasDefault
+	}
+	if me[""] == space {
+		return v, true
+	}
+	for _, prefix := range me.prefixes() {
+		if (len(prefix) > 0) && (me[prefix] == space) {
+			v.Prefix = prefix
+			return v, true
+		}
+	}
+	return v, false
+}
+
+//	Returns Prefixes mapping each namespace URI of this Namespaces to its prefix (see QName), to marshal documents with the prefixes of a schema.
+func (me Namespaces) Prefixes() (prefixes Prefixes) {
+	prefixes = Prefixes{}
+	for _, prefix := range me.prefixes() {
+		if _, done := prefixes[me[prefix]]; (!done) && (len(me[prefix]) > 0) {
+			prefixes[me[prefix]] = prefix
+		}
+	}
+	return
+}
+
+//	Returns the prefixes of this Namespaces in alphabetical order, so that the empty prefix comes first.
+func (me Namespaces) prefixes() (prefixes []string) {
+	for prefix, _ := range me {
+		prefixes = append(prefixes, prefix)
+	}
+	sort.Strings(prefixes)
+	return
+}
+
+//	Records the namespaces used by a document for Prefixes.Rewrite and the prefixes assigned to them.
+type prefixRewriter struct {
+	order                      []string
+	isElemNs, isAttrNs         map[string]bool
+	elemPrefixes, attrPrefixes map[string]string
+	taken                      map[string]bool
+	root                       string
+	unqualified                bool
+	xsiTypes                   map[*xml.Attr]xml.Name
+}
+
+//	Records that ns is used for an attribute (if isAttr) or element name (or xsi:type value).
+func (me *prefixRewriter) use(ns string, isAttr bool) {
+	if me.isElemNs == nil {
+		me.isElemNs, me.isAttrNs = map[string]bool{}, map[string]bool{}
+	}
+	if len(ns) == 0 {
+		me.unqualified = me.unqualified || !isAttr
+		return
+	} else if ns == xmlNamespace {
+		return
+	}
+	if (!me.isElemNs[ns]) && (!me.isAttrNs[ns]) {
+		me.order = append(me.order, ns)
+	}
+	if isAttr {
+		me.isAttrNs[ns] = true
+	} else {
+		me.isElemNs[ns] = true
+	}
+}
+
+//	Assigns the prefixes of all namespaces used, preferring those in prefixes.
+func (me *prefixRewriter) assign(prefixes Prefixes) {
+	var n int
+	var generate = func() string {
+		for n++; me.taken[fmt.Sprintf("ns%d", n)]; n++ {
+		}
+		return fmt.Sprintf("ns%d", n)
+	}
+	me.taken["xml"], me.taken["xmlns"] = true, true
+	for _, ns := range me.order {
+		if prefix, ok := prefixes[ns]; ok && !me.taken[prefix] {
+			if (len(prefix) > 0) || (me.isElemNs[ns] && !me.unqualified) {
+				me.taken[prefix], me.elemPrefixes[ns] = true, prefix
+			}
+		}
+	}
+	if _, ok := me.elemPrefixes[me.root]; (!ok) && (len(me.root) > 0) && !(me.taken[""] || me.unqualified) {
+		me.taken[""], me.elemPrefixes[me.root] = true, ""
+	}
+	for _, ns := range me.order {
+		if _, ok := me.elemPrefixes[ns]; !ok {
+			me.elemPrefixes[ns] = generate()
+			me.taken[me.elemPrefixes[ns]] = true
+		}
+		if me.attrPrefixes[ns] = me.elemPrefixes[ns]; me.isAttrNs[ns] && (len(me.attrPrefixes[ns]) == 0) {
+			me.attrPrefixes[ns] = generate()
+			me.taken[me.attrPrefixes[ns]] = true
+		}
+	}
+}
+
+//	Returns the non-empty prefixes to declare for ns on the root element.
+func (me *prefixRewriter) declsOf(ns string) (prefixes []string) {
+	if prefix := me.elemPrefixes[ns]; len(prefix) > 0 {
+		prefixes = append(prefixes, prefix)
+	}
+	if prefix := me.attrPrefixes[ns]; (len(prefix) > 0) && (prefix != me.elemPrefixes[ns]) {
+		prefixes = append(prefixes, prefix)
+	}
+	return
+}
+
+//	Returns the qualified name to write for name as an attribute (if isAttr) or element name.
+func (me *prefixRewriter) qname(name xml.Name, isAttr bool) string {
+	var prefix string
+	switch {
+	case len(name.Space) == 0:
+		return name.Local
+	case name.Space == xmlNamespace:
+		prefix = "xml"
+	case isAttr:
+		prefix = me.attrPrefixes[name.Space]
+	default:
+		prefix = me.elemPrefixes[name.Space]
+	}
+	if len(prefix) == 0 {
+		return name.Local
+	}
+	return prefix + ":" + name.Local
+}
+
+//	Returns whether name is that of a namespace declaration attribute.
+func isNamespaceDecl(name xml.Name) bool {
+	return (name.Space == "xmlns") || ((len(name.Space) == 0) && (name.Local == "xmlns"))
+}
+
+func escapeXml(s string) string {
+	var buf bytes.Buffer
+	xml.EscapeText(&buf, []byte(s))
+	return buf.String()
+}
@@ -0,0 +1,72 @@
+package xsdt
+
+import (
+	"encoding/xml"
+	"fmt"
+	"testing"
+)
+
+type prefixesLine struct {
+	XMLName xml.Name `xml:"urn:example:orders line"`
+	Sku     string   `xml:"urn:example:catalog sku"`
+	Note    string   `xml:"note"`
+}
+
+type prefixesOrder struct {
+	XMLName xml.Name       `xml:"urn:example:orders order"`
+	Id      string         `xml:"urn:example:catalog id,attr"`
+	Lines   []prefixesLine `xml:"urn:example:orders line"`
+}
+
+func TestPrefixesDeclareNamespacesOnRoot(t *testing.T) {
+	order := &prefixesOrder{Id: "o1", Lines: []prefixesLine{{Sku: "A-1", Note: "gift"}, {Sku: "B-2"}}}
+	for _, c := range []struct {
+		prefixes Prefixes
+		expected string
+	}{
+		{Prefixes{"urn:example:orders": "ord", "urn:example:catalog": "cat"}, `<ord:order xmlns:ord="urn:example:orders" xmlns:cat="urn:example:catalog" cat:id="o1"><ord:line><cat:sku>A-1</cat:sku><note>gift</note></ord:line><ord:line><cat:sku>B-2</cat:sku><note></note></ord:line></ord:order>`},
+		{Prefixes{"urn:example:catalog": "cat"}, `<ns1:order xmlns:ns1="urn:example:orders" xmlns:cat="urn:example:catalog" cat:id="o1"><ns1:line><cat:sku>A-1</cat:sku><note>gift</note></ns1:line><ns1:line><cat:sku>B-2</cat:sku><note></note></ns1:line></ns1:order>`},
+	} {
+		var decoded prefixesOrder
+		if data, err := c.prefixes.Marshal(order); err != nil {
+			t.Fatal(err)
+		} else if string(data) != c.expected {
+			t.Errorf("expected\n%s\ngot\n%s", c.expected, data)
+		} else if err = xml.Unmarshal(data, &decoded); err != nil {
+			t.Fatal(err)
+		} else if (decoded.Id != "o1") || (len(decoded.Lines) != 2) || (decoded.Lines[0].Sku != "A-1") || (decoded.Lines[0].Note != "gift") {
+			t.Errorf("decoding %s lost values: %#v", data, decoded)
+		}
+	}
+}
+
+//	Tests resolving and constructing QNames by the prefixes of Namespaces, and marshaling with these prefixes.
+func TestNamespaces(t *testing.T) {
+	ns := Namespaces{"": "urn:example:orders", "cat": "urn:example:catalog", "c": "urn:example:catalog"}
+	for qname, expected := range map[string]string{
+		"line":     "urn:example:orders line true",
+		"cat:sku":  "urn:example:catalog sku true",
+		"xml:lang": "http://www.w3.org/XML/1998/namespace lang true",
+		"zz:sku":   " sku false",
+		"cat:a:b":  " a:b false",
+	} {
+		if v, ok := ns.Resolve(qname); fmt.Sprintf("%s %s %v", v.Space, v.Local, ok) != expected {
+			t.Errorf("%s: expected %s, got %s %s %v", qname, expected, v.Space, v.Local, ok)
+		}
+	}
+	for name, expected := range map[xml.Name]string{
+		{Space: "urn:example:orders", Local: "line"}: "line true",
+		{Space: "urn:example:catalog", Local: "sku"}: "c:sku true",
+		{Space: "urn:example:other", Local: "x"}:     "x false",
+		{Local: "note"}:                              "note false",
+	} {
+		if v, ok := ns.QName(name.Space, name.Local); fmt.Sprintf("%s %v", v, ok) != expected {
+			t.Errorf("%v: expected %s, got %s %v", name, expected, v, ok)
+		}
+	}
+	if data, err := ns.Prefixes().Marshal(&prefixesOrder{Id: "o1"}); err != nil {
+		t.Fatal(err)
+	} else if string(data) != `<order xmlns="urn:example:orders" xmlns:c="urn:example:catalog" c:id="o1"></order>` {
+		t.Errorf("unexpected marshaling with the prefixes of the namespaces: %s", data)
+	}
+}
@@ -0,0 +1,179 @@
+package xsdt
+
+import (
+	"bytes"
+	"context"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+)
+
+//	The envelope namespaces of SOAP 1.1 and SOAP 1.2, denoting the SOAP version for MarshalSoap and CallSoap.
+const (
+	Soap11 = "http://schemas.xmlsoap.org/soap/envelope/"
+	Soap12 = "http://www.w3.org/2003/05/soap-envelope"
+)
+
+//	A SOAP fault received in the body of a SOAP envelope, returned as an error by UnmarshalSoap and CallSoap.
+//	SOAP 1.1 and SOAP 1.2 faults are mapped onto the same fields.
+type SoapFault struct {
+	//	The fault code (such as "soap:Server" or "soap:Receiver") and, for SOAP 1.2, its subcode (if any), as qualified names.
+	Code, Subcode string
+
+	//	The human-readable explanation of the fault (the faultstring, or the first Reason text).
+	Reason string
+
+	//	The faultactor (or Role), and for SOAP 1.2 the Node, if any.
+	Actor, Node string
+
+	//	The content of the detail element, if any, such as an element declared by a wsdl:fault message of the operation called, for decoding via xml.Unmarshal.
+	//	Namespace prefixes that it uses but does not declare (as they are declared by the envelope) stay unresolved.
+	Detail []byte
+
+	//	The HTTP status code of the response carrying the fault, if received by CallSoap.
+	HTTPStatus int
+}
+
+//	Returns a description of this SoapFault, including its Code and Reason.
+func (me *SoapFault) Error() string {
+	return fmt.Sprintf("SOAP fault %s: %s", me.Code, me.Reason)
+}
+
+type soapInner struct {
+	Inner []byte `xml:",innerxml"`
+}
+
+type soapFault struct {
+	Code      string     `xml:"faultcode"`
+	Reason    string     `xml:"faultstring"`
+	Actor     string     `xml:"faultactor"`
+	Detail    *soapInner `xml:"detail"`
+	Code12    string     `xml:"Code>Value"`
+	Subcode12 string     `xml:"Code>Subcode>Value"`
+	Reasons12 []string   `xml:"Reason>Text"`
+	Role12    string     `xml:"Role"`
+	Node12    string     `xml:"Node"`
+	Detail12  *soapInner `xml:"Detail"`
+}
+
+type soapEnvelope struct {
+	XMLName xml.Name
+	Body    struct {
+		XMLName xml.Name
+		Fault   *soapFault `xml:"Fault"`
+	} `xml:"Body"`
+}
+
+//	Returns the SOAP envelope of the SOAP version denoted by its envelope namespace (Soap11 or Soap12) whose body holds the XML encoding of the fields
+//	of body (such as the request and response types generated for the operations of WSDL SOAP bindings), with all namespaces declared on the envelope
+//	element, using the prefixes registered in DefaultPrefixes (and "soap" for the envelope namespace, unless registered otherwise).
+func MarshalSoap(version string, body interface{}) ([]byte, error) {
+	var buf bytes.Buffer
+	enc := xml.NewEncoder(&buf)
+	env := xml.StartElement{Name: xml.Name{Space: version, Local: "Envelope"}}
+	err := enc.EncodeToken(env)
+	if err == nil {
+		err = enc.EncodeElement(body, xml.StartElement{Name: xml.Name{Space: version, Local: "Body"}})
+	}
+	if err == nil {
+		err = enc.EncodeToken(env.End())
+	}
+	if err == nil {
+		err = enc.Flush()
+	}
+	prefixes := defaultPrefixes()
+	if _, ok := prefixes[version]; !ok {
+		prefixes[version] = "soap"
+	}
+	return prefixes.rewriteBytes(buf.Bytes(), err)
+}
+
+//	Decodes the children of the body of the SOAP envelope raw (of either SOAP version) into the fields of body (unless it is nil), or returns a *SoapFault
+//	if the body holds a fault.
+func UnmarshalSoap(raw []byte, body interface{}) (err error) {
+	var env soapEnvelope
+	var tok xml.Token
+	if err = xml.Unmarshal(raw, &env); err != nil {
+		return
+	} else if (env.XMLName.Local != "Envelope") || ((env.XMLName.Space != Soap11) && (env.XMLName.Space != Soap12)) {
+		return fmt.Errorf("expected a SOAP envelope, got <%s> in namespace %q", env.XMLName.Local, env.XMLName.Space)
+	} else if env.Body.XMLName.Space != env.XMLName.Space {
+		return errors.New("no body found in SOAP envelope")
+	} else if f := env.Body.Fault; f != nil {
+		fault := &SoapFault{Code: f.Code, Subcode: f.Subcode12, Reason: f.Reason, Actor: f.Actor, Node: f.Node12}
+		if len(fault.Code) == 0 {
+			fault.Code, fault.Actor = f.Code12, f.Role12
+		}
+		if (len(fault.Reason) == 0) && (len(f.Reasons12) > 0) {
+			fault.Reason = f.Reasons12[0]
+		}
+		if f.Detail != nil {
+			fault.Detail = f.Detail.Inner
+		} else if f.Detail12 != nil {
+			fault.Detail = f.Detail12.Inner
+		}
+		fault.Code, fault.Subcode, fault.Reason = strings.TrimSpace(fault.Code), strings.TrimSpace(fault.Subcode), strings.TrimSpace(fault.Reason)
+		return fault
+	} else if body == nil {
+		return
+	}
+	dec := xml.NewDecoder(bytes.NewReader(raw))
+	for tok, err = dec.Token(); err == nil; tok, err = dec.Token() {
+		if start, ok := tok.(xml.StartElement); ok && (start.Name == env.Body.XMLName) {
+			return dec.DecodeElement(body, &start)
+		}
+	}
+	return
+}
+
+//	Calls a SOAP operation: posts the SOAP envelope (see MarshalSoap) of the version denoted by its envelope namespace (Soap11 or Soap12) holding req
+//	to the endpoint URL via client (or http.DefaultClient if nil), along with the SOAP action (if not empty) in the SOAPAction header (SOAP 1.1) or the
+//	action parameter of the Content-Type header (SOAP 1.2), and decodes the body of the response envelope into resp (see UnmarshalSoap), unless resp
+//	is nil (as for one-way operations). A fault received is returned as a *SoapFault, and any other response of an HTTP status other than 2xx as an error.
+func CallSoap(ctx context.Context, client *http.Client, endpoint, action, version string, req, resp interface{}) (err error) {
+	var raw []byte
+	var hreq *http.Request
+	var hresp *http.Response
+	if raw, err = MarshalSoap(version, req); err != nil {
+		return
+	}
+	if hreq, err = http.NewRequest("POST", endpoint, bytes.NewReader(raw)); err != nil {
+		return
+	}
+	if hreq = hreq.WithContext(ctx); version == Soap12 {
+		contentType := "application/soap+xml; charset=utf-8"
+		if len(action) > 0 {
+			contentType += "; action=\"" + action + "\""
+		}
+		hreq.Header.Set("Content-Type", contentType)
+	} else {
+		hreq.Header.Set("Content-Type", "text/xml; charset=utf-8")
+		hreq.Header.Set("SOAPAction", "\""+action+"\"")
+	}
+	if client == nil {
+		client = http.DefaultClient
+	}
+	if hresp, err = client.Do(hreq); err != nil {
+		return
+	}
+	defer hresp.Body.Close()
+	if raw, err = ioutil.ReadAll(hresp.Body); err != nil {
+		return
+	}
+	ok := (hresp.StatusCode >= 200) && (hresp.StatusCode < 300)
+	if (resp != nil) || !ok {
+		if err = UnmarshalSoap(raw, resp); err != nil {
+			if fault, isFault := err.(*SoapFault); isFault {
+				fault.HTTPStatus = hresp.StatusCode
+				return
+			}
+		}
+	}
+	if !ok {
+		err = fmt.Errorf("SOAP call to %s failed with HTTP status %s", endpoint, hresp.Status)
+	}
+	return
+}
@@ -0,0 +1,940 @@
+package xsdt
+
+import (
+	"encoding"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/xml"
+	"fmt"
+	"math"
+	"math/big"
+	"net/url"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+//	The typed counterparts of the string-based XSD built-in types, as used by generated wrapper packages if xsd.PkgGen.TypedBuiltins is set.
+//	All of them implement encoding.TextMarshaler and encoding.TextUnmarshaler with the lexical forms of their XSD types, and (like all other
+//	types of this package) Set and String methods. Their zero values denote absent values and are encoded as empty strings.
+
+var (
+	durationPattern = regexp.MustCompile(`^(-)?P(?:(\d+)Y)?(?:(\d+)M)?(?:(\d+)D)?(?:T(?:(\d+)H)?(?:(\d+)M)?(?:(\d+(?:\.\d*)?|\.\d+)S)?)?$`)
+	decimalPattern  = regexp.MustCompile(`^[+-]?(\d+(\.\d*)?|\.\d+)$`)
+	doublePattern   = regexp.MustCompile(`^[+-]?(\d+(\.\d*)?|\.\d+)([Ee][+-]?\d+)?$`)
+)
+
+//	Represents a calendar date (xs:date) as the midnight starting it.
+type DateValue struct {
+	time.Time
+
+	//	Whether the value has a time zone indicator (such as "Z" or "+01:00"). If false, Time is in UTC but is encoded without one.
+	HasTimezone bool
+}
+
+//	Implements encoding.TextMarshaler: encodes the value as CCYY-MM-DD, followed by its time zone indicator (if any).
+func (me DateValue) MarshalText() ([]byte, error) {
+	return []byte(me.String()), nil
+}
+
+//	Sets its current value obtained from parsing the specified string, or the zero value if it is not a valid xs:date.
+func (me *DateValue) Set(v string) {
+	if me.UnmarshalText([]byte(v)) != nil {
+		*me = DateValue{}
+	}
+}
+
+//	Returns its lexical representation, or "" if it is the zero value.
+func (me DateValue) String() string {
+	return formatTime(me.Time, me.HasTimezone, "2006-01-02")
+}
+
+//	Implements encoding.TextUnmarshaler: decodes an xs:date such as "2002-09-24" or "2002-09-24+06:00".
+func (me *DateValue) UnmarshalText(text []byte) (err error) {
+	*me = DateValue{}
+	me.Time, me.HasTimezone, err = parseTime(string(text), "2006-01-02", "xs:date")
+	return
+}
+
+//	A convenience interface that declares a type conversion to DateValue.
+type ToXsdtDateValue interface {
+	ToXsdtDateValue() DateValue
+}
+
+//	Represents a specific instance of time (xs:dateTime).
+type DateTimeValue struct {
+	time.Time
+
+	//	Whether the value has a time zone indicator (such as "Z" or "+01:00"). If false, Time is in UTC but is encoded without one.
+	HasTimezone bool
+
+	//	The number of digits of its fractional seconds (at most 9), as decoded (such as 2 for "2002-05-30T09:30:10.50"), so that trailing zeroes are kept.
+	//	If 0, fractional seconds are encoded with as many digits as needed, if any.
+	FractionDigits int
+}
+
+//	Implements encoding.TextMarshaler: encodes the value as CCYY-MM-DDThh:mm:ss (with fractional seconds, if any), followed by its time zone indicator (if any).
+func (me DateTimeValue) MarshalText() ([]byte, error) {
+	return []byte(me.String()), nil
+}
+
+//	Sets its current value obtained from parsing the specified string, or the zero value if it is not a valid xs:dateTime.
+func (me *DateTimeValue) Set(v string) {
+	if me.UnmarshalText([]byte(v)) != nil {
+		*me = DateTimeValue{}
+	}
+}
+
+//	Returns its lexical representation, or "" if it is the zero value.
+func (me DateTimeValue) String() string {
+	return formatTime(me.Time, me.HasTimezone, "2006-01-02T15:04:05"+fractionLayout(me.FractionDigits))
+}
+
+//	Implements encoding.TextUnmarshaler: decodes an xs:dateTime such as "2002-05-30T09:30:10.5" or "2002-05-30T09:30:10Z".
+func (me *DateTimeValue) UnmarshalText(text []byte) (err error) {
+	*me = DateTimeValue{}
+	if me.Time, me.HasTimezone, err = parseTime(string(text), "2006-01-02T15:04:05", "xs:dateTime"); err == nil {
+		me.FractionDigits = fractionDigits(string(text))
+	}
+	return
+}
+
+//	A convenience interface that declares a type conversion to DateTimeValue.
+type ToXsdtDateTimeValue interface {
+	ToXsdtDateTimeValue() DateTimeValue
+}
+
+//	Represents a time of day (xs:time) as that time on January 1 of year 0.
+type TimeValue struct {
+	time.Time
+
+	//	Whether the value has a time zone indicator (such as "Z" or "+01:00"). If false, Time is in UTC but is encoded without one.
+	HasTimezone bool
+
+	//	The number of digits of its fractional seconds (at most 9), as decoded (such as 3 for "13:20:00.000"), so that trailing zeroes are kept.
+	//	If 0, fractional seconds are encoded with as many digits as needed, if any.
+	FractionDigits int
+}
+
+//	Implements encoding.TextMarshaler: encodes the value as hh:mm:ss (with fractional seconds, if any), followed by its time zone indicator (if any).
+func (me TimeValue) MarshalText() ([]byte, error) {
+	return []byte(me.String()), nil
+}
+
+//	Sets its current value obtained from parsing the specified string, or the zero value if it is not a valid xs:time.
+func (me *TimeValue) Set(v string) {
+	if me.UnmarshalText([]byte(v)) != nil {
+		*me = TimeValue{}
+	}
+}
+
+//	Returns its lexical representation, or "" if it is the zero value.
+func (me TimeValue) String() string {
+	return formatTime(me.Time, me.HasTimezone, "15:04:05"+fractionLayout(me.FractionDigits))
+}
+
+//	Implements encoding.TextUnmarshaler: decodes an xs:time such as "13:20:00" or "13:20:00.5-05:00".
+func (me *TimeValue) UnmarshalText(text []byte) (err error) {
+	*me = TimeValue{}
+	if me.Time, me.HasTimezone, err = parseTime(string(text), "15:04:05", "xs:time"); err == nil {
+		me.FractionDigits = fractionDigits(string(text))
+	}
+	return
+}
+
+//	A convenience interface that declares a type conversion to TimeValue.
+type ToXsdtTimeValue interface {
+	ToXsdtTimeValue() TimeValue
+}
+
+//	Represents a recurring day of the month (xs:gDay) as midnight starting that day of January of year 0.
+type GDayValue struct {
+	time.Time
+
+	//	Whether the value has a time zone indicator (such as "Z" or "+01:00"). If false, Time is in UTC but is encoded without one.
+	HasTimezone bool
+}
+
+//	Implements encoding.TextMarshaler: encodes the value as ---DD, followed by its time zone indicator (if any).
+func (me GDayValue) MarshalText() ([]byte, error) {
+	return []byte(me.String()), nil
+}
+
+//	Sets its current value obtained from parsing the specified string, or the zero value if it is not a valid xs:gDay.
+func (me *GDayValue) Set(v string) {
+	if me.UnmarshalText([]byte(v)) != nil {
+		*me = GDayValue{}
+	}
+}
+
+//	Returns its lexical representation, or "" if it is the zero value.
+func (me GDayValue) String() string {
+	return formatTime(me.Time, me.HasTimezone, "---02")
+}
+
+//	Implements encoding.TextUnmarshaler: decodes an xs:gDay such as "---05" or "---05Z".
+func (me *GDayValue) UnmarshalText(text []byte) (err error) {
+	*me = GDayValue{}
+	me.Time, me.HasTimezone, err = parseTime(string(text), "---02", "xs:gDay")
+	return
+}
+
+//	A convenience interface that declares a type conversion to GDayValue.
+type ToXsdtGDayValue interface {
+	ToXsdtGDayValue() GDayValue
+}
+
+//	Represents a recurring month of the year (xs:gMonth) as midnight starting the first day of that month of year 0.
+type GMonthValue struct {
+	time.Time
+
+	//	Whether the value has a time zone indicator (such as "Z" or "+01:00"). If false, Time is in UTC but is encoded without one.
+	HasTimezone bool
+}
+
+//	Implements encoding.TextMarshaler: encodes the value as --MM, followed by its time zone indicator (if any).
+func (me GMonthValue) MarshalText() ([]byte, error) {
+	return []byte(me.String()), nil
+}
+
+//	Sets its current value obtained from parsing the specified string, or the zero value if it is not a valid xs:gMonth.
+func (me *GMonthValue) Set(v string) {
+	if me.UnmarshalText([]byte(v)) != nil {
+		*me = GMonthValue{}
+	}
+}
+
+//	Returns its lexical representation, or "" if it is the zero value.
+func (me GMonthValue) String() string {
+	return formatTime(me.Time, me.HasTimezone, "--01")
+}
+
+//	Implements encoding.TextUnmarshaler: decodes an xs:gMonth such as "--05" or "--05-05:00".
+func (me *GMonthValue) UnmarshalText(text []byte) (err error) {
+	*me = GMonthValue{}
+	me.Time, me.HasTimezone, err = parseTime(string(text), "--01", "xs:gMonth")
+	return
+}
+
+//	A convenience interface that declares a type conversion to GMonthValue.
+type ToXsdtGMonthValue interface {
+	ToXsdtGMonthValue() GMonthValue
+}
+
+//	Represents a recurring day of the year (xs:gMonthDay) as midnight starting that day of year 0 (a leap year).
+type GMonthDayValue struct {
+	time.Time
+
+	//	Whether the value has a time zone indicator (such as "Z" or "+01:00"). If false, Time is in UTC but is encoded without one.
+	HasTimezone bool
+}
+
+//	Implements encoding.TextMarshaler: encodes the value as --MM-DD, followed by its time zone indicator (if any).
+func (me GMonthDayValue) MarshalText() ([]byte, error) {
+	return []byte(me.String()), nil
+}
+
+//	Sets its current value obtained from parsing the specified string, or the zero value if it is not a valid xs:gMonthDay.
+func (me *GMonthDayValue) Set(v string) {
+	if me.UnmarshalText([]byte(v)) != nil {
+		*me = GMonthDayValue{}
+	}
+}
+
+//	Returns its lexical representation, or "" if it is the zero value.
+func (me GMonthDayValue) String() string {
+	return formatTime(me.Time, me.HasTimezone, "--01-02")
+}
+
+//	Implements encoding.TextUnmarshaler: decodes an xs:gMonthDay such as "--05-03" or "--02-29+01:00".
+func (me *GMonthDayValue) UnmarshalText(text []byte) (err error) {
+	*me = GMonthDayValue{}
+	me.Time, me.HasTimezone, err = parseTime(string(text), "--01-02", "xs:gMonthDay")
+	return
+}
+
+//	A convenience interface that declares a type conversion to GMonthDayValue.
+type ToXsdtGMonthDayValue interface {
+	ToXsdtGMonthDayValue() GMonthDayValue
+}
+
+//	Represents a calendar year (xs:gYear) as midnight starting its first day.
+type GYearValue struct {
+	time.Time
+
+	//	Whether the value has a time zone indicator (such as "Z" or "+01:00"). If false, Time is in UTC but is encoded without one.
+	HasTimezone bool
+}
+
+//	Implements encoding.TextMarshaler: encodes the value as CCYY, followed by its time zone indicator (if any).
+func (me GYearValue) MarshalText() ([]byte, error) {
+	return []byte(me.String()), nil
+}
+
+//	Sets its current value obtained from parsing the specified string, or the zero value if it is not a valid xs:gYear.
+func (me *GYearValue) Set(v string) {
+	if me.UnmarshalText([]byte(v)) != nil {
+		*me = GYearValue{}
+	}
+}
+
+//	Returns its lexical representation, or "" if it is the zero value.
+func (me GYearValue) String() string {
+	return formatTime(me.Time, me.HasTimezone, "2006")
+}
+
+//	Implements encoding.TextUnmarshaler: decodes an xs:gYear such as "2002" or "2002Z".
+func (me *GYearValue) UnmarshalText(text []byte) (err error) {
+	*me = GYearValue{}
+	me.Time, me.HasTimezone, err = parseTime(string(text), "2006", "xs:gYear")
+	return
+}
+
+//	A convenience interface that declares a type conversion to GYearValue.
+type ToXsdtGYearValue interface {
+	ToXsdtGYearValue() GYearValue
+}
+
+//	Represents a calendar month (xs:gYearMonth) as midnight starting its first day.
+type GYearMonthValue struct {
+	time.Time
+
+	//	Whether the value has a time zone indicator (such as "Z" or "+01:00"). If false, Time is in UTC but is encoded without one.
+	HasTimezone bool
+}
+
+//	Implements encoding.TextMarshaler: encodes the value as CCYY-MM, followed by its time zone indicator (if any).
+func (me GYearMonthValue) MarshalText() ([]byte, error) {
+	return []byte(me.String()), nil
+}
+
+//	Sets its current value obtained from parsing the specified string, or the zero value if it is not a valid xs:gYearMonth.
+func (me *GYearMonthValue) Set(v string) {
+	if me.UnmarshalText([]byte(v)) != nil {
+		*me = GYearMonthValue{}
+	}
+}
+
+//	Returns its lexical representation, or "" if it is the zero value.
+func (me GYearMonthValue) String() string {
+	return formatTime(me.Time, me.HasTimezone, "2006-01")
+}
+
+//	Implements encoding.TextUnmarshaler: decodes an xs:gYearMonth such as "2002-10" or "2002-10+02:00".
+func (me *GYearMonthValue) UnmarshalText(text []byte) (err error) {
+	*me = GYearMonthValue{}
+	me.Time, me.HasTimezone, err = parseTime(string(text), "2006-01", "xs:gYearMonth")
+	return
+}
+
+//	A convenience interface that declares a type conversion to GYearMonthValue.
+type ToXsdtGYearMonthValue interface {
+	ToXsdtGYearMonthValue() GYearMonthValue
+}
+
+//	Parses the xs:date, xs:dateTime or xs:time (as per kind) s with the specified layout (lacking fractional seconds and time zone),
+//	followed by an optional time zone indicator. Values without one are parsed as UTC, values with one in a fixed zone named after it (such as "+01:00"),
+//	or UTC for "Z". An hour of 24 (denoting the end of the day) is accepted.
+func parseTime(s, layout, kind string) (t time.Time, hasTimezone bool, err error) {
+	var loc = time.UTC
+	var endOfDay bool
+	if s = strings.TrimSpace(s); len(s) == 0 {
+		return
+	}
+	if strings.HasSuffix(s, "Z") {
+		s, hasTimezone = s[:len(s)-1], true
+	} else if n := len(s); (n > 6) && ((s[n-6] == '+') || (s[n-6] == '-')) && (s[n-3] == ':') {
+		h, herr := strconv.Atoi(s[n-5 : n-3])
+		m, merr := strconv.Atoi(s[n-2:])
+		if (herr != nil) || (merr != nil) || (h > 14) || (m > 59) {
+			err = fmt.Errorf("invalid %s time zone in %q", kind, s)
+			return
+		}
+		offset := h*3600 + m*60
+		if s[n-6] == '-' {
+			offset = -offset
+		}
+		s, hasTimezone, loc = s[:n-6], true, time.FixedZone(s[n-6:], offset)
+	}
+	if pos := strings.Index(layout, "15"); pos >= 0 {
+		if (len(s) >= pos+8) && (s[pos:pos+8] == "24:00:00") && (len(strings.Trim(s[pos+8:], ".0")) == 0) {
+			s, endOfDay = s[:pos]+"00:00:00", true
+		}
+	}
+	if t, err = time.ParseInLocation(layout, s, loc); err != nil {
+		err = fmt.Errorf("invalid %s %q: %v", kind, s, err)
+	} else if endOfDay {
+		t = t.AddDate(0, 0, 1)
+	}
+	return
+}
+
+//	Formats t with the specified layout, followed by its time zone indicator if hasTimezone, or returns "" if t is the zero time.
+//	A zero offset is indicated by "Z", unless t is in a zone named "+00:00" or "-00:00" (as parsed by parseTime).
+func formatTime(t time.Time, hasTimezone bool, layout string) string {
+	if t.IsZero() && !hasTimezone {
+		return ""
+	}
+	var s = t.Format(layout)
+	if hasTimezone {
+		if name, offset := t.Zone(); (offset == 0) && ((name == "+00:00") || (name == "-00:00")) {
+			return s + name
+		} else if offset == 0 {
+			return s + "Z"
+		}
+		return s + t.Format("-07:00")
+	}
+	return s
+}
+
+//	Returns the number of digits (at most 9) of the fractional seconds of the xs:dateTime or xs:time s, or 0 if it has none.
+func fractionDigits(s string) (n int) {
+	if pos := strings.LastIndex(s, "."); pos >= 0 {
+		for _, r := range s[pos+1:] {
+			if (r < '0') || (r > '9') {
+				break
+			}
+			n++
+		}
+	}
+	if n > 9 {
+		n = 9
+	}
+	return
+}
+
+//	Returns the layout of fractional seconds with n digits (such as ".000" for 3), or with as many as needed if n is 0.
+func fractionLayout(n int) string {
+	if (n <= 0) || (n > 9) {
+		return ".999999999"
+	}
+	return "." + strings.Repeat("0", n)
+}
+
+//	Represents a duration of time (xs:duration) by its components, as it may span months or years, whose lengths vary.
+type DurationValue struct {
+	Negative                            bool
+	Years, Months, Days, Hours, Minutes int
+	Seconds                             float64
+}
+
+//	Adds the duration to t (subtracting it, if Negative), in the order of its components from years to seconds.
+func (me DurationValue) AddTo(t time.Time) time.Time {
+	var sign = 1
+	if me.Negative {
+		sign = -1
+	}
+	t = t.AddDate(sign*me.Years, sign*me.Months, sign*me.Days)
+	return t.Add(time.Duration(sign) * (time.Duration(me.Hours)*time.Hour + time.Duration(me.Minutes)*time.Minute + time.Duration(me.Seconds*float64(time.Second))))
+}
+
+//	Returns the duration as a time.Duration, which is only possible (ok) if it has no Years or Months.
+func (me DurationValue) Duration() (d time.Duration, ok bool) {
+	if ok = (me.Years == 0) && (me.Months == 0); ok {
+		d = me.AddTo(time.Time{}).Sub(time.Time{})
+	}
+	return
+}
+
+//	Implements encoding.TextMarshaler: encodes the value as PnYnMnDTnHnMnS, omitting zero components.
+func (me DurationValue) MarshalText() ([]byte, error) {
+	return []byte(me.String()), nil
+}
+
+//	Sets its current value obtained from parsing the specified string, or the zero value if it is not a valid xs:duration.
+func (me *DurationValue) Set(v string) {
+	if me.UnmarshalText([]byte(v)) != nil {
+		*me = DurationValue{}
+	}
+}
+
+//	Returns its lexical representation, or "" if it is the zero value (as decoded from "" or "PT0S").
+func (me DurationValue) String() string {
+	var date, clock string
+	if me == (DurationValue{}) {
+		return ""
+	}
+	for _, c := range []struct {
+		n int
+		d string
+	}{{me.Years, "Y"}, {me.Months, "M"}, {me.Days, "D"}} {
+		if c.n != 0 {
+			date += strconv.Itoa(c.n) + c.d
+		}
+	}
+	for _, c := range []struct {
+		n int
+		d string
+	}{{me.Hours, "H"}, {me.Minutes, "M"}} {
+		if c.n != 0 {
+			clock += strconv.Itoa(c.n) + c.d
+		}
+	}
+	if me.Seconds != 0 {
+		clock += strconv.FormatFloat(me.Seconds, 'f', -1, 64) + "S"
+	}
+	if (len(date) == 0) && (len(clock) == 0) {
+		clock = "0S"
+	}
+	s := "P" + date
+	if len(clock) > 0 {
+		s += "T" + clock
+	}
+	if me.Negative {
+		s = "-" + s
+	}
+	return s
+}
+
+//	Implements encoding.TextUnmarshaler: decodes an xs:duration such as "P1Y2M3DT10H30M" or "-PT0.5S".
+func (me *DurationValue) UnmarshalText(text []byte) (err error) {
+	var s = strings.TrimSpace(string(text))
+	*me = DurationValue{}
+	if len(s) == 0 {
+		return
+	}
+	m := durationPattern.FindStringSubmatch(s)
+	if (m == nil) || strings.HasSuffix(s, "P") || strings.HasSuffix(s, "T") {
+		return fmt.Errorf("invalid xs:duration %q", s)
+	}
+	me.Negative = len(m[1]) > 0
+	for i, n := range []*int{&me.Years, &me.Months, &me.Days, &me.Hours, &me.Minutes} {
+		if len(m[i+2]) > 0 {
+			if *n, err = strconv.Atoi(m[i+2]); err != nil {
+				return fmt.Errorf("invalid xs:duration %q: %v", s, err)
+			}
+		}
+	}
+	if len(m[7]) > 0 {
+		me.Seconds, _ = strconv.ParseFloat(m[7], 64)
+	}
+	return
+}
+
+//	A convenience interface that declares a type conversion to DurationValue.
+type ToXsdtDurationValue interface {
+	ToXsdtDurationValue() DurationValue
+}
+
+//	Represents an arbitrary-precision decimal number (xs:decimal). A nil Rat denotes an absent value.
+type DecimalValue struct {
+	*big.Rat
+}
+
+//	Returns a DecimalValue holding the specified rational number (which should have a finite decimal representation).
+func NewDecimalValue(r *big.Rat) DecimalValue {
+	return DecimalValue{Rat: new(big.Rat).Set(r)}
+}
+
+//	Implements encoding.TextMarshaler: encodes the value as a decimal number without exponent, with as many fraction digits as it needs.
+func (me DecimalValue) MarshalText() ([]byte, error) {
+	return []byte(me.String()), nil
+}
+
+//	Sets its current value obtained from parsing the specified string, or the zero value if it is not a valid xs:decimal.
+func (me *DecimalValue) Set(v string) {
+	if me.UnmarshalText([]byte(v)) != nil {
+		*me = DecimalValue{}
+	}
+}
+
+//	Returns its lexical representation, or "" if Rat is nil.
+func (me DecimalValue) String() string {
+	var twos, fives int
+	if me.Rat == nil {
+		return ""
+	}
+	var two, five, rem = big.NewInt(2), big.NewInt(5), new(big.Int)
+	for d := new(big.Int).Set(me.Rat.Denom()); ; {
+		if rem.Mod(d, two).Sign() == 0 {
+			d.Quo(d, two)
+			twos++
+		} else if rem.Mod(d, five).Sign() == 0 {
+			d.Quo(d, five)
+			fives++
+		} else {
+			if d.Cmp(big.NewInt(1)) != 0 {
+				return me.Rat.FloatString(18)
+			}
+			break
+		}
+	}
+	if twos < fives {
+		twos = fives
+	}
+	return me.Rat.FloatString(twos)
+}
+
+//	Implements encoding.TextUnmarshaler: decodes an xs:decimal such as "-1.23", "+100000.00" or "210".
+func (me *DecimalValue) UnmarshalText(text []byte) error {
+	var s = strings.TrimSpace(string(text))
+	*me = DecimalValue{}
+	if len(s) == 0 {
+		return nil
+	}
+	if r, ok := new(big.Rat).SetString(strings.TrimPrefix(s, "+")); ok && decimalPattern.MatchString(s) {
+		me.Rat = r
+		return nil
+	}
+	return fmt.Errorf("invalid xs:decimal %q", s)
+}
+
+//	A convenience interface that declares a type conversion to DecimalValue.
+type ToXsdtDecimalValue interface {
+	ToXsdtDecimalValue() DecimalValue
+}
+
+//	Represents Base64-encoded arbitrary binary data (xs:base64Binary) by its decoded bytes.
+type Base64BinaryValue []byte
+
+//	Implements encoding.TextMarshaler: encodes the bytes in standard Base64 encoding.
+func (me Base64BinaryValue) MarshalText() ([]byte, error) {
+	return []byte(me.String()), nil
+}
+
+//	Sets its current value obtained from decoding the specified string, or nil if it is not valid Base64.
+func (me *Base64BinaryValue) Set(v string) {
+	if me.UnmarshalText([]byte(v)) != nil {
+		*me = nil
+	}
+}
+
+//	Returns the bytes in standard Base64 encoding.
+func (me Base64BinaryValue) String() string {
+	return base64.StdEncoding.EncodeToString(me)
+}
+
+//	Implements encoding.TextUnmarshaler: decodes standard Base64 encoding, disregarding all whitespace.
+func (me *Base64BinaryValue) UnmarshalText(text []byte) (err error) {
+	var b []byte
+	if b, err = base64.StdEncoding.DecodeString(strings.Join(strings.Fields(string(text)), "")); err != nil {
+		err = fmt.Errorf("invalid xs:base64Binary: %v", err)
+	} else if *me = nil; len(b) > 0 {
+		*me = b
+	}
+	return
+}
+
+//	A convenience interface that declares a type conversion to Base64BinaryValue.
+type ToXsdtBase64BinaryValue interface {
+	ToXsdtBase64BinaryValue() Base64BinaryValue
+}
+
+//	Represents hex-encoded arbitrary binary data (xs:hexBinary) by its decoded bytes.
+type HexBinaryValue []byte
+
+//	Implements encoding.TextMarshaler: encodes the bytes as upper-case hex digits.
+func (me HexBinaryValue) MarshalText() ([]byte, error) {
+	return []byte(me.String()), nil
+}
+
+//	Sets its current value obtained from decoding the specified string, or nil if it is not valid hex.
+func (me *HexBinaryValue) Set(v string) {
+	if me.UnmarshalText([]byte(v)) != nil {
+		*me = nil
+	}
+}
+
+//	Returns the bytes as upper-case hex digits.
+func (me HexBinaryValue) String() string {
+	return strings.ToUpper(hex.EncodeToString(me))
+}
+
+//	Implements encoding.TextUnmarshaler: decodes hex digits of either case.
+func (me *HexBinaryValue) UnmarshalText(text []byte) (err error) {
+	var b []byte
+	if b, err = hex.DecodeString(strings.TrimSpace(string(text))); err != nil {
+		err = fmt.Errorf("invalid xs:hexBinary: %v", err)
+	} else if *me = nil; len(b) > 0 {
+		*me = b
+	}
+	return
+}
+
+//	A convenience interface that declares a type conversion to HexBinaryValue.
+type ToXsdtHexBinaryValue interface {
+	ToXsdtHexBinaryValue() HexBinaryValue
+}
+
+//	Represents a Uniform Resource Identifier reference (xs:anyURI), as parsed by url.Parse.
+type AnyURIValue struct {
+	url.URL
+}
+
+//	Implements encoding.TextMarshaler: encodes the URI as per url.URL.String.
+func (me AnyURIValue) MarshalText() ([]byte, error) {
+	return []byte(me.String()), nil
+}
+
+//	Sets its current value obtained from parsing the specified string, or the zero value if url.Parse rejects it.
+func (me *AnyURIValue) Set(v string) {
+	if me.UnmarshalText([]byte(v)) != nil {
+		*me = AnyURIValue{}
+	}
+}
+
+//	Returns the URI as per url.URL.String.
+func (me AnyURIValue) String() string {
+	return me.URL.String()
+}
+
+//	Implements encoding.TextUnmarshaler: parses the URI (with leading and trailing whitespace removed) via url.Parse.
+func (me *AnyURIValue) UnmarshalText(text []byte) error {
+	*me = AnyURIValue{}
+	if u, err := url.Parse(strings.TrimSpace(string(text))); err != nil {
+		return fmt.Errorf("invalid xs:anyURI: %v", err)
+	} else {
+		me.URL = *u
+	}
+	return nil
+}
+
+//	A convenience interface that declares a type conversion to AnyURIValue.
+type ToXsdtAnyURIValue interface {
+	ToXsdtAnyURIValue() AnyURIValue
+}
+
+//	Represents a qualified name (xs:QName).
+type QNameValue struct {
+	//	The namespace bound to Prefix. It is only resolved when decoding an element whose own start tag declares Prefix (see UnmarshalXML),
+	//	as encoding/xml does not expose the namespace declarations in scope otherwise.
+	Space string
+
+	//	The namespace prefix of the lexical representation, if any.
+	Prefix string
+
+	//	The local part of the name.
+	Local string
+}
+
+//	Implements encoding.TextMarshaler: encodes the name as Prefix:Local, or just Local if there is no Prefix.
+func (me QNameValue) MarshalText() ([]byte, error) {
+	return []byte(me.String()), nil
+}
+
+//	Sets its current value obtained from parsing the specified string, without resolving its prefix.
+func (me *QNameValue) Set(v string) {
+	me.UnmarshalText([]byte(v))
+}
+
+//	Returns the name as Prefix:Local, or just Local if there is no Prefix.
+func (me QNameValue) String() string {
+	if len(me.Prefix) > 0 {
+		return me.Prefix + ":" + me.Local
+	}
+	return me.Local
+}
+
+//	Returns the namespace-qualified name, such as for comparing it with the XMLName of a decoded element.
+func (me QNameValue) Name() xml.Name {
+	return xml.Name{Space: me.Space, Local: me.Local}
+}
+
+//	Implements encoding.TextUnmarshaler: splits the name into Prefix and Local, leaving Space empty.
+func (me *QNameValue) UnmarshalText(text []byte) error {
+	var s = strings.TrimSpace(string(text))
+	*me = QNameValue{Local: s}
+	if pos := strings.Index(s, ":"); pos >= 0 {
+		me.Prefix, me.Local = s[:pos], s[pos+1:]
+	}
+	if strings.Contains(me.Local, ":") || ((len(me.Local) == 0) && (len(s) > 0)) {
+		return fmt.Errorf("invalid xs:QName %q", s)
+	}
+	return nil
+}
+
+//	Implements xml.Unmarshaler: decodes the character data of the element as per UnmarshalText, and resolves Space from the namespace
+//	declarations of start (or, if no Prefix is given, from its default namespace declaration), if any.
+func (me *QNameValue) UnmarshalXML(dec *xml.Decoder, start xml.StartElement) (err error) {
+	var s string
+	if err = dec.DecodeElement(&s, &start); err == nil {
+		if err = me.UnmarshalText([]byte(s)); err == nil {
+			for _, att := range start.Attr {
+				if ((att.Name.Space == "xmlns") && (att.Name.Local == me.Prefix)) || ((len(me.Prefix) == 0) && (len(att.Name.Space) == 0) && (att.Name.Local == "xmlns")) {
+					me.Space = att.Value
+				}
+			}
+		}
+	}
+	return
+}
+
+//	A convenience interface that declares a type conversion to QNameValue.
+type ToXsdtQNameValue interface {
+	ToXsdtQNameValue() QNameValue
+}
+
+//	Holds a value of xs:anySimpleType, as used by generated wrapper packages if xsd.PkgGen.AnySimpleTypes is xsd.AnySimpleTypesVariant: as XSD does not
+//	constrain such values, its lexical form is kept verbatim, and is accessible as any of the built-in types it is a valid lexical form of (see Kind).
+type AnySimpleValue struct {
+	Lexical string
+}
+
+//	Returns an AnySimpleValue holding the lexical form of v: a value implementing encoding.TextMarshaler (such as the other typed values of this package),
+//	a bool, an integer or floating-point number (with "INF", "-INF" and "NaN" for the special values), a string, or else its fmt.Stringer or default format.
+func NewAnySimpleValue(v interface{}) AnySimpleValue {
+	if tm, ok := v.(encoding.TextMarshaler); ok {
+		text, _ := tm.MarshalText()
+		return AnySimpleValue{Lexical: string(text)}
+	}
+	switch rv := reflect.ValueOf(v); rv.Kind() {
+	case reflect.Bool:
+		return AnySimpleValue{Lexical: strconv.FormatBool(rv.Bool())}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return AnySimpleValue{Lexical: strconv.FormatInt(rv.Int(), 10)}
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return AnySimpleValue{Lexical: strconv.FormatUint(rv.Uint(), 10)}
+	case reflect.Float32, reflect.Float64:
+		switch f := rv.Float(); {
+		case math.IsInf(f, 1):
+			return AnySimpleValue{Lexical: "INF"}
+		case math.IsInf(f, -1):
+			return AnySimpleValue{Lexical: "-INF"}
+		case math.IsNaN(f):
+			return AnySimpleValue{Lexical: "NaN"}
+		default:
+			return AnySimpleValue{Lexical: strconv.FormatFloat(f, 'g', -1, rv.Type().Bits())}
+		}
+	case reflect.String:
+		return AnySimpleValue{Lexical: rv.String()}
+	}
+	return AnySimpleValue{Lexical: fmt.Sprint(v)}
+}
+
+//	Returns the value as an xs:boolean ("true", "false", "1" or "0").
+func (me AnySimpleValue) Bool() (bool, error) {
+	switch strings.TrimSpace(me.Lexical) {
+	case "true", "1":
+		return true, nil
+	case "false", "0":
+		return false, nil
+	}
+	return false, &LexicalError{Type: "Boolean", Value: me.Lexical}
+}
+
+//	Returns the value as an xs:date.
+func (me AnySimpleValue) Date() (v DateValue, err error) {
+	err = v.UnmarshalText([]byte(me.Lexical))
+	return
+}
+
+//	Returns the value as an xs:dateTime.
+func (me AnySimpleValue) DateTime() (v DateTimeValue, err error) {
+	err = v.UnmarshalText([]byte(me.Lexical))
+	return
+}
+
+//	Returns the value as an xs:decimal.
+func (me AnySimpleValue) Decimal() (v DecimalValue, err error) {
+	err = v.UnmarshalText([]byte(me.Lexical))
+	return
+}
+
+//	Returns the value as an xs:duration.
+func (me AnySimpleValue) Duration() (v DurationValue, err error) {
+	err = v.UnmarshalText([]byte(me.Lexical))
+	return
+}
+
+//	Returns the value as an xs:double, including "INF", "-INF" and "NaN".
+func (me AnySimpleValue) Float() (float64, error) {
+	switch s := strings.TrimSpace(me.Lexical); s {
+	case "INF":
+		return math.Inf(1), nil
+	case "-INF":
+		return math.Inf(-1), nil
+	case "NaN":
+		return math.NaN(), nil
+	default:
+		if f, err := strconv.ParseFloat(s, 64); (err == nil) && doublePattern.MatchString(s) {
+			return f, nil
+		}
+	}
+	return 0, &LexicalError{Type: "Double", Value: me.Lexical}
+}
+
+//	Returns the value as an xs:long.
+func (me AnySimpleValue) Int() (int64, error) {
+	norm, err := lexicalForm("Long", me.Lexical)
+	if err != nil {
+		return 0, err
+	}
+	return strconv.ParseInt(norm, 10, 64)
+}
+
+//	Returns the name of the XSD built-in type that the value is most specifically a valid lexical form of: "integer" (for values that Int accepts), "decimal",
+//	"double", "boolean", "dateTime", "date", "time", "duration", or else "string".
+func (me AnySimpleValue) Kind() string {
+	if len(strings.TrimSpace(me.Lexical)) == 0 {
+		return "string"
+	} else if _, err := me.Int(); err == nil {
+		return "integer"
+	} else if _, err = me.Decimal(); err == nil {
+		return "decimal"
+	} else if _, err = me.Float(); err == nil {
+		return "double"
+	} else if _, err = me.Bool(); err == nil {
+		return "boolean"
+	} else if _, err = me.DateTime(); err == nil {
+		return "dateTime"
+	} else if _, err = me.Date(); err == nil {
+		return "date"
+	} else if _, err = me.Time(); err == nil {
+		return "time"
+	} else if _, err = me.Duration(); err == nil {
+		return "duration"
+	}
+	return "string"
+}
+
+//	Implements encoding.TextMarshaler: encodes the lexical form verbatim.
+func (me AnySimpleValue) MarshalText() ([]byte, error) {
+	return []byte(me.Lexical), nil
+}
+
+//	Sets the lexical form to v.
+func (me *AnySimpleValue) Set(v string) {
+	me.Lexical = v
+}
+
+//	Returns the lexical form.
+func (me AnySimpleValue) String() string {
+	return me.Lexical
+}
+
+//	Returns the value as an xs:time.
+func (me AnySimpleValue) Time() (v TimeValue, err error) {
+	err = v.UnmarshalText([]byte(me.Lexical))
+	return
+}
+
+//	Implements encoding.TextUnmarshaler: keeps text verbatim as the lexical form.
+func (me *AnySimpleValue) UnmarshalText(text []byte) error {
+	me.Lexical = string(text)
+	return nil
+}
+
+//	A convenience interface that declares a type conversion to AnySimpleValue.
+type ToXsdtAnySimpleValue interface {
+	ToXsdtAnySimpleValue() AnySimpleValue
+}
+
+//	A helper function for the Set() methods of generated wrapper packages for simple types derived from custom Go types (see xsd.PkgGen.TypeOverrides):
+//	sets the value that ptr points to by decoding s via its UnmarshalText() method, or to its zero value if s is not valid.
+func SetText(ptr encoding.TextUnmarshaler, s string) {
+	if ptr.UnmarshalText([]byte(s)) != nil {
+		v := reflect.ValueOf(ptr).Elem()
+		v.Set(reflect.Zero(v.Type()))
+	}
+}
+
+//	A helper function for the String() methods of generated wrapper packages for simple types derived from custom Go types (see xsd.PkgGen.TypeOverrides):
+//	returns the text that ptr encodes its value as via its MarshalText() method, or "" if that fails.
+func Text(ptr encoding.TextMarshaler) string {
+	if text, err := ptr.MarshalText(); err == nil {
+		return string(text)
+	}
+	return ""
+}
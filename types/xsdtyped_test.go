@@ -0,0 +1,62 @@
+package xsdt
+
+import (
+	"encoding/xml"
+	"io/ioutil"
+	"testing"
+	"time"
+)
+
+type typedTimes struct {
+	XMLName    xml.Name        `xml:"times"`
+	DateTime   DateTimeValue   `xml:"dateTime,attr"`
+	Time       TimeValue       `xml:"time,attr"`
+	Date       DateValue       `xml:"date,attr"`
+	GDay       GDayValue       `xml:"gDay,attr"`
+	GMonth     GMonthValue     `xml:"gMonth,attr"`
+	GMonthDay  GMonthDayValue  `xml:"gMonthDay,attr"`
+	GYear      GYearValue      `xml:"gYear,attr"`
+	GYearMonth GYearMonthValue `xml:"gYearMonth,attr"`
+	At         []DateTimeValue `xml:"at"`
+}
+
+//	Tests that the typed date and time values keep their time zone indicators (including "+00:00" and "-00:00") and the digits of their
+//	fractional seconds, re-encoding a document exactly as decoded, and denote the expected instants.
+func TestTypedTimesLexicalForms(t *testing.T) {
+	data, err := ioutil.ReadFile("testdata/datetimes.xml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var times typedTimes
+	if err = xml.Unmarshal(data, &times); err != nil {
+		t.Fatal(err)
+	}
+	if out, err := xml.Marshal(&times); err != nil {
+		t.Fatal(err)
+	} else if string(out)+"\n" != string(data) {
+		t.Errorf("re-encoding changed\n%s\ninto\n%s", data, out)
+	}
+	for lexical, actual := range map[string]time.Time{
+		"2002-05-30T09:30:10.5Z":         times.DateTime.Time,
+		"0000-01-01T13:20:00Z":           times.Time.Time,
+		"2002-05-30T00:00:00-05:00":      times.Date.Time,
+		"0000-01-05T00:00:00Z":           times.GDay.Time,
+		"0000-05-01T00:00:00Z":           times.GMonth.Time,
+		"0000-02-29T00:00:00+01:00":      times.GMonthDay.Time,
+		"2002-01-01T00:00:00Z":           times.GYear.Time,
+		"2002-10-01T00:00:00+02:00":      times.GYearMonth.Time,
+		"2002-05-30T09:30:10Z":           times.At[0].Time,
+		"2002-05-30T09:30:10.123456789Z": times.At[1].Time,
+	} {
+		if expected, _ := time.Parse(time.RFC3339Nano, lexical); !actual.Equal(expected) {
+			t.Errorf("expected %s, got %s", lexical, actual)
+		}
+	}
+	if (times.DateTime.FractionDigits != 2) || (times.Time.FractionDigits != 3) || !times.GDay.HasTimezone || times.GMonth.HasTimezone || times.At[0].HasTimezone {
+		t.Errorf("unexpected lexical details %+v", times)
+	}
+	var gDay GDayValue
+	if gDay.Set("---32"); !gDay.IsZero() || (gDay.String() != "") {
+		t.Errorf("expected the invalid xs:gDay ---32 to be the zero value, got %s", gDay)
+	}
+}
@@ -1,17 +1,53 @@
 package xsdt
 
 import (
+	"encoding"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"math/big"
+	"reflect"
+	"regexp"
 	"strconv"
+	"strings"
+	"sync"
+	"unicode/utf8"
 )
 
-type notation struct {
+//	The namespace of the xsi:type and xsi:nil attributes that may occur in any XML instance document.
+const XsiNamespace = "http://www.w3.org/2001/XMLSchema-instance"
+
+//	An xs:notation declaration, as recorded by the generated wrapper packages in their XsdGoPkgNotations variable.
+type NotationDecl struct {
 	Id, Name, Public, System string
 }
 
-type Notations map[string]*notation
+//	The xs:notation declarations of a schema, keyed by their names.
+type Notations map[string]*NotationDecl
 
 func (me Notations) Add(id, name, public, system string) {
-	me[name] = &notation{Id: id, Name: name, Public: public, System: system}
+	me[name] = &NotationDecl{Id: id, Name: name, Public: public, System: system}
+}
+
+//	The content of an xs:appinfo annotating a schema construct, as recorded by the generated wrapper packages in their XsdGoPkgAppInfo variable
+//	if xsd.PkgGen.AddAppInfo is set: its source attribute, its text (other than that within its elements, trimmed) and the elements within it,
+//	encoded as XML (see Node.MarshalXML).
+type AppInfo struct {
+	Source, Text, XML string
+}
+
+//	Returns the elements within the xs:appinfo, decoded from XML as generic trees.
+func (me AppInfo) Nodes() (nodes []Node, err error) {
+	var appInfo struct {
+		Nodes []Node `xml:",any"`
+	}
+	err = xml.Unmarshal([]byte("<appinfo>"+me.XML+"</appinfo>"), &appInfo)
+	return appInfo.Nodes, err
+}
+
+//	Decodes the first element within the xs:appinfo into the value that ptr points to, as xml.Unmarshal does.
+func (me AppInfo) Decode(ptr interface{}) error {
+	return xml.Unmarshal([]byte(me.XML), ptr)
 }
 
 //	In XSD, the type xsd:anySimpleType is the base type from which all other built-in types are derived.
@@ -1075,3 +1111,529 @@ func OnWalkError(err *error, slice *[]error, breakWalk bool, handler func(error)
 	*err = nil
 	return
 }
+
+//	Embedded alongside a generated complex type in the anonymous structs that the generated MarshalXML() / UnmarshalXML() methods pass on to encoding/xml.
+//	Its fields hide those methods (which would otherwise be promoted from the embedded complex type), so that encoding/xml processes the struct fields normally instead of recursing endlessly.
+type XsiShadow struct {
+	MarshalXML   struct{} `xml:"-"`
+	UnmarshalXML struct{} `xml:"-"`
+}
+
+//	A helper function for the UnmarshalXML() methods of generated wrapper packages: returns the local type name specified by the xsi:type attribute of start, if any.
+func XsiType(start xml.StartElement) (name string) {
+	for _, att := range start.Attr {
+		if (att.Name.Space == XsiNamespace) && (att.Name.Local == "type") {
+			if name = strings.TrimSpace(att.Value); strings.Contains(name, ":") {
+				name = name[strings.Index(name, ":")+1:]
+			}
+			break
+		}
+	}
+	return
+}
+
+//	A helper function for the MarshalXML() methods of generated wrapper packages: returns a copy of start with its xsi:type attribute set to the specified type (and the namespace declarations this requires).
+func XsiTypeStart(start xml.StartElement, namespace, name string) xml.StartElement {
+	var atts = xsiAttrs(start, "type")
+	if (len(namespace) > 0) && (namespace != start.Name.Space) {
+		atts = append(atts, xml.Attr{Name: xml.Name{Local: "xmlns:xsitype"}, Value: namespace})
+		name = "xsitype:" + name
+	}
+	start.Attr = append(atts, xml.Attr{Name: xml.Name{Local: "xsi:type"}, Value: name})
+	return start
+}
+
+//	A helper function for the UnmarshalXML() methods of generated wrapper packages: returns whether start has an xsi:nil attribute of "true" (or "1").
+func XsiNil(start xml.StartElement) bool {
+	for _, att := range start.Attr {
+		if (att.Name.Space == XsiNamespace) && (att.Name.Local == "nil") {
+			v := strings.TrimSpace(att.Value)
+			return (v == "true") || (v == "1")
+		}
+	}
+	return false
+}
+
+//	A helper function for the MarshalXML() methods of generated wrapper packages: returns a copy of start with its xsi:nil attribute set to "true" (and the namespace declaration this requires).
+func XsiNilStart(start xml.StartElement) xml.StartElement {
+	start.Attr = append(xsiAttrs(start, "nil"), xml.Attr{Name: xml.Name{Local: "xsi:nil"}, Value: "true"})
+	return start
+}
+
+//	Returns the attributes of start other than its xsi:<local> attribute, declaring the xsi prefix unless already done.
+func xsiAttrs(start xml.StartElement, local string) (atts []xml.Attr) {
+	var xsiDeclared bool
+	atts = make([]xml.Attr, 0, len(start.Attr)+3)
+	for _, att := range start.Attr {
+		if ((att.Name.Space == XsiNamespace) && (att.Name.Local == local)) || ((len(att.Name.Space) == 0) && (att.Name.Local == "xsi:"+local)) {
+			continue
+		}
+		xsiDeclared = xsiDeclared || ((len(att.Name.Space) == 0) && (att.Name.Local == "xmlns:xsi"))
+		atts = append(atts, att)
+	}
+	if !xsiDeclared {
+		atts = append(atts, xml.Attr{Name: xml.Name{Local: "xmlns:xsi"}, Value: XsiNamespace})
+	}
+	return
+}
+
+//	Decodes a child element of a generated struct type that is a member of a substitution group, returning false if the element is not.
+type SubstitutionDecoder func(dec *xml.Decoder, start xml.StartElement) (ok bool, err error)
+
+//	A helper function for the UnmarshalXML() methods of generated wrapper packages: decodes the element start into v as usual,
+//	except that those of its child elements that any of decoders accepts (in turn) are decoded by that one instead, in document order.
+func DecodeSubstitutes(dec *xml.Decoder, start xml.StartElement, v interface{}, decoders ...SubstitutionDecoder) (err error) {
+	var toks = []xml.Token{start.Copy()}
+	var tok xml.Token
+	var ok bool
+	for depth := 0; depth >= 0; {
+		if tok, err = dec.Token(); err != nil {
+			return
+		}
+		switch t := tok.(type) {
+		case xml.StartElement:
+			if ok = false; depth == 0 {
+				for _, decode := range decoders {
+					if ok, err = decode(dec, t); err != nil {
+						return
+					} else if ok {
+						break
+					}
+				}
+				if ok {
+					continue
+				}
+			}
+			depth++
+		case xml.EndElement:
+			depth--
+		}
+		toks = append(toks, xml.CopyToken(tok))
+	}
+	return xml.NewTokenDecoder(&tokenReplay{toks: toks}).Decode(v)
+}
+
+//	Replays previously read tokens to an xml.Decoder.
+type tokenReplay struct {
+	toks []xml.Token
+}
+
+func (me *tokenReplay) Token() (tok xml.Token, err error) {
+	if len(me.toks) == 0 {
+		return nil, io.EOF
+	}
+	tok, me.toks = me.toks[0], me.toks[1:]
+	return
+}
+
+//	Holds an element matched by an xs:any wildcard, as captured by the generated wrapper packages: its name, its attributes and its raw inner XML.
+//	Namespace prefixes used in InnerXML that are declared by ancestor elements are not re-declared.
+type AnyElement struct {
+	XMLName  xml.Name
+	Attrs    []xml.Attr `xml:",any,attr"`
+	InnerXML string     `xml:",innerxml"`
+}
+
+//	Holds an element of xs:anyType as a generic tree, as used by generated wrapper packages if xsd.PkgGen.AnyTypes is xsd.AnyTypesNode:
+//	its name, its attributes (see AnyAttrs), its character data (concatenated, as encoding/xml does not keep it interleaved with child elements) and its child elements.
+type Node struct {
+	XMLName xml.Name
+	Attrs   []xml.Attr `xml:",any,attr"`
+	Text    string     `xml:",chardata"`
+	Nodes   []Node     `xml:",any"`
+}
+
+//	Returns the value of the attribute with the specified namespace and local name, if any.
+func (me *Node) Attr(space, local string) (value string, ok bool) {
+	return AnyAttrs(me.Attrs).Get(space, local)
+}
+
+//	Implements xml.Marshaler: encodes the element named by start (such as after the field holding it) with its Attrs other than namespace
+//	declarations (as encoding/xml declares the namespaces of the names it encodes by itself), its Text, and its child elements named by their XMLName.
+func (me Node) MarshalXML(enc *xml.Encoder, start xml.StartElement) (err error) {
+	for _, att := range me.Attrs {
+		if (att.Name.Space != "xmlns") && !((len(att.Name.Space) == 0) && (att.Name.Local == "xmlns")) {
+			start.Attr = append(start.Attr, att)
+		}
+	}
+	if err = enc.EncodeToken(start); (err == nil) && (len(me.Text) > 0) {
+		err = enc.EncodeToken(xml.CharData(me.Text))
+	}
+	for i := 0; (err == nil) && (i < len(me.Nodes)); i++ {
+		err = me.Nodes[i].MarshalXML(enc, xml.StartElement{Name: me.Nodes[i].XMLName})
+	}
+	if err == nil {
+		err = enc.EncodeToken(start.End())
+	}
+	return
+}
+
+//	Returns the child elements with the specified local name and, unless space is "*", namespace.
+func (me *Node) Children(space, local string) (nodes []*Node) {
+	for i := range me.Nodes {
+		if (me.Nodes[i].XMLName.Local == local) && ((space == "*") || (me.Nodes[i].XMLName.Space == space)) {
+			nodes = append(nodes, &me.Nodes[i])
+		}
+	}
+	return
+}
+
+//	Holds the attributes matched by an xs:anyAttribute wildcard, as captured by the generated wrapper packages.
+//	Namespace declarations are not captured (see UnmarshalXMLAttr), as encoding/xml declares the namespaces of elements and attributes itself.
+type AnyAttrs []xml.Attr
+
+//	Implements xml.UnmarshalerAttr by appending attr, unless it is a namespace declaration (as with Node.MarshalXML): re-encoding those would
+//	duplicate the declarations that encoding/xml writes itself, yielding ill-formed XML.
+func (me *AnyAttrs) UnmarshalXMLAttr(attr xml.Attr) error {
+	if (attr.Name.Space != "xmlns") && !((len(attr.Name.Space) == 0) && (attr.Name.Local == "xmlns")) {
+		*me = append(*me, attr)
+	}
+	return nil
+}
+
+//	Returns the value of the attribute with the specified namespace and local name, if any.
+func (me AnyAttrs) Get(space, local string) (value string, ok bool) {
+	for _, att := range me {
+		if (att.Name.Space == space) && (att.Name.Local == local) {
+			return att.Value, true
+		}
+	}
+	return
+}
+
+//	Returns the attributes (other than namespace declarations) keyed by their names.
+func (me AnyAttrs) Map() (m map[xml.Name]string) {
+	m = make(map[xml.Name]string, len(me))
+	for _, att := range me {
+		if (att.Name.Space != "xmlns") && !((len(att.Name.Space) == 0) && (att.Name.Local == "xmlns")) {
+			m[att.Name] = att.Value
+		}
+	}
+	return
+}
+
+//	A helper function for the ApplyDefaults() methods of generated wrapper packages: sets *ptr (the field for an attribute or element) to def,
+//	unless it holds a value other than the zero value of its type (such as after decoding an element that specifies that attribute or element).
+func ApplyDefault(ptr, def interface{}) {
+	if v := reflect.ValueOf(ptr).Elem(); isZeroValue(v) {
+		v.Set(reflect.ValueOf(def).Convert(v.Type()))
+	}
+}
+
+//	A helper function for the ApplyFixed() methods of generated wrapper packages: sets *ptr (the field for an attribute or element) to fixed if it holds the zero value of its type,
+//	or else returns a *FacetError (with Facet "fixed") if it holds any value other than fixed.
+func ApplyFixed(ptr, fixed interface{}) (err error) {
+	if v := reflect.ValueOf(ptr).Elem(); isZeroValue(v) {
+		v.Set(reflect.ValueOf(fixed).Convert(v.Type()))
+	} else if fv := reflect.ValueOf(fixed).Convert(v.Type()); !reflect.DeepEqual(v.Interface(), fv.Interface()) {
+		err = &FacetError{Facet: "fixed", Constraint: fmt.Sprint(fixed), Value: fmt.Sprint(v.Interface())}
+	}
+	return
+}
+
+func isZeroValue(v reflect.Value) bool {
+	return reflect.DeepEqual(v.Interface(), reflect.Zero(v.Type()).Interface())
+}
+
+//	The constraining facets of an XSD simple-type restriction, as checked by the Validate() methods of generated wrapper packages.
+//	Empty strings denote absent facets.
+type Facets struct {
+	Enumerations                                              []string
+	Pattern, WhiteSpace                                       string
+	Length, MinLength, MaxLength, TotalDigits, FractionDigits string
+	MinInclusive, MaxInclusive, MinExclusive, MaxExclusive    string
+}
+
+//	Returned by Facets.Check if a value violates a facet, and by ApplyFixed if a value differs from the fixed value of its attribute or element.
+type FacetError struct {
+	//	The name of the violated facet, such as "pattern" or "maxLength" (or "fixed").
+	Facet string
+
+	//	The value of the violated facet as specified in the XSD.
+	Constraint string
+
+	//	The offending value.
+	Value string
+}
+
+//	Returns a description of the facet violation.
+func (me *FacetError) Error() string {
+	switch me.Facet {
+	case "enumeration":
+		return fmt.Sprintf("%q is not one of the enumerated values %s", me.Value, me.Constraint)
+	case "fixed":
+		return fmt.Sprintf("%q is not the fixed value %q", me.Value, me.Constraint)
+	case "pattern":
+		return fmt.Sprintf("%q does not match the pattern %q", me.Value, me.Constraint)
+	case "length":
+		return fmt.Sprintf("%q must have a length of %s", me.Value, me.Constraint)
+	case "minLength":
+		return fmt.Sprintf("%q must have a minimum length of %s", me.Value, me.Constraint)
+	case "maxLength":
+		return fmt.Sprintf("%q must have a maximum length of %s", me.Value, me.Constraint)
+	case "totalDigits", "fractionDigits":
+		return fmt.Sprintf("%s has more than %s %s", me.Value, me.Constraint, facetDigits[me.Facet])
+	}
+	return fmt.Sprintf("%s violates the %s facet of %s", me.Value, me.Facet, me.Constraint)
+}
+
+var (
+	facetDigits = map[string]string{"totalDigits": "total digits", "fractionDigits": "fraction digits"}
+
+	patterns      = map[string]*regexp.Regexp{}
+	patternErrs   = map[string]error{}
+	patternsMutex sync.Mutex
+)
+
+//	Returns a *FacetError describing the first facet violated by value, if any, always checking the facets in the same order (so that values violating several facets yield the same error every time). Whitespace in value is collapsed first if so demanded by the WhiteSpace facet.
+//	Patterns that cannot be translated to Go regular expressions (see CompilePattern) are disregarded.
+func (me *Facets) Check(value string) error {
+	if me.WhiteSpace == "collapse" {
+		value = strings.Join(strings.Fields(value), " ")
+	}
+	if len(me.Enumerations) > 0 {
+		found := false
+		for _, enum := range me.Enumerations {
+			if found = (enum == value); found {
+				break
+			}
+		}
+		if !found {
+			return &FacetError{Facet: "enumeration", Constraint: fmt.Sprintf("%q", me.Enumerations), Value: value}
+		}
+	}
+	if len(me.Pattern) > 0 {
+		if re := Pattern(me.Pattern); (re != nil) && !re.MatchString(value) {
+			return &FacetError{Facet: "pattern", Constraint: me.Pattern, Value: value}
+		}
+	}
+	numChars := int64(utf8.RuneCountInString(value))
+	if l, err := strconv.ParseInt(me.Length, 10, 64); (err == nil) && (numChars != l) {
+		return &FacetError{Facet: "length", Constraint: me.Length, Value: value}
+	}
+	if l, err := strconv.ParseInt(me.MinLength, 10, 64); (err == nil) && (numChars < l) {
+		return &FacetError{Facet: "minLength", Constraint: me.MinLength, Value: value}
+	}
+	if l, err := strconv.ParseInt(me.MaxLength, 10, 64); (err == nil) && (numChars > l) {
+		return &FacetError{Facet: "maxLength", Constraint: me.MaxLength, Value: value}
+	}
+	if num, ok := new(big.Rat).SetString(value); ok {
+		for _, fb := range [][2]string{{"minInclusive", me.MinInclusive}, {"maxInclusive", me.MaxInclusive}, {"minExclusive", me.MinExclusive}, {"maxExclusive", me.MaxExclusive}} {
+			facet, bound := fb[0], fb[1]
+			if b, ok := new(big.Rat).SetString(bound); ok {
+				if c := num.Cmp(b); ((facet == "minInclusive") && (c < 0)) || ((facet == "maxInclusive") && (c > 0)) || ((facet == "minExclusive") && (c <= 0)) || ((facet == "maxExclusive") && (c >= 0)) {
+					return &FacetError{Facet: facet, Constraint: bound, Value: value}
+				}
+			}
+		}
+		intDigits, fracDigits := decimalDigits(value)
+		if l, err := strconv.Atoi(me.TotalDigits); (err == nil) && (intDigits+fracDigits > l) {
+			return &FacetError{Facet: "totalDigits", Constraint: me.TotalDigits, Value: value}
+		}
+		if l, err := strconv.Atoi(me.FractionDigits); (err == nil) && (fracDigits > l) {
+			return &FacetError{Facet: "fractionDigits", Constraint: me.FractionDigits, Value: value}
+		}
+	}
+	return nil
+}
+
+func decimalDigits(value string) (intDigits, fracDigits int) {
+	value = strings.TrimLeft(value, "+-")
+	intPart, fracPart := value, ""
+	if pos := strings.Index(value, "."); pos >= 0 {
+		intPart, fracPart = value[:pos], value[pos+1:]
+	}
+	intPart, fracPart = strings.TrimLeft(intPart, "0"), strings.TrimRight(fracPart, "0")
+	return len(intPart), len(fracPart)
+}
+
+//	Returns the (cached) Go regular expression for the specified xs:pattern facet value, or nil if it cannot be translated (see CompilePattern).
+func Pattern(pattern string) (re *regexp.Regexp) {
+	re, _ = CompilePattern(pattern)
+	return
+}
+
+//	Returns the (cached) Go regular expression for the specified xs:pattern facet value, translated from the XSD regular expression dialect
+//	(see TranslatePattern), or a *PatternError if it is not valid or cannot be translated.
+func CompilePattern(pattern string) (re *regexp.Regexp, err error) {
+	var ok bool
+	patternsMutex.Lock()
+	defer patternsMutex.Unlock()
+	if re, ok = patterns[pattern]; !ok {
+		var expr string
+		if expr, err = TranslatePattern(pattern); err == nil {
+			if re, err = regexp.Compile(expr); err != nil {
+				err = &PatternError{Pattern: pattern, Message: err.Error()}
+			}
+		}
+		patterns[pattern], patternErrs[pattern] = re, err
+	}
+	err = patternErrs[pattern]
+	return
+}
+
+//	A helper function for the Validate() methods of generated wrapper packages: calls the Validate() method of v if it has one.
+//	Nil pointers and zero-valued simple-type values are skipped, as these are indistinguishable from absent optional elements and attributes.
+func ValidateValue(v interface{}) error {
+	if val, ok := v.(interface {
+		Validate() error
+	}); ok {
+		rv := reflect.ValueOf(v)
+		if rv.Kind() == reflect.Ptr {
+			if rv.IsNil() {
+				return nil
+			}
+			rv = rv.Elem()
+		}
+		if _, isText := v.(encoding.TextMarshaler); ((rv.Kind() == reflect.Struct) && !isText) || !rv.IsZero() {
+			return val.Validate()
+		}
+	}
+	return nil
+}
+
+//	Returned by the CheckBeforeMarshal() methods of generated wrapper packages if an instance does not conform to the content model of its complex type.
+type ContentError struct {
+	//	The path of the offending element or attribute relative to the instance checked, such as "item[2]/qty" or "shipTo/@country".
+	//	Empty if the instance itself is at fault, such as if none of the alternatives of a required xs:choice is set.
+	Path string
+
+	//	A description of the violation.
+	Problem string
+}
+
+//	Returns the path of the offending element or attribute (if any), followed by a description of the violation.
+func (me *ContentError) Error() string {
+	if len(me.Path) == 0 {
+		return me.Problem
+	}
+	return me.Path + ": " + me.Problem
+}
+
+//	An element field of an xs:choice, as passed to CheckChoice.
+type ChoiceField struct {
+	//	The index of the alternative (particle) of the xs:choice that the element belongs to.
+	Alternative int
+
+	//	The name of the element.
+	Name string
+
+	//	A pointer to the field.
+	Value interface{}
+}
+
+//	A helper function for the CheckBeforeMarshal() methods of generated wrapper packages: returns a *ContentError if the element or attribute of the field *ptr
+//	occurs less than min or (unless max is negative) more than max times. A slice field occurs as often as its length, any other field once unless it holds
+//	the zero value of its type (as this is indistinguishable from an absent optional element or attribute).
+func CheckOccurs(path string, ptr interface{}, min, max int) error {
+	return CheckLen(path, occurrences(ptr), min, max)
+}
+
+//	A helper function for the CheckOccurrences() methods of generated wrapper packages, like CheckOccurs but without reflection:
+//	returns a *ContentError if n (the length of a slice field) is less than min or (unless max is negative) greater than max.
+func CheckLen(path string, n, min, max int) error {
+	switch {
+	case (n == 0) && (min > 0):
+		return &ContentError{Path: path, Problem: "is required but missing"}
+	case n < min:
+		return &ContentError{Path: path, Problem: fmt.Sprintf("occurs %d times, but at least %d occurrences are required", n, min)}
+	case (max >= 0) && (n > max):
+		return &ContentError{Path: path, Problem: fmt.Sprintf("occurs %d times, but at most %d occurrences are allowed", n, max)}
+	}
+	return nil
+}
+
+//	A helper function for the CheckBeforeMarshal() methods of generated wrapper packages: returns a *ContentError if the fields of more than one
+//	alternative of an xs:choice occur (see CheckOccurs) or, if required, if none of them does.
+func CheckChoice(required bool, fields ...ChoiceField) error {
+	var set *ChoiceField
+	var names []string
+	for i := range fields {
+		if f := &fields[i]; occurrences(f.Value) > 0 {
+			if set == nil {
+				set = f
+			} else if f.Alternative != set.Alternative {
+				return &ContentError{Path: f.Name, Problem: fmt.Sprintf("cannot occur along with %s, as both are alternatives of the same xs:choice", set.Name)}
+			}
+		}
+		names = append(names, fields[i].Name)
+	}
+	if required && (set == nil) {
+		return &ContentError{Problem: fmt.Sprintf("requires one of the xs:choice alternatives %s", strings.Join(names, ", "))}
+	}
+	return nil
+}
+
+//	A helper function for the CheckBeforeMarshal() methods of generated wrapper packages: returns a *ContentError if which (the Which field of a choice union)
+//	names none of the specified alternatives (the local names of their elements) or, if required, if it is empty.
+func CheckWhich(which string, required bool, alternatives ...string) error {
+	if len(which) == 0 {
+		if required {
+			return &ContentError{Problem: fmt.Sprintf("requires one of the xs:choice alternatives %s", strings.Join(alternatives, ", "))}
+		}
+		return nil
+	}
+	for _, alt := range alternatives {
+		if alt == which {
+			return nil
+		}
+	}
+	return &ContentError{Path: which, Problem: fmt.Sprintf("is not one of the xs:choice alternatives %s", strings.Join(alternatives, ", "))}
+}
+
+//	A helper function for the CheckBeforeMarshal() methods of generated wrapper packages: returns a *ContentError if the field *ptr, holding the abstract
+//	head element of a substitution group, occurs (see CheckOccurs), as abstract elements must be replaced by one of the members of their substitution group.
+func CheckAbstract(path string, ptr interface{}) error {
+	if occurrences(ptr) > 0 {
+		return &ContentError{Path: path, Problem: "is abstract and must be replaced by a member of its substitution group"}
+	}
+	return nil
+}
+
+//	A helper function for the CheckBeforeMarshal() methods of generated wrapper packages: returns a *ContentError stating that the instance checked is
+//	of the abstract complex type typeName, as such instances must instead be of a type derived from it (such as by setting their XsdGoPkgXsiType field).
+func CheckAbstractType(typeName string) error {
+	return &ContentError{Problem: fmt.Sprintf("is of the abstract type %s and must instead be of a type derived from it", typeName)}
+}
+
+//	A helper function for the CheckBeforeMarshal() methods of generated wrapper packages: calls the CheckBeforeMarshal() method of v (or, if v points to a slice,
+//	of each of its items) if it has one, prefixing the Path of a *ContentError returned with path (and, for slice items, their 1-based index, such as "item[2]").
+//	Nil pointers and interfaces are skipped.
+func CheckValue(path string, v interface{}) (err error) {
+	var rv = reflect.ValueOf(v)
+	if (rv.Kind() == reflect.Ptr) && !rv.IsNil() && (rv.Elem().Kind() == reflect.Slice) {
+		for i, sl := 0, rv.Elem(); (i < sl.Len()) && (err == nil); i++ {
+			err = CheckValue(fmt.Sprintf("%s[%d]", path, i+1), sl.Index(i).Addr().Interface())
+		}
+		return
+	}
+	for ((rv.Kind() == reflect.Ptr) || (rv.Kind() == reflect.Interface)) && !rv.IsNil() && ((rv.Elem().Kind() == reflect.Ptr) || (rv.Elem().Kind() == reflect.Interface)) {
+		rv = rv.Elem()
+	}
+	if (!rv.IsValid()) || (((rv.Kind() == reflect.Ptr) || (rv.Kind() == reflect.Interface)) && rv.IsNil()) {
+		return nil
+	}
+	if c, ok := rv.Interface().(interface {
+		CheckBeforeMarshal() error
+	}); ok {
+		if err = c.CheckBeforeMarshal(); err != nil {
+			if ce, ok := err.(*ContentError); ok && (len(path) > 0) {
+				if len(ce.Path) > 0 {
+					path += "/" + ce.Path
+				}
+				err = &ContentError{Path: path, Problem: ce.Problem}
+			}
+		}
+	}
+	return
+}
+
+//	Returns how often the element or attribute of the field *ptr occurs, see CheckOccurs.
+func occurrences(ptr interface{}) int {
+	if v := reflect.ValueOf(ptr).Elem(); v.Kind() == reflect.Slice {
+		return v.Len()
+	} else if !isZeroValue(v) {
+		return 1
+	}
+	return 0
+}
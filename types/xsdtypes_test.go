@@ -0,0 +1,73 @@
+package xsdt
+
+import (
+	"bytes"
+	"encoding/xml"
+	"io"
+	"io/ioutil"
+	"testing"
+)
+
+type anyAttrsOrder struct {
+	XMLName xml.Name `xml:"urn:example:orders order"`
+	Attrs   AnyAttrs `xml:",any,attr"`
+}
+
+//	Fails t unless data is well-formed XML without duplicate attributes.
+func checkWellFormed(t *testing.T, data []byte) {
+	dec := xml.NewDecoder(bytes.NewReader(data))
+	for {
+		tok, err := dec.RawToken()
+		if err == io.EOF {
+			return
+		} else if err != nil {
+			t.Fatalf("%s: %v", data, err)
+		}
+		if start, ok := tok.(xml.StartElement); ok {
+			var seen = map[xml.Name]bool{}
+			for _, att := range start.Attr {
+				if seen[att.Name] {
+					t.Fatalf("%s: duplicate attribute %s:%s", data, att.Name.Space, att.Name.Local)
+				}
+				seen[att.Name] = true
+			}
+		}
+	}
+}
+
+func TestAnyAttrsRoundTrip(t *testing.T) {
+	data, err := ioutil.ReadFile("testdata/anyattrs.xml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var prev []byte
+	for i := 0; i < 3; i++ {
+		var order anyAttrsOrder
+		if err = xml.Unmarshal(data, &order); err != nil {
+			t.Fatal(err)
+		}
+		if len(order.Attrs) != 2 {
+			t.Fatalf("round trip %d: expected 2 attributes, got %v", i, order.Attrs)
+		}
+		if v, ok := order.Attrs.Get("urn:example:ext", "channel"); !ok || (v != "web") {
+			t.Fatalf("round trip %d: ext:channel = %q, %v", i, v, ok)
+		}
+		if data, err = xml.Marshal(&order); err != nil {
+			t.Fatal(err)
+		}
+		checkWellFormed(t, data)
+		if (prev != nil) && !bytes.Equal(prev, data) {
+			t.Fatalf("round trip %d changed\n%s\ninto\n%s", i, prev, data)
+		}
+		prev = data
+	}
+}
+
+func TestFacetsCheckOrder(t *testing.T) {
+	facets := &Facets{MinInclusive: "10", MaxInclusive: "0", MinExclusive: "10", MaxExclusive: "0"}
+	for i := 0; i < 20; i++ {
+		if err, _ := facets.Check("5").(*FacetError); (err == nil) || (err.Facet != "minInclusive") {
+			t.Fatalf("expected a minInclusive violation, got %v", err)
+		}
+	}
+}
@@ -0,0 +1,82 @@
+package xsd
+
+import (
+	"hash/fnv"
+	"strings"
+
+	"github.com/metaleap/go-util-str"
+	xsdt "github.com/metaleap/go-xsd/types"
+)
+
+//	The values of PkgGen.AnonTypeNames, denoting how the Go types generated for anonymous xs:complexTypes and xs:simpleTypes
+//	(those declared within elements, attributes, lists, unions etc. rather than globally) are named. All of these names start with "Txsd".
+const (
+	//	Named after all the constructs enclosing the anonymous type within its global component (such as TxsdOrderSequenceItem for the type
+	//	of the item element in the sequence of the order element), numbered in the order they are generated in where several would be named alike.
+	AnonTypeNamesConstructPath = ""
+
+	//	Named after the elements, attributes, groups and types enclosing the anonymous type only (such as TxsdOrderItem), numbered in the order
+	//	they are generated in where several would be named alike (such as the types of two item elements in different choices of order).
+	AnonTypeNamesElementPath = "path"
+
+	//	Named as for AnonTypeNamesElementPath, suffixed with a hash of the location of the anonymous type within its schema (such as TxsdOrderItem_1c0b8e3f),
+	//	so that no name ever depends on other anonymous types: each one stays the same across regenerations until the anonymous type itself,
+	//	or the declarations enclosing it, are renamed or moved, which is what downstream code referring to these types needs.
+	AnonTypeNamesHashed = "hash"
+
+	//	Numbered in the order they are generated in throughout the package (such as TxsdAnon1, TxsdAnon2), for the shortest names. Adding or removing
+	//	an anonymous type renumbers all those generated after it.
+	AnonTypeNamesSequential = "sequential"
+)
+
+//	Returns the name of the Go type of this anonymous xs:complexType or xs:simpleType (without the "T" prefix of all type names) according to
+//	PkgGen.AnonTypeNames, unique within the package.
+func (me *elemBase) anonName(bag *PkgBag) xsdt.NCName {
+	switch bag.gen.AnonTypeNames {
+	case AnonTypeNamesElementPath:
+		return bag.AnonName(me.namedPath(bag))
+	case AnonTypeNamesHashed:
+		hash := fnv.New32a()
+		hash.Write([]byte(me.locationKey(bag)))
+		return bag.AnonName(sfmt("%s_%08x", me.namedPath(bag), hash.Sum32()))
+	case AnonTypeNamesSequential:
+		bag.anonCounts[""]++
+		return bag.AnonName(sfmt("Anon%d", bag.anonCounts[""]))
+	}
+	return bag.AnonName(me.longSafeName(bag))
+}
+
+//	Returns the concatenated names of the named constructs enclosing this element within its schema document (or its longSafeName if there are none).
+func (me *elemBase) namedPath(bag *PkgBag) (np string) {
+	for el := me.self; el != nil; el = el.Parent() {
+		if _, isSchema := el.(*Schema); isSchema {
+			break
+		} else if eb := el.base(); eb.hasNameAttr && (len(bag.selfName(el)) > 0) {
+			np = bag.safeName(bag.selfName(el).String()) + np
+		}
+	}
+	return ustr.Ifs(len(np) > 0, np, me.longSafeName(bag))
+}
+
+//	Returns the location of this element within its schema document, such as "{urn:example:order}/element:order/complextype[0]/sequence[0]/element:item/complextype[0]":
+//	named constructs are identified by their names, all others by their positions among their siblings of the same kind.
+func (me *elemBase) locationKey(bag *PkgBag) (key string) {
+	for el := me.self; el != nil; el = el.Parent() {
+		if sd, isSchema := el.(*Schema); isSchema {
+			return "{" + sd.TargetNamespace.String() + "}" + key
+		} else if eb := el.base(); eb.hasNameAttr && (len(bag.selfName(el)) > 0) {
+			key = "/" + strings.ToLower(eb.xsdName.String()) + ":" + bag.selfName(el).String() + key
+		} else if pos := strings.LastIndex(eb.path, "/"); pos >= 0 {
+			key = eb.path[pos:] + key
+		}
+	}
+	return
+}
+
+//	Returns the name of el (see elemBase.selfName), or else the name given to it by anonName while generating this package, if it is an anonymous type.
+func (me *PkgBag) selfName(el element) xsdt.NCName {
+	if name := el.base().selfName(); (len(name) > 0) || !el.base().hasNameAttr {
+		return name
+	}
+	return me.anonNames[el]
+}
@@ -0,0 +1,32 @@
+package xsd
+
+import (
+	"regexp"
+	"strings"
+	"testing"
+)
+
+//	Tests the names each PkgGen.AnonTypeNames strategy gives the Go types of anonymous types, before and after another anonymous type
+//	is inserted ahead of them: only hashed names are guaranteed to stay the same.
+func TestAnonTypeNames(t *testing.T) {
+	typeDecl := regexp.MustCompile(`(?m)^type (Txsd\w+) `)
+	for _, c := range []struct {
+		mode, names, changedNames string
+	}{
+		{AnonTypeNamesConstructPath, "TxsdOrderSequenceItem TxsdOrderSequenceNote TxsdOrder", "TxsdOrderSequenceGift TxsdOrderSequenceItem TxsdOrderSequenceNote TxsdOrder"},
+		{AnonTypeNamesElementPath, "TxsdOrderItem TxsdOrderNote TxsdOrder", "TxsdOrderGift TxsdOrderItem TxsdOrderNote TxsdOrder"},
+		{AnonTypeNamesHashed, "TxsdOrderItem_35ce2bef TxsdOrderNote_4be7d138 TxsdOrder_ac859296", "TxsdOrderGift_7a1f7f88 TxsdOrderItem_35ce2bef TxsdOrderNote_4be7d138 TxsdOrder_ac859296"},
+		{AnonTypeNamesSequential, "TxsdAnon1 TxsdAnon2 TxsdAnon3", "TxsdAnon1 TxsdAnon2 TxsdAnon3 TxsdAnon4"},
+	} {
+		for dir, expected := range map[string]string{"anon": c.names, "anonchanged": c.changedNames} {
+			var names []string
+			src, _ := genTestSrc(t, dir, "order.xsd", func(opts *GenOptions) { opts.AnonTypeNames = c.mode })
+			for _, m := range typeDecl.FindAllStringSubmatch(src, -1) {
+				names = append(names, m[1])
+			}
+			if s := strings.Join(names, " "); s != expected {
+				t.Errorf("AnonTypeNames %q, testdata/%s: expected %s, got %s", c.mode, dir, expected, s)
+			}
+		}
+	}
+}
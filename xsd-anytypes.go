@@ -0,0 +1,60 @@
+package xsd
+
+import (
+	"github.com/metaleap/go-util-str"
+)
+
+//	The values of PkgGen.AnyTypes, denoting the Go types of the fields for elements of xs:anyType (such as extension points of schemas
+//	accepting arbitrary content), including, unless AnyTypesString, elements declaring neither a type nor a substitution group.
+const (
+	//	xsdt.AnyType, a string holding the character data of the element, but nothing of its attributes or child elements.
+	//	Elements declaring no type are generated as xsdt.String.
+	AnyTypesString = ""
+
+	//	xsdt.AnyElement, capturing the name, the attributes and the raw inner XML of the element verbatim, such as for passing it through
+	//	or decoding it later.
+	AnyTypesRawXML = "rawxml"
+
+	//	interface{}, for values of any Go type (such as the struct types of another generated package): encoding/xml encodes the value held,
+	//	but skips the element when decoding, which callers thus need to do themselves.
+	AnyTypesInterface = "interface"
+
+	//	xsdt.Node, a generic tree of the element, its attributes, its character data and its child elements.
+	AnyTypesNode = "node"
+)
+
+//	The values of PkgGen.AnySimpleTypes, denoting the Go types of the fields for attributes and elements of xs:anySimpleType, including,
+//	unless AnySimpleTypesString, attributes declaring no type.
+const (
+	//	xsdt.AnySimpleType, a string. Attributes declaring no type are generated as xsdt.String.
+	AnySimpleTypesString = ""
+
+	//	xsdt.AnySimpleValue, holding the lexical form verbatim along with accessors for the built-in types that it is valid for
+	//	(such as Int(), Decimal() and DateTime()) and Kind() naming the most specific of these.
+	AnySimpleTypesVariant = "variant"
+)
+
+//	Returns the Go type of the field for an element (or, if attr, an attribute) of the type qname (as specified by its declaration, or "" if none)
+//	according to PkgGen.AnyTypes and PkgGen.AnySimpleTypes, or "" if that is not xs:anyType or xs:anySimpleType, if it is generated as by default,
+//	or if it is overridden (see PkgGen.TypeOverrides).
+func (me *PkgBag) anyTypeRef(qname string, attr bool) string {
+	var local = ustr.Ifs(attr, "anySimpleType", "anyType")
+	if len(qname) > 0 {
+		qn := me.Schema.qname(qname)
+		if (qn.Space != xsdNamespaceUri) || (((qn.Local != "anyType") || attr) && (qn.Local != "anySimpleType")) || (len(me.overrideType(qn.Space, qn.Local, "T")) > 0) {
+			return ""
+		}
+		local = qn.Local
+	}
+	switch {
+	case (local == "anySimpleType") && (me.gen.AnySimpleTypes == AnySimpleTypesVariant):
+		return me.impName + ".AnySimpleValue"
+	case (local == "anyType") && (me.gen.AnyTypes == AnyTypesRawXML):
+		return me.impName + ".AnyElement"
+	case (local == "anyType") && (me.gen.AnyTypes == AnyTypesInterface):
+		return "interface{}"
+	case (local == "anyType") && (me.gen.AnyTypes == AnyTypesNode):
+		return me.impName + ".Node"
+	}
+	return ""
+}
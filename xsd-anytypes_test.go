@@ -0,0 +1,59 @@
+package xsd
+
+import (
+	"strings"
+	"testing"
+)
+
+//	Tests that PkgGen.AnyTypes and PkgGen.AnySimpleTypes select the Go types of the fields for xs:anyType and xs:anySimpleType (and, unless
+//	generated as by default, for elements and attributes declaring no type), and that generated packages decode into a generic Node and a variant.
+func TestAnyTypeMappings(t *testing.T) {
+	for _, test := range []struct {
+		anyTypes, anySimpleTypes              string
+		payload, extra, setting, value, label string
+	}{
+		{AnyTypesString, AnySimpleTypesString, "xsdt.AnyType", "xsdt.String", "xsdt.AnySimpleType", "xsdt.AnySimpleType", "xsdt.String"},
+		{AnyTypesRawXML, AnySimpleTypesString, "xsdt.AnyElement", "xsdt.AnyElement", "xsdt.AnySimpleType", "xsdt.AnySimpleType", "xsdt.String"},
+		{AnyTypesInterface, AnySimpleTypesString, "interface{}", "interface{}", "xsdt.AnySimpleType", "xsdt.AnySimpleType", "xsdt.String"},
+		{AnyTypesNode, AnySimpleTypesVariant, "xsdt.Node", "xsdt.Node", "xsdt.AnySimpleValue", "xsdt.AnySimpleValue", "xsdt.AnySimpleValue"},
+	} {
+		anyTypes, anySimpleTypes := test.anyTypes, test.anySimpleTypes
+		src, _ := genTestSrc(t, "anytypes", "doc.xsd", func(opts *GenOptions) { opts.AnyTypes, opts.AnySimpleTypes = anyTypes, anySimpleTypes })
+		for _, decl := range []string{"Payload " + test.payload, "Extra " + test.extra, "Setting " + test.setting, "Value " + test.value, "Label " + test.label} {
+			if !strings.Contains(src, "\t"+decl+" `") {
+				t.Errorf("with %q and %q, expected the field %s", anyTypes, anySimpleTypes, decl)
+			}
+		}
+	}
+	gopath, goOutFilePaths := genTestPkgs(t, "anytypes", func(opts *GenOptions) { opts.AnyTypes, opts.AnySimpleTypes = AnyTypesNode, AnySimpleTypesVariant })
+	goTestPkg(t, gopath, goOutFilePaths[0], `package go_Doc
+
+import (
+	"encoding/xml"
+	"strings"
+	"testing"
+)
+
+func TestAnyTypeMappings(t *testing.T) {
+	var doc XsdGoPkgHasElem_Envelope
+	src := `+"`"+`<envelope xmlns="urn:example:anytypes" value="42" label="2024-02-29"><payload xmlns:p="urn:example:p" p:id="7"><p:a>1</p:a><p:b>2</p:b></payload><extra>x</extra><setting>1.5</setting></envelope>`+"`"+`
+	if err := xml.Unmarshal([]byte("<doc>"+src+"</doc>"), &doc); err != nil {
+		t.Fatal(err)
+	}
+	env := doc.Envelope
+	if id, _ := env.Payload.Attr("urn:example:p", "id"); (id != "7") || (len(env.Payload.Children("urn:example:p", "b")) != 1) || (env.Extra.Text != "x") {
+		t.Errorf("expected the payload and extra nodes, got %#v", env)
+	}
+	if (env.Value.Kind() != "integer") || (env.Label.Kind() != "date") || (env.Setting.Kind() != "decimal") {
+		t.Errorf("expected an integer, a date and a decimal, got %s, %s and %s", env.Value.Kind(), env.Label.Kind(), env.Setting.Kind())
+	} else if n, err := env.Value.Int(); (err != nil) || (n != 42) {
+		t.Errorf("expected 42, got %v (%v)", n, err)
+	}
+	if out, err := xml.Marshal(env); err != nil {
+		t.Fatal(err)
+	} else if !strings.Contains(string(out), `+"`"+`value="42"`+"`"+`) || !strings.Contains(string(out), `+"`"+`<b xmlns="urn:example:p">2</b>`+"`"+`) {
+		t.Errorf("expected the variant and node to be encoded, got %s", out)
+	}
+}
+`)
+}
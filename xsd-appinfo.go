@@ -0,0 +1,96 @@
+package xsd
+
+import (
+	"bytes"
+	"encoding/xml"
+	"sort"
+	"strings"
+
+	xsdt "github.com/metaleap/go-xsd/types"
+)
+
+//	Returns the elements within this xs:appinfo encoded as XML (see xsdt.Node.MarshalXML), each declaring the namespaces of the names
+//	it uses and with the text of each element trimmed, or "" if there are none.
+func (me *AppInfo) XML() string {
+	var buf bytes.Buffer
+	var enc = xml.NewEncoder(&buf)
+	for _, node := range trimmedNodes(me.Nodes) {
+		if node.MarshalXML(enc, xml.StartElement{Name: node.XMLName}) != nil {
+			break
+		}
+	}
+	enc.Flush()
+	return buf.String()
+}
+
+//	Returns copies of nodes and their child elements with their texts trimmed, dropping the indentation between child elements.
+func trimmedNodes(nodes []xsdt.Node) (trimmed []xsdt.Node) {
+	for _, node := range nodes {
+		node.Text, node.Nodes = strings.TrimSpace(node.Text), trimmedNodes(node.Nodes)
+		trimmed = append(trimmed, node)
+	}
+	return
+}
+
+//	Returns the xs:appinfos of the annotations of the construct (or, for an xs:annotation, its own), such as to look up the machine-readable
+//	hints that the components of a schema are annotated with via Schema.Walk or Schema.Query.
+func (me SchemaNode) AppInfos() (appInfos []*AppInfo) {
+	if ann, ok := me.Elem.(*Annotation); ok {
+		return ann.AppInfos
+	}
+	for _, kid := range me.Elem.(element).base().kids {
+		if ann, ok := kid.(*Annotation); ok {
+			appInfos = append(appInfos, ann.AppInfos...)
+		}
+	}
+	return
+}
+
+//	Records the xs:appinfos of anns (if any) for the generated declaration name (a type name, or "Type.Field") if PkgGen.AddAppInfo is set.
+func (me *PkgBag) appInfoOf(name string, anns []*Annotation) {
+	if me.gen.AddAppInfo && (me.appInfos[name] == nil) {
+		var seen = map[*AppInfo]bool{}
+		for _, ann := range anns {
+			if ann != nil {
+				for _, ai := range ann.AppInfos {
+					if !seen[ai] {
+						seen[ai], me.appInfos[name] = true, append(me.appInfos[name], ai)
+					}
+				}
+			}
+		}
+	}
+}
+
+//	Renders the XsdGoPkgAppInfo variable (see PkgGen.AddAppInfo) with the xs:appinfos recorded by appInfoOf, unless there are none.
+func (me *PkgBag) addAppInfoVar() {
+	var names []string
+	for name, _ := range me.appInfos {
+		names = append(names, name)
+	}
+	if len(names) == 0 {
+		return
+	}
+	sort.Strings(names)
+	me.impsUsed[me.impName] = true
+	me.appendFmt(false, "//\tThe contents of the xs:appinfo annotations of the schema constructs that the types, struct fields and embedded types of this package were generated from,\n//\tkeyed by the names of the types or \"Type.Field\".")
+	me.appendFmt(false, "var %sAppInfo = map[string][]%s.AppInfo{", idPrefix, me.impName)
+	for _, name := range names {
+		var lits []string
+		for _, ai := range me.appInfos[name] {
+			var fields []string
+			if len(ai.Source) > 0 {
+				fields = append(fields, sfmt("Source: %q", ai.Source.String()))
+			}
+			if text := strings.TrimSpace(ai.CDATA); len(text) > 0 {
+				fields = append(fields, sfmt("Text: %q", text))
+			}
+			if raw := ai.XML(); len(raw) > 0 {
+				fields = append(fields, sfmt("XML: %q", raw))
+			}
+			lits = append(lits, "{"+strings.Join(fields, ", ")+"}")
+		}
+		me.appendFmt(false, "\t%q: {%s},", name, strings.Join(lits, ", "))
+	}
+	me.appendFmt(true, "}")
+}
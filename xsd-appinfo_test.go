@@ -0,0 +1,78 @@
+package xsd
+
+import (
+	"encoding/xml"
+	"testing"
+)
+
+//	Tests that the xs:appinfos of constructs are exposed with their elements, that Compiled.Notations and Compiled.Notation return the xs:notation
+//	declarations, and that generated packages record both in XsdGoPkgAppInfo (if PkgGen.AddAppInfo is set) and XsdGoPkgNotations.
+func TestAppInfoAndNotations(t *testing.T) {
+	sd := loadTestSchema(t, "appinfo", "doc.xsd")
+	for name, expected := range map[string]string{
+		"Country": `urn:codelists <list xmlns="urn:example:codelists" version="2"><uri xmlns="urn:example:codelists">http://example.com/countries</uri></list>`,
+		"country": ` <widget xmlns="urn:example:ui">select</widget>`,
+	} {
+		nodes := sd.Find(ByName(name))
+		if len(nodes) != 1 {
+			t.Fatalf("expected 1 construct named %s, got %d", name, len(nodes))
+		} else if appInfos := nodes[0].AppInfos(); (len(appInfos) != 1) || (appInfos[0].Source.String()+" "+appInfos[0].XML() != expected) {
+			t.Errorf("expected the xs:appinfo of %s to be %s, got %v", name, expected, appInfos)
+		}
+	}
+	compiled, err := sd.Compile()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for _, not := range compiled.Notations() {
+		names = append(names, not.Name.String())
+	}
+	if sfmt("%v", names) != "[jpeg png]" {
+		t.Errorf("expected the notations jpeg and png, got %v", names)
+	}
+	if not := compiled.Notation(xml.Name{Space: "urn:example:appinfo", Local: "jpeg"}); (not == nil) || (not.System.String() != "viewer.exe") {
+		t.Errorf("expected the notation jpeg of the system viewer.exe, got %v", not)
+	}
+	gopath, goOutFilePaths := genTestPkgs(t, "appinfo", func(opts *GenOptions) { opts.AddAppInfo = true })
+	goTestPkg(t, gopath, goOutFilePaths[0], `package go_Doc
+
+import (
+	"encoding/xml"
+	"strings"
+	"testing"
+)
+
+func TestAppInfo(t *testing.T) {
+	ai := XsdGoPkgAppInfo["TCountry"]
+	if (len(ai) != 1) || (ai[0].Source != "urn:codelists") || (ai[0].Text != "ISO 3166") {
+		t.Fatalf("unexpected xs:appinfo of TCountry %#v", ai)
+	}
+	var list struct {
+		XMLName xml.Name `+"`"+`xml:"urn:example:codelists list"`+"`"+`
+		Version int      `+"`"+`xml:"version,attr"`+"`"+`
+		Uri     string   `+"`"+`xml:"urn:example:codelists uri"`+"`"+`
+	}
+	if err := ai[0].Decode(&list); err != nil {
+		t.Fatal(err)
+	} else if (list.Version != 2) || (list.Uri != "http://example.com/countries") {
+		t.Errorf("unexpected code list %#v", list)
+	}
+	var widgets int
+	for name, ais := range XsdGoPkgAppInfo {
+		if strings.HasPrefix(name, "TAddress.") {
+			if nodes, err := ais[0].Nodes(); (err != nil) || (len(nodes) != 1) || (nodes[0].XMLName.Local != "widget") || (nodes[0].Text != "select") {
+				t.Errorf("%s: unexpected xs:appinfo nodes %#v (%v)", name, nodes, err)
+			}
+			widgets++
+		}
+	}
+	if widgets != 1 {
+		t.Errorf("expected the xs:appinfo of the country field of TAddress, got %d", widgets)
+	}
+	if jpeg := XsdGoPkgNotations["jpeg"]; (jpeg == nil) || (jpeg.Public != "image/jpeg") || (jpeg.System != "viewer.exe") || (len(XsdGoPkgNotations) != 2) {
+		t.Errorf("unexpected notations %#v", XsdGoPkgNotations)
+	}
+}
+`)
+}
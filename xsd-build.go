@@ -0,0 +1,380 @@
+package xsd
+
+import (
+	"path/filepath"
+	"strconv"
+
+	"github.com/metaleap/go-util-str"
+
+	xsdt "github.com/metaleap/go-xsd/types"
+)
+
+//	Returns a new, empty schema document for targetNamespace (which may be empty), to be built up in code (see AddElement, AddComplexType
+//	and AddSimpleType) and then written as XSD (see WriteXSD) or generated Go code from (see MakeGoPkgSrcFileAt and GenerateGoSourceAs)
+//	as if it had been loaded from uri (such as "example.com/order.xsd", which the generated Go source file and package are named after).
+//	The prefix "xs" is bound to the XSD namespace and both the prefix "tns" and the default namespace to targetNamespace, so that type
+//	and element references can be given as "xs:string", "tns:OrderType" or "OrderType". Local element declarations are qualified.
+func NewSchema(uri, targetNamespace string) (me *Schema) {
+	me = &Schema{XSDNamespacePrefix: "xs", XMLIncludedSchemas: []*Schema{}, XMLImportedSchemas: []*Schema{}}
+	me.loadUri, me.loadLocalPath = uri, filepath.Join(PkgGen.BaseCodePath, filepath.FromSlash(uri))
+	me.XMLNamespaces = map[string]string{"xs": xsdNamespaceUri, "xml": "http://www.w3.org/XML/1998/namespace", "": targetNamespace}
+	if me.TargetNamespace = xsdt.AnyURI(targetNamespace); len(targetNamespace) > 0 {
+		me.XMLNamespaces["tns"], me.XMLNamespacePrefix, me.ElementFormDefault = targetNamespace, "tns", "qualified"
+	}
+	me.initElement(nil)
+	return
+}
+
+//	Binds prefix to namespace for the QName references of this schema (such as to the namespace of an AddImport).
+func (me *Schema) AddNamespace(prefix, namespace string) {
+	me.XMLNamespaces[prefix] = namespace
+}
+
+//	Adds an xs:import of namespace (bound to prefix, see AddNamespace) from schemaLocation (which may be empty). The imported
+//	schema is not loaded: the Go code generated for this schema imports the package generated for it, as with any unresolved import.
+func (me *Schema) AddImport(prefix, namespace, schemaLocation string) (imp *Import) {
+	imp = &Import{}
+	imp.Namespace, imp.SchemaLocation = namespace, xsdt.AnyURI(schemaLocation)
+	me.AddNamespace(prefix, namespace)
+	me.Imports = append(me.Imports, imp)
+	imp.initElement(me)
+	return
+}
+
+//	Adds a global element declaration of the specified name and type (a QName, or empty for an anonymous type, see Element.NewComplexType).
+func (me *Schema) AddElement(name, typeQName string) (el *Element) {
+	el = newElement(name, typeQName)
+	me.Elements = append(me.Elements, el)
+	el.initElement(me)
+	return
+}
+
+//	Adds a global attribute declaration of the specified name and type (a QName, or empty for an anonymous type, see Attribute.NewSimpleType).
+func (me *Schema) AddAttribute(name, typeQName string) (att *Attribute) {
+	att = newAttribute(name, typeQName)
+	me.Attributes = append(me.Attributes, att)
+	att.initElement(me)
+	return
+}
+
+//	Adds a global complex type definition of the specified name, to be filled via ComplexType.AddElement and ComplexType.AddAttribute.
+func (me *Schema) AddComplexType(name string) (ct *ComplexType) {
+	ct = &ComplexType{}
+	ct.Name = xsdt.NCName(name)
+	me.ComplexTypes = append(me.ComplexTypes, ct)
+	ct.initElement(me)
+	return
+}
+
+//	Adds a global simple type definition of the specified name restricting baseQName (such as "xs:string"), to be constrained
+//	via the facet methods of SimpleType (such as WithMaxLength and WithEnumeration).
+func (me *Schema) AddSimpleType(name, baseQName string) (st *SimpleType) {
+	st = newSimpleType(baseQName)
+	st.Name = xsdt.NCName(name)
+	me.SimpleTypes = append(me.SimpleTypes, st)
+	st.initElement(me)
+	return
+}
+
+func newElement(name, typeQName string) (el *Element) {
+	el = &Element{}
+	el.Name, el.Type = xsdt.NCName(name), xsdt.Qname(typeQName)
+	return
+}
+
+func newAttribute(name, typeQName string) (att *Attribute) {
+	att = &Attribute{}
+	att.Name, att.Type = xsdt.NCName(name), xsdt.Qname(typeQName)
+	return
+}
+
+func newSimpleType(baseQName string) (st *SimpleType) {
+	st = &SimpleType{}
+	st.RestrictionSimpleType = &RestrictionSimpleType{}
+	st.RestrictionSimpleType.Base = xsdt.Qname(baseQName)
+	return
+}
+
+func newDocAnnotation(doc string) (ann *Annotation) {
+	ann = &Annotation{}
+	ann.Documentations = []*Documentation{&Documentation{}}
+	ann.Documentations[0].CDATA = doc
+	return
+}
+
+//	Sets the occurrence constraints of this (local) element declaration: a negative max means "unbounded".
+func (me *Element) Occurs(min, max int) *Element {
+	if me.MinOccurs, me.MaxOccurs = strconv.Itoa(min), strconv.Itoa(max); max < 0 {
+		me.MaxOccurs = "unbounded"
+	}
+	return me
+}
+
+//	Documents this element declaration with an xs:annotation holding doc.
+func (me *Element) Doc(doc string) *Element {
+	me.Annotation = newDocAnnotation(doc)
+	me.Annotation.initElement(me)
+	return me
+}
+
+//	Gives this element declaration an anonymous complex type (in place of its Type) and returns it.
+func (me *Element) NewComplexType() (ct *ComplexType) {
+	ct, me.Type, me.SimpleTypes = &ComplexType{}, "", nil
+	me.ComplexType = ct
+	ct.initElement(me)
+	return
+}
+
+//	Gives this element declaration an anonymous simple type (in place of its Type) restricting baseQName and returns it.
+func (me *Element) NewSimpleType(baseQName string) (st *SimpleType) {
+	st, me.Type, me.ComplexType = newSimpleType(baseQName), "", nil
+	me.SimpleTypes = []*SimpleType{st}
+	st.initElement(me)
+	return
+}
+
+//	Makes this (local) attribute declaration required.
+func (me *Attribute) Required() *Attribute {
+	me.Use = "required"
+	return me
+}
+
+//	Documents this attribute declaration with an xs:annotation holding doc.
+func (me *Attribute) Doc(doc string) *Attribute {
+	me.Annotation = newDocAnnotation(doc)
+	me.Annotation.initElement(me)
+	return me
+}
+
+//	Gives this attribute declaration an anonymous simple type (in place of its Type) restricting baseQName and returns it.
+func (me *Attribute) NewSimpleType(baseQName string) (st *SimpleType) {
+	st, me.Type = newSimpleType(baseQName), ""
+	me.SimpleTypes = []*SimpleType{st}
+	st.initElement(me)
+	return
+}
+
+//	Documents this complex type definition with an xs:annotation holding doc.
+func (me *ComplexType) Doc(doc string) *ComplexType {
+	me.Annotation = newDocAnnotation(doc)
+	me.Annotation.initElement(me)
+	return me
+}
+
+//	Derives this complex type by extension of the complex type baseQName: any elements and attributes added afterwards
+//	(see AddElement and AddAttribute) are added to the extension rather than to the complex type itself.
+func (me *ComplexType) Extend(baseQName string) *ComplexType {
+	me.ComplexContent = &ComplexContent{}
+	me.ComplexContent.ExtensionComplexContent = &ExtensionComplexContent{}
+	me.ComplexContent.ExtensionComplexContent.Base = xsdt.Qname(baseQName)
+	me.ComplexContent.initElement(me)
+	return me
+}
+
+//	Gives this complex type simple content by extension of the simple type baseQName (such as "xs:decimal"): any attributes
+//	added afterwards (see AddAttribute) are added to the extension. Elements cannot be added to a complex type with simple content.
+func (me *ComplexType) ExtendSimple(baseQName string) *ComplexType {
+	me.SimpleContent = &SimpleContent{}
+	me.SimpleContent.ExtensionSimpleContent = &ExtensionSimpleContent{}
+	me.SimpleContent.ExtensionSimpleContent.Base = xsdt.Qname(baseQName)
+	me.SimpleContent.initElement(me)
+	return me
+}
+
+//	Adds a local element declaration of the specified name and type (a QName, or empty for an anonymous type) to the xs:sequence
+//	of this complex type (or of its extension, see Extend), which is created if need be. Returns nil if this type has simple content.
+func (me *ComplexType) AddElement(name, typeQName string) (el *Element) {
+	var seq **Sequence
+	var parent element = me
+	if me.SimpleContent != nil {
+		return
+	}
+	if seq = &me.Sequence; (me.ComplexContent != nil) && (me.ComplexContent.ExtensionComplexContent != nil) {
+		ext := me.ComplexContent.ExtensionComplexContent
+		if parent = ext; len(ext.Sequences) == 0 {
+			ext.Sequences = []*Sequence{nil}
+		}
+		seq = &ext.Sequences[0]
+	}
+	if *seq == nil {
+		*seq = &Sequence{}
+		(*seq).initElement(parent)
+	}
+	return (*seq).AddElement(name, typeQName)
+}
+
+//	Adds a local attribute declaration of the specified name and type (a QName, or empty for an anonymous type) to this complex type
+//	(or to its extension, see Extend and ExtendSimple).
+func (me *ComplexType) AddAttribute(name, typeQName string) (att *Attribute) {
+	var parent element = me
+	var atts = &me.Attributes
+	att = newAttribute(name, typeQName)
+	if sc := me.SimpleContent; (sc != nil) && (sc.ExtensionSimpleContent != nil) {
+		parent, atts = sc.ExtensionSimpleContent, &sc.ExtensionSimpleContent.Attributes
+	} else if cc := me.ComplexContent; (cc != nil) && (cc.ExtensionComplexContent != nil) {
+		parent, atts = cc.ExtensionComplexContent, &cc.ExtensionComplexContent.Attributes
+	}
+	*atts = append(*atts, att)
+	att.initElement(parent)
+	return
+}
+
+//	Appends a local element declaration of the specified name and type (a QName, or empty for an anonymous type) to this sequence.
+func (me *Sequence) AddElement(name, typeQName string) (el *Element) {
+	el = newElement(name, typeQName)
+	me.Elements = append(me.Elements, el)
+	me.childOrder = append(me.childOrder, "element")
+	el.initElement(me)
+	return
+}
+
+//	Appends an xs:choice to this sequence, to be filled via Choice.AddElement.
+func (me *Sequence) AddChoice() (ch *Choice) {
+	ch = &Choice{}
+	me.Choices = append(me.Choices, ch)
+	me.childOrder = append(me.childOrder, "choice")
+	ch.initElement(me)
+	return
+}
+
+//	Adds a local element declaration of the specified name and type (a QName, or empty for an anonymous type) as an alternative of this choice.
+func (me *Choice) AddElement(name, typeQName string) (el *Element) {
+	el = newElement(name, typeQName)
+	me.Elements = append(me.Elements, el)
+	el.initElement(me)
+	return
+}
+
+//	Documents this simple type definition with an xs:annotation holding doc.
+func (me *SimpleType) Doc(doc string) *SimpleType {
+	me.Annotation = newDocAnnotation(doc)
+	me.Annotation.initElement(me)
+	return me
+}
+
+//	Returns the xs:restriction of this simple type, creating one (of "xs:anySimpleType") if it has none.
+func (me *SimpleType) restriction() (res *RestrictionSimpleType) {
+	if res = me.RestrictionSimpleType; res == nil {
+		var xsdPrefix string
+		for el := element(me); el != nil; el = el.Parent() {
+			if sd, ok := el.(*Schema); ok {
+				xsdPrefix = sd.XSDNamespacePrefix
+			}
+		}
+		res = newSimpleType(ustr.PrefixWithSep(xsdPrefix, ":", "anySimpleType")).RestrictionSimpleType
+		me.RestrictionSimpleType, me.List, me.Union = res, nil, nil
+		res.initElement(me)
+	}
+	return
+}
+
+//	Adds the specified xs:enumeration facets to this simple type.
+func (me *SimpleType) WithEnumeration(values ...string) *SimpleType {
+	res := me.restriction()
+	for _, v := range values {
+		enum := &RestrictionSimpleEnumeration{}
+		enum.Value = v
+		res.Enumerations = append(res.Enumerations, enum)
+		enum.initElement(res)
+	}
+	return me
+}
+
+//	Sets the xs:pattern facet of this simple type.
+func (me *SimpleType) WithPattern(pattern string) *SimpleType {
+	res := me.restriction()
+	res.Pattern = &RestrictionSimplePattern{}
+	res.Pattern.Value = pattern
+	res.Pattern.initElement(res)
+	return me
+}
+
+//	Sets the xs:length facet of this simple type.
+func (me *SimpleType) WithLength(length int) *SimpleType {
+	res := me.restriction()
+	res.Length = &RestrictionSimpleLength{}
+	res.Length.Value = strconv.Itoa(length)
+	res.Length.initElement(res)
+	return me
+}
+
+//	Sets the xs:minLength facet of this simple type.
+func (me *SimpleType) WithMinLength(length int) *SimpleType {
+	res := me.restriction()
+	res.MinLength = &RestrictionSimpleMinLength{}
+	res.MinLength.Value = strconv.Itoa(length)
+	res.MinLength.initElement(res)
+	return me
+}
+
+//	Sets the xs:maxLength facet of this simple type.
+func (me *SimpleType) WithMaxLength(length int) *SimpleType {
+	res := me.restriction()
+	res.MaxLength = &RestrictionSimpleMaxLength{}
+	res.MaxLength.Value = strconv.Itoa(length)
+	res.MaxLength.initElement(res)
+	return me
+}
+
+//	Sets the xs:minInclusive facet of this simple type to the specified literal (such as "0" or "2000-01-01").
+func (me *SimpleType) WithMinInclusive(value string) *SimpleType {
+	res := me.restriction()
+	res.MinInclusive = &RestrictionSimpleMinInclusive{}
+	res.MinInclusive.Value = value
+	res.MinInclusive.initElement(res)
+	return me
+}
+
+//	Sets the xs:maxInclusive facet of this simple type to the specified literal.
+func (me *SimpleType) WithMaxInclusive(value string) *SimpleType {
+	res := me.restriction()
+	res.MaxInclusive = &RestrictionSimpleMaxInclusive{}
+	res.MaxInclusive.Value = value
+	res.MaxInclusive.initElement(res)
+	return me
+}
+
+//	Sets the xs:minExclusive facet of this simple type to the specified literal.
+func (me *SimpleType) WithMinExclusive(value string) *SimpleType {
+	res := me.restriction()
+	res.MinExclusive = &RestrictionSimpleMinExclusive{}
+	res.MinExclusive.Value = value
+	res.MinExclusive.initElement(res)
+	return me
+}
+
+//	Sets the xs:maxExclusive facet of this simple type to the specified literal.
+func (me *SimpleType) WithMaxExclusive(value string) *SimpleType {
+	res := me.restriction()
+	res.MaxExclusive = &RestrictionSimpleMaxExclusive{}
+	res.MaxExclusive.Value = value
+	res.MaxExclusive.initElement(res)
+	return me
+}
+
+//	Sets the xs:totalDigits facet of this simple type.
+func (me *SimpleType) WithTotalDigits(digits int) *SimpleType {
+	res := me.restriction()
+	res.TotalDigits = &RestrictionSimpleTotalDigits{}
+	res.TotalDigits.Value = strconv.Itoa(digits)
+	res.TotalDigits.initElement(res)
+	return me
+}
+
+//	Sets the xs:fractionDigits facet of this simple type.
+func (me *SimpleType) WithFractionDigits(digits int) *SimpleType {
+	res := me.restriction()
+	res.FractionDigits = &RestrictionSimpleFractionDigits{}
+	res.FractionDigits.Value = strconv.Itoa(digits)
+	res.FractionDigits.initElement(res)
+	return me
+}
+
+//	Sets the xs:whiteSpace facet of this simple type to "preserve", "replace" or "collapse".
+func (me *SimpleType) WithWhiteSpace(whiteSpace string) *SimpleType {
+	res := me.restriction()
+	res.WhiteSpace = &RestrictionSimpleWhiteSpace{}
+	res.WhiteSpace.Value = whiteSpace
+	res.WhiteSpace.initElement(res)
+	return me
+}
@@ -0,0 +1,55 @@
+package xsd
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+//	Tests that a schema built in code via NewSchema and the builder methods is written as the expected XSD,
+//	validates documents and generates Go code just like a loaded one.
+func TestBuildSchema(t *testing.T) {
+	var buf bytes.Buffer
+	sd := NewSchema("example.com/built.xsd", "urn:example:built")
+	sd.AddSimpleType("Sku", "xs:string").WithPattern("[A-Z]{3}")
+	item := sd.AddComplexType("Item").Doc("An ordered item.")
+	item.AddElement("sku", "Sku")
+	item.AddElement("qty", "xs:int").Occurs(0, 1)
+	item.AddAttribute("id", "xs:string").Required()
+	sd.AddElement("item", "Item")
+	if err := sd.WriteXSD(&buf); err != nil {
+		t.Fatal(err)
+	}
+	expected := `<?xml version="1.0" encoding="UTF-8"?>
+<xs:schema xmlns="urn:example:built" xmlns:tns="urn:example:built" xmlns:xs="http://www.w3.org/2001/XMLSchema" elementFormDefault="qualified" targetNamespace="urn:example:built">
+	<xs:simpleType name="Sku">
+		<xs:restriction base="xs:string">
+			<xs:pattern value="[A-Z]{3}"/>
+		</xs:restriction>
+	</xs:simpleType>
+	<xs:complexType name="Item">
+		<xs:annotation>
+			<xs:documentation>An ordered item.</xs:documentation>
+		</xs:annotation>
+		<xs:sequence>
+			<xs:element name="sku" type="Sku"/>
+			<xs:element name="qty" maxOccurs="1" minOccurs="0" type="xs:int"/>
+		</xs:sequence>
+		<xs:attribute name="id" type="xs:string" use="required"/>
+	</xs:complexType>
+	<xs:element name="item" type="Item"/>
+</xs:schema>`
+	if strings.TrimSpace(buf.String()) != expected {
+		t.Errorf("expected\n%s\ngot\n%s", expected, buf.String())
+	}
+	if errs, err := sd.Validate(strings.NewReader(`<item xmlns="urn:example:built" id="1"><sku>abc</sku></item>`)); (err != nil) || (len(errs) != 1) || (errs[0].Path != "/item/sku") {
+		t.Errorf("expected an error at /item/sku, got %v %v", errs, err)
+	}
+	srcs, _, err := NewGenerator(DefaultGenOptions()).GenerateGoSourceAs(sd, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if src := string(srcs["built.xsd.go"]); !strings.Contains(src, "package go_Built\n") || !strings.Contains(src, "type TItem struct {") {
+		t.Errorf("expected package go_Built declaring TItem, got\n%s", src)
+	}
+}
@@ -0,0 +1,41 @@
+package xsd
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/metaleap/go-xsd/c14n"
+)
+
+//	Tests that canonicalizing with a schema adds the default and fixed attributes it declares (prefixing qualified ones), so that documents
+//	canonicalize alike whether or not they spell these out, and that exclusive canonicalization drops namespace declarations not visibly utilized.
+func TestCanonicalizeWithSchema(t *testing.T) {
+	var buf bytes.Buffer
+	sd := loadTestSchema(t, "c14n", "doc.xsd")
+	doc := "<?xml version=\"1.0\"?>\n<doc xmlns=\"urn:example:c14n\" xmlns:unused=\"urn:unused\"   id='x'>\n<item unit=\"kg\"/><item></item></doc>"
+	excExpected := `<doc xmlns="urn:example:c14n" id="x" version="1.0">` + "\n" + `<item xmlns:ns1="urn:example:c14n" unit="kg" ns1:status="new"></item><item xmlns:ns1="urn:example:c14n" unit="pcs" ns1:status="new"></item></doc>`
+	for _, c := range []struct {
+		algorithm, expected string
+	}{
+		{c14n.ExcC14N10, excExpected},
+		{c14n.C14N10, `<doc xmlns="urn:example:c14n" xmlns:unused="urn:unused" id="x" version="1.0">` + "\n" + `<item xmlns:ns1="urn:example:c14n" unit="kg" ns1:status="new"></item><item xmlns:ns1="urn:example:c14n" unit="pcs" ns1:status="new"></item></doc>`},
+	} {
+		buf.Reset()
+		if err := c14n.CanonicalizeWithSchema(&buf, strings.NewReader(doc), sd, c14n.Options{Algorithm: c.algorithm}); err != nil {
+			t.Fatal(err)
+		} else if buf.String() != c.expected {
+			t.Errorf("%s: expected\n%s\ngot\n%s", c.algorithm, c.expected, buf.String())
+		}
+	}
+	buf.Reset()
+	if err := c14n.Canonicalize(&buf, strings.NewReader(doc), c14n.Options{}); (err != nil) || strings.Contains(buf.String(), "status") || strings.Contains(buf.String(), "version") {
+		t.Errorf("expected no default attributes without a schema, got %s %v", buf.String(), err)
+	}
+	spelledOut := strings.NewReplacer("id='x'", `version="1.0" id="x"`, `<item unit="kg"/>`, `<item xmlns:ns1="urn:example:c14n" ns1:status="new" unit="kg"/>`,
+		"<item></item>", `<item unit="pcs" xmlns:ns1="urn:example:c14n" ns1:status="new"/>`).Replace(doc)
+	buf.Reset()
+	if err := c14n.CanonicalizeWithSchema(&buf, strings.NewReader(spelledOut), sd, c14n.Options{}); (err != nil) || (buf.String() != excExpected) {
+		t.Errorf("expected the document spelling out its defaults to canonicalize alike, got\n%s %v", buf.String(), err)
+	}
+}
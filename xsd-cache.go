@@ -0,0 +1,262 @@
+package xsd
+
+import (
+	"container/list"
+	"context"
+	"encoding/xml"
+	"io"
+	"io/fs"
+	"sync"
+)
+
+var (
+	//	The SchemaCache used by LoadSchema and LoadSchemaContext.
+	DefaultSchemaCache = NewSchemaCache(0)
+)
+
+//	A cache of loaded schema documents keyed by their (protocol-less) URIs, consulted when resolving xs:include and xs:import schemaLocations.
+//	All methods of a SchemaCache are safe for concurrent use, so a single one can be shared by concurrent loads, or separate ones can be used to keep independent loads apart.
+type SchemaCache struct {
+	maxSize int
+	mutex   sync.Mutex
+	entries map[string]*list.Element
+	lru     *list.List
+}
+
+type schemaCacheEntry struct {
+	uri    string
+	schema *Schema
+}
+
+//	Returns a new, empty SchemaCache holding at most maxSize schemas (or any number of schemas, if maxSize is 0 or less).
+//	If full, the least-recently used schema is evicted.
+func NewSchemaCache(maxSize int) *SchemaCache {
+	return &SchemaCache{maxSize: maxSize, entries: map[string]*list.Element{}, lru: list.New()}
+}
+
+//	Removes all schemas from this cache.
+func (me *SchemaCache) Clear() {
+	me.mutex.Lock()
+	defer me.mutex.Unlock()
+	me.entries, me.lru = map[string]*list.Element{}, list.New()
+}
+
+//	Returns the cached schema loaded from the specified protocol-less uri, if any.
+func (me *SchemaCache) Get(uri string) (sd *Schema, ok bool) {
+	var el *list.Element
+	me.mutex.Lock()
+	defer me.mutex.Unlock()
+	if el, ok = me.entries[uri]; ok {
+		me.lru.MoveToFront(el)
+		sd = el.Value.(*schemaCacheEntry).schema
+	}
+	return
+}
+
+//	Returns the number of schemas currently in this cache.
+func (me *SchemaCache) Len() int {
+	me.mutex.Lock()
+	defer me.mutex.Unlock()
+	return me.lru.Len()
+}
+
+//	Like LoadSchemaContext, but uses (and populates) this cache instead of DefaultSchemaCache.
+func (me *SchemaCache) LoadSchema(ctx context.Context, uri string, localCopy bool) (sd *Schema, err error) {
+	return me.LoadSchemaWithOptions(ctx, uri, localCopy, LoadOptions{})
+}
+
+//	Like LoadSchemaWithOptions, but uses (and populates) this cache instead of DefaultSchemaCache.
+func (me *SchemaCache) LoadSchemaWithOptions(ctx context.Context, uri string, localCopy bool, opts LoadOptions) (sd *Schema, err error) {
+	var cancel context.CancelFunc
+	ctx, cancel = context.WithCancel(ctx)
+	defer cancel()
+	loader := newSchemaLoader(ctx, me, opts)
+	if sd, err = loader.loadUri(uri, "", localCopy); err == nil {
+		if err = loader.strictError([]*Schema{sd}); err != nil {
+			return nil, err
+		}
+		for pendingUri, pendingSchema := range loader.pending {
+			me.Put(pendingUri, pendingSchema)
+		}
+	}
+	return
+}
+
+//	Like LoadSchemaReader, but uses (and populates) this cache instead of DefaultSchemaCache, aborts as soon as ctx is done and loads according to opts.
+//	The schema read from r replaces any schema cached for baseURI.
+func (me *SchemaCache) LoadSchemaReader(ctx context.Context, r io.Reader, baseURI string, opts LoadOptions) (sd *Schema, err error) {
+	var cancel context.CancelFunc
+	ctx, cancel = context.WithCancel(ctx)
+	defer cancel()
+	loader := newSchemaLoader(ctx, me, opts)
+	_, uri := splitUri(baseURI, "")
+	loader.fetched[uri] = true
+	if sd, err = loader.loadSchemaFrom(r, uri, ""); err == nil {
+		if err = loader.strictError([]*Schema{sd}); err != nil {
+			return nil, err
+		}
+		for pendingUri, pendingSchema := range loader.pending {
+			me.Put(pendingUri, pendingSchema)
+		}
+	}
+	return
+}
+
+//	Adds the specified schema to this cache (or replaces the one cached for uri), evicting the least-recently used schema if the cache is full.
+func (me *SchemaCache) Put(uri string, sd *Schema) {
+	me.mutex.Lock()
+	defer me.mutex.Unlock()
+	if el, ok := me.entries[uri]; ok {
+		el.Value.(*schemaCacheEntry).schema = sd
+		me.lru.MoveToFront(el)
+	} else {
+		me.entries[uri] = me.lru.PushFront(&schemaCacheEntry{uri: uri, schema: sd})
+	}
+	for (me.maxSize > 0) && (me.lru.Len() > me.maxSize) {
+		delete(me.entries, me.lru.Remove(me.lru.Back()).(*schemaCacheEntry).uri)
+	}
+}
+
+//	Removes the schema loaded from the specified protocol-less uri from this cache, if present.
+func (me *SchemaCache) Remove(uri string) {
+	me.mutex.Lock()
+	defer me.mutex.Unlock()
+	if el, ok := me.entries[uri]; ok {
+		me.lru.Remove(el)
+		delete(me.entries, uri)
+	}
+}
+
+//	The state of a single LoadSchema call: schemas loaded by it are only added to the cache once the whole load succeeded.
+//	The schema documents referenced by xs:includes and xs:imports are fetched and decoded ahead of time by concurrent goroutines (no more than the MaxConcurrentLoads of its Generator, see LoadOptions.Generator),
+//	whereas processing them (see Schema.onLoad) happens strictly sequentially in the order they are referenced.
+type schemaLoader struct {
+	ctx     context.Context
+	cache   *SchemaCache
+	opts    LoadOptions
+	gen     *Generator // whose settings govern loading, see LoadOptions.Generator
+	pending map[string]*Schema
+
+	//	The schema documents being processed by Schema.onLoad, each referencing the next one (see schemaLoader.follow).
+	chain []*schemaLink
+
+	//	If not empty, the target namespace of the including schema document, for Schema.onLoad to adopt into the chameleon include it is about to process.
+	chameleonNs string
+
+	//	In strict mode, the unknown elements and attributes in the schema documents processed so far (see positionRecorder).
+	unknowns Diagnostics
+
+	//	For LoadWSDL, the service descriptions of the WSDL documents loaded so far (see SoapOperation).
+	wsdlDefs []*wsdlDefinitions
+
+	//	For LoadSchemaDir, the directory tree, the protocol-less uri corresponding to it, and the target namespaces of the schema documents in it.
+	dirPath, dirUri string
+	dirNamespaces   map[string]bool
+
+	//	For LoadSchemaFS, the file system and the protocol-less uris of the schema documents loaded from it (guarded by mutex).
+	fsys   fs.FS
+	fsUris map[string]bool
+
+	//	For VendorSchema, the schema documents fetched so far, keyed by their protocol-less uris (guarded by mutex).
+	vendored map[string]*vendoredDoc
+
+	//	Guards fetches and fetched, which (unlike pending) are also accessed by the prefetching goroutines.
+	mutex   sync.Mutex
+	fetches map[string]*schemaFetch
+	fetched map[string]bool
+	slots   chan bool
+}
+
+//	A fetched and decoded, but not yet processed, schema document.
+type schemaDoc struct {
+	sd             *Schema
+	rootAtts       []xml.Attr
+	uri, localPath string
+	unknowns       Diagnostics
+}
+
+//	A schema document being prefetched: done is closed once doc or err is set.
+type schemaFetch struct {
+	done chan bool
+	doc  *schemaDoc
+	err  error
+}
+
+func newSchemaLoader(ctx context.Context, cache *SchemaCache, opts LoadOptions) (me *schemaLoader) {
+	me = &schemaLoader{ctx: ctx, cache: cache, opts: opts, gen: opts.Generator, pending: map[string]*Schema{}, fetches: map[string]*schemaFetch{}, fetched: map[string]bool{}}
+	if me.gen == nil {
+		me.gen = PkgGen
+	}
+	if me.gen.MaxConcurrentLoads > 1 {
+		me.slots = make(chan bool, me.gen.MaxConcurrentLoads)
+	}
+	return
+}
+
+func (me *schemaLoader) cached(uri string) (sd *Schema, ok bool) {
+	if sd, ok = me.pending[uri]; !ok {
+		sd, ok = me.cache.Get(uri)
+	}
+	return
+}
+
+//	Returns the fetched and decoded schema document at location: if it is being prefetched, waits for that and takes it over, otherwise fetches it right away.
+//	Either way, it is never prefetched again, so that each prefetched schema document is processed at most once.
+func (me *schemaLoader) fetch(location, baseUri string, localCopy bool) (doc *schemaDoc, err error) {
+	_, uri := splitUri(location, baseUri)
+	me.mutex.Lock()
+	f := me.fetches[uri]
+	delete(me.fetches, uri)
+	me.fetched[uri] = true
+	me.mutex.Unlock()
+	if f == nil {
+		doc, err = me.fetchUri(location, baseUri, localCopy)
+	} else {
+		select {
+		case <-f.done:
+			doc, err = f.doc, f.err
+		case <-me.ctx.Done():
+			err = me.ctx.Err()
+		}
+	}
+	return
+}
+
+//	Starts prefetching the schema document at location in a new goroutine, unless it is cached or has been (or is being) fetched already.
+//	No more than gen.MaxConcurrentLoads such goroutines fetch and decode at the same time.
+func (me *schemaLoader) prefetch(location, baseUri string, localCopy bool) {
+	_, uri := splitUri(location, baseUri)
+	if _, ok := me.cache.Get(uri); !ok {
+		me.mutex.Lock()
+		defer me.mutex.Unlock()
+		if !me.fetched[uri] {
+			f := &schemaFetch{done: make(chan bool)}
+			me.fetched[uri], me.fetches[uri] = true, f
+			go func() {
+				defer close(f.done)
+				select {
+				case me.slots <- true:
+					f.doc, f.err = me.fetchUri(location, baseUri, localCopy)
+					<-me.slots
+				case <-me.ctx.Done():
+					f.err = me.ctx.Err()
+				}
+			}()
+		}
+	}
+}
+
+//	Prefetches the schema documents referenced by the xs:includes and xs:imports of doc (except imports resolved by namespace, see importsFromDir), if gen.MaxConcurrentLoads allows for concurrent loading.
+func (me *schemaLoader) prefetchRefs(doc *schemaDoc) {
+	if me.slots != nil {
+		localCopy := len(doc.localPath) > 0
+		for _, inc := range doc.sd.Includes {
+			me.prefetch(inc.SchemaLocation.String(), doc.uri, localCopy)
+		}
+		for _, imp := range doc.sd.Imports {
+			if (len(imp.SchemaLocation) > 0) && !me.importsFromDir(doc.uri, imp) {
+				me.prefetch(imp.SchemaLocation.String(), doc.uri, localCopy)
+			}
+		}
+	}
+}
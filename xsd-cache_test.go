@@ -0,0 +1,119 @@
+package xsd
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestLoadSchemaAbortsWhenContextDone(t *testing.T) {
+	stall := make(chan struct{})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-stall
+	}))
+	defer srv.Close()
+	defer close(stall)
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	if _, err := NewSchemaCache(0).LoadSchema(ctx, srv.URL+"/stalled.xsd", false); err == nil {
+		t.Fatal("expected an error loading from a stalled server")
+	} else if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Fatalf("the load took %v rather than aborting when its context was done: %v", elapsed, err)
+	}
+	if _, err := NewSchemaCache(0).LoadSchema(ctx, srv.URL+"/later.xsd", false); err == nil {
+		t.Fatal("expected an error loading with a done context")
+	}
+}
+
+func TestSchemaCacheEvictsLeastRecentlyUsed(t *testing.T) {
+	cache := NewSchemaCache(2)
+	a, b, c := new(Schema), new(Schema), new(Schema)
+	cache.Put("a.xsd", a)
+	cache.Put("b.xsd", b)
+	if sd, ok := cache.Get("a.xsd"); !ok || (sd != a) {
+		t.Fatal("a.xsd not cached")
+	}
+	cache.Put("c.xsd", c)
+	if _, ok := cache.Get("b.xsd"); ok || (cache.Len() != 2) {
+		t.Fatalf("expected b.xsd (least recently used) to be evicted, %d schemas cached", cache.Len())
+	}
+	if cache.Remove("a.xsd"); cache.Len() != 1 {
+		t.Fatalf("expected 1 schema cached after Remove, got %d", cache.Len())
+	}
+}
+
+func TestSchemaCacheSharedByConcurrentLoads(t *testing.T) {
+	var resolves int32
+	opts := DefaultGenOptions()
+	opts.Offline = true
+	opts.Resolver = SchemaResolverFunc(func(location, baseUri string) (io.ReadCloser, error) {
+		atomic.AddInt32(&resolves, 1)
+		return os.Open(filepath.Join("testdata", "resolver", path.Base(location)))
+	})
+	load := LoadOptions{Generator: NewGenerator(opts)}
+	cache := NewSchemaCache(0)
+	if _, err := cache.LoadSchemaWithOptions(context.Background(), "cache.example.com/root.xsd", false, load); err != nil {
+		t.Fatal(err)
+	}
+	if (resolves != 2) || (cache.Len() != 2) {
+		t.Fatalf("expected the schema and its include to be resolved and cached, got %d resolves and %d cached", resolves, cache.Len())
+	}
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if sd, err := cache.LoadSchemaWithOptions(context.Background(), "cache.example.com/root.xsd", false, load); (err != nil) || (sd == nil) {
+				t.Error(err)
+			}
+		}()
+	}
+	if wg.Wait(); resolves != 2+8 {
+		t.Errorf("expected only the root schema to be resolved anew by each load, and its cached include to be reused, got %d resolves", resolves)
+	}
+}
+
+func TestConcurrentLoadsAreBoundedAndDeduplicated(t *testing.T) {
+	for _, maxLoads := range []int32{1, 3} {
+		var mutex sync.Mutex
+		var inFlight, maxInFlight int32
+		var resolved = map[string]int{}
+		opts := DefaultGenOptions()
+		opts.Offline, opts.MaxConcurrentLoads = true, int(maxLoads)
+		opts.Resolver = SchemaResolverFunc(func(location, baseUri string) (io.ReadCloser, error) {
+			mutex.Lock()
+			resolved[path.Base(location)]++
+			if inFlight++; inFlight > maxInFlight {
+				maxInFlight = inFlight
+			}
+			mutex.Unlock()
+			time.Sleep(50 * time.Millisecond)
+			mutex.Lock()
+			inFlight--
+			mutex.Unlock()
+			return os.Open(filepath.Join("testdata", "parallel", path.Base(location)))
+		})
+		sd, err := NewSchemaCache(0).LoadSchemaWithOptions(context.Background(), "parallel.example.com/root.xsd", false, LoadOptions{Generator: NewGenerator(opts)})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(sd.XMLIncludedSchemas) != 4 {
+			t.Errorf("expected 4 includes, got %d", len(sd.XMLIncludedSchemas))
+		}
+		if (len(resolved) != 6) || (resolved["common.xsd"] != 1) {
+			t.Errorf("expected each of the 6 schema documents to be resolved once, got %v", resolved)
+		}
+		if maxInFlight != maxLoads {
+			t.Errorf("MaxConcurrentLoads %d: expected as many concurrent resolves, got %d", maxLoads, maxInFlight)
+		}
+	}
+}
@@ -0,0 +1,188 @@
+package xsd
+
+import (
+	"encoding/xml"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+const (
+	catalogNamespaceUri = "urn:oasis:names:tc:entity:xmlns:xml:catalog"
+)
+
+const (
+	catalogEntryExact = iota
+	catalogEntryRewrite
+	catalogEntrySuffix
+)
+
+//	An OASIS XML Catalog (see https://www.oasis-open.org/committees/entity/spec.html) remapping schema URIs to other, typically local, locations.
+//	Supported entries are uri, system, rewriteURI, rewriteSystem, uriSuffix, systemSuffix, group and nextCatalog.
+//	Set PkgGen.Catalog to have LoadSchema consult a Catalog for every schema document (including xs:includes and xs:imports) before attempting any download.
+type Catalog struct {
+	entries []*catalogEntry
+	next    []*Catalog
+}
+
+type catalogEntry struct {
+	kind          int
+	match, target string
+}
+
+//	Loads the OASIS XML Catalog files at the specified local file paths, in order, into a single Catalog.
+//	Catalogs referenced via nextCatalog entries are loaded, too.
+func LoadCatalog(filePaths ...string) (cat *Catalog, err error) {
+	var done = map[string]bool{}
+	cat = &Catalog{}
+	for _, filePath := range filePaths {
+		var next *Catalog
+		if next, err = loadCatalogFile(filePath, done); err != nil {
+			cat = nil
+			break
+		}
+		cat.next = append(cat.next, next)
+	}
+	return
+}
+
+func loadCatalogFile(filePath string, done map[string]bool) (cat *Catalog, err error) {
+	var (
+		file  *os.File
+		tok   xml.Token
+		bases []string
+		nexts []string
+	)
+	if filePath, err = filepath.Abs(filePath); err != nil {
+		return
+	}
+	cat = &Catalog{}
+	if done[filePath] {
+		return
+	}
+	done[filePath] = true
+	if file, err = os.Open(filePath); err != nil {
+		return
+	}
+	defer file.Close()
+	bases = []string{filepath.Dir(filePath)}
+	for xd := xml.NewDecoder(file); err == nil; {
+		if tok, err = xd.Token(); err == nil {
+			switch t := tok.(type) {
+			case xml.StartElement:
+				var atts = map[string]string{}
+				var base = bases[len(bases)-1]
+				for _, att := range t.Attr {
+					if (att.Name.Space == xmlNamespaceUri) && (att.Name.Local == "base") {
+						base = catalogTarget(base, att.Value)
+					} else if len(att.Name.Space) == 0 {
+						atts[att.Name.Local] = att.Value
+					}
+				}
+				bases = append(bases, base)
+				if t.Name.Space == catalogNamespaceUri {
+					switch t.Name.Local {
+					case "uri":
+						cat.add(catalogEntryExact, atts["name"], base, atts["uri"])
+					case "system":
+						cat.add(catalogEntryExact, atts["systemId"], base, atts["uri"])
+					case "rewriteURI":
+						cat.add(catalogEntryRewrite, atts["uriStartString"], base, atts["rewritePrefix"])
+					case "rewriteSystem":
+						cat.add(catalogEntryRewrite, atts["systemIdStartString"], base, atts["rewritePrefix"])
+					case "uriSuffix":
+						cat.add(catalogEntrySuffix, atts["uriSuffix"], base, atts["uri"])
+					case "systemSuffix":
+						cat.add(catalogEntrySuffix, atts["systemSuffix"], base, atts["uri"])
+					case "nextCatalog":
+						if len(atts["catalog"]) > 0 {
+							nexts = append(nexts, catalogTarget(base, atts["catalog"]))
+						}
+					}
+				}
+			case xml.EndElement:
+				bases = bases[:len(bases)-1]
+			}
+		}
+	}
+	if err == io.EOF {
+		err = nil
+	}
+	for _, nextPath := range nexts {
+		var next *Catalog
+		if strings.HasPrefix(nextPath, "file"+protSep) {
+			nextPath = nextPath[len("file"+protSep):]
+		}
+		if next, err = loadCatalogFile(nextPath, done); err != nil {
+			break
+		}
+		cat.next = append(cat.next, next)
+	}
+	return
+}
+
+func (me *Catalog) add(kind int, match, base, target string) {
+	if (len(match) > 0) && (len(target) > 0) {
+		entry := &catalogEntry{kind: kind, match: catalogNormalize(match), target: catalogTarget(base, target)}
+		if strings.HasSuffix(target, "/") && !strings.HasSuffix(entry.target, "/") {
+			entry.target += "/"
+		}
+		me.entries = append(me.entries, entry)
+	}
+}
+
+//	Returns the location that the specified schema URI is mapped to by this Catalog: either a local file path, a file:// URI, or another remote URI.
+//	For matching, any http:// or https:// prefixes are disregarded, both in uri and in the catalog entries.
+func (me *Catalog) Lookup(uri string) (mapped string, ok bool) {
+	var best *catalogEntry
+	uri = catalogNormalize(uri)
+	for _, kind := range []int{catalogEntryExact, catalogEntryRewrite, catalogEntrySuffix} {
+		for _, entry := range me.entries {
+			if (entry.kind == kind) && ((best == nil) || (len(entry.match) > len(best.match))) {
+				if ((kind == catalogEntryExact) && (uri == entry.match)) || ((kind == catalogEntryRewrite) && strings.HasPrefix(uri, entry.match)) || ((kind == catalogEntrySuffix) && strings.HasSuffix(uri, entry.match)) {
+					best = entry
+				}
+			}
+		}
+		if best != nil {
+			if mapped, ok = best.target, true; kind == catalogEntryRewrite {
+				mapped += uri[len(best.match):]
+			}
+			return
+		}
+	}
+	for _, next := range me.next {
+		if mapped, ok = next.Lookup(uri); ok {
+			return
+		}
+	}
+	return
+}
+
+func catalogNormalize(uri string) string {
+	for _, prot := range []string{"http" + protSep, "https" + protSep} {
+		if strings.HasPrefix(uri, prot) {
+			return uri[len(prot):]
+		}
+	}
+	return uri
+}
+
+//	Resolves target relative to base, which is either a local directory path or a URI.
+func catalogTarget(base, target string) string {
+	if strings.Contains(target, protSep) || path.IsAbs(target) || filepath.IsAbs(target) {
+		return target
+	} else if strings.Contains(base, protSep) {
+		if strings.HasSuffix(base, "/") {
+			return base + target
+		}
+		return path.Join(path.Dir(base), target)
+	}
+	return filepath.Join(base, target)
+}
+
+func openCatalogTarget(mapped string) (*os.File, error) {
+	return os.Open(strings.TrimPrefix(mapped, "file"+protSep))
+}
@@ -0,0 +1,43 @@
+package xsd
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+)
+
+func TestCatalogLookup(t *testing.T) {
+	cat, err := LoadCatalog(filepath.Join("testdata", "catalog", "catalog.xml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	dir, _ := filepath.Abs(filepath.Join("testdata", "catalog"))
+	for uri, expected := range map[string]string{
+		"http://catalog.example.com/exact.xsd":      filepath.Join(dir, "schemas", "note.xsd"),
+		"https://catalog.example.com/v1/note.xsd":   filepath.Join(dir, "schemas", "note.xsd"),
+		"catalog.example.com/v1/deep/other.xsd":     "http://mirror.example.com/deep/other.xsd",
+		"http://elsewhere.example.com/a/suffix.xsd": filepath.Join(dir, "schemas", "note.xsd"),
+		"http://catalog.example.com/next.xsd":       filepath.Join(dir, "schemas", "note.xsd"),
+		"http://catalog.example.com/unmapped.xsd":   "",
+	} {
+		if mapped, ok := cat.Lookup(uri); (mapped != expected) || (ok != (len(expected) > 0)) {
+			t.Errorf("%s: expected %q, got %q", uri, expected, mapped)
+		}
+	}
+}
+
+func TestCatalogLoadsOffline(t *testing.T) {
+	opts := DefaultGenOptions()
+	opts.Offline = true
+	var err error
+	if opts.Catalog, err = LoadCatalog(filepath.Join("testdata", "catalog", "catalog.xml")); err != nil {
+		t.Fatal(err)
+	}
+	sd, err := NewSchemaCache(0).LoadSchemaWithOptions(context.Background(), "http://catalog.example.com/v1/note.xsd", false, LoadOptions{Generator: NewGenerator(opts)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sd.TargetNamespace != "urn:example:note" {
+		t.Errorf("unexpected target namespace %q", sd.TargetNamespace)
+	}
+}
@@ -0,0 +1,35 @@
+package xsd
+
+import (
+	"strings"
+	"testing"
+)
+
+//	Tests that a schema without target namespace included by schemas of two different namespaces takes on the namespace of each includer,
+//	including its unprefixed references to its own components, so that documents of either namespace validate against it.
+func TestChameleonIncludes(t *testing.T) {
+	for _, c := range []struct {
+		fileName, ns, doc string
+	}{
+		{"home.xsd", "urn:example:home", `<h:home xmlns:h="urn:example:home"><street>Main St</street></h:home>`},
+		{"office.xsd", "urn:example:office", `<o:office xmlns:o="urn:example:office"><street>Main St</street></o:office>`},
+	} {
+		sd := loadTestSchema(t, "chameleon", c.fileName)
+		if incs := sd.XMLIncludedSchemas; (len(incs) != 1) || (incs[0].TargetNamespace.String() != c.ns) || (incs[0].XMLNamespaces[""] != c.ns) {
+			t.Fatalf("%s: expected lib.xsd to be included in the namespace %s, got %v", c.fileName, c.ns, incs)
+		}
+		if errs, err := sd.Validate(strings.NewReader(c.doc)); (err != nil) || (len(errs) > 0) {
+			t.Errorf("%s: expected %s to be valid, got %v %v", c.fileName, c.doc, errs, err)
+		}
+		if errs, err := sd.Validate(strings.NewReader(strings.Replace(c.doc, "Main St", "Main Street 1", 1))); (err != nil) || (len(errs) == 0) || !strings.HasSuffix(errs[0].Path, "/street") {
+			t.Errorf("%s: expected the maxLength of Street to be violated, got %v %v", c.fileName, errs, err)
+		}
+	}
+	if sd := loadTestSchema(t, "chameleon", "lib.xsd"); len(sd.TargetNamespace) > 0 {
+		t.Errorf("expected lib.xsd itself to keep having no target namespace, got %s", sd.TargetNamespace)
+	}
+	src, _ := genTestSrc(t, "chameleon", "office.xsd", nil)
+	if decl := goTypeDecl(t, src, "XsdGoPkgHasElem_Office"); !strings.Contains(decl, "Office *TAddress `xml:\"urn:example:office office\"`") {
+		t.Errorf("expected the office element of the included TAddress, got\n%s", decl)
+	}
+}
@@ -0,0 +1,154 @@
+package xsd
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/metaleap/go-util-fs"
+	"github.com/metaleap/go-util-str"
+)
+
+//	A lock file of the expected SHA-256 digests of downloaded schema documents, by their URIs (see PkgGen.Checksums).
+//	All methods of a Checksums are safe for concurrent use.
+type Checksums struct {
+	//	The file that Save writes these checksums to, as JSON.
+	FilePath string
+
+	//	If true, the digests of downloaded schema documents whose URIs are not pinned yet are recorded (for Save to write them to FilePath)
+	//	rather than left unverified. Documents whose URIs are pinned are verified either way.
+	Record bool
+
+	mutex   sync.Mutex
+	digests map[string]string
+	changed bool
+}
+
+//	Returned by LoadSchema (and all other functions loading schemas) if a downloaded schema document, or the local copy of one,
+//	does not have the SHA-256 digest pinned for its URI in PkgGen.Checksums.
+type ChecksumError struct {
+	//	The URI of the schema document.
+	Uri string
+
+	//	The local copy of the schema document, or empty if it was just downloaded (and was not written to it).
+	LocalPath string
+
+	//	The pinned and the actual SHA-256 digests, in lower-case hex.
+	Expected, Actual string
+}
+
+func (me *ChecksumError) Error() string {
+	return sfmt("checksum mismatch for %s%s: expected sha256 %s, got %s", me.Uri, ustr.Ifs(len(me.LocalPath) > 0, " (local copy "+me.LocalPath+")", ""), me.Expected, me.Actual)
+}
+
+//	Returns new, empty Checksums to be saved to filePath.
+func NewChecksums(filePath string) *Checksums {
+	return &Checksums{FilePath: filePath, digests: map[string]string{}}
+}
+
+//	Returns the Checksums previously saved to filePath, or new, empty ones if that file does not exist.
+func LoadChecksums(filePath string) (me *Checksums, err error) {
+	var raw []byte
+	me = NewChecksums(filePath)
+	if raw, err = ioutil.ReadFile(filePath); os.IsNotExist(err) {
+		err = nil
+	} else if err == nil {
+		if err = json.Unmarshal(raw, &me.digests); err == nil {
+			for uri, digest := range me.digests {
+				me.digests[uri] = strings.ToLower(strings.TrimPrefix(digest, "sha256:"))
+			}
+		}
+	}
+	return
+}
+
+//	Pins the SHA-256 digest (in hex, optionally prefixed with "sha256:") expected for the schema document at uri.
+func (me *Checksums) Pin(uri, digest string) {
+	me.mutex.Lock()
+	defer me.mutex.Unlock()
+	me.digests[uri], me.changed = strings.ToLower(strings.TrimPrefix(digest, "sha256:")), true
+}
+
+//	Returns the SHA-256 digest pinned for the schema document at uri, in lower-case hex, if any.
+func (me *Checksums) Lookup(uri string) (digest string, ok bool) {
+	me.mutex.Lock()
+	defer me.mutex.Unlock()
+	digest, ok = me.digests[uri]
+	return
+}
+
+//	Returns the URIs of all pinned schema documents, sorted.
+func (me *Checksums) Uris() (uris []string) {
+	me.mutex.Lock()
+	defer me.mutex.Unlock()
+	for uri, _ := range me.digests {
+		uris = append(uris, uri)
+	}
+	sort.Strings(uris)
+	return
+}
+
+//	Writes these checksums to FilePath, as JSON, if any were pinned or recorded since they were created or loaded.
+func (me *Checksums) Save() (err error) {
+	var raw []byte
+	me.mutex.Lock()
+	defer me.mutex.Unlock()
+	if !me.changed {
+		return
+	}
+	if raw, err = json.MarshalIndent(me.digests, "", "\t"); err == nil {
+		if err = ufs.EnsureDirExists(filepath.Dir(me.FilePath)); err == nil {
+			if err = ufs.WriteBinaryFile(me.FilePath, raw); err == nil {
+				me.changed = false
+			}
+		}
+	}
+	return
+}
+
+//	Verifies the SHA-256 digest (in lower-case hex) of the schema document at uri against the one pinned for it, if any,
+//	or else records it if Record is set. localPath is only used for the ChecksumError returned on a mismatch.
+func (me *Checksums) verify(uri, localPath, actual string) error {
+	me.mutex.Lock()
+	defer me.mutex.Unlock()
+	if expected, ok := me.digests[uri]; ok {
+		if expected != actual {
+			return &ChecksumError{Uri: uri, LocalPath: localPath, Expected: expected, Actual: actual}
+		}
+	} else if me.Record {
+		me.digests[uri], me.changed = actual, true
+	}
+	return nil
+}
+
+//	Returns whether the schema document at uri is to be verified against (or recorded in) Checksums.
+func (me *Generator) checksummed(uri string) bool {
+	if me.Checksums == nil {
+		return false
+	}
+	_, ok := me.Checksums.Lookup(uri)
+	return ok || me.Checksums.Record
+}
+
+//	Verifies the contents of the local copy at localPath of the schema document at uri against Checksums (see Checksums.verify).
+func (me *Generator) verifyLocalCopy(uri, localPath string) (err error) {
+	var file *os.File
+	if !me.checksummed(uri) {
+		return
+	}
+	if file, err = os.Open(localPath); err == nil {
+		defer file.Close()
+		hash := sha256.New()
+		if _, err = io.Copy(hash, file); err == nil {
+			err = me.Checksums.verify(uri, localPath, hex.EncodeToString(hash.Sum(nil)))
+		}
+	}
+	return
+}
@@ -0,0 +1,97 @@
+package xsd
+
+import (
+	"strings"
+)
+
+//	Replaces the embeds for the alternatives of every xs:choice that can be represented as a tagged union (see unionChoice) by a field of a new struct type
+//	(see addChoiceUnion) in the struct types declaring them, if PkgGen.ChoiceUnions is set. Called before addMarshalMethods, which has the struct types
+//	holding such fields (directly or in their embeds) decode their alternatives via DecodeAlternative() (see substDecoders).
+func (me *PkgBag) addChoiceUnions() {
+	for _, tn := range me.sortedTypeNames() {
+		var choices []*Choice
+		var alts = map[*Choice][]*declEmbed{}
+		dt := me.declTypes[tn]
+		if (len(dt.Type) > 0) || strings.HasPrefix(tn, idPrefix+"Choice_") {
+			continue
+		}
+		for _, e := range dt.positionalEmbeds() {
+			if el, isEl := e.elem.(*Element); isEl {
+				if ch, isChoice := el.Parent().(*Choice); isChoice {
+					if alts[ch] == nil {
+						choices = append(choices, ch)
+					}
+					alts[ch] = append(alts[ch], e)
+				}
+			}
+		}
+		for _, ch := range choices {
+			if me.unionChoice(ch, alts[ch]) {
+				me.addChoiceUnion(dt, ch, alts[ch])
+			}
+		}
+	}
+}
+
+//	Returns whether the xs:choice ch, whose element alternatives are embedded as embeds, can be represented as a tagged union: that is, whether it
+//	cannot repeat and all of its alternatives are elements that cannot repeat either, each embedded as a type of this package holding only its field.
+func (me *PkgBag) unionChoice(ch *Choice, embeds []*declEmbed) bool {
+	if _, max := particleOccurs(ch); (max != 1) || (len(embeds) < 2) || (len(embeds) != len(choiceAlternatives(ch))) {
+		return false
+	}
+	for _, e := range embeds {
+		edt := me.declTypes[e.Name]
+		if _, max := particleOccurs(e.elem); (max != 1) || (edt == nil) || (len(edt.Embeds) > 0) || (len(edt.Fields) != 1) {
+			return false
+		}
+		for _, f := range edt.Fields {
+			if strings.HasPrefix(f.Type, "[]") || strings.HasPrefix(f.Type, idPrefix+"Substs_") {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+//	Moves the embeds of the alternatives of the xs:choice ch from the struct type dt into a new XsdGoPkgChoice_ struct type, and adds a field of that type to dt
+//	named after the fields of the alternatives (such as EmailOrPhone). Its Which field holds the local name of the one alternative that is present, which its
+//	SetXyz() methods set (clearing all others), its MarshalXML() method encodes (disregarding all others), and its DecodeAlternative() method records.
+func (me *PkgBag) addChoiceUnion(dt *declType, ch *Choice, embeds []*declEmbed) {
+	var fields []*declField
+	var names, locals, quoted []string
+	var sets, marshal, decode string
+	var xmlImp = me.xmlImpName()
+	me.imports[xmlImp], me.impsUsed[xmlImp] = "encoding/xml", true
+	for _, e := range embeds {
+		for _, f := range me.declTypes[e.Name].Fields {
+			fields, names, locals = append(fields, f), append(names, f.Name), append(locals, xmlTagName(f))
+			quoted = append(quoted, sfmt("%q", xmlTagName(f)))
+		}
+	}
+	field := strings.Join(names, "Or")
+	tn := idPrefix + "Choice_" + strings.TrimPrefix(dt.Name, idPrefix+"Has") + "_" + field
+	ut := me.addType(ch, tn, "", docAnnotation(sfmt("Holds the one alternative of an xs:choice that is present: %s. Only that alternative is encoded.", strings.Join(locals, ", "))))
+	ut.addField(nil, "Which", "string", "-", docAnnotation(sfmt("The local name of the alternative that is present (%s), or empty if none is.", strings.Join(quoted, ", "))))
+	for i, e := range embeds {
+		f := fields[i]
+		space, local := "", f.XmlTag
+		if pos := strings.LastIndex(f.XmlTag, " "); pos >= 0 {
+			space, local = f.XmlTag[:pos], f.XmlTag[pos+1:]
+		}
+		name := sfmt("%s.Name{Space: %#v, Local: %#v}", xmlImp, space, local)
+		ut.addEmbed(e.elem, e.Name, e.Annotations...)
+		delete(dt.Embeds, e.Name)
+		ut.addMethod(nil, "*"+tn, sfmt("Set%s (v %s)", f.Name, f.Type), "", sfmt("\n\t*me = %s{Which: %#v}\n\tme.%s = v\n", tn, local, f.Name), sfmt("Makes the %s element the alternative that is present, with the value v, and clears all others.", local))
+		sets += sfmt("\n\tcase %#v:\n\t\treturn enc.EncodeElement(&me.%s, %s.StartElement{Name: %s})", local, f.Name, xmlImp, name)
+		decode += sfmt("\n\tcase %s:\n\t\t*me = %s{Which: %#v}\n\t\terr = dec.DecodeElement(&me.%s, &start)", name, tn, local, f.Name)
+	}
+	marshal = sfmt("\n\tswitch me.Which {%s\n\t}\n\treturn\n", sets)
+	ut.addMethod(nil, "*"+tn, sfmt("MarshalXML (enc *%s.Encoder, start %s.StartElement)", xmlImp, xmlImp), "(err error)", marshal, "Implements xml.Marshaler: encodes the alternative named by Which (if any) as its element (disregarding start), and none of the others.")
+	ut.addMethod(nil, "*"+tn, sfmt("DecodeAlternative (dec *%s.Decoder, start %s.StartElement)", xmlImp, xmlImp), "(ok bool, err error)", sfmt("\n\tswitch start.Name {%s\n\tdefault:\n\t\treturn\n\t}\n\treturn true, err\n", decode), sfmt("If start is the element of one of the alternatives, decodes it into the field of that alternative (clearing all others) and sets Which to its local name. Called by the UnmarshalXML() methods of the struct types holding a %s.", tn))
+	ut.addMethod(nil, "*"+tn, sfmt("UnmarshalXML (dec *%s.Decoder, start %s.StartElement)", xmlImp, xmlImp), "(err error)", "\n\tvar ok bool\n\tif ok, err = me.DecodeAlternative(dec, start); !ok {\n\t\terr = dec.Skip()\n\t}\n\treturn\n", "Implements xml.Unmarshaler by calling DecodeAlternative, skipping start if it is not the element of any alternative.")
+	if me.gen.AddWalkers {
+		me.walkerTypes[tn] = true
+	}
+	f := dt.addField(ch, field, tn, fields[0].XmlTag, docAnnotation(sfmt("Holds the one alternative of the xs:choice of %s that is present, see %s.", strings.Join(locals, ", "), tn)))
+	f.via, f.positional = embeds[0].via, true
+}
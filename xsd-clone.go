@@ -0,0 +1,103 @@
+package xsd
+
+import (
+	"strings"
+
+	"github.com/metaleap/go-util-str"
+)
+
+//	Renders a Clone() and an Equal() method (see PkgGen.AddCloneAndEqual) for every struct type rendered, after all types are rendered so that the final
+//	type names of their embeds and fields are known. Embeds and fields of struct types of this package are copied and compared by calling their own methods,
+//	those of strings, numbers and bools by assignment and ==, and all others (such as typed built-in types, wildcards and the types of other packages)
+//	by xsdt.CloneValue and xsdt.EqualValues.
+func (me *PkgBag) addCloneAndEqual() {
+	for _, dt := range me.declWrittenTypes {
+		if !me.isCloneType(dt.Name) {
+			continue
+		}
+		var clones, equals []string
+		var cloneBody, equalExpr = "", "true"
+		for _, e := range dt.sortedEmbeds() {
+			if len(e.finalTypeName) == 0 {
+				continue
+			}
+			name := e.finalTypeName[strings.LastIndex(e.finalTypeName, ".")+1:]
+			clones, equals = me.cloneStmts(clones, name, e.finalTypeName), append(equals, me.equalTerm(name, e.finalTypeName))
+		}
+		for _, f := range dt.sortedFields() {
+			clones, equals = me.cloneStmts(clones, f.Name, f.finalTypeName), append(equals, me.equalTerm(f.Name, f.finalTypeName))
+		}
+		for _, stmt := range clones {
+			cloneBody += "\n\t\t" + strings.Replace(stmt, "\n", "\n\t\t", -1)
+		}
+		if len(equals) > 0 {
+			equalExpr = strings.Join(equals, " &&\n\t\t")
+		}
+		me.renderSplit(dt.elem, func() {
+			me.appendFmt(false, "//\tReturns a deep copy of this %s instance, or nil if it is nil.", dt.Name)
+			me.appendFmt(true, "func (me *%s) Clone () (c *%s) {\n\tif me != nil {\n\t\tc = &%s{}\n\t\t*c = *me%s\n\t}\n\treturn\n}", dt.Name, dt.Name, dt.Name, cloneBody)
+			me.appendFmt(false, "//\tReturns whether this %s instance and other are equal, that is, both nil or holding equal values in all their embeds and fields.", dt.Name)
+			me.appendFmt(true, "func (me *%s) Equal (other *%s) bool {\n\tif (me == nil) || (other == nil) {\n\t\treturn me == other\n\t}\n\treturn %s\n}", dt.Name, dt.Name, equalExpr)
+		})
+	}
+}
+
+//	Returns whether the Go type tn is a struct type of this package that gets Clone() and Equal() methods: one that was rendered, and has no fields named so.
+func (me *PkgBag) isCloneType(tn string) bool {
+	dt := me.declTypes[tn]
+	return (dt != nil) && dt.rendered && ((len(dt.EquivalentTo) == 0) || (dt.EquivalentTo == tn)) && (len(dt.Type) == 0) && (dt.Fields["Clone"] == nil) && (dt.Fields["Equal"] == nil)
+}
+
+//	Returns whether values of the Go type tn (as found in a struct field) are strings, numbers or bools, copied by assignment and compared with ==.
+func (me *PkgBag) isPlainType(tn string) bool {
+	for depth := 0; depth < 64; depth++ {
+		if me.textTypes[tn] || me.overrideTypes[tn] {
+			return false
+		} else if dt := me.declTypes[tn]; dt == nil {
+			break
+		} else if tn = dt.Type; len(tn) == 0 {
+			return false
+		}
+	}
+	switch tn {
+	case "string", "bool":
+		return true
+	case me.impName + ".AnyElement", me.impName + ".AnyAttrs", me.impName + ".MixedContent", me.impName + ".Node":
+		return false
+	}
+	return strings.HasPrefix(tn, me.impName+".")
+}
+
+//	Appends to stmts the statements (if any) of a Clone() method that deep-copy the embed or field name of the Go type tn into c, after all embeds and fields were assigned.
+func (me *PkgBag) cloneStmts(stmts []string, name, tn string) []string {
+	switch {
+	case me.isPlainType(tn):
+		return stmts
+	case me.isCloneType(tn):
+		return append(stmts, sfmt("c.%s = *me.%s.Clone()", name, name))
+	case strings.HasPrefix(tn, "*") && me.isCloneType(tn[1:]):
+		return append(stmts, sfmt("c.%s = me.%s.Clone()", name, name))
+	case strings.HasPrefix(tn, "*") && me.isPlainType(tn[1:]):
+		return append(stmts, sfmt("if me.%s != nil {\n\tv := *me.%s\n\tc.%s = &v\n}", name, name, name))
+	case strings.HasPrefix(tn, "[]") && me.isPlainType(tn[2:]):
+		return append(stmts, sfmt("c.%s = append(%s(nil), me.%s...)", name, tn, name))
+	case strings.HasPrefix(tn, "[]*") && me.isCloneType(tn[3:]), strings.HasPrefix(tn, "[]") && me.isCloneType(tn[2:]):
+		return append(stmts, sfmt("if me.%s != nil {\n\tc.%s = make(%s, len(me.%s))\n\tfor i, x := range me.%s {\n\t\tc.%s[i] = %sx.Clone()\n\t}\n}", name, name, tn, name, name, name, ustr.Ifs(strings.HasPrefix(tn, "[]*"), "", "*")))
+	}
+	me.impsUsed[me.impName] = true
+	return append(stmts, sfmt("%s.CloneValue(&c.%s, me.%s)", me.impName, name, name))
+}
+
+//	Returns the term of an Equal() method comparing the embed or field name of the Go type tn of me and other.
+func (me *PkgBag) equalTerm(name, tn string) string {
+	switch {
+	case me.isPlainType(tn):
+		return sfmt("(me.%s == other.%s)", name, name)
+	case me.isCloneType(tn):
+		return sfmt("me.%s.Equal(&other.%s)", name, name)
+	case strings.HasPrefix(tn, "*") && me.isCloneType(tn[1:]):
+		return sfmt("me.%s.Equal(other.%s)", name, name)
+	}
+	me.impsUsed[me.impName] = true
+	return sfmt("%s.EqualValues(&me.%s, &other.%s)", me.impName, name, name)
+}
@@ -0,0 +1,536 @@
+package xsd
+
+import (
+	"encoding/xml"
+	"sort"
+	"strings"
+)
+
+var (
+	//	The built-in XSD types that the other built-in types (but xs:anyType) derive from (by restriction, unless listed in builtinItemTypes).
+	//	All primitive types derive from xs:anyAtomicType.
+	builtinBases = map[string]string{
+		"anySimpleType": "anyType", "anyAtomicType": "anySimpleType", "normalizedString": "string", "token": "normalizedString", "language": "token",
+		"Name": "token", "NMTOKEN": "token", "NCName": "Name", "ID": "NCName", "IDREF": "NCName", "ENTITY": "NCName", "integer": "decimal",
+		"nonPositiveInteger": "integer", "negativeInteger": "nonPositiveInteger", "long": "integer", "int": "long", "short": "int", "byte": "short",
+		"nonNegativeInteger": "integer", "unsignedLong": "nonNegativeInteger", "unsignedInt": "unsignedLong", "unsignedShort": "unsignedInt",
+		"unsignedByte": "unsignedShort", "positiveInteger": "nonNegativeInteger", "dayTimeDuration": "duration", "yearMonthDuration": "duration",
+		"dateTimeStamp": "dateTime", "IDREFS": "anySimpleType", "ENTITIES": "anySimpleType", "NMTOKENS": "anySimpleType",
+	}
+
+	//	The item types of the built-in list types.
+	builtinItemTypes = map[string]string{"IDREFS": "IDREF", "ENTITIES": "ENTITY", "NMTOKENS": "NMTOKEN"}
+)
+
+//	The compiled component model of a schema (see Schema.Compile): its global components and those of all schema documents it includes and
+//	(transitively) imports, with every QName reference among them resolved to the component referred to, the type definitions of all element
+//	and attribute declarations linked along their derivation chains, the members of all substitution groups, and the effective attribute uses of
+//	all complex types, with the default and fixed values of the attribute declarations they refer to applied. The validator (see Schema.Validate),
+//	the instance generator (see Schema.GenerateInstance) and the Go code generator consume this model rather than resolving QNames themselves.
+//	A Compiled is not modified once compiled, and so is safe for concurrent use.
+type Compiled struct {
+	comps    *schemaComponents
+	schemas  []*Schema
+	builtins map[string]*TypeDef
+
+	//	The TypeDefs of all complex and simple types, keyed by their definitions.
+	defs map[element]*TypeDef
+
+	//	The TypeDefs of all element and attribute declarations and XSD 1.1 type alternatives, keyed by their declarations.
+	types map[element]*TypeDef
+
+	//	The global components that element, attribute, group and attribute group references refer to, keyed by the references.
+	refs map[element]element
+
+	heads     map[*Element]*Element
+	members   map[*Element][]*Element
+	uses      map[*ComplexType][]*AttributeUse
+	wildcards map[*ComplexType][]*AnyAttribute
+}
+
+//	A type definition of a compiled schema (see Compiled.Type): a built-in XSD type, or a global or anonymous complex or simple type.
+type TypeDef struct {
+	//	The namespace-qualified name of the type, or the zero xml.Name for anonymous types.
+	Name xml.Name
+
+	//	The local name of the built-in XSD type, such as "string" or "anyType", if this is one; otherwise "".
+	Builtin string
+
+	//	The definition of this type unless it is built-in: either a complex or a simple type.
+	Complex *ComplexType
+	Simple  *SimpleType
+
+	//	The type this type derives from: its base type for complex types and simple type restrictions, xs:anySimpleType for list and union types.
+	//	Base types that cannot be resolved are taken to be xs:anyType (for complex types) or xs:anySimpleType. Only nil for xs:anyType.
+	Base *TypeDef
+
+	//	How this type derives from its Base: "extension", "restriction", "list" or "union", or "" for xs:anyType.
+	Derivation string
+
+	//	The item type of list types, otherwise nil.
+	ItemType *TypeDef
+
+	//	The member types of union types (those named by memberTypes first, then the anonymous ones), otherwise nil.
+	MemberTypes []*TypeDef
+}
+
+//	An attribute that the elements of a complex type may (or must) have, as declared by the type itself, by the attribute groups it refers to,
+//	or by the types it derives from (see Compiled.AttributeUses).
+type AttributeUse struct {
+	//	The namespace-qualified name of the attribute.
+	Name xml.Name
+
+	//	The declaration of the attribute: the global one for attribute references.
+	Decl *Attribute
+
+	//	The xs:attribute within the complex type (or one of its attribute groups) declaring or referring to Decl.
+	Use *Attribute
+
+	Type *TypeDef
+
+	Required bool
+
+	//	The default or fixed value of the attribute, as specified by Use or else by Decl, if any.
+	Default, Fixed string
+}
+
+//	Compiles this schema (and all schemas it includes or imports) into its component model. Compiling is independent of code generation and
+//	does not modify the schema, so a Compiled can be kept around to validate any number of XML instance documents (see Compiled.Validate).
+//	The returned error holds the Diagnostics of all QName references that do not resolve, if any (as with LoadOptions.Strict), but c is
+//	usable regardless: such references resolve as documented for TypeDef.Base, and element declarations of unresolvable types are of xs:anyType.
+func (me *Schema) Compile() (c *Compiled, err error) {
+	c = compileSchemas(me)
+	if diags := c.unresolvedRefs(); len(diags) > 0 {
+		err = diags
+	}
+	return
+}
+
+//	Like Schema.Compile, for the Schemas of this set together.
+func (me *SchemaSet) Compile() (c *Compiled, err error) {
+	c = compileSchemas(me.Schemas...)
+	if diags := c.unresolvedRefs(); len(diags) > 0 {
+		err = diags
+	}
+	return
+}
+
+func compileSchemas(roots ...*Schema) (me *Compiled) {
+	var done = map[*Schema]bool{}
+	var decls []element
+	var cts []*ComplexType
+	me = &Compiled{comps: newSchemaComponents(roots...), builtins: map[string]*TypeDef{}, defs: map[element]*TypeDef{}, types: map[element]*TypeDef{},
+		refs: map[element]element{}, heads: map[*Element]*Element{}, members: map[*Element][]*Element{}, uses: map[*ComplexType][]*AttributeUse{},
+		wildcards: map[*ComplexType][]*AnyAttribute{}}
+	for name, _ := range xsdBuiltinTypes {
+		me.builtin(name)
+	}
+	for _, root := range roots {
+		me.schemas = strictSchemas(root, me.schemas, done)
+	}
+	for _, sd := range me.schemas {
+		sd.Walk(func(node SchemaNode) bool {
+			switch el := node.Elem.(type) {
+			case *Element, *Attribute, *Alternative:
+				me.resolveRef(el.(element))
+				decls = append(decls, el.(element))
+			case *Group, *AttributeGroup:
+				me.resolveRef(el.(element))
+			case *ComplexType:
+				me.def(el)
+				cts = append(cts, el)
+			case *SimpleType:
+				me.def(el)
+			}
+			return true
+		})
+	}
+	for _, decl := range decls {
+		me.declType(decl, 0)
+	}
+	for _, qn := range sortedNames(me.comps.byKind("element")) {
+		el := me.comps.elements[qn]
+		for head, depth := me.heads[el], 0; (head != nil) && (head != el) && (depth < 64); head, depth = me.heads[head], depth+1 {
+			me.members[head] = append(me.members[head], el)
+		}
+	}
+	for _, ct := range cts {
+		var uses, names = map[xml.Name]*AttributeUse{}, []xml.Name{}
+		var wildcards []*AnyAttribute
+		me.attributeUses(ct, uses, &wildcards, map[element]bool{})
+		for name, _ := range uses {
+			names = append(names, name)
+		}
+		sort.Sort(xmlNames(names))
+		for _, name := range names {
+			me.uses[ct] = append(me.uses[ct], uses[name])
+		}
+		me.wildcards[ct] = wildcards
+	}
+	return
+}
+
+//	Records the global component that the element, attribute, group or attribute group reference el refers to, and the head of the
+//	substitution group of the global element declaration el.
+func (me *Compiled) resolveRef(el element) {
+	var owner = ownerSchema(el)
+	switch x := el.(type) {
+	case *Element:
+		if glob := me.comps.elements[owner.qname(x.Ref.String())]; (len(x.Ref) > 0) && (glob != nil) {
+			me.refs[x] = glob
+		}
+		if head := me.comps.elements[owner.qname(x.SubstitutionGroup.String())]; (len(x.SubstitutionGroup) > 0) && isGlobal(x) && (head != nil) && (head != x) {
+			me.heads[x] = head
+		}
+	case *Attribute:
+		if glob := me.comps.attributes[owner.qname(x.Ref.String())]; (len(x.Ref) > 0) && (glob != nil) {
+			me.refs[x] = glob
+		}
+	case *Group:
+		if glob := me.comps.groups[owner.qname(x.Ref.String())]; (len(x.Ref) > 0) && (glob != nil) {
+			me.refs[x] = glob
+		}
+	case *AttributeGroup:
+		if glob := me.comps.attributeGroups[owner.qname(x.Ref.String())]; (len(x.Ref) > 0) && (glob != nil) {
+			me.refs[x] = glob
+		}
+	}
+}
+
+//	Returns the TypeDef of the built-in XSD type named local, creating it (and those it derives from) on first use.
+func (me *Compiled) builtin(local string) (t *TypeDef) {
+	if t = me.builtins[local]; t == nil {
+		t = &TypeDef{Name: xml.Name{Space: xsdNamespaceUri, Local: local}, Builtin: local}
+		me.builtins[local] = t
+		if item := builtinItemTypes[local]; len(item) > 0 {
+			t.Base, t.Derivation, t.ItemType = me.builtin("anySimpleType"), "list", me.builtin(item)
+		} else if base := builtinBases[local]; len(base) > 0 {
+			t.Base, t.Derivation = me.builtin(base), "restriction"
+		} else if local != "anyType" {
+			t.Base, t.Derivation = me.builtin("anyAtomicType"), "restriction"
+		}
+	}
+	return
+}
+
+//	Returns the TypeDef of the global type named qn, or of the built-in XSD type if qn is in the XSD namespace, or nil if there is no such type.
+//	While compiling, TypeDefs are created on first use.
+func (me *Compiled) typeNamed(qn xml.Name) *TypeDef {
+	if qn.Space == xsdNamespaceUri {
+		if t := me.builtins[qn.Local]; t != nil {
+			return t
+		}
+		return &TypeDef{Name: qn, Builtin: qn.Local, Base: me.builtins["anySimpleType"], Derivation: "restriction"}
+	} else if ct := me.comps.complexTypes[qn]; ct != nil {
+		return me.def(ct)
+	} else if st := me.comps.simpleTypes[qn]; st != nil {
+		return me.def(st)
+	}
+	return nil
+}
+
+//	Like typeNamed, for the QName reference ref in the schema document declaring el, but returns the built-in XSD type named dflt if ref does not resolve.
+func (me *Compiled) typeRef(el element, ref, dflt string) (t *TypeDef) {
+	if t = me.typeNamed(ownerSchema(el).qname(ref)); t == nil {
+		t = me.builtins[dflt]
+	}
+	return
+}
+
+//	Returns the TypeDef of the complex or simple type el, linking it along its derivation chain on first use.
+func (me *Compiled) def(el element) (t *TypeDef) {
+	if t = me.defs[el]; t != nil {
+		return
+	}
+	t = &TypeDef{}
+	me.defs[el] = t
+	switch x := el.(type) {
+	case *ComplexType:
+		if t.Complex = x; isGlobal(x) {
+			t.Name = xml.Name{Space: ownerSchema(x).TargetNamespace.String(), Local: x.Name.String()}
+		}
+		t.Base, t.Derivation = me.builtins["anyType"], "restriction"
+		if cc := x.ComplexContent; (cc != nil) && (cc.ExtensionComplexContent != nil) {
+			t.Base, t.Derivation = me.typeRef(x, cc.ExtensionComplexContent.Base.String(), "anyType"), "extension"
+		} else if (cc != nil) && (cc.RestrictionComplexContent != nil) {
+			t.Base = me.typeRef(x, cc.RestrictionComplexContent.Base.String(), "anyType")
+		} else if sc := x.SimpleContent; (sc != nil) && (sc.ExtensionSimpleContent != nil) {
+			t.Base, t.Derivation = me.typeRef(x, sc.ExtensionSimpleContent.Base.String(), "anyType"), "extension"
+		} else if (sc != nil) && (sc.RestrictionSimpleContent != nil) {
+			t.Base = me.typeRef(x, sc.RestrictionSimpleContent.Base.String(), "anyType")
+		}
+	case *SimpleType:
+		if t.Simple = x; isGlobal(x) {
+			t.Name = xml.Name{Space: ownerSchema(x).TargetNamespace.String(), Local: x.Name.String()}
+		}
+		t.Base = me.builtins["anySimpleType"]
+		if res := x.RestrictionSimpleType; res != nil {
+			if t.Derivation = "restriction"; len(res.SimpleTypes) > 0 {
+				t.Base = me.def(res.SimpleTypes[0])
+			} else if len(res.Base) > 0 {
+				t.Base = me.typeRef(x, res.Base.String(), "anySimpleType")
+			}
+		} else if l := x.List; l != nil {
+			if t.Derivation, t.ItemType = "list", me.builtins["anySimpleType"]; len(l.ItemType) > 0 {
+				t.ItemType = me.typeRef(x, l.ItemType.String(), "anySimpleType")
+			} else if len(l.SimpleTypes) > 0 {
+				t.ItemType = me.def(l.SimpleTypes[0])
+			}
+		} else if u := x.Union; u != nil {
+			t.Derivation = "union"
+			for _, mt := range strings.Fields(u.MemberTypes) {
+				t.MemberTypes = append(t.MemberTypes, me.typeRef(x, mt, "anySimpleType"))
+			}
+			for _, mst := range u.SimpleTypes {
+				t.MemberTypes = append(t.MemberTypes, me.def(mst))
+			}
+		}
+	}
+	return
+}
+
+//	Returns (and records) the TypeDef of the element or attribute declaration or type alternative decl, following element and attribute
+//	references and, for element declarations specifying no type, the heads of their substitution groups.
+func (me *Compiled) declType(decl element, depth int) (t *TypeDef) {
+	if t = me.types[decl]; (t != nil) || (depth > 64) {
+		return
+	}
+	if glob := me.refs[decl]; glob != nil {
+		t = me.declType(glob, depth+1)
+	} else {
+		switch x := decl.(type) {
+		case *Element:
+			if len(x.Type) > 0 {
+				t = me.typeRef(x, x.Type.String(), "anyType")
+			} else if x.ComplexType != nil {
+				t = me.def(x.ComplexType)
+			} else if len(x.SimpleTypes) > 0 {
+				t = me.def(x.SimpleTypes[0])
+			} else if head := me.heads[x]; head != nil {
+				t = me.declType(head, depth+1)
+			}
+			if t == nil {
+				t = me.builtins["anyType"]
+			}
+		case *Attribute:
+			if len(x.Type) > 0 {
+				t = me.typeRef(x, x.Type.String(), "anySimpleType")
+			} else if len(x.SimpleTypes) > 0 {
+				t = me.def(x.SimpleTypes[0])
+			} else {
+				t = me.builtins["anySimpleType"]
+			}
+		case *Alternative:
+			if len(x.Type) > 0 {
+				t = me.typeNamed(ownerSchema(x).qname(x.Type.String()))
+			} else if x.ComplexType != nil {
+				t = me.def(x.ComplexType)
+			} else if len(x.SimpleTypes) > 0 {
+				t = me.def(x.SimpleTypes[0])
+			} else {
+				t = me.builtins["anyType"]
+			}
+		}
+	}
+	if t != nil {
+		me.types[decl] = t
+	}
+	return
+}
+
+//	Adds to uses the attribute uses of ct, those inherited from its base types first, and to wildcards its xs:anyAttributes and those it inherits.
+//	The types and attribute groups in busy are not followed again, as they are circular.
+func (me *Compiled) attributeUses(ct *ComplexType, uses map[xml.Name]*AttributeUse, wildcards *[]*AnyAttribute, busy map[element]bool) {
+	if busy[ct] {
+		return
+	}
+	busy[ct] = true
+	if base := me.def(ct).Base; (base != nil) && (base.Complex != nil) {
+		me.attributeUses(base.Complex, uses, wildcards, busy)
+	}
+	if cc := ct.ComplexContent; (cc != nil) && (cc.ExtensionComplexContent != nil) {
+		ext := cc.ExtensionComplexContent
+		me.addAttributeUses(ext.Attributes, ext.AttributeGroups, ext.AnyAttributes, uses, wildcards, busy)
+	} else if (cc != nil) && (cc.RestrictionComplexContent != nil) {
+		res := cc.RestrictionComplexContent
+		me.addAttributeUses(res.Attributes, res.AttributeGroups, res.AnyAttributes, uses, wildcards, busy)
+	} else if sc := ct.SimpleContent; (sc != nil) && (sc.ExtensionSimpleContent != nil) {
+		ext := sc.ExtensionSimpleContent
+		me.addAttributeUses(ext.Attributes, ext.AttributeGroups, ext.AnyAttributes, uses, wildcards, busy)
+	} else if (sc != nil) && (sc.RestrictionSimpleContent != nil) {
+		res := sc.RestrictionSimpleContent
+		me.addAttributeUses(res.Attributes, res.AttributeGroups, res.AnyAttributes, uses, wildcards, busy)
+	}
+	me.addAttributeUses(ct.Attributes, ct.AttributeGroups, ct.AnyAttributes, uses, wildcards, busy)
+	delete(busy, ct)
+}
+
+func (me *Compiled) addAttributeUses(atts []*Attribute, groups []*AttributeGroup, anys []*AnyAttribute, uses map[xml.Name]*AttributeUse, wildcards *[]*AnyAttribute, busy map[element]bool) {
+	for _, att := range atts {
+		name := me.comps.attributeName(att)
+		if att.Use == "prohibited" {
+			delete(uses, name)
+			continue
+		}
+		use := &AttributeUse{Name: name, Decl: att, Use: att, Type: me.declType(att, 0), Required: att.Use == "required", Default: att.Default, Fixed: att.Fixed}
+		if glob, _ := me.refs[att].(*Attribute); glob != nil {
+			if use.Decl = glob; len(use.Fixed) == 0 {
+				use.Fixed = glob.Fixed
+			}
+			if len(use.Default) == 0 {
+				use.Default = glob.Default
+			}
+		}
+		uses[name] = use
+	}
+	for _, ag := range groups {
+		if glob, _ := me.refs[ag].(*AttributeGroup); glob != nil {
+			ag = glob
+		} else if len(ag.Ref) > 0 {
+			continue
+		}
+		if !busy[ag] {
+			busy[ag] = true
+			me.addAttributeUses(ag.Attributes, ag.AttributeGroups, ag.AnyAttributes, uses, wildcards, busy)
+			delete(busy, ag)
+		}
+	}
+	*wildcards = append(*wildcards, anys...)
+}
+
+//	Returns the Diagnostics of all QName references in the compiled schema documents that do not resolve.
+func (me *Compiled) unresolvedRefs() (diags Diagnostics) {
+	for _, sd := range me.schemas {
+		sd.Walk(func(node SchemaNode) bool {
+			diags = append(diags, me.comps.refDiags(node)...)
+			return true
+		})
+	}
+	return
+}
+
+//	Returns the global element declaration named qn, or nil if there is none.
+func (me *Compiled) Element(qn xml.Name) *Element {
+	return me.comps.elements[qn]
+}
+
+//	Returns the xs:notation declaration named qn, or nil if there is none.
+func (me *Compiled) Notation(qn xml.Name) *Notation {
+	return me.comps.notations[qn]
+}
+
+//	Returns the xs:notation declarations of the compiled schema documents, sorted by name.
+func (me *Compiled) Notations() (nots []*Notation) {
+	for _, qn := range sortedNames(me.comps.byKind("notation")) {
+		nots = append(nots, me.comps.notations[qn])
+	}
+	return
+}
+
+//	Returns the global type named qn, or the built-in XSD type if qn is in the XSD namespace, or nil if there is no such type.
+func (me *Compiled) Type(qn xml.Name) *TypeDef {
+	if (qn.Space != xsdNamespaceUri) || (me.builtins[qn.Local] != nil) {
+		return me.typeNamed(qn)
+	}
+	return nil
+}
+
+//	Returns the TypeDef of the complex or simple type def, or nil if def is not a type of the compiled schema documents.
+func (me *Compiled) TypeDefOf(def interface{}) *TypeDef {
+	if el, ok := def.(element); ok {
+		return me.defs[el]
+	}
+	return nil
+}
+
+//	Returns the type of the element or attribute declaration or XSD 1.1 type alternative decl, following element and attribute references and,
+//	for element declarations specifying no type, the heads of their substitution groups. Returns nil if decl is not of the compiled schema
+//	documents, and for type alternatives whose type does not resolve.
+func (me *Compiled) TypeOf(decl interface{}) *TypeDef {
+	if el, ok := decl.(element); ok {
+		return me.types[el]
+	}
+	return nil
+}
+
+//	Returns the global component that the element, attribute, group or attribute group reference ref refers to, or else ref itself (such as
+//	when ref is a declaration rather than a reference), or nil if the reference does not resolve.
+func (me *Compiled) Resolve(ref interface{}) interface{} {
+	var el, _ = ref.(element)
+	if glob := me.refs[el]; glob != nil {
+		return glob
+	}
+	switch x := ref.(type) {
+	case *Element:
+		if len(x.Ref) > 0 {
+			return nil
+		}
+	case *Attribute:
+		if len(x.Ref) > 0 {
+			return nil
+		}
+	case *Group:
+		if len(x.Ref) > 0 {
+			return nil
+		}
+	case *AttributeGroup:
+		if len(x.Ref) > 0 {
+			return nil
+		}
+	}
+	return ref
+}
+
+//	Returns the global element declaration heading the substitution group of the global element declaration el, or nil if none.
+func (me *Compiled) SubstitutionHead(el *Element) *Element {
+	return me.heads[el]
+}
+
+//	Returns the members of the substitution group headed by the global element declaration head, including those of the substitution groups
+//	of its members, sorted by name.
+func (me *Compiled) Substitutes(head *Element) []*Element {
+	return me.members[head]
+}
+
+//	Returns the attribute uses of the complex type ct (including those inherited from its base types and via its attribute groups), sorted by name.
+func (me *Compiled) AttributeUses(ct *ComplexType) []*AttributeUse {
+	return me.uses[ct]
+}
+
+//	Returns the xs:anyAttribute wildcards of the complex type ct, including those inherited from its base types and via its attribute groups.
+func (me *Compiled) AttributeWildcards(ct *ComplexType) []*AnyAttribute {
+	return me.wildcards[ct]
+}
+
+//	Returns whether this type is t or derives from it (directly or indirectly).
+func (me *TypeDef) DerivesFrom(t *TypeDef) bool {
+	for cur, depth := me, 0; (cur != nil) && (depth < 64); cur, depth = cur.Base, depth+1 {
+		if cur == t {
+			return true
+		}
+	}
+	return false
+}
+
+//	Returns the local name of the built-in atomic XSD type that this simple type is or restricts (directly or indirectly), or "" for list
+//	and union types, complex types, xs:anySimpleType and xs:anyType.
+func (me *TypeDef) BuiltinBase() string {
+	for cur, depth := me, 0; (cur != nil) && (depth < 64); cur, depth = cur.Base, depth+1 {
+		if (cur.Complex != nil) || (cur.Derivation == "list") || (cur.Derivation == "union") || (cur.Builtin == "anySimpleType") || (cur.Builtin == "anyType") {
+			break
+		} else if len(cur.Builtin) > 0 {
+			return cur.Builtin
+		}
+	}
+	return ""
+}
+
+//	Returns whether this type is a list type or restricts one (directly or indirectly).
+func (me *TypeDef) IsList() bool {
+	for cur, depth := me, 0; (cur != nil) && (depth < 64); cur, depth = cur.Base, depth+1 {
+		if cur.Derivation != "restriction" {
+			return cur.Derivation == "list"
+		}
+	}
+	return false
+}
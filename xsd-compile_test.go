@@ -0,0 +1,67 @@
+package xsd
+
+import (
+	"encoding/xml"
+	"strings"
+	"testing"
+)
+
+//	Tests that Compile resolves QName references across imports into TypeDefs linked by their derivation chains, collects the attribute uses of
+//	complex types with their defaults, links substitution groups, reports unresolvable references while staying usable, and validates instances.
+func TestCompile(t *testing.T) {
+	c, err := loadTestSchema(t, "compile", "main.xsd").Compile()
+	if (err == nil) || !strings.Contains(err.Error(), `main.xsd:25:44: error: unresolved type reference "Missing"`) {
+		t.Errorf("expected the unresolved type reference to be reported, got %v", err)
+	}
+	main, lib := func(local string) xml.Name { return xml.Name{Space: "urn:example:main", Local: local} }, func(local string) xml.Name { return xml.Name{Space: "urn:example:lib", Local: local} }
+	derived, base, anyType := c.Type(main("Derived")), c.Type(main("Base")), c.Type(xml.Name{Space: xsdNamespaceUri, Local: "anyType"})
+	if (derived.Base != base) || (derived.Derivation != "extension") || (base.Base != anyType) || !derived.DerivesFrom(anyType) || base.DerivesFrom(derived) {
+		t.Errorf("expected Derived to extend Base, which restricts xs:anyType, got %+v", derived)
+	}
+	var uses []string
+	for _, use := range c.AttributeUses(derived.Complex) {
+		uses = append(uses, sfmt("%s:%s:%v:%s", use.Name.Local, use.Type.Name.Local, use.Required, use.Default))
+	}
+	if strings.Join(uses, " ") != "codes:Codes:false: id:ID:true: lang:language:false:en" {
+		t.Errorf("expected the attribute uses codes, id and lang, got %v", uses)
+	}
+	if codes := c.Type(lib("Codes")); !codes.IsList() || (codes.ItemType != c.Type(lib("Code"))) || (codes.ItemType.BuiltinBase() != "token") {
+		t.Errorf("expected a list of codes, got %+v", codes)
+	}
+	if members := c.Type(main("CodeOrCount")).MemberTypes; (len(members) != 2) || (members[0] != c.Type(lib("Code"))) || (members[1].BuiltinBase() != "int") {
+		t.Errorf("expected the member types Code and xs:int, got %+v", members)
+	}
+	shape := c.Element(main("shape"))
+	var subs []string
+	for _, el := range c.Substitutes(shape) {
+		subs = append(subs, sfmt("%s:%s:%s", el.Name, c.SubstitutionHead(el).Name, c.TypeOf(el).Name.Local))
+	}
+	if strings.Join(subs, " ") != "circle:shape:CodeOrCount ring:circle:CodeOrCount square:shape:int" {
+		t.Errorf("expected circle, ring and square to substitute shape, got %v", subs)
+	}
+	if ref := base.Complex.Sequence.Elements[0]; (c.Resolve(ref) != shape) || (c.TypeOf(ref) != c.Type(main("CodeOrCount"))) {
+		t.Errorf("expected the element reference to resolve to shape")
+	}
+	if broken := c.TypeOf(c.Element(main("broken"))); broken != anyType {
+		t.Errorf("expected the element of the unresolvable type to be of xs:anyType, got %+v", broken)
+	}
+	for instance, expected := range map[string][]string{
+		`<doc xmlns="urn:example:main" id="d1" codes="ab cd"><circle>ab</circle><square>3</square></doc>`: nil,
+		`<doc xmlns="urn:example:main" codes="abcde"><square>x</square></doc>`: []string{
+			`list item "abcde": "abcde" must have a maximum length of 4`, `missing required attribute "id"`, `"x" is not a valid int`,
+		},
+	} {
+		errs, err := c.Validate(strings.NewReader(instance))
+		if err != nil {
+			t.Fatal(err)
+		} else if len(errs) != len(expected) {
+			t.Errorf("expected %d errors validating %s, got %v", len(expected), instance, errs)
+			continue
+		}
+		for i, e := range expected {
+			if !strings.Contains(errs[i].Error(), e) {
+				t.Errorf("expected %q validating %s, got %v", e, instance, errs[i])
+			}
+		}
+	}
+}
@@ -0,0 +1,167 @@
+package xsd
+
+import (
+	"encoding/xml"
+	"strings"
+)
+
+// All global components of a schema, its includes and (transitively) its imports, keyed by their namespace-qualified names.
+type schemaComponents struct {
+	attributes      map[xml.Name]*Attribute
+	attributeGroups map[xml.Name]*AttributeGroup
+	complexTypes    map[xml.Name]*ComplexType
+	elements        map[xml.Name]*Element
+	groups          map[xml.Name]*Group
+	notations       map[xml.Name]*Notation
+	simpleTypes     map[xml.Name]*SimpleType
+}
+
+func newSchemaComponents(roots ...*Schema) (me *schemaComponents) {
+	me = &schemaComponents{
+		attributes:      map[xml.Name]*Attribute{},
+		attributeGroups: map[xml.Name]*AttributeGroup{},
+		complexTypes:    map[xml.Name]*ComplexType{},
+		elements:        map[xml.Name]*Element{},
+		groups:          map[xml.Name]*Group{},
+		notations:       map[xml.Name]*Notation{},
+		simpleTypes:     map[xml.Name]*SimpleType{},
+	}
+	done := map[*Schema]bool{}
+	for _, root := range roots {
+		me.collect(root, done)
+	}
+	return
+}
+
+func (me *schemaComponents) collect(sd *Schema, done map[*Schema]bool) {
+	if done[sd] {
+		return
+	}
+	done[sd] = true
+	ns := sd.TargetNamespace.String()
+	for _, att := range sd.globalAttributes() {
+		me.attributes[xml.Name{Space: ns, Local: att.Name.String()}] = att
+	}
+	for _, agr := range sd.globalAttributeGroups() {
+		me.attributeGroups[xml.Name{Space: ns, Local: agr.Name.String()}] = agr
+	}
+	for _, ct := range sd.globalComplexTypes() {
+		me.complexTypes[xml.Name{Space: ns, Local: ct.Name.String()}] = ct
+	}
+	for _, el := range sd.globalElements() {
+		me.elements[xml.Name{Space: ns, Local: el.Name.String()}] = el
+	}
+	for _, gr := range sd.globalGroups() {
+		me.groups[xml.Name{Space: ns, Local: gr.Name.String()}] = gr
+	}
+	for _, not := range sd.globalNotations() {
+		me.notations[xml.Name{Space: ns, Local: not.Name.String()}] = not
+	}
+	for _, st := range sd.globalSimpleTypes() {
+		me.simpleTypes[xml.Name{Space: ns, Local: st.Name.String()}] = st
+	}
+	for _, inc := range sd.XMLIncludedSchemas {
+		me.collect(inc, done)
+	}
+	for _, imp := range sd.XMLImportedSchemas {
+		me.collect(imp, done)
+	}
+}
+
+//	Returns whether there is a global component of the specified kind (such as "complexType") with the specified name.
+func (me *schemaComponents) has(kind string, qn xml.Name) (ok bool) {
+	switch kind {
+	case "attribute":
+		_, ok = me.attributes[qn]
+	case "attributeGroup":
+		_, ok = me.attributeGroups[qn]
+	case "complexType":
+		_, ok = me.complexTypes[qn]
+	case "element":
+		_, ok = me.elements[qn]
+	case "group":
+		_, ok = me.groups[qn]
+	case "notation":
+		_, ok = me.notations[qn]
+	case "simpleType":
+		_, ok = me.simpleTypes[qn]
+	}
+	return
+}
+
+// Returns the namespace-qualified name of the global element that the specified element declaration is or refers to.
+func (me *schemaComponents) elementName(el *Element) (qn xml.Name) {
+	owner := ownerSchema(el)
+	if len(el.Ref) > 0 {
+		qn = owner.qname(el.Ref.String())
+	} else if qn.Local = el.Name.String(); isGlobal(el) || (el.Form == "qualified") || ((len(el.Form) == 0) && (owner.ElementFormDefault == "qualified")) {
+		qn.Space = owner.TargetNamespace.String()
+	}
+	return
+}
+
+// Returns the namespace-qualified name of the attribute that the specified attribute declaration is or refers to.
+func (me *schemaComponents) attributeName(att *Attribute) (qn xml.Name) {
+	owner := ownerSchema(att)
+	if len(att.Ref) > 0 {
+		qn = owner.qname(att.Ref.String())
+	} else if qn.Local = att.Name.String(); isGlobal(att) || (att.Form == "qualified") || ((len(att.Form) == 0) && (owner.AttributeFormDefault == "qualified")) {
+		qn.Space = owner.TargetNamespace.String()
+	}
+	return
+}
+
+//	Returns the local name of the built-in XSD type that the specified type is ultimately derived from, if it can be determined.
+func (me *schemaComponents) builtinBase(qn xml.Name, depth int) string {
+	if qn.Space == xsdNamespaceUri {
+		return qn.Local
+	} else if st := me.simpleTypes[qn]; (st != nil) && (st.RestrictionSimpleType != nil) && (depth < 64) {
+		return me.builtinBase(ownerSchema(st).qname(st.RestrictionSimpleType.Base.String()), depth+1)
+	}
+	return ""
+}
+
+//	Returns whether res restricts a list type (directly or indirectly).
+func (me *schemaComponents) restrictsList(res *RestrictionSimpleType, depth int) bool {
+	var base *SimpleType
+	if len(res.SimpleTypes) > 0 {
+		base = res.SimpleTypes[0]
+	} else if len(res.Base) > 0 {
+		base = me.simpleTypes[ownerSchema(res).qname(res.Base.String())]
+	}
+	if (base == nil) || (depth > 64) {
+		return false
+	} else if base.RestrictionSimpleType != nil {
+		return me.restrictsList(base.RestrictionSimpleType, depth+1)
+	}
+	return base.List != nil
+}
+
+//	Returns whether the specified schema component is declared at the top level of its schema document, including within xs:redefine and xs:override.
+func isGlobal(el element) bool {
+	switch el.Parent().(type) {
+	case *Schema, *Redefine, *Override:
+		return true
+	}
+	return false
+}
+
+// Returns the schema document that declares the specified schema component.
+func ownerSchema(el element) *Schema {
+	for ; el != nil; el = el.Parent() {
+		if sd, ok := el.(*Schema); ok {
+			return sd
+		}
+	}
+	return nil
+}
+
+// Resolves the specified "prefix:local" QName reference against the namespace declarations of this schema document.
+func (me *Schema) qname(ref string) (qn xml.Name) {
+	if pos := strings.Index(ref, ":"); pos > 0 {
+		qn.Space, qn.Local = me.XMLNamespaces[ref[:pos]], ref[pos+1:]
+	} else {
+		qn.Space, qn.Local = me.XMLNamespaces[""], ref
+	}
+	return
+}
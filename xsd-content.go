@@ -0,0 +1,345 @@
+package xsd
+
+import (
+	"strings"
+)
+
+//	A field of the flattened content model of a complex type, as derived by the backends that map complex types to flat records (see MakeProtoFile and MakeJSONSchema).
+type contentField struct {
+	//	The local name of the element or attribute, "any" for element wildcards, or "value" for simple content.
+	Name string
+
+	//	The *Element or *Attribute declaring the field (for references, the referenced global declaration), the *Any wildcard,
+	//	or else (for simple content) the derivation whose Base is the type of the field.
+	Decl element
+
+	//	For simple content only, the QName (relative to Decl) of its simple type.
+	Base string
+
+	Attr bool
+
+	//	The minimum and maximum number of occurrences of the field, taking all enclosing particles into account. MaxOccurs is -1 if unbounded.
+	MinOccurs, MaxOccurs int64
+
+	inherited bool
+
+	//	For element fields, the element particle in the content model (which, unlike Decl, may be a reference).
+	particle *Element
+}
+
+//	Returns whether the field can occur more than once.
+func (me *contentField) Repeated() bool {
+	return me.MaxOccurs != 1
+}
+
+//	Returns whether the field can be absent.
+func (me *contentField) Optional() bool {
+	return me.MinOccurs == 0
+}
+
+//	Returns the fields of the content and attributes of ct, flattening in those of its base types:
+//	a field re-declared in a derivation (such as in a restriction) replaces the one of the same name inherited from the base type, and a restriction
+//	drops the element fields of its base type (as it declares its entire content model) as well as the attributes it prohibits.
+func (me *schemaComponents) contentFields(ct *ComplexType) (fields []*contentField) {
+	me.addContentFields(&fields, ct, false, map[*ComplexType]bool{})
+	return
+}
+
+func (me *schemaComponents) addContentFields(fields *[]*contentField, ct *ComplexType, inherited bool, busy map[*ComplexType]bool) {
+	if busy[ct] {
+		return
+	}
+	busy[ct] = true
+	defer delete(busy, ct)
+	if cc := ct.ComplexContent; cc != nil {
+		if ext := cc.ExtensionComplexContent; ext != nil {
+			if base := me.complexTypes[ownerSchema(ext).qname(ext.Base.String())]; base != nil {
+				me.addContentFields(fields, base, true, busy)
+			}
+			me.addParticleFields(fields, inherited, 1, 1, ext.All, ext.Choices, ext.Groups, ext.Sequences, map[*Group]bool{})
+			me.addAttributeFields(fields, inherited, ext.Attributes, ext.AttributeGroups, map[*AttributeGroup]bool{})
+		}
+		if rest := cc.RestrictionComplexContent; rest != nil {
+			if base := me.complexTypes[ownerSchema(rest).qname(rest.Base.String())]; base != nil {
+				var kept []*contentField
+				me.addContentFields(fields, base, true, busy)
+				for _, f := range *fields {
+					if f.Attr || !f.inherited {
+						kept = append(kept, f)
+					}
+				}
+				*fields = kept
+			}
+			me.addParticleFields(fields, inherited, 1, 1, rest.All, rest.Choices, nil, rest.Sequences, map[*Group]bool{})
+			me.addAttributeFields(fields, inherited, rest.Attributes, rest.AttributeGroups, map[*AttributeGroup]bool{})
+		}
+	} else if sc := ct.SimpleContent; sc != nil {
+		if ext := sc.ExtensionSimpleContent; ext != nil {
+			me.addValueField(fields, ext, ext.Base.String(), inherited, busy)
+			me.addAttributeFields(fields, inherited, ext.Attributes, ext.AttributeGroups, map[*AttributeGroup]bool{})
+		}
+		if rest := sc.RestrictionSimpleContent; rest != nil {
+			me.addValueField(fields, rest, rest.Base.String(), inherited, busy)
+			me.addAttributeFields(fields, inherited, rest.Attributes, rest.AttributeGroups, map[*AttributeGroup]bool{})
+		}
+	} else {
+		var grs []*Group
+		var chs []*Choice
+		var seqs []*Sequence
+		if ct.Group != nil {
+			grs = append(grs, ct.Group)
+		}
+		if ct.Choice != nil {
+			chs = append(chs, ct.Choice)
+		}
+		if ct.Sequence != nil {
+			seqs = append(seqs, ct.Sequence)
+		}
+		me.addParticleFields(fields, inherited, 1, 1, ct.All, chs, grs, seqs, map[*Group]bool{})
+		me.addAttributeFields(fields, inherited, ct.Attributes, ct.AttributeGroups, map[*AttributeGroup]bool{})
+	}
+}
+
+func addContentField(fields *[]*contentField, field *contentField) {
+	for i, f := range *fields {
+		if (f.Name == field.Name) && (f.Attr == field.Attr) && f.inherited && !field.inherited {
+			(*fields)[i] = field
+			return
+		}
+	}
+	*fields = append(*fields, field)
+}
+
+//	Adds the "value" field for the simple content of a complex type deriving from base, or else the fields of base if that is a complex type itself.
+func (me *schemaComponents) addValueField(fields *[]*contentField, decl element, base string, inherited bool, busy map[*ComplexType]bool) {
+	if ct := me.complexTypes[ownerSchema(decl).qname(base)]; ct != nil {
+		me.addContentFields(fields, ct, true, busy)
+	} else {
+		addContentField(fields, &contentField{Name: "value", Decl: decl, Base: base, MinOccurs: 1, MaxOccurs: 1, inherited: inherited})
+	}
+}
+
+//	Adds the fields for the specified attributes and for those of the specified attribute groups. The attribute groups in busy (those being added already)
+//	are not added again, so that circular attribute group references terminate.
+func (me *schemaComponents) addAttributeFields(fields *[]*contentField, inherited bool, atts []*Attribute, agrs []*AttributeGroup, busy map[*AttributeGroup]bool) {
+	for _, att := range atts {
+		var name = att.Name.String()
+		if att.Use == "prohibited" {
+			var kept []*contentField
+			for _, f := range *fields {
+				if (!f.Attr) || (f.Name != me.attributeName(att).Local) {
+					kept = append(kept, f)
+				}
+			}
+			*fields = kept
+			continue
+		}
+		field := &contentField{Attr: true, Decl: att, MaxOccurs: 1, inherited: inherited}
+		if att.Use == "required" {
+			field.MinOccurs = 1
+		}
+		if ref := att.Ref.String(); len(ref) > 0 {
+			qn := ownerSchema(att).qname(ref)
+			if name = qn.Local; me.attributes[qn] != nil {
+				field.Decl = me.attributes[qn]
+			}
+		}
+		field.Name = name
+		addContentField(fields, field)
+	}
+	for _, agr := range agrs {
+		if ref := agr.Ref.String(); len(ref) > 0 {
+			if ga := me.attributeGroups[ownerSchema(agr).qname(ref)]; (ga != nil) && !busy[ga] {
+				busy[ga] = true
+				me.addAttributeFields(fields, inherited, ga.Attributes, ga.AttributeGroups, busy)
+				delete(busy, ga)
+			}
+		} else {
+			me.addAttributeFields(fields, inherited, agr.Attributes, agr.AttributeGroups, busy)
+		}
+	}
+}
+
+//	Adds the fields for the specified particles, all of which occur between min and max times (-1 if unbounded) due to their enclosing particles.
+//	The named groups in busy (those being added already) are not added again, so that circular group references terminate.
+func (me *schemaComponents) addParticleFields(fields *[]*contentField, inherited bool, min, max int64, all *All, chs []*Choice, grs []*Group, seqs []*Sequence, busy map[*Group]bool) {
+	if all != nil {
+		amin, amax := occurs(min, max, &all.hasAttrMinOccurs, &all.hasAttrMaxOccurs)
+		for _, el := range all.Elements {
+			me.addElementField(fields, el, inherited, amin, amax)
+		}
+	}
+	for _, ch := range chs {
+		cmin, cmax := occurs(min, max, &ch.hasAttrMinOccurs, &ch.hasAttrMaxOccurs)
+		if len(ch.Elements)+len(ch.Anys)+len(ch.Choices)+len(ch.Groups)+len(ch.Sequences) > 1 {
+			cmin = 0
+		}
+		for _, el := range ch.Elements {
+			me.addElementField(fields, el, inherited, cmin, cmax)
+		}
+		for _, any := range ch.Anys {
+			amin, amax := occurs(cmin, cmax, &any.hasAttrMinOccurs, &any.hasAttrMaxOccurs)
+			addContentField(fields, &contentField{Name: "any", Decl: any, MinOccurs: amin, MaxOccurs: amax, inherited: inherited})
+		}
+		me.addParticleFields(fields, inherited, cmin, cmax, nil, ch.Choices, ch.Groups, ch.Sequences, busy)
+	}
+	for _, gr := range grs {
+		gmin, gmax := occurs(min, max, &gr.hasAttrMinOccurs, &gr.hasAttrMaxOccurs)
+		if ref := gr.Ref.String(); len(ref) > 0 {
+			if gr = me.groups[ownerSchema(gr).qname(ref)]; (gr == nil) || busy[gr] {
+				continue
+			}
+		}
+		var chs []*Choice
+		var seqs []*Sequence
+		if gr.Choice != nil {
+			chs = append(chs, gr.Choice)
+		}
+		if gr.Sequence != nil {
+			seqs = append(seqs, gr.Sequence)
+		}
+		busy[gr] = true
+		me.addParticleFields(fields, inherited, gmin, gmax, gr.All, chs, nil, seqs, busy)
+		delete(busy, gr)
+	}
+	for _, seq := range seqs {
+		smin, smax := occurs(min, max, &seq.hasAttrMinOccurs, &seq.hasAttrMaxOccurs)
+		for _, p := range seq.Particles() {
+			switch particle := p.(type) {
+			case *Element:
+				me.addElementField(fields, particle, inherited, smin, smax)
+			case *Any:
+				amin, amax := occurs(smin, smax, &particle.hasAttrMinOccurs, &particle.hasAttrMaxOccurs)
+				addContentField(fields, &contentField{Name: "any", Decl: particle, MinOccurs: amin, MaxOccurs: amax, inherited: inherited})
+			case *Choice:
+				me.addParticleFields(fields, inherited, smin, smax, nil, []*Choice{particle}, nil, nil, busy)
+			case *Group:
+				me.addParticleFields(fields, inherited, smin, smax, nil, nil, []*Group{particle}, nil, busy)
+			case *Sequence:
+				me.addParticleFields(fields, inherited, smin, smax, nil, nil, nil, []*Sequence{particle}, busy)
+			}
+		}
+	}
+}
+
+func (me *schemaComponents) addElementField(fields *[]*contentField, el *Element, inherited bool, min, max int64) {
+	var name, particle = el.Name.String(), el
+	emin, emax := occurs(min, max, &el.hasAttrMinOccurs, &el.hasAttrMaxOccurs)
+	if emax == 0 {
+		return
+	}
+	if ref := el.Ref.String(); len(ref) > 0 {
+		qn := ownerSchema(el).qname(ref)
+		if name = qn.Local; me.elements[qn] != nil {
+			el = me.elements[qn]
+		}
+	}
+	addContentField(fields, &contentField{Name: name, Decl: el, MinOccurs: emin, MaxOccurs: emax, inherited: inherited, particle: particle})
+}
+
+//	Returns the xs:any and xs:anyAttribute wildcards of the content and attributes of ct (including those of the groups and attribute groups it refers to), other than those inherited from its base type.
+func (me *schemaComponents) wildcards(ct *ComplexType) (anys []*Any, anyAtts []*AnyAttribute) {
+	for _, field := range me.contentFields(ct) {
+		if any, ok := field.Decl.(*Any); ok && !field.inherited {
+			anys = append(anys, any)
+		}
+	}
+	me.addAnyAttributes(&anyAtts, ct.AnyAttributes, ct.AttributeGroups, map[*AttributeGroup]bool{})
+	if cc := ct.ComplexContent; cc != nil {
+		if ext := cc.ExtensionComplexContent; ext != nil {
+			me.addAnyAttributes(&anyAtts, ext.AnyAttributes, ext.AttributeGroups, map[*AttributeGroup]bool{})
+		}
+		if rest := cc.RestrictionComplexContent; rest != nil {
+			me.addAnyAttributes(&anyAtts, rest.AnyAttributes, rest.AttributeGroups, map[*AttributeGroup]bool{})
+		}
+	}
+	if sc := ct.SimpleContent; sc != nil {
+		if ext := sc.ExtensionSimpleContent; ext != nil {
+			me.addAnyAttributes(&anyAtts, ext.AnyAttributes, ext.AttributeGroups, map[*AttributeGroup]bool{})
+		}
+		if rest := sc.RestrictionSimpleContent; rest != nil {
+			me.addAnyAttributes(&anyAtts, rest.AnyAttributes, rest.AttributeGroups, map[*AttributeGroup]bool{})
+		}
+	}
+	return
+}
+
+func (me *schemaComponents) addAnyAttributes(anyAtts *[]*AnyAttribute, aas []*AnyAttribute, agrs []*AttributeGroup, busy map[*AttributeGroup]bool) {
+	*anyAtts = append(*anyAtts, aas...)
+	for _, agr := range agrs {
+		if ref := agr.Ref.String(); len(ref) > 0 {
+			if ga := me.attributeGroups[ownerSchema(agr).qname(ref)]; (ga != nil) && !busy[ga] {
+				busy[ga] = true
+				me.addAnyAttributes(anyAtts, ga.AnyAttributes, ga.AttributeGroups, busy)
+				delete(busy, ga)
+			}
+		} else {
+			me.addAnyAttributes(anyAtts, agr.AnyAttributes, agr.AttributeGroups, busy)
+		}
+	}
+}
+
+//	Returns the number of occurrences of a particle with the specified minOccurs and maxOccurs, given that its enclosing particles occur between min and max times (-1 if unbounded).
+func occurs(min, max int64, minOccurs *hasAttrMinOccurs, maxOccurs *hasAttrMaxOccurs) (int64, int64) {
+	pmin, pmax := int64(minOccurs.Value()), int64(maxOccurs.Value())
+	if (max < 0) || (pmax < 0) {
+		return min * pmin, -1
+	}
+	return min * pmin, max * pmax
+}
+
+//	Returns the documentation in this annotation (if any, as this may be nil) as a single string of trimmed lines.
+func (me *Annotation) docText() string {
+	var lines []string
+	if me != nil {
+		for _, doc := range PkgGen.selectDocumentations(me.Documentations) {
+			for _, ln := range strings.Split(doc.CDATA, "\n") {
+				if ln = strings.TrimSpace(ln); len(ln) > 0 {
+					lines = append(lines, ln)
+				}
+			}
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+//	Returns those of docs in the language of DocLanguage (see there), or all of docs if it is empty.
+func (me *Generator) selectDocumentations(docs []*Documentation) []*Documentation {
+	var matched, neutral []*Documentation
+	if (len(me.DocLanguage) == 0) || (len(docs) < 2) {
+		return docs
+	}
+	for _, doc := range docs {
+		if lang := doc.language(); len(lang) == 0 {
+			neutral = append(neutral, doc)
+		} else if want := strings.ToLower(me.DocLanguage); (lang == want) || strings.HasPrefix(lang, want+"-") {
+			matched = append(matched, doc)
+		}
+	}
+	if len(matched) > 0 {
+		return matched
+	} else if len(neutral) > 0 {
+		return neutral
+	}
+	return docs
+}
+
+//	Returns the lower-cased language of this documentation: its xml:lang attribute, or else that of its schema document, if any.
+func (me *Documentation) language() string {
+	if len(me.Lang) > 0 {
+		return strings.ToLower(me.Lang.String())
+	} else if sd := ownerSchema(me); sd != nil {
+		return strings.ToLower(sd.Lang.String())
+	}
+	return ""
+}
+
+//	Returns name, or else name suffixed with "_2", "_3" etc. if name is already taken, and marks the returned name as taken.
+func uniqueName(name string, taken map[string]bool) string {
+	var n = name
+	for i := 2; taken[n]; i++ {
+		n = sfmt("%s_%d", name, i)
+	}
+	taken[n] = true
+	return n
+}
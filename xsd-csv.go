@@ -0,0 +1,122 @@
+package xsd
+
+import (
+	"strings"
+
+	"github.com/metaleap/go-util-str"
+)
+
+//	A CSV column of a flat record type (see PkgBag.csvColumns), for a field of its struct type.
+type csvColumn struct {
+	Name     string // the local name of the attribute or element of the field
+	field    *dtoField
+	builtin  string // the xsdt type that the type of the field ultimately derives from, see PkgBag.builtinBaseType
+	required bool   // whether the attribute or element must occur, so that its column must be present
+}
+
+//	Renders (see PkgGen.AddCSV) MarshalCSV() and UnmarshalCSV() methods for the struct type of every global element whose only attribute or element is a
+//	repeating element of a flat record type (see csvColumns), along with an XsdGoPkgCSVColumns_ variable and MarshalCSVRecord() and UnmarshalCSVRecord()
+//	methods for the record type, after all types are rendered so that the final type names of their embeds and fields are known.
+func (me *PkgBag) addCSV() {
+	var done = map[string]bool{}
+	for _, tn := range me.sortedTypeNames() {
+		dt := me.declTypes[tn]
+		if el, _ := dt.elem.(*Element); (el == nil) || !isGlobal(el) || !dt.rendered || (len(dt.EquivalentTo) > 0) || !strings.HasPrefix(tn, idPrefix+"HasElem_") || (len(dt.Fields) != 1) {
+			continue
+		}
+		var root string
+		for _, f := range dt.Fields {
+			root = strings.TrimPrefix(f.typeName(me), "*")
+		}
+		if done[root] || !me.isCSVType(root, "MarshalCSV", "UnmarshalCSV") {
+			continue
+		}
+		fields := me.dtoFields(me.declTypes[root])
+		if (len(fields) != 1) || (fields[0].field == nil) || !strings.HasPrefix(fields[0].tn, "[]") {
+			continue
+		}
+		rec := strings.TrimPrefix(fields[0].tn, "[]")
+		recPtr := strings.HasPrefix(rec, "*")
+		if rec = strings.TrimPrefix(rec, "*"); !me.isCSVType(rec, "MarshalCSVRecord", "UnmarshalCSVRecord") {
+			continue
+		}
+		cols := me.csvColumns(me.declTypes[rec])
+		if len(cols) == 0 {
+			continue
+		}
+		done[root], me.impsUsed[me.impName] = true, true
+		ioImp := me.overrideImpName("io")
+		me.impsUsed[ioImp] = true
+		colsVar := idPrefix + "CSVColumns_" + rec
+		var names, marshals, cases, required []string
+		for _, col := range cols {
+			names, marshals = append(names, sfmt("%q", col.Name)), append(marshals, sfmt("%s.CSVValue(&me.%s)", me.impName, col.field.access))
+			cases = append(cases, sfmt("\t\tcase %q:\n\t\t\terr = %s.ParseCSVValue(&me.%s, %q, values[i], %v)", col.Name, me.impName, col.field.access, col.builtin, !col.required))
+			if col.required {
+				required = append(required, sfmt("%q", col.Name))
+			}
+		}
+		if !done[rec] {
+			done[rec] = true
+			me.renderSplit(me.declTypes[rec].elem, func() {
+				me.appendFmt(false, "//\tThe names of the CSV columns of %s records (see %s.MarshalCSVRecord): those of its attributes and elements.", rec, rec)
+				me.appendFmt(true, "var %s = []string{%s}", colsVar, strings.Join(names, ", "))
+				me.appendFmt(false, "//\tReturns the values of the CSV columns (see %s) of this %s, empty for absent optional attributes and elements.", colsVar, rec)
+				me.appendFmt(true, "func (me *%s) MarshalCSVRecord () []string {\n\treturn []string{%s}\n}", rec, strings.Join(marshals, ", "))
+				me.appendFmt(false, "//\tSets the attributes and elements of this %s from the values of the CSV columns named by columns (see %s), checking them against the", rec, colsVar)
+				me.appendFmt(false, "//\tlexical forms and facets of their types. Empty values leave optional attributes and elements absent, and columns of other names are ignored.")
+				me.appendFmt(false, "//\tErrors are returned as *%s.CSVError (whose Row is not set).", me.impName)
+				me.appendFmt(true, "func (me *%s) UnmarshalCSVRecord (columns, values []string) (err error) {\n\tfor i := 0; (i < len(columns)) && (i < len(values)); i++ {\n\t\tswitch columns[i] {\n%s\n\t\t}\n\t\tif err != nil {\n\t\t\treturn &%s.CSVError{Column: columns[i], Err: err}\n\t\t}\n\t}\n\treturn\n}", rec, strings.Join(cases, "\n"), me.impName)
+			})
+		}
+		access := fields[0].access
+		me.renderSplit(me.declTypes[root].elem, func() {
+			me.appendFmt(false, "//\tEncodes %s (see %s.MarshalCSVRecord) as CSV data to w, preceded by a header row naming the columns (see %s).", access, rec, colsVar)
+			me.appendFmt(false, "//\tRecords not satisfying the facets of their types are rejected with an *%s.CSVError.", me.impName)
+			me.appendFmt(true, "func (me *%s) MarshalCSV (w %s.Writer) (err error) {\n\trecords := make([][]string, 0, len(me.%s))\n\tfor i := range me.%s {\n\t\trec := %sme.%s[i]%s\n\t\tif err = %s.ValidateValue(rec); err != nil {\n\t\t\treturn &%s.CSVError{Row: len(records) + 2, Err: err}\n\t\t}\n\t\trecords = append(records, rec.MarshalCSVRecord())\n\t}\n\treturn %s.WriteCSV(w, %s, records)\n}",
+				root, ioImp, access, access, ustr.Ifs(recPtr, "", "&"), access, ustr.Ifs(recPtr, "\n\t\tif rec == nil {\n\t\t\tcontinue\n\t\t}", ""), me.impName, me.impName, me.impName, colsVar)
+			me.appendFmt(false, "//\tDecodes the records of the CSV data r, whose header row names their columns (see %s), and appends them to %s (see %s.UnmarshalCSVRecord).", colsVar, access, rec)
+			me.appendFmt(false, "//\tErrors are returned as *%s.CSVError, those of reading the CSV data as they are.", me.impName)
+			me.appendFmt(true, "func (me *%s) UnmarshalCSV (r %s.Reader) error {\n\treturn %s.ReadCSV(r, %s, %s, func(header, values []string) (err error) {\n\t\tvar rec %s\n\t\tif err = rec.UnmarshalCSVRecord(header, values); err == nil {\n\t\t\tme.%s = append(me.%s, %srec)\n\t\t}\n\t\treturn\n\t})\n}",
+				root, ioImp, me.impName, colsVar, ustr.Ifs(len(required) == 0, "nil", "[]string{"+strings.Join(required, ", ")+"}"), rec, access, access, ustr.Ifs(recPtr, "&", ""))
+		})
+	}
+}
+
+//	Returns whether the Go type tn is a struct type of this package that may get CSV methods: one rendered for a complex type, and has no fields of the specified method names.
+func (me *PkgBag) isCSVType(tn string, methodNames ...string) bool {
+	dt := me.declTypes[tn]
+	if (dt == nil) || !dt.rendered || (len(dt.EquivalentTo) > 0) || (len(dt.Type) > 0) {
+		return false
+	}
+	for _, name := range methodNames {
+		if dt.Fields[name] != nil {
+			return false
+		}
+	}
+	_, isCt := dt.elem.(*ComplexType)
+	return isCt
+}
+
+//	Returns the CSV columns of the struct type dt if it is a flat record type, or else nil: one for each of the fields of its DTO (see dtoFields), all of which
+//	must be fields of attributes or elements (rather than embedded simple content) that occur at most once, and are of plain types (see isPlainType) or types
+//	encoding their values as text (such as typed built-in types), with distinct XML local names.
+func (me *PkgBag) csvColumns(dt *declType) (cols []*csvColumn) {
+	var names = map[string]bool{}
+	for _, f := range me.dtoFields(dt) {
+		tn := strings.TrimPrefix(f.tn, "*")
+		if (f.field == nil) || strings.HasPrefix(tn, "[]") || !(me.isPlainType(tn) || me.textTypes[tn]) {
+			return nil
+		}
+		name := f.field.XmlTag
+		if pos := strings.Index(name, ","); pos >= 0 {
+			name = name[:pos]
+		}
+		if name = name[strings.LastIndex(name, " ")+1:]; (len(name) == 0) || (name == "-") || names[name] {
+			return nil
+		}
+		names[name] = true
+		cols = append(cols, &csvColumn{Name: name, field: f, builtin: me.builtinBaseType(tn), required: !(f.field.optional() || strings.HasPrefix(f.tn, "*"))})
+	}
+	return
+}
@@ -0,0 +1,56 @@
+package xsd
+
+import (
+	"strings"
+	"testing"
+)
+
+//	Tests that PkgGen.AddCSV gives only global elements repeating a flat record type CSV methods, which decode and encode records by column name,
+//	leaving empty optional values absent, and report invalid CSV data and records as *xsdt.CSVErrors.
+func TestCSVRecords(t *testing.T) {
+	src, _ := genTestSrc(t, "csv", "batch.xsd", func(opts *GenOptions) { opts.AddCSV = true })
+	if !strings.Contains(src, "var XsdGoPkgCSVColumns_TLine = []string{\"qty\", \"note\", \"sku\", \"price\"}") || (strings.Count(src, ") MarshalCSV(") != 1) {
+		t.Errorf("expected CSV methods for TxsdBatch only in\n%s", src)
+	}
+	gopath, goOutFilePaths := genTestPkgs(t, "csv", func(opts *GenOptions) { opts.AddCSV, opts.AddValidators = true, true })
+	goTestPkg(t, gopath, goOutFilePaths[0], `package go_Batch
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestCSV(t *testing.T) {
+	var batch TxsdBatch
+	if err := batch.UnmarshalCSV(strings.NewReader("sku,qty,price\nA-1,2,1.50\nB-2,3,\n")); err != nil {
+		t.Fatal(err)
+	} else if (len(batch.Lines) != 2) || (batch.Lines[0].Sku != "A-1") || (batch.Lines[1].Qty != 3) {
+		t.Fatalf("unexpected records %#v", batch.Lines)
+	}
+	var buf bytes.Buffer
+	if err := batch.MarshalCSV(&buf); err != nil {
+		t.Fatal(err)
+	} else if buf.String() != "qty,note,sku,price\n2,,A-1,1.50\n3,,B-2,\n" {
+		t.Errorf("unexpected CSV data\n%s", buf.String())
+	}
+	for data, msg := range map[string]string{
+		"":                      "CSV row 1: no header row",
+		"sku,qty,size\n":        "CSV row 1, column size: unknown column",
+		"sku,qty,sku\n":         "CSV row 1, column sku: duplicate column",
+		"sku,price\nA-1,1\n":    "CSV row 1, column qty: missing required column",
+		"sku,qty\nA-1,1\nB-2,x": "CSV row 3, column qty: ",
+		"sku,qty\na-1,1\n":      "CSV row 2, column sku: ",
+	} {
+		var batch TxsdBatch
+		if err := batch.UnmarshalCSV(strings.NewReader(data)); (err == nil) || !strings.HasPrefix(err.Error(), msg) {
+			t.Errorf("%q: expected an error starting with %q, got %v", data, msg, err)
+		}
+	}
+	batch.Lines[1].Sku = "b2"
+	if err := batch.MarshalCSV(&buf); (err == nil) || !strings.HasPrefix(err.Error(), "CSV row 3: ") {
+		t.Errorf("expected the invalid second record to be rejected, got %v", err)
+	}
+}
+`)
+}
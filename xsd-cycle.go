@@ -0,0 +1,59 @@
+package xsd
+
+import (
+	"strings"
+)
+
+//	Returned when loading schema documents that reference each other in a cycle which cannot be resolved. Cycles of xs:includes between schema documents
+//	of the same target namespace, and cycles involving xs:imports, are legitimate and loaded as usual (each schema document only once). Cycles of
+//	xs:includes between schema documents of differing target namespaces, and cycles involving xs:redefines or xs:overrides, are not.
+type CycleError struct {
+	//	The URIs of the schema documents in the cycle, each referencing the next one, the last one being the first one again.
+	Chain []string
+
+	//	The kinds of these references: Kinds[i] is one of DependencyInclude, DependencyRedefine, DependencyOverride or DependencyImport,
+	//	denoting how Chain[i] references Chain[i+1].
+	Kinds []string
+}
+
+//	Implements error, listing the chain, such as "a.xsd -include-> b.xsd -redefine-> a.xsd".
+func (me *CycleError) Error() string {
+	var parts = []string{"invalid cyclic schema references:"}
+	for i, uri := range me.Chain {
+		if parts = append(parts, uri); i < len(me.Kinds) {
+			parts = append(parts, "-"+me.Kinds[i]+"->")
+		}
+	}
+	return strings.Join(parts, " ")
+}
+
+//	A schema document being processed by Schema.onLoad, along with the kind of reference it currently follows (see schemaLoader.follow).
+type schemaLink struct {
+	sd   *Schema
+	kind string
+}
+
+//	Records that sd (being processed by Schema.onLoad) references the schema document at schemaLocation by the specified kind of reference
+//	(DependencyInclude, DependencyRedefine, DependencyOverride or DependencyImport). Returns whether this closes a cycle back to a schema document
+//	still being processed, and a *CycleError if that cycle is invalid.
+func (me *schemaLoader) follow(sd *Schema, kind, schemaLocation string) (cyclic bool, err error) {
+	_, uri := splitUri(schemaLocation, sd.loadUri)
+	me.chain[len(me.chain)-1].kind = kind
+	for i, link := range me.chain {
+		if cyclic = link.sd.loadUri == uri; cyclic {
+			var cycle = &CycleError{}
+			var redefines, imports, mixedNs bool
+			for _, l := range me.chain[i:] {
+				cycle.Chain, cycle.Kinds = append(cycle.Chain, l.sd.loadUri), append(cycle.Kinds, l.kind)
+				redefines = redefines || (l.kind == DependencyRedefine) || (l.kind == DependencyOverride)
+				imports = imports || (l.kind == DependencyImport)
+				mixedNs = mixedNs || (l.sd.TargetNamespace != link.sd.TargetNamespace)
+			}
+			if cycle.Chain = append(cycle.Chain, uri); redefines || (mixedNs && !imports) {
+				err = cycle
+			}
+			return
+		}
+	}
+	return
+}
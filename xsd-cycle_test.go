@@ -0,0 +1,33 @@
+package xsd
+
+import (
+	"context"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+//	Tests that cyclic includes within one namespace and cyclic imports load, while a cycle through an xs:redefine fails with a CycleError listing it.
+func TestCyclicSchemaReferences(t *testing.T) {
+	opts := DefaultGenOptions()
+	opts.Offline = true
+	opts.Resolver = SchemaResolverFunc(func(location, baseUri string) (io.ReadCloser, error) {
+		return os.Open(filepath.Join("testdata", "cycle", path.Base(location)))
+	})
+	load := func(fileName string) error {
+		_, err := NewSchemaCache(0).LoadSchemaWithOptions(context.Background(), "cycle.example.com/"+fileName, false, LoadOptions{Generator: NewGenerator(opts)})
+		return err
+	}
+	for _, fileName := range []string{"a.xsd", "c.xsd"} {
+		if err := load(fileName); err != nil {
+			t.Errorf("%s: %v", fileName, err)
+		}
+	}
+	err := load("e.xsd")
+	if cycle, _ := err.(*CycleError); (cycle == nil) || (strings.Join(cycle.Chain, " ") != "cycle.example.com/e.xsd cycle.example.com/f.xsd cycle.example.com/e.xsd") || (strings.Join(cycle.Kinds, " ") != "redefine include") {
+		t.Errorf("expected a *CycleError for e.xsd -redefine-> f.xsd -include-> e.xsd, got %v", err)
+	}
+}
@@ -0,0 +1,291 @@
+package xsd
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/metaleap/go-util-str"
+
+	xsdt "github.com/metaleap/go-xsd/types"
+)
+
+//	The kinds of DependencyEdges.
+const (
+	//	From a schema document to a schema document it xs:includes.
+	DependencyInclude = "include"
+
+	//	From a schema document to a schema document it xs:redefines.
+	DependencyRedefine = "redefine"
+
+	//	From a schema document to a schema document it xs:overrides.
+	DependencyOverride = "override"
+
+	//	From a schema document to a schema document it xs:imports.
+	DependencyImport = "import"
+
+	//	From a global component to the type named by the type attribute of an element, attribute or alternative declared in it.
+	DependencyType = "type"
+
+	//	From a global component to the type named by the base attribute of an extension or restriction declared in it.
+	DependencyBase = "base"
+
+	//	From a global component to the element, attribute, group or attribute group named by the ref attribute of a particle or attribute declared in it.
+	DependencyRef = "ref"
+
+	//	From a global element to the head of its substitution group.
+	DependencySubstitutionGroup = "substitutionGroup"
+
+	//	From a global component to the simple type named by the itemType attribute of a list declared in it.
+	DependencyItemType = "itemType"
+
+	//	From a global component to a simple type named by the memberTypes attribute of a union declared in it.
+	DependencyMemberType = "memberType"
+)
+
+//	The dependencies between schema documents and their global components, as returned by Schema.DependencyGraph.
+type DependencyGraph struct {
+	//	All schema documents (in the order they are reached via includes and imports) and, following each, the global components it declares.
+	Nodes []*DependencyNode `json:"nodes"`
+
+	//	All dependencies between Nodes, each only once, in the order they are found.
+	Edges []*DependencyEdge `json:"edges"`
+
+	nodes map[string]*DependencyNode
+	edges map[DependencyEdge]bool
+}
+
+//	A schema document or global component in a DependencyGraph.
+type DependencyNode struct {
+	//	Unique within its DependencyGraph: the URI of a schema document, or the kind and (namespace-qualified) name of a
+	//	global component (such as "complexType {urn:example:order}OrderType").
+	ID string `json:"id"`
+
+	//	"schema" for a schema document, or else the XSD element name of the component, such as "complexType" or "element".
+	Kind string `json:"kind"`
+
+	//	The target namespace of the schema document or component.
+	Namespace string `json:"namespace,omitempty"`
+
+	//	The local name of the component, or the file name of the schema document.
+	Name string `json:"name"`
+
+	//	The URI of the schema document (declaring the component).
+	Schema string `json:"schema"`
+}
+
+//	A dependency of one DependencyNode on another.
+type DependencyEdge struct {
+	//	The ID of the depending DependencyNode.
+	From string `json:"from"`
+
+	//	The ID of the depended-on DependencyNode.
+	To string `json:"to"`
+
+	//	One of the Dependency* constants, such as DependencyImport or DependencyType.
+	Kind string `json:"kind"`
+}
+
+//	Returns the dependencies of this schema document and all the schema documents it includes, redefines, overrides or imports (directly or indirectly):
+//	those between the schema documents themselves, and the type, base, ref, substitutionGroup, itemType and memberTypes references between their global components.
+//	References within a local declaration or anonymous type are attributed to the global component containing it. References to built-in XSD types,
+//	and references that resolve to no global component of these schema documents, are omitted.
+func (me *Schema) DependencyGraph() (graph *DependencyGraph) {
+	var schemas []*Schema
+	var done = map[*Schema]bool{}
+	var comps = newSchemaComponents(me)
+	graph = &DependencyGraph{nodes: map[string]*DependencyNode{}, edges: map[DependencyEdge]bool{}}
+	for todo := []*Schema{me}; len(todo) > 0; todo = todo[1:] {
+		if sd := todo[0]; !done[sd] {
+			done[sd], schemas = true, append(schemas, sd)
+			todo = append(append(todo, sd.XMLIncludedSchemas...), sd.XMLImportedSchemas...)
+		}
+	}
+	for _, sd := range schemas {
+		ns := sd.TargetNamespace.String()
+		graph.addNode(&DependencyNode{ID: sd.loadUri, Kind: "schema", Namespace: ns, Name: sd.loadUri[strings.LastIndex(sd.loadUri, "/")+1:], Schema: sd.loadUri})
+		for _, el := range sd.globalAttributes() {
+			graph.addComponent(sd, "attribute", el.Name.String())
+		}
+		for _, el := range sd.globalAttributeGroups() {
+			graph.addComponent(sd, "attributeGroup", el.Name.String())
+		}
+		for _, el := range sd.globalComplexTypes() {
+			graph.addComponent(sd, "complexType", el.Name.String())
+		}
+		for _, el := range sd.globalElements() {
+			graph.addComponent(sd, "element", el.Name.String())
+		}
+		for _, el := range sd.globalGroups() {
+			graph.addComponent(sd, "group", el.Name.String())
+		}
+		for _, el := range sd.globalSimpleTypes() {
+			graph.addComponent(sd, "simpleType", el.Name.String())
+		}
+	}
+	for _, sd := range schemas {
+		for i, inc := range sd.XMLIncludedSchemas {
+			graph.addEdge(sd.loadUri, inc.loadUri, ustr.Ifs(i < len(sd.Includes), DependencyInclude, ustr.Ifs(i < len(sd.Includes)+len(sd.Redefines), DependencyRedefine, DependencyOverride)))
+		}
+		for _, imp := range sd.XMLImportedSchemas {
+			graph.addEdge(sd.loadUri, imp.loadUri, DependencyImport)
+		}
+		sd.Walk(func(node SchemaNode) bool {
+			graph.addReferences(comps, node)
+			return true
+		})
+	}
+	return
+}
+
+func (me *DependencyGraph) addNode(node *DependencyNode) {
+	if _, ok := me.nodes[node.ID]; !ok {
+		me.nodes[node.ID], me.Nodes = node, append(me.Nodes, node)
+	}
+}
+
+func (me *DependencyGraph) addComponent(sd *Schema, kind, name string) {
+	qn := xml.Name{Space: sd.TargetNamespace.String(), Local: name}
+	me.addNode(&DependencyNode{ID: dependencyID(kind, qn), Kind: kind, Namespace: qn.Space, Name: qn.Local, Schema: sd.loadUri})
+}
+
+func (me *DependencyGraph) addEdge(from, to, kind string) {
+	edge := DependencyEdge{From: from, To: to, Kind: kind}
+	if _, ok := me.nodes[to]; ok && (from != to) && !me.edges[edge] {
+		me.edges[edge], me.Edges = true, append(me.Edges, &edge)
+	}
+}
+
+//	Adds the edges for the references made by the construct of node, from the global component containing it.
+func (me *DependencyGraph) addReferences(comps *schemaComponents, node SchemaNode) {
+	var from string
+	var owner = ownerSchema(node.Elem.(element))
+	for n := &node; (n != nil) && (len(from) == 0) && (owner != nil); n = n.Parent {
+		from = componentID(n.Elem.(element))
+	}
+	if len(from) == 0 {
+		return
+	}
+	var ref = func(kind, qname string, kinds ...string) {
+		if qn := owner.qname(qname); (len(qname) > 0) && (qn.Space != xsdNamespaceUri) {
+			for _, k := range kinds {
+				if comps.has(k, qn) {
+					me.addEdge(from, dependencyID(k, qn), kind)
+					return
+				}
+			}
+		}
+	}
+	switch el := node.Elem.(type) {
+	case *Alternative:
+		ref(DependencyType, el.Type.String(), "complexType", "simpleType")
+	case *Attribute:
+		ref(DependencyType, el.Type.String(), "simpleType")
+		ref(DependencyRef, el.Ref.String(), "attribute")
+	case *AttributeGroup:
+		ref(DependencyRef, el.Ref.String(), "attributeGroup")
+	case *Element:
+		ref(DependencyType, el.Type.String(), "complexType", "simpleType")
+		ref(DependencyRef, el.Ref.String(), "element")
+		ref(DependencySubstitutionGroup, el.SubstitutionGroup.String(), "element")
+	case *ExtensionComplexContent:
+		ref(DependencyBase, el.Base.String(), "complexType")
+	case *ExtensionSimpleContent:
+		ref(DependencyBase, el.Base.String(), "complexType", "simpleType")
+	case *Group:
+		ref(DependencyRef, el.Ref.String(), "group")
+	case *List:
+		ref(DependencyItemType, el.ItemType.String(), "simpleType")
+	case *RestrictionComplexContent:
+		ref(DependencyBase, el.Base.String(), "complexType")
+	case *RestrictionSimpleContent:
+		ref(DependencyBase, el.Base.String(), "complexType", "simpleType")
+	case *RestrictionSimpleType:
+		ref(DependencyBase, el.Base.String(), "simpleType")
+	case *Union:
+		for _, mt := range strings.Fields(el.MemberTypes) {
+			ref(DependencyMemberType, mt, "simpleType")
+		}
+	}
+}
+
+//	Returns the DependencyNode ID of el if it is a named global attribute, attribute group, complex type, element, group or simple type, or else "".
+func componentID(el element) (id string) {
+	var name xsdt.NCName
+	if owner := ownerSchema(el); (owner != nil) && isGlobal(el) {
+		switch decl := el.(type) {
+		case *Attribute:
+			name = decl.Name
+		case *AttributeGroup:
+			name = decl.Name
+		case *ComplexType:
+			name = decl.Name
+		case *Element:
+			name = decl.Name
+		case *Group:
+			name = decl.Name
+		case *SimpleType:
+			name = decl.Name
+		}
+		if len(name) > 0 {
+			id = dependencyID(el.base().xsdName.String(), xml.Name{Space: owner.TargetNamespace.String(), Local: name.String()})
+		}
+	}
+	return
+}
+
+func dependencyID(kind string, qn xml.Name) string {
+	return kind + " " + diffName(qn)
+}
+
+//	Writes this graph as a Graphviz DOT digraph, with the global components of each schema document clustered together with it.
+//	Edges between schema documents are bold (and dashed for imports), those between components are labeled with their kinds.
+func (me *DependencyGraph) WriteDOT(w io.Writer) (err error) {
+	var cluster int
+	var lines = []string{"digraph schemas {", "\trankdir=LR;", "\tnode [shape=box, fontsize=10];", "\tedge [fontsize=8];"}
+	for i, node := range me.Nodes {
+		if node.Kind == "schema" {
+			if cluster++; i > 0 {
+				lines = append(lines, "\t}")
+			}
+			lines = append(lines, sfmt("\tsubgraph cluster_%d {", cluster), sfmt("\t\tlabel=%s;", dotQuote(node.Schema)))
+			lines = append(lines, sfmt("\t\t%s [shape=folder, label=%s];", dotQuote(node.ID), dotQuote(node.Name)))
+		} else {
+			lines = append(lines, sfmt("\t\t%s [label=\"%s\\n%s\"];", dotQuote(node.ID), dotEscape(node.Kind), dotEscape(node.Name)))
+		}
+	}
+	if cluster > 0 {
+		lines = append(lines, "\t}")
+	}
+	for _, edge := range me.Edges {
+		switch edge.Kind {
+		case DependencyImport:
+			lines = append(lines, sfmt("\t%s -> %s [style=\"bold,dashed\", label=%s];", dotQuote(edge.From), dotQuote(edge.To), dotQuote(edge.Kind)))
+		case DependencyInclude, DependencyRedefine, DependencyOverride:
+			lines = append(lines, sfmt("\t%s -> %s [style=bold, label=%s];", dotQuote(edge.From), dotQuote(edge.To), dotQuote(edge.Kind)))
+		default:
+			lines = append(lines, sfmt("\t%s -> %s [label=%s];", dotQuote(edge.From), dotQuote(edge.To), dotQuote(edge.Kind)))
+		}
+	}
+	_, err = fmt.Fprintln(w, strings.Join(append(lines, "}"), "\n"))
+	return
+}
+
+//	Writes this graph as a JSON object with "nodes" and "edges" arrays (see DependencyNode and DependencyEdge).
+func (me *DependencyGraph) WriteJSON(w io.Writer) (err error) {
+	var raw []byte
+	if raw, err = json.MarshalIndent(me, "", "  "); err == nil {
+		_, err = fmt.Fprintln(w, string(raw))
+	}
+	return
+}
+
+func dotQuote(s string) string {
+	return "\"" + dotEscape(s) + "\""
+}
+
+func dotEscape(s string) string {
+	return strings.NewReplacer("\\", "\\\\", "\"", "\\\"", "\n", "\\n").Replace(s)
+}
@@ -0,0 +1,51 @@
+package xsd
+
+import (
+	"bytes"
+	"encoding/json"
+	"path"
+	"strings"
+	"testing"
+)
+
+//	Tests that a DependencyGraph holds the include and import edges between schema documents and the type references between their components,
+//	and that its JSON and DOT renderings hold all of them.
+func TestDependencyGraph(t *testing.T) {
+	var edges []string
+	var decoded DependencyGraph
+	var dot, js bytes.Buffer
+	graph := loadTestSchema(t, "schemadir", "order.xsd").DependencyGraph()
+	short := func(id string) string {
+		if strings.Contains(id, "/") {
+			return path.Base(id)
+		}
+		return id
+	}
+	for _, edge := range graph.Edges {
+		edges = append(edges, short(edge.From)+" -"+edge.Kind+"-> "+short(edge.To))
+	}
+	expected := []string{
+		"order.xsd -import-> money.xsd",
+		"element {urn:example:orders}total -type-> complexType {urn:example:common}Money",
+		"money.xsd -include-> currency.xsd",
+		"complexType {urn:example:common}Money -type-> simpleType {urn:example:common}Currency",
+	}
+	if strings.Join(edges, "\n") != strings.Join(expected, "\n") {
+		t.Errorf("expected the edges\n%s\ngot\n%s", strings.Join(expected, "\n"), strings.Join(edges, "\n"))
+	}
+	if err := graph.WriteJSON(&js); err != nil {
+		t.Fatal(err)
+	} else if err = json.Unmarshal(js.Bytes(), &decoded); err != nil {
+		t.Fatal(err)
+	} else if (len(decoded.Nodes) != 6) || (len(decoded.Edges) != len(expected)) {
+		t.Errorf("expected 6 nodes and %d edges in %s", len(expected), js.String())
+	}
+	if err := graph.WriteDOT(&dot); err != nil {
+		t.Fatal(err)
+	}
+	for _, line := range []string{"subgraph cluster_3 {", `"element {urn:example:orders}total" -> "complexType {urn:example:common}Money" [label="type"];`, `[style="bold,dashed", label="import"];`} {
+		if !strings.Contains(dot.String(), line) {
+			t.Errorf("expected %s in\n%s", line, dot.String())
+		}
+	}
+}
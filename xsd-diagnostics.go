@@ -0,0 +1,149 @@
+package xsd
+
+import (
+	"encoding/xml"
+	"io"
+	"strings"
+)
+
+//	The severity of a Diagnostic.
+type Severity int
+
+const (
+	//	The generated Go code is usable, but may not fully represent the offending schema construct.
+	SeverityWarning Severity = iota
+
+	//	The generated Go code is likely incomplete or does not compile.
+	SeverityError
+
+	//	Merely informational, such as a name generated as a different Go identifier (see PkgGen.Identifiers).
+	SeverityInfo
+)
+
+//	Returns "warning", "error" or "info".
+func (me Severity) String() string {
+	switch me {
+	case SeverityError:
+		return "error"
+	case SeverityInfo:
+		return "info"
+	}
+	return "warning"
+}
+
+//	A problem encountered while generating Go code from a schema, such as an unresolvable QName, an unsupported construct or a duplicate name.
+type Diagnostic struct {
+	//	The local file path (or else the URI) of the schema document containing the offending construct.
+	File string
+
+	//	The position in that schema document right after the start tag of the offending construct, or 0 if unknown.
+	Line, Column int
+
+	Severity Severity
+
+	Message string
+
+	//	If the Diagnostic reports a construct that the generated Go code does not represent (see Diagnostics.Ignored), the XSD element name
+	//	of that construct, such as "key", "keyref", "assert", "assertion", "alternative" or "pattern"; otherwise "".
+	Ignored string
+
+	//	If the Diagnostic was reported by Lint, the name of the rule flagging the construct, such as "missing-docs" (see LintRules); otherwise "".
+	Rule string
+}
+
+//	Returns a description of this Diagnostic in the customary file:line:column: form.
+func (me *Diagnostic) Error() string {
+	return sfmt("%s:%d:%d: %s: %s", me.File, me.Line, me.Column, me.Severity, me.Message)
+}
+
+//	All Diagnostics reported while generating Go code, in the order they were reported.
+type Diagnostics []*Diagnostic
+
+//	Returns all Diagnostics, one per line.
+func (me Diagnostics) Error() string {
+	var lines []string
+	for _, d := range me {
+		lines = append(lines, d.Error())
+	}
+	return strings.Join(lines, "\n")
+}
+
+//	Returns only those Diagnostics of SeverityError.
+func (me Diagnostics) Errors() (errs Diagnostics) {
+	for _, d := range me {
+		if d.Severity == SeverityError {
+			errs = append(errs, d)
+		}
+	}
+	return
+}
+
+//	Returns only those Diagnostics (all of SeverityWarning) that report schema constructs that did not influence the generated Go code:
+//	identity constraints (other than those that PkgGen.AddKeyIndexes generates index methods for), assertions and type alternatives (which
+//	are merely documented), the facets of simpleContent restrictions, and the facets of simple types if PkgGen.AddValidators is off
+//	(other than enumerations) or if they consist of nothing but a whiteSpace facet.
+func (me Diagnostics) Ignored() (ignored Diagnostics) {
+	for _, d := range me {
+		if len(d.Ignored) > 0 {
+			ignored = append(ignored, d)
+		}
+	}
+	return
+}
+
+//	Returns whether any of the Diagnostics is of SeverityError.
+func (me Diagnostics) HasErrors() bool {
+	return len(me.Errors()) > 0
+}
+
+//	An xml.TokenReader that passes through the tokens of an underlying xml.Decoder, recording on the way the root element's attributes and the positions of all elements, keyed by their element paths (see elemBase.path).
+//	This way, a schema document is decoded and its element positions are recorded in one single pass over its source, without buffering it in memory.
+//	If strict (see LoadOptions), the unknown elements and attributes are recorded on the way, too (see checkVocab).
+type positionRecorder struct {
+	gen       *Generator // whose settings govern the decoding of entities, see Generator.newSchemaDecoder
+	xd        *xml.Decoder
+	paths     []string
+	counts    []map[string]int
+	positions map[string][2]int
+	rootAtts  []xml.Attr
+
+	strict   bool
+	vocabs   []*xsdVocab
+	names    []string
+	unknowns Diagnostics
+}
+
+func newPositionRecorder(gen *Generator, r io.Reader, strict bool) *positionRecorder {
+	return &positionRecorder{gen: gen, xd: gen.newSchemaDecoder(r), paths: []string{""}, counts: []map[string]int{{}}, positions: map[string][2]int{}, strict: strict}
+}
+
+//	Implements xml.TokenReader.
+func (me *positionRecorder) Token() (t xml.Token, err error) {
+	if t, err = me.xd.Token(); err == nil {
+		switch tok := t.(type) {
+		case xml.StartElement:
+			var p string
+			if len(me.paths) > 1 {
+				name := strings.ToLower(tok.Name.Local)
+				p = sfmt("%s/%s[%d]", me.paths[len(me.paths)-1], name, me.counts[len(me.counts)-1][name])
+				me.counts[len(me.counts)-1][name]++
+			} else {
+				me.rootAtts = tok.Attr
+			}
+			line, col := me.xd.InputPos()
+			me.positions[p], me.paths, me.counts = [2]int{line, col}, append(me.paths, p), append(me.counts, map[string]int{})
+			if me.strict {
+				me.checkVocab(tok, line, col)
+			}
+		case xml.Directive:
+			me.gen.declareDocTypeEntities(me.xd.Entity, tok)
+		case xml.EndElement:
+			me.paths, me.counts = me.paths[:len(me.paths)-1], me.counts[:len(me.counts)-1]
+			if me.strict {
+				me.vocabs, me.names = me.vocabs[:len(me.vocabs)-1], me.names[:len(me.names)-1]
+			}
+		}
+		t = xml.CopyToken(t)
+	}
+	return
+}
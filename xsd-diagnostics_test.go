@@ -0,0 +1,45 @@
+package xsd
+
+import (
+	"encoding/xml"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"testing/iotest"
+)
+
+func TestUnresolvableTypeDiagnostic(t *testing.T) {
+	_, diags := genTestSrc(t, "diagnostics", "broken.xsd", nil)
+	if errs := diags.Errors(); (len(errs) != 1) || !diags.HasErrors() {
+		t.Fatalf("expected 1 error diagnostic, got %v", diags)
+	} else if d := errs[0]; (filepath.Base(d.File) != "broken.xsd") || (d.Line != 5) || (d.Column != 49) || !strings.Contains(d.Message, "TCustomer") {
+		t.Errorf("expected the unresolvable type Customer reported at broken.xsd:5:49, got %v", d)
+	} else if msg := d.Error(); !strings.HasSuffix(msg, "broken.xsd:5:49: error: "+d.Message) {
+		t.Errorf("unexpected Error(): %s", msg)
+	}
+}
+
+func TestPositionRecorderDecodesInOnePass(t *testing.T) {
+	var sd Schema
+	file, err := os.Open(filepath.Join("testdata", "diagnostics", "broken.xsd"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+	rec := newPositionRecorder(NewGenerator(DefaultGenOptions()), iotest.OneByteReader(file), false)
+	if err = xml.NewTokenDecoder(rec).Decode(&sd); err != nil {
+		t.Fatal(err)
+	}
+	if (len(sd.ComplexTypes) != 1) || (sd.TargetNamespace != "urn:example:diagnostics") {
+		t.Errorf("the schema document was not decoded: %#v", sd)
+	}
+	if (len(rec.rootAtts) != 4) || (rec.rootAtts[2].Name.Local != "targetNamespace") {
+		t.Errorf("unexpected root attributes %v", rec.rootAtts)
+	}
+	for p, line := range map[string]int{"": 2, "/complextype[0]": 3, "/complextype[0]/sequence[0]/element[0]": 5, "/element[0]": 8} {
+		if pos, ok := rec.positions[p]; !ok || (pos[0] != line) {
+			t.Errorf("expected %q at line %d, got %v", p, line, pos)
+		}
+	}
+}
@@ -0,0 +1,429 @@
+package xsd
+
+import (
+	"encoding/xml"
+	"math/big"
+	"sort"
+	"strings"
+
+	"github.com/metaleap/go-util-str"
+
+	xsdt "github.com/metaleap/go-xsd/types"
+)
+
+//	The kinds of SchemaChange reported by Diff.
+const (
+	//	A global component, or an element or attribute of a complex type's content, exists only in the new schema.
+	ChangeAdded = "added"
+
+	//	A global component, or an element or attribute of a complex type's content, exists only in the old schema.
+	ChangeRemoved = "removed"
+
+	//	A global component exists only in the old schema, and one of the same kind and namespace and with the same definition exists only in the new schema.
+	ChangeRenamed = "renamed"
+
+	//	The type of an element or attribute, or the base or variety of a simple type, differs.
+	ChangeType = "type"
+
+	//	The number of occurrences of an element (or, as 0..1 or 1..1, the use of an attribute) differs.
+	ChangeCardinality = "cardinality"
+
+	//	A constraining facet of a simple type differs.
+	ChangeFacet = "facet"
+
+	//	The nillable property of an element differs.
+	ChangeNillable = "nillable"
+)
+
+//	A single difference between two versions of a schema, as reported by Diff.
+type SchemaChange struct {
+	//	One of the Change* constants.
+	Kind string
+
+	//	Denotes the changed schema component, such as "complexType {urn:example:order}OrderType/element item",
+	//	"complexType {urn:example:order}OrderType/@id" or (for ChangeFacet) "simpleType {urn:example:order}SkuType/maxLength".
+	Path string
+
+	//	The old and new name, type, cardinality (such as "1..unbounded") or facet value (for enumerations, the removed or added value), if any.
+	Old, New string
+
+	//	Whether instance documents that are valid against the old schema may be invalid against the new one. Note that the generated Go code
+	//	can change incompatibly even if this is false, such as when the maximum number of occurrences of an element grows beyond 1.
+	Breaking bool
+}
+
+//	Returns a description of this SchemaChange.
+func (me *SchemaChange) String() (s string) {
+	s = sfmt("%s: %s", me.Kind, me.Path)
+	if (len(me.Old) > 0) || (len(me.New) > 0) {
+		s += sfmt(" (%q -> %q)", me.Old, me.New)
+	}
+	return s + ustr.Ifs(me.Breaking, " [breaking]", "")
+}
+
+//	The changes reported by Diff.
+type SchemaChanges []*SchemaChange
+
+//	Returns those changes that are Breaking.
+func (me SchemaChanges) Breaking() (breaking SchemaChanges) {
+	for _, c := range me {
+		if c.Breaking {
+			breaking = append(breaking, c)
+		}
+	}
+	return
+}
+
+type schemaDiff struct {
+	old, new *schemaComponents
+	renames  map[xml.Name]xml.Name
+	changes  SchemaChanges
+	busy     map[*ComplexType]bool // the anonymous complex types being compared or fingerprinted, which elements of them can recur within
+}
+
+//	Compares the global components of two versions of a schema (each including the schemas it includes and imports), reporting: added, removed and renamed
+//	global elements, attributes, types, groups and attribute groups; and, for global elements, attributes and types in both versions, changed types and
+//	nillability, added and removed elements and attributes of (flattened) complex type content, their changed types and cardinalities, and changed simple-type facets.
+//	Changes are reported in a stable order, and are classified as Breaking if they can make instance documents of the old version invalid.
+func Diff(old, new *Schema) SchemaChanges {
+	var me = &schemaDiff{old: newSchemaComponents(old), new: newSchemaComponents(new), renames: map[xml.Name]xml.Name{}, busy: map[*ComplexType]bool{}}
+	for _, kind := range []string{"simpleType", "complexType", "attributeGroup", "group", "attribute", "element"} {
+		for _, qn := range me.globals(kind) {
+			path := kind + " " + diffName(qn)
+			switch kind {
+			case "simpleType":
+				me.simpleTypes(path, me.old.simpleTypes[qn], me.new.simpleTypes[qn])
+			case "complexType":
+				me.complexTypes(path, me.old.complexTypes[qn], me.new.complexTypes[qn])
+			case "attribute":
+				me.fieldTypes(path, &contentField{Decl: me.old.attributes[qn]}, &contentField{Decl: me.new.attributes[qn]})
+			case "element":
+				me.fieldTypes(path, &contentField{Decl: me.old.elements[qn]}, &contentField{Decl: me.new.elements[qn]})
+				me.nillable(path, me.old.elements[qn], me.new.elements[qn])
+			}
+		}
+	}
+	return me.changes
+}
+
+func (me *schemaDiff) add(kind, path, old, new string, breaking bool) {
+	me.changes = append(me.changes, &SchemaChange{Kind: kind, Path: path, Old: old, New: new, Breaking: breaking})
+}
+
+//	Reports the global components of the specified kind that were added, removed or renamed, and returns the names of those in both versions.
+func (me *schemaDiff) globals(kind string) (common []xml.Name) {
+	var olds, news = me.old.byKind(kind), me.new.byKind(kind)
+	var removed, added []xml.Name
+	for _, qn := range sortedNames(olds) {
+		if news[qn] != nil {
+			common = append(common, qn)
+		} else {
+			removed = append(removed, qn)
+		}
+	}
+	for _, qn := range sortedNames(news) {
+		if olds[qn] == nil {
+			added = append(added, qn)
+		}
+	}
+	var oldPrints, newPrints = map[string][]xml.Name{}, map[string][]xml.Name{}
+	for _, qn := range removed {
+		if fp := me.fingerprint(me.old, kind, olds[qn]); len(fp) > 0 {
+			oldPrints[qn.Space+" "+fp] = append(oldPrints[qn.Space+" "+fp], qn)
+		}
+	}
+	for _, qn := range added {
+		if fp := me.fingerprint(me.new, kind, news[qn]); len(fp) > 0 {
+			newPrints[qn.Space+" "+fp] = append(newPrints[qn.Space+" "+fp], qn)
+		}
+	}
+	for _, qn := range removed {
+		fp := qn.Space + " " + me.fingerprint(me.old, kind, olds[qn])
+		if (len(oldPrints[fp]) == 1) && (len(newPrints[fp]) == 1) {
+			me.renames[qn] = newPrints[fp][0]
+			me.add(ChangeRenamed, kind+" "+diffName(qn), qn.Local, newPrints[fp][0].Local, true)
+		} else {
+			me.add(ChangeRemoved, kind+" "+diffName(qn), "", "", true)
+		}
+	}
+	for _, qn := range added {
+		fp := qn.Space + " " + me.fingerprint(me.new, kind, news[qn])
+		if (len(oldPrints[fp]) != 1) || (len(newPrints[fp]) != 1) {
+			me.add(ChangeAdded, kind+" "+diffName(qn), "", "", false)
+		}
+	}
+	return
+}
+
+//	Returns a description of the definition of el that is equal for equal definitions, or "" for kinds of components that are not checked for renames.
+func (me *schemaDiff) fingerprint(comps *schemaComponents, kind string, el element) string {
+	switch kind {
+	case "simpleType":
+		return me.simpleFingerprint(el.(*SimpleType))
+	case "complexType":
+		var fields []string
+		for _, f := range comps.contentFields(el.(*ComplexType)) {
+			fields = append(fields, sfmt("%s %v %s %s", ustr.Ifs(f.Attr, "@", "")+f.Name, f.MinOccurs, diffOccurs(f.MaxOccurs), me.typeFingerprint(comps, f)))
+		}
+		sort.Strings(fields)
+		return "{" + strings.Join(fields, "; ") + "}"
+	case "element", "attribute":
+		return me.typeFingerprint(comps, &contentField{Decl: el})
+	}
+	return ""
+}
+
+func (me *schemaDiff) simpleFingerprint(st *SimpleType) string {
+	if rest := st.RestrictionSimpleType; rest != nil {
+		return sfmt("restriction %s %+v", diffName(ownerSchema(rest).qname(rest.Base.String())), *rest.facets())
+	} else if st.List != nil {
+		return "list " + diffName(ownerSchema(st).qname(st.List.ItemType.String()))
+	} else if st.Union != nil {
+		return "union " + st.Union.MemberTypes
+	}
+	return "?"
+}
+
+func (me *schemaDiff) typeFingerprint(comps *schemaComponents, f *contentField) string {
+	switch qn, ct, st := diffType(f); {
+	case (ct != nil) && me.busy[ct]:
+		return "(recursive)"
+	case ct != nil:
+		me.busy[ct] = true
+		defer delete(me.busy, ct)
+		return me.fingerprint(comps, "complexType", ct)
+	case st != nil:
+		return me.simpleFingerprint(st)
+	default:
+		return diffName(qn)
+	}
+}
+
+//	Returns the type of the element, attribute or simple content of the specified field: either the name of a named type, or else an anonymous type.
+func diffType(f *contentField) (qn xml.Name, ct *ComplexType, st *SimpleType) {
+	switch decl := f.Decl.(type) {
+	case *Element:
+		if len(decl.Type) > 0 {
+			qn = ownerSchema(decl).qname(decl.Type.String())
+		} else if decl.ComplexType != nil {
+			ct = decl.ComplexType
+		} else if len(decl.SimpleTypes) > 0 {
+			st = decl.SimpleTypes[0]
+		} else {
+			qn = xml.Name{Space: xsdNamespaceUri, Local: "anyType"}
+		}
+	case *Attribute:
+		if len(decl.Type) > 0 {
+			qn = ownerSchema(decl).qname(decl.Type.String())
+		} else if len(decl.SimpleTypes) > 0 {
+			st = decl.SimpleTypes[0]
+		} else {
+			qn = xml.Name{Space: xsdNamespaceUri, Local: "anySimpleType"}
+		}
+	default:
+		if len(f.Base) > 0 {
+			qn = ownerSchema(f.Decl).qname(f.Base)
+		}
+	}
+	return
+}
+
+//	Reports a changed type of the element, attribute or simple content of the specified fields, comparing their anonymous types in turn.
+func (me *schemaDiff) fieldTypes(path string, old, new *contentField) {
+	oldQn, oldCt, oldSt := diffType(old)
+	newQn, newCt, newSt := diffType(new)
+	if renamed, ok := me.renames[oldQn]; ok {
+		oldQn = renamed
+	}
+	switch {
+	case (oldCt != nil) && (newCt != nil):
+		if !me.busy[oldCt] {
+			me.busy[oldCt] = true
+			me.complexTypes(path, oldCt, newCt)
+			delete(me.busy, oldCt)
+		}
+	case (oldSt != nil) && (newSt != nil):
+		me.simpleTypes(path, oldSt, newSt)
+	case (oldCt == nil) && (oldSt == nil) && (newCt == nil) && (newSt == nil):
+		if oldQn != newQn {
+			me.add(ChangeType, path, diffName(oldQn), diffName(newQn), true)
+		}
+	default:
+		me.add(ChangeType, path, ustr.Ifs((oldCt == nil) && (oldSt == nil), diffName(oldQn), "(anonymous type)"), ustr.Ifs((newCt == nil) && (newSt == nil), diffName(newQn), "(anonymous type)"), true)
+	}
+}
+
+func (me *schemaDiff) nillable(path string, old, new element) {
+	if oldEl, newEl := old.(*Element), new.(*Element); oldEl.Nillable != newEl.Nillable {
+		me.add(ChangeNillable, path, sfmt("%v", oldEl.Nillable), sfmt("%v", newEl.Nillable), oldEl.Nillable)
+	}
+}
+
+//	Reports the elements and attributes added to or removed from the flattened content of a complex type, and their changed types and cardinalities.
+func (me *schemaDiff) complexTypes(path string, old, new *ComplexType) {
+	var olds, news = me.old.contentFields(old), me.new.contentFields(new)
+	var newsByKey = map[string]*contentField{}
+	for _, f := range news {
+		newsByKey[diffFieldKey(f)] = f
+	}
+	for _, of := range olds {
+		fpath := path + "/" + diffFieldKey(of)
+		if nf := newsByKey[diffFieldKey(of)]; nf == nil {
+			me.add(ChangeRemoved, fpath, "", "", true)
+		} else {
+			delete(newsByKey, diffFieldKey(of))
+			if (of.MinOccurs != nf.MinOccurs) || (of.MaxOccurs != nf.MaxOccurs) {
+				me.add(ChangeCardinality, fpath, diffCardinality(of), diffCardinality(nf), (nf.MinOccurs > of.MinOccurs) || ((nf.MaxOccurs >= 0) && ((of.MaxOccurs < 0) || (nf.MaxOccurs < of.MaxOccurs))))
+			}
+			if of.Name != "any" {
+				me.fieldTypes(fpath, of, nf)
+			}
+			if oldEl, isEl := of.Decl.(*Element); isEl {
+				if newEl, isEl := nf.Decl.(*Element); isEl {
+					me.nillable(fpath, oldEl, newEl)
+				}
+			}
+		}
+	}
+	for _, nf := range news {
+		if newsByKey[diffFieldKey(nf)] == nf {
+			me.add(ChangeAdded, path+"/"+diffFieldKey(nf), "", "", nf.MinOccurs > 0)
+		}
+	}
+}
+
+//	Reports the changed variety, base type and facets of a simple type.
+func (me *schemaDiff) simpleTypes(path string, old, new *SimpleType) {
+	var oldRest, newRest = old.RestrictionSimpleType, new.RestrictionSimpleType
+	if (oldRest == nil) || (newRest == nil) {
+		if oldFp, newFp := me.simpleFingerprint(old), me.simpleFingerprint(new); oldFp != newFp {
+			me.add(ChangeType, path, oldFp, newFp, true)
+		}
+		return
+	}
+	oldBase, newBase := ownerSchema(oldRest).qname(oldRest.Base.String()), ownerSchema(newRest).qname(newRest.Base.String())
+	if renamed, ok := me.renames[oldBase]; ok {
+		oldBase = renamed
+	}
+	if oldBase != newBase {
+		me.add(ChangeType, path, diffName(oldBase), diffName(newBase), true)
+	}
+	me.facets(path, oldRest.facets(), newRest.facets())
+}
+
+//	Reports the changed facets, classifying those that restrict the value space further as breaking.
+func (me *schemaDiff) facets(path string, oldFacets, newFacets *xsdt.Facets) {
+	var oldEnums, newEnums = map[string]bool{}, map[string]bool{}
+	for _, e := range oldFacets.Enumerations {
+		oldEnums[e] = true
+	}
+	for _, e := range newFacets.Enumerations {
+		newEnums[e] = true
+	}
+	for _, e := range oldFacets.Enumerations {
+		if (len(newFacets.Enumerations) > 0) && !newEnums[e] {
+			me.add(ChangeFacet, path+"/enumeration", e, "", true)
+		}
+	}
+	for _, e := range newFacets.Enumerations {
+		if !oldEnums[e] {
+			me.add(ChangeFacet, path+"/enumeration", "", e, len(oldFacets.Enumerations) == 0)
+		}
+	}
+	for _, facet := range []struct {
+		name, old, new string
+		tighter        int
+	}{
+		{"pattern", oldFacets.Pattern, newFacets.Pattern, 0},
+		{"whiteSpace", oldFacets.WhiteSpace, newFacets.WhiteSpace, 0},
+		{"length", oldFacets.Length, newFacets.Length, 0},
+		{"minLength", oldFacets.MinLength, newFacets.MinLength, 1},
+		{"maxLength", oldFacets.MaxLength, newFacets.MaxLength, -1},
+		{"totalDigits", oldFacets.TotalDigits, newFacets.TotalDigits, -1},
+		{"fractionDigits", oldFacets.FractionDigits, newFacets.FractionDigits, -1},
+		{"minInclusive", oldFacets.MinInclusive, newFacets.MinInclusive, 1},
+		{"maxInclusive", oldFacets.MaxInclusive, newFacets.MaxInclusive, -1},
+		{"minExclusive", oldFacets.MinExclusive, newFacets.MinExclusive, 1},
+		{"maxExclusive", oldFacets.MaxExclusive, newFacets.MaxExclusive, -1},
+	} {
+		if facet.old != facet.new {
+			breaking := len(facet.new) > 0
+			if (len(facet.old) > 0) && breaking && (facet.tighter != 0) {
+				if oldNum, isNum := new(big.Rat).SetString(facet.old); isNum {
+					if newNum, isNum := new(big.Rat).SetString(facet.new); isNum {
+						breaking = newNum.Cmp(oldNum) == facet.tighter
+					}
+				}
+			}
+			me.add(ChangeFacet, path+"/"+facet.name, facet.old, facet.new, breaking)
+		}
+	}
+}
+
+//	Returns the global components of the specified kind, keyed by their names.
+func (me *schemaComponents) byKind(kind string) (els map[xml.Name]element) {
+	els = map[xml.Name]element{}
+	switch kind {
+	case "attribute":
+		for qn, el := range me.attributes {
+			els[qn] = el
+		}
+	case "attributeGroup":
+		for qn, el := range me.attributeGroups {
+			els[qn] = el
+		}
+	case "complexType":
+		for qn, el := range me.complexTypes {
+			els[qn] = el
+		}
+	case "element":
+		for qn, el := range me.elements {
+			els[qn] = el
+		}
+	case "group":
+		for qn, el := range me.groups {
+			els[qn] = el
+		}
+	case "notation":
+		for qn, el := range me.notations {
+			els[qn] = el
+		}
+	case "simpleType":
+		for qn, el := range me.simpleTypes {
+			els[qn] = el
+		}
+	}
+	return
+}
+
+func sortedNames(els map[xml.Name]element) (names []xml.Name) {
+	for qn, _ := range els {
+		names = append(names, qn)
+	}
+	sort.Sort(xmlNames(names))
+	return
+}
+
+type xmlNames []xml.Name
+
+func (me xmlNames) Len() int      { return len(me) }
+func (me xmlNames) Swap(i, j int) { me[i], me[j] = me[j], me[i] }
+func (me xmlNames) Less(i, j int) bool {
+	return (me[i].Space < me[j].Space) || ((me[i].Space == me[j].Space) && (me[i].Local < me[j].Local))
+}
+
+func diffName(qn xml.Name) string {
+	return ustr.Ifs(len(qn.Space) > 0, "{"+qn.Space+"}", "") + qn.Local
+}
+
+func diffFieldKey(f *contentField) string {
+	return ustr.Ifs(f.Attr, "@"+f.Name, ustr.Ifs(f.Name == "value", "value", ustr.Ifs(f.Name == "any", "any", "element "+f.Name)))
+}
+
+func diffCardinality(f *contentField) string {
+	return sfmt("%v..%s", f.MinOccurs, diffOccurs(f.MaxOccurs))
+}
+
+func diffOccurs(max int64) string {
+	return ustr.Ifs(max < 0, "unbounded", sfmt("%v", max))
+}
@@ -0,0 +1,29 @@
+package xsd
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestDiff(t *testing.T) {
+	var actual []string
+	changes := Diff(loadTestSchema(t, "diff/v1", "order.xsd"), loadTestSchema(t, "diff/v2", "order.xsd"))
+	for _, c := range changes {
+		actual = append(actual, c.String())
+	}
+	expected := []string{
+		`facet: simpleType {urn:example:diff}Sku/maxLength ("20" -> "10") [breaking]`,
+		`renamed: complexType {urn:example:diff}Address ("Address" -> "PostalAddress") [breaking]`,
+		`cardinality: complexType {urn:example:diff}Order/element sku ("1..1" -> "1..unbounded")`,
+		`added: complexType {urn:example:diff}Order/element note`,
+	}
+	if strings.Join(actual, "\n") != strings.Join(expected, "\n") {
+		t.Errorf("expected\n%s\ngot\n%s", strings.Join(expected, "\n"), strings.Join(actual, "\n"))
+	}
+	if len(changes.Breaking()) != 2 {
+		t.Errorf("expected 2 breaking changes, got %v", changes.Breaking())
+	}
+	if changes = Diff(loadTestSchema(t, "diff/v2", "order.xsd"), loadTestSchema(t, "diff/v2", "order.xsd")); len(changes) > 0 {
+		t.Errorf("expected no changes between identical schemas, got %v", changes)
+	}
+}
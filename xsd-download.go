@@ -0,0 +1,105 @@
+package xsd
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"os"
+	"time"
+)
+
+//	The suffix of the manifest file recorded next to the local copy of a downloaded schema document (see PkgGen.DownloadTTL).
+const downloadManifestSuffix = ".download.json"
+
+//	Returned by LoadSchema (and all other functions loading schemas) if PkgGen.Offline is set and a schema document would have to be downloaded.
+type OfflineError struct {
+	//	The URI of the schema document.
+	Uri string
+
+	//	The path of the local copy that would have to be revalidated (see PkgGen.DownloadTTL), or empty if there is none.
+	LocalPath string
+}
+
+func (me *OfflineError) Error() string {
+	if len(me.LocalPath) > 0 {
+		return sfmt("offline: the local copy %s of %s would have to be revalidated", me.LocalPath, me.Uri)
+	}
+	return sfmt("offline: %s would have to be downloaded", me.Uri)
+}
+
+//	The manifest recorded next to the local copy of a downloaded schema document (see PkgGen.DownloadTTL).
+type downloadManifest struct {
+	Uri          string    `json:"uri"`
+	ETag         string    `json:"etag,omitempty"`
+	LastModified string    `json:"lastModified,omitempty"`
+	Validated    time.Time `json:"validated"`
+}
+
+//	Returns the manifest recorded for the local copy at localPath of the document at uri, or nil if there is none (or it is for another uri).
+func readDownloadManifest(uri, localPath string) (man *downloadManifest) {
+	if raw, err := ioutil.ReadFile(localPath + downloadManifestSuffix); err == nil {
+		if man = new(downloadManifest); (json.Unmarshal(raw, man) != nil) || (man.Uri != uri) {
+			man = nil
+		}
+	}
+	return
+}
+
+//	Downloads the document at uri to localPath (via a temporary file, so that an existing local copy is only replaced once the download succeeded
+//	and matches its digest pinned in Checksums, if any), conditional upon the values recorded in man (if not nil), and records its manifest
+//	next to it if DownloadTTL is greater than zero.
+func (me *Generator) downloadFile(ctx context.Context, uri, localPath string, man *downloadManifest) (err error) {
+	var rc io.ReadCloser
+	var fresh *downloadManifest
+	var file *os.File
+	if rc, fresh, err = me.openRemoteFileIf(ctx, uri, man); (err == nil) && (rc != nil) {
+		defer rc.Close()
+		tmpPath, hash := localPath+".download", sha256.New()
+		if file, err = os.Create(tmpPath); err == nil {
+			if _, err = io.Copy(io.MultiWriter(file, hash), rc); err == nil {
+				err = file.Close()
+			} else {
+				file.Close()
+			}
+			if (err == nil) && me.checksummed(uri) {
+				err = me.Checksums.verify(uri, "", hex.EncodeToString(hash.Sum(nil)))
+			}
+			if err == nil {
+				err = os.Rename(tmpPath, localPath)
+			} else {
+				os.Remove(tmpPath)
+			}
+		}
+	}
+	if (err == nil) && (me.DownloadTTL > 0) {
+		var raw []byte
+		if raw, err = json.MarshalIndent(fresh, "", "\t"); err == nil {
+			err = ioutil.WriteFile(localPath+downloadManifestSuffix, raw, 0644)
+		}
+	}
+	return
+}
+
+//	Revalidates the existing local copy at localPath of the document at uri if DownloadTTL is greater than zero and it was last downloaded or
+//	revalidated longer ago than that (as recorded in its manifest, or else by its modification time), failing with an *OfflineError if Offline is set.
+func (me *Generator) revalidateLocalCopy(ctx context.Context, uri, localPath string) (err error) {
+	var validated time.Time
+	if me.DownloadTTL <= 0 {
+		return
+	}
+	man := readDownloadManifest(uri, localPath)
+	if man != nil {
+		validated = man.Validated
+	} else if info, statErr := os.Stat(localPath); statErr == nil {
+		validated = info.ModTime()
+	}
+	if time.Since(validated) < me.DownloadTTL {
+		return
+	} else if me.Offline {
+		return &OfflineError{Uri: uri, LocalPath: localPath}
+	}
+	return me.downloadFile(ctx, uri, localPath, man)
+}
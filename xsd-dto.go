@@ -0,0 +1,212 @@
+package xsd
+
+import (
+	"strings"
+
+	"github.com/metaleap/go-util-str"
+)
+
+var (
+	//	The Go types of the DTO fields (see PkgGen.AddDTOs) of the numeric and boolean xsdt types, keyed by their names. All other plain xsdt types are strings.
+	dtoBasicTypes = map[string]string{
+		"Boolean": "bool", "Byte": "int8", "Double": "float64", "Float": "float32", "Int": "int32", "Integer": "int64", "Long": "int64",
+		"NegativeInteger": "int64", "NonNegativeInteger": "uint64", "NonPositiveInteger": "int64", "PositiveInteger": "uint64", "Short": "int16",
+		"UnsignedByte": "uint8", "UnsignedInt": "uint32", "UnsignedLong": "uint64", "UnsignedShort": "uint16",
+	}
+)
+
+//	A field of the DTO struct type of a complex type (see PkgGen.AddDTOs), for a (possibly promoted) field of its struct type.
+type dtoField struct {
+	Name   string
+	access string     // the name of the field of the struct type, promoted from its embeds
+	tn     string     // the type of the field of the struct type
+	field  *declField // the field of the struct type, or nil for an embedded type (such as the base type of a simpleContent extension)
+	depth  int        // the embedding depth of the field within the struct type
+}
+
+//	Renders a flat XyzDTO struct type with ToDTO() and FromDTO() methods (see PkgGen.AddDTOs) for every struct type rendered for a complex type,
+//	after all types are rendered so that the final type names of their embeds and fields are known.
+func (me *PkgBag) addDTOs() {
+	for _, dt := range me.declWrittenTypes {
+		if !me.isDTOType(dt.Name) {
+			continue
+		}
+		var decls, tos, froms []string
+		for _, f := range me.dtoFields(dt) {
+			typ, to, from := me.dtoConversion(f)
+			decls = append(decls, sfmt("\t%s %s%s", f.Name, typ, me.dtoJsonTag(f)))
+			tos, froms = append(tos, to), append(froms, from)
+		}
+		dto := dt.Name + "DTO"
+		me.renderSplit(dt.elem, func() {
+			me.appendFmt(false, "//\tA flat data transfer object of %s, holding the values of its attributes and elements (including those of its base types) in plain Go types, see %s.ToDTO and %s.FromDTO.", dt.Name, dt.Name, dt.Name)
+			me.appendFmt(true, "type %s struct {\n%s\n}", dto, strings.Join(decls, "\n"))
+			me.appendFmt(false, "//\tReturns a new %s holding the values of this %s instance, or nil if it is nil.", dto, dt.Name)
+			me.appendFmt(true, "func (me *%s) ToDTO () (d *%s) {\n\tif me != nil {\n\t\td = &%s{}%s\n\t}\n\treturn\n}", dt.Name, dto, dto, dtoBody(tos, "\t\t"))
+			me.appendFmt(false, "//\tSets all fields of this %s instance that %s holds values for to those of d (or to their zero values, if d is nil).", dt.Name, dto)
+			me.appendFmt(true, "func (me *%s) FromDTO (d *%s) {\n\tif d == nil {\n\t\td = &%s{}\n\t}%s\n}", dt.Name, dto, dto, dtoBody(froms, "\t"))
+		})
+	}
+}
+
+//	Returns the statements stmts indented by indent, each on a line of its own.
+func dtoBody(stmts []string, indent string) (body string) {
+	for _, stmt := range stmts {
+		body += "\n" + indent + strings.Replace(stmt, "\n", "\n"+indent, -1)
+	}
+	return
+}
+
+//	Returns whether the Go type tn is a struct type of this package that gets a DTO: one rendered for a complex type, and has no fields named ToDTO or FromDTO.
+func (me *PkgBag) isDTOType(tn string) bool {
+	dt := me.declTypes[tn]
+	if (dt == nil) || !dt.rendered || ((len(dt.EquivalentTo) > 0) && (dt.EquivalentTo != tn)) || (len(dt.Type) > 0) || (dt.Fields["ToDTO"] != nil) || (dt.Fields["FromDTO"] != nil) {
+		return false
+	}
+	_, isCt := dt.elem.(*ComplexType)
+	return isCt
+}
+
+//	Returns the fields of the DTO of the struct type dt: one for every field of dt or of the struct types it embeds (directly or indirectly, other than
+//	those of other packages) that is promoted to dt as Go promotes fields, and one named Value for an embedded simple type or for the XsdGoPkgValue
+//	field holding simple content. Other fields named XsdGoPkgXyz (such as those capturing wildcards, mixed content or xsi:type instances) are omitted.
+func (me *PkgBag) dtoFields(dt *declType) (fields []*dtoField) {
+	var all []*dtoField
+	var minDepths, counts = map[string]int{}, map[string]int{}
+	me.collectDTOFields(dt, 0, &all)
+	for _, f := range all {
+		if depth, ok := minDepths[f.access]; (!ok) || (f.depth < depth) {
+			minDepths[f.access], counts[f.access] = f.depth, 1
+		} else if f.depth == depth {
+			counts[f.access]++
+		}
+	}
+	var names = map[string]bool{}
+	for _, f := range all {
+		if (f.depth == minDepths[f.access]) && (counts[f.access] == 1) {
+			fields, names[f.access] = append(fields, f), true
+		}
+	}
+	for _, f := range fields {
+		if f.Name = f.access; ((f.access == idPrefix+"Value") || ((f.field == nil) && (me.isPlainType(f.tn) || me.textTypes[f.tn]))) && !names["Value"] {
+			f.Name, names["Value"] = "Value", true
+		}
+	}
+	return
+}
+
+//	Appends to all the candidate DTO fields of the struct type dt at the embedding depth, in the order of its embeds (see declType.positionalEmbeds) and then its fields.
+func (me *PkgBag) collectDTOFields(dt *declType, depth int, all *[]*dtoField) {
+	if depth > 64 {
+		return
+	}
+	for _, e := range dt.positionalEmbeds() {
+		etn := e.typeName(me)
+		if edt := me.declTypes[etn]; (edt != nil) && (len(edt.Type) == 0) {
+			me.collectDTOFields(edt, depth+1, all)
+		} else if name := strings.TrimPrefix(etn[strings.LastIndex(etn, ".")+1:], "*"); !strings.HasPrefix(name, idPrefix) {
+			*all = append(*all, &dtoField{access: name, tn: etn, depth: depth})
+		}
+	}
+	for _, f := range dt.sortedFields() {
+		if (f.Name == idPrefix+"Value") || !(strings.HasPrefix(f.Name, idPrefix) || (f.Name == "XMLName")) {
+			*all = append(*all, &dtoField{access: f.Name, tn: f.typeName(me), field: f, depth: depth})
+		}
+	}
+}
+
+//	Returns the json struct tag (with a leading space) of the DTO field f according to PkgGen.JsonTags, or "" if none.
+func (me *PkgBag) dtoJsonTag(f *dtoField) (tag string) {
+	if me.gen.JsonTags != JsonTagsNone {
+		if f.field != nil {
+			tag = f.field.jsonTag(me)
+		} else {
+			tag = me.gen.jsonName("value")
+		}
+	}
+	return ustr.Ifs(len(tag) > 0, sfmt(" `json:%q`", tag), "")
+}
+
+//	Returns the Go type of the plain DTO field for values of the Go type tn, such as "string" for xsdt.String or "int32" for a simple type restricting
+//	xsdt.Int, or "" if tn is not a plain type (see PkgBag.isPlainType).
+func (me *PkgBag) dtoBasicType(tn string) string {
+	if !me.isPlainType(tn) {
+		return ""
+	}
+	for depth := 0; (me.declTypes[tn] != nil) && (depth < 64); depth++ {
+		tn = me.declTypes[tn].Type
+	}
+	if (tn == "string") || (tn == "bool") {
+		return tn
+	} else if basic := dtoBasicTypes[strings.TrimPrefix(tn, me.impName+".")]; len(basic) > 0 {
+		return basic
+	}
+	return "string"
+}
+
+//	If the struct type tn has a DTO consisting of nothing but a Value field of a plain type (see dtoFields), such as that of a complexType with
+//	simpleContent but no attributes, returns that field, whose values replace the DTOs of tn in the DTOs of other types.
+func (me *PkgBag) dtoCollapsed(tn string) *dtoField {
+	if me.isDTOType(tn) {
+		if fields := me.dtoFields(me.declTypes[tn]); (len(fields) == 1) && ((fields[0].field == nil) || (fields[0].access == idPrefix+"Value")) && (len(me.dtoBasicType(fields[0].tn)) > 0) {
+			return fields[0]
+		}
+	}
+	return nil
+}
+
+//	Returns the Go type of the DTO field f, and the statements of ToDTO() setting it from the struct type (me) and of FromDTO() setting the struct type from it (d).
+//	Plain types (and pointers and slices of them) become the Go types of their values, struct types of this package (and pointers and slices of them) their DTOs
+//	(or the plain values of their collapsed DTOs, see dtoCollapsed), and nillable element wrappers pointers to the DTO types of their values. All other types
+//	(such as typed built-in types, list and union types, wildcard content and the types of other packages) are kept and deep-copied by xsdt.CloneValue.
+func (me *PkgBag) dtoConversion(f *dtoField) (typ, to, from string) {
+	var src, dst = "me." + f.access, "d." + f.Name
+	var elem, prefix = strings.TrimPrefix(strings.TrimPrefix(f.tn, "[]"), "*"), f.tn[:len(f.tn)-len(strings.TrimPrefix(strings.TrimPrefix(f.tn, "[]"), "*"))]
+	var basic = me.dtoBasicType(elem)
+	if (len(basic) > 0) && (prefix != "[]*") {
+		switch prefix {
+		case "":
+			return basic, sfmt("%s = %s(%s)", dst, basic, src), sfmt("%s = %s(%s)", src, elem, dst)
+		case "*":
+			return "*" + basic, sfmt("if %s != nil {\n\tv := %s(*%s)\n\t%s = &v\n}", src, basic, src, dst), sfmt("%s = nil\nif %s != nil {\n\tv := %s(*%s)\n\t%s = &v\n}", src, dst, elem, dst, src)
+		case "[]":
+			return "[]" + basic, sfmt("if %s != nil {\n\t%s = make([]%s, len(%s))\n\tfor i, v := range %s {\n\t\t%s[i] = %s(v)\n\t}\n}", src, dst, basic, src, src, dst, basic), sfmt("%s = nil\nif %s != nil {\n\t%s = make(%s, len(%s))\n\tfor i, v := range %s {\n\t\t%s[i] = %s(v)\n\t}\n}", src, dst, src, f.tn, dst, dst, src, elem)
+		}
+	}
+	if cf := me.dtoCollapsed(elem); cf != nil {
+		basic = me.dtoBasicType(cf.tn)
+		switch prefix {
+		case "":
+			return basic, sfmt("%s = %s(%s.%s)", dst, basic, src, cf.access), sfmt("%s.%s = %s(%s)", src, cf.access, cf.tn, dst)
+		case "*":
+			return "*" + basic, sfmt("if %s != nil {\n\tv := %s(%s.%s)\n\t%s = &v\n}", src, basic, src, cf.access, dst), sfmt("%s = nil\nif %s != nil {\n\t%s = &%s{}\n\t%s.%s = %s(*%s)\n}", src, dst, src, elem, src, cf.access, cf.tn, dst)
+		case "[]":
+			return "[]" + basic, sfmt("if %s != nil {\n\t%s = make([]%s, len(%s))\n\tfor i := range %s {\n\t\t%s[i] = %s(%s[i].%s)\n\t}\n}", src, dst, basic, src, src, dst, basic, src, cf.access), sfmt("%s = nil\nif %s != nil {\n\t%s = make(%s, len(%s))\n\tfor i, v := range %s {\n\t\t%s[i].%s = %s(v)\n\t}\n}", src, dst, src, f.tn, dst, dst, src, cf.access, cf.tn)
+		case "[]*":
+			return "[]" + basic, sfmt("if %s != nil {\n\t%s = make([]%s, len(%s))\n\tfor i, x := range %s {\n\t\tif x != nil {\n\t\t\t%s[i] = %s(x.%s)\n\t\t}\n\t}\n}", src, dst, basic, src, src, dst, basic, cf.access), sfmt("%s = nil\nif %s != nil {\n\t%s = make(%s, len(%s))\n\tfor i, v := range %s {\n\t\t%s[i] = &%s{}\n\t\t%s[i].%s = %s(v)\n\t}\n}", src, dst, src, f.tn, dst, dst, src, elem, src, cf.access, cf.tn)
+		}
+	}
+	if me.isDTOType(elem) {
+		dto := elem + "DTO"
+		switch prefix {
+		case "":
+			return "*" + dto, sfmt("%s = %s.ToDTO()", dst, src), sfmt("%s.FromDTO(%s)", src, dst)
+		case "*":
+			return "*" + dto, sfmt("%s = %s.ToDTO()", dst, src), sfmt("%s = nil\nif %s != nil {\n\t%s = &%s{}\n\t%s.FromDTO(%s)\n}", src, dst, src, elem, src, dst)
+		case "[]":
+			return "[]*" + dto, sfmt("if %s != nil {\n\t%s = make([]*%s, len(%s))\n\tfor i := range %s {\n\t\t%s[i] = %s[i].ToDTO()\n\t}\n}", src, dst, dto, src, src, dst, src), sfmt("%s = nil\nif %s != nil {\n\t%s = make(%s, len(%s))\n\tfor i, x := range %s {\n\t\t%s[i].FromDTO(x)\n\t}\n}", src, dst, src, f.tn, dst, dst, src)
+		case "[]*":
+			return "[]*" + dto, sfmt("if %s != nil {\n\t%s = make([]*%s, len(%s))\n\tfor i, x := range %s {\n\t\t%s[i] = x.ToDTO()\n\t}\n}", src, dst, dto, src, src, dst), sfmt("%s = nil\nif %s != nil {\n\t%s = make(%s, len(%s))\n\tfor i, x := range %s {\n\t\tif x != nil {\n\t\t\t%s[i] = &%s{}\n\t\t\t%s[i].FromDTO(x)\n\t\t}\n\t}\n}", src, dst, src, f.tn, dst, dst, src, elem, src)
+		}
+	}
+	if vtn := me.nillables[elem]; (prefix == "*") && (len(vtn) > 0) && (me.declTypes[elem] != nil) && (me.declTypes[elem].Fields["Value"] != nil) {
+		vtn = me.declTypes[elem].Fields["Value"].typeName(me)
+		if basic = me.dtoBasicType(vtn); len(basic) > 0 {
+			return "*" + basic, sfmt("if (%s != nil) && !%s.Nil {\n\tv := %s(%s.Value)\n\t%s = &v\n}", src, src, basic, src, dst), sfmt("%s = nil\nif %s != nil {\n\t%s = &%s{Value: %s(*%s)}\n}", src, dst, src, elem, vtn, dst)
+		} else if me.isDTOType(vtn) {
+			return "*" + vtn + "DTO", sfmt("if (%s != nil) && !%s.Nil {\n\t%s = %s.Value.ToDTO()\n}", src, src, dst, src), sfmt("%s = nil\nif %s != nil {\n\t%s = &%s{}\n\t%s.Value.FromDTO(%s)\n}", src, dst, src, elem, src, dst)
+		}
+	}
+	me.impsUsed[me.impName] = true
+	return f.tn, sfmt("%s.CloneValue(&%s, %s)", me.impName, dst, src), sfmt("%s = %s\n%s.CloneValue(&%s, %s)", src, dst, me.impName, src, dst)
+}
@@ -0,0 +1,27 @@
+package xsd
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+//	Tests that identical redeclarations of global components in included schema documents are merged silently, that differing ones are reported
+//	as errors naming the positions of both declarations, and that only the first declaration is generated, so that the Go package builds.
+func TestDuplicateGlobals(t *testing.T) {
+	src, diags := genTestSrc(t, "duplicates", "main.xsd", nil)
+	if len(diags) != 1 {
+		t.Fatalf("expected exactly 1 diagnostic, got %v", diags)
+	}
+	if diag := diags[0]; (diag.Severity != SeverityError) || (filepath.Base(diag.File) != "b.xsd") ||
+		!strings.HasPrefix(diag.Message, `conflicting declarations: complexType "Item" is declared differently before, at `) || !strings.Contains(diag.Message, "a.xsd:8:30") {
+		t.Errorf("expected the conflicting Item of b.xsd to be reported along with that of a.xsd, got %v", diag)
+	}
+	if decl := goTypeDecl(t, src, "TItem"); !strings.Contains(decl, "XsdGoPkgHasAttr_Sku_") || (strings.Count(src, "\ntype TItem ") != 1) || (strings.Count(src, "\ntype TCode ") != 1) {
+		t.Errorf("expected TCode and TItem (of a.xsd) to be declared once, got\n%s", decl)
+	}
+	gopath, goOutFilePaths := genTestPkgs(t, "duplicates", nil)
+	for _, goOutFilePath := range goOutFilePaths {
+		goTool(t, gopath, goOutFilePath, "build")
+	}
+}
@@ -0,0 +1,84 @@
+package xsd
+
+//	Callbacks emitting additional Go code into the generated packages (see PkgGen.EmitHooks), such as an interface assertion after each complex type,
+//	via the exported methods of the PkgBag passed to them (see PkgBag.Append, PkgBag.Import, PkgBag.GoTypeName and PkgBag.TypesImportName).
+//	Any of them may be nil. Code appended for a type goes into the same source file as the type (see PkgGen.SplitFiles). The generated source is
+//	gofmt-formatted afterwards, so appended code need not be indented exactly, but must be syntactically valid.
+type EmitHooks struct {
+	//	Called right after the declaration (and the methods) of every Go type generated (that is, neither pruned nor found equivalent to another)
+	//	is rendered, with its name and the schema construct it was generated from, such as an *Element for an XsdGoPkgHasElem_ type, or nil for
+	//	helper types not generated from any particular construct.
+	AfterType func(bag *PkgBag, goTypeName string, source interface{})
+
+	//	Like AfterType, but only called for the struct types of complex types (anonymous ones included), after AfterType.
+	AfterComplexType func(bag *PkgBag, goTypeName string, ct *ComplexType)
+
+	//	Like AfterType, but only called for the Go types of simple types (anonymous ones included), after AfterType.
+	AfterSimpleType func(bag *PkgBag, goTypeName string, st *SimpleType)
+
+	//	Called once after all the Go code of the package is generated, such as to append package-level declarations.
+	AfterPackage func(bag *PkgBag)
+}
+
+//	Calls the AfterType, AfterComplexType and AfterSimpleType callbacks of PkgGen.EmitHooks for the rendered type dt.
+func (me *PkgBag) emitAfterType(dt *declType) {
+	for _, hooks := range me.gen.EmitHooks {
+		if hooks.AfterType != nil {
+			hooks.AfterType(me, dt.Name, dt.elem)
+		}
+		switch el := dt.elem.(type) {
+		case *ComplexType:
+			if hooks.AfterComplexType != nil {
+				hooks.AfterComplexType(me, dt.Name, el)
+			}
+		case *SimpleType:
+			if hooks.AfterSimpleType != nil {
+				hooks.AfterSimpleType(me, dt.Name, el)
+			}
+		}
+	}
+}
+
+//	Calls the AfterPackage callbacks of PkgGen.EmitHooks.
+func (me *PkgBag) emitAfterPackage() {
+	for _, hooks := range me.gen.EmitHooks {
+		if hooks.AfterPackage != nil {
+			hooks.AfterPackage(me)
+		}
+	}
+}
+
+//	Appends Go code (formatted as per fmt.Sprintf, and followed by an empty line) to the source generated for this package. For use by EmitHooks.
+func (me *PkgBag) Append(format string, fmtArgs ...interface{}) {
+	me.appendFmt(true, format, fmtArgs...)
+}
+
+//	Registers the Go package impPath as imported by this package, returning the name to refer to it by: its last path element, unless another
+//	import or a namespace prefix of the schema already takes that name. For use by EmitHooks.
+func (me *PkgBag) Import(impPath string) (impName string) {
+	impName = me.overrideImpName(impPath)
+	me.impsUsed[impName] = true
+	return
+}
+
+//	Returns the name this package imports the github.com/metaleap/go-xsd/types package by (usually "xsdt"), registering it as used. For use by EmitHooks.
+func (me *PkgBag) TypesImportName() string {
+	me.impsUsed[me.impName] = true
+	return me.impName
+}
+
+//	Returns the name of the Go package being generated.
+func (me *PkgBag) PkgName() string {
+	return me.pkgName
+}
+
+//	Returns the Go type generated for the XSD type named by qname, a QName such as "tns:OrderType" or "xs:string" as resolved by the namespace
+//	declarations of the schema document of this package, qualified with the name of its package if declared by another one (which is then
+//	registered as imported). For use by EmitHooks.
+func (me *PkgBag) GoTypeName(qname string) (tn string) {
+	var impName string
+	if tn = me.rewriteTypeSpec(me.resolveQnameRef(qname, "T", &impName)); len(impName) > 0 {
+		me.impsUsed[impName] = true
+	}
+	return
+}
@@ -0,0 +1,49 @@
+package xsd
+
+import (
+	"strings"
+	"testing"
+)
+
+//	Tests that the callbacks of PkgGen.EmitHooks are called for the generated types and the package, in order, and that the code they append
+//	(using the imports and Go type names obtained from the PkgBag) compiles into the generated package.
+func TestEmitHooks(t *testing.T) {
+	var calls []string
+	hooks := &EmitHooks{
+		AfterType: func(bag *PkgBag, goTypeName string, source interface{}) {
+			if (goTypeName == "TOrder") || (goTypeName == "TCode") {
+				calls = append(calls, "type "+goTypeName)
+			}
+		},
+		AfterComplexType: func(bag *PkgBag, goTypeName string, ct *ComplexType) {
+			calls = append(calls, "complexType "+goTypeName+" "+ct.Name.String())
+			bag.Append("//\tReturns the XSD type name of %s.\nfunc (me *%s) XsdTypeName() string { return %q }", goTypeName, goTypeName, ct.Name)
+		},
+		AfterSimpleType: func(bag *PkgBag, goTypeName string, st *SimpleType) {
+			calls = append(calls, "simpleType "+goTypeName+" "+st.Name.String())
+		},
+		AfterPackage: func(bag *PkgBag) {
+			calls = append(calls, "package "+bag.PkgName())
+			bag.Append("var Codes = []%s{%s(%s.ToUpper(\"a1\"))}\n\nvar Label %s", bag.GoTypeName("Code"), bag.GoTypeName("Code"), bag.Import("strings"), bag.GoTypeName("xs:string"))
+		},
+	}
+	gopath, goOutFilePaths := genTestPkgs(t, "emit", func(opts *GenOptions) { opts.EmitHooks = []*EmitHooks{hooks, {}} })
+	if actual := strings.Join(calls, ", "); actual != "type TCode, simpleType TCode Code, type TOrder, complexType TOrder Order, package go_Order" {
+		t.Errorf("unexpected EmitHooks calls %s", actual)
+	}
+	goTestPkg(t, gopath, goOutFilePaths[0], `package go_Order
+
+import (
+	"testing"
+
+	xsdt "github.com/metaleap/go-xsd/types"
+)
+
+func TestEmitted(t *testing.T) {
+	var label xsdt.String = Label
+	if ((&TOrder{}).XsdTypeName() != "Order") || (len(Codes) != 1) || (Codes[0] != "A1") || (label != "") {
+		t.Errorf("unexpected emitted declarations %v %v", Codes, label)
+	}
+}
+`)
+}
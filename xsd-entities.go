@@ -0,0 +1,136 @@
+package xsd
+
+import (
+	"encoding/xml"
+	"io"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+//	The values of PkgGen.DocTypes, denoting how LoadSchema treats the DOCTYPE declarations of schema documents (such as those of xhtml1 or of older
+//	releases of the XML Schema for Schemas), and references to entities that are neither predefined by XML nor known from PkgGen.Entities.
+const (
+	//	DOCTYPE declarations are ignored, and references to unknown entities fail the decoding of the schema document.
+	DocTypesKeep = ""
+
+	//	The general entities declared in the internal subset of a DOCTYPE declaration (such as <!ENTITY copy "&#169;">) are resolved in the rest of
+	//	the schema document, unless PkgGen.Entities declares them, too. Their replacement texts are not parsed for markup, and external entities
+	//	(declared with SYSTEM or PUBLIC) and parameter entities are ignored, so references to them fail as with DocTypesKeep.
+	DocTypesResolve = "resolve"
+
+	//	DOCTYPE declarations are ignored, and references to unknown entities are kept verbatim (such as "&nbsp;") in the text and attribute values
+	//	they occur in, rather than failing the decoding of the schema document.
+	DocTypesStrip = "strip"
+)
+
+//	Returns a new xml.Decoder reading a schema (or WSDL) document from r, knowing the entities of Entities and HTMLEntities
+//	and tolerating references to unknown entities if DocTypes is DocTypesStrip.
+func (me *Generator) newSchemaDecoder(r io.Reader) (xd *xml.Decoder) {
+	xd = xml.NewDecoder(r)
+	if me.HTMLEntities || (len(me.Entities) > 0) || (me.DocTypes == DocTypesResolve) {
+		xd.Entity = map[string]string{}
+		if me.HTMLEntities {
+			for name, text := range xml.HTMLEntity {
+				xd.Entity[name] = text
+			}
+		}
+		for name, text := range me.Entities {
+			xd.Entity[name] = text
+		}
+	}
+	xd.Strict = (me.DocTypes != DocTypesStrip)
+	return
+}
+
+//	If DocTypes is DocTypesResolve and dir is a DOCTYPE declaration, adds the general entities declared in its internal subset to entities
+//	(other than those already in there, as the first declaration of an entity is binding), with all references to character entities and to
+//	entities declared before them expanded in their replacement texts.
+func (me *Generator) declareDocTypeEntities(entities map[string]string, dir xml.Directive) {
+	var decl, name, text string
+	subset := string(dir)
+	pos := strings.Index(subset, "[")
+	if (me.DocTypes != DocTypesResolve) || (entities == nil) || !strings.HasPrefix(subset, "DOCTYPE") || (pos < 0) {
+		return
+	}
+	subset = subset[pos+1:]
+	for pos = strings.Index(subset, "<!--"); pos >= 0; pos = strings.Index(subset, "<!--") {
+		if end := strings.Index(subset[pos:], "-->"); end < 0 {
+			subset = subset[:pos]
+		} else {
+			subset = subset[:pos] + subset[pos+end+3:]
+		}
+	}
+	for pos = strings.Index(subset, "<!ENTITY"); pos >= 0; pos = strings.Index(subset, "<!ENTITY") {
+		if decl, subset = strings.TrimSpace(subset[pos+len("<!ENTITY"):]), ""; strings.HasPrefix(decl, "%") {
+			subset = decl[1:]
+			continue
+		}
+		if pos = strings.IndexAny(decl, " \t\r\n"); pos < 0 {
+			break
+		}
+		name, decl = decl[:pos], strings.TrimSpace(decl[pos:])
+		if (len(decl) == 0) || ((decl[0] != '"') && (decl[0] != '\'')) {
+			subset = decl
+			continue
+		}
+		if pos = strings.IndexByte(decl[1:], decl[0]); pos < 0 {
+			break
+		}
+		text, subset = decl[1:pos+1], decl[pos+2:]
+		if _, declared := entities[name]; !declared {
+			entities[name] = expandEntityRefs(text, entities)
+		}
+	}
+}
+
+//	Returns text with all references to character entities and to the entities predefined by XML or known from entities expanded.
+//	References to other entities are kept verbatim.
+func expandEntityRefs(text string, entities map[string]string) string {
+	var buf []byte
+	for pos := strings.IndexByte(text, '&'); pos >= 0; pos = strings.IndexByte(text, '&') {
+		end := strings.IndexByte(text[pos:], ';')
+		if end < 0 {
+			break
+		}
+		buf, text = append(buf, text[:pos]...), text[pos:]
+		if repl, ok := entityText(text[1:end], entities); ok {
+			buf = append(buf, repl...)
+		} else {
+			buf = append(buf, text[:end+1]...)
+		}
+		text = text[end+1:]
+	}
+	return string(append(buf, text...))
+}
+
+//	Returns the replacement text of the entity reference whose name (between "&" and ";") is name, if it is a character reference or the entity is known.
+func entityText(name string, entities map[string]string) (text string, ok bool) {
+	switch name {
+	case "amp":
+		return "&", true
+	case "lt":
+		return "<", true
+	case "gt":
+		return ">", true
+	case "quot":
+		return "\"", true
+	case "apos":
+		return "'", true
+	}
+	if strings.HasPrefix(name, "#") {
+		var char uint64
+		var err error
+		if strings.HasPrefix(name, "#x") {
+			char, err = strconv.ParseUint(name[2:], 16, 32)
+		} else {
+			char, err = strconv.ParseUint(name[1:], 10, 32)
+		}
+		if ok = (err == nil) && utf8.ValidRune(rune(char)); ok {
+			text = string(rune(char))
+		}
+		return
+	}
+	text, ok = entities[name]
+	return
+}
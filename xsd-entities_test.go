@@ -0,0 +1,37 @@
+package xsd
+
+import (
+	"context"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+//	Tests that entity references in schema documents fail loading unless known from PkgGen.Entities, the HTML entities or the DOCTYPE
+//	(as per PkgGen.DocTypes), and that DocTypesStrip keeps references to unknown entities verbatim.
+func TestEntities(t *testing.T) {
+	for _, c := range []struct {
+		setup      func(opts *GenOptions)
+		doc, error string
+	}{
+		{func(opts *GenOptions) {}, "", "invalid character entity &company;"},
+		{func(opts *GenOptions) { opts.DocTypes = DocTypesStrip }, "Sold by &company;&nbsp;&custom;", ""},
+		{func(opts *GenOptions) { opts.DocTypes = DocTypesResolve }, "", "invalid character entity &nbsp;"},
+		{func(opts *GenOptions) {
+			opts.DocTypes, opts.HTMLEntities, opts.Entities = DocTypesResolve, true, map[string]string{"custom": "for you"}
+		}, "Sold by Acme Corp for you", ""},
+	} {
+		opts := DefaultGenOptions()
+		c.setup(&opts)
+		set, err := NewSchemaCache(0).LoadSchemaDir(context.Background(), filepath.Join("testdata", "entities"), LoadOptions{Generator: NewGenerator(opts)})
+		if len(c.error) > 0 {
+			if (err == nil) || !strings.Contains(err.Error(), c.error) {
+				t.Errorf("expected an error containing %s, got %v", c.error, err)
+			}
+		} else if err != nil {
+			t.Errorf("expected %q, got %v", c.doc, err)
+		} else if doc := set.Schemas[0].ComplexTypes[0].Annotation.Documentations[0].CDATA; doc != c.doc {
+			t.Errorf("expected %q, got %q", c.doc, doc)
+		}
+	}
+}
@@ -0,0 +1,391 @@
+package xsd
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"path"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/metaleap/go-util-fs"
+	"github.com/metaleap/go-util-str"
+
+	xsdt "github.com/metaleap/go-xsd/types"
+)
+
+var (
+	elementType = reflect.TypeOf((*element)(nil)).Elem()
+	qnameType   = reflect.TypeOf(xsdt.Qname(""))
+
+	//	Matches the namespace prefixes (along with their colons) of the QNames in the XPaths of xs:selector and xs:field.
+	xpathPrefix = regexp.MustCompile(`[\pL_][\pL\pN._\-]*:`)
+)
+
+//	The state of a single Schema.Flatten call.
+type schemaFlattener struct {
+	root, flat *Schema
+	copies     map[element]element
+	declared   map[string]element
+	declaredIn map[string]*Schema
+	seen       map[string]bool
+	head       []element // the annotation and xs:imports of flat, which precede its global components
+	order      []element // the global components of flat, in document order
+	err        error
+
+	//	The schema document being flattened, the prefixes of its namespace declarations mapped to those of flat,
+	//	and the form, block and final defaults of flat that its declarations need to be given explicitly.
+	doc                                              *Schema
+	prefixes                                         map[string]string
+	elementForm, attributeForm, blockDflt, finalDflt string
+}
+
+//	Returns a new, self-contained schema document merging this schema document and all the schema documents it includes, redefines and overrides
+//	(directly or indirectly) into one, such as for shipping a schema to partners as a single file (see WriteXSD). Their global components are deep
+//	copies of those of the merged documents, in document order, with those of redefining and overriding components in place of the originals (see
+//	RedefinedNameSuffix), and xs:include, xs:redefine and xs:override are gone. xs:imports are kept, with schemaLocations relative to this schema document.
+//	The namespace declarations of all documents are merged, prefixes bound to differing namespaces being renamed (in all QName references and XPaths),
+//	and the components of documents without a target namespace (chameleon includes) take on that of this schema document. Where the elementFormDefault,
+//	attributeFormDefault, blockDefault or finalDefault of a merged document differ from those of the result, its declarations get explicit form, block
+//	and final attributes instead. A component that is declared in more than one of the merged documents is kept once if all its declarations are
+//	identical, and is an error otherwise, as are QName references to components without a namespace from documents with a default namespace.
+func (me *Schema) Flatten() (flat *Schema, err error) {
+	var docs = me.allSchemas(map[string]bool{})
+	var fl = &schemaFlattener{root: me, copies: map[element]element{}, declared: map[string]element{}, declaredIn: map[string]*Schema{}, seen: map[string]bool{}}
+	fl.flat = &Schema{XMLName: me.XMLName, XMLNamespaces: map[string]string{}, XMLIncludedSchemas: []*Schema{}, XMLImportedSchemas: []*Schema{}, loadUri: me.loadUri, loadLocalPath: me.loadLocalPath}
+	flat = fl.flat
+	flat.hasAttrAttributeFormDefault, flat.hasAttrElementFormDefault = me.hasAttrAttributeFormDefault, me.hasAttrElementFormDefault
+	flat.hasAttrBlockDefault, flat.hasAttrFinalDefault = me.hasAttrBlockDefault, me.hasAttrFinalDefault
+	flat.hasAttrId, flat.hasAttrLang, flat.hasAttrTargetNamespace, flat.hasAttrVersion = me.hasAttrId, me.hasAttrLang, me.hasAttrTargetNamespace, me.hasAttrVersion
+	for _, sd := range docs {
+		if sd.BlockDefault != me.BlockDefault {
+			flat.BlockDefault = ""
+		}
+		if sd.FinalDefault != me.FinalDefault {
+			flat.FinalDefault = ""
+		}
+	}
+	for prefix, ns := range me.XMLNamespaces {
+		flat.XMLNamespaces[prefix] = ns
+	}
+	for _, sd := range docs {
+		fl.setDoc(sd)
+		for _, kid := range xsdKids(sd) {
+			switch k := kid.(type) {
+			case *Annotation:
+				if sd == me {
+					flat.Annotation = fl.copyElem(k, 1).(*Annotation)
+					fl.head = append(fl.head, flat.Annotation)
+				}
+			case *Import:
+				fl.addImport(k)
+			case *Redefine, *Override:
+				for _, comp := range xsdKids(k) {
+					if _, isAnn := comp.(*Annotation); !isAnn {
+						fl.addComponent(comp)
+					}
+				}
+			case *Include:
+			default:
+				fl.addComponent(kid)
+			}
+		}
+		for _, imp := range sd.XMLImportedSchemas {
+			if !fl.seen["schema:"+imp.loadUri] {
+				fl.seen["schema:"+imp.loadUri], flat.XMLImportedSchemas = true, append(flat.XMLImportedSchemas, imp)
+			}
+		}
+	}
+	if err = fl.err; err != nil {
+		return nil, err
+	}
+	flat.initElement(nil)
+	for orig, cp := range fl.copies {
+		var kids []element
+		for _, kid := range xsdKids(orig) {
+			kids = append(kids, fl.copies[kid])
+		}
+		cp.base().kids = kids
+	}
+	flat.kids = append(fl.head, fl.order...)
+	for prefix, ns := range flat.XMLNamespaces {
+		if ns == xsdNamespaceUri {
+			flat.XSDNamespacePrefix = prefix
+		} else if ns == flat.TargetNamespace.String() {
+			flat.XMLNamespacePrefix = prefix
+		}
+	}
+	return
+}
+
+//	Writes the result of Flatten as an XSD document (see WriteXSD) into flatOutDirPath (defaulting to the directory of the local copy of this schema's
+//	XSD file), named after this schema's XSD file with a .flat.xsd extension (such as order.flat.xsd). As the schemaLocations of its xs:imports are
+//	relative to this schema document, the imported XSD files have to be shipped along with it, in the same relative locations.
+func (me *Schema) MakeFlatXSDFileAt(flatOutDirPath string) (flatOutFilePath string, err error) {
+	var flat *Schema
+	var buf bytes.Buffer
+	if len(flatOutDirPath) == 0 {
+		flatOutDirPath = filepath.Dir(me.loadLocalPath)
+	}
+	flatOutFilePath = filepath.Join(flatOutDirPath, strings.TrimSuffix(path.Base(me.loadUri), path.Ext(me.loadUri))+".flat.xsd")
+	if flat, err = me.Flatten(); err == nil {
+		if err = flat.WriteXSD(&buf); err == nil {
+			if err = ufs.EnsureDirExists(filepath.Dir(flatOutFilePath)); err == nil {
+				err = ufs.WriteBinaryFile(flatOutFilePath, buf.Bytes())
+			}
+		}
+	}
+	return
+}
+
+func (me *schemaFlattener) fail(format string, args ...interface{}) {
+	if me.err == nil {
+		me.err = fmt.Errorf(format, args...)
+	}
+}
+
+//	Makes sd the schema document being flattened, binding the prefixes of its namespace declarations in me.flat
+//	(renamed where already bound to another namespace), and determining the defaults its declarations need explicitly.
+func (me *schemaFlattener) setDoc(sd *Schema) {
+	var prefixes []string
+	var form = func(dflt string) string {
+		return ustr.Ifs(len(dflt) > 0, dflt, "unqualified")
+	}
+	me.doc, me.prefixes = sd, map[string]string{}
+	for prefix, _ := range sd.XMLNamespaces {
+		prefixes = append(prefixes, prefix)
+	}
+	sort.Strings(prefixes)
+	for _, prefix := range prefixes {
+		ns, flatPrefix := sd.XMLNamespaces[prefix], prefix
+		for _, other := range sortedPrefixes(me.flat.XMLNamespaces) {
+			if (me.flat.XMLNamespaces[other] == ns) && (me.flat.XMLNamespaces[flatPrefix] != ns) {
+				flatPrefix = other
+			}
+		}
+		if bound, ok := me.flat.XMLNamespaces[flatPrefix]; !ok {
+			me.flat.XMLNamespaces[flatPrefix] = ns
+		} else if bound != ns {
+			for i := 1; ; i++ {
+				if _, taken := me.flat.XMLNamespaces[sfmt("%s%d", ustr.Ifs(len(prefix) > 0, prefix, "ns"), i)]; !taken {
+					flatPrefix = sfmt("%s%d", ustr.Ifs(len(prefix) > 0, prefix, "ns"), i)
+					break
+				}
+			}
+			me.flat.XMLNamespaces[flatPrefix] = ns
+		}
+		me.prefixes[prefix] = flatPrefix
+	}
+	me.elementForm, me.attributeForm, me.blockDflt, me.finalDflt = "", "", "", ""
+	if form(sd.ElementFormDefault) != form(me.flat.ElementFormDefault) {
+		me.elementForm = form(sd.ElementFormDefault)
+	}
+	if form(sd.AttributeFormDefault) != form(me.flat.AttributeFormDefault) {
+		me.attributeForm = form(sd.AttributeFormDefault)
+	}
+	if sd.BlockDefault != me.flat.BlockDefault {
+		me.blockDflt = sd.BlockDefault
+	}
+	if sd.FinalDefault != me.flat.FinalDefault {
+		me.finalDflt = sd.FinalDefault
+	}
+}
+
+func sortedPrefixes(namespaces map[string]string) (prefixes []string) {
+	for prefix, _ := range namespaces {
+		prefixes = append(prefixes, prefix)
+	}
+	sort.Strings(prefixes)
+	return
+}
+
+func (me *schemaFlattener) addImport(imp *Import) {
+	var loc = imp.SchemaLocation.String()
+	if (len(loc) > 0) && (me.doc != me.root) && !strings.Contains(loc, protSep) {
+		loc = relativeUri(me.root.loadUri, path.Join(path.Dir(me.doc.loadUri), loc))
+	}
+	if key := "import:" + imp.Namespace + "\x00" + loc; !me.seen[key] {
+		me.seen[key] = true
+		cp := me.copyElem(imp, 1).(*Import)
+		cp.SchemaLocation = xsdt.AnyURI(loc)
+		me.flat.Imports, me.head = append(me.flat.Imports, cp), append(me.head, cp)
+	}
+}
+
+//	Returns the path of the document at uri relative to the directory of the document at baseUri.
+func relativeUri(baseUri, uri string) string {
+	var base, target = strings.Split(path.Dir(baseUri), "/"), strings.Split(uri, "/")
+	var i int
+	for i = 0; (i < len(base)) && (i < len(target)-1) && (base[i] == target[i]); i++ {
+	}
+	var parts []string
+	for j := i; j < len(base); j++ {
+		if base[j] != "." {
+			parts = append(parts, "..")
+		}
+	}
+	return path.Join(append(parts, target[i:]...)...)
+}
+
+//	Adds a deep copy of the global component comp of the schema document being flattened to me.flat, unless an identical component of the same
+//	kind and name was added before. Records an error if a differing one was.
+func (me *schemaFlattener) addComponent(comp element) {
+	var kind string
+	var name xsdt.NCName
+	var cp = me.copyElem(comp, 0)
+	switch c := cp.(type) {
+	case *Attribute:
+		kind, name, me.flat.Attributes = "attribute", c.Name, append(me.flat.Attributes, c)
+	case *AttributeGroup:
+		kind, name, me.flat.AttributeGroups = "attributeGroup", c.Name, append(me.flat.AttributeGroups, c)
+	case *ComplexType:
+		kind, name, me.flat.ComplexTypes = "type", c.Name, append(me.flat.ComplexTypes, c)
+	case *Element:
+		kind, name, me.flat.Elements = "element", c.Name, append(me.flat.Elements, c)
+	case *Group:
+		kind, name, me.flat.Groups = "group", c.Name, append(me.flat.Groups, c)
+	case *Notation:
+		kind, name, me.flat.Notations = "notation", c.Name, append(me.flat.Notations, c)
+	case *SimpleType:
+		kind, name, me.flat.SimpleTypes = "type", c.Name, append(me.flat.SimpleTypes, c)
+	default:
+		return
+	}
+	key := kind + ":" + name.String()
+	if prev := me.declared[key]; prev == nil {
+		me.declared[key], me.declaredIn[key], me.order = cp, me.doc, append(me.order, cp)
+	} else {
+		me.dropLast(cp)
+		prevRaw, _ := xml.Marshal(prev)
+		if raw, _ := xml.Marshal(cp); !bytes.Equal(raw, prevRaw) {
+			me.fail("cannot flatten %s: %s %q is declared differently in %s and in %s", me.root.loadUri, kind, name, me.declaredIn[key].loadUri, me.doc.loadUri)
+		}
+	}
+}
+
+//	Removes cp, the global component just added by addComponent, from me.flat again.
+func (me *schemaFlattener) dropLast(cp element) {
+	switch cp.(type) {
+	case *Attribute:
+		me.flat.Attributes = me.flat.Attributes[:len(me.flat.Attributes)-1]
+	case *AttributeGroup:
+		me.flat.AttributeGroups = me.flat.AttributeGroups[:len(me.flat.AttributeGroups)-1]
+	case *ComplexType:
+		me.flat.ComplexTypes = me.flat.ComplexTypes[:len(me.flat.ComplexTypes)-1]
+	case *Element:
+		me.flat.Elements = me.flat.Elements[:len(me.flat.Elements)-1]
+	case *Group:
+		me.flat.Groups = me.flat.Groups[:len(me.flat.Groups)-1]
+	case *Notation:
+		me.flat.Notations = me.flat.Notations[:len(me.flat.Notations)-1]
+	case *SimpleType:
+		me.flat.SimpleTypes = me.flat.SimpleTypes[:len(me.flat.SimpleTypes)-1]
+	}
+}
+
+//	Returns a deep copy of el (whose parent and children are set up by Schema.initElement later on), with all its QName references and XPaths
+//	rewritten for the namespace declarations of me.flat, and explicit form, block and final attributes where needed (see setDoc). The depth
+//	of el is 0 for global components. Every copy made is recorded in me.copies, keyed by its original.
+func (me *schemaFlattener) copyElem(el element, depth int) element {
+	var orig = reflect.ValueOf(el)
+	var cp = reflect.New(orig.Elem().Type())
+	cp.Elem().Set(orig.Elem())
+	me.copyFields(cp.Elem(), depth)
+	switch c := cp.Interface().(type) {
+	case *Element:
+		if (depth > 0) && (len(c.Ref) == 0) && (len(c.Form) == 0) {
+			c.Form = me.elementForm
+		}
+		if (len(c.Ref) == 0) && (len(c.Block) == 0) {
+			c.Block = me.blockDflt
+		}
+		if (depth == 0) && (len(c.Final) == 0) {
+			c.Final = me.finalDflt
+		}
+	case *Attribute:
+		if (depth > 0) && (len(c.Ref) == 0) && (len(c.Form) == 0) {
+			c.Form = me.attributeForm
+		}
+	case *ComplexType:
+		if (depth == 0) && (len(c.Block) == 0) {
+			c.Block = me.blockDflt
+		}
+		if (depth == 0) && (len(c.Final) == 0) {
+			c.Final = me.finalDflt
+		}
+	case *SimpleType:
+		if (depth == 0) && (len(c.Final) == 0) {
+			c.Final = me.finalDflt
+		}
+	}
+	me.copies[el] = cp.Interface().(element)
+	return me.copies[el]
+}
+
+func (me *schemaFlattener) copyFields(v reflect.Value, depth int) {
+	for i := 0; i < v.NumField(); i++ {
+		f, sf := v.Field(i), v.Type().Field(i)
+		switch {
+		case sf.Anonymous && (f.Kind() == reflect.Struct):
+			me.copyFields(f, depth)
+		case !f.CanSet():
+		case f.Type() == qnameType:
+			if len(f.String()) > 0 {
+				f.SetString(me.qname(f.String()))
+			}
+		case (sf.Name == "MemberTypes") && (f.Kind() == reflect.String):
+			var refs = strings.Fields(f.String())
+			for j, ref := range refs {
+				refs[j] = me.qname(ref)
+			}
+			f.SetString(strings.Join(refs, " "))
+		case (sf.Name == "Xpath") && (f.Kind() == reflect.String):
+			f.SetString(xpathPrefix.ReplaceAllStringFunc(f.String(), func(prefix string) string {
+				if flatPrefix, ok := me.prefixes[prefix[:len(prefix)-1]]; ok {
+					return flatPrefix + ":"
+				}
+				return prefix
+			}))
+		case (f.Kind() == reflect.Ptr) && f.Type().Implements(elementType) && !f.IsNil():
+			f.Set(reflect.ValueOf(me.copyElem(f.Interface().(element), depth+1)))
+		case (f.Kind() == reflect.Slice) && f.Type().Elem().Implements(elementType) && !f.IsNil():
+			s := reflect.MakeSlice(f.Type(), f.Len(), f.Len())
+			for j := 0; j < f.Len(); j++ {
+				if !f.Index(j).IsNil() {
+					s.Index(j).Set(reflect.ValueOf(me.copyElem(f.Index(j).Interface().(element), depth+1)))
+				}
+			}
+			f.Set(s)
+		}
+	}
+}
+
+//	Returns the QName reference ref of the schema document being flattened, prefixed for the namespace declarations of me.flat.
+func (me *schemaFlattener) qname(ref string) string {
+	var qn = me.doc.qname(ref)
+	if (len(qn.Space) == 0) && (len(me.doc.TargetNamespace) == 0) {
+		qn.Space = me.flat.TargetNamespace.String()
+	}
+	var prefix, ok = "", false
+	if pos := strings.Index(ref, ":"); pos > 0 {
+		prefix = ref[:pos]
+	}
+	if prefix, ok = me.prefixes[prefix]; (!ok) || (me.flat.XMLNamespaces[prefix] != qn.Space) {
+		for _, other := range sortedPrefixes(me.flat.XMLNamespaces) {
+			if ok = me.flat.XMLNamespaces[other] == qn.Space; ok {
+				prefix = other
+				break
+			}
+		}
+	}
+	if !ok {
+		if ok = (len(qn.Space) == 0) && (len(me.flat.XMLNamespaces[""]) == 0); !ok {
+			me.fail("cannot flatten %s: the reference %q in %s cannot be expressed in the flattened schema", me.root.loadUri, ref, me.doc.loadUri)
+		}
+		prefix = ""
+	}
+	return ustr.Ifs(len(prefix) > 0, prefix+":"+qn.Local, qn.Local)
+}
@@ -0,0 +1,53 @@
+package xsd
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+)
+
+//	Tests that Flatten merges included documents (renaming prefixes and giving chameleon components the target namespace) into one
+//	written by WriteXSD without any xs:include, and that the written schema validates documents just like the original.
+func TestFlattenAndWriteXSD(t *testing.T) {
+	var buf bytes.Buffer
+	sd := loadTestSchema(t, "flatten", "main.xsd")
+	flat, err := sd.Flatten()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = flat.WriteXSD(&buf); err != nil {
+		t.Fatal(err)
+	}
+	written := buf.String()
+	for _, s := range []string{`<xs:complexType name="Part">`, `<xs:element name="sku" type="m:Sku"/>`, `<xs:simpleType name="Note">`} {
+		if !strings.Contains(written, s) {
+			t.Errorf("expected %s in\n%s", s, written)
+		}
+	}
+	if strings.Contains(written, "xs:include") {
+		t.Errorf("expected no xs:include in\n%s", written)
+	}
+	reloaded, err := NewSchemaCache(0).LoadSchemaReader(context.Background(), strings.NewReader(written), "flat.xsd", LoadOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, c := range []struct {
+		doc, path string
+	}{
+		{`<order xmlns="urn:example:flatten"><part><sku>ABC</sku></part><note>rush</note></order>`, ""},
+		{`<order xmlns="urn:example:flatten"><part><sku>abc</sku></part></order>`, "/order/part/sku"},
+		{`<order xmlns="urn:example:flatten"><part><sku>ABC</sku></part><note>a note far too long to be valid</note></order>`, "/order/note"},
+	} {
+		for _, schema := range []*Schema{sd, reloaded} {
+			errs, err := schema.Validate(strings.NewReader(c.doc))
+			if err != nil {
+				t.Fatal(err)
+			} else if (len(c.path) == 0) && (len(errs) > 0) {
+				t.Errorf("%s: unexpected %v", c.doc, errs)
+			} else if (len(c.path) > 0) && ((len(errs) == 0) || (errs[0].Path != c.path)) {
+				t.Errorf("%s: expected an error at %s, got %v", c.doc, c.path, errs)
+			}
+		}
+	}
+}
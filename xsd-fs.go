@@ -0,0 +1,77 @@
+package xsd
+
+import (
+	"context"
+	"io/fs"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+//	Loads the XML Schema Definition at the specified slash-separated path within fsys (such as an embed.FS of schemas compiled into the program,
+//	an fstest.MapFS or a zip.Reader), including all its xs:include'd (and xs:import'ed etc.) schemas: schemaLocations without a protocol
+//	(such as "common.xsd" or "../types/addr.xsd") are resolved relative to the schema document referencing them, within fsys only, so that
+//	neither the local file system nor the network is consulted for them. Other schemaLocations (such as "http://www.w3.org/2001/xml.xsd")
+//	are loaded as by LoadSchema with localCopy being false.
+//	The schemas are named (and cached, and their Go packages generated) by their paths within fsys, as if they were files under PkgGen.BaseCodePath.
+//	Use a SchemaCache of its own (see SchemaCache.LoadSchemaFS) if other loads may name different schemas alike. As schema documents are loaded
+//	concurrently (see PkgGen.MaxConcurrentLoads), fsys must be safe for concurrent use, as embed.FS and the file systems of the os package are.
+func LoadSchemaFS(fsys fs.FS, path string) (sd *Schema, err error) {
+	return DefaultSchemaCache.LoadSchemaFS(context.Background(), fsys, path, LoadOptions{})
+}
+
+//	Like LoadSchemaFS, but uses (and populates) this cache instead of DefaultSchemaCache, aborts as soon as ctx is done and loads according to opts.
+func (me *SchemaCache) LoadSchemaFS(ctx context.Context, fsys fs.FS, filePath string, opts LoadOptions) (sd *Schema, err error) {
+	var cancel context.CancelFunc
+	ctx, cancel = context.WithCancel(ctx)
+	defer cancel()
+	loader := newSchemaLoader(ctx, me, opts)
+	loader.fsys, loader.fsUris = fsys, map[string]bool{}
+	if sd, err = loader.loadUri(path.Clean(filePath), "", false); err == nil {
+		if err = loader.strictError([]*Schema{sd}); err != nil {
+			return nil, err
+		}
+		for pendingUri, pendingSchema := range loader.pending {
+			me.Put(pendingUri, pendingSchema)
+		}
+	}
+	return
+}
+
+//	Returns whether the schema document at location (relative to baseUri) is to be loaded from the file system being loaded (see LoadSchemaFS):
+//	if location has no protocol and baseUri is either empty or the uri of a schema document loaded from there.
+func (me *schemaLoader) inFS(location, baseUri string) bool {
+	if (me.fsys == nil) || strings.Contains(location, protSep) {
+		return false
+	}
+	return (len(baseUri) == 0) || me.fsUri(baseUri)
+}
+
+//	Returns whether the schema document at the specified protocol-less uri was loaded from the file system being loaded (see LoadSchemaFS).
+func (me *schemaLoader) fsUri(uri string) bool {
+	me.mutex.Lock()
+	defer me.mutex.Unlock()
+	return me.fsUris[uri]
+}
+
+//	Opens the file at the protocol-less uri in the file system being loaded (see LoadSchemaFS) and has load decode it.
+func (me *schemaLoader) loadFSFile(uri string, load docLoader) (err error) {
+	var file fs.File
+	if !fs.ValidPath(uri) {
+		return &fs.PathError{Op: "open", Path: uri, Err: fs.ErrNotExist}
+	}
+	if file, err = me.fsys.Open(uri); err == nil {
+		defer file.Close()
+		me.mutex.Lock()
+		me.fsUris[uri] = true
+		me.mutex.Unlock()
+		err = load(file, uri, "")
+	}
+	return
+}
+
+//	Returns the (non-existent) local path that a schema document loaded from a file system (see LoadSchemaFS) stands in for,
+//	so that the Go package and other files generated for it are written under BaseCodePath.
+func (me *Generator) fsLocalPath(uri string) string {
+	return filepath.Join(me.BaseCodePath, filepath.FromSlash(uri))
+}
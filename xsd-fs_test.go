@@ -0,0 +1,46 @@
+package xsd
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+//	Tests that LoadSchemaFS resolves relative includes and imports within the file system, and fails for schemaLocations missing from it.
+func TestLoadSchemaFS(t *testing.T) {
+	const header = `<?xml version="1.0"?>` + "\n" + `<xs:schema xmlns:xs="http://www.w3.org/2001/XMLSchema" `
+	fsys := fstest.MapFS{
+		"schemas/orders/order.xsd": {Data: []byte(header + `xmlns:c="urn:example:common" targetNamespace="urn:example:orders" elementFormDefault="qualified">
+	<xs:import namespace="urn:example:common" schemaLocation="../common/money.xsd"/>
+	<xs:element name="total" type="c:Money"/>
+</xs:schema>`)},
+		"schemas/common/money.xsd": {Data: []byte(header + `xmlns="urn:example:common" targetNamespace="urn:example:common">
+	<xs:include schemaLocation="amount.xsd"/>
+	<xs:complexType name="Money">
+		<xs:attribute name="amount" type="Amount"/>
+	</xs:complexType>
+</xs:schema>`)},
+		"schemas/common/amount.xsd": {Data: []byte(header + `targetNamespace="urn:example:common">
+	<xs:simpleType name="Amount">
+		<xs:restriction base="xs:decimal"/>
+	</xs:simpleType>
+</xs:schema>`)},
+		"schemas/broken.xsd": {Data: []byte(header + `targetNamespace="urn:example:broken">
+	<xs:include schemaLocation="missing.xsd"/>
+</xs:schema>`)},
+	}
+	sd, err := NewSchemaCache(0).LoadSchemaFS(context.Background(), fsys, "schemas/orders/order.xsd", LoadOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if (len(sd.XMLImportedSchemas) != 1) || (sd.XMLImportedSchemas[0].loadUri != "schemas/common/money.xsd") || (len(sd.XMLImportedSchemas[0].XMLIncludedSchemas) != 1) {
+		t.Fatalf("expected schemas/common/money.xsd imported, including amount.xsd")
+	}
+	if errs, err := sd.Validate(strings.NewReader(`<total xmlns="urn:example:orders" amount="x"/>`)); (err != nil) || (len(errs) != 1) {
+		t.Errorf("expected the invalid amount reported, got %v %v", errs, err)
+	}
+	if _, err = NewSchemaCache(0).LoadSchemaFS(context.Background(), fsys, "schemas/broken.xsd", LoadOptions{}); (err == nil) || !strings.Contains(err.Error(), "missing.xsd") {
+		t.Errorf("expected an error for missing.xsd, got %v", err)
+	}
+}
@@ -0,0 +1,65 @@
+package xsd
+
+import (
+	"bytes"
+	"encoding/xml"
+	"strings"
+)
+
+//	Returns the source of the Go fuzz test file of this package (see PkgGen.AddFuzzTests): a FuzzXyz() function for the XsdGoPkgHasElem_ type
+//	of every global element of the package that is rendered and not abstract, seeded with a minimal and a pseudo-random sample instance of the
+//	element (if these can be generated), and the XsdGoPkgFuzzRoundTrip() function they all call. Must be called after assembleSource.
+func (me *PkgBag) fuzzTestSource() string {
+	var src = me.src
+	me.src = &bytes.Buffer{}
+	defer func() {
+		me.src = src
+	}()
+	me.append("//go:build go1.18", "")
+	me.appendTmpl(me.tmpls.fileHeader, &TmplFileHeader{SchemaUri: me.Schema.loadUri, PkgName: me.pkgName})
+	me.appendFmt(true, "import (\n\t\"bytes\"\n\t\"encoding/xml\"\n\t\"testing\"\n)")
+	me.appendFmt(false, "//\tDecodes data as the content of an element (such as an instance document of a global element, whose XML declaration is skipped) into v1 and")
+	me.appendFmt(false, "//\tre-encodes it, then decodes that into v2 and re-encodes that, failing t if this fails or yields a different encoding. Inputs that v1 cannot")
+	me.appendFmt(false, "//\tdecode or encode are skipped.")
+	me.appendFmt(true, `func %sFuzzRoundTrip (t *testing.T, data []byte, v1, v2 interface{}) {
+	var raw1, raw2 []byte
+	var err error
+	if pos := bytes.Index(data, []byte("?>")); bytes.HasPrefix(data, []byte("<?xml")) && (pos > 0) {
+		data = data[pos+2:]
+	}
+	if err = xml.Unmarshal(append(append([]byte("<%sFuzz>"), data...), "</%sFuzz>"...), v1); err != nil {
+		t.Skip(err)
+	}
+	if raw1, err = xml.Marshal(v1); err != nil {
+		t.Skip(err)
+	}
+	if err = xml.Unmarshal(raw1, v2); err != nil {
+		t.Fatalf("decoding the re-encoded %%s failed: %%v", raw1, err)
+	}
+	if raw2, err = xml.Marshal(v2); err != nil {
+		t.Fatalf("re-encoding %%s failed after decoding it: %%v", raw1, err)
+	}
+	if !bytes.Equal(raw1, raw2) {
+		t.Fatalf("decoding and re-encoding\n%%s\nyields\n%%s", raw1, raw2)
+	}
+}`, idPrefix, idPrefix, idPrefix)
+	for _, tn := range me.sortedTypeNames() {
+		dt := me.declTypes[tn]
+		el, _ := dt.elem.(*Element)
+		if (el == nil) || el.Abstract || !isGlobal(el) || !dt.rendered || (len(dt.EquivalentTo) > 0) || !strings.HasPrefix(tn, idPrefix+"HasElem_") {
+			continue
+		}
+		qn := xml.Name{Space: me.Schema.TargetNamespace.String(), Local: el.Name.String()}
+		me.appendFmt(false, "//\tChecks that decoding and re-encoding instances of the global element %s is stable (see %sFuzzRoundTrip).", qn.Local, idPrefix)
+		me.appendFmt(false, "func Fuzz%s (f *testing.F) {", strings.TrimPrefix(tn, idPrefix+"HasElem_"))
+		var seeds = map[string]bool{}
+		for _, opts := range []GenOpts{{Minimal: true}, {Seed: 1}} {
+			if doc, err := generateInstance(me.compile(), qn, opts); (err == nil) && !seeds[string(doc)] {
+				seeds[string(doc)] = true
+				me.appendFmt(false, "\tf.Add([]byte(%q))", doc)
+			}
+		}
+		me.appendFmt(true, "\tf.Fuzz(func(t *testing.T, data []byte) {\n\t\t%sFuzzRoundTrip(t, data, new(%s), new(%s))\n\t})\n}", idPrefix, tn, tn)
+	}
+	return me.src.String()
+}
@@ -0,0 +1,22 @@
+package xsd
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestFuzzTestsPassOwnSeeds(t *testing.T) {
+	gopath, goOutFilePaths := genTestPkgs(t, "fuzz", func(opts *GenOptions) {
+		opts.AddFuzzTests = true
+	})
+	src, err := ioutil.ReadFile(filepath.Join(filepath.Dir(goOutFilePaths[0]), "order.xsd.fuzz_test.go"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(src), "func FuzzOrder(") {
+		t.Fatalf("no FuzzOrder in\n%s", src)
+	}
+	goTool(t, gopath, goOutFilePaths[0], "test", "-run", "Fuzz")
+}
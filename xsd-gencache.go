@@ -0,0 +1,187 @@
+package xsd
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/metaleap/go-util-fs"
+)
+
+//	A cache of the Go source files generated by MakeGoPkgSrcFileAt (and MakeGoPkgSrcFile and MakeGoPkgSrcFiles), see PkgGen.Cache.
+//	It records for every generated Go source file a hash of the contents of the schema documents it was generated from (including all the schemas
+//	these xs:include, xs:redefine, xs:override or xs:import, directly or indirectly) and of the PkgGen options it was generated with.
+//	All methods of a GenCache are safe for concurrent use.
+type GenCache struct {
+	//	The file that Save writes this cache to, as JSON.
+	FilePath string
+
+	mutex       sync.Mutex
+	entries     map[string]*genCacheEntry
+	regenerated []string
+}
+
+type genCacheEntry struct {
+	Hash  string
+	Files []string
+}
+
+//	Returns a new, empty GenCache to be saved to filePath.
+func NewGenCache(filePath string) *GenCache {
+	return &GenCache{FilePath: filePath, entries: map[string]*genCacheEntry{}}
+}
+
+//	Returns the GenCache previously saved to filePath, or a new, empty one if that file does not exist.
+func LoadGenCache(filePath string) (me *GenCache, err error) {
+	var raw []byte
+	me = NewGenCache(filePath)
+	if raw, err = ioutil.ReadFile(filePath); os.IsNotExist(err) {
+		err = nil
+	} else if err == nil {
+		err = json.Unmarshal(raw, &me.entries)
+	}
+	return
+}
+
+//	Removes all entries from this cache, so that all Go source files are generated again.
+func (me *GenCache) Clear() {
+	me.mutex.Lock()
+	defer me.mutex.Unlock()
+	me.entries = map[string]*genCacheEntry{}
+}
+
+//	Returns the paths of all Go source files that were written (because they did not exist or their contents changed) since this cache was created or loaded,
+//	in the order they were written. Files whose generation was skipped, or whose regenerated contents were identical to their existing contents, are not included.
+func (me *GenCache) Regenerated() (filePaths []string) {
+	me.mutex.Lock()
+	defer me.mutex.Unlock()
+	return append(filePaths, me.regenerated...)
+}
+
+//	Writes this cache to FilePath, as JSON.
+func (me *GenCache) Save() (err error) {
+	var raw []byte
+	me.mutex.Lock()
+	defer me.mutex.Unlock()
+	if raw, err = json.MarshalIndent(me.entries, "", "\t"); err == nil {
+		if err = ufs.EnsureDirExists(filepath.Dir(me.FilePath)); err == nil {
+			err = ufs.WriteBinaryFile(me.FilePath, raw)
+		}
+	}
+	return
+}
+
+//	Returns whether goOutFilePath was generated with the specified hash, and it and all the split files generated along with it still exist.
+func (me *GenCache) upToDate(goOutFilePath, hash string) bool {
+	me.mutex.Lock()
+	defer me.mutex.Unlock()
+	entry := me.entries[goOutFilePath]
+	if (entry == nil) || (len(hash) == 0) || (entry.Hash != hash) {
+		return false
+	}
+	for _, fileName := range entry.Files {
+		if !ufs.FileExists(filepath.Join(filepath.Dir(goOutFilePath), fileName)) {
+			return false
+		}
+	}
+	return true
+}
+
+//	Records that goOutFilePath (along with the split files named fileNames) was generated with the specified hash, or forgets it if hash is empty.
+func (me *GenCache) put(goOutFilePath, hash string, fileNames []string) {
+	me.mutex.Lock()
+	defer me.mutex.Unlock()
+	if len(hash) == 0 {
+		delete(me.entries, goOutFilePath)
+	} else {
+		sort.Strings(fileNames)
+		me.entries[goOutFilePath] = &genCacheEntry{Hash: hash, Files: fileNames}
+	}
+}
+
+//	Records that filePath was written.
+func (me *GenCache) written(filePath string) {
+	me.mutex.Lock()
+	defer me.mutex.Unlock()
+	me.regenerated = append(me.regenerated, filePath)
+}
+
+//	Returns the hash of the contents of this schema and all the schemas it includes or imports (directly or indirectly), of its SoapOperations, of the options of gen
+//	affecting the generated Go source, of goOutDirPath and goPkgName, and of the global components in keep (see Schema.rootsClosure), which
+//	may also depend on the schema importing this one. Returns an empty hash if any of these schemas has no local file.
+func (me *Schema) genCacheHash(gen *Generator, goOutDirPath, goPkgName string, keep map[string]bool) (hash string, err error) {
+	var raw []byte
+	var uris, ids []string
+	var schemas = map[string]*Schema{}
+	var sum = sha256.New()
+	if raw, err = json.Marshal(gen); err != nil {
+		return
+	}
+	fmt.Fprintf(sum, "%s\x00%s\x00%s\x00%s\x00", raw, goOutDirPath, goPkgName, me.loadUri)
+	if keep != nil {
+		for id, _ := range keep {
+			ids = append(ids, id)
+		}
+		sort.Strings(ids)
+		fmt.Fprintf(sum, "%s\x00", strings.Join(ids, "\x00"))
+	}
+	if len(me.SoapOperations) > 0 {
+		if raw, err = json.Marshal(me.SoapOperations); err != nil {
+			return
+		}
+		fmt.Fprintf(sum, "%s\x00", raw)
+	}
+	me.genCacheSchemas(schemas)
+	for uri, _ := range schemas {
+		uris = append(uris, uri)
+	}
+	sort.Strings(uris)
+	for _, uri := range uris {
+		if len(schemas[uri].loadLocalPath) == 0 {
+			return "", nil
+		}
+		if sd := schemas[uri]; sd.loadFS != nil {
+			if raw, err = fs.ReadFile(sd.loadFS, uri); err != nil {
+				return
+			}
+		} else if raw, err = ioutil.ReadFile(sd.loadLocalPath); err != nil {
+			return
+		}
+		fmt.Fprintf(sum, "%s\x00%d\x00", uri, len(raw))
+		sum.Write(raw)
+	}
+	hash = hex.EncodeToString(sum.Sum(nil))
+	return
+}
+
+//	Adds this schema and all the schemas it includes or imports (directly or indirectly) to schemas, keyed by their load URIs.
+func (me *Schema) genCacheSchemas(schemas map[string]*Schema) {
+	if _, ok := schemas[me.loadUri]; !ok {
+		schemas[me.loadUri] = me
+		for _, sd := range me.XMLIncludedSchemas {
+			sd.genCacheSchemas(schemas)
+		}
+		for _, sd := range me.XMLImportedSchemas {
+			sd.genCacheSchemas(schemas)
+		}
+	}
+}
+
+//	Writes src to filePath unless that file already has exactly these contents, recording it in Cache (if any) if written.
+func (me *Generator) writeSourceFile(filePath string, src []byte) (err error) {
+	if existing, readErr := ioutil.ReadFile(filePath); (readErr != nil) || !bytes.Equal(existing, src) {
+		if err = ufs.WriteBinaryFile(filePath, src); (err == nil) && (me.Cache != nil) {
+			me.Cache.written(filePath)
+		}
+	}
+	return
+}
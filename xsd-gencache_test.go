@@ -0,0 +1,64 @@
+package xsd
+
+import (
+	"context"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+//	Tests that a GenCache skips regenerating packages whose schemas are unchanged, and regenerates those whose schemas (or the schemas
+//	these include or import, transitively) changed, writing only the files whose contents changed.
+func TestGenCacheRegeneratesChangedSchemasOnly(t *testing.T) {
+	var cacheFilePath string
+	var cache *GenCache
+	gopath, _ := genTestPkgs(t, "schemadir", func(opts *GenOptions) {
+		cacheFilePath = filepath.Join(opts.BaseCodePath, "gencache.json")
+		cache = NewGenCache(cacheFilePath)
+		opts.Cache = cache
+	})
+	if len(cache.Regenerated()) != 2 {
+		t.Fatalf("expected 2 files to be generated, got %v", cache.Regenerated())
+	} else if err := cache.Save(); err != nil {
+		t.Fatal(err)
+	}
+	dirPath := filepath.Join(gopath, "src", "xsdtest", "schemadir")
+	regenerate := func() (fileNames []string) {
+		cache, err := LoadGenCache(cacheFilePath)
+		if err != nil {
+			t.Fatal(err)
+		}
+		set, err := NewSchemaCache(0).LoadSchemaDir(context.Background(), dirPath, LoadOptions{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		opts := DefaultGenOptions()
+		opts.BaseCodePath, opts.BasePath, opts.Cache = filepath.Join(gopath, "src", "xsdtest"), "xsdtest", cache
+		set.Generator = NewGenerator(opts)
+		if _, _, err = set.MakeGoPkgSrcFiles(); err != nil {
+			t.Fatal(err)
+		}
+		if err = cache.Save(); err != nil {
+			t.Fatal(err)
+		}
+		for _, filePath := range cache.Regenerated() {
+			fileNames = append(fileNames, filepath.Base(filePath))
+		}
+		return
+	}
+	if fileNames := regenerate(); len(fileNames) > 0 {
+		t.Errorf("expected nothing to be regenerated, got %v", fileNames)
+	}
+	currencyFilePath := filepath.Join(dirPath, "common", "currency.xsd")
+	src, err := ioutil.ReadFile(currencyFilePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = ioutil.WriteFile(currencyFilePath, []byte(strings.Replace(string(src), `<xs:restriction base="xs:string"/>`, `<xs:restriction base="xs:token"/>`, 1)), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if fileNames := regenerate(); strings.Join(fileNames, " ") != "money.xsd.go" {
+		t.Errorf("expected only money.xsd.go to be rewritten, got %v", fileNames)
+	}
+}
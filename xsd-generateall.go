@@ -0,0 +1,109 @@
+package xsd
+
+import (
+	"context"
+	"sync"
+)
+
+//	Calls PkgGen.GenerateAll.
+func GenerateAll(uris []string) (goOutFilePaths []string, diags Diagnostics, err error) {
+	return PkgGen.GenerateAll(uris)
+}
+
+//	Loads the schemas at uris (see LoadSchema, keeping local copies) as governed by the load settings of this Generator (see LoadOptions.Generator) and generates the Go packages of each of them and of all the schemas they import,
+//	directly or indirectly (as MakeGoPkgSrcFiles does), with up to MaxConcurrentGens goroutines loading and then generating at the same time.
+//	A schema imported by several of them (or also among uris) is generated only once (for the components needed by any of them, if Roots is set).
+//	The paths and diags of all generated packages are returned in the order of uris and their imports, along with the first error (if any) in
+//	that order: a schema failing to load or generate does not keep the others from being generated. Any Hooks must be safe for concurrent use.
+func (me *Generator) GenerateAll(uris []string) (goOutFilePaths []string, diags Diagnostics, err error) {
+	type genJob struct {
+		sd            *Schema
+		keep          map[string]bool
+		goOutFilePath string
+		diags         Diagnostics
+		err           error
+	}
+	var jobs []*genJob
+	var jobsByUri = map[string]*genJob{}
+	var roots = make([]*Schema, len(uris))
+	var errs = make([]error, len(uris))
+	me.fanOut(len(uris), func(i int) {
+		roots[i], errs[i] = DefaultSchemaCache.LoadSchemaWithOptions(context.Background(), uris[i], true, LoadOptions{Generator: me})
+	})
+	for i, root := range roots {
+		var keep map[string]bool
+		if errs[i] == nil {
+			keep, errs[i] = root.rootsClosure(me)
+		}
+		if errs[i] != nil {
+			if err == nil {
+				err = errs[i]
+			}
+			continue
+		}
+		for todo := []*Schema{root}; len(todo) > 0; todo = todo[1:] {
+			sd := todo[0]
+			if job := jobsByUri[sd.loadUri]; job != nil {
+				job.keep = mergeKeeps(job.keep, keep)
+				continue
+			}
+			job := &genJob{sd: sd, keep: keep}
+			jobsByUri[sd.loadUri], jobs = job, append(jobs, job)
+			for _, inc := range sd.allSchemas(map[string]bool{}) {
+				for _, imp := range inc.XMLImportedSchemas {
+					if len(me.ImportPaths[imp.TargetNamespace.String()]) == 0 {
+						todo = append(todo, imp)
+					}
+				}
+			}
+		}
+	}
+	me.fanOut(len(jobs), func(i int) {
+		job := jobs[i]
+		job.goOutFilePath, job.diags, job.err = me.makeGoPkgSrcFileAt(job.sd, "", "", job.keep)
+	})
+	for _, job := range jobs {
+		if diags = append(diags, job.diags...); job.err != nil {
+			if err == nil {
+				err = job.err
+			}
+		} else {
+			goOutFilePaths = append(goOutFilePaths, job.goOutFilePath)
+		}
+	}
+	return
+}
+
+//	Calls fn(0) through fn(n-1) from up to MaxConcurrentGens goroutines at the same time, returning once all of them have returned.
+func (me *Generator) fanOut(n int, fn func(int)) {
+	var wait sync.WaitGroup
+	var next = make(chan int)
+	for w := 0; (w < me.MaxConcurrentGens) || (w == 0); w++ {
+		wait.Add(1)
+		go func() {
+			defer wait.Done()
+			for i := range next {
+				fn(i)
+			}
+		}()
+	}
+	for i := 0; i < n; i++ {
+		next <- i
+	}
+	close(next)
+	wait.Wait()
+}
+
+//	Returns the union of the DependencyNode IDs in keep1 and keep2 (see Schema.rootsClosure), or nil if either is nil (for all components).
+func mergeKeeps(keep1, keep2 map[string]bool) (keep map[string]bool) {
+	if (keep1 != nil) && (keep2 != nil) {
+		keep = map[string]bool{}
+		for id, _ := range keep1 {
+			keep[id] = true
+		}
+		for id, _ := range keep2 {
+			keep[id] = true
+		}
+	}
+	return
+}
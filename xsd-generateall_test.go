@@ -0,0 +1,24 @@
+package xsd
+
+import (
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"testing"
+)
+
+func TestGenerateAllUsesOwnLoadSettings(t *testing.T) {
+	opts := DefaultGenOptions()
+	opts.BaseCodePath, opts.BasePath, opts.Offline = t.TempDir(), "xsdtest", true
+	opts.Resolver = SchemaResolverFunc(func(location, baseUri string) (io.ReadCloser, error) {
+		return os.Open(filepath.Join("testdata", "resolved", path.Base(location)))
+	})
+	goOutFilePaths, _, err := NewGenerator(opts).GenerateAll([]string{"resolved.example.com/generateall/note.xsd"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rel, err := filepath.Rel(opts.BaseCodePath, goOutFilePaths[0]); (err != nil) || (rel != filepath.Join("resolved.example.com", "generateall", "note.xsd_go", "note.xsd.go")) {
+		t.Fatalf("expected the package within BaseCodePath %s, got %s", opts.BaseCodePath, goOutFilePaths[0])
+	}
+}
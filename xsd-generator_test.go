@@ -0,0 +1,54 @@
+package xsd
+
+import (
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+)
+
+//	Tests that Generators of different settings generate the same schema concurrently just as they do one after another, each according to its
+//	own settings, without modifying PkgGen.
+func TestGeneratorsRunConcurrently(t *testing.T) {
+	var wait sync.WaitGroup
+	sd := loadTestSchema(t, "validate", "order.xsd")
+	pkgGen := PkgGen.GenOptions
+	plain, checked := DefaultGenOptions(), DefaultGenOptions()
+	plain.AddValidators, checked.AddValidators, checked.AddMarshalChecks = false, true, true
+	gens := []*Generator{NewGenerator(plain), NewGenerator(checked)}
+	sequential, concurrent := make([]string, len(gens)), make([]string, len(gens))
+	generate := func(gen *Generator, src *string) {
+		srcs, _, err := gen.GenerateGoSourceAs(sd, "")
+		if err != nil {
+			t.Error(err)
+		}
+		*src = string(srcs["order.xsd.go"])
+	}
+	for i, gen := range gens {
+		generate(gen, &sequential[i])
+	}
+	for n := 0; n < 4; n++ {
+		for i, gen := range gens {
+			wait.Add(1)
+			go func(gen *Generator, src *string) {
+				defer wait.Done()
+				generate(gen, src)
+			}(gen, &concurrent[i])
+		}
+		wait.Wait()
+		for i := range gens {
+			if concurrent[i] != sequential[i] {
+				t.Fatalf("generator %d: concurrent generation differs from sequential generation", i)
+			}
+		}
+	}
+	if strings.Contains(sequential[0], "CheckBeforeMarshal") || strings.Contains(sequential[0], ") Validate()") {
+		t.Error("expected no validators and marshal checks from the plain generator")
+	}
+	if !strings.Contains(sequential[1], "CheckBeforeMarshal") || !strings.Contains(sequential[1], ") Validate()") {
+		t.Error("expected validators and marshal checks from the checking generator")
+	}
+	if !reflect.DeepEqual(PkgGen.GenOptions, pkgGen) {
+		t.Error("expected PkgGen to be left alone")
+	}
+}
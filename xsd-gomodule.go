@@ -0,0 +1,152 @@
+package xsd
+
+import (
+	"go/token"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/metaleap/go-util-fs"
+	"github.com/metaleap/go-util-str"
+)
+
+//	The module path of go-xsd itself, which all generated packages import (for the xsdt package) and the go.mod files written by MakeGoModule may require.
+const GoXsdModulePath = "github.com/metaleap/go-xsd"
+
+//	Configures the Go module written by MakeGoModule.
+type GoModuleOptions struct {
+	//	The module path declared by the go.mod file, such as "example.com/mymod/xsd". The package generated for each target namespace
+	//	is imported from a subdirectory of it.
+	Path string
+
+	//	The Go version declared by the go.mod file, defaulting to "1.13".
+	GoVersion string
+
+	//	Maps module paths to the versions required by the go.mod file, such as GoXsdModulePath to the version of go-xsd the code is generated with.
+	//	If GoXsdModulePath is not mapped, its requirement is left to "go mod tidy" (or "go get").
+	Require map[string]string
+}
+
+//	Calls PkgGen.MakeGoModule for this schema.
+func (me *Schema) MakeGoModule(dirPath string, opts GoModuleOptions) (filePaths []string, diags Diagnostics, err error) {
+	return PkgGen.MakeGoModule(me, dirPath, opts)
+}
+
+//	Like MakeGoModule of a SchemaSet holding only sd and generating with this Generator.
+func (me *Generator) MakeGoModule(sd *Schema, dirPath string, opts GoModuleOptions) (filePaths []string, diags Diagnostics, err error) {
+	return (&SchemaSet{Schemas: []*Schema{sd}, Packages: map[string]*GoPkgOptions{}, Generator: me}).MakeGoModule(dirPath, opts)
+}
+
+//	Like MakeGoPkgSrcFiles, but generates a Go module into dirPath, rather than loose packages next to the XSD files: a go.mod file declaring opts.Path,
+//	a doc.go file for the (otherwise empty) root package listing the generated packages, and one subpackage per target namespace. Subpackages are
+//	named after the last segment of their namespace (such as "order" for "urn:example:order" or "atom" for "http://www.w3.org/2005/Atom", skipping
+//	version segments such as "v1" or "2005"), or after the XSD file for schemas without a target namespace, made unique by appending a number.
+//	The Packages of this set (and those of its Generator) still take precedence for the namespaces they map. The paths of the go.mod and doc.go files
+//	and of the Go source files generated for all packages are returned.
+func (me *SchemaSet) MakeGoModule(dirPath string, opts GoModuleOptions) (filePaths []string, diags Diagnostics, err error) {
+	var srcFilePaths, namespaces []string
+	var taken = map[string]bool{}
+	var pkgs = map[string]*GoPkgOptions{}
+	var origPackages = me.Packages
+	defer func() {
+		me.Packages = origPackages
+	}()
+	for _, sd := range me.pkgSchemas() {
+		ns := sd.TargetNamespace.String()
+		if _, ok := pkgs[ns]; !ok {
+			var pkg GoPkgOptions
+			if known := origPackages[ns]; known != nil {
+				pkg = *known
+			} else if known = me.generator().Packages[ns]; known != nil {
+				pkg = *known
+			}
+			if len(pkg.Name) == 0 {
+				pkg.Name = goModulePkgName(ns, sd.loadUri)
+			}
+			for name, i := pkg.Name, 2; taken[pkg.Name]; i++ {
+				pkg.Name = sfmt("%s%d", name, i)
+			}
+			taken[pkg.Name] = true
+			if len(pkg.Dir) == 0 {
+				pkg.Dir = filepath.Join(dirPath, pkg.Name)
+			}
+			if len(pkg.ImportPath) == 0 {
+				pkg.ImportPath = path.Join(opts.Path, pkg.Name)
+			}
+			pkgs[ns], namespaces = &pkg, append(namespaces, ns)
+		}
+	}
+	me.Packages = pkgs
+	if srcFilePaths, diags, err = me.MakeGoPkgSrcFiles(); err == nil {
+		if err = ufs.EnsureDirExists(dirPath); err == nil {
+			filePaths = []string{filepath.Join(dirPath, "go.mod"), filepath.Join(dirPath, "doc.go")}
+			if err = me.generator().writeSourceFile(filePaths[0], []byte(goModFile(opts))); err == nil {
+				err = me.generator().writeSourceFile(filePaths[1], []byte(goModuleDocFile(opts.Path, namespaces, pkgs)))
+			}
+			filePaths = append(filePaths, srcFilePaths...)
+		}
+	}
+	return
+}
+
+//	Returns the contents of the go.mod file for opts.
+func goModFile(opts GoModuleOptions) string {
+	var mods []string
+	var lines = []string{"module " + opts.Path, "", "go " + ustr.Ifs(len(opts.GoVersion) > 0, opts.GoVersion, "1.13")}
+	for mod, _ := range opts.Require {
+		mods = append(mods, mod)
+	}
+	if sort.Strings(mods); len(mods) > 0 {
+		lines = append(lines, "", "require (")
+		for _, mod := range mods {
+			lines = append(lines, "\t"+mod+" "+opts.Require[mod])
+		}
+		lines = append(lines, ")")
+	}
+	return strings.Join(lines, "\n") + "\n"
+}
+
+//	Returns the contents of the doc.go file of the root package of the module at modulePath, listing the packages generated for the specified namespaces.
+func goModuleDocFile(modulePath string, namespaces []string, pkgs map[string]*GoPkgOptions) string {
+	var name = goModulePkgName(modulePath, "")
+	var lines = []string{"// Auto-generated by the \"go-xsd\" package located at:", "//", "//\t" + GoXsdModulePath, "", "// Package " + name + " holds the Go packages generated from XML Schema Definitions, one per target namespace:", "//"}
+	for _, ns := range namespaces {
+		lines = append(lines, "//\t"+pkgs[ns].ImportPath+" - "+ustr.Ifs(len(ns) > 0, ns, "(no namespace)"))
+	}
+	return strings.Join(append(lines, "package "+name), "\n") + "\n"
+}
+
+//	Returns a Go package name derived from the last segment of namespace (that is not a version, such as "v1" or "2005"),
+//	or else from the file name of schemaUri (without its extension).
+func goModulePkgName(namespace, schemaUri string) (name string) {
+	var isVersion = func(seg string) bool {
+		return len(strings.TrimLeft(strings.TrimLeft(seg, "vV"), "0123456789.")) == 0
+	}
+	segs := strings.FieldsFunc(namespace, func(r rune) bool { return (r == '/') || (r == ':') || (r == '#') })
+	for i := len(segs) - 1; (i >= 0) && (len(name) == 0); i-- {
+		if !isVersion(segs[i]) {
+			name = goPkgIdent(strings.TrimSuffix(strings.TrimSuffix(segs[i], ".xsd"), ".xml"))
+		}
+	}
+	if base := path.Base(schemaUri); (len(name) == 0) && (len(schemaUri) > 0) {
+		name = goPkgIdent(strings.TrimSuffix(base, path.Ext(base)))
+	}
+	if len(name) == 0 {
+		name = "xsd"
+	} else if unicode.IsDigit(rune(name[0])) || token.Lookup(name).IsKeyword() {
+		name = "xsd" + name
+	}
+	return
+}
+
+//	Returns s lower-cased and stripped of all characters other than letters and digits.
+func goPkgIdent(s string) string {
+	return strings.Map(func(r rune) rune {
+		if (r < unicode.MaxASCII) && (unicode.IsLetter(r) || unicode.IsDigit(r)) {
+			return unicode.ToLower(r)
+		}
+		return -1
+	}, s)
+}
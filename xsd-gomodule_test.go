@@ -0,0 +1,39 @@
+package xsd
+
+import (
+	"context"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+//	Tests that MakeGoModule writes a go.mod declaring the module path, a doc.go and one subpackage per target namespace, importing each other
+//	by their module paths, and that the module builds.
+func TestMakeGoModule(t *testing.T) {
+	var fileNames []string
+	gopath := t.TempDir()
+	dirPath := filepath.Join(gopath, "src", "example.com", "xsdmod")
+	set, err := NewSchemaCache(0).LoadSchemaDir(context.Background(), filepath.Join("testdata", "schemadir"), LoadOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	filePaths, _, err := set.MakeGoModule(dirPath, GoModuleOptions{Path: "example.com/xsdmod"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, filePath := range filePaths {
+		rel, _ := filepath.Rel(dirPath, filePath)
+		fileNames = append(fileNames, filepath.ToSlash(rel))
+	}
+	if strings.Join(fileNames, " ") != "go.mod doc.go common/money.xsd.go orders/order.xsd.go" {
+		t.Fatalf("unexpected files %v", fileNames)
+	}
+	if goMod, _ := ioutil.ReadFile(filePaths[0]); !strings.HasPrefix(string(goMod), "module example.com/xsdmod\n\ngo 1.13\n") {
+		t.Errorf("unexpected go.mod:\n%s", goMod)
+	}
+	if src, _ := ioutil.ReadFile(filePaths[3]); !strings.Contains(string(src), `c "example.com/xsdmod/common"`) {
+		t.Errorf("expected orders to import common by its module path:\n%s", src)
+	}
+	goTool(t, gopath, filePaths[1], "build", "./...")
+}
@@ -0,0 +1,89 @@
+package xsd
+
+//	The values of PkgGen.Groups (and PkgGen.GroupModes), denoting how the struct types of complex types (and of other groups) hold the members of the
+//	xs:groups and xs:attributeGroups they refer to.
+const (
+	//	A struct type is generated for every named group (such as XsdGoPkgHasGroup_AddressGroup or XsdGoPkgHasAtts_CommonAtts) and embedded
+	//	by all the struct types referring to it, so that code handling the group's members can be shared.
+	GroupsEmbed = ""
+
+	//	The members of the group are embedded directly by all the struct types referring to it, as if declared there. The struct type of the group is still
+	//	generated (for other packages and the groups kept embedded), but none of the struct types of this package embeds it.
+	GroupsFlatten = "flatten"
+)
+
+//	Returns the PkgGen.Groups mode (GroupsEmbed or GroupsFlatten) applying to the xs:group or xs:attributeGroup of the specified name,
+//	which PkgGen.GroupModes may override.
+func (me *Generator) groupMode(name string) string {
+	if mode, ok := me.GroupModes[name]; ok {
+		return mode
+	}
+	return me.Groups
+}
+
+//	Replaces, in every struct type of this package (including those of the same name generated more than once), each embed of the struct type of a named xs:group or xs:attributeGroup of this package whose groupMode is
+//	GroupsFlatten by the embeds and fields of that struct type (flattening the groups it refers to first). Called before addChoiceUnions and addMarshalMethods,
+//	so that these (and the Walk(), ApplyDefaults() and Validate() methods rendered from the embeds) see the flattened members.
+func (me *PkgBag) flattenGroups() {
+	var done = map[*declType]bool{}
+	if (len(me.gen.Groups) > 0) || (len(me.gen.GroupModes) > 0) {
+		for _, tn := range me.sortedTypeNames() {
+			me.flattenGroupEmbeds(me.declTypes[tn], done)
+		}
+		for _, dts := range me.declElemTypes {
+			for _, dt := range dts {
+				if dt != nil {
+					me.flattenGroupEmbeds(dt, done)
+				}
+			}
+		}
+	}
+}
+
+func (me *PkgBag) flattenGroupEmbeds(dt *declType, done map[*declType]bool) {
+	if done[dt] {
+		return
+	}
+	done[dt] = true
+	for _, e := range dt.sortedEmbeds() {
+		var name string
+		gdt := me.declTypes[e.Name]
+		if gdt != nil {
+			switch gel := gdt.elem.(type) {
+			case *Group:
+				name = gel.Name.String()
+			case *AttributeGroup:
+				name = gel.Name.String()
+			}
+		}
+		if (len(name) == 0) || (me.gen.groupMode(name) != GroupsFlatten) || (len(gdt.Type) > 0) {
+			continue
+		}
+		me.flattenGroupEmbeds(gdt, done)
+		delete(dt.Embeds, e.Name)
+		for _, ge := range gdt.sortedEmbeds() {
+			if _, exists := dt.Embeds[ge.Name]; !exists {
+				fe := dt.addEmbed(ge.elem, ge.Name, ge.Annotations...)
+				fe.via = append(append(append(fe.via, e.via...), e.elem), ge.via...)
+			}
+		}
+		for _, f := range gdt.sortedFields() {
+			if _, exists := dt.Fields[f.Name]; !exists {
+				ff := *f
+				ff.via = append(append(append([]element{}, e.via...), e.elem), f.via...)
+				dt.Fields[f.Name] = &ff
+			}
+		}
+		dt.defaults, dt.fixeds = append(dt.defaults, gdt.defaults...), append(dt.fixeds, gdt.fixeds...)
+	}
+}
+
+//	Returns refs followed by the element group references in via, the group references that an embed or field was flattened from (see flattenGroups).
+func groupRefs(refs, via []element) []element {
+	for _, el := range via {
+		if _, isGroup := el.(*Group); isGroup {
+			refs = append(append([]element{}, refs...), el)
+		}
+	}
+	return refs
+}
@@ -0,0 +1,32 @@
+package xsd
+
+import (
+	"strings"
+	"testing"
+)
+
+//	Tests that the struct types of complex types embed a shared struct type per xs:group and xs:attributeGroup, or their members directly
+//	(in document order), as selected by PkgGen.Groups and overridden per group by PkgGen.GroupModes.
+func TestGroupModes(t *testing.T) {
+	for _, c := range []struct {
+		groups     string
+		groupModes map[string]string
+		embeds     string
+	}{
+		{GroupsEmbed, nil, "HasGroup_Names HasElem_Phone HasGroup_Address HasAtts_Ids"},
+		{GroupsFlatten, nil, "HasElem_First HasElem_Last HasElem_Phone HasElem_Street HasAttr_Id_XsdtString_"},
+		{GroupsEmbed, map[string]string{"Names": GroupsFlatten}, "HasElem_First HasElem_Last HasElem_Phone HasGroup_Address HasAtts_Ids"},
+		{GroupsFlatten, map[string]string{"Address": GroupsEmbed}, "HasElem_First HasElem_Last HasElem_Phone HasGroup_Address HasAttr_Id_XsdtString_"},
+	} {
+		var embeds []string
+		src, _ := genTestSrc(t, "groups", "contact.xsd", func(opts *GenOptions) { opts.Groups, opts.GroupModes = c.groups, c.groupModes })
+		for _, line := range strings.Split(goTypeDecl(t, src, "TContact"), "\n") {
+			if line = strings.TrimSpace(line); strings.HasPrefix(line, idPrefix) {
+				embeds = append(embeds, strings.SplitN(strings.TrimPrefix(line, idPrefix), "sequence", 2)[0])
+			}
+		}
+		if s := strings.Join(embeds, " "); s != c.embeds {
+			t.Errorf("Groups %q, GroupModes %v: expected the embeds %s, got %s", c.groups, c.groupModes, c.embeds, s)
+		}
+	}
+}
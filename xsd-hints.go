@@ -0,0 +1,117 @@
+package xsd
+
+import (
+	"context"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/metaleap/go-util-str"
+)
+
+//	A schema location hint of an XML instance document: the value of an xsi:noNamespaceSchemaLocation attribute (with an empty Namespace),
+//	or one namespace-location pair of an xsi:schemaLocation attribute.
+type SchemaLocationHint struct {
+	Namespace, Location string
+}
+
+//	Returns the schema location hints of all elements of the XML instance document read from r, in document order and without duplicates.
+//	Hints may be given on any element, so all of r is read.
+func ScanSchemaLocations(r io.Reader) (hints []SchemaLocationHint, err error) {
+	var tok xml.Token
+	var done = map[SchemaLocationHint]bool{}
+	add := func(hint SchemaLocationHint) {
+		if !done[hint] {
+			done[hint], hints = true, append(hints, hint)
+		}
+	}
+	for xd := xml.NewDecoder(r); err == nil; {
+		if tok, err = xd.Token(); err == nil {
+			if start, ok := tok.(xml.StartElement); ok {
+				for _, att := range start.Attr {
+					if att.Name.Space != xsiNamespaceUri {
+						continue
+					} else if att.Name.Local == "noNamespaceSchemaLocation" {
+						for _, loc := range strings.Fields(att.Value) {
+							add(SchemaLocationHint{Location: loc})
+						}
+					} else if att.Name.Local == "schemaLocation" {
+						pairs := strings.Fields(att.Value)
+						for i := 1; i < len(pairs); i += 2 {
+							add(SchemaLocationHint{Namespace: pairs[i-1], Location: pairs[i]})
+						}
+					}
+				}
+			}
+		}
+	}
+	if err == io.EOF {
+		err = nil
+	}
+	return
+}
+
+//	Loads the schemas referenced by the xsi:schemaLocation and xsi:noNamespaceSchemaLocation hints of the XML instance document read from r
+//	(see ScanSchemaLocations), as LoadSchema does with localCopy being true: so PkgGen.Resolver and PkgGen.Catalog apply, and hints without
+//	a protocol are taken as http:// URIs (or the local copies of these). Returns them as a new SchemaSet (with a SchemaCache of its own),
+//	ready to validate the instance document with SchemaSet.Validate, such as:
+//		set, err := xsd.LoadSchemasForInstance(bytes.NewReader(doc))
+//		if err == nil { errs, err = set.Validate(bytes.NewReader(doc)) }
+func LoadSchemasForInstance(r io.Reader) (set *SchemaSet, err error) {
+	set = NewSchemaSet()
+	if err = set.LoadForInstance(context.Background(), r, "", true, LoadOptions{}); err != nil {
+		set = nil
+	}
+	return
+}
+
+//	Like LoadSchemasForInstance, but loads into this set (see Load), resolves hints without a protocol relative to baseUri (the URI of the
+//	instance document, if any, in the form passed to LoadSchema), and aborts as soon as ctx is done. Hints of namespaces that the Schemas of this
+//	set already declare, and of schemas already in it, are skipped.
+func (me *SchemaSet) LoadForInstance(ctx context.Context, r io.Reader, baseUri string, localCopy bool, opts LoadOptions) (err error) {
+	var hints []SchemaLocationHint
+	var sd *Schema
+	if hints, err = ScanSchemaLocations(r); (err == nil) && (len(hints) == 0) {
+		err = errors.New("the instance document has no xsi:schemaLocation or xsi:noNamespaceSchemaLocation hints")
+	}
+	for _, hint := range hints {
+		if err != nil {
+			break
+		} else if me.declares(hint.Namespace) && (len(hint.Namespace) > 0) {
+			continue
+		}
+		location := hint.Location
+		if (len(baseUri) > 0) && !strings.Contains(location, protSep) {
+			protocol, base := splitUri(baseUri, "")
+			_, location = splitUri(location, base)
+			location = ustr.Ifs(strings.Contains(baseUri, protSep), protocol+location, location)
+		}
+		if sd, err = me.Load(ctx, location, localCopy, opts); (err == nil) && (sd.TargetNamespace.String() != hint.Namespace) {
+			err = fmt.Errorf("the schema %s hinted at for the namespace %q has the target namespace %q", hint.Location, hint.Namespace, sd.TargetNamespace.String())
+		}
+	}
+	return
+}
+
+//	Returns whether any of the Schemas of this set has the specified target namespace.
+func (me *SchemaSet) declares(namespace string) bool {
+	for _, sd := range me.Schemas {
+		if sd.TargetNamespace.String() == namespace {
+			return true
+		}
+	}
+	return false
+}
+
+//	Checks the XML instance document read from r against the Schemas of this set together (and all schemas they include or import), as Schema.Validate does.
+func (me *SchemaSet) Validate(r io.Reader) (errs []ValidationError, err error) {
+	return compileSchemas(me.Schemas...).Validate(r)
+}
+
+//	Returns the default and fixed attributes absent from the elements of the XML instance document read from r, as assigned by the Schemas of this set
+//	together (and all schemas they include or import), as Schema.DefaultAttributes does.
+func (me *SchemaSet) DefaultAttributes(r io.Reader) (defaults [][]xml.Attr, err error) {
+	return compileSchemas(me.Schemas...).DefaultAttributes(r)
+}
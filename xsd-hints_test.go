@@ -0,0 +1,46 @@
+package xsd
+
+import (
+	"context"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+//	Tests that the schemas hinted at by the xsi:schemaLocation of an instance document are loaded relative to the URI of the document, that hints
+//	of mismatching namespaces fail and documents without hints fail, and that the resulting SchemaSet validates the document.
+func TestLoadForInstance(t *testing.T) {
+	codePath := t.TempDir()
+	if err := copyTestdata(filepath.Join("testdata", "validate"), filepath.Join(codePath, "validate")); err != nil {
+		t.Fatal(err)
+	}
+	opts := DefaultGenOptions()
+	opts.BaseCodePath, opts.Offline = codePath, true
+	load := func(doc string) (set *SchemaSet, err error) {
+		set = NewSchemaSet()
+		err = set.LoadForInstance(context.Background(), strings.NewReader(doc), "validate/instance.xml", true, LoadOptions{Generator: NewGenerator(opts)})
+		return
+	}
+	item := "<item><sku>ABC-123</sku><qty>2</qty></item>"
+	doc := `<order xmlns="urn:example:validate" xmlns:xsi="http://www.w3.org/2001/XMLSchema-instance" xsi:schemaLocation="urn:example:validate order.xsd" id="1"><date>2020-02-29</date>` + item + `</order>`
+	set, err := load(doc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if (len(set.Schemas) != 1) || (set.Schemas[0].TargetNamespace.String() != "urn:example:validate") {
+		t.Fatalf("expected the order schema to be loaded, got %v", set.Schemas)
+	}
+	if errs, err := set.Validate(strings.NewReader(doc)); (err != nil) || (len(errs) > 0) {
+		t.Errorf("expected the document to be valid, got %v %v", errs, err)
+	}
+	invalid := strings.Replace(doc, "ABC-123", "abc", 1)
+	if errs, err := set.Validate(strings.NewReader(invalid)); (err != nil) || (len(errs) == 0) || (errs[0].Path != "/order/item/sku") {
+		t.Errorf("expected an error at /order/item/sku, got %v %v", errs, err)
+	}
+	if _, err = load(strings.Replace(doc, "urn:example:validate order.xsd", "urn:example:other order.xsd", 1)); err == nil {
+		t.Error("expected an error for a hint of a mismatching namespace")
+	}
+	if _, err = load(`<order xmlns="urn:example:validate" id="1"/>`); err == nil {
+		t.Error("expected an error for a document without hints")
+	}
+}
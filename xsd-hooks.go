@@ -0,0 +1,82 @@
+package xsd
+
+import (
+	"strings"
+	"time"
+)
+
+//	Callbacks reporting the progress of loading schemas and generating Go code from them (see PkgGen.Hooks), such as for progress bars or to find out
+//	which of many includes is slow or failing. Any of them may be nil. As schema documents are loaded concurrently (see PkgGen.MaxConcurrentLoads),
+//	and Go packages may be generated concurrently, too, the callbacks must be safe for concurrent use, and should return quickly.
+type Hooks struct {
+	//	Called when the fetching of the schema (or WSDL) document at the specified (protocol-less) uri begins.
+	OnSchemaLoadStart func(uri string)
+
+	//	Called when the schema (or WSDL) document at the specified (protocol-less) uri was fetched and decoded (but its references not yet resolved),
+	//	or failed to: with the path of its local copy (if any), the time it took and the error (if any).
+	OnSchemaLoadEnd func(uri, localPath string, elapsed time.Duration, err error)
+
+	//	Called when the xs:include, xs:redefine, xs:override or xs:import (see the Dependency* kinds) of schemaLocation in the schema document
+	//	at the specified uri was resolved to the schema document at resolvedUri (loaded, or taken from the cache), or failed to.
+	OnIncludeResolved func(uri, kind, schemaLocation, resolvedUri string, err error)
+
+	//	Called for every Go type declaration generated (that is, neither pruned nor found equivalent to another) into the Go package of the schema at uri.
+	OnTypeGenerated func(uri, goTypeName string)
+}
+
+//	Returns Hooks that report every event to logf (such as log.Printf), for debugging slow or failing loads.
+func LogHooks(logf func(format string, args ...interface{})) *Hooks {
+	return &Hooks{
+		OnSchemaLoadStart: func(uri string) {
+			logf("FETCH:\t%s\n", uri)
+		},
+		OnSchemaLoadEnd: func(uri, localPath string, elapsed time.Duration, err error) {
+			if err != nil {
+				logf("FETCHED:\t%s (%v): %v\n", uri, elapsed, err)
+			} else {
+				logf("FETCHED:\t%s (%v)\n", uri, elapsed)
+			}
+		},
+		OnIncludeResolved: func(uri, kind, schemaLocation, resolvedUri string, err error) {
+			if err != nil {
+				logf("%s:\t%s: %s: %v\n", strings.ToUpper(kind), uri, schemaLocation, err)
+			} else {
+				logf("%s:\t%s: %s -> %s\n", strings.ToUpper(kind), uri, schemaLocation, resolvedUri)
+			}
+		},
+		OnTypeGenerated: func(uri, goTypeName string) {
+			logf("TYPE:\t%s: %s\n", uri, goTypeName)
+		},
+	}
+}
+
+//	Calls Hooks.OnSchemaLoadStart, if any, and returns a func calling Hooks.OnSchemaLoadEnd, if any, with the time elapsed since.
+func (me *Generator) hookSchemaLoad(uri string) func(localPath string, err error) {
+	var start = time.Now()
+	if (me.Hooks != nil) && (me.Hooks.OnSchemaLoadStart != nil) {
+		me.Hooks.OnSchemaLoadStart(uri)
+	}
+	return func(localPath string, err error) {
+		if (me.Hooks != nil) && (me.Hooks.OnSchemaLoadEnd != nil) {
+			me.Hooks.OnSchemaLoadEnd(uri, localPath, time.Since(start), err)
+		}
+	}
+}
+
+//	Calls Hooks.OnIncludeResolved, if any, for the reference of the specified kind to schemaLocation by sd, resolved to resolved (unless err).
+func (me *Generator) hookIncludeResolved(sd *Schema, kind, schemaLocation string, resolved *Schema, err error) {
+	if (me.Hooks != nil) && (me.Hooks.OnIncludeResolved != nil) {
+		var resolvedUri string
+		if (err == nil) && (resolved != nil) {
+			resolvedUri = resolved.loadUri
+		}
+		me.Hooks.OnIncludeResolved(sd.loadUri, kind, schemaLocation, resolvedUri, err)
+	}
+}
+
+//	Calls Hooks.OnTypeGenerated, if any, for the Go type declaration goTypeName generated into the Go package of sd.
+func (me *Generator) hookTypeGenerated(sd *Schema, goTypeName string) {
+	if (me.Hooks != nil) && (me.Hooks.OnTypeGenerated != nil) {
+		me.Hooks.OnTypeGenerated(sd.loadUri, goTypeName)
+	}
+}
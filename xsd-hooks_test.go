@@ -0,0 +1,76 @@
+package xsd
+
+import (
+	"context"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+//	Tests that the Hooks of the Generator report the loading of every schema document, the resolution of every include and import
+//	(including imports resolved by namespace among the documents of a directory) and every Go type generated.
+func TestHooks(t *testing.T) {
+	var mutex sync.Mutex
+	var events []string
+	add := func(event string) {
+		mutex.Lock()
+		events = append(events, event)
+		mutex.Unlock()
+	}
+	opts := DefaultGenOptions()
+	opts.Hooks = &Hooks{
+		OnSchemaLoadStart: func(uri string) {
+			add("start " + uri)
+		},
+		OnSchemaLoadEnd: func(uri, localPath string, elapsed time.Duration, err error) {
+			if (err != nil) || (filepath.Base(localPath) != filepath.Base(uri)) {
+				t.Errorf("loading %s: local path %q, error %v", uri, localPath, err)
+			}
+			add("end " + uri)
+		},
+		OnIncludeResolved: func(uri, kind, schemaLocation, resolvedUri string, err error) {
+			if err != nil {
+				t.Errorf("%s %s by %s: %v", kind, schemaLocation, uri, err)
+			}
+			add(kind + " " + uri + " -> " + resolvedUri)
+		},
+		OnTypeGenerated: func(uri, goTypeName string) {
+			add("type " + uri + " " + goTypeName)
+		},
+	}
+	gen := NewGenerator(opts)
+	set, err := NewSchemaCache(0).LoadSchemaDir(context.Background(), filepath.Join("testdata", "schemadir"), LoadOptions{Generator: gen})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, sd := range set.Schemas {
+		if _, _, err = gen.GenerateGoSourceAs(sd, ""); err != nil {
+			t.Fatal(err)
+		}
+	}
+	sort.Strings(events)
+	have := map[string]bool{}
+	for _, event := range events {
+		have[event] = true
+	}
+	for _, expected := range []string{
+		"start schemadir/common/currency.xsd",
+		"end schemadir/common/currency.xsd",
+		"start schemadir/common/money.xsd",
+		"end schemadir/common/money.xsd",
+		"start schemadir/orders/order.xsd",
+		"end schemadir/orders/order.xsd",
+		"include schemadir/common/money.xsd -> schemadir/common/currency.xsd",
+		"import schemadir/orders/order.xsd -> schemadir/common/money.xsd",
+		"type schemadir/common/money.xsd TCurrency",
+		"type schemadir/common/money.xsd TMoney",
+		"type schemadir/orders/order.xsd XsdGoPkgHasElem_Total",
+	} {
+		if !have[expected] {
+			t.Errorf("missing hook event %q in:\n%s", expected, strings.Join(events, "\n"))
+		}
+	}
+}
@@ -0,0 +1,235 @@
+package xsd
+
+import (
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/metaleap/go-util-str"
+)
+
+//	Rules for deriving Go identifiers from the names of schema components (see PkgGen.Identifiers), applied in the order of the fields below.
+//	Every name maps to the same identifier wherever it is declared or referred to, so that the Go packages generated for schemas importing
+//	one another agree on the identifiers of their declarations.
+type IdentifierRules struct {
+	//	Words written as given (such as "ID" or "URL") wherever they occur as a whole word of a name, matched case-insensitively. Names are split into
+	//	words at hyphens, dots, underscores and all other characters not allowed in Go identifiers, and at lower-to-upper-case transitions (keeping
+	//	acronyms together), and all other words are joined with their first letters upper-cased: so "customer-id" and "customerId" both become "CustomerID".
+	Initialisms []string
+
+	//	Identifiers that get ReservedSuffix (or "_" if empty) appended in addition to the names of the methods generated for struct types (and "XMLName"),
+	//	which fields of the same names would clash with, and which always get it. (Go keywords need no suffix, as all generated identifiers are upper-case.)
+	Reserved       []string
+	ReservedSuffix string
+
+	//	If true, distinct names declared in the schema documents of one Go package that still map to the same identifier (such as "item" and "Item",
+	//	or "first-name" and "firstName") get numbered identifiers: in the byte-wise order of the names, the first keeps its identifier, and the others
+	//	get the lowest number from 2 on appended that makes them unique.
+	NumberCollisions bool
+}
+
+//	The names of the methods generated for struct types (and "XMLName"), which fields of the same names would clash with: names mapping to
+//	them get IdentifierRules.ReservedSuffix appended, or "_" if PkgGen.Identifiers is nil.
+var reservedIdentifiers = []string{"ApplyDefaults", "ApplyFixed", "CheckBeforeMarshal", "CheckOccurrences", "Clone", "Equal", "FromDTO", "MarshalCSV", "MarshalCSVRecord", "MarshalSoap", "MarshalXML", "ToDTO", "UnmarshalCSV", "UnmarshalCSVRecord", "UnmarshalSoap", "UnmarshalXML", "Validate", "Walk", "XMLName"}
+
+//	Returns IdentifierRules with the initialisms commonly written in all caps in Go code, "_" as ReservedSuffix, and NumberCollisions set.
+func DefaultIdentifierRules() *IdentifierRules {
+	return &IdentifierRules{
+		Initialisms:      []string{"ACL", "API", "ASCII", "CPU", "CSS", "DNS", "EOF", "GUID", "HTML", "HTTP", "HTTPS", "ID", "IP", "JSON", "QPS", "RAM", "RPC", "SLA", "SMTP", "SQL", "SSH", "TCP", "TLS", "TTL", "UDP", "UI", "UID", "UUID", "URI", "URL", "UTF8", "VM", "XML", "XMPP", "XSRF", "XSS"},
+		ReservedSuffix:   "_",
+		NumberCollisions: true,
+	}
+}
+
+//	Returns the identifier for name according to Initialisms and Reserved (and the reservedIdentifiers), and whether it is reserved.
+func (me *IdentifierRules) identifier(name string) (id string, reserved bool) {
+	for _, word := range jsonTagWords(name) {
+		initialism := ""
+		for _, ini := range me.Initialisms {
+			if strings.EqualFold(ini, word) {
+				initialism = ini
+			}
+		}
+		if runes := []rune(word); len(initialism) > 0 {
+			id += initialism
+		} else {
+			runes[0] = unicode.ToUpper(runes[0])
+			id += string(runes)
+		}
+	}
+	if (len(id) > 0) && unicode.IsDigit([]rune(id)[0]) {
+		id = "N" + id
+	}
+	if reserved = isReservedIdentifier(id); !reserved {
+		for _, res := range me.Reserved {
+			reserved = reserved || (id == res)
+		}
+	}
+	if reserved {
+		id += me.suffix()
+	}
+	return
+}
+
+//	Returns the identifier for name if PkgGen.Identifiers is nil: name with all characters not allowed in Go identifiers dropped and its first letter
+//	upper-cased, with "_" appended if it is one of the reservedIdentifiers, and whether it is.
+func plainIdentifier(name string) (id string, reserved bool) {
+	if id = ustr.SafeIdentifier(name); isReservedIdentifier(id) {
+		return id + "_", true
+	}
+	return
+}
+
+func isReservedIdentifier(id string) bool {
+	for _, res := range reservedIdentifiers {
+		if id == res {
+			return true
+		}
+	}
+	return false
+}
+
+//	Returns ReservedSuffix, or "_" if empty.
+func (me *IdentifierRules) suffix() string {
+	return ustr.Ifs(len(me.ReservedSuffix) > 0, me.ReservedSuffix, "_")
+}
+
+//	A name declared in the schema documents of a Go package, see PkgBag.identifiers.
+type identifierDecl struct {
+	kind string
+	name string
+	el   element
+}
+
+//	Returns the identifier for the name of this declaration according to rules (see plainIdentifier if nil), and whether it is reserved. The key of a simple or complex type
+//	is its name with "T" prepended (unless it starts with a "T" already), as passed to safeNsName: its identifier is the one of its name, with "T"
+//	prepended likewise, so that "item" and "Item" both become "TItem" rather than only the latter.
+func (me *identifierDecl) identifier(rules *IdentifierRules, key string) (id string, reserved bool) {
+	if rules == nil {
+		return plainIdentifier(key)
+	} else if key == me.name {
+		return rules.identifier(key)
+	}
+	id, reserved = rules.identifier(me.name)
+	return ustr.PrependIf(id, "T"), reserved
+}
+
+//	Returns the Go identifier for the name of a construct (or other string) of the namespace ns: the one numbered for it (see
+//	IdentifierRules.NumberCollisions), or else the one derived from it by PkgGen.Identifiers, or by plainIdentifier if nil. Names of the XSD
+//	namespace (the built-in types) map to identifiers by merely dropping the characters not allowed in Go identifiers.
+func (me *PkgBag) safeNsName(ns, name string) (id string) {
+	if ns == xsdNamespaceUri {
+		return ustr.SafeIdentifier(name)
+	} else if id = me.identifiers(ns)[name]; len(id) == 0 {
+		if me.gen.Identifiers == nil {
+			id, _ = plainIdentifier(name)
+		} else {
+			id, _ = me.gen.Identifiers.identifier(name)
+		}
+	}
+	return
+}
+
+//	Returns the Go identifier for the prefix:local QName ref (see safeNsName) of the local name only, resolving its prefix like resolveQnameRef.
+func (me *PkgBag) safeRefName(ref string) string {
+	var ns = me.Schema.XMLNamespaces[""]
+	if pos := strings.Index(ref, ":"); pos >= 0 {
+		ns, ref = me.Schema.XMLNamespaces[ref[:pos]], ref[pos+1:]
+	}
+	return me.safeNsName(ns, ref)
+}
+
+//	Returns the numbered identifiers (see IdentifierRules.NumberCollisions) of the names declared by the schema documents of the Go package of
+//	the namespace ns (this package, or one that it imports), and the identifiers of their simple and complex types (see identifierDecl.identifier),
+//	collecting them on first use. For this package, reports every name whose identifier differs from the one it gets by merely dropping the characters
+//	not allowed in Go identifiers as a Diagnostic of SeverityInfo.
+func (me *PkgBag) identifiers(ns string) map[string]string {
+	var sd *Schema
+	var decls = map[string]*identifierDecl{}
+	var names []string
+	var ids = map[string][]string{}
+	if numbered, ok := me.renames[ns]; ok {
+		return numbered
+	}
+	numbered := map[string]string{}
+	if me.renames == nil {
+		me.renames = map[string]map[string]string{}
+	}
+	me.renames[ns] = numbered
+	if ns == me.Schema.TargetNamespace.String() {
+		sd = me.Schema
+	} else {
+		for _, inc := range me.Schema.allSchemas(map[string]bool{}) {
+			for _, imp := range inc.XMLImportedSchemas {
+				if (sd == nil) && (imp.TargetNamespace.String() == ns) {
+					sd = imp
+				}
+			}
+		}
+	}
+	if sd == nil {
+		return numbered
+	}
+	for _, inc := range sd.allSchemas(map[string]bool{}) {
+		inc.Walk(func(node SchemaNode) bool {
+			var decl *identifierDecl
+			switch el := node.Elem.(type) {
+			case *Element:
+				decl = &identifierDecl{"element", el.Name.String(), el}
+			case *Attribute:
+				decl = &identifierDecl{"attribute", el.Name.String(), el}
+			case *Group:
+				decl = &identifierDecl{"group", el.Name.String(), el}
+			case *AttributeGroup:
+				decl = &identifierDecl{"attributeGroup", el.Name.String(), el}
+			case *ComplexType:
+				decl = &identifierDecl{"complexType", el.Name.String(), el}
+			case *SimpleType:
+				decl = &identifierDecl{"simpleType", el.Name.String(), el}
+			}
+			if (decl != nil) && (len(decl.name) > 0) {
+				key := ustr.Ifs((decl.kind == "complexType") || (decl.kind == "simpleType"), ustr.PrependIf(decl.name, "T"), decl.name)
+				if decls[key] == nil {
+					decls[key], names = decl, append(names, key)
+				}
+			}
+			return true
+		})
+	}
+	sort.Strings(names)
+	taken := map[string]bool{}
+	for _, name := range names {
+		id, _ := decls[name].identifier(me.gen.Identifiers, name)
+		ids[id], taken[id] = append(ids[id], name), true
+	}
+	for _, name := range names {
+		id, _ := decls[name].identifier(me.gen.Identifiers, name)
+		if clashing := ids[id]; (me.gen.Identifiers != nil) && me.gen.Identifiers.NumberCollisions && (len(clashing) > 1) && (clashing[0] != name) {
+			for n := 2; len(numbered[name]) == 0; n++ {
+				if num := sfmt("%s%d", id, n); !taken[num] {
+					numbered[name], taken[num] = num, true
+				}
+			}
+		} else if name != decls[name].name {
+			numbered[name] = id
+		}
+	}
+	if sd == me.Schema {
+		suffix := "_"
+		if me.gen.Identifiers != nil {
+			suffix = me.gen.Identifiers.suffix()
+		}
+		for _, name := range names {
+			decl := decls[name]
+			id, reserved := decl.identifier(me.gen.Identifiers, name)
+			if num := numbered[name]; (len(num) > 0) && (num != id) {
+				me.report(decl.el, SeverityInfo, "identifier: %s %q is generated as %s, as %q is generated as %s", decl.kind, decl.name, num, decls[ids[id][0]].name, id)
+			} else if reserved {
+				me.report(decl.el, SeverityInfo, "identifier: %s %q is generated as %s, as %s is reserved", decl.kind, decl.name, id, strings.TrimSuffix(id, suffix))
+			} else if id != ustr.SafeIdentifier(name) {
+				me.report(decl.el, SeverityInfo, "identifier: %s %q is generated as %s", decl.kind, decl.name, id)
+			}
+		}
+	}
+	return numbered
+}
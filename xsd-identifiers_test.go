@@ -0,0 +1,59 @@
+package xsd
+
+import (
+	"strings"
+	"testing"
+)
+
+//	Tests that with DefaultIdentifierRules, names are joined in camel case with initialisms in all caps, reserved identifiers are suffixed, and types
+//	whose names differ only by case are numbered, with every rename reported as a diagnostic and the generated package still building.
+func TestIdentifierRules(t *testing.T) {
+	setup := func(opts *GenOptions) { opts.Identifiers = DefaultIdentifierRules() }
+	src, diags := genTestSrc(t, "identifiers", "names.xsd", setup)
+	var infos []string
+	for _, diag := range diags {
+		if diag.Severity == SeverityInfo {
+			infos = append(infos, diag.Message)
+		}
+	}
+	expected := []string{
+		`identifier: complexType "item" is generated as TItem2, as "Item" is generated as TItem`,
+		`identifier: element "customer-id" is generated as CustomerID`,
+		`identifier: element "validate" is generated as Validate_, as Validate is reserved`,
+	}
+	if strings.Join(infos, "\n") != strings.Join(expected, "\n") {
+		t.Errorf("expected the diagnostics\n%s\ngot\n%s", strings.Join(expected, "\n"), strings.Join(infos, "\n"))
+	}
+	for typeName, decl := range map[string]string{
+		"XsdGoPkgHasElem_Order": "Order *TItem2 `",
+		"XsdGoPkgHasElem_CustomerIdsequenceitemschema_CustomerID_XsdtString_": "CustomerID xsdt.String `",
+		"XsdGoPkgHasElem_Validatesequenceitemschema_Validate__XsdtBoolean_":   "Validate_ xsdt.Boolean `",
+		"XsdGoPkgHasElem_Typesequenceitemschema_Type_TItem_":                  "Type *TItem `",
+	} {
+		if d := goTypeDecl(t, src, typeName); !strings.Contains(d, decl) {
+			t.Errorf("expected %s in\n%s", decl, d)
+		}
+	}
+	gopath, goOutFilePaths := genTestPkgs(t, "identifiers", setup)
+	goTool(t, gopath, goOutFilePaths[0], "build")
+}
+
+//	Tests that names clashing with generated methods get suffixed, and reported as such, also with the default options (where PkgGen.Identifiers
+//	is nil) and with rules of one's own that reserve no identifiers.
+func TestReservedIdentifiers(t *testing.T) {
+	for id, rules := range map[string]*IdentifierRules{"Validate_": nil, "ValidateX": {Initialisms: []string{"ID"}, ReservedSuffix: "X"}} {
+		src, diags := genTestSrc(t, "identifiers", "names.xsd", func(opts *GenOptions) { opts.Identifiers = rules })
+		var infos []string
+		for _, diag := range diags {
+			if diag.Severity == SeverityInfo {
+				infos = append(infos, diag.Message)
+			}
+		}
+		if expected := `identifier: element "validate" is generated as ` + id + `, as Validate is reserved`; (len(infos) == 0) || (infos[len(infos)-1] != expected) || ((rules == nil) && (len(infos) != 1)) {
+			t.Errorf("expected the diagnostic %s, got\n%s", expected, strings.Join(infos, "\n"))
+		}
+		if d := goTypeDecl(t, src, "XsdGoPkgHasElem_Validatesequenceitemschema_"+id+"_XsdtBoolean_"); !strings.Contains(d, id+" xsdt.Boolean `") {
+			t.Errorf("expected the field %s in\n%s", id, d)
+		}
+	}
+}
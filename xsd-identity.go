@@ -0,0 +1,273 @@
+package xsd
+
+import (
+	"encoding/xml"
+	"strings"
+)
+
+//	An xs:key, xs:unique or xs:keyref identity constraint, as checked by Schema.Validate.
+type vIdentity struct {
+	kind        string
+	name, refer xml.Name
+	selector    []xpathPath
+	fields      [][]xpathPath
+	xpathsOk    bool
+}
+
+//	The element instances selected by an xs:key or xs:unique within one instance element (its scope), keyed by their field values.
+type vIdentityTable struct {
+	ident *vIdentity
+	scope *instNode
+	rows  map[string]*instNode
+}
+
+//	An instance element validated against an element declaration that has identity constraints.
+type vIdentityScope struct {
+	node *instNode
+	decl *Element
+}
+
+//	A location path of the restricted XPath subset permitted in the xpath of xs:selector and xs:field:
+//	an optional leading ".//" followed by "/"-separated child steps (and, for fields only, a final attribute step).
+type xpathPath struct {
+	descendants bool
+	steps       []xpathStep
+}
+
+type xpathStep struct {
+	name                           xml.Name
+	anyLocal, anySpace, attr, self bool
+}
+
+func newIdentity(owner *Schema, kind string, name, refer string, sel *Selector, fields []*Field) (me *vIdentity) {
+	var ok bool
+	me = &vIdentity{kind: kind, name: xml.Name{Space: owner.TargetNamespace.String(), Local: name}, xpathsOk: sel != nil}
+	if len(refer) > 0 {
+		me.refer = owner.qname(refer)
+	}
+	if sel != nil {
+		me.selector, ok = parseXpath(owner, sel.Xpath, false)
+		me.xpathsOk = ok && (len(fields) > 0)
+	}
+	for _, f := range fields {
+		paths, ok := parseXpath(owner, f.Xpath, true)
+		me.fields, me.xpathsOk = append(me.fields, paths), me.xpathsOk && ok
+	}
+	return
+}
+
+//	Returns the identity constraints of decl, with their selector and field XPaths parsed.
+func identitiesOf(decl *Element) (keys, uniques, keyRefs []*vIdentity) {
+	owner := ownerSchema(decl)
+	for _, k := range decl.Keys {
+		keys = append(keys, newIdentity(owner, "key", k.Name.String(), "", k.Selector, k.Fields))
+	}
+	for _, u := range decl.Uniques {
+		uniques = append(uniques, newIdentity(owner, "unique", u.Name.String(), "", u.Selector, u.Fields))
+	}
+	for _, kr := range decl.KeyRefs {
+		keyRefs = append(keyRefs, newIdentity(owner, "keyref", kr.Name.String(), kr.Refer.String(), kr.Selector, kr.Fields))
+	}
+	return
+}
+
+//	Checks the xs:key, xs:unique and xs:keyref constraints of all element declarations that instance elements were validated against:
+//	the field values of the elements selected by a key or unique must be distinct within each instance of the declaring element (and present, for keys),
+//	and those selected by a keyref must match the values of the referenced key or unique within the same instance (or within its descendants).
+func (me *validator) identityConstraints() {
+	var tables []*vIdentityTable
+	for _, sc := range me.idScopes {
+		keys, uniques, _ := identitiesOf(sc.decl)
+		for _, id := range append(keys, uniques...) {
+			if table := me.identityTable(sc.node, id); table != nil {
+				tables = append(tables, table)
+			}
+		}
+	}
+	for _, sc := range me.idScopes {
+		_, _, keyRefs := identitiesOf(sc.decl)
+		for _, id := range keyRefs {
+			me.keyRef(sc.node, id, tables)
+		}
+	}
+}
+
+func (me *validator) identityTable(n *instNode, id *vIdentity) (table *vIdentityTable) {
+	if !id.xpathsOk {
+		me.fail(n, "unsupported selector or field XPath in %s %q", id.kind, id.name.Local)
+		return
+	}
+	table = &vIdentityTable{ident: id, scope: n, rows: map[string]*instNode{}}
+	for _, t := range xpathSelect(n, id.selector) {
+		if key, vals, complete := me.identityKey(t, id); complete {
+			if table.rows[key] != nil {
+				me.fail(t, "duplicate value %q for %s %q", strings.Join(vals, ", "), id.kind, id.name.Local)
+			} else {
+				table.rows[key] = t
+			}
+		}
+	}
+	return
+}
+
+func (me *validator) keyRef(n *instNode, id *vIdentity, tables []*vIdentityTable) {
+	var rows = map[string]bool{}
+	if !id.xpathsOk {
+		me.fail(n, "unsupported selector or field XPath in keyref %q", id.name.Local)
+		return
+	}
+	for _, table := range tables {
+		if (table.ident.name == id.refer) && table.scope.isDescendantOf(n) {
+			for key, _ := range table.rows {
+				rows[key] = true
+			}
+		}
+	}
+	for _, t := range xpathSelect(n, id.selector) {
+		if key, vals, complete := me.identityKey(t, id); complete && !rows[key] {
+			me.fail(t, "value %q of keyref %q does not match any %s", strings.Join(vals, ", "), id.name.Local, id.refer.Local)
+		}
+	}
+}
+
+//	Returns the field values of the element t selected by id, and their concatenation used as the key of its table.
+//	complete is false if any field is absent, which is reported for keys (but not for uniques and keyrefs, which then disregard t).
+func (me *validator) identityKey(t *instNode, id *vIdentity) (key string, vals []string, complete bool) {
+	complete = true
+	for i, paths := range id.fields {
+		switch fv := xpathValues(t, paths); len(fv) {
+		case 0:
+			if complete = false; id.kind == "key" {
+				me.fail(t, "missing value for field %d of key %q", i+1, id.name.Local)
+			}
+		case 1:
+			vals = append(vals, fv[0])
+		default:
+			complete = false
+			me.fail(t, "field %d of %s %q selects more than one value", i+1, id.kind, id.name.Local)
+		}
+	}
+	key = strings.Join(vals, "\x00")
+	return
+}
+
+func (me *instNode) isDescendantOf(n *instNode) bool {
+	for p := me; p != nil; p = p.parent {
+		if p == n {
+			return true
+		}
+	}
+	return false
+}
+
+//	Parses the "|"-separated alternative paths of a selector (or, if field is true, field) XPath, resolving prefixed names against owner.
+//	Unprefixed names denote names without namespace, as in XSD 1.0. If the XPath is outside the supported subset, ok is false.
+func parseXpath(owner *Schema, xpath string, field bool) (paths []xpathPath, ok bool) {
+	for _, alt := range strings.Split(xpath, "|") {
+		var path xpathPath
+		if alt = strings.Join(strings.Fields(alt), ""); strings.HasPrefix(alt, ".//") {
+			path.descendants, alt = true, alt[3:]
+		}
+		parts := strings.Split(alt, "/")
+		for i, part := range parts {
+			var step xpathStep
+			if strings.HasPrefix(part, "child::") {
+				part = part[len("child::"):]
+			} else if strings.HasPrefix(part, "attribute::") {
+				step.attr, part = true, part[len("attribute::"):]
+			} else if strings.HasPrefix(part, "@") {
+				step.attr, part = true, part[1:]
+			}
+			if step.attr && ((!field) || (i < len(parts)-1)) {
+				return nil, false
+			}
+			switch {
+			case (part == ".") && !step.attr:
+				step.self = true
+			case part == "*":
+				step.anyLocal, step.anySpace = true, true
+			case strings.HasSuffix(part, ":*") && builtinPatterns["NCName"].MatchString(part[:len(part)-2]):
+				step.anyLocal, step.name.Space = true, owner.XMLNamespaces[part[:len(part)-2]]
+			case builtinPatterns["QName"].MatchString(part):
+				if step.name.Local = part; strings.Contains(part, ":") {
+					step.name = owner.qname(part)
+				}
+			default:
+				return nil, false
+			}
+			path.steps = append(path.steps, step)
+		}
+		paths = append(paths, path)
+	}
+	return paths, len(paths) > 0
+}
+
+func (me *xpathStep) matches(name xml.Name) bool {
+	return (me.anySpace || (name.Space == me.name.Space)) && (me.anyLocal || (name.Local == me.name.Local))
+}
+
+//	Returns the elements selected by the specified paths relative to n, in document order within each path.
+func xpathSelect(n *instNode, paths []xpathPath) (nodes []*instNode) {
+	var seen = map[*instNode]bool{}
+	for _, path := range paths {
+		for _, sel := range path.nodes(n) {
+			if !seen[sel] {
+				seen[sel], nodes = true, append(nodes, sel)
+			}
+		}
+	}
+	return
+}
+
+//	Returns the values of the attributes or (whitespace-trimmed) elements selected by the specified field paths relative to n.
+func xpathValues(n *instNode, paths []xpathPath) (vals []string) {
+	for _, path := range paths {
+		if last := len(path.steps) - 1; (last >= 0) && path.steps[last].attr {
+			att := path.steps[last]
+			path.steps = path.steps[:last]
+			for _, sel := range path.nodes(n) {
+				for _, a := range sel.atts {
+					if (!isSpecialAttr(a)) && att.matches(a.Name) {
+						vals = append(vals, strings.TrimSpace(a.Value))
+					}
+				}
+			}
+		} else {
+			for _, sel := range path.nodes(n) {
+				vals = append(vals, strings.TrimSpace(sel.text))
+			}
+		}
+	}
+	return
+}
+
+func (me *xpathPath) nodes(n *instNode) (nodes []*instNode) {
+	nodes = []*instNode{n}
+	if me.descendants {
+		nodes = n.descendantsOrSelf(nil)
+	}
+	for _, step := range me.steps {
+		var next []*instNode
+		for _, cur := range nodes {
+			if step.self {
+				next = append(next, cur)
+			} else {
+				for _, kid := range cur.kids {
+					if step.matches(kid.name) {
+						next = append(next, kid)
+					}
+				}
+			}
+		}
+		nodes = next
+	}
+	return
+}
+
+func (me *instNode) descendantsOrSelf(nodes []*instNode) []*instNode {
+	nodes = append(nodes, me)
+	for _, kid := range me.kids {
+		nodes = kid.descendantsOrSelf(nodes)
+	}
+	return nodes
+}
@@ -0,0 +1,35 @@
+package xsd
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestIdentityConstraints(t *testing.T) {
+	sd := loadTestSchema(t, "identity", "library.xsd")
+	for doc, msg := range map[string]string{
+		`<library xmlns="urn:example:identity"><book isbn="1"/><book isbn="2"/><loan book="2"/></library>`: "",
+		`<library xmlns="urn:example:identity"><book isbn="1"/><book isbn="1"/></library>`:                 `duplicate value "1" for key "bookIsbn"`,
+		`<library xmlns="urn:example:identity"><book isbn="1"/><loan book="2"/></library>`:                 `value "2" of keyref "loanBook" does not match any bookIsbn`,
+	} {
+		errs, err := sd.Validate(strings.NewReader(doc))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if (len(msg) == 0) && (len(errs) > 0) {
+			t.Errorf("%s: unexpected %v", doc, errs)
+		} else if (len(msg) > 0) && ((len(errs) != 1) || !strings.Contains(errs[0].Error(), msg)) {
+			t.Errorf("%s: expected %s, got %v", doc, msg, errs)
+		}
+	}
+}
+
+func TestKeyIndexes(t *testing.T) {
+	src, _ := genTestSrc(t, "identity", "library.xsd", func(opts *GenOptions) { opts.AddKeyIndexes = true })
+	if !strings.Contains(src, ") BookIsbnIndex() (index map[xsdt.String]*TBook) {") {
+		t.Errorf("no BookIsbnIndex() method generated:\n%s", src)
+	}
+	if src, _ = genTestSrc(t, "identity", "library.xsd", nil); strings.Contains(src, "BookIsbnIndex") {
+		t.Error("BookIsbnIndex() generated without AddKeyIndexes")
+	}
+}
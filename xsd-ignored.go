@@ -0,0 +1,88 @@
+package xsd
+
+import (
+	"github.com/metaleap/go-util-str"
+
+	xsdt "github.com/metaleap/go-xsd/types"
+)
+
+//	Reports every construct of the schema documents of this package that did not influence the generated Go code (see Diagnostics.Ignored),
+//	skipping the global components that no code is generated for (see PkgBag.skips) and the simple types overridden by PkgGen.TypeOverrides.
+func (me *PkgBag) reportIgnoredConstructs() {
+	var root = me.Schema
+	for _, sd := range root.allSchemas(map[string]bool{}) {
+		me.Schema = sd
+		sd.Walk(func(node SchemaNode) bool {
+			switch el := node.Elem.(type) {
+			case *Schema:
+				return true
+			case *SimpleType:
+				return !(me.skips(el) || me.isOverridden(el.Name.String()))
+			case *Key:
+				if !me.keyIndexed[el] {
+					me.reportIgnored(el, "the identity constraint xs:key %q is not enforced by the generated Go code", el.Name)
+				}
+			case *Unique:
+				if !me.keyIndexed[el] {
+					me.reportIgnored(el, "the identity constraint xs:unique %q is not enforced by the generated Go code", el.Name)
+				}
+			case *KeyRef:
+				me.reportIgnored(el, "the identity constraint xs:keyref %q (referring to %s) is not enforced by the generated Go code", el.Name, el.Refer)
+			case *Assert:
+				me.reportIgnored(el, "the assertion %q is not enforced by the generated Go code, but merely documented", el.Test)
+			case *Alternative:
+				me.reportIgnored(el, "%s is not applied by the generated Go code, but merely documented", ustr.Ifs(len(el.Test) > 0, sfmt("the type alternative for %q", el.Test), "the default type alternative"))
+			case *RestrictionSimpleContent:
+				for _, facet := range facetElems(el) {
+					me.reportIgnored(facet, "the %s facet %q of a simpleContent restriction is not enforced by the generated Go code", facet.base().xsdName, facetValue(facet))
+				}
+			case *RestrictionSimpleType:
+				var facets = facetElems(el)
+				for _, facet := range facets {
+					if kind := facet.base().xsdName.String(); !me.gen.AddValidators && (kind != "enumeration") {
+						me.reportIgnored(facet, "the %s facet %q is not enforced by the generated Go code, as PkgGen.AddValidators is off", kind, facetValue(facet))
+					} else if kind == "pattern" {
+						if _, err := xsdt.CompilePattern(facetValue(facet)); err != nil {
+							me.reportIgnored(facet, "the %s facet %q is not enforced by the generated Go code: %v", kind, facetValue(facet), err)
+						}
+					} else if (kind == "whiteSpace") && (len(facets) == 1) {
+						me.reportIgnored(facet, "the %s facet %q is not applied by the generated Go code, as no other facet restricts the type", kind, facetValue(facet))
+					}
+				}
+			default:
+				return !me.skips(node.Elem.(element))
+			}
+			return true
+		})
+	}
+	me.Schema = root
+}
+
+//	Returns the facets (such as xs:pattern or xs:enumeration) among the children of the restriction el, in document order.
+func facetElems(el element) (facets []element) {
+	for _, kid := range el.base().kids {
+		if isFacetName(kid.base().xsdName.String()) {
+			facets = append(facets, kid)
+		}
+	}
+	return
+}
+
+//	Returns whether name is the XSD element name of a constraining facet.
+func isFacetName(name string) bool {
+	switch name {
+	case "enumeration", "pattern", "whiteSpace", "length", "minLength", "maxLength", "totalDigits", "fractionDigits", "minInclusive", "maxInclusive", "minExclusive", "maxExclusive":
+		return true
+	}
+	return false
+}
+
+//	Returns the value attribute of the facet el, or "" if it has none.
+func facetValue(el element) string {
+	for _, at := range el.base().atts {
+		if v, ok := at.(*hasAttrValue); ok {
+			return v.Value
+		}
+	}
+	return ""
+}
@@ -0,0 +1,42 @@
+package xsd
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+//	Tests that Diagnostics.Ignored enumerates, in the order reported, exactly the constructs that did not influence the generated Go code, taking into account
+//	which of them PkgGen.AddValidators and PkgGen.AddKeyIndexes generate code for.
+func TestIgnoredConstructs(t *testing.T) {
+	for _, test := range []struct {
+		checked  bool
+		expected []string
+	}{
+		{false, []string{
+			`41:27: key: the identity constraint xs:key "itemCode" is not enforced by the generated Go code`,
+			`45:50: keyref: the identity constraint xs:keyref "itemRef" (referring to doc:itemCode) is not enforced by the generated Go code`,
+			`16:33: minInclusive: the minInclusive facet "0" of a simpleContent restriction is not enforced by the generated Go code`,
+			`5:29: maxLength: the maxLength facet "8" is not enforced by the generated Go code, as PkgGen.AddValidators is off`,
+			`10:37: whiteSpace: the whiteSpace facet "collapse" is not enforced by the generated Go code, as PkgGen.AddValidators is off`,
+		}},
+		{true, []string{
+			`45:50: keyref: the identity constraint xs:keyref "itemRef" (referring to doc:itemCode) is not enforced by the generated Go code`,
+			`16:33: minInclusive: the minInclusive facet "0" of a simpleContent restriction is not enforced by the generated Go code`,
+			`10:37: whiteSpace: the whiteSpace facet "collapse" is not applied by the generated Go code, as no other facet restricts the type`,
+		}},
+	} {
+		checked := test.checked
+		_, diags := genTestSrc(t, "ignored", "doc.xsd", func(opts *GenOptions) { opts.AddValidators, opts.AddKeyIndexes = checked, checked })
+		var ignored []string
+		for _, d := range diags.Ignored() {
+			if (d.Severity != SeverityWarning) || (filepath.Base(d.File) != "doc.xsd") || !strings.HasPrefix(d.Message, "ignored construct: ") {
+				t.Errorf("unexpected ignored diagnostic %s", d.Error())
+			}
+			ignored = append(ignored, sfmt("%d:%d: %s: %s", d.Line, d.Column, d.Ignored, strings.TrimPrefix(d.Message, "ignored construct: ")))
+		}
+		if strings.Join(ignored, "\n") != strings.Join(test.expected, "\n") {
+			t.Errorf("with checks %v, expected the ignored constructs\n%s\ngot\n%s", checked, strings.Join(test.expected, "\n"), strings.Join(ignored, "\n"))
+		}
+	}
+}
@@ -0,0 +1,726 @@
+package xsd
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/xml"
+	"fmt"
+	"math/big"
+	"math/rand"
+	"regexp/syntax"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/metaleap/go-util-str"
+
+	xsdt "github.com/metaleap/go-xsd/types"
+)
+
+//	Configures the XML instance documents generated by Schema.GenerateInstance.
+type GenOpts struct {
+	//	Seeds the pseudo-random choices made while generating, so that the same schema and seed always yield the same document.
+	Seed int64
+
+	//	If true, only the attributes and elements that must occur are generated, each as often as it must occur, taking the first
+	//	alternative of every xs:choice that may be absent (or else the first one).
+	Minimal bool
+
+	//	The maximum number of occurrences generated for particles with a larger (or unbounded) maxOccurs, defaulting to 3.
+	MaxOccurs int
+
+	//	The element nesting depth beyond which only the attributes and elements that must occur are generated, so that recursive content models terminate.
+	//	Defaults to 8.
+	MaxDepth int
+
+	//	If not empty, every element starts on a new line, indented by this string once per nesting level.
+	Indent string
+}
+
+//	The state of a single Schema.GenerateInstance call.
+type instanceGen struct {
+	v    *validator
+	opts GenOpts
+	rnd  *rand.Rand
+	err  error
+}
+
+//	An element of a generated instance document.
+type instanceNode struct {
+	name    xml.Name
+	atts    []xml.Attr
+	xsiType xml.Name
+	text    string
+	kids    []*instanceNode
+}
+
+//	Facets merged from a simple type and all the simple types it is derived from, see instanceGen.builtinValue.
+type instanceFacets struct {
+	pattern                                   string
+	length, minLength, maxLength, totalDigits int
+	fractionDigits                            int
+	min, max                                  *big.Rat
+	minExclusive, maxExclusive                bool
+}
+
+//	Returns a sample XML instance document of the global element named elementQName (either as "{namespace}local", as "prefix:local" resolved against the
+//	namespace declarations of this schema document, or as "local" in its target namespace), valid against this schema and all the schemas it includes or imports:
+//	occurrence constraints, required and fixed attributes and elements, xs:choice and xs:all, substitution groups, abstract elements and types (replaced by members
+//	and derived types, the latter via xsi:type), enumerations, patterns (generating strings that match them), length facets and numeric bounds are respected.
+//	Element wildcards are filled with global elements of the namespaces they permit, or (if lax or skip) with empty elements that are not declared.
+//	Optional attributes and elements, repetitions, choices and values are picked pseudo-randomly (see GenOpts.Seed). Identity constraints (xs:key, xs:unique and
+//	xs:keyref) and XSD 1.1 assertions and type alternatives are not taken into account. An error is returned if no value satisfying the facets of a simple type
+//	could be generated, if a strict wildcard that must occur permits no element declarations, or if the content model requires nesting elements more deeply
+//	than GenOpts.MaxDepth permits.
+func (me *Schema) GenerateInstance(elementQName string, opts GenOpts) (doc []byte, err error) {
+	var qn xml.Name
+	if pos := strings.Index(elementQName, "}"); strings.HasPrefix(elementQName, "{") && (pos > 0) {
+		qn = xml.Name{Space: elementQName[1:pos], Local: elementQName[pos+1:]}
+	} else if strings.Contains(elementQName, ":") {
+		qn = me.qname(elementQName)
+	} else {
+		qn = xml.Name{Space: me.TargetNamespace.String(), Local: elementQName}
+	}
+	return generateInstance(compileSchemas(me), qn, opts)
+}
+
+//	Implements Schema.GenerateInstance for the global element qn of the compiled schema set compiled.
+func generateInstance(compiled *Compiled, qn xml.Name, opts GenOpts) (doc []byte, err error) {
+	if opts.MaxOccurs <= 0 {
+		opts.MaxOccurs = 3
+	}
+	if opts.MaxDepth <= 0 {
+		opts.MaxDepth = 8
+	}
+	gen := &instanceGen{opts: opts, rnd: rand.New(rand.NewSource(opts.Seed))}
+	gen.v = &validator{compiled: compiled, particles: map[*ComplexType]*vParticle{}, groupsBusy: map[*Group]bool{}}
+	decl := gen.v.compiled.Element(qn)
+	if decl == nil {
+		return nil, fmt.Errorf("no global element declaration found for {%s}%s", qn.Space, qn.Local)
+	}
+	if decl.Abstract {
+		if decl, qn = gen.substitute(decl); decl == nil {
+			return nil, fmt.Errorf("element {%s}%s is abstract and has no substitution group members that are not", qn.Space, qn.Local)
+		}
+	}
+	root := gen.element(decl, qn, 0)
+	if err = gen.err; err == nil {
+		doc = gen.write(root)
+	}
+	return
+}
+
+func (me *instanceGen) fail(format string, args ...interface{}) {
+	if me.err == nil {
+		me.err = fmt.Errorf(format, args...)
+	}
+}
+
+func (me *instanceGen) minimal(depth int) bool {
+	return me.opts.Minimal || (depth >= me.opts.MaxDepth)
+}
+
+func (me *instanceGen) element(decl *Element, name xml.Name, depth int) (n *instanceNode) {
+	var typ = me.v.elementType(decl)
+	n = &instanceNode{name: name}
+	if depth > me.opts.MaxDepth+64 {
+		me.fail("the content of element <%s> nests too deeply", name.Local)
+		return
+	}
+	switch {
+	case typ.Complex != nil:
+		ct := typ.Complex
+		if ct.Abstract {
+			if ct, n.xsiType = me.derivedType(ct); ct == nil {
+				me.fail("the type of element <%s> is abstract and has no derived types that are not", name.Local)
+				return
+			}
+		}
+		me.attributes(n, ct, depth)
+		if ct.SimpleContent != nil {
+			t := me.v.compiled.TypeDefOf(ct)
+			n.text = me.checked(decl.Fixed, sfmt("element <%s>", name.Local), func() string { return me.typeValue(t, nil, 0) }, func(value string) string { return me.v.typeValue(value, t, 0) })
+		} else {
+			me.particle(n, me.v.contentParticle(ct), depth)
+		}
+	case typ.Builtin == "anyType":
+		n.text = decl.Fixed
+	default:
+		n.text = me.checked(decl.Fixed, sfmt("element <%s>", name.Local), func() string { return me.typeValue(typ, nil, 0) }, func(value string) string { return me.v.typeValue(value, typ, 0) })
+	}
+	return
+}
+
+//	Returns fixed if not empty, or else the first of several values returned by value that check deems valid (returning an empty description),
+//	recording an error (naming what) if there is none.
+func (me *instanceGen) checked(fixed, what string, value func() string, check func(string) string) (val string) {
+	var msg string
+	if len(fixed) > 0 {
+		return fixed
+	}
+	for i := 0; i < 64; i++ {
+		if val = value(); i == 0 {
+			if msg = check(val); len(msg) == 0 {
+				return
+			}
+		} else if len(check(val)) == 0 {
+			return
+		}
+	}
+	me.fail("cannot generate a valid value for %s: %s", what, msg)
+	return
+}
+
+func (me *instanceGen) attributes(n *instanceNode, ct *ComplexType, depth int) {
+	var names []string
+	var uses = map[string]*AttributeUse{}
+	for _, use := range me.v.compiled.AttributeUses(ct) {
+		names, uses[diffName(use.Name)] = append(names, diffName(use.Name)), use
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if use := uses[name]; use.Required || ((!me.minimal(depth)) && (me.rnd.Intn(2) == 0)) {
+			value := me.checked(use.Fixed, sfmt("attribute %q", use.Name.Local), func() string { return me.typeValue(use.Type, nil, 0) }, func(value string) string { return me.v.typeValue(value, use.Type, 0) })
+			n.atts = append(n.atts, xml.Attr{Name: use.Name, Value: value})
+		}
+	}
+}
+
+//	Returns how often the particle p is to occur.
+func (me *instanceGen) occurs(p *vParticle, depth int) (count int64) {
+	var max = p.max
+	if (max < 0) || (max > int64(me.opts.MaxOccurs)) {
+		max = int64(me.opts.MaxOccurs)
+	}
+	if count = p.min; (max > count) && !me.minimal(depth) {
+		count += me.rnd.Int63n(max - count + 1)
+	}
+	return
+}
+
+func (me *instanceGen) particle(n *instanceNode, p *vParticle, depth int) {
+	for i := me.occurs(p, depth); i > 0; i-- {
+		switch p.kind {
+		case particleElement:
+			decl, name := p.elem, p.name
+			if decl.Abstract {
+				if decl, name = me.substitute(decl); decl == nil {
+					me.fail("element <%s> is abstract and has no substitution group members that are not", p.name.Local)
+					return
+				}
+			}
+			n.kids = append(n.kids, me.element(decl, name, depth+1))
+		case particleAny:
+			if decl, name := me.wildcardElement(p.any, depth); decl != nil {
+				n.kids = append(n.kids, me.element(decl, name, depth+1))
+			} else if len(name.Local) > 0 {
+				n.kids = append(n.kids, &instanceNode{name: name})
+			} else if p.min > 0 {
+				me.fail("the strict xs:any wildcard in element <%s> permits no global element declarations that are not abstract", n.name.Local)
+				return
+			}
+		case particleSequence:
+			for _, k := range p.kids {
+				me.particle(n, k, depth)
+			}
+		case particleChoice:
+			if len(p.kids) > 0 {
+				k := p.kids[0]
+				if me.minimal(depth) {
+					for _, alt := range p.kids {
+						if alt.min == 0 {
+							k = alt
+							break
+						}
+					}
+				} else {
+					k = p.kids[me.rnd.Intn(len(p.kids))]
+				}
+				me.particle(n, k, depth)
+			}
+		case particleAll:
+			kids := append([]*vParticle{}, p.kids...)
+			if !me.minimal(depth) {
+				for i, j := range me.rnd.Perm(len(kids)) {
+					kids[i] = p.kids[j]
+				}
+			}
+			for _, k := range kids {
+				me.particle(n, k, depth)
+			}
+		}
+	}
+}
+
+//	Returns a member of the substitution group of the abstract element decl (directly or indirectly) that is not abstract itself, and its name.
+func (me *instanceGen) substitute(decl *Element) (*Element, xml.Name) {
+	var names []string
+	var members = map[string]xml.Name{}
+	for _, el := range me.v.compiled.Substitutes(decl) {
+		if qn := me.v.compiled.comps.elementName(el); (!el.Abstract) && (el != decl) {
+			names, members[diffName(qn)] = append(names, diffName(qn)), qn
+		}
+	}
+	if len(names) == 0 {
+		return nil, me.v.compiled.comps.elementName(decl)
+	}
+	sort.Strings(names)
+	qn := members[names[me.pick(len(names))]]
+	return me.v.compiled.Element(qn), qn
+}
+
+//	Returns a global element (that is not abstract) of a namespace permitted by the wildcard, and its name, if there is one. For lax and skip wildcards,
+//	returns instead (with a nil *Element) the name of an undeclared element of a permitted namespace if there is none, or if only the attributes and
+//	elements that must occur are to be generated at depth, so that wildcards permitting their enclosing elements terminate; and always for skip
+//	wildcards, whose elements are not validated anyway.
+func (me *instanceGen) wildcardElement(any *Any, depth int) (*Element, xml.Name) {
+	var names []string
+	var allowed = map[string]xml.Name{}
+	var tns = ownerSchema(any).TargetNamespace.String()
+	var lax = (any.ProcessContents == "lax") || (any.ProcessContents == "skip")
+	if !((lax && me.minimal(depth)) || (any.ProcessContents == "skip")) {
+		for qn, el := range me.v.compiled.comps.elements {
+			if (!el.Abstract) && namespaceAllowed(any.Namespace, tns, qn.Space) {
+				names, allowed[diffName(qn)] = append(names, diffName(qn)), qn
+			}
+		}
+	}
+	if len(names) > 0 {
+		sort.Strings(names)
+		qn := allowed[names[me.pick(len(names))]]
+		return me.v.compiled.Element(qn), qn
+	} else if lax {
+		for _, ns := range append(strings.Fields(any.Namespace), tns, "", "http://example.com/any") {
+			if qn := (xml.Name{Space: ns, Local: "any"}); (!strings.HasPrefix(ns, "##")) && namespaceAllowed(any.Namespace, tns, ns) {
+				for i := 2; me.v.compiled.Element(qn) != nil; i++ {
+					qn.Local = sfmt("any%d", i)
+				}
+				return nil, qn
+			}
+		}
+	}
+	return nil, xml.Name{}
+}
+
+//	Returns a complex type (that is not abstract) derived from the abstract complex type ct (directly or indirectly), and its name.
+func (me *instanceGen) derivedType(ct *ComplexType) (*ComplexType, xml.Name) {
+	var names []string
+	var derived = map[string]xml.Name{}
+	for qn, dt := range me.v.compiled.comps.complexTypes {
+		if (!dt.Abstract) && (dt != ct) && me.v.compiled.TypeDefOf(dt).DerivesFrom(me.v.compiled.TypeDefOf(ct)) {
+			names, derived[diffName(qn)] = append(names, diffName(qn)), qn
+		}
+	}
+	if len(names) == 0 {
+		return nil, xml.Name{}
+	}
+	sort.Strings(names)
+	qn := derived[names[me.pick(len(names))]]
+	return me.v.compiled.comps.complexTypes[qn], qn
+}
+
+//	Returns a pseudo-random index below n, or 0 if GenOpts.Minimal is set.
+func (me *instanceGen) pick(n int) int {
+	if me.opts.Minimal || (n <= 1) {
+		return 0
+	}
+	return me.rnd.Intn(n)
+}
+
+//	Returns a value of the type t, restricted by facets (those of the types derived from it, most derived first).
+func (me *instanceGen) typeValue(t *TypeDef, facets []*xsdt.Facets, depth int) string {
+	if len(t.Builtin) > 0 {
+		return me.builtinValue(t.Builtin, facets)
+	} else if t.Complex != nil {
+		return me.simpleContentValue(t, facets, depth)
+	}
+	return me.simpleTypeValue(t, facets, depth)
+}
+
+func (me *instanceGen) simpleContentValue(t *TypeDef, facets []*xsdt.Facets, depth int) string {
+	if sc := t.Complex.SimpleContent; (sc != nil) && (depth < 64) {
+		if sc.ExtensionSimpleContent != nil {
+			return me.typeValue(t.Base, facets, depth+1)
+		} else if res := sc.RestrictionSimpleContent; res != nil {
+			if facets = append(facets, res.facets()); len(res.SimpleTypes) > 0 {
+				return me.typeValue(me.v.compiled.TypeDefOf(res.SimpleTypes[0]), facets, depth+1)
+			}
+			return me.typeValue(t.Base, facets, depth+1)
+		}
+	}
+	return me.builtinValue("string", facets)
+}
+
+func (me *instanceGen) simpleTypeValue(t *TypeDef, facets []*xsdt.Facets, depth int) string {
+	if enums := facetEnumerations(facets); len(enums) > 0 {
+		return enums[me.pick(len(enums))]
+	} else if depth > 64 {
+		return ""
+	}
+	switch st := t.Simple; t.Derivation {
+	case "restriction":
+		return me.typeValue(t.Base, append(facets, st.RestrictionSimpleType.facets()), depth+1)
+	case "list":
+		// the length facets of restrictions of list types constrain the number of list items
+		var items []string
+		var mf, count = mergeFacets(facets), me.pick(3) + 1
+		if mf.length >= 0 {
+			count = mf.length
+		} else if (mf.maxLength >= 0) && (count > mf.maxLength) {
+			count = mf.maxLength
+		}
+		if (mf.length < 0) && (count < mf.minLength) {
+			count = mf.minLength
+		}
+		for i := 0; i < count; i++ {
+			items = append(items, me.typeValue(t.ItemType, nil, depth+1))
+		}
+		return strings.Join(items, " ")
+	case "union":
+		if len(t.MemberTypes) > 0 {
+			return me.typeValue(t.MemberTypes[me.pick(len(t.MemberTypes))], nil, depth+1)
+		}
+	}
+	return me.builtinValue("string", facets)
+}
+
+//	Returns the enumerations of the first (that is, most derived) facets declaring any.
+func facetEnumerations(facets []*xsdt.Facets) []string {
+	for _, f := range facets {
+		if len(f.Enumerations) > 0 {
+			return f.Enumerations
+		}
+	}
+	return nil
+}
+
+//	Merges facets (most derived first) into the tightest constraints they impose together.
+func mergeFacets(facets []*xsdt.Facets) (mf *instanceFacets) {
+	var atoi = func(s string, prev int, tighter func(int, int) bool) int {
+		if i, err := strconv.Atoi(strings.TrimSpace(s)); (err == nil) && ((prev < 0) || tighter(i, prev)) {
+			return i
+		}
+		return prev
+	}
+	var less, greater = func(a, b int) bool { return a < b }, func(a, b int) bool { return a > b }
+	mf = &instanceFacets{length: -1, minLength: -1, maxLength: -1, totalDigits: -1, fractionDigits: -1}
+	for _, f := range facets {
+		if len(mf.pattern) == 0 {
+			mf.pattern = f.Pattern
+		}
+		mf.length, mf.minLength, mf.maxLength = atoi(f.Length, mf.length, less), atoi(f.MinLength, mf.minLength, greater), atoi(f.MaxLength, mf.maxLength, less)
+		mf.totalDigits, mf.fractionDigits = atoi(f.TotalDigits, mf.totalDigits, less), atoi(f.FractionDigits, mf.fractionDigits, less)
+		for i, bound := range []string{f.MinInclusive, f.MinExclusive, f.MaxInclusive, f.MaxExclusive} {
+			if b, ok := new(big.Rat).SetString(strings.TrimSpace(bound)); ok {
+				if i < 2 {
+					if (mf.min == nil) || (b.Cmp(mf.min) > 0) || ((b.Cmp(mf.min) == 0) && (i == 1)) {
+						mf.min, mf.minExclusive = b, i == 1
+					}
+				} else if (mf.max == nil) || (b.Cmp(mf.max) < 0) || ((b.Cmp(mf.max) == 0) && (i == 3)) {
+					mf.max, mf.maxExclusive = b, i == 3
+				}
+			}
+		}
+	}
+	return
+}
+
+//	Returns a value of the built-in XSD type builtin, restricted by facets (most derived first).
+func (me *instanceGen) builtinValue(builtin string, facets []*xsdt.Facets) string {
+	var mf = mergeFacets(facets)
+	if enums := facetEnumerations(facets); len(enums) > 0 {
+		return enums[me.pick(len(enums))]
+	} else if len(mf.pattern) > 0 {
+		if re2, err := xsdt.TranslatePattern(mf.pattern); err == nil {
+			if re, err := syntax.Parse(re2, syntax.Perl); err == nil {
+				var buf bytes.Buffer
+				me.patternValue(&buf, re.Simplify())
+				return buf.String()
+			}
+		}
+	}
+	if _, isInt := builtinRanges[builtin]; isInt || (builtin == "decimal") || (builtin == "float") || (builtin == "double") {
+		return me.numericValue(builtin, mf)
+	}
+	if bound := facetBound(facets); len(bound) > 0 {
+		return bound
+	}
+	switch n := me.rnd.Intn(9) + 1; builtin {
+	case "boolean":
+		return []string{"true", "false"}[me.pick(2)]
+	case "date":
+		return sfmt("%04d-%02d-%02d", 2000+me.rnd.Intn(30), n, n*3)
+	case "dateTime":
+		return sfmt("%04d-%02d-%02dT%02d:%02d:00", 2000+me.rnd.Intn(30), n, n*3, n*2, n*6)
+	case "time":
+		return sfmt("%02d:%02d:00", n*2, n*6)
+	case "duration":
+		return sfmt("P%dDT%dH", n, n*2)
+	case "gYear":
+		return sfmt("%04d", 2000+me.rnd.Intn(30))
+	case "gYearMonth":
+		return sfmt("%04d-%02d", 2000+me.rnd.Intn(30), n)
+	case "gMonth":
+		return sfmt("--%02d", n)
+	case "gDay":
+		return sfmt("---%02d", n*3)
+	case "gMonthDay":
+		return sfmt("--%02d-%02d", n, n*3)
+	case "hexBinary":
+		return strings.ToUpper(hex.EncodeToString([]byte(me.word(mf, 3))))
+	case "base64Binary":
+		return base64.StdEncoding.EncodeToString([]byte(me.word(mf, 4)))
+	case "anyURI":
+		return "http://example.com/" + me.word(&instanceFacets{length: -1, minLength: -1, maxLength: -1}, 6)
+	case "language":
+		return []string{"en", "de", "fr", "en-US"}[me.pick(4)]
+	case "NMTOKENS", "IDREFS", "ENTITIES":
+		return me.word(mf, 5) + " " + me.word(mf, 5)
+	}
+	return me.word(mf, 6)
+}
+
+//	Returns the minInclusive (or else maxInclusive) value of the first (that is, most derived) facets declaring either, for the
+//	non-numeric types (such as xs:date) whose values are not generated within bounds.
+func facetBound(facets []*xsdt.Facets) string {
+	for _, f := range facets {
+		if len(f.MinInclusive) > 0 {
+			return strings.TrimSpace(f.MinInclusive)
+		} else if len(f.MaxInclusive) > 0 {
+			return strings.TrimSpace(f.MaxInclusive)
+		}
+	}
+	return ""
+}
+
+//	Returns a pseudo-random word of lower-case letters, of the length demanded by mf (or else of about size letters).
+func (me *instanceGen) word(mf *instanceFacets, size int) string {
+	var length = size + me.rnd.Intn(size)
+	if mf.length >= 0 {
+		length = mf.length
+	} else if (mf.maxLength >= 0) && (length > mf.maxLength) {
+		length = mf.maxLength
+	}
+	if (mf.length < 0) && (length < mf.minLength) {
+		length = mf.minLength
+	}
+	var runes = make([]rune, length)
+	for i := range runes {
+		runes[i] = rune('a' + me.rnd.Intn(26))
+	}
+	return string(runes)
+}
+
+//	Returns a pseudo-random number of the numeric built-in XSD type builtin within the bounds of mf (or between 0 and 100 if it has none).
+func (me *instanceGen) numericValue(builtin string, mf *instanceFacets) string {
+	var lo, hi *big.Rat
+	var scale = new(big.Rat).SetInt64(1)
+	var fracDigits int
+	if rng, isInt := builtinRanges[builtin]; isInt {
+		lo, _ = new(big.Rat).SetString(rng[0])
+		hi, _ = new(big.Rat).SetString(rng[1])
+	} else if fracDigits = 2; mf.fractionDigits >= 0 {
+		fracDigits = mf.fractionDigits
+	}
+	for i := 0; i < fracDigits; i++ {
+		scale.Mul(scale, big.NewRat(10, 1))
+	}
+	step := new(big.Rat).Inv(scale)
+	if (mf.min != nil) && ((lo == nil) || (mf.min.Cmp(lo) >= 0)) {
+		if lo = new(big.Rat).Set(mf.min); mf.minExclusive {
+			lo.Add(lo, step)
+		}
+	}
+	if (mf.max != nil) && ((hi == nil) || (mf.max.Cmp(hi) <= 0)) {
+		if hi = new(big.Rat).Set(mf.max); mf.maxExclusive {
+			hi.Sub(hi, step)
+		}
+	}
+	if mf.totalDigits > 0 {
+		limit := new(big.Rat).SetInt64(1)
+		for i := 0; i < mf.totalDigits-fracDigits; i++ {
+			limit.Mul(limit, big.NewRat(10, 1))
+		}
+		if limit.Sub(limit, step); (hi == nil) || (hi.Cmp(limit) > 0) {
+			hi = limit
+		}
+		if limit = new(big.Rat).Neg(limit); (lo == nil) || (lo.Cmp(limit) < 0) {
+			lo = limit
+		}
+	}
+	if (lo == nil) && (hi == nil) {
+		lo, hi = new(big.Rat), big.NewRat(100, 1)
+	} else if lo == nil {
+		lo = new(big.Rat).Sub(hi, big.NewRat(100, 1))
+	} else if hi == nil {
+		hi = new(big.Rat).Add(lo, big.NewRat(100, 1))
+	}
+	// in units of step: from ceil(lo*scale) to floor(hi*scale), at most 1000000 units apart
+	var from, to = ratCeil(new(big.Rat).Mul(lo, scale)), ratFloor(new(big.Rat).Mul(hi, scale))
+	if span := new(big.Int).Sub(to, from); span.Sign() > 0 {
+		if span.Cmp(big.NewInt(1000000)) > 0 {
+			span.SetInt64(1000000)
+		}
+		from.Add(from, big.NewInt(me.rnd.Int63n(span.Int64()+1)))
+	}
+	return new(big.Rat).SetFrac(from, scale.Num()).FloatString(fracDigits)
+}
+
+func ratFloor(r *big.Rat) *big.Int {
+	return new(big.Int).Div(r.Num(), r.Denom())
+}
+
+func ratCeil(r *big.Rat) *big.Int {
+	var q, m = new(big.Int).DivMod(r.Num(), r.Denom(), new(big.Int))
+	if m.Sign() != 0 {
+		q.Add(q, big.NewInt(1))
+	}
+	return q
+}
+
+//	Appends to buf a string matching the regular expression re.
+func (me *instanceGen) patternValue(buf *bytes.Buffer, re *syntax.Regexp) {
+	switch re.Op {
+	case syntax.OpLiteral:
+		for _, r := range re.Rune {
+			buf.WriteRune(r)
+		}
+	case syntax.OpCharClass:
+		buf.WriteRune(me.classRune(re.Rune))
+	case syntax.OpAnyChar, syntax.OpAnyCharNotNL:
+		buf.WriteRune(rune('a' + me.rnd.Intn(26)))
+	case syntax.OpCapture, syntax.OpConcat:
+		for _, sub := range re.Sub {
+			me.patternValue(buf, sub)
+		}
+	case syntax.OpAlternate:
+		me.patternValue(buf, re.Sub[me.pick(len(re.Sub))])
+	case syntax.OpStar, syntax.OpPlus, syntax.OpQuest, syntax.OpRepeat:
+		min, max := re.Min, re.Max
+		switch re.Op {
+		case syntax.OpStar:
+			min, max = 0, -1
+		case syntax.OpPlus:
+			min, max = 1, -1
+		case syntax.OpQuest:
+			min, max = 0, 1
+		}
+		if (max < 0) || (max > min+me.opts.MaxOccurs) {
+			max = min + me.opts.MaxOccurs
+		}
+		count := min
+		if (max > min) && !me.opts.Minimal {
+			count += me.rnd.Intn(max - min + 1)
+		}
+		for i := 0; i < count; i++ {
+			me.patternValue(buf, re.Sub[0])
+		}
+	}
+}
+
+//	Returns a rune of the character class denoted by the specified pairs of inclusive bounds, preferring letters, digits and other printable ASCII characters.
+func (me *instanceGen) classRune(ranges []rune) rune {
+	var candidates []rune
+	for i := 0; i+1 < len(ranges); i += 2 {
+		for r := ranges[i]; (r <= ranges[i+1]) && (r <= unicode.MaxASCII); r++ {
+			if unicode.IsLetter(r) || unicode.IsDigit(r) {
+				candidates = append(candidates, r)
+			}
+		}
+	}
+	if len(candidates) == 0 {
+		for i := 0; i+1 < len(ranges); i += 2 {
+			for r := ranges[i]; (r <= ranges[i+1]) && (r <= unicode.MaxASCII); r++ {
+				if (r > ' ') && (r < unicode.MaxASCII) {
+					candidates = append(candidates, r)
+				}
+			}
+		}
+	}
+	if len(candidates) > 0 {
+		return candidates[me.pick(len(candidates))]
+	} else if len(ranges) > 0 {
+		return ranges[0]
+	}
+	return 'a'
+}
+
+//	Returns the XML document rooted at root, declaring its namespace as the default namespace (redeclared by descendants of other namespaces),
+//	and all namespaces of qualified attributes and xsi:types on root, with generated prefixes.
+func (me *instanceGen) write(root *instanceNode) []byte {
+	var buf bytes.Buffer
+	var namespaces []string
+	var prefixes, numbered = map[string]string{xmlNamespaceUri: "xml"}, 0
+	var collect func(*instanceNode)
+	collect = func(n *instanceNode) {
+		var add = func(ns string) {
+			if _, ok := prefixes[ns]; (len(ns) > 0) && !ok {
+				numbered++
+				prefixes[ns], namespaces = sfmt("ns%d", numbered), append(namespaces, ns)
+			}
+		}
+		if len(n.xsiType.Local) > 0 {
+			if _, ok := prefixes[xsiNamespaceUri]; !ok {
+				prefixes[xsiNamespaceUri], namespaces = "xsi", append(namespaces, xsiNamespaceUri)
+			}
+			add(n.xsiType.Space)
+		}
+		for _, a := range n.atts {
+			add(a.Name.Space)
+		}
+		for _, kid := range n.kids {
+			collect(kid)
+		}
+	}
+	collect(root)
+	buf.WriteString(xml.Header[:len(xml.Header)-1])
+	me.writeNode(&buf, root, "", 0, namespaces, prefixes)
+	buf.WriteByte('\n')
+	return buf.Bytes()
+}
+
+func (me *instanceGen) writeNode(buf *bytes.Buffer, n *instanceNode, defaultNs string, depth int, namespaces []string, prefixes map[string]string) {
+	var attr = func(name, value string) {
+		buf.WriteString(" " + name + "=\"")
+		xml.EscapeText(buf, []byte(value))
+		buf.WriteString("\"")
+	}
+	if (depth > 0) || (len(me.opts.Indent) > 0) {
+		buf.WriteString("\n" + strings.Repeat(me.opts.Indent, depth))
+	}
+	buf.WriteString("<" + n.name.Local)
+	if (depth == 0) || (n.name.Space != defaultNs) {
+		if defaultNs = n.name.Space; (depth > 0) || (len(defaultNs) > 0) {
+			attr("xmlns", defaultNs)
+		}
+	}
+	for _, ns := range namespaces {
+		attr("xmlns:"+prefixes[ns], ns)
+	}
+	if len(n.xsiType.Local) > 0 {
+		attr("xsi:type", instanceName(prefixes[n.xsiType.Space], n.xsiType.Local))
+	}
+	for _, a := range n.atts {
+		attr(instanceName(prefixes[a.Name.Space], a.Name.Local), a.Value)
+	}
+	if (len(n.kids) == 0) && (len(n.text) == 0) {
+		buf.WriteString("/>")
+		return
+	}
+	buf.WriteString(">")
+	xml.EscapeText(buf, []byte(n.text))
+	for _, kid := range n.kids {
+		me.writeNode(buf, kid, defaultNs, depth+1, nil, prefixes)
+	}
+	if (len(n.kids) > 0) && (len(me.opts.Indent) > 0) {
+		buf.WriteString("\n" + strings.Repeat(me.opts.Indent, depth))
+	}
+	buf.WriteString("</" + n.name.Local + ">")
+}
+
+//	Returns local, qualified by prefix if not empty.
+func instanceName(prefix, local string) string {
+	return ustr.Ifs(len(prefix) > 0, prefix+":"+local, local)
+}
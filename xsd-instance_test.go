@@ -0,0 +1,64 @@
+package xsd
+
+import (
+	"bytes"
+	"context"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+//	Tests that the instances GenerateInstance generates for the global elements of the testdata schemas are valid against them, minimal ones as well
+//	as those of several seeds, except for the schemas with identity constraints (which GenerateInstance does not take into account) and those that
+//	cannot be loaded without further options. Values that cannot be generated as the validator cannot check their patterns are tolerated.
+func TestGenerateInstanceRoundTrip(t *testing.T) {
+	var excluded = map[string]bool{"cycle": true, "entities": true, "identity": true, "ignored": true, "strictinclude": true, "unsupported": true}
+	var failing = map[string]string{"instance/crate": "the strict xs:any wildcard in element <crate> permits no global element declarations that are not abstract"}
+	dirs, err := ioutil.ReadDir("testdata")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, dir := range dirs {
+		if (!dir.IsDir()) || excluded[dir.Name()] {
+			continue
+		}
+		set, err := NewSchemaCache(0).LoadSchemaDir(context.Background(), filepath.Join("testdata", dir.Name()), LoadOptions{})
+		if err != nil {
+			t.Fatalf("%s: %v", dir.Name(), err)
+		}
+		for _, sd := range set.Schemas {
+			for _, el := range sd.globalElements() {
+				name := dir.Name() + "/" + el.Name.String()
+				for _, opts := range []GenOpts{{Minimal: true}, {Seed: 1}, {Seed: 2}, {Seed: 3}} {
+					doc, err := sd.GenerateInstance("{"+sd.TargetNamespace.String()+"}"+el.Name.String(), opts)
+					if msg := failing[name]; (len(msg) > 0) && ((err == nil) || (err.Error() != msg)) {
+						t.Errorf("%s: expected the error %q, got %v", name, msg, err)
+					} else if (err != nil) && (len(msg) == 0) && !strings.Contains(err.Error(), "cannot be checked") {
+						t.Errorf("%s: %v", name, err)
+					} else if err == nil {
+						if errs, err := sd.Validate(bytes.NewReader(doc)); (err != nil) || (len(errs) > 0) {
+							t.Errorf("%s: the instance generated with %+v is invalid: %v %v\n%s", name, opts, err, errs, doc)
+						}
+					}
+				}
+			}
+		}
+	}
+}
+
+//	Tests that required lax and skip wildcards are filled with undeclared elements of permitted namespaces where no global element declaration is
+//	permitted, and (so that recursion via wildcards terminates) in minimal instances, such as those of testdata/lint, whose only global element
+//	requires a lax wildcard.
+func TestGenerateInstanceWildcards(t *testing.T) {
+	for _, test := range [][4]string{
+		{"lint", "doc.xsd", "order", `<order xmlns="urn:lint"><line><part><serial_no>byhizz</serial_no></part></line><any/></order>`},
+		{"instance", "wild.xsd", "box", `<box xmlns="urn:example:instance"><any xmlns="http://example.com/any"/></box>`},
+	} {
+		if doc, err := loadTestSchema(t, test[0], test[1]).GenerateInstance(test[2], GenOpts{Minimal: true}); err != nil {
+			t.Error(err)
+		} else if !strings.HasSuffix(strings.Replace(string(doc), "\n", "", -1), test[3]) {
+			t.Errorf("expected %s, got\n%s", test[3], doc)
+		}
+	}
+}
@@ -0,0 +1,502 @@
+package xsd
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/metaleap/go-util-fs"
+	"github.com/metaleap/go-util-str"
+
+	xsdt "github.com/metaleap/go-xsd/types"
+)
+
+var (
+	//	The bounds (if any) of the XSD built-in integer types.
+	jsonSchemaIntBounds = map[string][2]string{
+		"byte": {"-128", "127"}, "int": {"-2147483648", "2147483647"}, "integer": {"", ""}, "long": {"-9223372036854775808", "9223372036854775807"},
+		"negativeInteger": {"", "-1"}, "nonNegativeInteger": {"0", ""}, "nonPositiveInteger": {"", "0"}, "positiveInteger": {"1", ""}, "short": {"-32768", "32767"},
+		"unsignedByte": {"0", "255"}, "unsignedInt": {"0", "4294967295"}, "unsignedLong": {"0", "18446744073709551615"}, "unsignedShort": {"0", "65535"},
+	}
+
+	//	The JSON Schema formats of those XSD built-in types that have one.
+	jsonSchemaFormats = map[string]string{"anyURI": "uri-reference", "date": "date", "dateTime": "date-time", "duration": "duration", "time": "time"}
+)
+
+//	A JSON object whose members are marshaled in the order they were set, rather than sorted by key as for maps.
+type jsonObject struct {
+	keys   []string
+	values map[string]interface{}
+}
+
+func newJsonObject() *jsonObject {
+	return &jsonObject{values: map[string]interface{}{}}
+}
+
+//	Implements json.Marshaler.
+func (me *jsonObject) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteString("{")
+	for i, k := range me.keys {
+		key, _ := json.Marshal(k)
+		val, err := json.Marshal(me.values[k])
+		if err != nil {
+			return nil, err
+		}
+		if i > 0 {
+			buf.WriteString(",")
+		}
+		buf.Write(key)
+		buf.WriteString(":")
+		buf.Write(val)
+	}
+	buf.WriteString("}")
+	return buf.Bytes(), nil
+}
+
+func (me *jsonObject) has(key string) (ok bool) {
+	_, ok = me.values[key]
+	return
+}
+
+//	Sets the member key to val, keeping its position if already set.
+func (me *jsonObject) set(key string, val interface{}) *jsonObject {
+	if !me.has(key) {
+		me.keys = append(me.keys, key)
+	}
+	me.values[key] = val
+	return me
+}
+
+type jsonSchemaGen struct {
+	comps   *schemaComponents
+	schemas []*Schema
+	names   map[element]string // the $defs names of all global types, and of all global elements of anonymous types
+	refBase string             // the URI fragment that the names are appended to in "$ref"s
+	xmlObjs bool               // whether to annotate properties with OpenAPI XML objects
+	taken   map[string]bool    // the names in use (see uniqueName)
+
+	expanding map[*ComplexType]bool // the anonymous complex types of local elements whose schemas are being generated
+	hoisted   []*Element            // the local elements whose anonymous complex types recur within themselves, defined (and referenced) like those of global elements
+}
+
+//	Returns a draft 2020-12 JSON Schema document for validating JSON renderings of the XML instances of this schema (and all schemas it includes and, directly or indirectly, imports).
+//	All global complex and simple types (as well as the anonymous types of global elements) are mirrored in its "$defs": complex types (including the fields of their base types)
+//	as objects with a property per element and attribute (named according to PkgGen.JsonTags, as are the fields of the generated Go structs), which is an array for elements
+//	that can occur more than once and required for those that must occur; simple types with their facets as pattern, enum, length and numeric-bound keywords.
+//	The document itself describes an object with exactly one property, named after any of the global elements of this schema and its includes.
+func (me *Schema) MakeJSONSchema() (doc []byte, err error) {
+	var gen = newJsonSchemaGen(me, "#/$defs/")
+	var root, props = newJsonObject(), newJsonObject()
+	for _, sd := range me.allSchemas(map[string]bool{}) {
+		for _, el := range sd.globalElements() {
+			if !el.Abstract {
+				props.set(PkgGen.jsonName(el.Name.String()), gen.element(el))
+			}
+		}
+	}
+	root.set("$schema", "https://json-schema.org/draft/2020-12/schema")
+	root.set("$comment", "Auto-generated by the \"go-xsd\" package located at github.com/metaleap/go-xsd from the XSD file located at "+me.loadUri)
+	root.set("type", "object").set("properties", props).set("minProperties", 1).set("maxProperties", 1).set("additionalProperties", false)
+	root.set("$defs", gen.defs())
+	return json.MarshalIndent(root, "", "  ")
+}
+
+//	Returns a jsonSchemaGen for sd and all schemas it includes and imports, with their definitions referenced as refBase followed by their names.
+func newJsonSchemaGen(sd *Schema, refBase string) (me *jsonSchemaGen) {
+	var taken = map[string]bool{}
+	me = &jsonSchemaGen{comps: newSchemaComponents(sd), names: map[element]string{}, refBase: refBase, taken: taken, expanding: map[*ComplexType]bool{}}
+	me.collectSchemas(sd)
+	for _, sd := range me.schemas {
+		for _, st := range sd.globalSimpleTypes() {
+			me.names[st] = uniqueName(st.Name.String(), taken)
+		}
+		for _, ct := range sd.globalComplexTypes() {
+			me.names[ct] = uniqueName(ct.Name.String(), taken)
+		}
+		for _, el := range sd.globalElements() {
+			if (el.ComplexType != nil) || (len(el.SimpleTypes) > 0) {
+				me.names[el] = uniqueName(el.Name.String(), taken)
+			}
+		}
+	}
+	return
+}
+
+//	Returns the definitions of all global types (and anonymous types of global elements), in document order and keyed by their names,
+//	followed by those of the recursive anonymous types of local elements (see element).
+func (me *jsonSchemaGen) defs() (defs *jsonObject) {
+	defs = newJsonObject()
+	for _, sd := range me.schemas {
+		for _, st := range sd.globalSimpleTypes() {
+			defs.set(me.names[st], me.simpleType(st))
+		}
+		for _, ct := range sd.globalComplexTypes() {
+			defs.set(me.names[ct], me.complexType(ct))
+		}
+		for _, el := range sd.globalElements() {
+			if el.ComplexType != nil {
+				defs.set(me.names[el], me.complexType(el.ComplexType))
+			} else if len(el.SimpleTypes) > 0 {
+				defs.set(me.names[el], me.simpleType(el.SimpleTypes[0]))
+			}
+		}
+	}
+	for i := 0; i < len(me.hoisted); i++ {
+		defs.set(me.names[me.hoisted[i]], me.complexType(me.hoisted[i].ComplexType))
+	}
+	return
+}
+
+//	Writes the JSON Schema document returned by MakeJSONSchema into jsonOutDirPath (defaulting to the directory of the local copy of the XSD file),
+//	naming it after the XSD file name (such as "order.schema.json" for "order.xsd").
+func (me *Schema) MakeJSONSchemaFileAt(jsonOutDirPath string) (jsonOutFilePath string, err error) {
+	var doc []byte
+	if len(jsonOutDirPath) == 0 {
+		jsonOutDirPath = filepath.Dir(me.loadLocalPath)
+	}
+	jsonOutFilePath = filepath.Join(jsonOutDirPath, strings.TrimSuffix(path.Base(me.loadUri), path.Ext(me.loadUri))+".schema.json")
+	if doc, err = me.MakeJSONSchema(); err == nil {
+		if err = ufs.EnsureDirExists(filepath.Dir(jsonOutFilePath)); err == nil {
+			err = ufs.WriteBinaryFile(jsonOutFilePath, doc)
+		}
+	}
+	return
+}
+
+func (me *jsonSchemaGen) collectSchemas(sd *Schema) {
+	for _, s := range me.schemas {
+		if s == sd {
+			return
+		}
+	}
+	me.schemas = append(me.schemas, sd)
+	for _, inc := range sd.XMLIncludedSchemas {
+		me.collectSchemas(inc)
+	}
+	for _, imp := range sd.XMLImportedSchemas {
+		me.collectSchemas(imp)
+	}
+}
+
+func (me *jsonSchemaGen) complexType(ct *ComplexType) (obj *jsonObject) {
+	var props = newJsonObject()
+	var required []string
+	var open bool
+	obj = newJsonObject().set("type", "object")
+	if doc := ct.Annotation.docText(); len(doc) > 0 {
+		obj.set("description", doc)
+	}
+	for _, f := range me.comps.contentFields(ct) {
+		var prop *jsonObject
+		var name = PkgGen.jsonName(f.Name)
+		switch decl := f.Decl.(type) {
+		case *Any:
+			open = true
+			continue
+		case *Element:
+			prop = me.xmlObject(me.element(decl), f, name)
+		case *Attribute:
+			prop = me.xmlObject(me.attribute(decl), f, name)
+		default:
+			prop = me.typeRef(f.Decl, f.Base)
+		}
+		if props.has(name) {
+			continue
+		}
+		if f.Repeated() {
+			arr := newJsonObject().set("type", "array").set("items", prop)
+			if f.MinOccurs > 0 {
+				arr.set("minItems", f.MinOccurs)
+			}
+			if f.MaxOccurs > 1 {
+				arr.set("maxItems", f.MaxOccurs)
+			}
+			prop = arr
+		}
+		if props.set(name, prop); !f.Optional() {
+			required = append(required, name)
+		}
+	}
+	obj.set("properties", props)
+	if len(required) > 0 {
+		obj.set("required", required)
+	}
+	if !open {
+		obj.set("additionalProperties", false)
+	}
+	return
+}
+
+//	If xmlObjs, adds to the schema prop of the property name for f an OpenAPI XML object marking attributes as such and naming the element or attribute
+//	if its name differs from the property name.
+func (me *jsonSchemaGen) xmlObject(prop *jsonObject, f *contentField, name string) *jsonObject {
+	if me.xmlObjs && (f.Attr || (name != f.Name)) {
+		xmlObj := newJsonObject()
+		if name != f.Name {
+			xmlObj.set("name", f.Name)
+		}
+		if f.Attr {
+			xmlObj.set("attribute", true)
+		}
+		prop.set("xml", xmlObj)
+	}
+	return prop
+}
+
+//	Returns the schema of el: a reference to its type (or to the definition of its anonymous type, for global elements), or else its anonymous type inlined.
+//	An anonymous complex type declaring (directly or via further anonymous types) an element of itself, as tree structures without global types do, is
+//	inlined only once: its recurrence is hoisted into a definition named after el, which defs then emits.
+func (me *jsonSchemaGen) element(el *Element) (obj *jsonObject) {
+	if name, ok := me.names[el]; ok {
+		obj = me.ref(name)
+	} else if t := el.Type.String(); len(t) > 0 {
+		obj = me.typeRef(el, t)
+	} else if (el.ComplexType != nil) && me.expanding[el.ComplexType] {
+		me.names[el] = uniqueName(el.Name.String(), me.taken)
+		me.hoisted = append(me.hoisted, el)
+		obj = me.ref(me.names[el])
+	} else if el.ComplexType != nil {
+		me.expanding[el.ComplexType] = true
+		obj = me.complexType(el.ComplexType)
+		delete(me.expanding, el.ComplexType)
+	} else if len(el.SimpleTypes) > 0 {
+		obj = me.simpleType(el.SimpleTypes[0])
+	} else {
+		obj = newJsonObject()
+	}
+	if el.Nillable {
+		obj = newJsonObject().set("anyOf", []interface{}{obj, newJsonObject().set("type", "null")})
+	}
+	if doc := el.Annotation.docText(); len(doc) > 0 {
+		obj = newJsonObject().set("description", doc).set("allOf", []interface{}{obj})
+	}
+	return
+}
+
+func (me *jsonSchemaGen) attribute(att *Attribute) (obj *jsonObject) {
+	if t := att.Type.String(); len(t) > 0 {
+		obj = me.typeRef(att, t)
+	} else if len(att.SimpleTypes) > 0 {
+		obj = me.simpleType(att.SimpleTypes[0])
+	} else {
+		obj = newJsonObject()
+	}
+	if doc := att.Annotation.docText(); len(doc) > 0 {
+		obj = newJsonObject().set("description", doc).set("allOf", []interface{}{obj})
+	}
+	return
+}
+
+//	Returns the schema for the simple or complex type referenced by qname from el.
+func (me *jsonSchemaGen) typeRef(el element, qname string) *jsonObject {
+	qn := ownerSchema(el).qname(qname)
+	if qn.Space == xsdNamespaceUri {
+		return jsonSchemaBuiltin(qn.Local)
+	} else if ct := me.comps.complexTypes[qn]; ct != nil {
+		return me.ref(me.names[ct])
+	} else if st := me.comps.simpleTypes[qn]; st != nil {
+		return me.ref(me.names[st])
+	}
+	return newJsonObject()
+}
+
+func (me *jsonSchemaGen) simpleType(st *SimpleType) (obj *jsonObject) {
+	if rest := st.RestrictionSimpleType; rest != nil {
+		var builtin string
+		if base := rest.Base.String(); len(base) > 0 {
+			qn := ownerSchema(rest).qname(base)
+			obj, builtin = me.typeRef(rest, base), me.comps.builtinBase(qn, 0)
+			if (len(builtin) == 0) && me.isList(qn, 0) {
+				builtin = "NMTOKENS"
+			}
+		} else if len(rest.SimpleTypes) > 0 {
+			obj = me.simpleType(rest.SimpleTypes[0])
+		} else {
+			obj = newJsonObject()
+		}
+		jsonSchemaFacets(obj, rest.facets(), builtin)
+	} else if list := st.List; list != nil {
+		var items *jsonObject
+		if item := list.ItemType.String(); len(item) > 0 {
+			items = me.typeRef(list, item)
+		} else if len(list.SimpleTypes) > 0 {
+			items = me.simpleType(list.SimpleTypes[0])
+		} else {
+			items = newJsonObject()
+		}
+		obj = newJsonObject().set("type", "array").set("items", items)
+	} else if union := st.Union; union != nil {
+		var members []interface{}
+		for _, mt := range strings.Fields(union.MemberTypes) {
+			members = append(members, me.typeRef(union, mt))
+		}
+		for _, mst := range union.SimpleTypes {
+			members = append(members, me.simpleType(mst))
+		}
+		obj = newJsonObject().set("anyOf", members)
+	} else {
+		obj = newJsonObject()
+	}
+	if doc := st.Annotation.docText(); len(doc) > 0 {
+		obj.set("description", doc)
+	}
+	return
+}
+
+//	Returns whether the simple type qn is (or restricts) a list type.
+func (me *jsonSchemaGen) isList(qn xml.Name, depth int) bool {
+	if qn.Space == xsdNamespaceUri {
+		return (qn.Local == "ENTITIES") || (qn.Local == "IDREFS") || (qn.Local == "NMTOKENS")
+	} else if st := me.comps.simpleTypes[qn]; (st != nil) && (depth < 64) {
+		if st.List != nil {
+			return true
+		} else if st.RestrictionSimpleType != nil {
+			return me.isList(ownerSchema(st).qname(st.RestrictionSimpleType.Base.String()), depth+1)
+		}
+	}
+	return false
+}
+
+func jsonSchemaBuiltin(name string) (obj *jsonObject) {
+	obj = newJsonObject()
+	if bounds, ok := jsonSchemaIntBounds[name]; ok {
+		if obj.set("type", "integer"); len(bounds[0]) > 0 {
+			obj.set("minimum", json.Number(bounds[0]))
+		}
+		if len(bounds[1]) > 0 {
+			obj.set("maximum", json.Number(bounds[1]))
+		}
+		return
+	}
+	switch name {
+	case "anySimpleType", "anyType":
+	case "boolean":
+		obj.set("type", "boolean")
+	case "decimal", "double", "float":
+		obj.set("type", "number")
+	case "base64Binary":
+		obj.set("type", "string").set("contentEncoding", "base64")
+	case "hexBinary":
+		obj.set("type", "string").set("pattern", "^([0-9a-fA-F]{2})*$")
+	case "ENTITIES", "IDREFS", "NMTOKENS":
+		obj.set("type", "array").set("items", newJsonObject().set("type", "string"))
+	default:
+		if obj.set("type", "string"); len(jsonSchemaFormats[name]) > 0 {
+			obj.set("format", jsonSchemaFormats[name])
+		}
+	}
+	return
+}
+
+//	Returns the ECMA-262 regular expression (in the Unicode mode that JSON Schema validators commonly use) equivalent to the XSD regular expression
+//	pattern, by rewriting the RE2 syntax that xsdt.TranslatePattern translates it to, or false if that fails: "\x{...}" escapes become "\u{...}",
+//	and "\-" outside of character classes (an invalid escape in the Unicode mode) becomes "-".
+func jsonSchemaPattern(pattern string) (re string, ok bool) {
+	var buf bytes.Buffer
+	var inClass bool
+	re2, err := xsdt.TranslatePattern(pattern)
+	if err != nil {
+		return
+	}
+	for i := 0; i < len(re2); i++ {
+		if (re2[i] == '\\') && (i+1 < len(re2)) {
+			if i++; re2[i] == 'x' {
+				buf.WriteString(`\u`)
+			} else if (re2[i] != '-') || inClass {
+				buf.WriteString(re2[i-1 : i+1])
+			} else {
+				buf.WriteByte('-')
+			}
+		} else {
+			if (re2[i] == '[') || (re2[i] == ']') {
+				inClass = (re2[i] == '[')
+			}
+			buf.WriteByte(re2[i])
+		}
+	}
+	return buf.String(), true
+}
+
+//	Adds to obj the JSON Schema keywords for those facets that can be expressed, given the built-in type that the restricted type derives from.
+func jsonSchemaFacets(obj *jsonObject, f *xsdt.Facets, builtin string) {
+	var isList = (builtin == "ENTITIES") || (builtin == "IDREFS") || (builtin == "NMTOKENS")
+	var isBinary = (builtin == "base64Binary") || (builtin == "hexBinary")
+	var _, isInt = jsonSchemaIntBounds[builtin]
+	var isNum = isInt || (builtin == "decimal") || (builtin == "double") || (builtin == "float")
+	if (len(f.Enumerations) > 0) && !isList {
+		var enum []interface{}
+		var seen = map[string]bool{}
+		for _, v := range f.Enumerations {
+			if lit, ok := jsonSchemaLiteral(v, builtin, isNum); ok && !seen[sfmt("%v", lit)] {
+				seen[sfmt("%v", lit)], enum = true, append(enum, lit)
+			}
+		}
+		if len(enum) > 0 {
+			obj.set("enum", enum)
+		}
+	}
+	if (len(f.Pattern) > 0) && !(isList || isNum || (builtin == "boolean")) {
+		if re, ok := jsonSchemaPattern(f.Pattern); ok {
+			obj.set("pattern", re)
+		}
+	}
+	if !(isNum || isBinary || (builtin == "boolean")) {
+		minKey, maxKey := ustr.Ifs(isList, "minItems", "minLength"), ustr.Ifs(isList, "maxItems", "maxLength")
+		if n, ok := jsonSchemaNumber(f.Length); ok {
+			obj.set(minKey, n).set(maxKey, n)
+		}
+		if n, ok := jsonSchemaNumber(f.MinLength); ok {
+			obj.set(minKey, n)
+		}
+		if n, ok := jsonSchemaNumber(f.MaxLength); ok {
+			obj.set(maxKey, n)
+		}
+	}
+	if isNum {
+		for i, val := range []string{f.MinInclusive, f.MaxInclusive, f.MinExclusive, f.MaxExclusive} {
+			if n, ok := jsonSchemaNumber(val); ok {
+				obj.set([]string{"minimum", "maximum", "exclusiveMinimum", "exclusiveMaximum"}[i], n)
+			}
+		}
+	}
+}
+
+//	Returns the JSON value for the enumeration value v of a type deriving from the specified built-in type, unless JSON cannot represent it (such as "INF" for numeric types).
+func jsonSchemaLiteral(v, builtin string, isNum bool) (lit interface{}, ok bool) {
+	if isNum {
+		return jsonSchemaNumber(v)
+	} else if builtin == "boolean" {
+		return (v == "true") || (v == "1"), true
+	}
+	return v, true
+}
+
+//	Returns the facet value s as a JSON number, if it is an XSD numeric literal (such as "+01.50" or "-.5") that JSON can represent.
+func jsonSchemaNumber(s string) (n json.Number, ok bool) {
+	var sign string
+	if s = strings.TrimSpace(s); strings.HasPrefix(s, "-") {
+		sign = "-"
+	}
+	if s = strings.TrimLeft(s, "+-"); strings.HasPrefix(s, ".") {
+		s = "0" + s
+	}
+	for (len(s) > 1) && (s[0] == '0') && (s[1] >= '0') && (s[1] <= '9') {
+		s = s[1:]
+	}
+	s = strings.Replace(strings.Replace(s, ".e", ".0e", 1), ".E", ".0E", 1)
+	if strings.HasSuffix(s, ".") {
+		s += "0"
+	}
+	if ok = (len(s) > 0) && (s[0] >= '0') && (s[0] <= '9') && json.Valid([]byte(s)); ok {
+		n = json.Number(sign + s)
+	}
+	return
+}
+
+func (me *jsonSchemaGen) ref(name string) *jsonObject {
+	return newJsonObject().set("$ref", me.refBase+name)
+}
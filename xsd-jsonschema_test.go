@@ -0,0 +1,32 @@
+package xsd
+
+import (
+	"strings"
+	"testing"
+)
+
+//	Tests that MakeJSONSchema mirrors the global types of a schema under "$defs", with patterns translated to ECMA-262 (and omitted if they cannot
+//	be), enumerations, length facets and numeric bounds as keywords, and arrays and "required" properties as per the occurrence constraints.
+func TestMakeJSONSchema(t *testing.T) {
+	doc, err := loadTestSchema(t, "jsonschema", "order.xsd").MakeJSONSchema()
+	if err != nil {
+		t.Fatal(err)
+	}
+	js := compactJson(t, doc)
+	for _, expected := range []string{
+		`{"$schema":"https://json-schema.org/draft/2020-12/schema",`,
+		`"type":"object","properties":{"order":{"$ref":"#/$defs/Order"}},"minProperties":1,"maxProperties":1,"additionalProperties":false,`,
+		`"Code":{"type":"string","pattern":"^(?:\\^[A-HJ-NP-Z]{2}\\p{Nd})$","minLength":3,"maxLength":8}`,
+		`"Runes":{"type":"string","pattern":"^(?:[\\u{0}-\\u{7F}]+-x)$"}`,
+		`"Broken":{"type":"string","minLength":2,"maxLength":2}`,
+		`"Status":{"type":"string","enum":["open","closed"]}`,
+		`"Qty":{"type":"integer","minimum":1,"maximum":2147483647,"enum":[1,10],"exclusiveMaximum":100}`,
+		`"Order":{"type":"object","properties":{"code":{"$ref":"#/$defs/Code"},"note":{"$ref":"#/$defs/Runes"},` +
+			`"qty":{"type":"array","items":{"$ref":"#/$defs/Qty"},"minItems":1,"maxItems":5},"pair":{"type":"array","items":{"$ref":"#/$defs/Broken"}},` +
+			`"status":{"$ref":"#/$defs/Status"}},"required":["code","qty","status"],"additionalProperties":false}`,
+	} {
+		if !strings.Contains(js, expected) {
+			t.Errorf("expected %s in\n%s", expected, doc)
+		}
+	}
+}
@@ -0,0 +1,228 @@
+package xsd
+
+import (
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/metaleap/go-util-str"
+)
+
+//	The names of the rules checked by Lint, as recorded in the Rule of the Diagnostics it returns.
+const (
+	LintAnonymousTypes = "anonymous-types"
+	LintUnboundedAny   = "unbounded-any"
+	LintDeprecated     = "deprecated"
+	LintMissingDocs    = "missing-docs"
+	LintCamelCase      = "camel-case"
+	LintDeepNesting    = "deep-nesting"
+)
+
+//	The rules checked by Lint, each of which is off if its field is false or 0.
+type LintRules struct {
+	//	If greater than 0, schema documents declaring more than this number of anonymous complex and simple types (see LintAnonymousTypes)
+	//	are reported, as anonymous types cannot be reused and get generated names (see PkgGen.AnonTypeNames).
+	MaxAnonymousTypes int
+
+	//	If true, xs:any wildcards that may occur any number of times, by their own maxOccurs or that of an enclosing particle (see LintUnboundedAny), are reported.
+	UnboundedAny bool
+
+	//	If true, xs:redefines (deprecated by XSD 1.1 in favor of xs:override) and references to components whose documentation starts with
+	//	"Deprecated" (as is customary for Go doc comments) are reported (see LintDeprecated).
+	Deprecated bool
+
+	//	If true, global elements, attributes, types, groups and attribute groups without xs:documentation are reported (see LintMissingDocs).
+	RequireDocs bool
+
+	//	If true, the names of elements, attributes, types, groups, attribute groups and notations that are not camel case, consisting of
+	//	nothing but letters and digits and starting with a letter, are reported (see LintCamelCase).
+	CamelCaseNames bool
+
+	//	If greater than 0, local element declarations nested in more than this number of enclosing element declarations are reported
+	//	(see LintDeepNesting), but not those nested even deeper within these.
+	MaxNesting int
+
+	//	Additional rules, keyed by their names, each returning a message for every construct it flags, or else "".
+	//	They are checked for every construct after the rules above, in the order of their names.
+	Custom map[string]func(node SchemaNode) string
+
+	//	The Severity of the Diagnostics reported (SeverityWarning by default).
+	Severity Severity
+}
+
+//	Returns LintRules with all rules on: at most 10 anonymous types per schema document and at most 4 enclosing element declarations.
+func DefaultLintRules() *LintRules {
+	return &LintRules{MaxAnonymousTypes: 10, UnboundedAny: true, Deprecated: true, RequireDocs: true, CamelCaseNames: true, MaxNesting: 4}
+}
+
+//	Checks rules (or DefaultLintRules, if nil) against all constructs (see Schema.Walk) of sd and the schema documents it includes, redefines
+//	and overrides, returning a Diagnostic for every problematic pattern found, with its Rule set to the name of the rule flagging it.
+func Lint(sd *Schema, rules *LintRules) (diags Diagnostics) {
+	var compiled = compileSchemas(sd)
+	if rules == nil {
+		rules = DefaultLintRules()
+	}
+	var custom []string
+	for name, _ := range rules.Custom {
+		custom = append(custom, name)
+	}
+	sort.Strings(custom)
+	for _, doc := range sd.allSchemas(map[string]bool{}) {
+		var anons []SchemaNode
+		doc.Walk(func(node SchemaNode) bool {
+			report := func(rule, format string, args ...interface{}) {
+				d := &Diagnostic{Severity: rules.Severity, Rule: rule, Message: sfmt(format, args...)}
+				d.File, d.Line, d.Column = node.Position()
+				diags = append(diags, d)
+			}
+			el := node.Elem.(element)
+			_, name := globalKindName(el)
+			if isAnonymousType(el) {
+				anons = append(anons, node)
+			}
+			if wildcard, ok := el.(*Any); ok && rules.UnboundedAny && unboundedParticle(wildcard) {
+				report(LintUnboundedAny, "xs:any may occur any number of times and accepts any content (processContents=%q)", ustr.Ifs(len(wildcard.ProcessContents) > 0, wildcard.ProcessContents, "strict"))
+			}
+			if rules.Deprecated {
+				if _, ok := el.(*Redefine); ok {
+					report(LintDeprecated, "xs:redefine is deprecated as of XSD 1.1, use xs:override instead")
+				}
+				for _, ref := range lintRefs(compiled, el) {
+					if kind, refName := globalKindName(ref); strings.HasPrefix(strings.ToLower(annotationOf(ref).docText()), "deprecated") {
+						report(LintDeprecated, "refers to the deprecated %s %s", kind, refName)
+					}
+				}
+			}
+			if rules.RequireDocs && isGlobal(el) && (len(name) > 0) && (len(annotationOf(el).docText()) == 0) {
+				report(LintMissingDocs, "the global %s %s has no documentation", node.XsdName(), name)
+			}
+			if rules.CamelCaseNames && (len(name) > 0) && !isCamelCase(name) {
+				report(LintCamelCase, "the name of the %s %s is not camel case", node.XsdName(), name)
+			}
+			if _, ok := el.(*Element); ok && (rules.MaxNesting > 0) && (enclosingElements(el) == rules.MaxNesting+1) {
+				report(LintDeepNesting, "the element %s is nested in more than %d element declarations", ustr.Ifs(len(name) > 0, name, nodeName(el).Local), rules.MaxNesting)
+			}
+			for _, rule := range custom {
+				if msg := rules.Custom[rule](node); len(msg) > 0 {
+					report(rule, "%s", msg)
+				}
+			}
+			return true
+		})
+		if (rules.MaxAnonymousTypes > 0) && (len(anons) > rules.MaxAnonymousTypes) {
+			d := &Diagnostic{Severity: rules.Severity, Rule: LintAnonymousTypes, Message: sfmt("the schema document declares %d anonymous types, more than %d: consider declaring named types", len(anons), rules.MaxAnonymousTypes)}
+			d.File, d.Line, d.Column = anons[rules.MaxAnonymousTypes].Position()
+			diags = append(diags, d)
+		}
+	}
+	return
+}
+
+//	Returns the xs:annotation of el, or nil if it has none.
+func annotationOf(el element) *Annotation {
+	for _, kid := range el.base().kids {
+		if ann, ok := kid.(*Annotation); ok {
+			return ann
+		}
+	}
+	return nil
+}
+
+//	Returns whether el is a complex or simple type declared without a name, within an element, attribute or other type.
+func isAnonymousType(el element) bool {
+	switch t := el.(type) {
+	case *ComplexType:
+		return len(t.Name) == 0
+	case *SimpleType:
+		return len(t.Name) == 0
+	}
+	return false
+}
+
+//	Returns whether the xs:any wildcard, or any particle enclosing it within its complex type or group, may occur any number of times.
+func unboundedParticle(wildcard *Any) bool {
+	if wildcard.hasAttrMaxOccurs.Value() < 0 {
+		return true
+	}
+	for p := wildcard.Parent(); p != nil; p = p.Parent() {
+		var maxOccurs *hasAttrMaxOccurs
+		switch particle := p.(type) {
+		case *Sequence:
+			maxOccurs = &particle.hasAttrMaxOccurs
+		case *Choice:
+			maxOccurs = &particle.hasAttrMaxOccurs
+		case *All:
+			maxOccurs = &particle.hasAttrMaxOccurs
+		default:
+			return false
+		}
+		if maxOccurs.Value() < 0 {
+			return true
+		}
+	}
+	return false
+}
+
+//	Returns the global components that el refers to by QName: the declarations of element, attribute, group and attribute group references,
+//	the types of element and attribute declarations, the base types of derivations and the item types of lists.
+func lintRefs(compiled *Compiled, el element) (refs []element) {
+	add := func(ref interface{}) {
+		if glob, ok := ref.(element); ok && (glob != el) && isGlobal(glob) {
+			refs = append(refs, glob)
+		}
+	}
+	addType := func(ref string) {
+		if len(ref) > 0 {
+			if t := compiled.Type(ownerSchema(el).qname(ref)); (t != nil) && (t.Complex != nil) {
+				add(t.Complex)
+			} else if (t != nil) && (t.Simple != nil) {
+				add(t.Simple)
+			}
+		}
+	}
+	switch decl := el.(type) {
+	case *Element:
+		add(compiled.Resolve(decl))
+		addType(decl.Type.String())
+	case *Attribute:
+		add(compiled.Resolve(decl))
+		addType(decl.Type.String())
+	case *Group:
+		add(compiled.Resolve(decl))
+	case *AttributeGroup:
+		add(compiled.Resolve(decl))
+	case *ExtensionComplexContent:
+		addType(decl.Base.String())
+	case *ExtensionSimpleContent:
+		addType(decl.Base.String())
+	case *RestrictionComplexContent:
+		addType(decl.Base.String())
+	case *RestrictionSimpleContent:
+		addType(decl.Base.String())
+	case *RestrictionSimpleType:
+		addType(decl.Base.String())
+	case *List:
+		addType(decl.ItemType.String())
+	}
+	return
+}
+
+//	Returns whether name consists of nothing but letters and digits, starting with a letter.
+func isCamelCase(name string) bool {
+	for i, r := range name {
+		if !(unicode.IsLetter(r) || ((i > 0) && unicode.IsDigit(r))) {
+			return false
+		}
+	}
+	return len(name) > 0
+}
+
+//	Returns the number of element declarations enclosing el.
+func enclosingElements(el element) (n int) {
+	for p := el.Parent(); p != nil; p = p.Parent() {
+		if _, ok := p.(*Element); ok {
+			n++
+		}
+	}
+	return
+}
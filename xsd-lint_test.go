@@ -0,0 +1,39 @@
+package xsd
+
+import "testing"
+
+//	Tests that Lint reports every rule in the order of the constructs flagged, with the anonymous types of a document last, and that rules
+//	that are off report nothing.
+func TestLint(t *testing.T) {
+	sd := loadTestSchema(t, "lint", "doc.xsd")
+	rules := DefaultLintRules()
+	rules.MaxAnonymousTypes, rules.MaxNesting, rules.Severity = 2, 2, SeverityError
+	rules.Custom = map[string]func(SchemaNode) string{"no-attributes": func(node SchemaNode) string {
+		if _, ok := node.Elem.(*Attribute); ok {
+			return "attributes are not allowed"
+		}
+		return ""
+	}}
+	var found []string
+	for _, d := range Lint(sd, rules) {
+		if d.Severity != SeverityError {
+			t.Errorf("expected %s to be an error", d.Error())
+		}
+		found = append(found, sfmt("%d:%d %s: %s", d.Line, d.Column, d.Rule, d.Message))
+	}
+	expected := []string{
+		"34:41 missing-docs: the global attribute code has no documentation",
+		"34:41 no-attributes: attributes are not allowed",
+		"21:56 deprecated: refers to the deprecated simpleType OldCode",
+		"21:56 camel-case: the name of the element serial_no is not camel case",
+		"21:56 deep-nesting: the element serial_no is nested in more than 2 element declarations",
+		`29:37 unbounded-any: xs:any may occur any number of times and accepts any content (processContents="lax")`,
+		"19:25 anonymous-types: the schema document declares 3 anonymous types, more than 2: consider declaring named types",
+	}
+	if sfmt("%q", found) != sfmt("%q", expected) {
+		t.Errorf("expected the lint findings\n%q\ngot\n%q", expected, found)
+	}
+	if diags := Lint(sd, &LintRules{MaxAnonymousTypes: 3, MaxNesting: 3}); len(diags) > 0 {
+		t.Errorf("expected no lint findings within the limits, got %v", diags)
+	}
+}
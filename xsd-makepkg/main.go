@@ -17,6 +17,8 @@ var (
 	flagSchema     = flag.String("uri", "", "The XML Schema Definition file URIs to generate a Go wrapper packages from, whitespace-separated. (For each, the protocol prefix can be omitted, it then defaults to http://. Only protocols understood by the net/http package are supported.)")
 	flagLocalCopy  = flag.Bool("local", true, "Local copy: only downloads if file does not exist locally")
 	flagForceParse = flag.Bool("parse", false, "Not necessary unless the generated Go wrapper package won't compile.")
+	flagCatalog    = flag.String("catalog", "", "OASIS XML Catalog file paths, whitespace-separated, to remap schema URIs to local files (or other URIs) before downloading.")
+	flagJsonTags   = flag.String("json", xsd.JsonTagsNone, "If not empty, generated struct fields get json tags alongside their xml tags, with names cased as either camelCase, snake_case or as-is.")
 	flagBasePath   = flag.String("basepath", "", "Defaults to "+xsd.PkgGen.BasePath+". A $GOPATH/src/-relative path (always a slash-style path, even on Windows) where XSD files are downloaded to / loaded from and generated Go wrapper packages are created. Any XSD imports are also rewritten as Go imports from that path (but are not otherwise auto-magically processed in any way).")
 
 	//	if no schemas are specified in *flagSchema, we run the pkg-maker through a default series of various XSDs...
@@ -44,10 +46,11 @@ var (
 
 func main() {
 	var (
-		sd          *xsd.Schema
-		err         error
-		raw         []byte
-		outFilePath string
+		sd           *xsd.Schema
+		err          error
+		raw          []byte
+		outFilePaths []string
+		diags        xsd.Diagnostics
 	)
 	flag.Parse()
 	if len(*flagSchema) > 0 {
@@ -56,28 +59,40 @@ func main() {
 	if len(*flagBasePath) > 0 {
 		xsd.PkgGen.BasePath, xsd.PkgGen.BaseCodePath = *flagBasePath, ugo.GopathSrc(strings.Split(*flagBasePath, "/")...)
 	}
+	xsd.PkgGen.JsonTags = *flagJsonTags
+	if len(*flagCatalog) > 0 {
+		if xsd.PkgGen.Catalog, err = xsd.LoadCatalog(strings.Fields(*flagCatalog)...); err != nil {
+			log.Fatalf("CATALOG:\t%v\n", err)
+		}
+	}
 	for _, s := range schemas {
 		log.Printf("LOAD:\t%v\n", s)
 		if sd, err = xsd.LoadSchema(s, *flagLocalCopy); err != nil {
 			log.Printf("\tERROR: %v\n", err)
 		} else if sd != nil {
 			xsd.PkgGen.ForceParseForDefaults = *flagForceParse || (s == "schemas.opengis.net/kml/2.2.0/ogckml22.xsd") // KML schema uses 0 and 1 as defaults for booleans...
-			if outFilePath, err = sd.MakeGoPkgSrcFile(); err == nil {
-				log.Printf("MKPKG:\t%v\n", outFilePath)
-				if *flagGoFmt {
-					if raw, err = exec.Command("gofmt", "-w=true", "-s=true", "-e=true", outFilePath).CombinedOutput(); len(raw) > 0 {
-						log.Printf("GOFMT:\t%s\n", string(raw))
-					}
-					if err != nil {
-						log.Printf("GOFMT:\t%v\n", err)
-					}
-				}
-				if *flagGoInst {
-					if raw, err = exec.Command("go-buildrun", "-d=__doc.html", "-f="+outFilePath).CombinedOutput(); len(raw) > 0 {
-						println(string(raw))
+			outFilePaths, diags, err = sd.MakeGoPkgSrcFiles()
+			for _, d := range diags {
+				log.Printf("DIAG:\t%v\n", d)
+			}
+			if err == nil {
+				for _, outFilePath := range outFilePaths {
+					log.Printf("MKPKG:\t%v\n", outFilePath)
+					if *flagGoFmt {
+						if raw, err = exec.Command("gofmt", "-w=true", "-s=true", "-e=true", outFilePath).CombinedOutput(); len(raw) > 0 {
+							log.Printf("GOFMT:\t%s\n", string(raw))
+						}
+						if err != nil {
+							log.Printf("GOFMT:\t%v\n", err)
+						}
 					}
-					if err != nil {
-						log.Printf("GOINST:\t%v\n", err)
+					if *flagGoInst {
+						if raw, err = exec.Command("go-buildrun", "-d=__doc.html", "-f="+outFilePath).CombinedOutput(); len(raw) > 0 {
+							println(string(raw))
+						}
+						if err != nil {
+							log.Printf("GOINST:\t%v\n", err)
+						}
 					}
 				}
 			} else {
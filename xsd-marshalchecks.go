@@ -0,0 +1,292 @@
+package xsd
+
+import (
+	"strings"
+
+	"github.com/metaleap/go-util-str"
+
+	xsdt "github.com/metaleap/go-xsd/types"
+)
+
+//	The statements of a CheckBeforeMarshal() method being collected by marshalChecks.
+type marshalCheck struct {
+	stmts   []string
+	choices []*marshalChoice
+}
+
+//	An xs:choice whose alternatives are checked for exclusivity by a CheckBeforeMarshal() method.
+type marshalChoice struct {
+	choice *Choice
+
+	//	Whether the choice must occur in every instance, see particlesRequired.
+	required bool
+
+	//	The xsdt.ChoiceField literals of the element fields of its alternatives.
+	fields []string
+
+	//	Its alternatives in the order their first fields were found, and whether each of these requires at least one of its fields to be set.
+	alts   []element
+	needed map[element]bool
+}
+
+//	Renders a CheckBeforeMarshal() method (see PkgGen.AddMarshalChecks) for every struct type rendered for a complex type, after all types are rendered
+//	so that the names of the embeds that its fields are reached through are final. Its checks are in the document order of the XSD components declaring
+//	the fields checked, followed by those of the xs:choices containing them.
+func (me *PkgBag) addMarshalChecks() {
+	for _, dt := range me.declWrittenTypes {
+		if ct, isCt := dt.elem.(*ComplexType); isCt && (len(dt.Type) == 0) {
+			var body, doc string
+			mc := &marshalCheck{}
+			me.marshalChecks(dt, "me", nil, mc, 0)
+			if ct.Abstract {
+				mc.stmts = append(mc.stmts, sfmt("%s.CheckAbstractType(%#v)", me.impName, ct.Name.String()))
+				doc = sfmt(" As %s is abstract, this also fails unless its %sXsiType field holds an instance of a type derived from it.", ct.Name, idPrefix)
+			}
+			if dt.Fields[idPrefix+"XsiType"] != nil {
+				body += sfmt("\n\tif me.%sXsiType != nil {\n\t\treturn %s.CheckValue(\"\", me.%sXsiType)\n\t}", idPrefix, me.impName, idPrefix)
+			}
+			for _, stmt := range mc.stmts {
+				body += sfmt("\n\tif err = %s; err != nil {\n\t\treturn\n\t}", stmt)
+			}
+			for _, ch := range mc.choices {
+				if len(ch.alts) > 1 {
+					required := ch.required && (len(ch.alts) == len(choiceAlternatives(ch.choice)))
+					for _, alt := range ch.alts {
+						required = required && ch.needed[alt]
+					}
+					body += sfmt("\n\tif err = %s.CheckChoice(%v, %s); err != nil {\n\t\treturn\n\t}", me.impName, required, strings.Join(ch.fields, ", "))
+				}
+			}
+			me.impsUsed[me.impName] = true
+			me.renderSplit(dt.elem, func() {
+				me.appendFmt(false, "//\tReturns an *%s.ContentError if this %s instance (or any instance in its element fields, checked in turn) does not conform to the content model of its XSD type: if required attributes or elements are missing, elements occur more often than permitted, or more than one alternative of an xs:choice is set.%s", me.impName, dt.Name, doc)
+				me.appendFmt(true, "func (me *%s) CheckBeforeMarshal () (err error) {%s\n\treturn\n}", dt.Name, body)
+			})
+		} else if cases := me.choiceUnionChecks(dt); len(cases) > 0 {
+			me.impsUsed[me.impName] = true
+			me.renderSplit(dt.elem, func() {
+				me.appendFmt(false, "//\tReturns an *%s.ContentError if the instance of the alternative named by Which does not conform to the content model of its XSD type (see CheckValue).", me.impName)
+				me.appendFmt(true, "func (me *%s) CheckBeforeMarshal () (err error) {\n\tswitch me.Which {%s\n\t}\n\treturn\n}", dt.Name, cases)
+			})
+		}
+	}
+}
+
+//	Returns the cases of the switch on Which in the CheckBeforeMarshal() method of the choice union type dt (see addChoiceUnions), one for every alternative
+//	whose element field may have a CheckBeforeMarshal() method, or "" if dt is not a choice union type or none of its alternatives has such a field.
+func (me *PkgBag) choiceUnionChecks(dt *declType) (cases string) {
+	if _, isChoice := dt.elem.(*Choice); isChoice && strings.HasPrefix(dt.Name, idPrefix+"Choice_") {
+		for _, e := range dt.positionalEmbeds() {
+			for _, f := range me.declTypes[e.typeName(me)].sortedFields() {
+				if me.isCheckedType(f.typeName(me)) {
+					cases += sfmt("\n\tcase %#v:\n\t\treturn %s.CheckValue(%#v, &me.%s)", xmlTagName(f), me.impName, xmlTagName(f), f.Name)
+				}
+			}
+		}
+	}
+	return
+}
+
+//	Appends to mc the checks of the choice union fields (see addChoiceUnions) of the struct type dt and of the attributes and elements declared by its embeds,
+//	including those of its base type and of the attribute groups and element groups it refers to. Fields are denoted by path, and refs are the element group
+//	references that dt was reached through (outermost first). For base types of other packages, their own CheckBeforeMarshal() method (if any) is called.
+func (me *PkgBag) marshalChecks(dt *declType, path string, refs []element, mc *marshalCheck, depth int) {
+	for _, f := range dt.sortedFields() {
+		if ch, isChoice := f.elem.(*Choice); isChoice && strings.HasPrefix(f.Type, idPrefix+"Choice_") {
+			var names []string
+			min, _ := particleOccurs(ch)
+			for _, e := range me.declTypes[f.Type].positionalEmbeds() {
+				for _, af := range me.declTypes[e.typeName(me)].sortedFields() {
+					names = append(names, sfmt("%#v", xmlTagName(af)))
+				}
+			}
+			mc.stmts = append(mc.stmts, sfmt("%s.CheckWhich(%s.%s.Which, %v, %s)", me.impName, path, f.Name, (min > 0) && particlesRequired(particleChain(ch, groupRefs(refs, f.via))[1:]), strings.Join(names, ", ")))
+			if len(me.choiceUnionChecks(me.declTypes[f.Type])) > 0 {
+				mc.stmts = append(mc.stmts, sfmt("%s.CheckValue(\"\", &%s.%s)", me.impName, path, f.Name))
+			}
+		}
+	}
+	for _, e := range dt.positionalEmbeds() {
+		etn := e.typeName(me)
+		edt := me.declTypes[etn]
+		if depth > 64 {
+			return
+		} else if edt == nil {
+			if (e.elem == nil) && strings.Contains(etn, ".") && !me.isAbstractBase(dt) {
+				mc.stmts = append(mc.stmts, sfmt("%s.CheckValue(\"\", &%s.%s)", me.impName, path, etn[strings.LastIndex(etn, ".")+1:]))
+			}
+			continue
+		}
+		switch el := e.elem.(type) {
+		case nil:
+			me.marshalChecks(edt, path+"."+etn, nil, mc, depth+1)
+		case *AttributeGroup:
+			me.marshalChecks(edt, path+"."+etn, refs, mc, depth+1)
+		case *Group:
+			me.marshalChecks(edt, path+"."+etn, append(append([]element{}, refs...), el), mc, depth+1)
+		case *Attribute:
+			if el.Use == "required" {
+				for _, f := range edt.sortedFields() {
+					mc.stmts = append(mc.stmts, sfmt("%s.CheckOccurs(%#v, &%s.%s.%s, 1, 1)", me.impName, "@"+xmlTagName(f), path, etn, f.Name))
+				}
+			}
+		case *Element:
+			chain := particleChain(el, groupRefs(refs, e.via))
+			min, max := particleOccurs(el)
+			for _, p := range chain[1:] {
+				if _, pmax := particleOccurs(p); pmax != 1 {
+					max = -1
+				}
+			}
+			if !particlesRequired(chain) {
+				min = 0
+			}
+			for _, f := range edt.sortedFields() {
+				name, field := xmlTagName(f), sfmt("%s.%s.%s", path, etn, f.Name)
+				ftn := f.typeName(me)
+				if isSlice := strings.HasPrefix(ftn, "[]"); ((min > 0) && (len(edt.Embeds) == 0)) || (isSlice && (max >= 0)) {
+					mc.stmts = append(mc.stmts, sfmt("%s.CheckOccurs(%#v, &%s, %s, %s)", me.impName, name, field, ustr.Ifs(len(edt.Embeds) == 0, sfmt("%d", min), "0"), ustr.Ifs(isSlice, sfmt("%d", max), "-1")))
+				}
+				if decl, _ := me.compile().Resolve(el).(*Element); (decl != nil) && decl.Abstract && !strings.HasPrefix(strings.TrimLeft(ftn, "[]*"), idPrefix+"Substs_") {
+					mc.stmts = append(mc.stmts, sfmt("%s.CheckAbstract(%#v, &%s)", me.impName, name, field))
+				} else if me.isCheckedType(ftn) {
+					mc.stmts = append(mc.stmts, sfmt("%s.CheckValue(%#v, &%s)", me.impName, name, field))
+				}
+				for k := 1; k < len(chain); k++ {
+					if ch, isChoice := chain[k].(*Choice); isChoice && !particlesRepeated(chain[k:]) {
+						mc.choice(ch, chain[k+1:]).add(chain[k-1], particlesRequired(chain[:k]), sfmt("%s.ChoiceField{Alternative: %%d, Name: %#v, Value: &%s}", me.impName, name, field))
+					}
+				}
+			}
+		}
+	}
+}
+
+//	Returns the marshalChoice recorded in mc for ch, recording a new one if there is none yet. The particles enclosing ch determine whether it is required.
+func (me *marshalCheck) choice(ch *Choice, enclosing []element) (mch *marshalChoice) {
+	for _, mch = range me.choices {
+		if mch.choice == ch {
+			return
+		}
+	}
+	min, _ := particleOccurs(ch)
+	mch = &marshalChoice{choice: ch, required: (min > 0) && particlesRequired(enclosing), needed: map[element]bool{}}
+	me.choices = append(me.choices, mch)
+	return
+}
+
+//	Records the xsdt.ChoiceField literal format lit (missing the index of its alternative) of an element field of the alternative alt,
+//	and whether that element must occur in every instance of alt.
+func (me *marshalChoice) add(alt element, needed bool, lit string) {
+	var index = -1
+	for i, a := range me.alts {
+		if a == alt {
+			index = i
+		}
+	}
+	if index < 0 {
+		index, me.alts = len(me.alts), append(me.alts, alt)
+	}
+	me.needed[alt] = me.needed[alt] || needed
+	me.fields = append(me.fields, sfmt(lit, index))
+}
+
+//	Returns the particles (elements, element group references and compositors) that are alternatives of ch.
+func choiceAlternatives(ch *Choice) (alts []element) {
+	for _, kid := range ch.kids {
+		switch kid.(type) {
+		case *Element, *Group, *Choice, *Sequence, *Any:
+			alts = append(alts, kid)
+		}
+	}
+	return
+}
+
+//	Returns whether the struct type dt is of a complex type deriving from an abstract complex type, whose CheckBeforeMarshal() method (if any) would always fail
+//	when called on the embedded base type instance, so that it is not called.
+func (me *PkgBag) isAbstractBase(dt *declType) bool {
+	if td := me.compile().TypeDefOf(dt.elem); (td != nil) && (td.Base != nil) && (td.Base.Complex != nil) {
+		return td.Base.Complex.Abstract
+	}
+	return false
+}
+
+//	Returns whether values of the specified type (as found in a struct field, and if a slice, as its items) may have a CheckBeforeMarshal() method.
+func (me *PkgBag) isCheckedType(tn string) bool {
+	tn = strings.TrimLeft(tn, "[]*")
+	if strings.Contains(tn, ".") {
+		return !strings.HasPrefix(tn, me.impName+".")
+	} else if dt := me.declTypes[tn]; dt != nil {
+		_, isCt := dt.elem.(*ComplexType)
+		return (isCt && (len(dt.Type) == 0)) || strings.HasPrefix(tn, idPrefix+"Substs_")
+	}
+	return false
+}
+
+//	Returns the local XML name in the xml tag of the field f.
+func xmlTagName(f *declField) (name string) {
+	if name = f.XmlTag; strings.Contains(name, ",") {
+		name = name[:strings.Index(name, ",")]
+	}
+	return name[strings.LastIndex(name, " ")+1:]
+}
+
+//	Returns the particles from particle (an element, element group reference or compositor) up to, but excluding, the complex type it is declared in.
+//	If it is declared in a named element group, the chain continues with that of the last of refs (the element group references that the group was reached
+//	through, outermost first), if any.
+func particleChain(particle element, refs []element) (chain []element) {
+	for el := particle; el != nil; el = el.Parent() {
+		switch el.(type) {
+		case *ComplexType:
+			return
+		case *Group:
+			if el != particle {
+				if len(refs) > 0 {
+					chain = append(chain, particleChain(refs[len(refs)-1], refs[:len(refs)-1])...)
+				}
+				return
+			}
+		}
+		chain = append(chain, el)
+	}
+	return
+}
+
+//	Returns the minOccurs and maxOccurs (-1 if unbounded) of the specified particle, or 1 and 1 if it has none (such as a derivation).
+func particleOccurs(particle element) (min, max xsdt.Long) {
+	switch p := particle.(type) {
+	case *Element:
+		return p.hasAttrMinOccurs.Value(), p.hasAttrMaxOccurs.Value()
+	case *Group:
+		return p.hasAttrMinOccurs.Value(), p.hasAttrMaxOccurs.Value()
+	case *Sequence:
+		return p.hasAttrMinOccurs.Value(), p.hasAttrMaxOccurs.Value()
+	case *Choice:
+		return p.hasAttrMinOccurs.Value(), p.hasAttrMaxOccurs.Value()
+	case *All:
+		return p.hasAttrMinOccurs.Value(), p.hasAttrMaxOccurs.Value()
+	}
+	return 1, 1
+}
+
+//	Returns whether none of the specified particles has a minOccurs of 0 or is an xs:choice.
+func particlesRequired(chain []element) bool {
+	for _, p := range chain {
+		if _, isChoice := p.(*Choice); isChoice {
+			return false
+		} else if min, _ := particleOccurs(p); min < 1 {
+			return false
+		}
+	}
+	return true
+}
+
+//	Returns whether any of the specified particles may occur more than once.
+func particlesRepeated(chain []element) bool {
+	for _, p := range chain {
+		if _, max := particleOccurs(p); max != 1 {
+			return true
+		}
+	}
+	return false
+}
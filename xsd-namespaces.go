@@ -0,0 +1,91 @@
+package xsd
+
+import (
+	"strings"
+
+	"github.com/metaleap/go-util-str"
+)
+
+//	Renders (see PkgGen.AddNamespaces) a constant for the target namespace and for every other namespace declared in the schema document of
+//	this package or the schema documents it includes, the XsdGoPkgNamespaces variable of their namespace declarations, and the XsdGoPkgName()
+//	and XsdGoPkgQName() functions returning the names of the schema's components. Prefixes declared differently by several of these schema
+//	documents are bound as by the first of them (the schema document itself first).
+func (me *PkgBag) addNamespaces() {
+	var prefixes = map[string]string{}
+	var consts = map[string]string{}
+	var names []string
+	var taken = map[string]bool{}
+	var tns = me.Schema.TargetNamespace.String()
+	for _, sd := range me.Schema.includedSchemas(nil) {
+		for _, prefix := range sortedKeys(sd.XMLNamespaces) {
+			if _, done := prefixes[prefix]; !done {
+				prefixes[prefix] = sd.XMLNamespaces[prefix]
+			}
+		}
+	}
+	consts[tns], taken[idPrefix+"TargetNamespace"] = idPrefix+"TargetNamespace", true
+	for _, prefix := range sortedKeys(prefixes) {
+		if ns := prefixes[prefix]; len(consts[ns]) == 0 {
+			name := idPrefix + "Ns_" + ustr.Ifs(len(prefix) == 0, "Default", safeIdentifier(prefix))
+			for i := 1; taken[name]; i++ {
+				name = sfmt("%sNs_%s%d", idPrefix, ustr.Ifs(len(prefix) == 0, "Default", safeIdentifier(prefix)), i)
+			}
+			consts[ns], taken[name] = name, true
+			names = append(names, ns)
+		}
+	}
+	var xmlImp = me.xmlImpName()
+	me.imports[xmlImp], me.impsUsed[xmlImp], me.impsUsed[me.impName] = "encoding/xml", true, true
+	me.appendFmt(false, "const (\n\t//\tThe target namespace of the schema of this package.\n\t%s = %q", consts[tns], tns)
+	for _, ns := range names {
+		var bound []string
+		var isDefault bool
+		for _, prefix := range sortedKeys(prefixes) {
+			if prefixes[prefix] != ns {
+				continue
+			} else if len(prefix) == 0 {
+				isDefault = true
+			} else {
+				bound = append(bound, prefix)
+			}
+		}
+		var how string
+		switch len(bound) {
+		case 0:
+		case 1:
+			how = " with the prefix " + bound[0]
+		default:
+			how = sfmt(" with the prefixes %s and %s", strings.Join(bound[:len(bound)-1], ", "), bound[len(bound)-1])
+		}
+		if isDefault {
+			how = ustr.Ifs(len(how) == 0, " as the default namespace", " as the default namespace and"+how)
+		}
+		me.appendFmt(false, "\t//\tA namespace declared in the schema%s.\n\t%s = %q", how, consts[ns], ns)
+	}
+	me.appendFmt(true, ")")
+	var entries []string
+	for _, prefix := range sortedKeys(prefixes) {
+		entries = append(entries, sfmt("%q: %s", prefix, consts[prefixes[prefix]]))
+	}
+	me.appendFmt(false, "//\tThe namespace declarations of the schema of this package, mapping their prefixes to the namespaces bound to them.")
+	me.appendFmt(true, "var %sNamespaces = %s.Namespaces{%s}", idPrefix, me.impName, strings.Join(entries, ", "))
+	me.appendFmt(false, "//\tReturns the name of the global element, attribute or type named local in the target namespace of the schema of this package.")
+	me.appendFmt(true, "func %sName (local string) %s.Name {\n\treturn %s.Name{Space: %s, Local: local}\n}", idPrefix, xmlImp, xmlImp, consts[tns])
+	me.appendFmt(false, "//\tReturns the name denoted by the lexical QName qname (such as \"xs:string\"), with its prefix resolved as declared in the schema of this package")
+	me.appendFmt(false, "//\t(see %sNamespaces). Unprefixed names are in the default namespace of the schema, if any. Unbound prefixes resolve to no namespace.", idPrefix)
+	me.appendFmt(true, "func %sQName (qname string) %s.Name {\n\tv, _ := %sNamespaces.Resolve(qname)\n\treturn v.Name()\n}", idPrefix, xmlImp, idPrefix)
+}
+
+//	Returns this schema document and all the schema documents it includes, directly or indirectly, in document order and depth-first.
+func (me *Schema) includedSchemas(done map[*Schema]bool) (sds []*Schema) {
+	if done == nil {
+		done = map[*Schema]bool{}
+	}
+	if !done[me] {
+		done[me], sds = true, []*Schema{me}
+		for _, inc := range me.XMLIncludedSchemas {
+			sds = append(sds, inc.includedSchemas(done)...)
+		}
+	}
+	return
+}
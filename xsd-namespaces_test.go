@@ -0,0 +1,46 @@
+package xsd
+
+import (
+	"strings"
+	"testing"
+)
+
+//	Tests that PkgGen.AddNamespaces declares a constant per namespace of the schema document and its includes, the first declaration of a prefix
+//	winning, and that XsdGoPkgName() and XsdGoPkgQName() resolve names as the schema does.
+func TestNamespaceConstants(t *testing.T) {
+	src, _ := genTestSrc(t, "namespaces", "main.xsd", func(opts *GenOptions) { opts.AddNamespaces = true })
+	for _, decl := range []string{
+		"\tXsdGoPkgTargetNamespace = \"urn:example:ns\"\n\t//\tA namespace declared in the schema with the prefix c.\n\tXsdGoPkgNs_c = \"urn:example:common\"\n\t//\tA namespace declared in the schema with the prefix o.\n\tXsdGoPkgNs_o = \"urn:example:other\"\n\t//\tA namespace declared in the schema with the prefix x-y.\n\tXsdGoPkgNs_x_y = \"urn:example:xy\"\n",
+		`var XsdGoPkgNamespaces = xsdt.Namespaces{"": XsdGoPkgTargetNamespace, "c": XsdGoPkgNs_c, "ns": XsdGoPkgTargetNamespace, "o": XsdGoPkgNs_o, "x-y": XsdGoPkgNs_x_y, `,
+	} {
+		if !strings.Contains(src, decl) {
+			t.Errorf("expected\n%s\nin\n%s", decl, src)
+		}
+	}
+	gopath, goOutFilePaths := genTestPkgs(t, "namespaces", func(opts *GenOptions) { opts.AddNamespaces = true })
+	goTestPkg(t, gopath, goOutFilePaths[0], `package go_Main
+
+import (
+	"encoding/xml"
+	"testing"
+)
+
+func TestNames(t *testing.T) {
+	for qname, expected := range map[string]xml.Name{
+		"Order":     XsdGoPkgName("Order"),
+		"ns:Order":  {Space: XsdGoPkgTargetNamespace, Local: "Order"},
+		"c:Code":    {Space: "urn:example:common", Local: "Code"},
+		"o:Code":    {Space: XsdGoPkgNs_o, Local: "Code"},
+		"xs:string": {Space: "http://www.w3.org/2001/XMLSchema", Local: "string"},
+		"zz:Code":   {Local: "Code"},
+	} {
+		if actual := XsdGoPkgQName(qname); actual != expected {
+			t.Errorf("%s: expected %v, got %v", qname, expected, actual)
+		}
+	}
+	if qn, ok := XsdGoPkgNamespaces.QName(XsdGoPkgTargetNamespace, "Order"); !ok || (qn.String() != "Order") {
+		t.Errorf("expected the target namespace to be the default namespace, got %v", qn)
+	}
+}
+`)
+}
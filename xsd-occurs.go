@@ -0,0 +1,85 @@
+package xsd
+
+import (
+	"strings"
+
+	xsdt "github.com/metaleap/go-xsd/types"
+)
+
+//	An element slice field whose number of items is checked by a CheckOccurrences() method (see occursChecks).
+type occursCheck struct {
+	field *declField
+
+	//	The name of the embed declaring field, and the Go expression denoting field, such as "me.XsdGoPkgHasElems_Thing.Things".
+	embed, path string
+
+	//	The minOccurs and maxOccurs (-1 if unbounded) of its element, as effective in the complex type checked.
+	min, max xsdt.Long
+}
+
+//	Renders a CheckOccurrences() method for every struct type rendered for a complex type that has element slice fields of bounded length (or of
+//	a minimum length greater than 1), along with exported constants of their minOccurs and maxOccurs, which its Validate() method calls (see declType.render).
+//	Like addMarshalChecks, this is done after all types are rendered, so that the names of the embeds that its fields are reached through are final.
+func (me *PkgBag) addOccursChecks() {
+	for _, dt := range me.declWrittenTypes {
+		var checks []*occursCheck
+		if me.occursChecks(dt, "me", nil, &checks, 0); len(checks) > 0 {
+			var consts, body string
+			var names = map[string]bool{}
+			for _, c := range checks {
+				name, cn := xmlTagName(c.field), dt.Name+"_"+c.field.Name
+				if names[cn] {
+					cn = dt.Name + "_" + c.embed + "_" + c.field.Name
+				}
+				names[cn] = true
+				consts += sfmt("\n\t//\tThe minOccurs and maxOccurs (-1 if unbounded) of the %s element in the %s field of %s.\n\t%s_MinOccurs, %s_MaxOccurs = %d, %d\n", name, c.field.Name, dt.Name, cn, cn, c.min, c.max)
+				body += sfmt("\n\tif err = %s.CheckLen(%#v, len(%s), %s_MinOccurs, %s_MaxOccurs); err != nil {\n\t\treturn\n\t}", me.impName, name, c.path, cn, cn)
+			}
+			me.impsUsed[me.impName] = true
+			me.renderSplit(dt.elem, func() {
+				me.appendFmt(true, "const (%s)", consts)
+				me.appendFmt(false, "//\tReturns an *%s.ContentError if an element of this %s instance that may only occur a bounded number of times (or at least more than once) occurs too few or too many times.", me.impName, dt.Name)
+				me.appendFmt(true, "func (me *%s) CheckOccurrences () (err error) {%s\n\treturn\n}", dt.Name, body)
+			})
+		}
+	}
+}
+
+//	Appends to checks the element slice fields declared by the embeds of the struct type dt (if it is that of a complex type), including those of the
+//	element groups it refers to but excluding those of its base type (which are checked by its own CheckOccurrences() method), whose number of items is
+//	bounded by the effective maxOccurs of their elements or of a minOccurs greater than 1. Fields are denoted by path, and refs are the element group
+//	references that dt was reached through (outermost first).
+func (me *PkgBag) occursChecks(dt *declType, path string, refs []element, checks *[]*occursCheck, depth int) {
+	if _, isCt := dt.elem.(*ComplexType); (depth == 0) && !(isCt && (len(dt.Type) == 0)) {
+		return
+	}
+	for _, e := range dt.positionalEmbeds() {
+		etn := e.typeName(me)
+		edt := me.declTypes[etn]
+		if depth > 64 {
+			return
+		} else if edt == nil {
+			continue
+		}
+		switch el := e.elem.(type) {
+		case *Group:
+			me.occursChecks(edt, path+"."+etn, append(append([]element{}, refs...), el), checks, depth+1)
+		case *Element:
+			chain := particleChain(el, groupRefs(refs, e.via))
+			min, max := particleOccurs(el)
+			for _, p := range chain[1:] {
+				if _, pmax := particleOccurs(p); pmax != 1 {
+					max = -1
+				}
+			}
+			if (!particlesRequired(chain)) || (len(edt.Embeds) > 0) {
+				min = 0
+			}
+			for _, f := range edt.sortedFields() {
+				if strings.HasPrefix(f.typeName(me), "[]") && ((min > 1) || (max >= 0)) {
+					*checks = append(*checks, &occursCheck{field: f, embed: etn, path: sfmt("%s.%s.%s", path, etn, f.Name), min: min, max: max})
+				}
+			}
+		}
+	}
+}
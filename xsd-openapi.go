@@ -0,0 +1,60 @@
+package xsd
+
+import (
+	"encoding/json"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/metaleap/go-util-fs"
+	"github.com/metaleap/go-util-str"
+)
+
+//	Returns an OpenAPI 3.1 document whose "components/schemas" hold the definitions of the JSON Schema document returned by MakeJSONSchema
+//	(all global complex and simple types of this schema and all schemas it includes and imports, as well as the anonymous types of their
+//	global elements), with the same descriptions (from xs:documentation) and constraints (occurrences, facets), for merging into the OpenAPI
+//	description of a REST gateway to an XML service. Attributes, and elements or attributes whose names differ from their property names
+//	(see PkgGen.JsonTags), carry OpenAPI XML objects, as do the schemas of global elements (naming the element and its namespace).
+//	The document has no paths; its info object is titled after the XSD file name, with the schema's version attribute (or else "1.0") as version.
+func (me *Schema) MakeOpenAPI() (doc []byte, err error) {
+	var gen = newJsonSchemaGen(me, "#/components/schemas/")
+	var root, info = newJsonObject(), newJsonObject()
+	gen.xmlObjs = true
+	defs := gen.defs()
+	for _, sd := range gen.schemas {
+		for _, el := range sd.globalElements() {
+			if name, ok := gen.names[el]; ok {
+				xmlObj := newJsonObject().set("name", el.Name.String())
+				if ns := sd.TargetNamespace.String(); len(ns) > 0 {
+					xmlObj.set("namespace", ns)
+				}
+				defs.values[name].(*jsonObject).set("xml", xmlObj)
+			}
+		}
+	}
+	info.set("title", strings.TrimSuffix(path.Base(me.loadUri), path.Ext(me.loadUri)))
+	if doc := me.Annotation.docText(); len(doc) > 0 {
+		info.set("description", doc)
+	}
+	info.set("version", ustr.Ifs(len(me.Version) > 0, me.Version.String(), "1.0"))
+	root.set("openapi", "3.1.0").set("info", info)
+	root.set("jsonSchemaDialect", "https://json-schema.org/draft/2020-12/schema")
+	root.set("components", newJsonObject().set("schemas", defs))
+	return json.MarshalIndent(root, "", "  ")
+}
+
+//	Writes the OpenAPI document returned by MakeOpenAPI into jsonOutDirPath (defaulting to the directory of the local copy of the XSD file),
+//	naming it after the XSD file name (such as "order.openapi.json" for "order.xsd").
+func (me *Schema) MakeOpenAPIFileAt(jsonOutDirPath string) (jsonOutFilePath string, err error) {
+	var doc []byte
+	if len(jsonOutDirPath) == 0 {
+		jsonOutDirPath = filepath.Dir(me.loadLocalPath)
+	}
+	jsonOutFilePath = filepath.Join(jsonOutDirPath, strings.TrimSuffix(path.Base(me.loadUri), path.Ext(me.loadUri))+".openapi.json")
+	if doc, err = me.MakeOpenAPI(); err == nil {
+		if err = ufs.EnsureDirExists(filepath.Dir(jsonOutFilePath)); err == nil {
+			err = ufs.WriteBinaryFile(jsonOutFilePath, doc)
+		}
+	}
+	return
+}
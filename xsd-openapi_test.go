@@ -0,0 +1,69 @@
+package xsd
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+)
+
+//	Tests that MakeOpenAPI emits the types of a schema as OpenAPI components with their constraints, references between them, XML objects
+//	for attributes and global elements, and descriptions from their documentation.
+func TestMakeOpenAPI(t *testing.T) {
+	var doc struct {
+		OpenAPI    string `json:"openapi"`
+		Components struct {
+			Schemas map[string]struct {
+				Description string                     `json:"description"`
+				Pattern     string                     `json:"pattern"`
+				Required    []string                   `json:"required"`
+				Properties  map[string]json.RawMessage `json:"properties"`
+				XML         *struct {
+					Name, Namespace string
+				} `json:"xml"`
+			} `json:"schemas"`
+		} `json:"components"`
+	}
+	raw, err := loadTestSchema(t, "validate", "order.xsd").MakeOpenAPI()
+	if err == nil {
+		err = json.Unmarshal(raw, &doc)
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+	schemas := doc.Components.Schemas
+	if (doc.OpenAPI != "3.1.0") || (len(schemas) != 3) {
+		t.Fatalf("expected an OpenAPI 3.1 document with 3 schemas, got\n%s", raw)
+	}
+	if sku := schemas["Sku"]; sku.Pattern != `^(?:[A-Z]{3}-\p{Nd}{3})$` {
+		t.Errorf("expected the pattern of Sku, got %q", sku.Pattern)
+	}
+	order := schemas["order"]
+	if (order.XML == nil) || (order.XML.Name != "order") || (order.XML.Namespace != "urn:example:validate") || (len(order.Required) != 3) {
+		t.Errorf("expected the order element with 3 required properties, got\n%s", raw)
+	}
+	for name, expected := range map[string]string{
+		"item": `{"type":"array","items":{"$ref":"#/components/schemas/Item"},"minItems":1,"maxItems":2}`,
+		"id":   `{"type":"integer","minimum":-2147483648,"maximum":2147483647,"xml":{"attribute":true}}`,
+	} {
+		if prop := compactJson(t, order.Properties[name]); prop != expected {
+			t.Errorf("%s: expected %s, got %s", name, expected, prop)
+		}
+	}
+	if raw, err = loadTestSchema(t, "docs", "order.xsd").MakeOpenAPI(); err == nil {
+		err = json.Unmarshal(raw, &doc)
+	}
+	if err != nil {
+		t.Fatal(err)
+	} else if desc := doc.Components.Schemas["Order"].Description; desc != "A purchase order, see ISO 1234.\nOrders are final." {
+		t.Errorf("expected the documentation of Order as its description, got %q", desc)
+	}
+}
+
+//	Returns the compact JSON encoding of raw, failing t if it is not valid JSON.
+func compactJson(t *testing.T, raw json.RawMessage) string {
+	var buf bytes.Buffer
+	if err := json.Compact(&buf, raw); err != nil {
+		t.Fatal(err)
+	}
+	return buf.String()
+}
@@ -0,0 +1,179 @@
+package xsd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+//	The contents of a package map file, see LoadPackageMap.
+type packageMapFile struct {
+	Module   string
+	Dir      string
+	Packages map[string]json.RawMessage
+}
+
+//	Loads the file at filePath mapping XML namespaces to the Go packages generated for them, for PkgGen.Packages (or SchemaSet.Packages),
+//	so that the packages of a schema set are generated into (and import one another from) the layout of one's own Go module.
+//	The file is either a JSON object or a YAML document (if filePath ends in ".yaml" or ".yml") of this form:
+//
+//		module: example.com/mymod
+//		dir: ..
+//		packages:
+//		  urn:example:addr: xsd/addr
+//		  urn:example:order:
+//		    name: orders
+//		    importPath: example.com/mymod/xsd/order
+//
+//	Each namespace maps either to an import path or to the fields of GoPkgOptions (matched case-insensitively). If module is set, import paths
+//	whose first element has no dot (such as "xsd/addr") are relative to it, and the packages of import paths within it are written into the
+//	corresponding subdirectories of dir (the root directory of the module, defaulting to that of the file), unless their dir is set. Relative dirs are resolved against the directory
+//	of the file. Packages are named after the last segment of their import path (other than a version segment such as "v2"), unless their name is set.
+//	Only the block mappings, plain and quoted scalars and comments of YAML are supported.
+func LoadPackageMap(filePath string) (pkgs map[string]*GoPkgOptions, err error) {
+	var raw []byte
+	var file packageMapFile
+	if raw, err = ioutil.ReadFile(filePath); err == nil {
+		if ext := strings.ToLower(filepath.Ext(filePath)); (ext == ".yaml") || (ext == ".yml") {
+			var doc interface{}
+			if doc, err = parseYamlMappings(raw); err == nil {
+				raw, err = json.Marshal(doc)
+			}
+		}
+		if err == nil {
+			err = json.Unmarshal(raw, &file)
+		}
+	}
+	if err != nil {
+		return nil, fmt.Errorf("cannot load the package map %s: %v", filePath, err)
+	}
+	baseDir, _ := filepath.Abs(filepath.Dir(filePath))
+	moduleDir := baseDir
+	if len(file.Dir) > 0 {
+		moduleDir = ensureAbsPath(baseDir, file.Dir)
+	}
+	pkgs = map[string]*GoPkgOptions{}
+	for ns, entry := range file.Packages {
+		var pkg GoPkgOptions
+		if json.Unmarshal(entry, &pkg.ImportPath) != nil {
+			if err = json.Unmarshal(entry, &pkg); err != nil {
+				return nil, fmt.Errorf("cannot load the package map %s: namespace %q: %v", filePath, ns, err)
+			}
+		}
+		if len(pkg.Dir) > 0 {
+			pkg.Dir = ensureAbsPath(baseDir, pkg.Dir)
+		}
+		if mod := file.Module; len(mod) > 0 {
+			if first := strings.Split(pkg.ImportPath, "/")[0]; (len(pkg.ImportPath) > 0) && !strings.Contains(first, ".") && (pkg.ImportPath != mod) && !strings.HasPrefix(pkg.ImportPath, mod+"/") {
+				pkg.ImportPath = path.Join(mod, pkg.ImportPath)
+			}
+			if (len(pkg.Dir) == 0) && ((pkg.ImportPath == mod) || strings.HasPrefix(pkg.ImportPath, mod+"/")) {
+				pkg.Dir = filepath.Join(moduleDir, filepath.FromSlash(strings.TrimPrefix(strings.TrimPrefix(pkg.ImportPath, mod), "/")))
+			}
+		}
+		if (len(pkg.Name) == 0) && (len(pkg.ImportPath) > 0) {
+			pkg.Name = goModulePkgName(pkg.ImportPath, "")
+		}
+		pkgs[ns] = &pkg
+	}
+	return
+}
+
+//	Returns filePath if absolute, or else joined to baseDir.
+func ensureAbsPath(baseDir, filePath string) string {
+	if filepath.IsAbs(filePath) {
+		return filePath
+	}
+	return filepath.Join(baseDir, filePath)
+}
+
+//	Parses the YAML document raw, consisting of nothing but (nested) block mappings of scalars and comments, into nested map[string]interface{}s of strings.
+func parseYamlMappings(raw []byte) (doc interface{}, err error) {
+	type level struct {
+		indent int
+		m      map[string]interface{}
+	}
+	var pendingKey string
+	var levels = []level{{-1, map[string]interface{}{}}}
+	for i, line := range strings.Split(strings.Replace(string(raw), "\r\n", "\n", -1), "\n") {
+		if pos := yamlCommentPos(line); pos >= 0 {
+			line = line[:pos]
+		}
+		if (len(strings.TrimSpace(line)) == 0) || (strings.TrimSpace(line) == "---") {
+			continue
+		}
+		indent := len(line) - len(strings.TrimLeft(line, " "))
+		line = strings.TrimSpace(line)
+		if len(pendingKey) > 0 {
+			if top := levels[len(levels)-1]; indent > top.indent {
+				child := map[string]interface{}{}
+				top.m[pendingKey], levels = child, append(levels, level{indent, child})
+			} else {
+				top.m[pendingKey] = ""
+			}
+			pendingKey = ""
+		}
+		for indent < levels[len(levels)-1].indent {
+			levels = levels[:len(levels)-1]
+		}
+		if top := &levels[len(levels)-1]; top.indent < 0 {
+			top.indent = indent
+		} else if indent != top.indent {
+			return nil, fmt.Errorf("line %d: inconsistent indentation", i+1)
+		}
+		var key, val string
+		if strings.HasSuffix(line, ":") {
+			key = line[:len(line)-1]
+		} else if pos := strings.Index(line, ": "); pos > 0 {
+			key, val = line[:pos], strings.TrimSpace(line[pos+2:])
+		} else {
+			return nil, fmt.Errorf("line %d: expected a key: value mapping", i+1)
+		}
+		if key, err = yamlScalar(key); err != nil {
+			return nil, fmt.Errorf("line %d: %v", i+1, err)
+		}
+		if (len(val) == 0) && strings.HasSuffix(line, ":") {
+			pendingKey = key
+		} else if levels[len(levels)-1].m[key], err = yamlScalar(val); err != nil {
+			return nil, fmt.Errorf("line %d: %v", i+1, err)
+		}
+	}
+	if len(pendingKey) > 0 {
+		levels[len(levels)-1].m[pendingKey] = ""
+	}
+	return levels[0].m, nil
+}
+
+//	Returns the position of the "#" starting a comment in the YAML line, or -1 if none.
+func yamlCommentPos(line string) int {
+	var quote rune
+	for i, r := range line {
+		switch {
+		case quote != 0:
+			if r == quote {
+				quote = 0
+			}
+		case (r == '"') || (r == '\''):
+			quote = r
+		case (r == '#') && ((i == 0) || (line[i-1] == ' ') || (line[i-1] == '\t')):
+			return i
+		}
+	}
+	return -1
+}
+
+//	Returns the value of the plain, double-quoted or single-quoted YAML scalar s.
+func yamlScalar(s string) (string, error) {
+	if (len(s) >= 2) && (s[0] == '"') && (s[len(s)-1] == '"') {
+		return strconv.Unquote(s)
+	} else if (len(s) >= 2) && (s[0] == '\'') && (s[len(s)-1] == '\'') {
+		return strings.Replace(s[1:len(s)-1], "''", "'", -1), nil
+	} else if (len(s) > 0) && strings.ContainsRune("[{&*!|>%@`", rune(s[0])) {
+		return "", fmt.Errorf("unsupported YAML syntax %q", s)
+	}
+	return s, nil
+}
@@ -0,0 +1,68 @@
+package xsd
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+//	Tests that LoadPackageMap reads equivalent JSON and YAML package maps, resolving import paths against the module and directories against the
+//	file, and that the packages of a schema set generated with it import one another by these paths and build.
+func TestLoadPackageMap(t *testing.T) {
+	gopath := t.TempDir()
+	modDir := filepath.Join(gopath, "src", "example.com", "mymod")
+	files := map[string]string{
+		"pkgmap.yaml": `# namespaces of the schemadir fixture
+module: example.com/mymod
+packages:
+  urn:example:common: xsd/common
+  "urn:example:orders":
+    name: orders
+    importPath: 'example.com/mymod/xsd/order'
+`,
+		"pkgmap.json": `{"module": "example.com/mymod", "packages": {"urn:example:common": "xsd/common", "urn:example:orders": {"name": "orders", "importPath": "example.com/mymod/xsd/order"}}}`,
+	}
+	var maps []map[string]*GoPkgOptions
+	if err := os.MkdirAll(modDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	for _, fileName := range []string{"pkgmap.yaml", "pkgmap.json"} {
+		filePath := filepath.Join(modDir, fileName)
+		if err := ioutil.WriteFile(filePath, []byte(files[fileName]), 0644); err != nil {
+			t.Fatal(err)
+		}
+		pkgs, err := LoadPackageMap(filePath)
+		if err != nil {
+			t.Fatal(err)
+		}
+		maps = append(maps, pkgs)
+	}
+	for _, pkgs := range maps {
+		for ns, expected := range map[string]GoPkgOptions{
+			"urn:example:common": {Name: "common", Dir: filepath.Join(modDir, "xsd", "common"), ImportPath: "example.com/mymod/xsd/common"},
+			"urn:example:orders": {Name: "orders", Dir: filepath.Join(modDir, "xsd", "order"), ImportPath: "example.com/mymod/xsd/order"},
+		} {
+			if pkg := pkgs[ns]; (pkg == nil) || (*pkg != expected) {
+				t.Errorf("%s: expected %#v, got %#v", ns, expected, pkg)
+			}
+		}
+	}
+	set, err := NewSchemaCache(0).LoadSchemaDir(context.Background(), filepath.Join("testdata", "schemadir"), LoadOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	set.Packages = maps[0]
+	goOutFilePaths, _, err := set.MakeGoPkgSrcFiles()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, goOutFilePath := range goOutFilePaths {
+		if src, _ := ioutil.ReadFile(goOutFilePath); strings.HasSuffix(goOutFilePath, "order.xsd.go") && !strings.Contains(string(src), `"example.com/mymod/xsd/common"`) {
+			t.Errorf("expected orders to import common by its mapped path:\n%s", src)
+		}
+		goTool(t, gopath, goOutFilePath, "build")
+	}
+}
@@ -0,0 +1,44 @@
+package xsd
+
+import (
+	"strings"
+
+	"github.com/metaleap/go-util-str"
+)
+
+//	If PkgGen.PresenceAccessors is set and this field holds an optional attribute or element (see optional), makes it a pointer (unless it is one already)
+//	that is nil while the attribute or element is absent, and records that it gets accessors (see PkgBag.addAccessors).
+func (me *declField) trackPresence(bag *PkgBag) {
+	if me.presence = bag.gen.PresenceAccessors && me.optional(); me.presence && !strings.HasPrefix(me.Type, "*") {
+		me.Type = "*" + me.Type
+	}
+}
+
+//	Renders the HasXyz(), GetXyz(), SetXyz() and ClearXyz() accessors of every field tracking the presence of its attribute or element (see declField.trackPresence)
+//	in the struct types rendered for attributes and elements. Like addOccursChecks, this is done after all types are rendered, so that the final type names of these fields are known.
+func (me *PkgBag) addAccessors() {
+	for _, dt := range me.declWrittenTypes {
+		for _, f := range dt.sortedFields() {
+			if !f.presence {
+				continue
+			}
+			var dflt, dfltName string
+			name, tn, kind := xmlTagName(f), strings.TrimPrefix(f.finalTypeName, "*"), ustr.Ifs(strings.HasSuffix(f.XmlTag, ",attr"), "attribute", "element")
+			for _, defName := range []string{"Default", "Fixed"} {
+				if dt.Methods[f.Name+defName] != nil {
+					dflt, dfltName = sfmt("me.%s%s()", f.Name, defName), strings.ToLower(defName)
+				}
+			}
+			me.renderSplit(dt.elem, func() {
+				me.appendFmt(false, "//\tReturns whether the %s %s is present, that is, whether %s is not nil.", name, kind, f.Name)
+				me.appendFmt(true, "func (me *%s) Has%s () bool { return me.%s != nil }", dt.Name, f.Name, f.Name)
+				me.appendFmt(false, "//\tReturns the value of the %s %s if it is present, or else %s.", name, kind, ustr.Ifs(len(dflt) > 0, "its "+dfltName+" value", "the zero value of "+tn))
+				me.appendFmt(true, "func (me *%s) Get%s () (v %s) {\n\tif me.%s != nil {\n\t\treturn *me.%s\n\t}%s\n\treturn\n}", dt.Name, f.Name, tn, f.Name, f.Name, ustr.Ifs(len(dflt) > 0, "\n\tv = "+dflt, ""))
+				me.appendFmt(false, "//\tMakes the %s %s present with the specified value.", name, kind)
+				me.appendFmt(true, "func (me *%s) Set%s (v %s) { me.%s = &v }", dt.Name, f.Name, tn, f.Name)
+				me.appendFmt(false, "//\tMakes the %s %s absent, that is, sets %s to nil.", name, kind, f.Name)
+				me.appendFmt(true, "func (me *%s) Clear%s () { me.%s = nil }", dt.Name, f.Name, f.Name)
+			})
+		}
+	}
+}
@@ -0,0 +1,344 @@
+package xsd
+
+import (
+	"bytes"
+	"path"
+	"path/filepath"
+	"strings"
+	"unicode"
+
+	"github.com/metaleap/go-util-fs"
+	"github.com/metaleap/go-util-str"
+)
+
+const (
+	protoAny = "google.protobuf.Any"
+)
+
+var (
+	//	Maps XSD built-in simple types to protobuf scalar types. All other built-in simple types (such as xs:string, xs:decimal or xs:dateTime) map to string.
+	protoScalars = map[string]string{
+		"base64Binary": "bytes", "boolean": "bool", "byte": "int32", "double": "double", "float": "float", "hexBinary": "bytes",
+		"int": "int32", "integer": "int64", "long": "int64", "negativeInteger": "int64", "nonNegativeInteger": "uint64", "nonPositiveInteger": "int64",
+		"positiveInteger": "uint64", "short": "int32", "unsignedByte": "uint32", "unsignedInt": "uint32", "unsignedLong": "uint64", "unsignedShort": "uint32",
+	}
+
+	//	The XSD built-in list types, whose values map to repeated strings.
+	protoListTypes = map[string]bool{"ENTITIES": true, "IDREFS": true, "NMTOKENS": true}
+)
+
+type protoEnum struct {
+	Doc, Name string
+	Values    []string
+}
+
+type protoField struct {
+	Doc, Name, Type string
+	Repeated        bool
+}
+
+type protoMessage struct {
+	Doc, Name string // Name is the full name, such as "Order.Items" for a nested message
+	Enums     []*protoEnum
+	Fields    []*protoField
+	Messages  []*protoMessage
+
+	fieldNames, typeNames map[string]bool
+}
+
+func newProtoMessage(name string, ann *Annotation) *protoMessage {
+	return &protoMessage{Name: name, Doc: ann.docText(), fieldNames: map[string]bool{}, typeNames: map[string]bool{}}
+}
+
+func (me *protoMessage) addField(name, typ string, repeated bool, ann *Annotation) {
+	me.Fields = append(me.Fields, &protoField{Doc: ann.docText(), Name: uniqueName(protoFieldName(name), me.fieldNames), Type: typ, Repeated: repeated})
+}
+
+//	Returns the full name for a new nested message or enum named after name.
+func (me *protoMessage) nestedName(name string) string {
+	return me.Name + "." + uniqueName(protoTypeName(name), me.typeNames)
+}
+
+type protoGen struct {
+	comps   *schemaComponents
+	schemas []*Schema
+	names   map[element]string      // the proto names of all top-level messages and enums
+	nested  map[*ComplexType]string // the full names of the nested messages being generated for the anonymous complex types of local elements
+	busy    map[*SimpleType]bool    // the simple types whose proto types are being determined, so that circular restrictions terminate
+	usesAny bool
+}
+
+//	Calls MakeProtoFileAt with default arguments.
+func (me *Schema) MakeProtoFile() (protoOutFilePath string, err error) {
+	return me.MakeProtoFileAt("", "")
+}
+
+//	Writes a protobuf (proto3) definition file derived from this schema and all the schemas it includes and (directly or indirectly) imports,
+//	as a mechanical first cut for migrating an XML interface to gRPC: complex types (and elements of anonymous complex types) map to messages,
+//	their elements and attributes to fields (repeated for those that can occur more than once), enumerated simple types to enums, and all other simple types to scalars.
+//	Derived complex types get all the fields of their base types. Anonymous types of local elements and attributes map to nested messages and enums.
+//	The file is written into protoOutDirPath (defaulting to the directory of the local copy of the XSD file) and declares the package protoPkgName (defaulting to a name derived from the XSD file name).
+func (me *Schema) MakeProtoFileAt(protoOutDirPath, protoPkgName string) (protoOutFilePath string, err error) {
+	var buf bytes.Buffer
+	var gen = &protoGen{comps: newSchemaComponents(me), names: map[element]string{}, nested: map[*ComplexType]string{}, busy: map[*SimpleType]bool{}}
+	var enums []*protoEnum
+	var msgs []*protoMessage
+	var fileName = strings.TrimSuffix(path.Base(me.loadUri), path.Ext(me.loadUri))
+	if len(protoOutDirPath) == 0 {
+		protoOutDirPath = filepath.Dir(me.loadLocalPath)
+	}
+	if protoOutFilePath = filepath.Join(protoOutDirPath, fileName+".proto"); len(protoPkgName) == 0 {
+		if protoPkgName = strings.ToLower(strings.Join(jsonTagWords(ustr.Replace(path.Base(me.loadUri), map[string]string{"xsd": "", "schema": ""})), "_")); len(protoPkgName) == 0 {
+			protoPkgName = "xsd"
+		}
+	}
+	gen.collectSchemas(me)
+	taken := map[string]bool{}
+	for _, sd := range gen.schemas {
+		for _, st := range sd.globalSimpleTypes() {
+			if protoIsEnum(st) {
+				gen.names[st] = uniqueName(protoTypeName(st.Name.String()), taken)
+			}
+		}
+		for _, ct := range sd.globalComplexTypes() {
+			gen.names[ct] = uniqueName(protoTypeName(ct.Name.String()), taken)
+		}
+		for _, el := range sd.globalElements() {
+			if (el.ComplexType != nil) || ((len(el.SimpleTypes) > 0) && protoIsEnum(el.SimpleTypes[0])) {
+				gen.names[el] = uniqueName(protoTypeName(el.Name.String()), taken)
+			}
+		}
+	}
+	for _, sd := range gen.schemas {
+		for _, st := range sd.globalSimpleTypes() {
+			if protoIsEnum(st) {
+				enums = append(enums, gen.enum(gen.names[st], st, st.Annotation))
+			}
+		}
+		for _, el := range sd.globalElements() {
+			if (el.ComplexType == nil) && (len(el.SimpleTypes) > 0) && protoIsEnum(el.SimpleTypes[0]) {
+				enums = append(enums, gen.enum(gen.names[el], el.SimpleTypes[0], el.Annotation))
+			}
+		}
+		for _, ct := range sd.globalComplexTypes() {
+			msg := newProtoMessage(gen.names[ct], ct.Annotation)
+			gen.addFields(msg, ct)
+			msgs = append(msgs, msg)
+		}
+		for _, el := range sd.globalElements() {
+			if el.ComplexType != nil {
+				msg := newProtoMessage(gen.names[el], el.Annotation)
+				gen.addFields(msg, el.ComplexType)
+				msgs = append(msgs, msg)
+			}
+		}
+	}
+	buf.WriteString("//\tAuto-generated by the \"go-xsd\" package located at:\n//\t\tgithub.com/metaleap/go-xsd\n//\tfrom the XSD file located at:\n//\t\t" + me.loadUri + "\n")
+	buf.WriteString("syntax = \"proto3\";\n\npackage " + protoPkgName + ";\n")
+	if gen.usesAny {
+		buf.WriteString("\nimport \"google/protobuf/any.proto\";\n")
+	}
+	for _, enum := range enums {
+		buf.WriteString("\n")
+		enum.render(&buf, "")
+	}
+	for _, msg := range msgs {
+		buf.WriteString("\n")
+		msg.render(&buf, "")
+	}
+	if err = ufs.EnsureDirExists(filepath.Dir(protoOutFilePath)); err == nil {
+		err = ufs.WriteTextFile(protoOutFilePath, buf.String())
+	}
+	return
+}
+
+func (me *protoGen) collectSchemas(sd *Schema) {
+	for _, s := range me.schemas {
+		if s == sd {
+			return
+		}
+	}
+	me.schemas = append(me.schemas, sd)
+	for _, inc := range sd.XMLIncludedSchemas {
+		me.collectSchemas(inc)
+	}
+	for _, imp := range sd.XMLImportedSchemas {
+		me.collectSchemas(imp)
+	}
+}
+
+//	Adds to msg the fields for the content and attributes of ct, including those of its base types.
+func (me *protoGen) addFields(msg *protoMessage, ct *ComplexType) {
+	for _, f := range me.comps.contentFields(ct) {
+		var typ string
+		var repeated bool
+		var ann *Annotation
+		switch decl := f.Decl.(type) {
+		case *Element:
+			typ, repeated = me.elementType(msg, decl, f.Name)
+			ann = decl.Annotation
+		case *Attribute:
+			if t := decl.Type.String(); len(t) > 0 {
+				typ, repeated = me.typeOf(msg, decl, t, f.Name)
+			} else if len(decl.SimpleTypes) > 0 {
+				typ, repeated = me.simpleTypeOf(msg, decl.SimpleTypes[0], f.Name)
+			} else {
+				typ = "string"
+			}
+			ann = decl.Annotation
+		case *Any:
+			me.usesAny, typ, ann = true, protoAny, decl.Annotation
+		default:
+			typ, repeated = me.typeOf(msg, f.Decl, f.Base, f.Name)
+		}
+		msg.addField(f.Name, typ, repeated || f.Repeated(), ann)
+	}
+}
+
+//	Returns the proto type of the field for el. An anonymous complex type is generated as a message nested in msg, unless it is being
+//	generated already: an element recurring within its own anonymous type (or within further anonymous types of it) refers to the enclosing message.
+func (me *protoGen) elementType(msg *protoMessage, el *Element, name string) (typ string, repeated bool) {
+	if n, ok := me.names[el]; ok {
+		typ = n
+	} else if t := el.Type.String(); len(t) > 0 {
+		typ, repeated = me.typeOf(msg, el, t, name)
+	} else if n, ok := me.nested[el.ComplexType]; ok && (el.ComplexType != nil) {
+		typ = n
+	} else if el.ComplexType != nil {
+		nested := newProtoMessage(msg.nestedName(name), nil)
+		msg.Messages, me.nested[el.ComplexType] = append(msg.Messages, nested), nested.Name
+		me.addFields(nested, el.ComplexType)
+		typ = nested.Name
+		delete(me.nested, el.ComplexType)
+	} else if len(el.SimpleTypes) > 0 {
+		typ, repeated = me.simpleTypeOf(msg, el.SimpleTypes[0], name)
+	} else {
+		me.usesAny, typ = true, protoAny
+	}
+	return
+}
+
+func (me *protoGen) enum(name string, st *SimpleType, ann *Annotation) (enum *protoEnum) {
+	var prefix = strings.ToUpper(strings.Join(jsonTagWords(name[strings.LastIndex(name, ".")+1:]), "_")) + "_"
+	var taken = map[string]bool{prefix + "UNSPECIFIED": true}
+	enum = &protoEnum{Doc: ann.docText(), Name: name}
+	for _, e := range st.RestrictionSimpleType.Enumerations {
+		valueName := strings.ToUpper(strings.Join(jsonTagWords(e.Value), "_"))
+		enum.Values = append(enum.Values, uniqueName(prefix+ustr.Ifs(len(valueName) > 0, valueName, "EMPTY"), taken))
+	}
+	return
+}
+
+//	Returns the proto type of the simple or complex type referenced by qname from el, and whether it is a list type.
+func (me *protoGen) typeOf(msg *protoMessage, el element, qname, name string) (typ string, repeated bool) {
+	qn := ownerSchema(el).qname(qname)
+	if qn.Space == xsdNamespaceUri {
+		if qn.Local == "anyType" {
+			me.usesAny, typ = true, protoAny
+		} else if typ = protoScalars[qn.Local]; len(typ) == 0 {
+			typ, repeated = "string", protoListTypes[qn.Local]
+		}
+	} else if ct := me.comps.complexTypes[qn]; ct != nil {
+		typ = me.names[ct]
+	} else if st := me.comps.simpleTypes[qn]; st != nil {
+		typ, repeated = me.simpleTypeOf(msg, st, name)
+	} else {
+		typ = "string"
+	}
+	return
+}
+
+//	Returns the proto type of the values of st, and whether it is a list type. Anonymous enumerated simple types are added to msg as nested enums named after name.
+//	Simple types deriving from themselves (directly or via other types) are strings, as the Go types generated for them are.
+func (me *protoGen) simpleTypeOf(msg *protoMessage, st *SimpleType, name string) (typ string, repeated bool) {
+	if n, ok := me.names[st]; ok {
+		typ = n
+	} else if me.busy[st] {
+		return "string", false
+	} else if protoIsEnum(st) {
+		enum := me.enum(msg.nestedName(name), st, nil)
+		msg.Enums, typ = append(msg.Enums, enum), enum.Name
+	} else if rest := st.RestrictionSimpleType; rest != nil {
+		me.busy[st] = true
+		if base := rest.Base.String(); len(base) > 0 {
+			typ, repeated = me.typeOf(msg, rest, base, name)
+		} else if len(rest.SimpleTypes) > 0 {
+			typ, repeated = me.simpleTypeOf(msg, rest.SimpleTypes[0], name)
+		}
+		delete(me.busy, st)
+	} else if list := st.List; list != nil {
+		if item := list.ItemType.String(); len(item) > 0 {
+			typ, _ = me.typeOf(msg, list, item, name)
+		} else if len(list.SimpleTypes) > 0 {
+			typ, _ = me.simpleTypeOf(msg, list.SimpleTypes[0], name)
+		}
+		repeated = true
+	}
+	if len(typ) == 0 {
+		typ = "string"
+	}
+	return
+}
+
+func (me *protoEnum) render(buf *bytes.Buffer, indent string) {
+	var name = me.Name[strings.LastIndex(me.Name, ".")+1:]
+	buf.WriteString(protoComment(me.Doc, indent) + indent + "enum " + name + " {\n")
+	buf.WriteString(sfmt("%s  %sUNSPECIFIED = 0;\n", indent, strings.ToUpper(strings.Join(jsonTagWords(name), "_"))+"_"))
+	for i, v := range me.Values {
+		buf.WriteString(sfmt("%s  %s = %d;\n", indent, v, i+1))
+	}
+	buf.WriteString(indent + "}\n")
+}
+
+func (me *protoMessage) render(buf *bytes.Buffer, indent string) {
+	buf.WriteString(protoComment(me.Doc, indent) + indent + "message " + me.Name[strings.LastIndex(me.Name, ".")+1:] + " {\n")
+	for _, enum := range me.Enums {
+		enum.render(buf, indent+"  ")
+		buf.WriteString("\n")
+	}
+	for _, msg := range me.Messages {
+		msg.render(buf, indent+"  ")
+		buf.WriteString("\n")
+	}
+	for i, f := range me.Fields {
+		buf.WriteString(protoComment(f.Doc, indent+"  ") + indent + "  " + ustr.Ifs(f.Repeated, "repeated ", "") + sfmt("%s %s = %d;\n", f.Type, f.Name, i+1))
+	}
+	buf.WriteString(indent + "}\n")
+}
+
+func protoComment(doc, indent string) (s string) {
+	if len(doc) > 0 {
+		for _, ln := range strings.Split(doc, "\n") {
+			s += indent + "// " + ln + "\n"
+		}
+	}
+	return
+}
+
+func protoFieldName(name string) string {
+	if name = strings.ToLower(strings.Join(jsonTagWords(name), "_")); len(name) == 0 {
+		name = "field"
+	} else if unicode.IsDigit([]rune(name)[0]) {
+		name = "f_" + name
+	}
+	return name
+}
+
+func protoIsEnum(st *SimpleType) bool {
+	return (st.RestrictionSimpleType != nil) && (len(st.RestrictionSimpleType.Enumerations) > 0)
+}
+
+func protoTypeName(name string) string {
+	var words = jsonTagWords(name)
+	for i, w := range words {
+		runes := []rune(w)
+		runes[0] = unicode.ToUpper(runes[0])
+		words[i] = string(runes)
+	}
+	if name = strings.Join(words, ""); len(name) == 0 {
+		name = "Type"
+	} else if unicode.IsDigit([]rune(name)[0]) {
+		name = "T" + name
+	}
+	return name
+}
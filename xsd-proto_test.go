@@ -0,0 +1,48 @@
+package xsd
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestMakeProtoFile(t *testing.T) {
+	protoOutFilePath, err := loadTestSchema(t, "proto", "shop.xsd").MakeProtoFileAt(t.TempDir(), "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	raw, err := ioutil.ReadFile(protoOutFilePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	const expected = `syntax = "proto3";
+
+package shop;
+
+enum Status {
+  STATUS_UNSPECIFIED = 0;
+  STATUS_OPEN = 1;
+  STATUS_SHIPPED = 2;
+}
+
+message Item {
+  string sku = 1;
+  int32 qty = 2;
+}
+
+message Order {
+  repeated Item item = 1;
+  Status status = 2;
+}
+
+message RushOrder {
+  repeated Item item = 1;
+  Status status = 2;
+  string deadline = 3;
+}
+`
+	if src := string(raw); (filepath.Base(protoOutFilePath) != "shop.proto") || !strings.HasSuffix(src, expected) {
+		t.Errorf("unexpected %s:\n%s", protoOutFilePath, src)
+	}
+}
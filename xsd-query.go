@@ -0,0 +1,286 @@
+package xsd
+
+import (
+	"encoding/xml"
+	"fmt"
+	"strings"
+
+	xsdt "github.com/metaleap/go-xsd/types"
+)
+
+//	A condition on schema constructs, for Schema.Query and Schema.Find, such as those returned by ByName, ByType and HasFacet.
+type NodePredicate func(node SchemaNode) bool
+
+//	Returns the element and attribute declarations matched by path, a "/"-separated path of element names (optionally ending in an "@"-prefixed
+//	attribute name) such as "tns:Order/Items/Item/@sku", that also satisfy all preds. The first step names global elements (or attributes) of this
+//	schema or of the schemas it includes and imports, each further step the elements (or attributes) of the content of the types of the elements matched by
+//	the step before, including those inherited from their base types and those of the groups and attribute groups they refer to.
+//	Names are matched as follows: a "prefix:local" name by namespace (as declared in this schema document) and local name, a "{namespace}local"
+//	name likewise, a plain "local" name by local name only (in any namespace), and "*" (or "@*") by any name.
+//	The Elem of each returned node is the *Element or *Attribute declaration (for references, the referenced global declaration), its Parent is
+//	the node of the element matched by the step before (nil for the first step) and its Depth is the number of the step, counting from 1.
+//	A declaration is returned only once, even if it is matched via several elements of the step before.
+func (me *Schema) Query(path string, preds ...NodePredicate) (nodes []SchemaNode, err error) {
+	var comps = newSchemaComponents(me)
+	var steps = strings.Split(path, "/")
+	var names = make([]xml.Name, len(steps))
+	for i, step := range steps {
+		if names[i], err = me.queryName(strings.TrimPrefix(step, "@")); err != nil {
+			return nil, fmt.Errorf("invalid query %q: %v", path, err)
+		} else if strings.HasPrefix(step, "@") && (i < len(steps)-1) {
+			return nil, fmt.Errorf("invalid query %q: attribute step %s is not the last step", path, step)
+		}
+	}
+	for _, sd := range strictSchemas(me, nil, map[*Schema]bool{}) {
+		if strings.HasPrefix(steps[0], "@") {
+			for _, att := range sd.globalAttributes() {
+				if queryMatches(names[0], comps.attributeName(att)) {
+					nodes = append(nodes, SchemaNode{Elem: att, Depth: 1})
+				}
+			}
+		} else {
+			for _, el := range sd.globalElements() {
+				if queryMatches(names[0], comps.elementName(el)) {
+					nodes = append(nodes, SchemaNode{Elem: el, Depth: 1})
+				}
+			}
+		}
+	}
+	for i := 1; i < len(steps); i++ {
+		var next []SchemaNode
+		var seen = map[element]bool{}
+		for j := range nodes {
+			parent, ct := &nodes[j], comps.elementType(nodes[j].Elem.(*Element))
+			if ct == nil {
+				continue
+			}
+			for _, f := range comps.contentFields(ct) {
+				var qn xml.Name
+				switch decl := f.Decl.(type) {
+				case *Element:
+					qn = comps.elementName(decl)
+				case *Attribute:
+					qn = comps.attributeName(decl)
+				default:
+					continue
+				}
+				if (f.Attr == strings.HasPrefix(steps[i], "@")) && queryMatches(names[i], qn) && !seen[f.Decl] {
+					seen[f.Decl], next = true, append(next, SchemaNode{Elem: f.Decl, Parent: parent, Depth: i + 1})
+				}
+			}
+		}
+		nodes = next
+	}
+	return filterNodes(nodes, preds), nil
+}
+
+//	Returns all constructs (see Schema.Walk) of this schema document and the schema documents it includes, redefines and overrides that satisfy all preds.
+func (me *Schema) Find(preds ...NodePredicate) (nodes []SchemaNode) {
+	for _, sd := range me.allSchemas(map[string]bool{}) {
+		sd.Walk(func(node SchemaNode) bool {
+			nodes = append(nodes, node)
+			return true
+		})
+	}
+	return filterNodes(nodes, preds)
+}
+
+//	Returns a NodePredicate that holds for the named constructs (elements, attributes, types, groups, attribute groups and notations) whose name
+//	matches name, which is either "{namespace}local", or a plain "local" name matching in any namespace. Element and attribute references match by
+//	the name they refer to.
+func ByName(name string) NodePredicate {
+	var qn = clarkName(name)
+	return func(node SchemaNode) bool {
+		el, ok := node.Elem.(element)
+		return ok && queryMatches(qn, nodeName(el))
+	}
+}
+
+//	Returns a NodePredicate that holds for the element and attribute declarations whose type attribute (or, for references, that of the declaration
+//	referred to) names the type typeName, which is either "{namespace}local", or a plain "local" name matching in any namespace: so that
+//	ByType("{http://www.w3.org/2001/XMLSchema}string") holds for all declarations of type xs:string.
+func ByType(typeName string) NodePredicate {
+	var qn = clarkName(typeName)
+	var cache = map[*Schema]*schemaComponents{}
+	return func(node SchemaNode) bool {
+		var typeRef string
+		var owner element
+		switch decl := nodeDecl(node.Elem, cache).(type) {
+		case *Element:
+			typeRef, owner = decl.Type.String(), decl
+		case *Attribute:
+			typeRef, owner = decl.Type.String(), decl
+		}
+		return (len(typeRef) > 0) && queryMatches(qn, ownerSchema(owner).qname(typeRef))
+	}
+}
+
+//	Returns a NodePredicate that holds for the simple types (and element and attribute declarations of simple types, and the xs:restrictions of
+//	simple types) restricted by the facet (such as "pattern", "enumeration" or "maxLength"), be it in their own restriction or in that of any
+//	type they derive from by restriction.
+func HasFacet(facet string) NodePredicate {
+	var cache = map[*Schema]*schemaComponents{}
+	return func(node SchemaNode) bool {
+		var st *SimpleType
+		switch decl := nodeDecl(node.Elem, cache).(type) {
+		case *SimpleType:
+			st = decl
+		case *RestrictionSimpleType:
+			if hasFacet(decl.facets(), facet) {
+				return true
+			}
+			st = nodeComponents(decl, cache).simpleTypes[ownerSchema(decl).qname(decl.Base.String())]
+		case *Element:
+			if st = nodeComponents(decl, cache).simpleTypes[ownerSchema(decl).qname(decl.Type.String())]; (st == nil) && (len(decl.SimpleTypes) > 0) {
+				st = decl.SimpleTypes[0]
+			}
+		case *Attribute:
+			if st = nodeComponents(decl, cache).simpleTypes[ownerSchema(decl).qname(decl.Type.String())]; (st == nil) && (len(decl.SimpleTypes) > 0) {
+				st = decl.SimpleTypes[0]
+			}
+		}
+		for depth := 0; (st != nil) && (st.RestrictionSimpleType != nil) && (depth < 64); depth++ {
+			rest := st.RestrictionSimpleType
+			if hasFacet(rest.facets(), facet) {
+				return true
+			} else if len(rest.SimpleTypes) > 0 {
+				st = rest.SimpleTypes[0]
+			} else {
+				st = nodeComponents(rest, cache).simpleTypes[ownerSchema(rest).qname(rest.Base.String())]
+			}
+		}
+		return false
+	}
+}
+
+//	Returns the complex type of el (be it named or anonymous), or nil if it has none.
+func (me *schemaComponents) elementType(el *Element) *ComplexType {
+	if el.ComplexType != nil {
+		return el.ComplexType
+	} else if t := el.Type.String(); len(t) > 0 {
+		return me.complexTypes[ownerSchema(el).qname(t)]
+	}
+	return nil
+}
+
+//	Returns the name to match a query step (other than "*") against: "prefix:local" and "{namespace}local" names are namespace-qualified, plain
+//	"local" names have a Space of "*", to match in any namespace.
+func (me *Schema) queryName(step string) (qn xml.Name, err error) {
+	if len(step) == 0 {
+		err = fmt.Errorf("empty step")
+	} else if pos := strings.Index(step, ":"); (pos > 0) && !strings.HasPrefix(step, "{") {
+		if _, ok := me.XMLNamespaces[step[:pos]]; !ok {
+			err = fmt.Errorf("the namespace prefix %s is not declared", step[:pos])
+		}
+		qn = me.qname(step)
+	} else {
+		qn = clarkName(step)
+	}
+	return
+}
+
+//	Returns the qualified name of the "{namespace}local" name, or else a name with a Space of "*" for a plain local name (or "*").
+func clarkName(name string) xml.Name {
+	if pos := strings.Index(name, "}"); strings.HasPrefix(name, "{") && (pos > 0) {
+		return xml.Name{Space: name[1:pos], Local: name[pos+1:]}
+	}
+	return xml.Name{Space: "*", Local: name}
+}
+
+//	Returns whether qn matches pattern, as returned by Schema.queryName or clarkName.
+func queryMatches(pattern, qn xml.Name) bool {
+	return ((pattern.Local == "*") || (pattern.Local == qn.Local)) && ((pattern.Space == "*") || (pattern.Space == qn.Space))
+}
+
+//	Returns the qualified name of the named construct el (for element and attribute references, the name referred to), if any.
+func nodeName(el element) (qn xml.Name) {
+	switch decl := el.(type) {
+	case *Element:
+		return (&schemaComponents{}).elementName(decl)
+	case *Attribute:
+		return (&schemaComponents{}).attributeName(decl)
+	}
+	if _, qn.Local = globalKindName(el); len(qn.Local) > 0 {
+		qn.Space = ownerSchema(el).TargetNamespace.String()
+	}
+	return
+}
+
+//	Returns the declaration of the construct elem: for element and attribute references, the global declaration referred to (if found), and else elem itself.
+func nodeDecl(elem interface{}, cache map[*Schema]*schemaComponents) interface{} {
+	switch decl := elem.(type) {
+	case *Element:
+		if ref := decl.Ref.String(); len(ref) > 0 {
+			if global := nodeComponents(decl, cache).elements[ownerSchema(decl).qname(ref)]; global != nil {
+				return global
+			}
+		}
+	case *Attribute:
+		if ref := decl.Ref.String(); len(ref) > 0 {
+			if global := nodeComponents(decl, cache).attributes[ownerSchema(decl).qname(ref)]; global != nil {
+				return global
+			}
+		}
+	}
+	return elem
+}
+
+//	Returns the global components visible from the schema document declaring el: those of the outermost schema document including it (directly
+//	or indirectly) and of the schemas that one includes and imports, collected once per such schema document into cache.
+func nodeComponents(el element, cache map[*Schema]*schemaComponents) *schemaComponents {
+	var sd = ownerSchema(el)
+	for (sd != nil) && (sd.XSDParentSchema != nil) {
+		sd = sd.XSDParentSchema
+	}
+	if cache[sd] == nil {
+		cache[sd] = newSchemaComponents(sd)
+	}
+	return cache[sd]
+}
+
+//	Returns whether f has the facet (such as "pattern" or "maxLength").
+func hasFacet(f *xsdt.Facets, facet string) bool {
+	switch facet {
+	case "enumeration":
+		return len(f.Enumerations) > 0
+	case "pattern":
+		return len(f.Pattern) > 0
+	case "whiteSpace":
+		return len(f.WhiteSpace) > 0
+	case "length":
+		return len(f.Length) > 0
+	case "minLength":
+		return len(f.MinLength) > 0
+	case "maxLength":
+		return len(f.MaxLength) > 0
+	case "totalDigits":
+		return len(f.TotalDigits) > 0
+	case "fractionDigits":
+		return len(f.FractionDigits) > 0
+	case "minInclusive":
+		return len(f.MinInclusive) > 0
+	case "maxInclusive":
+		return len(f.MaxInclusive) > 0
+	case "minExclusive":
+		return len(f.MinExclusive) > 0
+	case "maxExclusive":
+		return len(f.MaxExclusive) > 0
+	}
+	return false
+}
+
+//	Returns those of nodes that satisfy all preds.
+func filterNodes(nodes []SchemaNode, preds []NodePredicate) (matched []SchemaNode) {
+	for _, node := range nodes {
+		ok := true
+		for _, pred := range preds {
+			if ok = pred(node); !ok {
+				break
+			}
+		}
+		if ok {
+			matched = append(matched, node)
+		}
+	}
+	return
+}
@@ -0,0 +1,56 @@
+package xsd
+
+import (
+	"strings"
+	"testing"
+)
+
+//	Tests that Query follows paths of element and attribute names through the types of the elements matched (by local, prefixed or
+//	"{namespace}local" names and wildcards), filtered by predicates, and that Find returns the constructs satisfying ByName and HasFacet.
+func TestQuery(t *testing.T) {
+	sd := loadTestSchema(t, "validate", "order.xsd")
+	label := func(nodes []SchemaNode) string {
+		var names []string
+		for _, node := range nodes {
+			switch decl := node.Elem.(type) {
+			case *Element:
+				names = append(names, sfmt("%d:element %s", node.Depth, decl.Name))
+			case *Attribute:
+				names = append(names, sfmt("%d:attribute %s", node.Depth, decl.Name))
+			case *SimpleType:
+				names = append(names, sfmt("%d:simpleType %s", node.Depth, decl.Name))
+			case *ComplexType:
+				names = append(names, sfmt("%d:complexType %s", node.Depth, decl.Name))
+			default:
+				names = append(names, sfmt("%d:%T", node.Depth, decl))
+			}
+		}
+		return strings.Join(names, ", ")
+	}
+	for _, c := range []struct {
+		path     string
+		preds    []NodePredicate
+		expected string
+	}{
+		{"order/item/sku", nil, "3:element sku"},
+		{"order/@id", nil, "2:attribute id"},
+		{"{urn:example:validate}order/*", nil, "2:element date, 2:element item"},
+		{"order/*", []NodePredicate{ByType("{http://www.w3.org/2001/XMLSchema}date")}, "2:element date"},
+		{"order/item/@*", nil, ""},
+	} {
+		if nodes, err := sd.Query(c.path, c.preds...); err != nil {
+			t.Errorf("%s: %v", c.path, err)
+		} else if s := label(nodes); s != c.expected {
+			t.Errorf("%s: expected %q, got %q", c.path, c.expected, s)
+		}
+	}
+	if _, err := sd.Query("x:order"); (err == nil) || !strings.Contains(err.Error(), "the namespace prefix x is not declared") {
+		t.Errorf("expected an error for an undeclared prefix, got %v", err)
+	}
+	if s := label(sd.Find(HasFacet("pattern"))); s != "3:element sku, 1:simpleType Sku, 2:*xsd.RestrictionSimpleType" {
+		t.Errorf("expected sku, Sku and its restriction to have a pattern facet, got %q", s)
+	}
+	if s := label(sd.Find(ByName("Item"))); s != "1:complexType Item" {
+		t.Errorf("expected the Item complex type, got %q", s)
+	}
+}
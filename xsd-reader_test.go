@@ -0,0 +1,57 @@
+package xsd
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+//	Tests that LoadSchemaReader loads a schema read from memory as if fetched from its base URI, resolving its includes relative to that,
+//	caching it by that URI and generating its package where a local copy would have been kept, and that LoadSchemaBytes does the same.
+func TestLoadSchemaReader(t *testing.T) {
+	var resolved [][2]string
+	opts := DefaultGenOptions()
+	opts.Offline, opts.BaseCodePath = true, t.TempDir()
+	opts.Resolver = SchemaResolverFunc(func(location, baseUri string) (io.ReadCloser, error) {
+		resolved = append(resolved, [2]string{location, baseUri})
+		return os.Open(filepath.Join("testdata", "reader", filepath.FromSlash(location)))
+	})
+	src, err := os.ReadFile(filepath.Join("testdata", "reader", "order.xsd"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	gen, cache := NewGenerator(opts), NewSchemaCache(0)
+	sd, err := cache.LoadSchemaReader(context.Background(), bytes.NewReader(src), "reader.example.com/schemas/order.xsd", LoadOptions{Generator: gen})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if (len(resolved) != 1) || (resolved[0] != [2]string{"common/line.xsd", "reader.example.com/schemas/order.xsd"}) {
+		t.Errorf("expected only the include to be resolved, relative to the base URI, got %q", resolved)
+	}
+	if (len(sd.XMLIncludedSchemas) != 1) || (len(sd.XMLIncludedSchemas[0].globalComplexTypes()) != 1) {
+		t.Errorf("the include was not loaded")
+	}
+	if cached, ok := cache.Get("reader.example.com/schemas/order.xsd"); !ok || (cached != sd) {
+		t.Errorf("expected the schema to be cached by its base URI")
+	}
+	if dir := gen.goOutDirPath(sd); dir != filepath.Join(opts.BaseCodePath, "reader.example.com", "schemas", "order.xsd_go") {
+		t.Errorf("unexpected package directory %s", dir)
+	}
+	if srcs, err := gen.GenerateGoSource(sd); err != nil {
+		t.Fatal(err)
+	} else if !strings.Contains(string(srcs["order.xsd.go"]), "type TLine struct {") {
+		t.Errorf("expected the included type to be generated")
+	}
+	if src, err = os.ReadFile(filepath.Join("testdata", "reader", "common", "line.xsd")); err != nil {
+		t.Fatal(err)
+	}
+	if sd, err = LoadSchemaBytes(src, "reader.example.com/bytes/line.xsd"); err != nil {
+		t.Fatal(err)
+	} else if (sd.loadUri != "reader.example.com/bytes/line.xsd") || (len(sd.loadLocalPath) > 0) || (len(sd.globalComplexTypes()) != 1) {
+		t.Errorf("unexpected schema loaded from bytes: %s %q", sd.loadUri, sd.loadLocalPath)
+	}
+}
@@ -0,0 +1,116 @@
+package xsd
+
+import (
+	"strings"
+)
+
+//	A reference within the definition of a global component to another global component, as followed by circularRefs.
+type definitionRef struct {
+	//	The construct holding the reference: the *ComplexType or *SimpleType deriving from target, or the *Group or *AttributeGroup reference to target.
+	from element
+
+	//	The global component referred to.
+	target element
+}
+
+//	Returns the references (see definitionRef.from) closing a cycle of definitions that no Go type can represent, as these are embedded (or aliased) by value:
+//	complex and simple types deriving from themselves (directly or via other types), xs:groups containing themselves other than via an element declaration,
+//	and xs:attributeGroups referring to themselves. Of each cycle, the reference leading back to the component first visited is reported (as an error, or
+//	as a warning for attribute groups, which XSD 1.1 permits to be circular) and not generated: such a complex type does not embed its base type, such a simple
+//	type is based on xs:string, and such a group reference is not embedded. Recursion via element declarations, such as that of tree structures of complex types
+//	declaring elements of their own type (or of types in turn declaring elements of it), is not affected: fields of elements of complex types are pointers (or slices).
+func (me *PkgBag) circularRefs() map[element]bool {
+	if me.circular == nil {
+		var compiled = me.compile()
+		me.circular = map[element]bool{}
+		for _, kind := range []string{"complexType", "simpleType", "group", "attributeGroup"} {
+			var path []element
+			var done = map[element]bool{}
+			var els = compiled.comps.byKind(kind)
+			for _, qn := range sortedNames(els) {
+				me.findCircularRefs(compiled, els[qn], &path, done)
+			}
+		}
+	}
+	return me.circular
+}
+
+//	Follows the definitionRefs of el depth-first, recording in circular (and reporting) those that refer back to a component on path, the components being visited.
+func (me *PkgBag) findCircularRefs(compiled *Compiled, el element, path *[]element, done map[element]bool) {
+	if done[el] {
+		return
+	}
+	*path = append(*path, el)
+	for _, ref := range compiled.definitionRefs(el) {
+		var cycle []string
+		for i, p := range *path {
+			if (p == ref.target) || (len(cycle) > 0) {
+				_, name := globalKindName((*path)[i])
+				cycle = append(cycle, name)
+			}
+		}
+		if len(cycle) == 0 {
+			me.findCircularRefs(compiled, ref.target, path, done)
+			continue
+		}
+		var severity, effect = SeverityError, ""
+		kind, name := globalKindName(el)
+		_, target := globalKindName(ref.target)
+		switch kind {
+		case "complexType":
+			effect = sfmt("the struct type of %s does not embed that of its base type %s", name, target)
+		case "simpleType":
+			effect = sfmt("%s is based on xs:string rather than on %s", name, target)
+		case "group":
+			effect = sfmt("the reference of %s to %s is not generated", name, target)
+		case "attributeGroup":
+			severity, effect = SeverityWarning, sfmt("%s does not embed the attributes of %s", name, target)
+		}
+		me.circular[ref.from] = true
+		me.report(ref.from, severity, "circular %s definition %s -> %s: %s", kind, strings.Join(cycle, " -> "), target, effect)
+	}
+	*path, done[el] = (*path)[:len(*path)-1], true
+}
+
+//	Returns the references of the definition of the global component el to other global components, as followed by circularRefs: the base type of a complex type
+//	or of a simple type restriction, and the named groups (or attribute groups) that a group (or attribute group) refers to, other than via element declarations.
+func (me *Compiled) definitionRefs(el element) (refs []definitionRef) {
+	switch decl := el.(type) {
+	case *ComplexType:
+		if base := me.defs[decl].Base; base.Complex != nil {
+			refs = append(refs, definitionRef{from: decl, target: base.Complex})
+		}
+	case *SimpleType:
+		if rest := decl.RestrictionSimpleType; (rest != nil) && (len(rest.Base) > 0) && (me.defs[decl].Base.Simple != nil) {
+			refs = append(refs, definitionRef{from: decl, target: me.defs[decl].Base.Simple})
+		}
+	case *Group:
+		for _, gr := range collectGroupRefs(nil, []*Choice{decl.Choice}, []*Sequence{decl.Sequence}) {
+			if target, _ := me.refs[gr].(*Group); target != nil {
+				refs = append(refs, definitionRef{from: gr, target: target})
+			}
+		}
+	case *AttributeGroup:
+		for _, ag := range decl.AttributeGroups {
+			if target, _ := me.refs[ag].(*AttributeGroup); target != nil {
+				refs = append(refs, definitionRef{from: ag, target: target})
+			}
+		}
+	}
+	return
+}
+
+//	Returns refs followed by the group references in the specified choices and sequences and in those nested in them, in document order per compositor.
+func collectGroupRefs(refs []*Group, choices []*Choice, seqs []*Sequence) []*Group {
+	for _, ch := range choices {
+		if ch != nil {
+			refs = collectGroupRefs(append(refs, ch.Groups...), ch.Choices, ch.Sequences)
+		}
+	}
+	for _, seq := range seqs {
+		if seq != nil {
+			refs = collectGroupRefs(append(refs, seq.Groups...), seq.Choices, seq.Sequences)
+		}
+	}
+	return refs
+}
@@ -0,0 +1,104 @@
+package xsd
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+//	Tests that types recurring via element declarations (directly, mutually, or via a group within an anonymous type) are generated with pointer
+//	indirection and decode nested instances, and that the JSON Schema of the recursive anonymous type is inlined once and referenced thereafter.
+func TestRecursiveTypes(t *testing.T) {
+	src, diags := genTestSrc(t, "recursion", "tree.xsd", nil)
+	if len(diags) > 0 {
+		t.Errorf("expected no diagnostics, got %v", diags)
+	}
+	for typeName, decl := range map[string]string{
+		"XsdGoPkgHasElem_LinksequenceFileschema_Link_TFolder_":                             "Link *TFolder `",
+		"XsdGoPkgHasElems_FoldersequenceFolderschema_Folder_TFolder_":                      "Folders []*TFolder `",
+		"XsdGoPkgHasElems_BranchsequenceBranchesschema_Branch_TxsdBranchesSequenceBranch_": "Branchs []*TxsdBranchesSequenceBranch `",
+	} {
+		if d := goTypeDecl(t, src, typeName); !strings.Contains(d, decl) {
+			t.Errorf("expected %s in\n%s", decl, d)
+		}
+	}
+	doc, err := loadTestSchema(t, "recursion", "tree.xsd").MakeJSONSchema()
+	if err != nil {
+		t.Fatal(err)
+	} else if js := compactJson(t, doc); !strings.Contains(js, `"branch":{"type":"object","properties":{"branch":{"type":"array","items":{"$ref":"#/$defs/branch"}},"leaf":{"type":"string"}},"additionalProperties":false}`) {
+		t.Errorf("expected a branch definition referring to itself in\n%s", doc)
+	}
+	gopath, goOutFilePaths := genTestPkgs(t, "recursion", nil)
+	for _, goOutFilePath := range goOutFilePaths {
+		if filepath.Base(goOutFilePath) != "tree.xsd.go" {
+			continue
+		}
+		goTestPkg(t, gopath, goOutFilePath, `package go_Tree
+
+import (
+	"encoding/xml"
+	"fmt"
+	"testing"
+)
+
+func TestRecursiveTypes(t *testing.T) {
+	var doc XsdGoPkgHasElem_Root
+	var trees XsdGoPkgHasElem_Tree
+	src := `+"`"+`<root xmlns="urn:example:tree" name="/"><file name="a"><link name="/"><folder name="b"/></link></file><folder name="c"><folder name="d"><file name="e"/></folder></folder></root>`+"`"+`
+	if err := xml.Unmarshal([]byte("<doc>"+src+"</doc>"), &doc); err != nil {
+		t.Fatal(err)
+	} else if err = xml.Unmarshal([]byte(`+"`"+`<doc><tree xmlns="urn:example:tree"><branch leaf="1"><branch leaf="2"><branch leaf="3"/></branch></branch></tree></doc>`+"`"+`), &trees); err != nil {
+		t.Fatal(err)
+	}
+	if root := doc.Root; (root.Files[0].Link.Folders[0].Name != "b") || (root.Folders[0].Folders[0].Files[0].Name != "e") {
+		t.Errorf("expected nested folders and files, got %#v", root)
+	}
+	if leaf := trees.Tree.Branchs[0].Branchs[0].Branchs[0]; (leaf.Leaf != "3") || (len(leaf.Branchs) != 0) {
+		t.Errorf("expected the innermost branch 3, got %#v", leaf)
+	}
+	var names []string
+	WalkHandlers.TFolder = func(f *TFolder, enter bool) (err error) {
+		if enter {
+			names = append(names, string(f.Name))
+		}
+		return
+	}
+	if err := doc.Walk(); err != nil {
+		t.Fatal(err)
+	} else if fmt.Sprint(names) != "[/ / b c d]" {
+		t.Errorf("expected to walk the folders / / b c d, got %v", names)
+	}
+}
+`)
+	}
+}
+
+//	Tests that circular type, group and attribute group definitions are reported, with the references closing the cycles not generated, so that
+//	the generated package still builds and the JSON Schema and proto backends terminate.
+func TestCircularDefinitions(t *testing.T) {
+	_, diags := genTestSrc(t, "recursion", "circular.xsd", nil)
+	var msgs []string
+	for _, d := range diags {
+		msgs = append(msgs, sfmt("%d:%d: %s: %s", d.Line, d.Column, d.Severity, d.Message))
+	}
+	expected := []string{
+		"8:33: error: circular complexType definition Base -> Derived -> Base: the struct type of Derived does not embed that of its base type Base",
+		"21:30: error: circular simpleType definition Code -> Label -> Code: Label is based on xs:string rather than on Code",
+		"27:27: error: circular group definition Inner -> Outer -> Inner: the reference of Outer to Inner is not generated",
+		"37:35: warning: circular attributeGroup definition Flags -> Marks -> Flags: Marks does not embed the attributes of Flags",
+	}
+	if strings.Join(msgs, "\n") != strings.Join(expected, "\n") {
+		t.Errorf("expected the diagnostics\n%s\ngot\n%s", strings.Join(expected, "\n"), strings.Join(msgs, "\n"))
+	}
+	sd := loadTestSchema(t, "recursion", "circular.xsd")
+	if _, err := sd.MakeJSONSchema(); err != nil {
+		t.Error(err)
+	}
+	if _, err := sd.MakeProtoFileAt(t.TempDir(), ""); err != nil {
+		t.Error(err)
+	}
+	gopath, goOutFilePaths := genTestPkgs(t, "recursion", nil)
+	for _, goOutFilePath := range goOutFilePaths {
+		goTool(t, gopath, goOutFilePath, "build")
+	}
+}
@@ -0,0 +1,286 @@
+package xsd
+
+import (
+	"path"
+	"strings"
+
+	xsdt "github.com/metaleap/go-xsd/types"
+)
+
+const (
+	//	Appended to the names of the original components redefined by an xs:redefine, which are retained under these names (eg. an original complex type "Address" becomes "Address_Original" and so generates the Go type TAddress_Original) so that the redefining components can extend or restrict them.
+	RedefinedNameSuffix = "_Original"
+)
+
+//	Loads the schema document at schemaLocation for an xs:redefine or xs:override. Unlike for xs:include, a fresh copy is always loaded, and it is not added to the cache: redefining and overriding modify its components.
+func (me *Schema) loadPrivateSchema(loader *schemaLoader, schemaLocation, localPath string) (sd *Schema, err error) {
+	var toLoadUri string
+	if toLoadUri = schemaLocation; strings.Index(toLoadUri, protSep) < 0 {
+		toLoadUri = path.Join(path.Dir(me.loadUri), toLoadUri)
+	}
+	if pos := strings.Index(toLoadUri, protSep); pos >= 0 {
+		toLoadUri = toLoadUri[pos+len(protSep):]
+	}
+	prev, cached := loader.pending[toLoadUri]
+	sd, err = loader.loadUri(schemaLocation, me.loadUri, len(localPath) > 0)
+	if cached {
+		loader.pending[toLoadUri] = prev
+	} else {
+		delete(loader.pending, toLoadUri)
+	}
+	return
+}
+
+//	Applies this xs:redefine to sd, the redefined schema document: each original component (directly declared in sd) is renamed by appending RedefinedNameSuffix, and all references to it within its redefining component are rewritten accordingly.
+//	All other references to it, be they in sd or elsewhere, then refer to its redefining component.
+func (me *Redefine) apply(sd *Schema) {
+	for _, st := range me.SimpleTypes {
+		name := st.Name.String()
+		for _, orig := range sd.SimpleTypes {
+			if orig.Name.String() == name {
+				orig.Name = xsdt.NCName(name + RedefinedNameSuffix)
+				if st.RestrictionSimpleType != nil {
+					redefineRef(&st.RestrictionSimpleType.Base, name)
+				}
+			}
+		}
+	}
+	for _, ct := range me.ComplexTypes {
+		name := ct.Name.String()
+		for _, orig := range sd.ComplexTypes {
+			if orig.Name.String() == name {
+				orig.Name = xsdt.NCName(name + RedefinedNameSuffix)
+				if cc := ct.ComplexContent; cc != nil {
+					if cc.ExtensionComplexContent != nil {
+						redefineRef(&cc.ExtensionComplexContent.Base, name)
+					}
+					if cc.RestrictionComplexContent != nil {
+						redefineRef(&cc.RestrictionComplexContent.Base, name)
+					}
+				}
+				if sc := ct.SimpleContent; sc != nil {
+					if sc.ExtensionSimpleContent != nil {
+						redefineRef(&sc.ExtensionSimpleContent.Base, name)
+					}
+					if sc.RestrictionSimpleContent != nil {
+						redefineRef(&sc.RestrictionSimpleContent.Base, name)
+					}
+				}
+			}
+		}
+	}
+	for _, gr := range me.Groups {
+		name := gr.Name.String()
+		for _, orig := range sd.Groups {
+			if orig.Name.String() == name {
+				orig.Name = xsdt.NCName(name + RedefinedNameSuffix)
+				redefineGroupRefs([]*Choice{gr.Choice}, []*Sequence{gr.Sequence}, name)
+			}
+		}
+	}
+	for _, agr := range me.AttributeGroups {
+		name := agr.Name.String()
+		for _, orig := range sd.AttributeGroups {
+			if orig.Name.String() == name {
+				orig.Name = xsdt.NCName(name + RedefinedNameSuffix)
+				for _, ref := range agr.AttributeGroups {
+					redefineRef(&ref.Ref, name)
+				}
+			}
+		}
+	}
+}
+
+//	Rewrites the specified QName reference to name (in whatever namespace prefix) to refer to the renamed original component instead.
+func redefineRef(ref *xsdt.Qname, name string) {
+	if s := ref.String(); s[strings.Index(s, ":")+1:] == name {
+		ref.Set(s + RedefinedNameSuffix)
+	}
+}
+
+func redefineGroupRefs(choices []*Choice, seqs []*Sequence, name string) {
+	for _, ch := range choices {
+		if ch != nil {
+			for _, gr := range ch.Groups {
+				redefineRef(&gr.Ref, name)
+			}
+			redefineGroupRefs(ch.Choices, ch.Sequences, name)
+		}
+	}
+	for _, seq := range seqs {
+		if seq != nil {
+			for _, gr := range seq.Groups {
+				redefineRef(&gr.Ref, name)
+			}
+			redefineGroupRefs(seq.Choices, seq.Sequences, name)
+		}
+	}
+}
+
+//	Applies this xs:override to sd, the overridden schema document: each original component (directly declared in sd) with the same name as an overriding component is removed, so that all references to it refer to the overriding component.
+func (me *Override) apply(sd *Schema) {
+	var overridden = map[string]bool{}
+	for _, att := range me.Attributes {
+		overridden["attribute "+att.Name.String()] = true
+	}
+	for _, agr := range me.AttributeGroups {
+		overridden["attributeGroup "+agr.Name.String()] = true
+	}
+	for _, ct := range me.ComplexTypes {
+		overridden["type "+ct.Name.String()] = true
+	}
+	for _, el := range me.Elements {
+		overridden["element "+el.Name.String()] = true
+	}
+	for _, gr := range me.Groups {
+		overridden["group "+gr.Name.String()] = true
+	}
+	for _, not := range me.Notations {
+		overridden["notation "+not.Name.String()] = true
+	}
+	for _, st := range me.SimpleTypes {
+		overridden["type "+st.Name.String()] = true
+	}
+	var atts []*Attribute
+	for _, att := range sd.Attributes {
+		if !overridden["attribute "+att.Name.String()] {
+			atts = append(atts, att)
+		}
+	}
+	var agrs []*AttributeGroup
+	for _, agr := range sd.AttributeGroups {
+		if !overridden["attributeGroup "+agr.Name.String()] {
+			agrs = append(agrs, agr)
+		}
+	}
+	var cts []*ComplexType
+	for _, ct := range sd.ComplexTypes {
+		if !overridden["type "+ct.Name.String()] {
+			cts = append(cts, ct)
+		}
+	}
+	var els []*Element
+	for _, el := range sd.Elements {
+		if !overridden["element "+el.Name.String()] {
+			els = append(els, el)
+		}
+	}
+	var grs []*Group
+	for _, gr := range sd.Groups {
+		if !overridden["group "+gr.Name.String()] {
+			grs = append(grs, gr)
+		}
+	}
+	var nots []*Notation
+	for _, not := range sd.Notations {
+		if !overridden["notation "+not.Name.String()] {
+			nots = append(nots, not)
+		}
+	}
+	var sts []*SimpleType
+	for _, st := range sd.SimpleTypes {
+		if !overridden["type "+st.Name.String()] {
+			sts = append(sts, st)
+		}
+	}
+	sd.Attributes, sd.AttributeGroups, sd.ComplexTypes, sd.Elements, sd.Groups, sd.Notations, sd.SimpleTypes = atts, agrs, cts, els, grs, nots, sts
+	var kids []element
+	for _, kid := range sd.kids {
+		var key string
+		switch k := kid.(type) {
+		case *Attribute:
+			key = "attribute " + k.Name.String()
+		case *AttributeGroup:
+			key = "attributeGroup " + k.Name.String()
+		case *ComplexType:
+			key = "type " + k.Name.String()
+		case *Element:
+			key = "element " + k.Name.String()
+		case *Group:
+			key = "group " + k.Name.String()
+		case *Notation:
+			key = "notation " + k.Name.String()
+		case *SimpleType:
+			key = "type " + k.Name.String()
+		}
+		if !overridden[key] {
+			kids = append(kids, kid)
+		}
+	}
+	sd.kids = kids
+}
+
+//	Returns the global attributes declared in this schema document, including those in its xs:overrides.
+func (me *Schema) globalAttributes() (atts []*Attribute) {
+	atts = append(atts, me.Attributes...)
+	for _, ov := range me.Overrides {
+		atts = append(atts, ov.Attributes...)
+	}
+	return
+}
+
+//	Returns the global attribute groups declared in this schema document, including those in its xs:redefines and xs:overrides.
+func (me *Schema) globalAttributeGroups() (agrs []*AttributeGroup) {
+	agrs = append(agrs, me.AttributeGroups...)
+	for _, rd := range me.Redefines {
+		agrs = append(agrs, rd.AttributeGroups...)
+	}
+	for _, ov := range me.Overrides {
+		agrs = append(agrs, ov.AttributeGroups...)
+	}
+	return
+}
+
+//	Returns the global complex types declared in this schema document, including those in its xs:redefines and xs:overrides.
+func (me *Schema) globalComplexTypes() (cts []*ComplexType) {
+	cts = append(cts, me.ComplexTypes...)
+	for _, rd := range me.Redefines {
+		cts = append(cts, rd.ComplexTypes...)
+	}
+	for _, ov := range me.Overrides {
+		cts = append(cts, ov.ComplexTypes...)
+	}
+	return
+}
+
+//	Returns the global elements declared in this schema document, including those in its xs:overrides.
+func (me *Schema) globalElements() (els []*Element) {
+	els = append(els, me.Elements...)
+	for _, ov := range me.Overrides {
+		els = append(els, ov.Elements...)
+	}
+	return
+}
+
+//	Returns the global groups declared in this schema document, including those in its xs:redefines and xs:overrides.
+func (me *Schema) globalGroups() (grs []*Group) {
+	grs = append(grs, me.Groups...)
+	for _, rd := range me.Redefines {
+		grs = append(grs, rd.Groups...)
+	}
+	for _, ov := range me.Overrides {
+		grs = append(grs, ov.Groups...)
+	}
+	return
+}
+
+//	Returns the notations declared in this schema document, including those in its xs:overrides.
+func (me *Schema) globalNotations() (nots []*Notation) {
+	nots = append(nots, me.Notations...)
+	for _, ov := range me.Overrides {
+		nots = append(nots, ov.Notations...)
+	}
+	return
+}
+
+//	Returns the global simple types declared in this schema document, including those in its xs:redefines and xs:overrides.
+func (me *Schema) globalSimpleTypes() (sts []*SimpleType) {
+	sts = append(sts, me.SimpleTypes...)
+	for _, rd := range me.Redefines {
+		sts = append(sts, rd.SimpleTypes...)
+	}
+	for _, ov := range me.Overrides {
+		sts = append(sts, ov.SimpleTypes...)
+	}
+	return
+}
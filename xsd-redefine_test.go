@@ -0,0 +1,29 @@
+package xsd
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestRedefineExtendsOriginal(t *testing.T) {
+	src, _ := genTestSrc(t, "redefine", "redefined.xsd", nil)
+	if decl := goTypeDecl(t, src, "TAddress"); !strings.Contains(decl, "\tTAddress_Original\n") || !strings.Contains(decl, "_Zip_XsdtString_") {
+		t.Errorf("TAddress does not extend TAddress_Original with zip:\n%s", decl)
+	}
+	if decl := goTypeDecl(t, src, "TAddress_Original"); !strings.Contains(decl, "_Street_XsdtString_") {
+		t.Errorf("TAddress_Original does not have the street of the original Address:\n%s", decl)
+	}
+	if decl := goTypeDecl(t, src, "TCustomer"); !strings.Contains(decl, "_Address_TAddress_") {
+		t.Errorf("the address of Customer does not refer to the redefining Address:\n%s", decl)
+	}
+}
+
+func TestOverrideReplacesOriginal(t *testing.T) {
+	src, _ := genTestSrc(t, "redefine", "overridden.xsd", nil)
+	if decl := goTypeDecl(t, src, "TCode"); decl != "type TCode xsdt.Token" {
+		t.Errorf("TCode is not generated from the overriding Code: %s", decl)
+	}
+	if !strings.Contains(src, "TCodeA TCode = \"A\"") || strings.Contains(src, "TCode_Original") {
+		t.Errorf("the original Code was not replaced:\n%s", src)
+	}
+}
@@ -0,0 +1,226 @@
+package xsd
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"sort"
+	"sync"
+	"time"
+)
+
+//	Holds loaded schemas keyed by their target namespaces, for long-running services validating incoming XML documents against whichever schema they declare.
+//	Schemas registered via Load can be reloaded when their schema documents (or those they include or import) change, see Reload and Watch.
+//	All methods of a Registry are safe for concurrent use: validations in progress keep using the schema they started with while it is being replaced.
+type Registry struct {
+	//	The options that Load and Reload load schemas with.
+	Options LoadOptions
+
+	mutex   sync.RWMutex
+	entries map[string]*registryEntry
+}
+
+type registryEntry struct {
+	schema    *Schema
+	compiled  *Compiled
+	uri       string
+	localCopy bool
+	hash      string
+	modTimes  map[string]time.Time
+}
+
+//	Returns a new, empty Registry loading schemas with the specified options.
+func NewRegistry(opts LoadOptions) *Registry {
+	return &Registry{Options: opts, entries: map[string]*registryEntry{}}
+}
+
+//	Registers sd under its target namespace, replacing any schema registered for it before. Unlike those registered via Load, sd is never reloaded.
+func (me *Registry) Add(sd *Schema) {
+	me.put(&registryEntry{schema: sd, compiled: compileSchemas(sd)})
+}
+
+//	Loads the schema at the specified uri (see LoadSchemaWithOptions, but without consulting or populating DefaultSchemaCache, so that
+//	changed schema documents are always loaded afresh) and registers it under its target namespace, replacing any schema registered for it before.
+func (me *Registry) Load(ctx context.Context, uri string, localCopy bool) (sd *Schema, err error) {
+	var entry *registryEntry
+	if entry, err = me.load(ctx, uri, localCopy); err == nil {
+		sd = entry.schema
+		me.put(entry)
+	}
+	return
+}
+
+func (me *Registry) load(ctx context.Context, uri string, localCopy bool) (entry *registryEntry, err error) {
+	var sd *Schema
+	if sd, err = NewSchemaCache(0).LoadSchemaWithOptions(ctx, uri, localCopy, me.Options); err == nil {
+		entry = &registryEntry{schema: sd, compiled: compileSchemas(sd), uri: uri, localCopy: localCopy}
+		entry.hash, entry.modTimes, err = registryStamp(sd)
+	}
+	return
+}
+
+func (me *Registry) put(entry *registryEntry) {
+	me.mutex.Lock()
+	defer me.mutex.Unlock()
+	if me.entries == nil {
+		me.entries = map[string]*registryEntry{}
+	}
+	me.entries[entry.schema.TargetNamespace.String()] = entry
+}
+
+//	Returns the schema registered for the specified target namespace, if any.
+func (me *Registry) Get(namespace string) (sd *Schema, ok bool) {
+	var entry *registryEntry
+	if entry, ok = me.entry(namespace); ok {
+		sd = entry.schema
+	}
+	return
+}
+
+func (me *Registry) entry(namespace string) (entry *registryEntry, ok bool) {
+	me.mutex.RLock()
+	defer me.mutex.RUnlock()
+	entry, ok = me.entries[namespace]
+	return
+}
+
+//	Returns the target namespaces of all registered schemas, sorted.
+func (me *Registry) Namespaces() (namespaces []string) {
+	me.mutex.RLock()
+	defer me.mutex.RUnlock()
+	for ns, _ := range me.entries {
+		namespaces = append(namespaces, ns)
+	}
+	sort.Strings(namespaces)
+	return
+}
+
+//	Unregisters the schema registered for the specified target namespace, if any.
+func (me *Registry) Remove(namespace string) {
+	me.mutex.Lock()
+	defer me.mutex.Unlock()
+	delete(me.entries, namespace)
+}
+
+//	Checks the XML instance document read from r against the schema registered for the target namespace nsURI (see Schema.Validate).
+//	The returned error is non-nil if no schema is registered for nsURI, or if r could not be read or is not well-formed XML.
+func (me *Registry) ValidateDocument(nsURI string, r io.Reader) (errs []ValidationError, err error) {
+	if entry, ok := me.entry(nsURI); !ok {
+		err = fmt.Errorf("no schema registered for namespace %q", nsURI)
+	} else {
+		errs, err = entry.compiled.Validate(r)
+	}
+	return
+}
+
+//	Like ValidateDocument, against the schema registered for the namespace of the root element of the XML instance document read from r.
+func (me *Registry) Validate(r io.Reader) (errs []ValidationError, err error) {
+	var raw []byte
+	var tok xml.Token
+	if raw, err = ioutil.ReadAll(r); err == nil {
+		for dec := xml.NewDecoder(bytes.NewReader(raw)); err == nil; {
+			if tok, err = dec.Token(); err == io.EOF {
+				err = errors.New("no root element found in XML instance document")
+			} else if start, isStart := tok.(xml.StartElement); isStart && (err == nil) {
+				return me.ValidateDocument(start.Name.Space, bytes.NewReader(raw))
+			}
+		}
+	}
+	return
+}
+
+//	Loads afresh every schema registered via Load whose schema documents (or any of those it includes or imports) were modified since it was loaded,
+//	or were not loaded from local files (such as when downloaded without a local copy), and registers it in place of the old one. Returns the target
+//	namespaces of the schemas whose contents changed. Schemas that fail to reload stay registered as they were, and the first such error is returned.
+func (me *Registry) Reload(ctx context.Context) (namespaces []string, err error) {
+	for _, ns := range me.Namespaces() {
+		if entry, ok := me.entry(ns); ok && (len(entry.uri) > 0) && entry.modified() {
+			if fresh, loadErr := me.load(ctx, entry.uri, entry.localCopy); loadErr != nil {
+				if err == nil {
+					err = loadErr
+				}
+			} else {
+				me.mutex.Lock()
+				if me.entries[ns] == entry {
+					delete(me.entries, ns)
+					if me.entries[fresh.schema.TargetNamespace.String()] = fresh; fresh.hash != entry.hash {
+						namespaces = append(namespaces, ns)
+					}
+				}
+				me.mutex.Unlock()
+			}
+		}
+	}
+	return
+}
+
+//	Calls Reload every interval until ctx is done, then returns ctx.Err(). If onReload is not nil, it is called with the results of every
+//	Reload that replaced a schema or failed. Meant to be run in a goroutine of its own, such as go registry.Watch(ctx, time.Minute, logReloads).
+func (me *Registry) Watch(ctx context.Context, interval time.Duration, onReload func(namespaces []string, err error)) error {
+	var ticker = time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+			if namespaces, err := me.Reload(ctx); (onReload != nil) && ((len(namespaces) > 0) || (err != nil)) {
+				onReload(namespaces, err)
+			}
+		}
+	}
+}
+
+//	Returns whether any of the local files of the schema documents of this entry was modified (or removed) since it was loaded,
+//	or whether it has schema documents without local files (which Reload always loads afresh).
+func (me *registryEntry) modified() bool {
+	if me.modTimes == nil {
+		return true
+	}
+	for filePath, modTime := range me.modTimes {
+		if info, err := os.Stat(filePath); (err != nil) || !info.ModTime().Equal(modTime) {
+			return true
+		}
+	}
+	return false
+}
+
+//	Returns a hash of the contents of sd and all the schemas it includes or imports (directly or indirectly), and the modification times of their local files.
+//	The latter are nil if any of these schemas has no local file, in which case its decoded contents are hashed (as re-encoded by xml.Marshal).
+func registryStamp(sd *Schema) (hash string, modTimes map[string]time.Time, err error) {
+	var uris []string
+	var schemas = map[string]*Schema{}
+	var sum = sha256.New()
+	var info os.FileInfo
+	var raw []byte
+	sd.genCacheSchemas(schemas)
+	for uri, _ := range schemas {
+		uris = append(uris, uri)
+	}
+	sort.Strings(uris)
+	modTimes = map[string]time.Time{}
+	for _, uri := range uris {
+		if filePath := schemas[uri].loadLocalPath; len(filePath) == 0 {
+			modTimes = nil
+			raw, err = xml.Marshal(schemas[uri])
+		} else if info, err = os.Stat(filePath); err == nil {
+			if raw, err = ioutil.ReadFile(filePath); (err == nil) && (modTimes != nil) {
+				modTimes[filePath] = info.ModTime()
+			}
+		}
+		if err != nil {
+			return
+		}
+		fmt.Fprintf(sum, "%s\x00%d\x00", uri, len(raw))
+		sum.Write(raw)
+	}
+	hash = hex.EncodeToString(sum.Sum(nil))
+	return
+}
@@ -0,0 +1,58 @@
+package xsd
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+//	Tests that a Registry validates documents against the schema registered for their namespace, and that Reload replaces
+//	that schema by the changed contents of its file, and only then.
+func TestRegistryValidatesAndReloads(t *testing.T) {
+	codePath := t.TempDir()
+	if err := copyTestdata(filepath.Join("testdata", "validate"), filepath.Join(codePath, "validate")); err != nil {
+		t.Fatal(err)
+	}
+	opts := DefaultGenOptions()
+	opts.BaseCodePath, opts.Offline = codePath, true
+	registry := NewRegistry(LoadOptions{Generator: NewGenerator(opts)})
+	if _, err := registry.Load(context.Background(), "validate/order.xsd", true); err != nil {
+		t.Fatal(err)
+	}
+	if namespaces := registry.Namespaces(); strings.Join(namespaces, " ") != "urn:example:validate" {
+		t.Fatalf("expected urn:example:validate to be registered, got %v", namespaces)
+	}
+	item := "<item><sku>ABC-123</sku><qty>2</qty></item>"
+	doc := `<order xmlns="urn:example:validate" id="1"><date>2020-02-29</date>` + item + item + item + `</order>`
+	if errs, err := registry.Validate(strings.NewReader(doc)); (err != nil) || (len(errs) == 0) || (errs[0].Path != "/order/item[3]") {
+		t.Errorf("expected an error at /order/item[3], got %v %v", errs, err)
+	}
+	if _, err := registry.ValidateDocument("urn:example:unknown", strings.NewReader(doc)); err == nil {
+		t.Error("expected an error for an unregistered namespace")
+	}
+	if namespaces, err := registry.Reload(context.Background()); (err != nil) || (len(namespaces) > 0) {
+		t.Errorf("expected nothing to be reloaded, got %v %v", namespaces, err)
+	}
+	filePath := filepath.Join(codePath, "validate", "order.xsd")
+	src, err := ioutil.ReadFile(filePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = ioutil.WriteFile(filePath, []byte(strings.Replace(string(src), `maxOccurs="2"`, `maxOccurs="3"`, 1)), 0644); err != nil {
+		t.Fatal(err)
+	}
+	modTime := time.Now().Add(time.Minute)
+	if err = os.Chtimes(filePath, modTime, modTime); err != nil {
+		t.Fatal(err)
+	}
+	if namespaces, err := registry.Reload(context.Background()); (err != nil) || (strings.Join(namespaces, " ") != "urn:example:validate") {
+		t.Errorf("expected urn:example:validate to be reloaded, got %v %v", namespaces, err)
+	}
+	if errs, err := registry.ValidateDocument("urn:example:validate", strings.NewReader(doc)); (err != nil) || (len(errs) > 0) {
+		t.Errorf("expected 3 items to be valid after reloading, got %v %v", errs, err)
+	}
+}
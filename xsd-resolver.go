@@ -0,0 +1,22 @@
+package xsd
+
+import (
+	"io"
+)
+
+//	Provides the contents of schema documents before LoadSchema falls back to its own file-system or HTTP access.
+//	This allows serving schemas from embedded assets, archives, databases or artifact stores.
+type SchemaResolver interface {
+	//	Returns the contents of the schema document at the specified location, which is either the uri passed to LoadSchema (with an empty baseUri),
+	//	or the verbatim schemaLocation of an xs:include, in which case baseUri is the (protocol-less) uri of the including schema.
+	//	Returning a nil io.ReadCloser together with a nil error means the location is not handled by this resolver and LoadSchema should proceed as usual.
+	Resolve(location, baseUri string) (io.ReadCloser, error)
+}
+
+//	A function type implementing SchemaResolver by simply calling itself.
+type SchemaResolverFunc func(location, baseUri string) (io.ReadCloser, error)
+
+//	Returns me(location, baseUri).
+func (me SchemaResolverFunc) Resolve(location, baseUri string) (io.ReadCloser, error) {
+	return me(location, baseUri)
+}
@@ -0,0 +1,30 @@
+package xsd
+
+import (
+	"context"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"testing"
+)
+
+func TestResolverServesSchemaAndIncludes(t *testing.T) {
+	var resolved [][2]string
+	opts := DefaultGenOptions()
+	opts.Offline = true
+	opts.Resolver = SchemaResolverFunc(func(location, baseUri string) (io.ReadCloser, error) {
+		resolved = append(resolved, [2]string{location, baseUri})
+		return os.Open(filepath.Join("testdata", "resolver", path.Base(location)))
+	})
+	sd, err := NewSchemaCache(0).LoadSchemaWithOptions(context.Background(), "resolver.example.com/schemas/root.xsd", false, LoadOptions{Generator: NewGenerator(opts)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if (len(resolved) != 2) || (resolved[0] != [2]string{"resolver.example.com/schemas/root.xsd", ""}) || (resolved[1] != [2]string{"part.xsd", "resolver.example.com/schemas/root.xsd"}) {
+		t.Errorf("unexpected Resolve calls: %q", resolved)
+	}
+	if (len(sd.XMLIncludedSchemas) != 1) || (len(sd.XMLIncludedSchemas[0].globalComplexTypes()) != 1) {
+		t.Errorf("the include was not loaded via the resolver")
+	}
+}
@@ -0,0 +1,153 @@
+package xsd
+
+import (
+	"encoding/xml"
+	"sort"
+	"strings"
+
+	"github.com/metaleap/go-util-str"
+)
+
+//	Returns whether the struct type tn of this complex type is narrowed to its complexContent restriction of the resolved base type (see PkgGen.NarrowRestrictions)
+//	rather than embedding base. If so, records it in bag.restrictions, and base in bag.ctBases unless it is xs:anyType.
+func (me *ComplexType) narrowed(bag *PkgBag, tn, base string) bool {
+	if (!bag.gen.NarrowRestrictions) || (me.ComplexContent == nil) || (me.ComplexContent.RestrictionComplexContent == nil) || (me.ComplexContent.ExtensionComplexContent != nil) {
+		return false
+	}
+	rest := me.ComplexContent.RestrictionComplexContent
+	if qn := ownerSchema(rest).qname(rest.Base.String()); (qn.Space == xsdNamespaceUri) && (qn.Local == "anyType") {
+		bag.restrictions[tn] = me
+	} else if (bag.compile().TypeDefOf(me).Base.Complex != nil) && !strings.Contains(base, ".") {
+		bag.restrictions[tn], bag.ctBases[tn] = me, bag.safeName(base)
+	}
+	return bag.restrictions[tn] == me
+}
+
+//	Adds to the struct types recorded in restrictions the attributes and attribute groups they inherit from their base types, as these are not embedded.
+//	Also has each of them implement the XsdGoPkgRestriction_<base> interface of every base type it derives from without embedding it (see embedsBase).
+func (me *PkgBag) addRestrictions() {
+	var tns []string
+	for tn, _ := range me.restrictions {
+		tns = append(tns, tn)
+	}
+	sort.Strings(tns)
+	comps := me.components()
+	for _, tn := range tns {
+		var atts []*Attribute
+		var groups []*AttributeGroup
+		ct, td := me.restrictions[tn], me.declTypes[tn]
+		rest, base := ct.ComplexContent.RestrictionComplexContent, me.compile().TypeDefOf(ct).Base.Complex
+		if (base == nil) || (td == nil) {
+			continue
+		}
+		me.attributeUses(base, &atts, &groups, 0)
+		for _, att := range rest.Attributes {
+			for _, ag := range groups {
+				if (att.Use == "prohibited") && me.attributeGroupHas(ag, comps.attributeName(att), 0) {
+					me.report(att, SeverityWarning, "unsupported construct: the attribute %s prohibited by %s is inherited via an attribute group, and is kept", diffName(comps.attributeName(att)), tn)
+				}
+			}
+		}
+		me.addAttributeUses(rest.Attributes, rest.AttributeGroups, &atts, &groups, true)
+		for _, att := range atts {
+			if key := me.attsKeys[att]; len(key) > 0 {
+				if name := ustr.PrefixWithSep(me.attRefImps[att], ".", me.attsCache[key][(strings.Index(me.attsCache[key], ".")+1):]); td.Embeds[name] == nil {
+					td.addEmbed(att, name, att.Annotation)
+				}
+			}
+		}
+		for _, ag := range groups {
+			if key := me.attGroups[ag]; len(key) > 0 {
+				if name := ustr.PrefixWithSep(me.attGroupRefImps[ag], ".", key[(strings.Index(key, ".")+1):]); td.Embeds[name] == nil {
+					td.addEmbed(ag, name, ag.Annotation)
+				}
+			}
+		}
+	}
+	for _, tn := range sortedKeys(me.ctBases) {
+		for bn, depth := me.ctBases[tn], 0; (len(bn) > 0) && (depth < 64); bn, depth = me.ctBases[bn], depth+1 {
+			if dt := me.declTypes[tn]; (dt != nil) && (me.declTypes[bn] != nil) && !me.embedsBase(tn, bn) {
+				iface, marker := idPrefix+"Restriction_"+bn, idPrefix+"Restricts_"+bn
+				if me.declTypes[iface] == nil {
+					me.addType(nil, iface, sfmt("interface { %s () }", marker), docAnnotation(sfmt("Implemented by the struct types of the complex types derived from %s by restriction (directly, or via other derived types), which do not embed %s.", bn, bn)))
+				}
+				dt.addMethod(nil, "*"+tn, marker, "", "", sfmt("Implements %s: marks %s as derived from %s by restriction.", iface, tn, bn))
+			}
+		}
+	}
+}
+
+//	Returns whether the struct type tn embeds the struct type of its (direct or indirect) base type bn, rather than deriving from it by a restriction narrowed by addRestrictions.
+func (me *PkgBag) embedsBase(tn, bn string) bool {
+	for depth := 0; (len(tn) > 0) && (tn != bn) && (depth < 64); tn, depth = me.ctBases[tn], depth+1 {
+		if me.restrictions[tn] != nil {
+			return false
+		}
+	}
+	return true
+}
+
+//	Collects the attribute declarations (other than those of attribute groups) and the attribute groups in effect for ct, in document order:
+//	those of its base types (transitively) that its derivation does not re-declare or prohibit, followed by its own.
+func (me *PkgBag) attributeUses(ct *ComplexType, atts *[]*Attribute, groups *[]*AttributeGroup, depth int) {
+	var bt *ComplexType
+	ownAtts, ownGroups := append([]*Attribute{}, ct.Attributes...), append([]*AttributeGroup{}, ct.AttributeGroups...)
+	if cc := ct.ComplexContent; cc != nil {
+		if bt = me.compile().TypeDefOf(ct).Base.Complex; cc.ExtensionComplexContent != nil {
+			ext := cc.ExtensionComplexContent
+			ownAtts, ownGroups = append(ownAtts, ext.Attributes...), append(ownGroups, ext.AttributeGroups...)
+		} else if res := cc.RestrictionComplexContent; res != nil {
+			ownAtts, ownGroups = append(ownAtts, res.Attributes...), append(ownGroups, res.AttributeGroups...)
+		}
+	}
+	if (bt != nil) && (bt != ct) && (depth < 64) {
+		me.attributeUses(bt, atts, groups, depth+1)
+	}
+	me.addAttributeUses(ownAtts, ownGroups, atts, groups, false)
+}
+
+//	Adds the specified attribute declarations and attribute groups to atts and groups, replacing (or, if prohibited, removing) those of the same names.
+//	If replaceOnly is set, the specified attribute declarations and attribute groups themselves are not added.
+func (me *PkgBag) addAttributeUses(ownAtts []*Attribute, ownGroups []*AttributeGroup, atts *[]*Attribute, groups *[]*AttributeGroup, replaceOnly bool) {
+	comps := me.components()
+	for _, att := range ownAtts {
+		var kept []*Attribute
+		name := comps.attributeName(att)
+		for _, a := range *atts {
+			if comps.attributeName(a) != name {
+				kept = append(kept, a)
+			}
+		}
+		if *atts = kept; (!replaceOnly) && (att.Use != "prohibited") {
+			*atts = append(*atts, att)
+		}
+	}
+	for _, ag := range ownGroups {
+		var kept []*AttributeGroup
+		for _, g := range *groups {
+			if (len(ag.Ref) == 0) || (ownerSchema(g).qname(g.Ref.String()) != ownerSchema(ag).qname(ag.Ref.String())) {
+				kept = append(kept, g)
+			}
+		}
+		if *groups = kept; !replaceOnly {
+			*groups = append(*groups, ag)
+		}
+	}
+}
+
+//	Returns whether the attribute group ag (or one it refers to) declares an attribute of the specified name.
+func (me *PkgBag) attributeGroupHas(ag *AttributeGroup, name xml.Name, depth int) bool {
+	if ag, _ = me.compile().Resolve(ag).(*AttributeGroup); (ag != nil) && (depth < 64) {
+		for _, att := range ag.Attributes {
+			if me.components().attributeName(att) == name {
+				return true
+			}
+		}
+		for _, sub := range ag.AttributeGroups {
+			if me.attributeGroupHas(sub, name, depth+1) {
+				return true
+			}
+		}
+	}
+	return false
+}
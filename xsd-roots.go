@@ -0,0 +1,63 @@
+package xsd
+
+import (
+	"encoding/xml"
+	"fmt"
+	"strings"
+)
+
+//	Returns the DependencyNode IDs of the global components that the Go code generated for this schema is restricted to by gen.Roots
+//	(or nil if it is empty): those named by gen.Roots, all those they depend on (directly or indirectly, see DependencyGraph), and all
+//	members of the substitution groups headed by any of these elements (so that instances of the heads can be decoded).
+func (me *Schema) rootsClosure(gen *Generator) (keep map[string]bool, err error) {
+	if len(gen.Roots) == 0 {
+		return
+	}
+	var graph = me.DependencyGraph()
+	var todo []string
+	var deps, substs = map[string][]string{}, map[string][]string{}
+	for _, edge := range graph.Edges {
+		if deps[edge.From] = append(deps[edge.From], edge.To); edge.Kind == DependencySubstitutionGroup {
+			substs[edge.To] = append(substs[edge.To], edge.From)
+		}
+	}
+	keep = map[string]bool{}
+	for _, root := range gen.Roots {
+		var qn xml.Name
+		var found bool
+		if pos := strings.Index(root, "}"); strings.HasPrefix(root, "{") && (pos > 0) {
+			qn = xml.Name{Space: root[1:pos], Local: root[pos+1:]}
+		} else if strings.Contains(root, ":") {
+			qn = me.qname(root)
+		} else {
+			qn = xml.Name{Space: me.TargetNamespace.String(), Local: root}
+		}
+		for _, node := range graph.Nodes {
+			if (node.Kind != "schema") && (node.Namespace == qn.Space) && (node.Name == qn.Local) {
+				found, todo = true, append(todo, node.ID)
+			}
+		}
+		if !found {
+			return nil, fmt.Errorf("PkgGen.Roots: no global element, type, group or attribute found for %s", diffName(qn))
+		}
+	}
+	for ; len(todo) > 0; todo = todo[1:] {
+		if id := todo[0]; !keep[id] {
+			keep[id] = true
+			todo = append(append(todo, deps[id]...), substs[id]...)
+		}
+	}
+	return
+}
+
+//	Returns whether el is a global component outside the closure of PkgGen.Roots (see Schema.rootsClosure), or a redeclaration of a global
+//	component (see Schema.collectDuplicates), for which no Go code is generated.
+func (me *PkgBag) skips(el element) bool {
+	if me.dupDecls[el] != nil {
+		return true
+	} else if me.keep == nil {
+		return false
+	}
+	id := componentID(el)
+	return (len(id) > 0) && !me.keep[id]
+}
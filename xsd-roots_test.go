@@ -0,0 +1,19 @@
+package xsd
+
+import (
+	"strings"
+	"testing"
+)
+
+//	Tests that with PkgGen.Roots, Go code is generated only for the named components, those they depend on and the members
+//	of the substitution groups they head, however the roots are named.
+func TestRootsRestrictGeneratedCode(t *testing.T) {
+	for _, roots := range [][]string{{"product"}, {"{urn:example:roots}product"}} {
+		src, _ := genTestSrc(t, "roots", "catalog.xsd", func(opts *GenOptions) { opts.Roots = roots })
+		for _, decl := range []string{"type TPrice ", "type TProduct struct", "type XsdGoPkgHasElem_Product struct", "type XsdGoPkgHasElem_Gadget struct", "type TCustomer struct", "type TEmail "} {
+			if expected := !strings.Contains(decl, "Customer") && !strings.Contains(decl, "Email"); strings.Contains(src, decl) != expected {
+				t.Errorf("Roots %v: expected %q to be declared: %v", roots, decl, expected)
+			}
+		}
+	}
+}
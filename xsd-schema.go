@@ -2,18 +2,25 @@ package xsd
 
 import (
 	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/xml"
+	"fmt"
 	"io"
+	"io/fs"
 	"io/ioutil"
+	"net/http"
 	"os"
 	"path"
 	"path/filepath"
 	"strings"
+	"time"
 
 	"github.com/metaleap/go-util-fs"
-	"github.com/metaleap/go-util-net"
 	"github.com/metaleap/go-util-str"
-	"fmt"
+
+	xsdt "github.com/metaleap/go-xsd/types"
 )
 
 const (
@@ -23,19 +30,19 @@ const (
 	xsdNamespaceUri = "http://www.w3.org/2001/XMLSchema"
 )
 
-var (
-	loadedSchemas = map[string]*Schema{}
-)
-
 type Schema struct {
 	elemBase
 	XMLName            xml.Name          `xml:"schema"`
 	XMLNamespacePrefix string            `xml:"-"`
 	XMLNamespaces      map[string]string `xml:"-"`
 	XMLIncludedSchemas []*Schema         `xml:"-"`
+	XMLImportedSchemas []*Schema         `xml:"-"`
 	XSDNamespacePrefix string            `xml:"-"`
 	XSDParentSchema    *Schema           `xml:"-"`
 
+	//	For schemas loaded by LoadWSDL, the operations of the SOAP bindings of the WSDL whose request elements this schema declares.
+	SoapOperations []*SoapOperation `xml:"-"`
+
 	hasAttrAttributeFormDefault
 	hasAttrBlockDefault
 	hasAttrElementFormDefault
@@ -43,6 +50,7 @@ type Schema struct {
 	hasAttrLang
 	hasAttrId
 	hasAttrSchemaLocation
+	hasAttrXmlBase
 	hasAttrTargetNamespace
 	hasAttrVersion
 	hasElemAnnotation
@@ -54,10 +62,13 @@ type Schema struct {
 	hasElemsInclude
 	hasElemsImport
 	hasElemsNotation
+	hasElemsOverride
 	hasElemsRedefine
 	hasElemsSimpleType
 
 	loadLocalPath, loadUri string
+	loadFS                 fs.FS // the file system this schema document was loaded from (see LoadSchemaFS), if any
+	elemPositions          map[string][2]int
 }
 
 func (me *Schema) allSchemas(loadedSchemas map[string]bool) (schemas []*Schema) {
@@ -74,20 +85,30 @@ func (me *Schema) allSchemas(loadedSchemas map[string]bool) (schemas []*Schema)
 
 func (me *Schema) collectGlobals(bag *PkgBag, loadedSchemas map[string]bool) {
 	loadedSchemas[me.loadUri] = true
-	for _, att := range me.Attributes {
-		bag.allAtts = append(bag.allAtts, att)
+	for _, att := range me.globalAttributes() {
+		if bag.dupDecls[att] == nil {
+			bag.allAtts = append(bag.allAtts, att)
+		}
 	}
-	for _, agr := range me.AttributeGroups {
-		bag.allAttGroups = append(bag.allAttGroups, agr)
+	for _, agr := range me.globalAttributeGroups() {
+		if bag.dupDecls[agr] == nil {
+			bag.allAttGroups = append(bag.allAttGroups, agr)
+		}
 	}
-	for _, el := range me.Elements {
-		bag.allElems = append(bag.allElems, el)
+	for _, el := range me.globalElements() {
+		if bag.dupDecls[el] == nil {
+			bag.allElems = append(bag.allElems, el)
+		}
 	}
-	for _, egr := range me.Groups {
-		bag.allElemGroups = append(bag.allElemGroups, egr)
+	for _, egr := range me.globalGroups() {
+		if bag.dupDecls[egr] == nil {
+			bag.allElemGroups = append(bag.allElemGroups, egr)
+		}
 	}
-	for _, not := range me.Notations {
-		bag.allNotations = append(bag.allNotations, not)
+	for _, not := range me.globalNotations() {
+		if bag.dupDecls[not] == nil {
+			bag.allNotations = append(bag.allNotations, not)
+		}
 	}
 	for _, ss := range me.XMLIncludedSchemas {
 		if v, ok := loadedSchemas[ss.loadUri]; ok && v {
@@ -97,93 +118,375 @@ func (me *Schema) collectGlobals(bag *PkgBag, loadedSchemas map[string]bool) {
 	}
 }
 
-func (me *Schema) globalComplexType(bag *PkgBag, name string, loadedSchemas map[string]bool) (ct *ComplexType) {
-	var imp string
-	for _, ct = range me.ComplexTypes {
-		if bag.resolveQnameRef(ustr.PrefixWithSep(me.XMLNamespacePrefix, ":", ct.Name.String()), "T", &imp) == name {
-			return
+//	Records in bag.dupDecls every global component of this schema document and the schema documents it includes, redefines and overrides
+//	that is declared again (under the same name, and as the same kind of component, complex and simple types counting as one kind), mapped
+//	to its first declaration, so that Go code is generated only once for it. Redeclarations identical to the first declaration (such as of
+//	the same schema document included from two locations) are merged into it silently, while differing ones are reported as errors, with the
+//	positions of both declarations.
+func (me *Schema) collectDuplicates(bag *PkgBag) {
+	var first = map[string]element{}
+	bag.dupDecls = map[element]element{}
+	for _, sd := range me.allSchemas(map[string]bool{}) {
+		var comps []element
+		for _, att := range sd.globalAttributes() {
+			comps = append(comps, att)
 		}
-	}
-	loadedSchemas[me.loadUri] = true
-	for _, ss := range me.XMLIncludedSchemas {
-		if v, ok := loadedSchemas[ss.loadUri]; ok && v {
-			//fmt.Printf("Ignoring processed schema: %s\n", ss.loadUri)
-			continue
+		for _, agr := range sd.globalAttributeGroups() {
+			comps = append(comps, agr)
 		}
-		if ct = ss.globalComplexType(bag, name, loadedSchemas); ct != nil {
-			return
+		for _, ct := range sd.globalComplexTypes() {
+			comps = append(comps, ct)
+		}
+		for _, el := range sd.globalElements() {
+			comps = append(comps, el)
+		}
+		for _, gr := range sd.globalGroups() {
+			comps = append(comps, gr)
+		}
+		for _, not := range sd.globalNotations() {
+			comps = append(comps, not)
+		}
+		for _, st := range sd.globalSimpleTypes() {
+			comps = append(comps, st)
+		}
+		for _, comp := range comps {
+			kind, name := globalKindName(comp)
+			key := ustr.Ifs((kind == "complexType") || (kind == "simpleType"), "type", kind) + " " + name
+			if prev := first[key]; (prev == nil) || (len(name) == 0) {
+				first[key] = comp
+			} else if prev != comp {
+				bag.dupDecls[comp] = prev
+				prevRaw, _ := xml.Marshal(prev)
+				if raw, _ := xml.Marshal(comp); !bytes.Equal(raw, prevRaw) {
+					file, line, col := SchemaNode{Elem: prev}.Position()
+					bag.report(comp, SeverityError, "conflicting declarations: %s %q is declared differently before, at %s:%d:%d, which is the one generated", kind, name, file, line, col)
+				}
+			}
 		}
 	}
-	ct = nil
+}
+
+//	Returns the XSD element name (such as "complexType") and the name of the global component el.
+func globalKindName(el element) (kind, name string) {
+	switch decl := el.(type) {
+	case *Attribute:
+		name = decl.Name.String()
+	case *AttributeGroup:
+		name = decl.Name.String()
+	case *ComplexType:
+		name = decl.Name.String()
+	case *Element:
+		name = decl.Name.String()
+	case *Group:
+		name = decl.Name.String()
+	case *Notation:
+		name = decl.Name.String()
+	case *SimpleType:
+		name = decl.Name.String()
+	}
+	return el.base().xsdName.String(), name
+}
+
+//	Returns the global complex type that the QName ref (as resolved by the namespace declarations of the current Schema) names, looked up by
+//	its namespace and local name among the components of the schema set (see PkgBag.components), if it is declared by one of the schema
+//	documents this package is generated from (rather than by an imported one) and not mapped to another Go type (see PkgGen.TypeOverrides), or else nil.
+func (me *PkgBag) globalComplexType(ref string) (ct *ComplexType) {
+	qn := me.Schema.qname(ref)
+	if ct = me.components().complexTypes[qn]; (ct != nil) && (!me.ownSchemas[ownerSchema(ct)] || (me.gen.TypeOverrides["{"+qn.Space+"}"+qn.Local] != nil)) {
+		ct = nil
+	}
 	return
 }
 
-func (me *Schema) globalElement(bag *PkgBag, name string) (el *Element) {
-	var imp string
-	if len(name) > 0 {
-		var rname = bag.resolveQnameRef(name, "", &imp)
-		for _, el = range me.Elements {
-			if bag.resolveQnameRef(ustr.PrefixWithSep(me.XMLNamespacePrefix, ":", el.Name.String()), "", &imp) == rname {
-				return
-			}
-		}
-		for _, ss := range me.XMLIncludedSchemas {
-			if el = ss.globalElement(bag, name); el != nil {
-				return
+//	Returns the global elements declared by the schema documents of this package whose substitutionGroup names the global element el,
+//	matched by namespace and local name (see schemaComponents.elementName), in the order of their schema documents and declarations.
+func (me *PkgBag) globalSubstitutionElems(el *Element) (els []*Element) {
+	head := me.components().elementName(el)
+	for _, sd := range me.Schema.RootSchema([]string{me.Schema.loadUri}).allSchemas(map[string]bool{}) {
+		for _, tle := range sd.globalElements() {
+			if (tle != el) && (len(tle.SubstitutionGroup) > 0) && (sd.qname(tle.SubstitutionGroup.String()) == head) {
+				els = append(els, tle)
 			}
 		}
 	}
-	el = nil
 	return
 }
 
-func (me *Schema) globalSubstitutionElems(el *Element, loadedSchemas map[string]bool) (els []*Element) {
-	var elName = el.Ref.String()
-	if len(elName) == 0 {
-		elName = el.Name.String()
+//	Calls PkgGen.MakeGoPkgSrcFile for this schema.
+func (me *Schema) MakeGoPkgSrcFile() (goOutFilePath string, diags Diagnostics, err error) {
+	return PkgGen.MakeGoPkgSrcFile(me)
+}
+
+//	Calls PkgGen.MakeGoPkgSrcFileAt for this schema.
+func (me *Schema) MakeGoPkgSrcFileAt(goOutDirPath, goPkgName string) (goOutFilePath string, diags Diagnostics, err error) {
+	return PkgGen.MakeGoPkgSrcFileAt(me, goOutDirPath, goPkgName)
+}
+
+//	Calls PkgGen.GenerateGoSource for this schema.
+func (me *Schema) GenerateGoSource() (srcs map[string][]byte, err error) {
+	return PkgGen.GenerateGoSource(me)
+}
+
+//	Calls PkgGen.GenerateGoSourceAs for this schema.
+func (me *Schema) GenerateGoSourceAs(goPkgName string) (srcs map[string][]byte, diags Diagnostics, err error) {
+	return PkgGen.GenerateGoSourceAs(me, goPkgName)
+}
+
+//	Calls PkgGen.MakeGoPkgSrcFiles for this schema.
+func (me *Schema) MakeGoPkgSrcFiles() (goOutFilePaths []string, diags Diagnostics, err error) {
+	return PkgGen.MakeGoPkgSrcFiles(me)
+}
+
+//	Calls PkgGen.MakeGoPkgSrcFilesAt for this schema.
+func (me *Schema) MakeGoPkgSrcFilesAt(goOutDirPath, goPkgName string) (goOutFilePaths []string, diags Diagnostics, err error) {
+	return PkgGen.MakeGoPkgSrcFilesAt(me, goOutDirPath, goPkgName)
+}
+
+//	Generates the Go package for sd into a directory next to the local copy of its XSD file (see MakeGoPkgSrcFileAt).
+func (me *Generator) MakeGoPkgSrcFile(sd *Schema) (goOutFilePath string, diags Diagnostics, err error) {
+	return me.MakeGoPkgSrcFileAt(sd, "", "")
+}
+
+//	Like MakeGoPkgSrcFile, but writes the Go source file into goOutDirPath and names its package goPkgName.
+//	If empty, these default to those in Packages for the target namespace of sd (if any), or else to a directory next to the local copy of
+//	the XSD file (within BaseCodePath rather than PkgGen.BaseCodePath, for schemas downloaded there) and to a name derived from the XSD file name, respectively.
+//	The source is gofmt-formatted (see PruneImports) and generated in a stable order, so regenerating from the same schema produces a byte-identical file.
+//	Should formatting fail, the unformatted source is written anyway (for inspection) and the formatting error returned.
+//	Problems with the schema itself do not abort generation but are returned as diags, which (unlike err) may be non-empty even if the file was written successfully.
+//	Should generation panic, the panic is returned as a SeverityError Diagnostic, both in diags and as err.
+//	If SplitFiles is set, the additional source files are written next to goOutFilePath. Either way, split files previously generated there for the same XSD file are removed.
+//	The same goes for the SourceMap written next to goOutFilePath if AddSourceMap is set, and for the fuzz test file if AddFuzzTests is set.
+//	Source files whose contents did not change are not rewritten. If Cache is set and records goOutFilePath as up to date, nothing is generated at all,
+//	so no diags are returned either (which is why generation with SeverityError diags is never recorded as up to date).
+func (me *Generator) MakeGoPkgSrcFileAt(sd *Schema, goOutDirPath, goPkgName string) (goOutFilePath string, diags Diagnostics, err error) {
+	var keep map[string]bool
+	if keep, err = sd.rootsClosure(me); err == nil {
+		goOutFilePath, diags, err = me.makeGoPkgSrcFileAt(sd, goOutDirPath, goPkgName, keep)
 	}
-	for _, tle := range me.Elements {
-		if (tle != el) && (len(tle.SubstitutionGroup) > 0) {
-			if tle.SubstitutionGroup.String()[(strings.Index(tle.SubstitutionGroup.String(), ":")+1):] == elName {
-				els = append(els, tle)
+	return
+}
+
+//	Implements MakeGoPkgSrcFileAt, generating Go code only for the global components in keep (if not nil, see Schema.rootsClosure).
+func (me *Generator) makeGoPkgSrcFileAt(sd *Schema, goOutDirPath, goPkgName string, keep map[string]bool) (goOutFilePath string, diags Diagnostics, err error) {
+	if opts := me.Packages[sd.TargetNamespace.String()]; opts != nil {
+		goOutDirPath = ustr.Ifs(len(goOutDirPath) > 0, goOutDirPath, opts.Dir)
+	}
+	if len(goOutDirPath) == 0 {
+		goOutDirPath = me.goOutDirPath(sd)
+	}
+	goOutFilePath = filepath.Join(goOutDirPath, sd.goSrcFileName())
+	var srcs map[string][]byte
+	var genErr error
+	var hash string
+	if me.Cache != nil {
+		if hash, err = sd.genCacheHash(me, goOutDirPath, goPkgName, keep); (err != nil) || me.Cache.upToDate(goOutFilePath, hash) {
+			return
+		}
+	}
+	if srcs, diags, genErr = me.generateGoSourceAs(sd, goPkgName, keep); srcs != nil {
+		if err = ufs.EnsureDirExists(goOutDirPath); err == nil {
+			if err = me.writeSourceFile(goOutFilePath, srcs[sd.goSrcFileName()]); err == nil {
+				delete(srcs, sd.goSrcFileName())
+				err = me.writeSplitSources(goOutFilePath, srcs)
 			}
 		}
 	}
-	loadedSchemas[me.loadUri] = true
-	for _, inc := range me.XMLIncludedSchemas {
-		if v, ok := loadedSchemas[inc.loadUri]; ok && v {
-			//fmt.Printf("Ignoring processed schema: %s\n", inc.loadUri)
-			continue
+	if err == nil {
+		err = genErr
+	}
+	if me.Cache != nil {
+		var fileNames []string
+		for fileName, _ := range srcs {
+			fileNames = append(fileNames, fileName)
 		}
-		els = append(els, inc.globalSubstitutionElems(el, loadedSchemas)...)
+		me.Cache.put(goOutFilePath, ustr.Ifs((err == nil) && !diags.HasErrors(), hash, ""), fileNames)
 	}
 	return
 }
 
-func (me *Schema) MakeGoPkgSrcFile() (goOutFilePath string, err error) {
-	var goOutDirPath = filepath.Join(filepath.Dir(me.loadLocalPath), goPkgPrefix+filepath.Base(me.loadLocalPath)+goPkgSuffix)
-	goOutFilePath = filepath.Join(goOutDirPath, path.Base(me.loadUri)+".go")
-	var bag = newPkgBag(me)
+//	Returns the directory that the Go package of sd is generated into by default: next to the local copy of its XSD file, rebased from
+//	PkgGen.BaseCodePath (where downloaded schemas are kept) onto BaseCodePath if that copy is within the former. Schemas without a local copy
+//	(such as those loaded by LoadSchemaReader) are treated as if their local copy was at their load URI within PkgGen.BaseCodePath.
+func (me *Generator) goOutDirPath(sd *Schema) string {
+	var localPath = sd.loadLocalPath
+	if len(localPath) == 0 {
+		localPath = me.fsLocalPath(sd.loadUri)
+	}
+	if rel, err := filepath.Rel(PkgGen.BaseCodePath, localPath); (me != PkgGen) && (len(PkgGen.BaseCodePath) > 0) && (err == nil) && !strings.HasPrefix(rel, "..") {
+		localPath = filepath.Join(me.BaseCodePath, rel)
+	}
+	return filepath.Join(filepath.Dir(localPath), goPkgPrefix+filepath.Base(localPath)+goPkgSuffix)
+}
+
+//	Like MakeGoPkgSrcFile, but returns the generated Go source files in memory, keyed by file name (such as "order.xsd.go" and,
+//	if SplitFiles is set, the split files next to it, if AddSourceMap is set, the SourceMap "order.xsd.go.map.json", and if AddFuzzTests is set, "order.xsd.fuzz_test.go"), rather than writing them to disk. Problems with the schema itself are discarded:
+//	call GenerateGoSourceAs to also obtain them as Diagnostics.
+func (me *Generator) GenerateGoSource(sd *Schema) (srcs map[string][]byte, err error) {
+	srcs, _, err = me.GenerateGoSourceAs(sd, "")
+	return
+}
+
+//	Like GenerateGoSource, but names the generated package goPkgName (see MakeGoPkgSrcFileAt) and also returns the diags of the generation.
+//	Should formatting fail, the unformatted sources are returned along with the formatting error. Otherwise, srcs is nil if err is not.
+func (me *Generator) GenerateGoSourceAs(sd *Schema, goPkgName string) (srcs map[string][]byte, diags Diagnostics, err error) {
+	var keep map[string]bool
+	if keep, err = sd.rootsClosure(me); err == nil {
+		srcs, diags, err = me.generateGoSourceAs(sd, goPkgName, keep)
+	}
+	return
+}
+
+//	Implements GenerateGoSourceAs, generating Go code only for the global components in keep (if not nil, see Schema.rootsClosure).
+//	Generating leaves the schema model as loaded (keeping the names of anonymous types and such in the PkgBag), so that any number of
+//	generations (by Generators of any settings) may run over the same schemas at once.
+func (me *Generator) generateGoSourceAs(sd *Schema, goPkgName string, keep map[string]bool) (srcs map[string][]byte, diags Diagnostics, err error) {
+	if opts := me.Packages[sd.TargetNamespace.String()]; opts != nil {
+		goPkgName = ustr.Ifs(len(goPkgName) > 0, goPkgName, opts.Name)
+	}
+	var bag = newPkgBag(me, sd, goPkgName)
+	var splitSrcs map[string]string
+	bag.keep = keep
+	if err = bag.tmplErr; err != nil {
+		return
+	}
+	defer func() {
+		if r := recover(); r != nil {
+			bag.report(nil, SeverityError, "internal error: %v", r)
+			srcs, err = nil, bag.diags[len(bag.diags)-1]
+		}
+		diags = bag.diags
+	}()
+	sd.collectDuplicates(bag)
+	bag.circularRefs()
 	loadedSchemas := make(map[string]bool)
-	for _, inc := range me.allSchemas(loadedSchemas) {
+	for _, inc := range sd.allSchemas(loadedSchemas) {
 		bag.Schema = inc
 		inc.makePkg(bag)
 	}
-	bag.Schema = me
-	me.hasElemAnnotation.makePkg(bag)
+	bag.Schema = sd
+	sd.hasElemAnnotation.makePkg(bag)
 	bag.appendFmt(true, "")
-	me.makePkg(bag)
-	if err = ufs.EnsureDirExists(filepath.Dir(goOutFilePath)); err == nil {
-		err = ufs.WriteTextFile(goOutFilePath, bag.assembleSource())
+	sd.makePkg(bag)
+	bag.reportIgnoredConstructs()
+	src := bag.assembleSource()
+	if me.SplitFiles {
+		splitSrcs = bag.assembleSplitSources()
+	}
+	if err = bag.tmplErr; err == nil {
+		var fmtErr error
+		srcs = map[string][]byte{}
+		srcs[sd.goSrcFileName()], err = formatSourceBytes(src, me.PruneImports)
+		for fileName, splitSrc := range splitSrcs {
+			if srcs[fileName], fmtErr = formatSourceBytes(splitSrc, true); err == nil {
+				err = fmtErr
+			}
+		}
+		if me.AddSourceMap && (err == nil) {
+			srcs[sd.goSrcFileName()+sourceMapSuffix], err = bag.sourceMap(srcs)
+		}
+		if me.AddFuzzTests && (err == nil) {
+			srcs[sd.goFuzzTestFileName()], err = formatSourceBytes(bag.fuzzTestSource(), false)
+		}
 	}
 	return
 }
 
-func (me *Schema) onLoad(rootAtts []xml.Attr, loadUri, localPath string) (err error) {
-	var tmpUrl string
+//	The name of the main Go source file generated for this schema, such as "order.xsd.go".
+func (me *Schema) goSrcFileName() string {
+	return path.Base(me.loadUri) + ".go"
+}
+
+//	The name of the Go fuzz test file generated for this schema if PkgGen.AddFuzzTests is set, such as "order.xsd.fuzz_test.go".
+func (me *Schema) goFuzzTestFileName() string {
+	return path.Base(me.loadUri) + ".fuzz_test.go"
+}
+
+//	Returns src formatted by formatSource, or else unformatted along with the formatting error.
+func formatSourceBytes(src string, prune bool) ([]byte, error) {
+	formatted, err := formatSource(src, prune)
+	return []byte(ustr.Ifs(err == nil, formatted, src)), err
+}
+
+//	Removes all split files (and the SourceMap) previously generated next to goOutFilePath that are not in srcs (keyed by file name), then writes srcs there (see writeSourceFile).
+func (me *Generator) writeSplitSources(goOutFilePath string, srcs map[string][]byte) (err error) {
+	var stale []string
+	if stale, err = filepath.Glob(filepath.Join(filepath.Dir(goOutFilePath), globEscape(strings.TrimSuffix(filepath.Base(goOutFilePath), ".go"))+".*.go")); err == nil {
+		if _, statErr := os.Stat(goOutFilePath + sourceMapSuffix); statErr == nil {
+			stale = append(stale, goOutFilePath+sourceMapSuffix)
+		}
+		for _, filePath := range stale {
+			if _, ok := srcs[filepath.Base(filePath)]; !ok {
+				if err = os.Remove(filePath); err != nil {
+					return
+				}
+			}
+		}
+		for fileName, src := range srcs {
+			if err = me.writeSourceFile(filepath.Join(filepath.Dir(goOutFilePath), fileName), src); err != nil {
+				return
+			}
+		}
+	}
+	return
+}
+
+//	Escapes the characters in s that filepath.Glob would otherwise treat as pattern syntax.
+func globEscape(s string) string {
+	return strings.NewReplacer("*", "[*]", "?", "[?]", "[", "[[]").Replace(s)
+}
+
+//	Calls MakeGoPkgSrcFile on sd and on every schema it (or any of its includes) xs:imports, directly or indirectly,
+//	so that the Go imports in all the generated packages can actually be satisfied.
+//	Imported schemas whose target namespaces are mapped to existing Go packages in ImportPaths are not generated.
+//	The diags of all generated packages are returned together.
+func (me *Generator) MakeGoPkgSrcFiles(sd *Schema) (goOutFilePaths []string, diags Diagnostics, err error) {
+	return me.MakeGoPkgSrcFilesAt(sd, "", "")
+}
+
+//	Like MakeGoPkgSrcFiles, but writes the Go source file of root into goOutDirPath and names its package goPkgName (see MakeGoPkgSrcFileAt).
+func (me *Generator) MakeGoPkgSrcFilesAt(root *Schema, goOutDirPath, goPkgName string) (goOutFilePaths []string, diags Diagnostics, err error) {
+	var goOutFilePath string
+	var pkgDiags Diagnostics
+	var keep map[string]bool
+	var done = map[*Schema]bool{}
+	var todo = []*Schema{root}
+	if keep, err = root.rootsClosure(me); err != nil {
+		return
+	}
+	for sd := root; len(todo) > 0; {
+		if sd, todo = todo[0], todo[1:]; !done[sd] {
+			done[sd] = true
+			if sd == root {
+				goOutFilePath, pkgDiags, err = me.makeGoPkgSrcFileAt(sd, goOutDirPath, goPkgName, keep)
+			} else {
+				goOutFilePath, pkgDiags, err = me.makeGoPkgSrcFileAt(sd, "", "", keep)
+			}
+			if diags = append(diags, pkgDiags...); err != nil {
+				return
+			}
+			goOutFilePaths = append(goOutFilePaths, goOutFilePath)
+			loadedSchemas := make(map[string]bool)
+			for _, inc := range sd.allSchemas(loadedSchemas) {
+				for _, imp := range inc.XMLImportedSchemas {
+					if len(me.ImportPaths[imp.TargetNamespace.String()]) == 0 {
+						todo = append(todo, imp)
+					}
+				}
+			}
+		}
+	}
+	return
+}
+
+func (me *Schema) onLoad(loader *schemaLoader, rootAtts []xml.Attr, loadUri, localPath string) (err error) {
 	var sd *Schema
-	loadedSchemas[loadUri] = me
-	me.loadLocalPath, me.loadUri = localPath, loadUri
+	var cyclic bool
+	loader.pending[loadUri] = me
+	if me.loadLocalPath, me.loadUri = localPath, loadUri; loader.fsUri(loadUri) {
+		me.loadFS, me.loadLocalPath = loader.fsys, loader.gen.fsLocalPath(loadUri)
+	}
+	loader.chain = append(loader.chain, &schemaLink{sd: me})
+	defer func() { loader.chain = loader.chain[:len(loader.chain)-1] }()
 	me.XMLNamespaces = map[string]string{}
 	for _, att := range rootAtts {
 		if att.Name.Space == "xmlns" {
@@ -194,6 +497,14 @@ func (me *Schema) onLoad(rootAtts []xml.Attr, loadUri, localPath string) (err er
 			me.XMLNamespaces[""] = att.Value
 		}
 	}
+	//	A chameleon include (see the xs:include loop below) takes on the target namespace of its includer, as do the unprefixed QName references in it,
+	//	unless it declares a default namespace.
+	if ns := loader.chameleonNs; len(ns) > 0 {
+		if loader.chameleonNs = ""; len(me.XMLNamespaces[""]) == 0 {
+			me.XMLNamespaces[""] = ns
+		}
+		me.TargetNamespace = xsdt.AnyURI(ns)
+	}
 	for k, v := range me.XMLNamespaces {
 		if v == xsdNamespaceUri {
 			me.XSDNamespacePrefix = k
@@ -205,29 +516,115 @@ func (me *Schema) onLoad(rootAtts []xml.Attr, loadUri, localPath string) (err er
 		me.XMLNamespaces["xml"] = "http://www.w3.org/XML/1998/namespace"
 	}
 	me.XMLIncludedSchemas = []*Schema{}
-	for _, inc := range me.Includes {
-		if tmpUrl = inc.SchemaLocation.String(); strings.Index(tmpUrl, protSep) < 0 {
-			tmpUrl = path.Join(path.Dir(loadUri), tmpUrl)
+	for i, inc := range me.Includes {
+		if cyclic, err = loader.follow(me, DependencyInclude, inc.SchemaLocation.String()); err == nil {
+			if sd, err = me.loadRefSchema(loader, inc.SchemaLocation.String(), localPath); (err == nil) && !cyclic && (len(sd.TargetNamespace) == 0) && (len(me.TargetNamespace) > 0) {
+				loader.chameleonNs = me.TargetNamespace.String()
+				sd, err = me.loadPrivateSchema(loader, inc.SchemaLocation.String(), localPath)
+				loader.chameleonNs = ""
+			}
 		}
-		var ok bool
-		var toLoadUri string
-		if pos := strings.Index(tmpUrl, protSep); pos >= 0 {
-			toLoadUri = tmpUrl[pos+len(protSep):]
-		} else {
-			toLoadUri = tmpUrl
+		if loader.gen.hookIncludeResolved(me, DependencyInclude, inc.SchemaLocation.String(), sd, err); err != nil {
+			err = loader.refError(me, sfmt("/include[%d]", i), inc.SchemaLocation.String(), err)
+			return
 		}
-		if sd, ok = loadedSchemas[toLoadUri]; !ok {
-			if sd, err = LoadSchema(tmpUrl, len(localPath) > 0); err != nil {
-				return
-			}
+		if !cyclic {
+			sd.XSDParentSchema = me
+		}
+		me.XMLIncludedSchemas = append(me.XMLIncludedSchemas, sd)
+	}
+	for i, rd := range me.Redefines {
+		if _, err = loader.follow(me, DependencyRedefine, rd.SchemaLocation.String()); err == nil {
+			sd, err = me.loadPrivateSchema(loader, rd.SchemaLocation.String(), localPath)
+		}
+		if loader.gen.hookIncludeResolved(me, DependencyRedefine, rd.SchemaLocation.String(), sd, err); err != nil {
+			err = loader.refError(me, sfmt("/redefine[%d]", i), rd.SchemaLocation.String(), err)
+			return
+		}
+		rd.apply(sd)
+		sd.XSDParentSchema = me
+		me.XMLIncludedSchemas = append(me.XMLIncludedSchemas, sd)
+	}
+	for i, ov := range me.Overrides {
+		if _, err = loader.follow(me, DependencyOverride, ov.SchemaLocation.String()); err == nil {
+			sd, err = me.loadPrivateSchema(loader, ov.SchemaLocation.String(), localPath)
+		}
+		if loader.gen.hookIncludeResolved(me, DependencyOverride, ov.SchemaLocation.String(), sd, err); err != nil {
+			err = loader.refError(me, sfmt("/override[%d]", i), ov.SchemaLocation.String(), err)
+			return
 		}
+		ov.apply(sd)
 		sd.XSDParentSchema = me
 		me.XMLIncludedSchemas = append(me.XMLIncludedSchemas, sd)
 	}
+	me.XMLImportedSchemas = []*Schema{}
+	for i, imp := range me.Imports {
+		if (len(imp.SchemaLocation) > 0) && !loader.importsFromDir(me.loadUri, imp) {
+			if _, err = loader.follow(me, DependencyImport, imp.SchemaLocation.String()); err == nil {
+				sd, err = me.loadRefSchema(loader, imp.SchemaLocation.String(), localPath)
+			}
+			if loader.gen.hookIncludeResolved(me, DependencyImport, imp.SchemaLocation.String(), sd, err); err != nil {
+				err = loader.refError(me, sfmt("/import[%d]", i), imp.SchemaLocation.String(), err)
+				return
+			}
+			imp.schema = sd
+			me.XMLImportedSchemas = append(me.XMLImportedSchemas, sd)
+		}
+	}
 	me.initElement(nil)
 	return
 }
 
+func (me *Schema) loadRefSchema(loader *schemaLoader, schemaLocation, localPath string) (sd *Schema, err error) {
+	var ok bool
+	var tmpUrl, toLoadUri string
+	if err = loader.ctx.Err(); err != nil {
+		return
+	}
+	if tmpUrl = schemaLocation; strings.Index(tmpUrl, protSep) < 0 {
+		tmpUrl = path.Join(path.Dir(me.loadUri), tmpUrl)
+	}
+	if pos := strings.Index(tmpUrl, protSep); pos >= 0 {
+		toLoadUri = tmpUrl[pos+len(protSep):]
+	} else {
+		toLoadUri = tmpUrl
+	}
+	if sd, ok = loader.cached(toLoadUri); !ok {
+		sd, err = loader.loadUri(schemaLocation, me.loadUri, len(localPath) > 0)
+	}
+	return
+}
+
+//	Returns the schema loaded for the xs:import of the specified target namespace, if any.
+func (me *Schema) ImportedSchema(namespace string) *Schema {
+	for _, sd := range me.XMLImportedSchemas {
+		if sd.TargetNamespace.String() == namespace {
+			return sd
+		}
+	}
+	return nil
+}
+
+//	Resolves the xs:imports of the specified schemas that are not yet resolved (as they have no schemaLocation, see LoadWSDL and LoadSchemaDir)
+//	to those among them of the imported target namespace, preferring schemas that are not included by another, reporting each to the Hooks of gen.
+func linkNamespaceImports(gen *Generator, schemas []*Schema) {
+	for _, sd := range schemas {
+		for _, imp := range sd.Imports {
+			if imp.schema == nil {
+				for _, other := range schemas {
+					if (other != sd) && (other.TargetNamespace.String() == imp.Namespace) && ((imp.schema == nil) || ((imp.schema.XSDParentSchema != nil) && (other.XSDParentSchema == nil))) {
+						imp.schema = other
+					}
+				}
+				if imp.schema != nil {
+					sd.XMLImportedSchemas = append(sd.XMLImportedSchemas, imp.schema)
+					gen.hookIncludeResolved(sd, DependencyImport, imp.SchemaLocation.String(), imp.schema, nil)
+				}
+			}
+		}
+	}
+}
+
 func (me *Schema) RootSchema(pathSchemas []string) *Schema {
 	if me.XSDParentSchema != nil {
 		for _, sch := range pathSchemas {
@@ -242,67 +639,263 @@ func (me *Schema) RootSchema(pathSchemas []string) *Schema {
 	return me
 }
 
+//	Removes all schemas from DefaultSchemaCache.
 func ClearLoadedSchemasCache() {
-	loadedSchemas = map[string]*Schema{}
-}
-
-func loadSchema(r io.Reader, loadUri, localPath string) (sd *Schema, err error) {
-	var data []byte
-	var rootAtts []xml.Attr
-	if data, err = ioutil.ReadAll(r); err == nil {
-		var t xml.Token
-		sd = new(Schema)
-		for xd := xml.NewDecoder(bytes.NewReader(data)); err == nil; {
-			if t, err = xd.Token(); err == nil {
-				if startEl, ok := t.(xml.StartElement); ok {
-					rootAtts = startEl.Attr
-					break
-				}
-			}
-		}
-		if err = xml.Unmarshal(data, sd); err == nil {
-			err = sd.onLoad(rootAtts, loadUri, localPath)
+	DefaultSchemaCache.Clear()
+}
+
+//	Applies the xml:base attributes of this schema document (see hasAttrXmlBase) to the schemaLocations of its includes, imports, redefines and overrides.
+func (me *Schema) applyXmlBase() {
+	var resolve = func(loc *hasAttrSchemaLocation, base *hasAttrXmlBase) {
+		if len(loc.SchemaLocation) > 0 {
+			loc.SchemaLocation = xsdt.AnyURI(xmlBaseJoin(xmlBaseJoin(me.XmlBase.String(), base.XmlBase.String()), loc.SchemaLocation.String()))
 		}
-		if err != nil {
-			sd = nil
+		base.XmlBase = ""
+	}
+	for _, inc := range me.Includes {
+		resolve(&inc.hasAttrSchemaLocation, &inc.hasAttrXmlBase)
+	}
+	for _, imp := range me.Imports {
+		resolve(&imp.hasAttrSchemaLocation, &imp.hasAttrXmlBase)
+	}
+	for _, rd := range me.Redefines {
+		resolve(&rd.hasAttrSchemaLocation, &rd.hasAttrXmlBase)
+	}
+	for _, ov := range me.Overrides {
+		resolve(&ov.hasAttrSchemaLocation, &ov.hasAttrXmlBase)
+	}
+	me.XmlBase = ""
+}
+
+//	Resolves the URI reference ref relative to the xml:base base, if any: ref is returned as-is if absolute (or if base is empty), base if ref
+//	is empty, and else ref appended to all of base up to its last slash.
+func xmlBaseJoin(base, ref string) string {
+	if (len(base) == 0) || strings.Contains(ref, protSep) || strings.HasPrefix(ref, "/") {
+		return ref
+	} else if len(ref) == 0 {
+		return base
+	} else if base = base[:strings.LastIndex(base, "/")+1]; strings.Contains(base, protSep) {
+		return base + ref
+	} else if len(base) == 0 {
+		return ref
+	}
+	return path.Clean(base + ref)
+}
+
+//	Decodes the schema document from r in one single streaming pass (see positionRecorder), so that even multi-megabyte schema documents are never held in memory in full.
+func (me *schemaLoader) load(r io.Reader, loadUri, localPath string) (doc *schemaDoc, err error) {
+	var rec = newPositionRecorder(me.gen, r, me.opts.Strict)
+	var sd = new(Schema)
+	if err = xml.NewTokenDecoder(rec).Decode(sd); err == nil {
+		sd.elemPositions = rec.positions
+		sd.applyXmlBase()
+		for _, d := range rec.unknowns {
+			d.File = ustr.Ifs(len(localPath) > 0, localPath, loadUri)
 		}
+		doc = &schemaDoc{sd: sd, rootAtts: rec.rootAtts, uri: loadUri, localPath: localPath, unknowns: rec.unknowns}
 	}
 	return
 }
 
-func loadSchemaFile(filename string, loadUri string) (sd *Schema, err error) {
+func (me *schemaLoader) loadFile(filename string, loadUri string, load docLoader) (err error) {
 	var file *os.File
 	if file, err = os.Open(filename); err == nil {
 		defer file.Close()
-		sd, err = loadSchema(file, loadUri, filename)
+		err = load(file, loadUri, filename)
 	}
 	return
 }
 
+func (me *Generator) openRemoteFile(ctx context.Context, uri string) (rc io.ReadCloser, err error) {
+	rc, _, err = me.openRemoteFileIf(ctx, uri, nil)
+	return
+}
+
+//	Like openRemoteFile, but conditional upon the ETag and Last-Modified values recorded in man (if not nil): then rc is nil if the document was not modified since.
+//	Also returns a manifest of the response (with these values as returned, or as recorded in man if not modified), unless Offline is set.
+func (me *Generator) openRemoteFileIf(ctx context.Context, uri string, man *downloadManifest) (rc io.ReadCloser, fresh *downloadManifest, err error) {
+	var req *http.Request
+	var resp *http.Response
+	var client = me.HttpClient
+	if me.Offline {
+		return nil, nil, &OfflineError{Uri: uri}
+	}
+	fresh = &downloadManifest{Uri: uri, Validated: time.Now().UTC()}
+	if me.Fetch != nil {
+		rc, err = me.Fetch(ctx, uri)
+		return
+	}
+	if client == nil {
+		client = http.DefaultClient
+	}
+	if req, err = http.NewRequestWithContext(ctx, "GET", uri, nil); err == nil {
+		if man != nil {
+			if len(man.ETag) > 0 {
+				req.Header.Set("If-None-Match", man.ETag)
+			}
+			if len(man.LastModified) > 0 {
+				req.Header.Set("If-Modified-Since", man.LastModified)
+			}
+		}
+		if resp, err = client.Do(req); err == nil {
+			if (man != nil) && (resp.StatusCode == http.StatusNotModified) {
+				resp.Body.Close()
+				fresh.ETag, fresh.LastModified = man.ETag, man.LastModified
+			} else if resp.StatusCode >= 300 {
+				resp.Body.Close()
+				err = fmt.Errorf("GET %s: %s", uri, resp.Status)
+			} else {
+				rc, fresh.ETag, fresh.LastModified = resp.Body, resp.Header.Get("ETag"), resp.Header.Get("Last-Modified")
+			}
+		}
+	}
+	return
+}
+
+//	Loads the XML Schema Definition at the specified uri (protocol prefix defaults to http:// if omitted), including all its xs:include'd schemas.
+//	If localCopy is true, the file is only downloaded if it does not yet exist locally (relative to PkgGen.BaseCodePath).
 func LoadSchema(uri string, localCopy bool) (sd *Schema, err error) {
-	var protocol, localPath string
-	var rc io.ReadCloser
+	return LoadSchemaContext(context.Background(), uri, localCopy)
+}
+
+//	Like LoadSchema, but any remote fetches and the recursive resolution of includes are aborted as soon as ctx is done, in which case ctx.Err() is returned.
+//	Anything in the schema documents that this package does not know is silently ignored: see LoadSchemaWithOptions for a strict mode.
+func LoadSchemaContext(ctx context.Context, uri string, localCopy bool) (sd *Schema, err error) {
+	return DefaultSchemaCache.LoadSchema(ctx, uri, localCopy)
+}
 
+//	Loads the XML Schema Definition read from r (such as os.Stdin) as if it had been fetched from baseURI (such as "example.com/schemas/order.xsd",
+//	the protocol prefix defaulting to http:// as for LoadSchema), including all its xs:include'd (and xs:import'ed etc.) schemas: their schemaLocations
+//	are resolved relative to baseURI and loaded as by LoadSchema with localCopy being false, via PkgGen.Resolver (see there), PkgGen.Catalog or downloads.
+//	So with a Resolver serving them from memory (or no includes and imports at all), neither temporary files nor the network are needed.
+//	The schema is named (and cached, and its Go package generated) by baseURI, as if it were a file under PkgGen.BaseCodePath that has no local copy.
+func LoadSchemaReader(r io.Reader, baseURI string) (sd *Schema, err error) {
+	return DefaultSchemaCache.LoadSchemaReader(context.Background(), r, baseURI, LoadOptions{})
+}
+
+//	Like LoadSchemaReader, but reads the XML Schema Definition from src.
+func LoadSchemaBytes(src []byte, baseURI string) (sd *Schema, err error) {
+	return LoadSchemaReader(bytes.NewReader(src), baseURI)
+}
+
+func (me *schemaLoader) loadUri(location, baseUri string, localCopy bool) (sd *Schema, err error) {
+	var doc *schemaDoc
+	if doc, err = me.fetch(location, baseUri, localCopy); err == nil {
+		me.unknowns = append(me.unknowns, doc.unknowns...)
+		if err = doc.sd.onLoad(me, doc.rootAtts, doc.uri, doc.localPath); err == nil {
+			sd = doc.sd
+		}
+	}
+	return
+}
+
+//	Returns the protocol (defaulting to http://) and the protocol-less uri of the schema document at location, relative to baseUri.
+func splitUri(location, baseUri string) (protocol, uri string) {
+	if uri = location; (len(baseUri) > 0) && (strings.Index(uri, protSep) < 0) {
+		uri = path.Join(path.Dir(baseUri), uri)
+	}
 	if pos := strings.Index(uri, protSep); pos < 0 {
 		protocol = "http" + protSep
 	} else {
 		protocol = uri[:pos+len(protSep)]
 		uri = uri[pos+len(protSep):]
 	}
-	if localCopy {
-		if localPath = filepath.Join(PkgGen.BaseCodePath, uri); !ufs.FileExists(localPath) {
-			if err = ufs.EnsureDirExists(filepath.Dir(localPath)); err == nil {
-				err = unet.DownloadFile(protocol+uri, localPath)
+	return
+}
+
+//	Fetches and decodes (but does not yet process) the schema document at location, then has the schema documents it references prefetched.
+//	Reports its progress to PkgGen.Hooks, if any.
+func (me *schemaLoader) fetchUri(location, baseUri string, localCopy bool) (doc *schemaDoc, err error) {
+	var docLocalPath string
+	protocol, uri := splitUri(location, baseUri)
+	loaded := me.gen.hookSchemaLoad(uri)
+	if err = me.openUri(location, baseUri, localCopy, func(r io.Reader, uri, localPath string) (err error) {
+		if docLocalPath = localPath; me.vendored != nil {
+			if r, err = me.vendor(protocol, uri, r); err != nil {
+				return
 			}
 		}
-		if err == nil {
-			if sd, err = loadSchemaFile(localPath, uri); sd != nil {
-				sd.loadLocalPath = localPath
+		doc, err = me.load(r, uri, localPath)
+		return
+	}); err == nil {
+		me.prefetchRefs(doc)
+	}
+	loaded(docLocalPath, err)
+	return
+}
+
+//	Decodes a document read from r that was fetched from the specified protocol-less uri (and, if not empty, stored at localPath).
+type docLoader func(r io.Reader, uri, localPath string) error
+
+//	Fetches the document at location (relative to baseUri) from the directory tree being loaded (see LoadSchemaDir) if it is in there, or from the file system being loaded
+//	(see LoadSchemaFS) if it belongs in there, or else via PkgGen.Resolver, PkgGen.Catalog, the local copy (if localCopy) or a download, and has load decode it.
+func (me *schemaLoader) openUri(location, baseUri string, localCopy bool, load docLoader) (err error) {
+	var localPath string
+	var rc io.ReadCloser
+
+	if err = me.ctx.Err(); err != nil {
+		return
+	}
+	protocol, uri := splitUri(location, baseUri)
+	if filePath, ok := me.dirFile(uri); ok {
+		return me.loadFile(filePath, uri, load)
+	} else if me.inFS(location, baseUri) {
+		return me.loadFSFile(uri, load)
+	}
+	if me.gen.Resolver != nil {
+		if rc, err = me.gen.Resolver.Resolve(location, baseUri); (err == nil) && (rc != nil) {
+			defer rc.Close()
+			if localCopy {
+				localPath = filepath.Join(me.gen.BaseCodePath, uri)
 			}
+			err = load(rc, uri, localPath)
 		}
-	} else if rc, err = unet.OpenRemoteFile(protocol + uri); err == nil {
+		if (err != nil) || (rc != nil) {
+			return
+		}
+	}
+	var remoteUri = protocol + uri
+	if me.gen.Catalog != nil {
+		if mapped, ok := me.gen.Catalog.Lookup(remoteUri); ok {
+			if strings.HasPrefix(mapped, "file"+protSep) || !strings.Contains(mapped, protSep) {
+				var file *os.File
+				if file, err = openCatalogTarget(mapped); err == nil {
+					defer file.Close()
+					if localCopy {
+						localPath = filepath.Join(me.gen.BaseCodePath, uri)
+					}
+					err = load(file, uri, localPath)
+				}
+				return
+			}
+			remoteUri = mapped
+		}
+	}
+	if localCopy {
+		if localPath = filepath.Join(me.gen.BaseCodePath, uri); ufs.FileExists(localPath) {
+			err = me.gen.revalidateLocalCopy(me.ctx, remoteUri, localPath)
+		} else if err = ufs.EnsureDirExists(filepath.Dir(localPath)); err == nil {
+			err = me.gen.downloadFile(me.ctx, remoteUri, localPath, nil)
+		}
+		if err == nil {
+			err = me.gen.verifyLocalCopy(remoteUri, localPath)
+		}
+		if err == nil {
+			err = me.loadFile(localPath, uri, load)
+		}
+	} else if rc, err = me.gen.openRemoteFile(me.ctx, remoteUri); err == nil {
 		defer rc.Close()
-		sd, err = loadSchema(rc, uri, "")
+		if me.gen.checksummed(remoteUri) {
+			var raw []byte
+			if raw, err = ioutil.ReadAll(rc); err == nil {
+				hash := sha256.Sum256(raw)
+				if err = me.gen.Checksums.verify(remoteUri, "", hex.EncodeToString(hash[:])); err == nil {
+					err = load(bytes.NewReader(raw), uri, "")
+				}
+			}
+		} else {
+			err = load(rc, uri, "")
+		}
 	}
 	return
 }
@@ -0,0 +1,225 @@
+package xsd
+
+import (
+	"context"
+	"go/parser"
+	"go/token"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestGlobalComplexTypeByNamespace(t *testing.T) {
+	src, diags := genTestSrc(t, "prefixed", "main.xsd", nil)
+	for _, d := range diags {
+		if d.Severity == SeverityError {
+			t.Error(d)
+		}
+	}
+	if !regexp.MustCompile(`\sItem\s+\*TItem\s`).MatchString(src) {
+		t.Errorf("the element of the included complex type Item is not a pointer:\n%s", src)
+	}
+}
+
+func TestSubstitutionGroupByNamespace(t *testing.T) {
+	src, _ := genTestSrc(t, "substitution", "main.xsd", nil)
+	if decl := goTypeDecl(t, src, "XsdGoPkgHasElem_Shape"); !strings.Contains(decl, "XsdGoPkgHasElem_Square") || strings.Contains(decl, "XsdGoPkgHasElem_Circle") {
+		t.Errorf("the substitution group of shape should have square, but not circle (of lib:shape), as its member:\n%s", decl)
+	}
+}
+
+func TestImportedSchemaPkgIsImported(t *testing.T) {
+	_, goOutFilePaths := genTestPkgs(t, "imported", nil)
+	var names []string
+	for _, goOutFilePath := range goOutFilePaths {
+		names = append(names, filepath.Base(goOutFilePath))
+		if filepath.Base(goOutFilePath) == "main.xsd.go" {
+			if src, err := ioutil.ReadFile(goOutFilePath); err != nil {
+				t.Fatal(err)
+			} else if !(strings.Contains(string(src), `lib "xsdtest/imported/lib.xsd_go"`) && strings.Contains(string(src), "lib.TItem")) {
+				t.Errorf("the package of main.xsd does not refer to the one of its import lib.xsd:\n%s", src)
+			}
+		}
+	}
+	if strings.Join(names, " ") != "lib.xsd.go main.xsd.go" {
+		t.Errorf("expected the packages of both main.xsd and its import lib.xsd, got %v", names)
+	}
+}
+
+func TestHttpClientLoadsRemoteSchemas(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer xsd" {
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		http.ServeFile(w, r, filepath.Join("testdata", "resolver", path.Base(r.URL.Path)))
+	}))
+	defer srv.Close()
+	opts := DefaultGenOptions()
+	if _, err := NewSchemaCache(0).LoadSchemaWithOptions(context.Background(), srv.URL+"/root.xsd", false, LoadOptions{Generator: NewGenerator(opts)}); err == nil {
+		t.Fatal("expected the unauthorized download to fail")
+	}
+	opts.HttpClient = &http.Client{Transport: authTransport("Bearer xsd")}
+	sd, err := NewSchemaCache(0).LoadSchemaWithOptions(context.Background(), srv.URL+"/root.xsd", false, LoadOptions{Generator: NewGenerator(opts)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(sd.XMLIncludedSchemas) != 1 {
+		t.Error("the include was not downloaded via the HttpClient")
+	}
+}
+
+func TestFetchLoadsRemoteSchemas(t *testing.T) {
+	var fetched []string
+	opts := DefaultGenOptions()
+	opts.HttpClient = &http.Client{Transport: authTransport("")}
+	opts.Fetch = func(ctx context.Context, uri string) (io.ReadCloser, error) {
+		fetched = append(fetched, uri)
+		return os.Open(filepath.Join("testdata", "resolver", path.Base(uri)))
+	}
+	if _, err := NewSchemaCache(0).LoadSchemaWithOptions(context.Background(), "fetch.example.com/schemas/root.xsd", false, LoadOptions{Generator: NewGenerator(opts)}); err != nil {
+		t.Fatal(err)
+	}
+	if (len(fetched) != 2) || (fetched[0] != "http://fetch.example.com/schemas/root.xsd") || (fetched[1] != "http://fetch.example.com/schemas/part.xsd") {
+		t.Errorf("unexpected Fetch calls: %q", fetched)
+	}
+}
+
+//	An http.RoundTripper adding its value as the Authorization header to all requests, or failing them if it is empty.
+type authTransport string
+
+func (me authTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if len(me) == 0 {
+		return nil, &OfflineError{Uri: req.URL.String()}
+	}
+	req = req.Clone(req.Context())
+	req.Header.Set("Authorization", string(me))
+	return http.DefaultTransport.RoundTrip(req)
+}
+
+func TestGenerateGoSourceWritesNothing(t *testing.T) {
+	dirPath := t.TempDir()
+	if err := copyTestdata(filepath.Join("testdata", "validate"), dirPath); err != nil {
+		t.Fatal(err)
+	}
+	set, err := NewSchemaCache(0).LoadSchemaDir(context.Background(), dirPath, LoadOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	opts := DefaultGenOptions()
+	opts.SplitFiles = true
+	srcs, err := NewGenerator(opts).GenerateGoSource(set.Schemas[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(srcs) != 4 {
+		t.Errorf("expected the main file and 3 split files, got %d files", len(srcs))
+	}
+	for fileName, src := range srcs {
+		if f, err := parser.ParseFile(token.NewFileSet(), fileName, src, parser.PackageClauseOnly); err != nil {
+			t.Error(err)
+		} else if f.Name.Name != "go_Order" {
+			t.Errorf("%s: unexpected package %s", fileName, f.Name.Name)
+		}
+	}
+	if fileInfos, err := ioutil.ReadDir(dirPath); (err != nil) || (len(fileInfos) != 1) {
+		t.Errorf("expected nothing written next to order.xsd (%v)", err)
+	}
+}
+
+func TestDownloadTTLRevalidatesLocalCopies(t *testing.T) {
+	var downloads, revalidations int
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if w.Header().Set("ETag", `"v1"`); r.Header.Get("If-None-Match") == `"v1"` {
+			revalidations++
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		downloads++
+		http.ServeFile(w, r, filepath.Join("testdata", "resolver", path.Base(r.URL.Path)))
+	}))
+	defer srv.Close()
+	codePath := t.TempDir()
+	load := func(ttl time.Duration, offline bool) error {
+		opts := DefaultGenOptions()
+		opts.BaseCodePath, opts.DownloadTTL, opts.Offline = codePath, ttl, offline
+		_, err := NewSchemaCache(0).LoadSchemaWithOptions(context.Background(), srv.URL+"/root.xsd", true, LoadOptions{Generator: NewGenerator(opts)})
+		return err
+	}
+	for i, c := range []struct {
+		ttl                      time.Duration
+		offline                  bool
+		downloads, revalidations int
+		offlineError             bool
+	}{
+		{time.Hour, false, 2, 0, false},
+		{time.Hour, false, 2, 0, false},
+		{0, false, 2, 0, false},
+		{time.Nanosecond, false, 2, 2, false},
+		{0, true, 2, 2, false},
+		{time.Nanosecond, true, 2, 2, true},
+	} {
+		err := load(c.ttl, c.offline)
+		if _, isOffline := err.(*OfflineError); (isOffline != c.offlineError) || ((err != nil) && !c.offlineError) {
+			t.Errorf("load %d: unexpected error %v", i, err)
+		}
+		if (downloads != c.downloads) || (revalidations != c.revalidations) {
+			t.Errorf("load %d: expected %d downloads and %d revalidations in total, got %d and %d", i, c.downloads, c.revalidations, downloads, revalidations)
+		}
+	}
+}
+
+func TestChecksumsPinDownloadedSchemas(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.ServeFile(w, r, filepath.Join("testdata", "resolver", path.Base(r.URL.Path)))
+	}))
+	defer srv.Close()
+	codePath, lockFilePath := t.TempDir(), filepath.Join(t.TempDir(), "xsd.lock.json")
+	load := func(codePath string, checksums *Checksums) error {
+		opts := DefaultGenOptions()
+		opts.BaseCodePath, opts.Checksums = codePath, checksums
+		_, err := NewSchemaCache(0).LoadSchemaWithOptions(context.Background(), srv.URL+"/root.xsd", true, LoadOptions{Generator: NewGenerator(opts)})
+		return err
+	}
+	recorded := NewChecksums(lockFilePath)
+	recorded.Record = true
+	if err := load(codePath, recorded); err != nil {
+		t.Fatal(err)
+	} else if err = recorded.Save(); err != nil {
+		t.Fatal(err)
+	}
+	pinned, err := LoadChecksums(lockFilePath)
+	if err != nil {
+		t.Fatal(err)
+	} else if uris := pinned.Uris(); (len(uris) != 2) || (uris[0] != srv.URL+"/part.xsd") || (uris[1] != srv.URL+"/root.xsd") {
+		t.Fatalf("expected the digests of part.xsd and root.xsd recorded, got %v", uris)
+	}
+	if err = load(codePath, pinned); err != nil {
+		t.Errorf("loading the unchanged local copies failed: %v", err)
+	}
+	var partLocalPath string
+	filepath.Walk(codePath, func(filePath string, info os.FileInfo, err error) error {
+		if filepath.Base(filePath) == "part.xsd" {
+			partLocalPath = filePath
+		}
+		return nil
+	})
+	if err = ioutil.WriteFile(partLocalPath, []byte(`<xs:schema xmlns:xs="http://www.w3.org/2001/XMLSchema"/>`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if cerr, _ := load(codePath, pinned).(*ChecksumError); (cerr == nil) || (cerr.Uri != srv.URL+"/part.xsd") || (cerr.LocalPath != partLocalPath) {
+		t.Errorf("expected a *ChecksumError for the changed local copy %s, got %v", partLocalPath, cerr)
+	}
+	pinned.Pin(srv.URL+"/root.xsd", "sha256:"+strings.Repeat("0", 64))
+	if cerr, _ := load(t.TempDir(), pinned).(*ChecksumError); (cerr == nil) || (cerr.Uri != srv.URL+"/root.xsd") || (len(cerr.LocalPath) > 0) {
+		t.Errorf("expected a *ChecksumError for the downloaded root.xsd, got %v", cerr)
+	}
+}
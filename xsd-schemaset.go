@@ -0,0 +1,296 @@
+package xsd
+
+import (
+	"context"
+	"encoding/xml"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/metaleap/go-util-fs"
+	"github.com/metaleap/go-util-str"
+)
+
+//	A set of independently loaded root schemas, such as by LoadSchemaDir or Load, that share a SchemaCache (so that schema documents
+//	included or imported by several of them are loaded only once) and are generated together by MakeGoPkgSrcFiles.
+type SchemaSet struct {
+	//	The root schemas of the set, in the order added. For LoadSchemaDir, these are those of its schema documents that are not included
+	//	(or redefined or overridden) by another one, sorted by their file paths. Schema documents they include are reachable via XMLIncludedSchemas.
+	Schemas []*Schema
+
+	//	The cache that Load loads into and takes shared schema documents from. If nil, DefaultSchemaCache is used.
+	Cache *SchemaCache
+
+	//	Maps target namespaces to the Go packages generated for them by MakeGoPkgSrcFiles, taking precedence over the Packages of Generator.
+	Packages map[string]*GoPkgOptions
+
+	//	The Generator whose settings MakeGoPkgSrcFiles and MakeGoModule generate with. If nil, PkgGen is used.
+	Generator *Generator
+}
+
+//	Returns a new, empty SchemaSet with a new SchemaCache of its own.
+func NewSchemaSet() *SchemaSet {
+	return &SchemaSet{Cache: NewSchemaCache(0), Packages: map[string]*GoPkgOptions{}}
+}
+
+//	Adds the specified schemas to the Schemas of this set, except those already in it.
+func (me *SchemaSet) Add(schemas ...*Schema) {
+	for _, sd := range schemas {
+		var found bool
+		for _, known := range me.Schemas {
+			if found = (known == sd); found {
+				break
+			}
+		}
+		if !found {
+			me.Schemas = append(me.Schemas, sd)
+		}
+	}
+}
+
+//	Loads the schema at the specified uri (see LoadSchemaWithOptions) using the Cache of this set, and adds it to the Schemas of this set.
+//	Unless opts specifies a Generator, the load settings of the Generator of this set apply.
+func (me *SchemaSet) Load(ctx context.Context, uri string, localCopy bool, opts LoadOptions) (sd *Schema, err error) {
+	var cache = me.Cache
+	if cache == nil {
+		cache = DefaultSchemaCache
+	}
+	if opts.Generator == nil {
+		opts.Generator = me.generator()
+	}
+	if sd, err = cache.LoadSchemaWithOptions(ctx, uri, localCopy, opts); err == nil {
+		me.Add(sd)
+	}
+	return
+}
+
+//	Generates one Go package per target namespace of the Schemas of this set and of all the schemas they (or any of their includes) xs:import,
+//	directly or indirectly, except for namespaces mapped to existing Go packages in the ImportPaths of its Generator, and returns the paths of the main Go
+//	source files written. Unlike calling MakeGoPkgSrcFiles on each of the Schemas in turn, every schema is generated only once. Should several
+//	of them declare the same target namespace, they are generated together into the package of the first one (as if it included the others),
+//	so that the components of the schema documents they all include are generated only once, and all packages importing that namespace
+//	import that one package. The Packages of this set take precedence over those of its Generator while generating (which is not modified,
+//	as a copy of it generates). The diags of all generated packages are returned together.
+func (me *SchemaSet) MakeGoPkgSrcFiles() (goOutFilePaths []string, diags Diagnostics, err error) {
+	var namespaces []string
+	var byNamespace = map[string][]*Schema{}
+	var gen = *me.generator()
+	for _, sd := range me.pkgSchemas() {
+		ns := sd.TargetNamespace.String()
+		if len(byNamespace[ns]) == 0 {
+			namespaces = append(namespaces, ns)
+		}
+		byNamespace[ns] = append(byNamespace[ns], sd)
+	}
+	gen.Packages = map[string]*GoPkgOptions{}
+	for ns, opts := range me.generator().Packages {
+		gen.Packages[ns] = opts
+	}
+	for ns, opts := range me.Packages {
+		gen.Packages[ns] = opts
+	}
+	for _, ns := range namespaces {
+		if sds := byNamespace[ns]; len(sds) > 1 {
+			var opts GoPkgOptions
+			if gen.Packages[ns] != nil {
+				opts = *gen.Packages[ns]
+			}
+			if (len(opts.ImportPath) == 0) && (len(opts.Dir) == 0) {
+				opts.ImportPath = path.Join(gen.BasePath, path.Dir(sds[0].loadUri), goPkgPrefix+path.Base(sds[0].loadUri)+goPkgSuffix)
+			}
+			gen.Packages[ns] = &opts
+		}
+	}
+	for _, ns := range namespaces {
+		var goOutFilePath string
+		var pkgDiags Diagnostics
+		goOutFilePath, pkgDiags, err = gen.makeMergedGoPkgSrcFile(byNamespace[ns])
+		if diags = append(diags, pkgDiags...); err != nil {
+			return
+		}
+		goOutFilePaths = append(goOutFilePaths, goOutFilePath)
+	}
+	return
+}
+
+//	Returns the Schemas of this set and all the schemas they (or any of their includes) xs:import, directly or indirectly, in breadth-first order,
+//	except those included by another one of them and those of namespaces mapped to existing Go packages in the ImportPaths of its Generator.
+func (me *SchemaSet) pkgSchemas() (schemas []*Schema) {
+	var done, included = map[*Schema]bool{}, map[*Schema]bool{}
+	var all []*Schema
+	var todo = append([]*Schema{}, me.Schemas...)
+	for sd := (*Schema)(nil); len(todo) > 0; {
+		if sd, todo = todo[0], todo[1:]; !done[sd] {
+			done[sd], all = true, append(all, sd)
+			for _, inc := range sd.allSchemas(map[string]bool{}) {
+				if inc != sd {
+					included[inc] = true
+				}
+				for _, imp := range inc.XMLImportedSchemas {
+					if len(me.generator().ImportPaths[imp.TargetNamespace.String()]) == 0 {
+						todo = append(todo, imp)
+					}
+				}
+			}
+		}
+	}
+	for _, sd := range all {
+		if !included[sd] {
+			schemas = append(schemas, sd)
+		}
+	}
+	return
+}
+
+//	Returns the Generator of this set, or else PkgGen.
+func (me *SchemaSet) generator() *Generator {
+	if me.Generator != nil {
+		return me.Generator
+	}
+	return PkgGen
+}
+
+//	Calls MakeGoPkgSrcFile on the first of the specified schemas (all of the same target namespace) as if it also included all the others.
+func (me *Generator) makeMergedGoPkgSrcFile(schemas []*Schema) (goOutFilePath string, diags Diagnostics, err error) {
+	var sd, others = schemas[0], schemas[1:]
+	var origIncludes, origParents = sd.XMLIncludedSchemas, make([]*Schema, len(others))
+	sd.XMLIncludedSchemas = append(append([]*Schema{}, origIncludes...), others...)
+	for i, other := range others {
+		origParents[i], other.XSDParentSchema = other.XSDParentSchema, sd
+	}
+	defer func() {
+		sd.XMLIncludedSchemas = origIncludes
+		for i, other := range others {
+			other.XSDParentSchema = origParents[i]
+		}
+	}()
+	return me.MakeGoPkgSrcFile(sd)
+}
+
+//	Loads every .xsd file in the directory tree at dir (such as a vendored folder of schemas that has no single root schema), with all the
+//	schema documents they xs:include and xs:import. Relative schemaLocations are resolved within the directory tree, and xs:imports of a
+//	target namespace declared by some .xsd file in the tree are resolved to that file whenever their schemaLocation is missing or refers
+//	to a file not in the tree (such as a remote copy of it), preferring files that are not included by another. Any other schemaLocation
+//	is loaded as usual, with localCopy being true (see LoadSchema).
+//	The schemas are named by their paths relative to PkgGen.BaseCodePath if dir is inside it, or else relative to the parent directory of
+//	dir. (Either way, the Go package for each is generated next to its .xsd file, but the Go import paths between generated packages
+//	are correct only in the former case, unless mapped via PkgGen.Packages or PkgGen.ImportPaths.)
+func LoadSchemaDir(dir string) (set *SchemaSet, err error) {
+	return DefaultSchemaCache.LoadSchemaDir(context.Background(), dir, LoadOptions{})
+}
+
+//	Like LoadSchemaDir, but uses (and populates) this cache instead of DefaultSchemaCache, aborts as soon as ctx is done and loads according to opts.
+func (me *SchemaCache) LoadSchemaDir(ctx context.Context, dir string, opts LoadOptions) (set *SchemaSet, err error) {
+	var cancel context.CancelFunc
+	var filePaths []string
+	var all []*Schema
+	ctx, cancel = context.WithCancel(ctx)
+	defer cancel()
+	loader := newSchemaLoader(ctx, me, opts)
+	if loader.dirPath, err = filepath.Abs(dir); err != nil {
+		return
+	}
+	if rel, relErr := filepath.Rel(loader.gen.BaseCodePath, loader.dirPath); (len(loader.gen.BaseCodePath) > 0) && (relErr == nil) && !strings.HasPrefix(rel, "..") {
+		loader.dirUri = ustr.Ifs(rel == ".", "", filepath.ToSlash(rel))
+	} else {
+		loader.dirUri = filepath.Base(loader.dirPath)
+	}
+	loader.dirNamespaces = map[string]bool{}
+	if err = filepath.Walk(loader.dirPath, func(filePath string, info os.FileInfo, err error) error {
+		if (err == nil) && info.Mode().IsRegular() && (strings.ToLower(filepath.Ext(filePath)) == ".xsd") {
+			var ns string
+			if ns, err = scanTargetNamespace(filePath); err == nil {
+				loader.dirNamespaces[ns], filePaths = true, append(filePaths, filePath)
+			}
+		}
+		return err
+	}); err != nil {
+		return
+	}
+	for _, filePath := range filePaths {
+		var sd *Schema
+		var ok bool
+		uri := loader.dirFileUri(filePath)
+		if sd, ok = loader.cached(uri); !ok {
+			if sd, err = loader.loadUri(uri, "", true); err != nil {
+				return
+			}
+		}
+		all = append(all, sd)
+	}
+	linkNamespaceImports(loader.gen, all)
+	set = &SchemaSet{Cache: me, Packages: map[string]*GoPkgOptions{}}
+	for _, sd := range all {
+		if sd.XSDParentSchema == nil {
+			set.Schemas = append(set.Schemas, sd)
+		}
+	}
+	if err = loader.strictError(set.Schemas); err != nil {
+		return nil, err
+	}
+	for pendingUri, pendingSchema := range loader.pending {
+		me.Put(pendingUri, pendingSchema)
+	}
+	return
+}
+
+//	Returns the value of the targetNamespace attribute of the root element of the XML document at filePath, reading no further than its start tag.
+func scanTargetNamespace(filePath string) (ns string, err error) {
+	var file *os.File
+	var tok xml.Token
+	if file, err = os.Open(filePath); err == nil {
+		defer file.Close()
+		for xd := xml.NewDecoder(file); err == nil; {
+			if tok, err = xd.RawToken(); err == nil {
+				if start, ok := tok.(xml.StartElement); ok {
+					for _, att := range start.Attr {
+						if (len(att.Name.Space) == 0) && (att.Name.Local == "targetNamespace") {
+							ns = att.Value
+						}
+					}
+					return
+				}
+			} else if err == io.EOF {
+				err = nil
+				return
+			}
+		}
+	}
+	return
+}
+
+//	Returns the protocol-less uri of the file at filePath in the directory tree being loaded (see LoadSchemaDir).
+func (me *schemaLoader) dirFileUri(filePath string) string {
+	rel, _ := filepath.Rel(me.dirPath, filePath)
+	return path.Join(me.dirUri, filepath.ToSlash(rel))
+}
+
+//	Returns the path of the file in the directory tree being loaded (see LoadSchemaDir) for the specified protocol-less uri, if it exists.
+func (me *schemaLoader) dirFile(uri string) (filePath string, ok bool) {
+	if me.dirNamespaces != nil {
+		rel := uri
+		if len(me.dirUri) > 0 {
+			if !strings.HasPrefix(uri, me.dirUri+"/") {
+				return
+			}
+			rel = uri[len(me.dirUri)+1:]
+		}
+		if !strings.HasPrefix(rel, "../") {
+			filePath = filepath.Join(me.dirPath, filepath.FromSlash(rel))
+			ok = ufs.FileExists(filePath)
+		}
+	}
+	return
+}
+
+//	Returns whether the xs:import imp of the schema loaded from baseUri is to be resolved by its target namespace (see LoadSchemaDir) rather than by loading its schemaLocation.
+func (me *schemaLoader) importsFromDir(baseUri string, imp *Import) bool {
+	if me.dirNamespaces[imp.Namespace] {
+		_, uri := splitUri(imp.SchemaLocation.String(), baseUri)
+		_, ok := me.dirFile(uri)
+		return !ok
+	}
+	return false
+}
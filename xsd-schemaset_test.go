@@ -0,0 +1,46 @@
+package xsd
+
+import (
+	"context"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestLoadSchemaDirWiresImportsByNamespace(t *testing.T) {
+	set, err := NewSchemaCache(0).LoadSchemaDir(context.Background(), filepath.Join("testdata", "schemadir"), LoadOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for _, sd := range set.Schemas {
+		names = append(names, sd.loadUri)
+	}
+	if (len(names) != 2) || (names[0] != "schemadir/common/money.xsd") || (names[1] != "schemadir/orders/order.xsd") {
+		t.Fatalf("expected the root schemas money.xsd and order.xsd (but not the included currency.xsd), got %v", names)
+	}
+	if incs := set.Schemas[0].XMLIncludedSchemas; (len(incs) != 1) || (incs[0].loadUri != "schemadir/common/currency.xsd") {
+		t.Errorf("the include of currency.xsd was not resolved within the tree: %v", incs)
+	}
+	if imps := set.Schemas[1].XMLImportedSchemas; (len(imps) != 1) || (imps[0] != set.Schemas[0]) {
+		t.Errorf("the import of the remote money.xsd was not resolved to the one in the tree: %v", imps)
+	}
+}
+
+func TestSchemaSetGeneratesEachNamespaceOnce(t *testing.T) {
+	gopath, goOutFilePaths := genTestPkgs(t, "multiroot", nil)
+	if len(goOutFilePaths) != 2 {
+		t.Fatalf("expected the packages of common.xsd and of both shop schemas, got %v", goOutFilePaths)
+	}
+	if src, err := ioutil.ReadFile(goOutFilePaths[1]); err != nil {
+		t.Fatal(err)
+	} else if filepath.Base(goOutFilePaths[1]) != "invoice.xsd.go" {
+		t.Errorf("expected the shop schemas to be generated into the package of invoice.xsd, got %s", goOutFilePaths[1])
+	} else if s := string(src); !(strings.Contains(s, "\ntype TInvoice struct") && strings.Contains(s, "\ntype TOrder struct") && strings.Contains(s, `"xsdtest/multiroot/common.xsd_go"`)) {
+		t.Errorf("the package of invoice.xsd does not declare TInvoice and TOrder and import the package of common.xsd:\n%s", s)
+	}
+	for _, goOutFilePath := range goOutFilePaths {
+		goTool(t, gopath, goOutFilePath, "build")
+	}
+}
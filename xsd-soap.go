@@ -0,0 +1,291 @@
+package xsd
+
+import (
+	"bytes"
+	"encoding/xml"
+	"strings"
+
+	"github.com/metaleap/go-util-str"
+
+	xsdt "github.com/metaleap/go-xsd/types"
+)
+
+const (
+	wsdlSoap11Uri = "http://schemas.xmlsoap.org/wsdl/soap/"
+	wsdlSoap12Uri = "http://schemas.xmlsoap.org/wsdl/soap12/"
+)
+
+//	An operation of a document/literal SOAP binding of a WSDL 1.1 document loaded by LoadWSDL (see Schema.SoapOperations), whose request and response
+//	wrapper types and CallXyz() function are generated into the Go package of the schema declaring its request element (see PkgGen.AddSoapOperations).
+type SoapOperation struct {
+	//	The names of the operation, and of the wsdl:binding and wsdl:portType it belongs to.
+	Name, Binding, PortType string
+
+	//	The soapAction of the operation, if any.
+	Action string
+
+	//	The envelope namespace of the SOAP version of the binding: xsdt.Soap11 or xsdt.Soap12.
+	Version string
+
+	//	The global elements of the body parts of the input and output messages of the operation. Output is empty for one-way operations.
+	Input, Output xml.Name
+
+	//	The soap:address locations of the wsdl:ports of wsdl:services that refer to the binding.
+	Endpoints []string
+}
+
+//	The service description of a WSDL 1.1 document, as far as needed for its SoapOperations. Elements are matched by local name only.
+type wsdlDefinitions struct {
+	TargetNamespace string     `xml:"targetNamespace,attr"`
+	Attrs           []xml.Attr `xml:",any,attr"`
+	Messages        []struct {
+		Name  string `xml:"name,attr"`
+		Parts []struct {
+			Name    string `xml:"name,attr"`
+			Element string `xml:"element,attr"`
+		} `xml:"part"`
+	} `xml:"message"`
+	PortTypes []struct {
+		Name       string `xml:"name,attr"`
+		Operations []struct {
+			Name   string          `xml:"name,attr"`
+			Input  *wsdlMessageRef `xml:"input"`
+			Output *wsdlMessageRef `xml:"output"`
+		} `xml:"operation"`
+	} `xml:"portType"`
+	Bindings []struct {
+		Name string `xml:"name,attr"`
+		Type string `xml:"type,attr"`
+		Soap *struct {
+			XMLName xml.Name
+			Style   string `xml:"style,attr"`
+		} `xml:"binding"`
+		Operations []struct {
+			Name string `xml:"name,attr"`
+			Soap *struct {
+				Action string `xml:"soapAction,attr"`
+				Style  string `xml:"style,attr"`
+			} `xml:"operation"`
+			Input  *wsdlSoapBody `xml:"input>body"`
+			Output *wsdlSoapBody `xml:"output>body"`
+		} `xml:"operation"`
+	} `xml:"binding"`
+	Services []struct {
+		Ports []struct {
+			Binding string `xml:"binding,attr"`
+			Address *struct {
+				Location string `xml:"location,attr"`
+			} `xml:"address"`
+		} `xml:"port"`
+	} `xml:"service"`
+}
+
+type wsdlMessageRef struct {
+	Message string `xml:"message,attr"`
+}
+
+type wsdlSoapBody struct {
+	Use   string `xml:"use,attr"`
+	Parts string `xml:"parts,attr"`
+}
+
+//	Decodes the service description of the WSDL 1.1 document raw. Returns nil if it cannot be decoded, as only its schemas are essential.
+func (me *Generator) parseWsdlDefinitions(raw []byte) (defs *wsdlDefinitions) {
+	defs = &wsdlDefinitions{}
+	if me.newSchemaDecoder(bytes.NewReader(raw)).Decode(defs) != nil {
+		defs = nil
+	}
+	return
+}
+
+//	Resolves the "prefix:local" QName ref against the namespace declarations of the wsdl:definitions element.
+func (me *wsdlDefinitions) qname(ref string) (qn xml.Name) {
+	var prefix string
+	if qn.Local = ref; strings.Contains(ref, ":") {
+		prefix, qn.Local = ref[:strings.Index(ref, ":")], ref[strings.Index(ref, ":")+1:]
+	}
+	for _, att := range me.Attrs {
+		if ((len(prefix) > 0) && (att.Name.Space == "xmlns") && (att.Name.Local == prefix)) || ((len(prefix) == 0) && (len(att.Name.Space) == 0) && (att.Name.Local == "xmlns")) {
+			qn.Space = att.Value
+		}
+	}
+	return
+}
+
+//	Returns the SoapOperations of the document/literal SOAP bindings of all defs (that is, of a WSDL document and all the WSDL documents it imports),
+//	in document order. Bindings, port types and messages may refer to those of other defs. Operations of other styles, operations with encoded bodies
+//	and operations whose input or output messages have other than a single body part referring to a global element are skipped.
+func soapOperations(defs []*wsdlDefinitions) (ops []*SoapOperation) {
+	var bodyElem = func(ref string, body *wsdlSoapBody) (qn xml.Name, ok bool) {
+		var local = ref[strings.Index(ref, ":")+1:]
+		var parts int
+		for _, d := range defs {
+			for _, msg := range d.Messages {
+				if msg.Name == local {
+					for _, part := range msg.Parts {
+						if (body == nil) || (len(body.Parts) == 0) || (" "+body.Parts+" " == " "+part.Name+" ") {
+							qn, parts = d.qname(part.Element), parts+1
+						}
+					}
+					return qn, (parts == 1) && (len(qn.Local) > 0)
+				}
+			}
+		}
+		return
+	}
+	for _, d := range defs {
+		for _, b := range d.Bindings {
+			if (b.Soap == nil) || ((b.Soap.XMLName.Space != wsdlSoap11Uri) && (b.Soap.XMLName.Space != wsdlSoap12Uri)) {
+				continue
+			}
+			var endpoints []string
+			for _, dd := range defs {
+				for _, svc := range dd.Services {
+					for _, port := range svc.Ports {
+						if (port.Address != nil) && (port.Binding[strings.Index(port.Binding, ":")+1:] == b.Name) {
+							endpoints = append(endpoints, port.Address.Location)
+						}
+					}
+				}
+			}
+			portType := b.Type[strings.Index(b.Type, ":")+1:]
+			for _, bop := range b.Operations {
+				op, style := &SoapOperation{Name: bop.Name, Binding: b.Name, PortType: portType, Version: xsdt.Soap11, Endpoints: endpoints}, b.Soap.Style
+				if b.Soap.XMLName.Space == wsdlSoap12Uri {
+					op.Version = xsdt.Soap12
+				}
+				if bop.Soap != nil {
+					if op.Action = bop.Soap.Action; len(bop.Soap.Style) > 0 {
+						style = bop.Soap.Style
+					}
+				}
+				if ((len(style) > 0) && (style != "document")) || ((bop.Input != nil) && (bop.Input.Use == "encoded")) || ((bop.Output != nil) && (bop.Output.Use == "encoded")) {
+					continue
+				}
+				found, ok := false, false
+				for _, dd := range defs {
+					for _, pt := range dd.PortTypes {
+						if pt.Name == portType {
+							for _, ptop := range pt.Operations {
+								if (ptop.Name == bop.Name) && (ptop.Input != nil) && !found {
+									found = true
+									if op.Input, ok = bodyElem(ptop.Input.Message, bop.Input); ok && (ptop.Output != nil) {
+										op.Output, ok = bodyElem(ptop.Output.Message, bop.Output)
+									}
+								}
+							}
+						}
+					}
+				}
+				if ok {
+					ops = append(ops, op)
+				}
+			}
+		}
+	}
+	return
+}
+
+//	Adds every SoapOperation of ops to the SoapOperations of the one of schemas (including the schemas it includes) that declares its request element,
+//	unless that schema has an operation of the same name already (such as of a SOAP 1.1 binding, for a SOAP 1.2 binding of the same port type).
+func attachSoapOperations(ops []*SoapOperation, schemas []*Schema) {
+	for _, op := range ops {
+		for _, sd := range schemas {
+			if sd.declaresElement(op.Input, map[string]bool{}) {
+				dupe := false
+				for _, other := range sd.SoapOperations {
+					dupe = dupe || (other.Name == op.Name)
+				}
+				if !dupe {
+					sd.SoapOperations = append(sd.SoapOperations, op)
+				}
+				break
+			}
+		}
+	}
+}
+
+//	Returns whether this schema (or one it includes) declares the global element qn.
+func (me *Schema) declaresElement(qn xml.Name, loadedSchemas map[string]bool) bool {
+	for _, sd := range me.allSchemas(loadedSchemas) {
+		if sd.TargetNamespace.String() == qn.Space {
+			for _, el := range sd.globalElements() {
+				if el.Name.String() == qn.Local {
+					return true
+				}
+			}
+		}
+	}
+	return false
+}
+
+//	Renders, for every operation of Schema.SoapOperations (see PkgGen.AddSoapOperations), the XyzRequest and XyzResponse wrapper types embedding the
+//	XsdGoPkgHasElem_ types of its input and output elements, with MarshalSoap() and UnmarshalSoap() methods, an XyzSoapAction constant, an XyzEndpoint
+//	constant (if the WSDL has a port for its binding) and a CallXyz() function. Operations whose output element has no such type in this package or in an
+//	imported one are skipped.
+func (me *PkgBag) addSoapOperations() {
+	var ctxImp, httpImp string
+	for _, op := range me.Schema.SoapOperations {
+		var resp string
+		req := me.soapElemType(op.Input)
+		if len(op.Output.Local) > 0 {
+			if resp = me.soapElemType(op.Output); len(resp) == 0 {
+				continue
+			}
+		}
+		if len(req) == 0 {
+			continue
+		}
+		name := me.safeName(op.Name)
+		for _, tn := range []string{name + "Request", name + "Response", name + "SoapAction", name + "Endpoint", "Call" + name} {
+			if me.declTypes[tn] != nil {
+				name += "Op"
+				break
+			}
+		}
+		if len(ctxImp) == 0 {
+			ctxImp, httpImp = me.overrideImpName("context"), me.overrideImpName("net/http")
+			me.impsUsed[ctxImp], me.impsUsed[httpImp], me.impsUsed[me.impName] = true, true, true
+		}
+		version := sfmt("%s.Soap%s", me.impName, ustr.Ifs(op.Version == xsdt.Soap12, "12", "11"))
+		desc := sfmt("the %s operation of the %s binding (document/literal, SOAP %s)", op.Name, op.Binding, ustr.Ifs(op.Version == xsdt.Soap12, "1.2", "1.1"))
+		me.appendFmt(false, "//\tThe SOAP action of %s.", desc)
+		me.appendFmt(true, "const %sSoapAction = %#v", name, op.Action)
+		if len(op.Endpoints) > 0 {
+			me.appendFmt(false, "//\tThe endpoint URL of %s, as given by the WSDL.", desc)
+			me.appendFmt(true, "const %sEndpoint = %#v", name, op.Endpoints[0])
+		}
+		for _, wrapper := range [][2]string{{"Request", req}, {"Response", resp}} {
+			if len(wrapper[1]) == 0 {
+				continue
+			}
+			tn := name + wrapper[0]
+			me.appendFmt(false, "//\tThe body of a SOAP %s of %s.", strings.ToLower(wrapper[0]), desc)
+			me.appendFmt(true, "type %s struct {\n\t%s\n}", tn, wrapper[1])
+			me.appendFmt(false, "//\tReturns the SOAP envelope holding this %s.", tn)
+			me.appendFmt(true, "func (me *%s) MarshalSoap () ([]byte, error) {\n\treturn %s.MarshalSoap(%s, me)\n}", tn, me.impName, version)
+			me.appendFmt(false, "//\tDecodes this %s from the SOAP envelope raw, returning an *%s.SoapFault if it holds a fault.", tn, me.impName)
+			me.appendFmt(true, "func (me *%s) UnmarshalSoap (raw []byte) error {\n\treturn %s.UnmarshalSoap(raw, me)\n}", tn, me.impName)
+		}
+		me.appendFmt(false, "//\tCalls %s at the endpoint URL%s via client (or http.DefaultClient if nil).", desc, ustr.Ifs(len(op.Endpoints) > 0, sfmt(" (such as %sEndpoint)", name), ""))
+		me.appendFmt(false, "//\tFaults are returned as *%s.SoapFault errors (see %s.CallSoap).", me.impName, me.impName)
+		if len(resp) > 0 {
+			me.appendFmt(true, "func Call%s (ctx %s.Context, client *%s.Client, endpoint string, req *%sRequest) (resp *%sResponse, err error) {\n\tresp = &%sResponse{}\n\tif err = %s.CallSoap(ctx, client, endpoint, %sSoapAction, %s, req, resp); err != nil {\n\t\tresp = nil\n\t}\n\treturn\n}", name, ctxImp, httpImp, name, name, name, me.impName, name, version)
+		} else {
+			me.appendFmt(true, "func Call%s (ctx %s.Context, client *%s.Client, endpoint string, req *%sRequest) error {\n\treturn %s.CallSoap(ctx, client, endpoint, %sSoapAction, %s, req, nil)\n}", name, ctxImp, httpImp, name, me.impName, name, version)
+		}
+	}
+}
+
+//	Returns the XsdGoPkgHasElem_ type of the global element qn declared by the schema of this package (or by one that it imports), or "" if there is none.
+func (me *PkgBag) soapElemType(qn xml.Name) string {
+	if qn.Space == me.Schema.TargetNamespace.String() {
+		if tn := idPrefix + "HasElem_" + me.safeName(qn.Local); me.declTypes[tn] != nil {
+			return tn
+		}
+	} else if impName := me.nsImps[qn.Space]; len(impName) > 0 {
+		me.impsUsed[impName] = true
+		return impName + "." + idPrefix + "HasElem_" + me.safeNsName(qn.Space, qn.Local)
+	}
+	return ""
+}
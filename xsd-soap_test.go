@@ -0,0 +1,84 @@
+package xsd
+
+import (
+	"context"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"testing"
+
+	xsdt "github.com/metaleap/go-xsd/types"
+)
+
+//	Tests that the document/literal operations of the SOAP bindings of a WSDL are found, and that their generated CallXyz() functions post the SOAP
+//	envelope of their request with the SOAP action, decoding the response envelope or returning the fault received as an *xsdt.SoapFault.
+func TestSoapOperations(t *testing.T) {
+	gopath := t.TempDir()
+	opts := DefaultGenOptions()
+	opts.Offline = true
+	opts.BaseCodePath, opts.BasePath = filepath.Join(gopath, "src", "xsdtest"), "xsdtest"
+	opts.Resolver = SchemaResolverFunc(func(location, baseUri string) (io.ReadCloser, error) {
+		return os.Open(filepath.Join("testdata", "soap", path.Base(location)))
+	})
+	gen := NewGenerator(opts)
+	schemas, err := NewSchemaCache(0).LoadWSDLWithOptions(context.Background(), "soap/prices.wsdl", false, LoadOptions{Generator: gen})
+	if err != nil {
+		t.Fatal(err)
+	} else if (len(schemas) != 1) || (len(schemas[0].SoapOperations) != 1) {
+		t.Fatalf("expected 1 schema with 1 SOAP operation, got %v", schemas)
+	}
+	if op := schemas[0].SoapOperations[0]; (op.Name != "GetPrice") || (op.Action != "urn:example:prices/GetPrice") || (op.Version != xsdt.Soap11) ||
+		(op.Input.Local != "getPrice") || (op.Output.Local != "getPriceResponse") || (len(op.Endpoints) != 1) || (op.Endpoints[0] != "http://prices.example.com/soap") {
+		t.Errorf("unexpected operation %#v", op)
+	}
+	goOutFilePaths, _, err := gen.MakeGoPkgSrcFiles(schemas[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	goTestPkg(t, gopath, goOutFilePaths[0], `package go_Priceswsdltypes1
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	xsdt "github.com/metaleap/go-xsd/types"
+)
+
+func TestCallGetPrice(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		raw, _ := ioutil.ReadAll(r.Body)
+		if r.Header.Get("SOAPAction") != `+"`"+`"urn:example:prices/GetPrice"`+"`"+` {
+			t.Errorf("unexpected SOAPAction %q", r.Header.Get("SOAPAction"))
+		}
+		w.Header().Set("Content-Type", "text/xml")
+		if strings.Contains(string(raw), ">ABC-123</") {
+			w.Write([]byte(`+"`"+`<s:Envelope xmlns:s="http://schemas.xmlsoap.org/soap/envelope/"><s:Body><getPriceResponse xmlns="urn:example:prices"><price>9.99</price></getPriceResponse></s:Body></s:Envelope>`+"`"+`))
+		} else {
+			w.WriteHeader(http.StatusInternalServerError)
+			w.Write([]byte(`+"`"+`<s:Envelope xmlns:s="http://schemas.xmlsoap.org/soap/envelope/"><s:Body><s:Fault><faultcode>s:Client</faultcode><faultstring>unknown sku</faultstring></s:Fault></s:Body></s:Envelope>`+"`"+`))
+		}
+	}))
+	defer server.Close()
+	req := &GetPriceRequest{}
+	req.GetPrice = &TxsdGetPrice{}
+	req.GetPrice.Sku = "ABC-123"
+	resp, err := CallGetPrice(context.Background(), nil, server.URL, req)
+	if err != nil {
+		t.Fatal(err)
+	} else if (resp.GetPriceResponse == nil) || (resp.GetPriceResponse.Price != "9.99") {
+		t.Errorf("expected the price 9.99, got %#v", resp.GetPriceResponse)
+	}
+	req.GetPrice.Sku = "XYZ-000"
+	if _, err = CallGetPrice(context.Background(), nil, server.URL, req); err == nil {
+		t.Fatal("expected a fault")
+	} else if fault, ok := err.(*xsdt.SoapFault); !ok || (fault.Code != "s:Client") || (fault.Reason != "unknown sku") || (fault.HTTPStatus != 500) {
+		t.Errorf("expected the unknown sku fault, got %#v", err)
+	}
+}
+`)
+}
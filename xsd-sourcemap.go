@@ -0,0 +1,137 @@
+package xsd
+
+import (
+	"encoding/json"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"sort"
+	"strings"
+
+	"github.com/metaleap/go-util-str"
+)
+
+//	The suffix appended to the name of the main generated Go source file (such as "order.xsd.go") to name its SourceMap.
+const sourceMapSuffix = ".map.json"
+
+//	The sourcemap generated alongside the Go source files of a package if PkgGen.AddSourceMap is set, named after the main source file
+//	with ".map.json" appended (such as "order.xsd.go.map.json"): it links the type declarations, struct fields and embedded types of the
+//	generated code to the schema constructs they were generated from, so that tools can trace generated code back to schema definitions.
+type SourceMap struct {
+	//	The version of the format of this SourceMap, currently 1.
+	Version int `json:"version"`
+
+	//	One entry per declaration, sorted by GoFile and GoLine.
+	Entries []SourceMapEntry `json:"entries"`
+}
+
+//	Links a declaration of the generated Go code to the schema construct it was generated from (see SourceMap).
+type SourceMapEntry struct {
+	//	The name of the generated Go source file declaring it, such as "order.xsd.go" or, if PkgGen.SplitFiles is set, that of a split file.
+	GoFile string `json:"goFile"`
+
+	//	The line of the declaration in GoFile.
+	GoLine int `json:"goLine"`
+
+	//	"type" for a type declaration, "field" for a struct field, or "embed" for an embedded struct type.
+	Kind string `json:"kind"`
+
+	//	The name of the Go type, or for fields and embeds the names of the struct type and of the field, such as "TOrderType.Items".
+	Name string `json:"name"`
+
+	//	The URI (as loaded) of the schema document declaring the construct.
+	Schema string `json:"schema"`
+
+	//	The position in that schema document right after the start tag of the construct.
+	Line   int `json:"line"`
+	Column int `json:"column"`
+
+	//	The XSD element name of the construct, such as "complexType" or "attribute", and the value of its name attribute, if any.
+	Construct string `json:"construct"`
+	XsdName   string `json:"xsdName,omitempty"`
+}
+
+//	Records el as the schema construct that the Go declaration name (a type name, or "Type.Field") is generated from, for the SourceMap
+//	generated if PkgGen.AddSourceMap is set. Returns the doc comment line noting its schema location if PkgGen.AddSourceComments is set,
+//	or else (or if the location is unknown) "".
+func (me *PkgBag) sourceOf(name string, el element) string {
+	if el == nil {
+		return ""
+	}
+	if me.gen.AddSourceMap && (me.sources[name] == nil) {
+		me.sources[name] = el
+	}
+	if sd, line, col := el.base().position(); me.gen.AddSourceComments && (sd != nil) && (line > 0) {
+		var xsdName, selfName = el.base().xsdName, el.base().selfName()
+		return sfmt("//\tSchema source: %s:%d:%d (xs:%s%s)\n", sd.loadUri, line, col, xsdName, ustr.Ifs(selfName != xsdName, " "+selfName.String(), ""))
+	}
+	return ""
+}
+
+//	Returns the SourceMap (as JSON) of the formatted Go source files srcs (keyed by file name), locating in them the declarations recorded by sourceOf.
+func (me *PkgBag) sourceMap(srcs map[string][]byte) (raw []byte, err error) {
+	var sm = SourceMap{Version: 1, Entries: []SourceMapEntry{}}
+	var fileNames []string
+	for fileName, _ := range srcs {
+		fileNames = append(fileNames, fileName)
+	}
+	sort.Strings(fileNames)
+	for _, fileName := range fileNames {
+		var file *ast.File
+		var fset = token.NewFileSet()
+		if file, err = parser.ParseFile(fset, fileName, srcs[fileName], 0); err != nil {
+			return
+		}
+		add := func(kind, name string, pos token.Pos) {
+			if el := me.sources[name]; el != nil {
+				sd, line, col := el.base().position()
+				entry := SourceMapEntry{GoFile: fileName, GoLine: fset.Position(pos).Line, Kind: kind, Name: name, Line: line, Column: col, Construct: el.base().xsdName.String()}
+				if sd != nil {
+					entry.Schema = sd.loadUri
+				}
+				if selfName := el.base().selfName(); selfName != el.base().xsdName {
+					entry.XsdName = selfName.String()
+				}
+				sm.Entries = append(sm.Entries, entry)
+			}
+		}
+		for _, decl := range file.Decls {
+			if gd, ok := decl.(*ast.GenDecl); ok && (gd.Tok == token.TYPE) {
+				for _, spec := range gd.Specs {
+					ts := spec.(*ast.TypeSpec)
+					add("type", ts.Name.Name, ts.Name.Pos())
+					if st, ok := ts.Type.(*ast.StructType); ok {
+						for _, f := range st.Fields.List {
+							if len(f.Names) == 0 {
+								add("embed", ts.Name.Name+"."+embeddedName(f.Type), f.Pos())
+							}
+							for _, n := range f.Names {
+								add("field", ts.Name.Name+"."+n.Name, n.Pos())
+							}
+						}
+					}
+				}
+			}
+		}
+	}
+	return json.MarshalIndent(&sm, "", "\t")
+}
+
+//	Returns the name of the field declared by an embedded type expression, such as "TAddress" for "addr.TAddress" or "*TAddress".
+func embeddedName(expr ast.Expr) string {
+	switch x := expr.(type) {
+	case *ast.StarExpr:
+		return embeddedName(x.X)
+	case *ast.SelectorExpr:
+		return x.Sel.Name
+	case *ast.Ident:
+		return x.Name
+	}
+	return ""
+}
+
+//	Returns the name of the last identifier of the type spec t (as recorded by sourceOf for embeds), such as "TAddress" for "addr.TAddress".
+func embedFieldName(t string) string {
+	t = strings.TrimLeft(t, "*")
+	return t[strings.LastIndex(t, ".")+1:]
+}
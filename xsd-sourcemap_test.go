@@ -0,0 +1,51 @@
+package xsd
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+//	Tests that PkgGen.AddSourceComments notes the schema location of generated types, fields and embeds in their doc comments, and that the
+//	SourceMap generated if PkgGen.AddSourceMap is set locates these declarations in the generated source and links them to their constructs.
+func TestSourceMap(t *testing.T) {
+	opts := DefaultGenOptions()
+	opts.AddSourceComments, opts.AddSourceMap = true, true
+	srcs, _, err := NewGenerator(opts).GenerateGoSourceAs(loadTestSchema(t, "sourcemap", "order.xsd"), "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	src := string(srcs["order.xsd.go"])
+	for _, decl := range []string{
+		"// Schema source: sourcemap/order.xsd:3:30 (xs:complexType Line)\ntype TLine struct {\n\t//\tSchema source: sourcemap/order.xsd:4:46 (xs:attribute sku)\n\tXsdGoPkgHasAttr_Sku_XsdtString_\n}",
+		"\t//\tSchema source: sourcemap/order.xsd:9:64 (xs:element line)\n\tLines []*TLine `",
+	} {
+		if !strings.Contains(src, decl) {
+			t.Errorf("expected the source comments in\n%s", decl)
+		}
+	}
+	var sm SourceMap
+	if err = json.Unmarshal(srcs["order.xsd.go"+sourceMapSuffix], &sm); err != nil {
+		t.Fatal(err)
+	} else if (sm.Version != 1) || (len(sm.Entries) == 0) {
+		t.Fatalf("expected a version 1 SourceMap with entries, got %+v", sm)
+	}
+	var goLines, found = strings.Split(src, "\n"), map[string]string{}
+	for _, entry := range sm.Entries {
+		name := entry.Name[strings.LastIndex(entry.Name, ".")+1:]
+		if (entry.GoFile != "order.xsd.go") || (entry.Schema != "sourcemap/order.xsd") || (entry.GoLine < 1) || (entry.GoLine > len(goLines)) || !strings.Contains(goLines[entry.GoLine-1], name) {
+			t.Errorf("expected %s in line %d of %s", name, entry.GoLine, entry.GoFile)
+		}
+		found[entry.Kind+" "+entry.Name] = sfmt("%d:%d %s %s", entry.Line, entry.Column, entry.Construct, entry.XsdName)
+	}
+	for decl, construct := range map[string]string{
+		"type TLine":                                  "3:30 complexType Line",
+		"type TxsdOrder":                              "7:19 complexType ",
+		"field XsdGoPkgHasElem_Order.Order":           "6:27 element order",
+		"embed TLine.XsdGoPkgHasAttr_Sku_XsdtString_": "4:46 attribute sku",
+	} {
+		if found[decl] != construct {
+			t.Errorf("expected %s to be linked to %s, got %q", decl, construct, found[decl])
+		}
+	}
+}
@@ -0,0 +1,255 @@
+package xsd
+
+import (
+	"context"
+	"encoding/xml"
+	"reflect"
+	"strings"
+
+	"github.com/metaleap/go-util-str"
+)
+
+//	Options for LoadSchemaWithOptions and LoadWSDLWithOptions.
+type LoadOptions struct {
+	//	If false (lax mode, as with LoadSchema), anything in a schema document that this package does not know is silently ignored, and
+	//	unresolvable QName references only surface as Diagnostics (if at all) when generating code. If true, loading instead fails with
+	//	Diagnostics (as the error) describing, with their positions, all unknown elements and attributes (other than namespace-qualified
+	//	attributes and the content of xs:appinfo and xs:documentation) in the schema documents loaded, all QName references in these or any
+	//	cached schema documents they include or import that do not resolve to a built-in type or to a global component of the schema set,
+	//	and any xs:include, xs:import, xs:redefine or xs:override whose schema document cannot be loaded.
+	Strict bool
+
+	//	The Generator whose settings govern how schema documents are fetched and decoded: BaseCodePath (where local copies are kept), Resolver,
+	//	Catalog, HttpClient, Fetch, DownloadTTL, Offline, Checksums, Entities, HTMLEntities, DocTypes, MaxConcurrentLoads and the load callbacks
+	//	of Hooks. If nil, PkgGen. Note that schema documents already in the cache loaded into are taken from there regardless.
+	Generator *Generator
+}
+
+//	The element and attribute names known to this package for an XSD element, as derived from the xml tags of the Go type it is decoded into.
+type xsdVocab struct {
+	atts map[string]bool
+	kids map[string]*xsdVocab
+
+	//	Whether its child elements are not checked, as for xs:appinfo and xs:documentation.
+	open bool
+}
+
+var (
+	schemaVocab = newXsdVocab(reflect.TypeOf(Schema{}), map[reflect.Type]*xsdVocab{})
+
+	//	The local names of all built-in XSD 1.0 and 1.1 types.
+	xsdBuiltinTypes = map[string]bool{}
+)
+
+func init() {
+	for _, name := range strings.Fields(`anyType anySimpleType anyAtomicType anyURI base64Binary boolean byte date dateTime dateTimeStamp dayTimeDuration decimal
+		double duration ENTITIES ENTITY error float gDay gMonth gMonthDay gYear gYearMonth hexBinary ID IDREF IDREFS int integer language long Name NCName
+		negativeInteger NMTOKEN NMTOKENS nonNegativeInteger nonPositiveInteger normalizedString NOTATION positiveInteger QName short string time token
+		unsignedByte unsignedInt unsignedLong unsignedShort yearMonthDuration`) {
+		xsdBuiltinTypes[name] = true
+	}
+}
+
+func newXsdVocab(t reflect.Type, vocabs map[reflect.Type]*xsdVocab) (me *xsdVocab) {
+	if me = vocabs[t]; me == nil {
+		me = &xsdVocab{atts: map[string]bool{}, kids: map[string]*xsdVocab{}}
+		me.open, vocabs[t] = (t == reflect.TypeOf(AppInfo{})) || (t == reflect.TypeOf(Documentation{})), me
+		me.addFields(t, vocabs)
+	}
+	return
+}
+
+func (me *xsdVocab) addFields(t reflect.Type, vocabs map[reflect.Type]*xsdVocab) {
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		tag, flags := f.Tag.Get("xml"), ""
+		if pos := strings.Index(tag, ","); pos >= 0 {
+			tag, flags = tag[:pos], tag[pos+1:]
+		}
+		switch {
+		case f.Anonymous && (len(tag) == 0) && (f.Type.Kind() == reflect.Struct):
+			me.addFields(f.Type, vocabs)
+		case (len(f.PkgPath) > 0) || (len(tag) == 0) || (tag == "-") || (f.Name == "XMLName"):
+		case flags == "attr":
+			me.atts[tag] = true
+		case len(flags) == 0:
+			ft := f.Type
+			for (ft.Kind() == reflect.Ptr) || (ft.Kind() == reflect.Slice) {
+				ft = ft.Elem()
+			}
+			me.kids[tag] = newXsdVocab(ft, vocabs)
+		}
+	}
+}
+
+//	Records a Diagnostic for each unknown attribute of the element whose start tag t (at line and col) was just read, and for the element
+//	itself if unknown in its parent, then pushes its vocabulary (or nil, if it is unknown or its parent is open) onto me.vocabs.
+func (me *positionRecorder) checkVocab(t xml.StartElement, line, col int) {
+	var vocab *xsdVocab
+	var parentName string
+	unknown := func(format string, fmtArgs ...interface{}) {
+		me.unknowns = append(me.unknowns, &Diagnostic{Line: line, Column: col, Severity: SeverityError, Message: sfmt(format, fmtArgs...)})
+	}
+	if len(me.vocabs) == 0 {
+		if vocab = schemaVocab; (t.Name.Space != xsdNamespaceUri) || (t.Name.Local != "schema") {
+			unknown("root element %s is not xs:schema", vocabElemName(t.Name))
+		}
+	} else if parent := me.vocabs[len(me.vocabs)-1]; (parent != nil) && !parent.open {
+		parentName = me.names[len(me.names)-1]
+		if vocab = parent.kids[t.Name.Local]; (vocab == nil) || (t.Name.Space != xsdNamespaceUri) {
+			vocab = nil
+			unknown("unknown element %s in xs:%s", vocabElemName(t.Name), parentName)
+		}
+	}
+	if vocab != nil {
+		for _, att := range t.Attr {
+			if (len(att.Name.Space) == 0) && (att.Name.Local != "xmlns") && !vocab.atts[att.Name.Local] {
+				unknown("unknown attribute %q on xs:%s", att.Name.Local, t.Name.Local)
+			}
+		}
+	}
+	me.vocabs, me.names = append(me.vocabs, vocab), append(me.names, t.Name.Local)
+}
+
+//	Returns name as "xs:local" if in the XSD namespace, or else its local name followed by its namespace (if any).
+func vocabElemName(name xml.Name) string {
+	if name.Space == xsdNamespaceUri {
+		return "xs:" + name.Local
+	}
+	return sfmt("%q", name.Local) + ustr.Ifs(len(name.Space) > 0, sfmt(" (of namespace %q)", name.Space), " (of no namespace)")
+}
+
+//	Like LoadSchemaContext, but loads according to opts.
+func LoadSchemaWithOptions(ctx context.Context, uri string, localCopy bool, opts LoadOptions) (sd *Schema, err error) {
+	return DefaultSchemaCache.LoadSchemaWithOptions(ctx, uri, localCopy, opts)
+}
+
+//	Like LoadWSDLContext, but loads according to opts. In strict mode, xs:imports without schemaLocation are resolved to the other embedded schemas (see LoadWSDL) before QName references are checked.
+func LoadWSDLWithOptions(ctx context.Context, uri string, localCopy bool, opts LoadOptions) (schemas []*Schema, err error) {
+	return DefaultSchemaCache.LoadWSDLWithOptions(ctx, uri, localCopy, opts)
+}
+
+//	If in strict mode, wraps the error err, which occurred loading the schema document at the specified schemaLocation of the xs:include,
+//	xs:import, xs:redefine or xs:override at the element path (see elemBase.path) of sd, into a Diagnostic giving its position.
+//	Cancelations and errors that already are Diagnostics (of nested loads) are returned as is.
+func (me *schemaLoader) refError(sd *Schema, path, schemaLocation string, err error) error {
+	if (!me.opts.Strict) || (me.ctx.Err() != nil) {
+		return err
+	}
+	switch err.(type) {
+	case *Diagnostic, Diagnostics, *CycleError, *OfflineError, *ChecksumError:
+		return err
+	}
+	pos, kind := sd.elemPositions[path], path[1:strings.Index(path, "[")]
+	return &Diagnostic{File: ustr.Ifs(len(sd.loadLocalPath) > 0, sd.loadLocalPath, sd.loadUri), Line: pos[0], Column: pos[1], Severity: SeverityError,
+		Message: sfmt("cannot load the schema document %q of xs:%s: %v", schemaLocation, kind, err)}
+}
+
+//	If in strict mode, returns the Diagnostics recorded while loading along with those of all unresolvable QName references in the
+//	specified schemas and in the schema documents they include and import, if any. Otherwise, returns nil.
+func (me *schemaLoader) strictError(schemas []*Schema) error {
+	var diags = me.unknowns
+	var done = map[*Schema]bool{}
+	if !me.opts.Strict {
+		return nil
+	}
+	for _, sd := range schemas {
+		comps := newSchemaComponents(sd)
+		for _, inc := range strictSchemas(sd, nil, done) {
+			inc.Walk(func(node SchemaNode) bool {
+				diags = append(diags, comps.refDiags(node)...)
+				return true
+			})
+		}
+	}
+	if len(diags) > 0 {
+		return diags
+	}
+	return nil
+}
+
+//	Appends sd and the schema documents it includes and imports (directly or indirectly) to schemas, except those already done.
+func strictSchemas(sd *Schema, schemas []*Schema, done map[*Schema]bool) []*Schema {
+	if !done[sd] {
+		done[sd], schemas = true, append(schemas, sd)
+		for _, inc := range sd.XMLIncludedSchemas {
+			schemas = strictSchemas(inc, schemas, done)
+		}
+		for _, imp := range sd.XMLImportedSchemas {
+			schemas = strictSchemas(imp, schemas, done)
+		}
+	}
+	return schemas
+}
+
+//	Returns a Diagnostic for each QName reference of the schema construct of node that does not resolve.
+func (me *schemaComponents) refDiags(node SchemaNode) (diags Diagnostics) {
+	check := func(kind, refs string) {
+		for _, ref := range strings.Fields(refs) {
+			if msg := me.unresolved(ownerSchema(node.Elem.(element)), kind, ref); len(msg) > 0 {
+				d := &Diagnostic{Severity: SeverityError, Message: sfmt("unresolved %s reference %q: %s", kind, ref, msg)}
+				d.File, d.Line, d.Column = node.Position()
+				diags = append(diags, d)
+			}
+		}
+	}
+	switch el := node.Elem.(type) {
+	case *Element:
+		check("element", el.Ref.String())
+		check("type", el.Type.String())
+		check("element", el.SubstitutionGroup.String())
+	case *Attribute:
+		check("attribute", el.Ref.String())
+		check("simple type", el.Type.String())
+	case *AttributeGroup:
+		check("attribute group", el.Ref.String())
+	case *Group:
+		check("group", el.Ref.String())
+	case *ExtensionComplexContent:
+		check("type", el.Base.String())
+	case *ExtensionSimpleContent:
+		check("type", el.Base.String())
+	case *RestrictionComplexContent:
+		check("type", el.Base.String())
+	case *RestrictionSimpleContent:
+		check("type", el.Base.String())
+	case *RestrictionSimpleType:
+		check("simple type", el.Base.String())
+	case *List:
+		check("simple type", el.ItemType.String())
+	case *Union:
+		check("simple type", el.MemberTypes)
+	}
+	return
+}
+
+//	Returns why the QName reference ref to a component of the specified kind in the schema document sd does not resolve, or "" if it does.
+func (me *schemaComponents) unresolved(sd *Schema, kind, ref string) string {
+	if pos := strings.Index(ref, ":"); pos > 0 {
+		if _, ok := sd.XMLNamespaces[ref[:pos]]; !ok {
+			return sfmt("namespace prefix %q is not declared", ref[:pos])
+		}
+	}
+	var ok bool
+	qn := sd.qname(ref)
+	switch kind {
+	case "type":
+		ok = ((qn.Space == xsdNamespaceUri) && xsdBuiltinTypes[qn.Local]) || (me.complexTypes[qn] != nil) || (me.simpleTypes[qn] != nil)
+	case "simple type":
+		ok = ((qn.Space == xsdNamespaceUri) && xsdBuiltinTypes[qn.Local] && (qn.Local != "anyType")) || (me.simpleTypes[qn] != nil)
+	case "element":
+		ok = me.elements[qn] != nil
+	case "attribute":
+		ok = me.attributes[qn] != nil
+	case "attribute group":
+		ok = me.attributeGroups[qn] != nil
+	case "group":
+		ok = me.groups[qn] != nil
+	}
+	if ok {
+		return ""
+	} else if qn.Space == xsdNamespaceUri {
+		return sfmt("no built-in XSD %s %q", kind, qn.Local)
+	}
+	return sfmt("no %s %q in namespace %q", kind, qn.Local, qn.Space)
+}
@@ -0,0 +1,39 @@
+package xsd
+
+import (
+	"context"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestStrictLoadingReportsTypos(t *testing.T) {
+	if _, err := NewSchemaCache(0).LoadSchemaDir(context.Background(), filepath.Join("testdata", "strict"), LoadOptions{}); err != nil {
+		t.Fatalf("lax loading failed: %v", err)
+	}
+	_, err := NewSchemaCache(0).LoadSchemaDir(context.Background(), filepath.Join("testdata", "strict"), LoadOptions{Strict: true})
+	diags, _ := err.(Diagnostics)
+	if len(diags) != 3 {
+		t.Fatalf("expected 3 diagnostics, got %v", err)
+	}
+	for i, msg := range []string{`typos.xsd:5:62: error: unknown attribute "maxOcurs" on xs:element`, `typos.xsd:6:45: error: unknown element xs:elemnt in xs:sequence`, `typos.xsd:5:62: error: unresolved type reference "Item"`} {
+		if !strings.Contains(diags[i].Error(), msg) {
+			t.Errorf("expected %s, got %v", msg, diags[i])
+		}
+	}
+}
+
+func TestStrictLoadingReportsMissingInclude(t *testing.T) {
+	opts := DefaultGenOptions()
+	opts.Offline = true
+	opts.Resolver = SchemaResolverFunc(func(location, baseUri string) (io.ReadCloser, error) {
+		return os.Open(filepath.Join("testdata", "strictinclude", path.Base(location)))
+	})
+	_, err := NewSchemaCache(0).LoadSchemaWithOptions(context.Background(), "strict.example.com/root.xsd", false, LoadOptions{Strict: true, Generator: NewGenerator(opts)})
+	if (err == nil) || !strings.Contains(err.Error(), `root.xsd:3:44: error: cannot load the schema document "missing.xsd" of xs:include`) {
+		t.Errorf("expected the missing include reported at line 3, got %v", err)
+	}
+}
@@ -0,0 +1,155 @@
+package xsd
+
+import (
+	"bytes"
+	"strings"
+	"text/template"
+)
+
+var (
+	//	The default templates that PkgGen.Templates is initialized with, reproducing the code that go-xsd always generated.
+	DefaultTemplates = Templates{
+		FileHeader: "//\tAuto-generated by the \"go-xsd\" package located at:\n//\t\tgithub.com/metaleap/go-xsd\n//\tComments on types and fields (if any) are from the XSD file located at:\n//\t\t{{.SchemaUri}}\npackage {{.PkgName}}\n\n",
+		Struct:     "{{.Doc}}type {{.Name}} struct {\n{{range .Members}}{{with .Field}}{{.Doc}}\t{{.Name}} {{.Type}} `xml:\"{{.XmlTag}}\"{{if .JsonTag}} json:\"{{.JsonTag}}\"{{end}}`\n\n{{end}}{{with .Embed}}{{.Doc}}\t{{.Type}}\n\n{{end}}{{end}}}\n\n",
+		SimpleType: "{{.Doc}}type {{.Name}} {{.Type}}\n\n",
+		Enum:       "//\t{{.Doc}}\nfunc (me {{.TypeName}}) {{.MethodName}} () bool { return me.String() == {{printf \"%#v\" .Value}} }\n\n",
+		EnumType:   "//\tThe enumerated values of {{.TypeName}}.\nconst (\n{{range .Values}}{{.Doc}}\t{{.Name}} {{$.TypeName}} = {{.Literal}}\n{{end}})\n\n//\tReturns the enumerated {{.TypeName}} value whose string representation is s, or else a *{{.XsdtPkg}}.FacetError.\nfunc Parse{{.TypeName}} (s string) (v {{.TypeName}}, err error) {\n\tfor _, ev := range []{{.TypeName}}{ {{range .Values}}{{.Name}}, {{end}} } {\n\t\tif ev.String() == s {\n\t\t\treturn ev, nil\n\t\t}\n\t}\n\terr = &{{.XsdtPkg}}.FacetError{Facet: \"enumeration\", Constraint: {{printf \"%#v\" .Constraint}}, Value: s}\n\treturn\n}\n\n//\tReturns whether this {{.TypeName}} is one of its enumerated values.\nfunc (me {{.TypeName}}) IsValid () bool {\n\tswitch me {\n\tcase {{range $i, $v := .Values}}{{if $i}}, {{end}}{{.Name}}{{end}}:\n\t\treturn true\n\t}\n\treturn false\n}\n\n",
+	}
+)
+
+//	The text/template sources from which MakeGoPkgSrcFile renders the generated Go code, so that naming, comments and boilerplate can be adapted to house style.
+//	Each Doc passed to a template holds complete Go comment lines (each terminated by a line break) rendered from the XSD annotations, if any.
+type Templates struct {
+	//	Executed with a TmplFileHeader, renders everything preceding the import declarations of a generated source file.
+	FileHeader string
+
+	//	Executed with a TmplStruct, renders the declaration of a generated struct type.
+	Struct string
+
+	//	Executed with a TmplSimpleType, renders the declaration of a generated non-struct type (mostly those for XSD simple-types).
+	SimpleType string
+
+	//	Executed with a TmplEnum, renders the IsXyz() method generated for every enumerated value of an XSD simple-type.
+	Enum string
+
+	//	Executed with a TmplEnumType, renders the typed constants, the ParseXyz() function and the IsValid() method generated for every enumerated XSD simple-type.
+	EnumType string
+}
+
+//	The data that Templates.FileHeader is executed with.
+type TmplFileHeader struct {
+	//	The name of the generated Go package, and the URI of the XSD it is generated from.
+	PkgName, SchemaUri string
+}
+
+//	The data that Templates.Struct is executed with.
+type TmplStruct struct {
+	Doc, Name string
+	Fields    []TmplStructField
+	Embeds    []TmplStructEmbed
+
+	//	All Fields and Embeds in the order they are to be declared in: fields first, then embeds in document order (amid which
+	//	the fields of choice unions, see PkgGen.ChoiceUnions, so that encoding/xml encodes all elements in the order of their xs:sequence).
+	Members []TmplStructMember
+}
+
+//	Describes either a single named field or a single embedded type of a TmplStruct.
+type TmplStructMember struct {
+	Field *TmplStructField
+	Embed *TmplStructEmbed
+}
+
+//	Describes a single named field of a TmplStruct.
+type TmplStructField struct {
+	Doc, Name, Type, XmlTag string
+
+	//	The json struct tag value, or "" if PkgGen.JsonTags is JsonTagsNone.
+	JsonTag string
+}
+
+//	Describes a single embedded type of a TmplStruct.
+type TmplStructEmbed struct {
+	Doc, Type string
+}
+
+//	The data that Templates.SimpleType is executed with.
+type TmplSimpleType struct {
+	Doc, Name, Type string
+}
+
+//	The data that Templates.Enum is executed with.
+type TmplEnum struct {
+	//	The plain-text description of the method (not a Go comment).
+	Doc string
+
+	//	The receiver type name and the method name, such as "TxsdWeather" and "IsSunny".
+	TypeName, MethodName string
+
+	//	The enumerated value as specified in the XSD.
+	Value string
+}
+
+//	The data that Templates.EnumType is executed with.
+type TmplEnumType struct {
+	//	The name of the enumerated type, such as "TxsdWeather", and the import name of the go-xsd/types package, usually "xsdt".
+	TypeName, XsdtPkg string
+
+	//	The enumerated values as listed in a FacetError, such as ["sunny" "cloudy"].
+	Constraint string
+
+	Values []TmplEnumValue
+}
+
+//	Describes a single enumerated value of a TmplEnumType.
+type TmplEnumValue struct {
+	//	The constant name, such as "TxsdWeatherSunny", and its Go literal value, such as "\"sunny\"" (or "42" or "true" for numeric or boolean types).
+	Name, Literal string
+
+	//	The enumerated value as specified in the XSD.
+	Value string
+
+	//	The comment lines rendered from the annotation of the xs:enumeration, if any.
+	Doc string
+}
+
+type pkgTemplates struct {
+	fileHeader, enum, enumType, simpleType, structType *template.Template
+}
+
+func (me *Templates) parse() (tmpls *pkgTemplates, err error) {
+	tmpls = &pkgTemplates{}
+	for _, t := range []struct {
+		tmpl **template.Template
+		name string
+		src  string
+	}{{&tmpls.fileHeader, "FileHeader", me.FileHeader}, {&tmpls.structType, "Struct", me.Struct}, {&tmpls.simpleType, "SimpleType", me.SimpleType}, {&tmpls.enum, "Enum", me.Enum}, {&tmpls.enumType, "EnumType", me.EnumType}} {
+		if *t.tmpl, err = template.New(t.name).Parse(t.src); err != nil {
+			tmpls = nil
+			break
+		}
+	}
+	return
+}
+
+//	Appends the output of executing tmpl with data, unless a template error occurred previously (which MakeGoPkgSrcFile then returns).
+func (me *PkgBag) appendTmpl(tmpl *template.Template, data interface{}) {
+	var buf bytes.Buffer
+	if me.tmplErr == nil {
+		if me.tmplErr = tmpl.Execute(&buf, data); me.tmplErr == nil {
+			me.append(strings.TrimSuffix(buf.String(), "\n"))
+		}
+	}
+}
+
+//	Returns the Go comment lines rendered from the specified annotations, rather than appending them right away.
+func (me *PkgBag) docLines(anns []*Annotation) (doc string) {
+	var srcLen = me.src.Len()
+	for _, ann := range anns {
+		if ann != nil {
+			ann.makePkg(me)
+		}
+	}
+	doc = string(me.src.Bytes()[srcLen:])
+	me.src.Truncate(srcLen)
+	return
+}
@@ -0,0 +1,40 @@
+package xsd
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestCustomTemplates(t *testing.T) {
+	src, _ := genTestSrc(t, "sequence", "seq.xsd", func(opts *GenOptions) {
+		opts.Templates.FileHeader = "// House header for {{.SchemaUri}}.\npackage {{.PkgName}}\n\n"
+		opts.Templates.Struct = strings.Replace(DefaultTemplates.Struct, "{{.Doc}}", "{{.Doc}}// House struct {{.Name}}.\n", 1)
+	})
+	if !strings.HasPrefix(src, "// House header for ") || strings.Contains(src, "Auto-generated") {
+		t.Errorf("the FileHeader template was not used:\n%s", src)
+	}
+	if !strings.Contains(src, "// House struct TShipment.\ntype TShipment struct {") {
+		t.Errorf("the Struct template was not used:\n%s", src)
+	}
+}
+
+func TestInvalidTemplate(t *testing.T) {
+	opts := DefaultGenOptions()
+	opts.Templates.SimpleType = "{{.Doc}"
+	if _, _, err := NewGenerator(opts).GenerateGoSourceAs(loadTestSchema(t, "sequence", "seq.xsd"), ""); err == nil {
+		t.Error("expected the parse error of the SimpleType template")
+	}
+}
+
+func TestDocumentationComments(t *testing.T) {
+	src, _ := genTestSrc(t, "docs", "order.xsd", nil)
+	for _, doc := range []string{
+		"// A purchase order, see ISO 1234.\n// Orders are final.\ntype TOrder struct {",
+		"\t//\tA note for the */ courier.\n\tNote xsdt.String `xml:\"urn:example:docs note\"`",
+		"\t//\tThe order awaits shipping.\n\tTStatusOpen    TStatus = \"open\"",
+	} {
+		if !strings.Contains(src, doc) {
+			t.Errorf("expected\n%s\nin\n%s", doc, src)
+		}
+	}
+}
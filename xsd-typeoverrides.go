@@ -0,0 +1,82 @@
+package xsd
+
+import (
+	"fmt"
+	"path"
+	"strings"
+)
+
+//	A custom Go type that an XSD built-in type or a named schema type is generated as (see PkgGen.TypeOverrides).
+//	For XSD built-in types and simple types, a pointer to it must implement encoding.TextMarshaler and encoding.TextUnmarshaler (decoding and encoding
+//	the lexical forms of the type overridden), and for complex types xml.Marshaler and xml.Unmarshaler. Its zero value should denote an absent value,
+//	as default and fixed values are only applied to fields holding zero values.
+type TypeOverride struct {
+	//	The import path of the Go package declaring GoType, such as "github.com/shopspring/decimal".
+	ImportPath string
+
+	//	The name of the Go type in that package, such as "Decimal".
+	GoType string
+}
+
+//	Parses the TypeOverride spec of the form "{namespace}local=importpath.GoType", such as
+//	"{http://www.w3.org/2001/XMLSchema}decimal=github.com/shopspring/decimal.Decimal", returning the key to register it with in PkgGen.TypeOverrides.
+func ParseTypeOverride(spec string) (qname string, override *TypeOverride, err error) {
+	pos := strings.Index(spec, "=")
+	if (pos <= 0) || !strings.HasPrefix(spec, "{") || !strings.Contains(spec[:pos], "}") || strings.HasSuffix(spec[:pos], "}") {
+		err = fmt.Errorf("expected {namespace}local=importpath.GoType, got %q", spec)
+		return
+	}
+	qname, spec = spec[:pos], spec[pos+1:]
+	if pos = strings.LastIndex(spec, "."); (pos <= 0) || (pos < strings.LastIndex(spec, "/")) || (pos == len(spec)-1) {
+		err = fmt.Errorf("expected importpath.GoType for %s, got %q", qname, spec)
+		return
+	}
+	override = &TypeOverride{ImportPath: spec[:pos], GoType: spec[pos+1:]}
+	return
+}
+
+//	Returns the Go type that PkgGen.TypeOverrides maps the type local of the namespace ns to (qualified with the import name of its package,
+//	which is added to the imports of this package), or "" if none (or if pref, as passed to resolveQnameRef, shows that a type is not referred to).
+func (me *PkgBag) overrideType(ns, local, pref string) (tn string) {
+	var override *TypeOverride
+	if override = me.gen.TypeOverrides["{"+ns+"}"+local]; (override == nil) || (pref != "T") {
+		return
+	}
+	tn = me.overrideImpName(override.ImportPath) + "." + override.GoType
+	me.textTypes[tn], me.overrideTypes[tn] = true, true
+	return
+}
+
+//	Returns the import name of the Go package impPath (of a TypeOverride, or a standard package used by generated code): the last element of impPath,
+//	unless another import or a namespace prefix of the schema already takes that name.
+func (me *PkgBag) overrideImpName(impPath string) (impName string) {
+	base := safeIdentifier(path.Base(impPath))
+	impName = base
+	for i := 0; me.imports[impName] != impPath; i++ {
+		taken := len(me.imports[impName]) > 0
+		for _, imp := range me.nsImps {
+			taken = taken || (imp == impName)
+		}
+		if !taken {
+			me.imports[impName] = impPath
+			break
+		}
+		impName = sfmt("%s%d", base, i)
+	}
+	return
+}
+
+//	Returns whether the XSD type of the specified name, declared by the schema of this package, is overridden by PkgGen.TypeOverrides and so gets no Go type declaration.
+func (me *PkgBag) isOverridden(name string) bool {
+	return (len(name) > 0) && (me.gen.TypeOverrides["{"+me.Schema.TargetNamespace.String()+"}"+name] != nil)
+}
+
+//	Returns the Go statement setting the value that ptr points to (of the type tn) to the one parsed from the Go string expression s:
+//	by calling its Set() method, or xsdt.SetText if tn is a TypeOverride.
+func (me *PkgBag) setCall(tn, ptr, s string) string {
+	if me.overrideTypes[tn] {
+		me.impsUsed[me.impName] = true
+		return sfmt("%s.SetText(%s, %s)", me.impName, ptr, s)
+	}
+	return sfmt("%s.Set(%s)", strings.TrimPrefix(ptr, "&"), s)
+}
@@ -0,0 +1,153 @@
+package xsd
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/metaleap/go-util-str"
+
+	xsdt "github.com/metaleap/go-xsd/types"
+)
+
+//	A use of an XSD construct in a schema document that the Go code generated from the schema or Schema.Validate (or both) do not fully support,
+//	as listed by Schema.UnsupportedFeatures.
+type UnsupportedFeature struct {
+	//	The local file path (or else the URI) of the schema document containing the construct.
+	File string
+
+	//	The position in that schema document right after the start tag of the construct, or 0 if unknown.
+	Line, Column int
+
+	//	The XSD element name of the construct, such as "assert", "keyref", "pattern" or "anyAttribute".
+	Construct string
+
+	//	Whether the generated Go code does not (fully) represent or enforce the construct.
+	Generator bool
+
+	//	Whether Schema.Validate does not (fully) check instance documents against the construct.
+	Validator bool
+
+	//	What is not supported about the construct, one description per Generator and Validator (if set, in this order).
+	Messages []string
+}
+
+//	Returns a description of this UnsupportedFeature in the customary file:line:column: form, followed by the construct, which of the
+//	generator and the validator do not support it, and how.
+func (me *UnsupportedFeature) String() string {
+	var by []string
+	if me.Generator {
+		by = append(by, "generator")
+	}
+	if me.Validator {
+		by = append(by, "validator")
+	}
+	return sfmt("%s:%d:%d: xs:%s (%s): %s", me.File, me.Line, me.Column, me.Construct, strings.Join(by, ", "), strings.Join(me.Messages, "; "))
+}
+
+//	Calls PkgGen.UnsupportedFeatures for this schema.
+func (me *Schema) UnsupportedFeatures() []*UnsupportedFeature {
+	return PkgGen.UnsupportedFeatures(me)
+}
+
+//	Lists, ordered by schema document and position, every use of a construct in sd (and all schema documents it includes or imports) that the Go
+//	code generated for it with the settings of this Generator, or Schema.Validate, do not fully support, so that one can tell whether either is
+//	trustworthy for this particular schema. For the generator, these are the constructs reported as ignored (see Diagnostics.Ignored) or unsupported
+//	when generating (in memory, see GenerateGoSourceAs). For the validator, these are assertions, type alternatives whose tests it cannot evaluate,
+//	identity constraints whose XPaths it does not support, patterns that cannot be translated to RE2, the block and blockDefault attributes (as
+//	xsi:type and substitution group members are not checked against them), and attribute wildcards not skipping the validation of the attributes
+//	they match (as these are not validated). Constructs unknown to this package are not loaded at all, so these are not listed: load schemas in
+//	strict mode (see LoadOptions) to have them reported.
+func (me *Generator) UnsupportedFeatures(sd *Schema) (features []*UnsupportedFeature) {
+	var byKey = map[string]*UnsupportedFeature{}
+	var add = func(file string, line, col int, construct string, byGenerator bool, msg string) {
+		key := sfmt("%s:%d:%d:%s", file, line, col, construct)
+		f := byKey[key]
+		if f == nil {
+			f = &UnsupportedFeature{File: file, Line: line, Column: col, Construct: construct}
+			byKey[key], features = f, append(features, f)
+		}
+		if byGenerator {
+			f.Generator, f.Messages = true, append([]string{msg}, f.Messages...)
+		} else {
+			f.Validator, f.Messages = true, append(f.Messages, msg)
+		}
+	}
+	_, diags, _ := me.GenerateGoSourceAs(sd, "")
+	for _, d := range diags {
+		if len(d.Ignored) > 0 {
+			add(d.File, d.Line, d.Column, d.Ignored, true, strings.TrimPrefix(d.Message, "ignored construct: "))
+		} else if strings.HasPrefix(d.Message, "unsupported construct: ") {
+			add(d.File, d.Line, d.Column, "attribute", true, strings.TrimPrefix(d.Message, "unsupported construct: "))
+		}
+	}
+	for _, doc := range sd.allSchemas(map[string]bool{}) {
+		doc.Walk(func(node SchemaNode) bool {
+			if msg := unsupportedByValidator(node.Elem.(element)); len(msg) > 0 {
+				file, line, col := node.Position()
+				add(file, line, col, node.XsdName(), false, msg)
+			}
+			return true
+		})
+	}
+	sort.Stable(unsupportedFeatures(features))
+	return
+}
+
+//	Returns what Schema.Validate does not support about the construct el, or "" if it fully supports it (see Generator.UnsupportedFeatures).
+func unsupportedByValidator(el element) string {
+	switch x := el.(type) {
+	case *Schema:
+		if len(x.BlockDefault) > 0 {
+			return sfmt("the blockDefault %q is not enforced by the validator", x.BlockDefault)
+		}
+	case *Element:
+		if len(x.Block) > 0 {
+			return sfmt("the block %q of element %s is not enforced by the validator", x.Block, x.Name)
+		}
+	case *ComplexType:
+		if len(x.Block) > 0 {
+			return sfmt("the block %q of complex type %s is not enforced by the validator", x.Block, x.Name)
+		}
+	case *Assert:
+		return sfmt("the assertion %q is not evaluated by the validator", x.Test)
+	case *Alternative:
+		if _, ok := evalXpathTest(&instNode{}, ownerSchema(x), x.Test); (len(strings.TrimSpace(x.Test)) > 0) && !ok {
+			return sfmt("the test %q of the type alternative cannot be evaluated by the validator, which disregards the alternative", x.Test)
+		}
+	case *Key:
+		if !newIdentity(ownerSchema(x), "key", x.Name.String(), "", x.Selector, x.Fields).xpathsOk {
+			return sfmt("the XPaths of the identity constraint xs:key %q are not supported by the validator", x.Name)
+		}
+	case *Unique:
+		if !newIdentity(ownerSchema(x), "unique", x.Name.String(), "", x.Selector, x.Fields).xpathsOk {
+			return sfmt("the XPaths of the identity constraint xs:unique %q are not supported by the validator", x.Name)
+		}
+	case *KeyRef:
+		if !newIdentity(ownerSchema(x), "keyref", x.Name.String(), x.Refer.String(), x.Selector, x.Fields).xpathsOk {
+			return sfmt("the XPaths of the identity constraint xs:keyref %q are not supported by the validator", x.Name)
+		}
+	case *RestrictionSimplePattern:
+		if _, err := xsdt.CompilePattern(x.Value); err != nil {
+			return sfmt("the pattern %q is not checked by the validator: %v", x.Value, err)
+		}
+	case *AnyAttribute:
+		if x.ProcessContents != "skip" {
+			return sfmt("the attributes matched by this wildcard are not validated against their declarations, despite processContents %q", ustr.Ifs(len(x.ProcessContents) > 0, x.ProcessContents, "strict"))
+		}
+	}
+	return ""
+}
+
+//	Sorts UnsupportedFeatures by file and position.
+type unsupportedFeatures []*UnsupportedFeature
+
+func (me unsupportedFeatures) Len() int      { return len(me) }
+func (me unsupportedFeatures) Swap(i, j int) { me[i], me[j] = me[j], me[i] }
+func (me unsupportedFeatures) Less(i, j int) bool {
+	if me[i].File != me[j].File {
+		return me[i].File < me[j].File
+	} else if me[i].Line != me[j].Line {
+		return me[i].Line < me[j].Line
+	}
+	return me[i].Column < me[j].Column
+}
@@ -0,0 +1,36 @@
+package xsd
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+//	Tests that UnsupportedFeatures lists the constructs that the generator (with its settings) or the validator do not fully support, in order,
+//	merging the messages of both for the same construct.
+func TestUnsupportedFeatures(t *testing.T) {
+	sd := loadTestSchema(t, "unsupported", "doc.xsd")
+	list := func(features []*UnsupportedFeature) (lines []string) {
+		for _, f := range features {
+			lines = append(lines, strings.TrimPrefix(f.String(), f.File[:len(f.File)-len(filepath.Base(f.File))]))
+		}
+		return
+	}
+	expected := []string{
+		`doc.xsd:2:220: xs:schema (validator): the blockDefault "extension" is not enforced by the validator`,
+		`doc.xsd:5:42: xs:pattern (generator, validator): the pattern facet "\\p{IsOldItalic}+" is not enforced by the generated Go code: xs:pattern "\\p{IsOldItalic}+" at offset 0: the Unicode block OldItalic is not supported; the pattern "\\p{IsOldItalic}+" is not checked by the validator: xs:pattern "\\p{IsOldItalic}+" at offset 0: the Unicode block OldItalic is not supported`,
+		`doc.xsd:11:43: xs:anyAttribute (validator): the attributes matched by this wildcard are not validated against their declarations, despite processContents "lax"`,
+		`doc.xsd:12:35: xs:assert (generator, validator): the assertion "@min le @max" is not enforced by the generated Go code, but merely documented; the assertion "@min le @max" is not evaluated by the validator`,
+		`doc.xsd:17:86: xs:element (validator): the block "restriction" of element range is not enforced by the validator`,
+		`doc.xsd:21:27: xs:key (generator, validator): the identity constraint xs:key "rangeKey" is not enforced by the generated Go code; the XPaths of the identity constraint xs:key "rangeKey" are not supported by the validator`,
+		`doc.xsd:25:30: xs:unique (generator): the identity constraint xs:unique "rangeMax" is not enforced by the generated Go code`,
+	}
+	if actual := list(sd.UnsupportedFeatures()); strings.Join(actual, "\n") != strings.Join(expected, "\n") {
+		t.Errorf("expected the unsupported features\n%s\ngot\n%s", strings.Join(expected, "\n"), strings.Join(actual, "\n"))
+	}
+	opts := DefaultGenOptions()
+	opts.AddValidators, opts.AddKeyIndexes = true, true
+	if actual := list(NewGenerator(opts).UnsupportedFeatures(sd)); strings.Join(actual, "\n") != strings.Join(expected[:len(expected)-1], "\n") {
+		t.Errorf("expected the xs:unique to be supported by the generator with AddKeyIndexes, got\n%s", strings.Join(actual, "\n"))
+	}
+}
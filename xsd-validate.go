@@ -0,0 +1,934 @@
+package xsd
+
+import (
+	"encoding"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/xml"
+	"errors"
+	"io"
+	"math/big"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/metaleap/go-util-str"
+
+	xsdt "github.com/metaleap/go-xsd/types"
+)
+
+const (
+	xsiNamespaceUri = "http://www.w3.org/2001/XMLSchema-instance"
+	xmlNamespaceUri = "http://www.w3.org/XML/1998/namespace"
+)
+
+const (
+	particleElement = iota
+	particleAny
+	particleSequence
+	particleChoice
+	particleAll
+)
+
+var (
+	//	Matches "@attr" or "@attr op literal" in the tests of XSD 1.1 type alternatives, see evalXpathTest.
+	xpathComparison = regexp.MustCompile(`^@([\w.:-]+)\s*(?:(!=|<=|>=|=|<|>)\s*(.+))?$`)
+
+	builtinPatterns = map[string]*regexp.Regexp{
+		"decimal":    regexp.MustCompile(`^[+-]?(\d+(\.\d*)?|\.\d+)$`),
+		"date":       regexp.MustCompile(`^-?\d{4,}-\d{2}-\d{2}(Z|[+-]\d{2}:\d{2})?$`),
+		"dateTime":   regexp.MustCompile(`^-?\d{4,}-\d{2}-\d{2}T\d{2}:\d{2}:\d{2}(\.\d+)?(Z|[+-]\d{2}:\d{2})?$`),
+		"duration":   regexp.MustCompile(`^-?P(\d+Y)?(\d+M)?(\d+D)?(T(\d+H)?(\d+M)?(\d+(\.\d+)?S)?)?$`),
+		"gDay":       regexp.MustCompile(`^---\d{2}(Z|[+-]\d{2}:\d{2})?$`),
+		"gMonth":     regexp.MustCompile(`^--\d{2}(Z|[+-]\d{2}:\d{2})?$`),
+		"gMonthDay":  regexp.MustCompile(`^--\d{2}-\d{2}(Z|[+-]\d{2}:\d{2})?$`),
+		"gYear":      regexp.MustCompile(`^-?\d{4,}(Z|[+-]\d{2}:\d{2})?$`),
+		"gYearMonth": regexp.MustCompile(`^-?\d{4,}-\d{2}(Z|[+-]\d{2}:\d{2})?$`),
+		"language":   regexp.MustCompile(`^[a-zA-Z]{1,8}(-[a-zA-Z0-9]{1,8})*$`),
+		"NCName":     regexp.MustCompile(`^[\pL_][\pL\pN._\-]*$`),
+		"QName":      regexp.MustCompile(`^([\pL_][\pL\pN._\-]*:)?[\pL_][\pL\pN._\-]*$`),
+		"time":       regexp.MustCompile(`^\d{2}:\d{2}:\d{2}(\.\d+)?(Z|[+-]\d{2}:\d{2})?$`),
+	}
+	//	The typed xsdt types of the built-in date and time types, whose UnmarshalText methods also reject values out of range (such as a month of 13
+	//	or a day of 45) that builtinPatterns match, see checkBuiltinValue.
+	builtinParsers = map[string]func() encoding.TextUnmarshaler{
+		"date":     func() encoding.TextUnmarshaler { return new(xsdt.DateValue) },
+		"dateTime": func() encoding.TextUnmarshaler { return new(xsdt.DateTimeValue) },
+		"duration": func() encoding.TextUnmarshaler { return new(xsdt.DurationValue) },
+		"time":     func() encoding.TextUnmarshaler { return new(xsdt.TimeValue) },
+	}
+	builtinRanges = map[string][2]string{
+		"byte":               {"-128", "127"},
+		"int":                {"-2147483648", "2147483647"},
+		"integer":            {"", ""},
+		"long":               {"-9223372036854775808", "9223372036854775807"},
+		"negativeInteger":    {"", "-1"},
+		"nonNegativeInteger": {"0", ""},
+		"nonPositiveInteger": {"", "0"},
+		"positiveInteger":    {"1", ""},
+		"short":              {"-32768", "32767"},
+		"unsignedByte":       {"0", "255"},
+		"unsignedInt":        {"0", "4294967295"},
+		"unsignedLong":       {"0", "18446744073709551615"},
+		"unsignedShort":      {"0", "65535"},
+	}
+)
+
+// A single problem found by Schema.Validate in an XML instance document.
+type ValidationError struct {
+	//	The slash-separated path of element names leading to the offending element, eg. "/order/item[2]/sku".
+	Path string
+
+	//	The position in the instance document right after the start tag of the offending element.
+	Line, Column int
+
+	Message string
+}
+
+// Returns a description of this ValidationError, including its Path and position.
+func (me ValidationError) Error() string {
+	return sfmt("%s (line %d, column %d): %s", me.Path, me.Line, me.Column, me.Message)
+}
+
+type instNode struct {
+	name      xml.Name
+	atts      []xml.Attr
+	kids      []*instNode
+	parent    *instNode
+	text      string
+	path      string
+	line, col int
+	defaults  []xml.Attr
+}
+
+func (me *instNode) att(ns, local string) (val string, ok bool) {
+	for _, a := range me.atts {
+		if (a.Name.Space == ns) && (a.Name.Local == local) {
+			return a.Value, true
+		}
+	}
+	return
+}
+
+func (me *instNode) hasText() bool {
+	return len(strings.TrimSpace(me.text)) > 0
+}
+
+// Resolves a "prefix:local" QName occurring in an attribute value of this instance element, such as in xsi:type.
+func (me *instNode) qname(ref string) (qn xml.Name) {
+	var prefix string
+	if pos := strings.Index(ref, ":"); pos > 0 {
+		prefix, qn.Local = ref[:pos], ref[pos+1:]
+	} else {
+		qn.Local = ref
+	}
+	for n := me; n != nil; n = n.parent {
+		for _, a := range n.atts {
+			if ((len(prefix) > 0) && (a.Name.Space == "xmlns") && (a.Name.Local == prefix)) || ((len(prefix) == 0) && (len(a.Name.Space) == 0) && (a.Name.Local == "xmlns")) {
+				qn.Space = a.Value
+				return
+			}
+		}
+	}
+	return
+}
+
+func (me *instNode) setKidPaths() {
+	var counts, seen = map[string]int{}, map[string]int{}
+	for _, kid := range me.kids {
+		counts[kid.name.Local]++
+	}
+	for _, kid := range me.kids {
+		if seen[kid.name.Local]++; counts[kid.name.Local] > 1 {
+			kid.path = sfmt("%s/%s[%d]", me.path, kid.name.Local, seen[kid.name.Local])
+		} else {
+			kid.path = me.path + "/" + kid.name.Local
+		}
+	}
+}
+
+func readInstance(r io.Reader) (root *instNode, err error) {
+	var (
+		tok xml.Token
+		cur *instNode
+		xd  = xml.NewDecoder(r)
+	)
+	for {
+		if tok, err = xd.Token(); err != nil {
+			if err == io.EOF {
+				err = nil
+			}
+			break
+		}
+		switch t := tok.(type) {
+		case xml.StartElement:
+			n := &instNode{name: t.Name, atts: t.Copy().Attr, parent: cur}
+			n.line, n.col = xd.InputPos()
+			if cur != nil {
+				cur.kids = append(cur.kids, n)
+			} else if root == nil {
+				root = n
+			}
+			cur = n
+		case xml.EndElement:
+			if cur != nil {
+				cur = cur.parent
+			}
+		case xml.CharData:
+			if cur != nil {
+				cur.text += string(t)
+			}
+		}
+	}
+	if (err == nil) && (root == nil) {
+		err = errors.New("no root element found in XML instance document")
+	}
+	return
+}
+
+type vParticle struct {
+	kind     int
+	min, max int64
+	name     xml.Name
+	elem     *Element
+	any      *Any
+	kids     []*vParticle
+}
+
+type vAssignment struct {
+	node *instNode
+	elem *Element
+	any  *Any
+}
+
+type validator struct {
+	compiled   *Compiled
+	errs       []ValidationError
+	particles  map[*ComplexType]*vParticle
+	assigned   []vAssignment
+	best       []vAssignment
+	farthest   int
+	expected   []string
+	groupsBusy map[*Group]bool
+	idScopes   []vIdentityScope
+	defaults   bool
+}
+
+// Checks the XML instance document read from r against this schema (and all schemas it includes or imports):
+// element structure and occurrence constraints, presence and values of attributes, the lexical values and facets of simple-typed content,
+// and the xs:key, xs:unique and xs:keyref identity constraints (whose XPaths must stay within the subset defined for xs:selector and xs:field by XSD 1.0).
+// Abstract elements must be replaced by members of their substitution groups, and elements of abstract types by xsi:type with a type derived from it.
+// The returned error is only non-nil if r could not be read or is not well-formed XML.
+func (me *Schema) Validate(r io.Reader) (errs []ValidationError, err error) {
+	return compileSchemas(me).Validate(r)
+}
+
+//	Like Schema.Validate, against the compiled schema.
+func (me *Compiled) Validate(r io.Reader) (errs []ValidationError, err error) {
+	var root *instNode
+	if root, err = readInstance(r); err == nil {
+		v := &validator{compiled: me, particles: map[*ComplexType]*vParticle{}, groupsBusy: map[*Group]bool{}}
+		v.check(root)
+		errs = v.errs
+	}
+	return
+}
+
+//	Returns, for every element of the XML instance document read from r in document order, the attributes that are absent from it but have
+//	default or fixed values in their declarations (sorted by namespace and local name), as assigned by this schema and all schemas it includes
+//	or imports. These are the attributes that a validating parser adds to the instance document, such as before canonicalizing it (see package
+//	c14n). Elements that fail validation still get the default attributes of the types assigned to them, if any. The returned error is only
+//	non-nil if r could not be read or is not well-formed XML.
+func (me *Schema) DefaultAttributes(r io.Reader) (defaults [][]xml.Attr, err error) {
+	return compileSchemas(me).DefaultAttributes(r)
+}
+
+//	Like Schema.DefaultAttributes, against the compiled schema.
+func (me *Compiled) DefaultAttributes(r io.Reader) (defaults [][]xml.Attr, err error) {
+	var root *instNode
+	if root, err = readInstance(r); err == nil {
+		v := &validator{compiled: me, particles: map[*ComplexType]*vParticle{}, groupsBusy: map[*Group]bool{}, defaults: true}
+		v.check(root)
+		var walk func(*instNode)
+		walk = func(n *instNode) {
+			defaults = append(defaults, n.defaults)
+			for _, kid := range n.kids {
+				walk(kid)
+			}
+		}
+		walk(root)
+	}
+	return
+}
+
+func (me *validator) check(root *instNode) {
+	root.path = "/" + root.name.Local
+	if decl := me.compiled.Element(root.name); decl == nil {
+		me.fail(root, "no global element declaration found for {%s}%s", root.name.Space, root.name.Local)
+	} else {
+		me.element(root, decl)
+	}
+	me.identityConstraints()
+}
+
+func (me *validator) fail(n *instNode, format string, args ...interface{}) {
+	me.errs = append(me.errs, ValidationError{Path: n.path, Line: n.line, Column: n.col, Message: sfmt(format, args...)})
+}
+
+func (me *validator) elementType(decl *Element) (t *TypeDef) {
+	if t = me.compiled.TypeOf(decl); t == nil {
+		t = me.compiled.builtins["anyType"]
+	}
+	return
+}
+
+//	Returns the type assigned to n by the first XSD 1.1 type alternative of decl whose test n satisfies (or by the default alternative), if any.
+//	Alternatives with tests that evalXpathTest does not support are disregarded.
+func (me *validator) alternativeType(n *instNode, decl *Element) *TypeDef {
+	for _, alt := range decl.Alternatives {
+		if result, ok := evalXpathTest(n, ownerSchema(alt), alt.Test); (len(strings.TrimSpace(alt.Test)) == 0) || (ok && result) {
+			return me.compiled.TypeOf(alt)
+		}
+	}
+	return nil
+}
+
+func (me *validator) element(n *instNode, decl *Element) {
+	var typ = me.elementType(decl)
+	if decl.Abstract {
+		me.fail(n, "element <%s> is abstract and must be replaced by a member of its substitution group", n.name.Local)
+	}
+	if len(decl.Keys)+len(decl.Uniques)+len(decl.KeyRefs) > 0 {
+		me.idScopes = append(me.idScopes, vIdentityScope{node: n, decl: decl})
+	}
+	if t := me.alternativeType(n, decl); t != nil {
+		typ = t
+	}
+	if xt, ok := n.att(xsiNamespaceUri, "type"); ok {
+		if t := me.compiled.Type(n.qname(strings.TrimSpace(xt))); t == nil {
+			me.fail(n, "unknown type %q in xsi:type", xt)
+		} else if typ = t; (t.Complex != nil) && t.Complex.Abstract {
+			me.fail(n, "type %q in xsi:type is abstract", xt)
+		}
+	} else if (typ.Complex != nil) && typ.Complex.Abstract && !decl.Abstract {
+		me.fail(n, "element <%s> has an abstract type and requires an xsi:type naming a type derived from it", n.name.Local)
+	}
+	if xn, _ := n.att(xsiNamespaceUri, "nil"); (strings.TrimSpace(xn) == "true") || (strings.TrimSpace(xn) == "1") {
+		if !decl.Nillable {
+			me.fail(n, "xsi:nil is not allowed because element <%s> is not nillable", n.name.Local)
+		} else if (len(n.kids) > 0) || n.hasText() {
+			me.fail(n, "element <%s> is xsi:nil but not empty", n.name.Local)
+		}
+		if typ.Complex != nil {
+			me.attributes(n, typ.Complex)
+		}
+		return
+	}
+	switch {
+	case typ.Complex != nil:
+		me.complexContent(n, typ.Complex)
+	case typ.Builtin == "anyType":
+	default:
+		if len(n.kids) > 0 {
+			me.fail(n, "element <%s> has a simple type and must not contain child elements", n.name.Local)
+		}
+		me.noAttributes(n)
+		if msg := me.typeValue(n.text, typ, 0); len(msg) > 0 {
+			me.fail(n, "invalid value for element <%s>: %s", n.name.Local, msg)
+		}
+	}
+	if (len(decl.Fixed) > 0) && (len(n.kids) == 0) && (strings.TrimSpace(n.text) != strings.TrimSpace(decl.Fixed)) {
+		me.fail(n, "element <%s> must have the fixed value %q", n.name.Local, decl.Fixed)
+	}
+}
+
+func (me *validator) complexContent(n *instNode, ct *ComplexType) {
+	me.attributes(n, ct)
+	if ct.SimpleContent != nil {
+		if len(n.kids) > 0 {
+			me.fail(n, "element <%s> has simple content and must not contain child elements", n.name.Local)
+		}
+		if msg := me.typeValue(n.text, me.compiled.TypeDefOf(ct), 0); len(msg) > 0 {
+			me.fail(n, "invalid value for element <%s>: %s", n.name.Local, msg)
+		}
+		return
+	}
+	if mixed := ct.Mixed || ((ct.ComplexContent != nil) && ct.ComplexContent.Mixed); (!mixed) && n.hasText() {
+		me.fail(n, "element <%s> must not contain character data", n.name.Local)
+	}
+	n.setKidPaths()
+	me.assigned, me.best, me.farthest, me.expected = nil, nil, 0, nil
+	pos, ok := me.matchRepeated(me.contentParticle(ct), n.kids, 0)
+	assigned := me.assigned
+	if (!ok) || (pos < len(n.kids)) {
+		if ok {
+			me.farthest, me.expected = pos, nil
+		} else {
+			assigned = me.best
+		}
+		if expected := strings.Join(me.expected, ", "); me.farthest < len(n.kids) {
+			kid := n.kids[me.farthest]
+			if len(expected) > 0 {
+				me.fail(kid, "unexpected element <%s>, expected one of: %s", kid.name.Local, expected)
+			} else {
+				me.fail(kid, "unexpected element <%s>", kid.name.Local)
+			}
+		} else {
+			me.fail(n, "content of element <%s> is incomplete, expected one of: %s", n.name.Local, expected)
+		}
+	}
+	for _, a := range assigned {
+		if a.elem != nil {
+			me.element(a.node, a.elem)
+		} else if a.any != nil {
+			if decl := me.compiled.Element(a.node.name); decl != nil {
+				if a.any.ProcessContents != "skip" {
+					me.element(a.node, decl)
+				}
+			} else if (len(a.any.ProcessContents) == 0) || (a.any.ProcessContents == "strict") {
+				me.fail(a.node, "no declaration found for element <%s> matched by a strict wildcard", a.node.name.Local)
+			}
+		}
+	}
+}
+
+func (me *validator) contentParticle(ct *ComplexType) (p *vParticle) {
+	if p = me.particles[ct]; p == nil {
+		p = &vParticle{kind: particleSequence, min: 1, max: 1, kids: me.typeParticles(ct)}
+		me.particles[ct] = p
+	}
+	return
+}
+
+func (me *validator) typeParticles(ct *ComplexType) (ps []*vParticle) {
+	if cc := ct.ComplexContent; cc != nil {
+		if ext := cc.ExtensionComplexContent; ext != nil {
+			if base := me.compiled.TypeDefOf(ct).Base; (base.Complex != nil) && !base.DerivesFrom(me.compiled.TypeDefOf(ct)) {
+				ps = me.typeParticles(base.Complex)
+			}
+			ps = append(ps, me.modelParticles(ext.All, ext.Choices, ext.Groups, ext.Sequences)...)
+		} else if res := cc.RestrictionComplexContent; res != nil {
+			ps = me.modelParticles(res.All, res.Choices, nil, res.Sequences)
+		}
+	} else if ct.SimpleContent == nil {
+		ps = me.modelParticles(ct.All, []*Choice{ct.Choice}, []*Group{ct.Group}, []*Sequence{ct.Sequence})
+	}
+	return
+}
+
+func (me *validator) modelParticles(all *All, choices []*Choice, groups []*Group, seqs []*Sequence) (ps []*vParticle) {
+	if all != nil {
+		ps = append(ps, me.particle(all))
+	}
+	for _, ch := range choices {
+		if ch != nil {
+			ps = append(ps, me.particle(ch))
+		}
+	}
+	for _, gr := range groups {
+		if gr != nil {
+			ps = append(ps, me.particle(gr))
+		}
+	}
+	for _, seq := range seqs {
+		if seq != nil {
+			ps = append(ps, me.particle(seq))
+		}
+	}
+	return
+}
+
+func (me *validator) particle(el element) (p *vParticle) {
+	switch x := el.(type) {
+	case *Element:
+		p = &vParticle{kind: particleElement, min: int64(x.hasAttrMinOccurs.Value()), max: int64(x.hasAttrMaxOccurs.Value()), elem: x, name: me.compiled.comps.elementName(x)}
+		if glob, _ := me.compiled.Resolve(x).(*Element); glob != nil {
+			p.elem = glob
+		}
+	case *Any:
+		p = &vParticle{kind: particleAny, min: int64(x.hasAttrMinOccurs.Value()), max: int64(x.hasAttrMaxOccurs.Value()), any: x}
+	case *All:
+		p = &vParticle{kind: particleAll, min: int64(x.hasAttrMinOccurs.Value()), max: 1}
+		for _, el := range x.Elements {
+			p.kids = append(p.kids, me.particle(el))
+		}
+	case *Choice:
+		p = &vParticle{kind: particleChoice, min: int64(x.hasAttrMinOccurs.Value()), max: int64(x.hasAttrMaxOccurs.Value())}
+		for _, el := range x.Elements {
+			p.kids = append(p.kids, me.particle(el))
+		}
+		for _, any := range x.Anys {
+			p.kids = append(p.kids, me.particle(any))
+		}
+		p.kids = append(p.kids, me.modelParticles(nil, x.Choices, x.Groups, x.Sequences)...)
+	case *Sequence:
+		p = &vParticle{kind: particleSequence, min: int64(x.hasAttrMinOccurs.Value()), max: int64(x.hasAttrMaxOccurs.Value())}
+		for _, el := range x.Particles() {
+			p.kids = append(p.kids, me.particle(el))
+		}
+	case *Group:
+		p = &vParticle{kind: particleSequence, min: int64(x.hasAttrMinOccurs.Value()), max: int64(x.hasAttrMaxOccurs.Value())}
+		if gr, _ := me.compiled.Resolve(x).(*Group); (gr != nil) && !me.groupsBusy[gr] {
+			me.groupsBusy[gr] = true
+			p.kids = me.modelParticles(gr.All, []*Choice{gr.Choice}, nil, []*Sequence{gr.Sequence})
+			delete(me.groupsBusy, gr)
+		}
+	}
+	return
+}
+
+func (me *validator) expect(pos int, what string) {
+	if pos > me.farthest {
+		me.farthest, me.expected = pos, nil
+		me.best = append(me.best[:0], me.assigned...)
+	}
+	if pos == me.farthest {
+		for _, e := range me.expected {
+			if e == what {
+				return
+			}
+		}
+		me.expected = append(me.expected, what)
+	}
+}
+
+func (me *validator) matchRepeated(p *vParticle, kids []*instNode, pos int) (int, bool) {
+	var count int64
+	for count = 0; (p.max < 0) || (count < p.max); count++ {
+		mark := len(me.assigned)
+		np, ok := me.matchOnce(p, kids, pos)
+		if !ok {
+			me.assigned = me.assigned[:mark]
+			break
+		} else if np == pos {
+			return pos, true
+		}
+		pos = np
+	}
+	return pos, count >= p.min
+}
+
+func (me *validator) matchOnce(p *vParticle, kids []*instNode, pos int) (int, bool) {
+	switch p.kind {
+	case particleElement:
+		if pos < len(kids) {
+			if decl := me.elementMatch(p, kids[pos]); decl != nil {
+				me.assigned = append(me.assigned, vAssignment{node: kids[pos], elem: decl})
+				return pos + 1, true
+			}
+		}
+		me.expect(pos, "<"+p.name.Local+">")
+	case particleAny:
+		if (pos < len(kids)) && namespaceAllowed(p.any.Namespace, ownerSchema(p.any).TargetNamespace.String(), kids[pos].name.Space) {
+			me.assigned = append(me.assigned, vAssignment{node: kids[pos], any: p.any})
+			return pos + 1, true
+		}
+		me.expect(pos, "any element")
+	case particleSequence:
+		var ok bool
+		cur := pos
+		for _, k := range p.kids {
+			if cur, ok = me.matchRepeated(k, kids, cur); !ok {
+				return pos, false
+			}
+		}
+		return cur, true
+	case particleChoice:
+		var emptyOk bool
+		for _, k := range p.kids {
+			mark := len(me.assigned)
+			if np, ok := me.matchRepeated(k, kids, pos); ok && (np > pos) {
+				return np, true
+			} else if ok {
+				emptyOk = true
+			}
+			me.assigned = me.assigned[:mark]
+		}
+		return pos, emptyOk || (len(p.kids) == 0)
+	case particleAll:
+		var used = make([]bool, len(p.kids))
+		cur := pos
+		for matched := true; matched && (cur < len(kids)); {
+			matched = false
+			for i, k := range p.kids {
+				if !used[i] {
+					if decl := me.elementMatch(k, kids[cur]); decl != nil {
+						me.assigned = append(me.assigned, vAssignment{node: kids[cur], elem: decl})
+						used[i], matched, cur = true, true, cur+1
+						break
+					}
+				}
+			}
+		}
+		ok := true
+		for i, k := range p.kids {
+			if (!used[i]) && (k.min > 0) {
+				me.expect(cur, "<"+k.name.Local+">")
+				ok = false
+			}
+		}
+		if ok {
+			return cur, true
+		}
+	}
+	return pos, false
+}
+
+// Returns the declaration to validate n against if n matches the element particle p, either directly or as a member of p's substitution group.
+func (me *validator) elementMatch(p *vParticle, n *instNode) *Element {
+	if n.name == p.name {
+		return p.elem
+	}
+	if decl := me.compiled.Element(n.name); (decl != nil) && isGlobal(p.elem) {
+		for _, member := range me.compiled.Substitutes(p.elem) {
+			if member == decl {
+				return decl
+			}
+		}
+	}
+	return nil
+}
+
+func namespaceAllowed(constraint, targetNamespace, ns string) bool {
+	switch constraint = strings.TrimSpace(constraint); constraint {
+	case "", "##any":
+		return true
+	case "##other":
+		return (ns != targetNamespace) && (len(ns) > 0)
+	}
+	for _, c := range strings.Fields(constraint) {
+		if (c == ns) || ((c == "##targetNamespace") && (ns == targetNamespace)) || ((c == "##local") && (len(ns) == 0)) {
+			return true
+		}
+	}
+	return false
+}
+
+func isSpecialAttr(a xml.Attr) bool {
+	return (a.Name.Space == "xmlns") || ((len(a.Name.Space) == 0) && (a.Name.Local == "xmlns")) || (a.Name.Space == xsiNamespaceUri)
+}
+
+func (me *validator) attributes(n *instNode, ct *ComplexType) {
+	var uses, names = map[xml.Name]*AttributeUse{}, []string{}
+	var present = map[xml.Name]bool{}
+	for _, use := range me.compiled.AttributeUses(ct) {
+		uses[use.Name] = use
+	}
+	for _, a := range n.atts {
+		if isSpecialAttr(a) {
+			continue
+		}
+		present[a.Name] = true
+		if use := uses[a.Name]; use != nil {
+			if msg := me.typeValue(a.Value, use.Type, 0); len(msg) > 0 {
+				me.fail(n, "invalid value for attribute %q: %s", a.Name.Local, msg)
+			} else if (len(use.Fixed) > 0) && (strings.TrimSpace(a.Value) != strings.TrimSpace(use.Fixed)) {
+				me.fail(n, "attribute %q must have the fixed value %q", a.Name.Local, use.Fixed)
+			}
+		} else if a.Name.Space != xmlNamespaceUri {
+			allowed := false
+			for _, wc := range me.compiled.AttributeWildcards(ct) {
+				if allowed = namespaceAllowed(wc.Namespace, ownerSchema(wc).TargetNamespace.String(), a.Name.Space); allowed {
+					break
+				}
+			}
+			if !allowed {
+				me.fail(n, "attribute %q is not allowed on element <%s>", a.Name.Local, n.name.Local)
+			}
+		}
+	}
+	for name, use := range uses {
+		if use.Required && !present[name] {
+			names = append(names, name.Local)
+		}
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		me.fail(n, "missing required attribute %q on element <%s>", name, n.name.Local)
+	}
+	if me.defaults {
+		me.addDefaults(n, me.compiled.AttributeUses(ct), present)
+	}
+}
+
+//	Records (in n.defaults) the attributes of uses absent from n (that is, not present) that have a fixed or default value.
+func (me *validator) addDefaults(n *instNode, uses []*AttributeUse, present map[xml.Name]bool) {
+	for _, use := range uses {
+		if (!present[use.Name]) && ((len(use.Fixed) > 0) || (len(use.Default) > 0)) {
+			n.defaults = append(n.defaults, xml.Attr{Name: use.Name, Value: ustr.Ifs(len(use.Fixed) > 0, use.Fixed, use.Default)})
+		}
+	}
+}
+
+func (me *validator) noAttributes(n *instNode) {
+	for _, a := range n.atts {
+		if (!isSpecialAttr(a)) && (a.Name.Space != xmlNamespaceUri) {
+			me.fail(n, "attribute %q is not allowed on simple-typed element <%s>", a.Name.Local, n.name.Local)
+		}
+	}
+}
+
+//	Checks value against the simple type t or, if t is a complex type with simple content, against its content type.
+func (me *validator) typeValue(value string, t *TypeDef, depth int) (msg string) {
+	if depth > 64 {
+		return
+	}
+	switch {
+	case len(t.Builtin) > 0:
+		return checkBuiltinValue(t.Builtin, value)
+	case t.Complex != nil:
+		return me.simpleContentValue(value, t, depth)
+	case t.Derivation == "list":
+		for _, item := range strings.Fields(value) {
+			if msg = me.typeValue(item, t.ItemType, depth+1); len(msg) > 0 {
+				return sfmt("list item %q: %s", item, msg)
+			}
+		}
+	case t.Derivation == "union":
+		for _, mt := range t.MemberTypes {
+			if len(me.typeValue(value, mt, depth+1)) == 0 {
+				return
+			}
+		}
+		msg = sfmt("%q is not valid for any member type of the union", value)
+	case (t.Simple != nil) && (t.Simple.RestrictionSimpleType != nil):
+		res := t.Simple.RestrictionSimpleType
+		if msg = me.typeValue(value, t.Base, depth+1); (len(msg) == 0) && t.Base.IsList() {
+			msg = checkListFacets(res.facets(), value)
+		} else if len(msg) == 0 {
+			msg = checkFacets(res.facets(), value, t.Base.BuiltinBase())
+		}
+	}
+	return
+}
+
+//	Checks value against the simple content of the complex type t, if any.
+func (me *validator) simpleContentValue(value string, t *TypeDef, depth int) (msg string) {
+	if sc := t.Complex.SimpleContent; sc != nil {
+		if sc.ExtensionSimpleContent != nil {
+			msg = me.typeValue(value, t.Base, depth+1)
+		} else if res := sc.RestrictionSimpleContent; res != nil {
+			if len(res.SimpleTypes) > 0 {
+				msg = me.typeValue(value, me.compiled.TypeDefOf(res.SimpleTypes[0]), depth+1)
+			} else {
+				msg = me.typeValue(value, t.Base, depth+1)
+			}
+			if len(msg) == 0 {
+				msg = checkFacets(res.facets(), value, t.Base.BuiltinBase())
+			}
+		}
+	}
+	return
+}
+
+func (me *RestrictionSimpleType) facets() (f *xsdt.Facets) {
+	f = &xsdt.Facets{}
+	for _, enum := range me.Enumerations {
+		f.Enumerations = append(f.Enumerations, enum.Value)
+	}
+	collectFacets(f, me.Pattern, me.WhiteSpace, me.Length, me.MinLength, me.MaxLength, me.TotalDigits, me.FractionDigits, me.MinInclusive, me.MaxInclusive, me.MinExclusive, me.MaxExclusive)
+	return
+}
+
+func (me *RestrictionSimpleContent) facets() (f *xsdt.Facets) {
+	f = &xsdt.Facets{}
+	for _, enum := range me.Enumerations {
+		f.Enumerations = append(f.Enumerations, enum.Value)
+	}
+	collectFacets(f, me.Pattern, me.WhiteSpace, me.Length, me.MinLength, me.MaxLength, me.TotalDigits, me.FractionDigits, me.MinInclusive, me.MaxInclusive, me.MinExclusive, me.MaxExclusive)
+	return
+}
+
+func collectFacets(f *xsdt.Facets, pattern *RestrictionSimplePattern, whiteSpace *RestrictionSimpleWhiteSpace, length *RestrictionSimpleLength, minLength *RestrictionSimpleMinLength, maxLength *RestrictionSimpleMaxLength, totalDigits *RestrictionSimpleTotalDigits, fractionDigits *RestrictionSimpleFractionDigits, minInclusive *RestrictionSimpleMinInclusive, maxInclusive *RestrictionSimpleMaxInclusive, minExclusive *RestrictionSimpleMinExclusive, maxExclusive *RestrictionSimpleMaxExclusive) {
+	if pattern != nil {
+		f.Pattern = pattern.Value
+	}
+	if whiteSpace != nil {
+		f.WhiteSpace = whiteSpace.Value
+	}
+	if length != nil {
+		f.Length = length.Value
+	}
+	if minLength != nil {
+		f.MinLength = minLength.Value
+	}
+	if maxLength != nil {
+		f.MaxLength = maxLength.Value
+	}
+	if totalDigits != nil {
+		f.TotalDigits = totalDigits.Value
+	}
+	if fractionDigits != nil {
+		f.FractionDigits = fractionDigits.Value
+	}
+	if minInclusive != nil {
+		f.MinInclusive = minInclusive.Value
+	}
+	if maxInclusive != nil {
+		f.MaxInclusive = maxInclusive.Value
+	}
+	if minExclusive != nil {
+		f.MinExclusive = minExclusive.Value
+	}
+	if maxExclusive != nil {
+		f.MaxExclusive = maxExclusive.Value
+	}
+}
+
+//	Checks value against the specified facets, returning a description of the first violation found (if any).
+//	Values of types not derived from xs:string or xs:normalizedString always have their whitespace collapsed.
+func checkFacets(f *xsdt.Facets, value, builtin string) string {
+	if (len(builtin) > 0) && (builtin != "string") && (builtin != "normalizedString") {
+		f.WhiteSpace = "collapse"
+	}
+	if len(f.Pattern) > 0 {
+		if _, err := xsdt.CompilePattern(f.Pattern); err != nil {
+			return sfmt("%q cannot be checked: %v", value, err)
+		}
+	}
+	if err := f.Check(value); err != nil {
+		return err.Error()
+	}
+	return ""
+}
+
+//	Like checkFacets, for restrictions of list types, whose length facets constrain the number of list items rather than characters.
+func checkListFacets(f *xsdt.Facets, value string) string {
+	var count = len(strings.Fields(value))
+	for i, bound := range []string{f.Length, f.MinLength, f.MaxLength} {
+		if n, err := strconv.Atoi(strings.TrimSpace(bound)); (err == nil) && (((i == 0) && (count != n)) || ((i == 1) && (count < n)) || ((i == 2) && (count > n))) {
+			return sfmt("%q must have %s %d list items", value, []string{"exactly", "at least", "at most"}[i], n)
+		}
+	}
+	f.Length, f.MinLength, f.MaxLength = "", "", ""
+	return checkFacets(f, value, "token")
+}
+
+//	Evaluates the subset of XPath 2.0 commonly used in the tests of XSD 1.1 type alternatives against the attributes of n:
+//	attribute existence (@a), comparisons of attributes with string or numeric literals (@a = 'x', @a != 'x', @a < 5 etc.),
+//	not(...), and unparenthesized combinations thereof via "and" / "or". For any other test, ok is false.
+func evalXpathTest(n *instNode, owner *Schema, test string) (result, ok bool) {
+	test = strings.TrimSpace(test)
+	for strings.HasPrefix(test, "(") && strings.HasSuffix(test, ")") && !strings.ContainsAny(test[1:len(test)-1], "()") {
+		test = strings.TrimSpace(test[1 : len(test)-1])
+	}
+	if strings.HasPrefix(test, "not(") && strings.HasSuffix(test, ")") && !strings.ContainsAny(test[4:len(test)-1], "()") {
+		result, ok = evalXpathTest(n, owner, test[4:len(test)-1])
+		return !result, ok
+	}
+	if strings.ContainsAny(test, "()") {
+		return
+	}
+	if disj := strings.Split(test, " or "); len(disj) > 1 {
+		for _, t := range disj {
+			var r bool
+			if r, ok = evalXpathTest(n, owner, t); !ok {
+				return
+			}
+			result = result || r
+		}
+		return
+	}
+	if conj := strings.Split(test, " and "); len(conj) > 1 {
+		result = true
+		for _, t := range conj {
+			var r bool
+			if r, ok = evalXpathTest(n, owner, t); !ok {
+				return
+			}
+			result = result && r
+		}
+		return
+	}
+	if m := xpathComparison.FindStringSubmatch(test); m != nil {
+		var qn = xml.Name{Local: m[1]}
+		if strings.Contains(m[1], ":") {
+			qn = owner.qname(m[1])
+		}
+		val, found := n.att(qn.Space, qn.Local)
+		if (len(m[2]) == 0) || !found {
+			return found, true
+		}
+		var cmp int
+		lit := strings.TrimSpace(m[3])
+		if (len(lit) > 1) && ((lit[0] == '\'') || (lit[0] == '"')) && (lit[len(lit)-1] == lit[0]) {
+			cmp = strings.Compare(strings.TrimSpace(val), lit[1:len(lit)-1])
+		} else if litNum, isNum := new(big.Rat).SetString(lit); !isNum {
+			return
+		} else if valNum, isNum := new(big.Rat).SetString(strings.TrimSpace(val)); !isNum {
+			return false, true
+		} else {
+			cmp = valNum.Cmp(litNum)
+		}
+		switch m[2] {
+		case "=":
+			result = cmp == 0
+		case "!=":
+			result = cmp != 0
+		case "<":
+			result = cmp < 0
+		case "<=":
+			result = cmp <= 0
+		case ">":
+			result = cmp > 0
+		case ">=":
+			result = cmp >= 0
+		}
+		return result, true
+	}
+	return
+}
+
+// Checks the lexical form of value against the specified built-in XSD type, returning a description of the problem (if any).
+func checkBuiltinValue(builtin, value string) string {
+	if (builtin != "string") && (builtin != "normalizedString") && (builtin != "anySimpleType") && (builtin != "anyType") {
+		value = strings.TrimSpace(value)
+	}
+	switch builtin {
+	case "boolean":
+		if (value != "true") && (value != "false") && (value != "1") && (value != "0") {
+			return sfmt("%q is not a valid boolean", value)
+		}
+	case "float", "double":
+		if _, err := strconv.ParseFloat(value, 64); (err != nil) && (value != "INF") && (value != "-INF") && (value != "NaN") {
+			return sfmt("%q is not a valid %s", value, builtin)
+		}
+	case "hexBinary":
+		if _, err := hex.DecodeString(value); err != nil {
+			return sfmt("%q is not a valid hexBinary", value)
+		}
+	case "base64Binary":
+		if _, err := base64.StdEncoding.DecodeString(strings.Join(strings.Fields(value), "")); err != nil {
+			return sfmt("%q is not a valid base64Binary", value)
+		}
+	case "ID", "IDREF", "ENTITY":
+		if !builtinPatterns["NCName"].MatchString(value) {
+			return sfmt("%q is not a valid %s", value, builtin)
+		}
+	default:
+		if re := builtinPatterns[builtin]; (re != nil) && !re.MatchString(value) {
+			return sfmt("%q is not a valid %s", value, builtin)
+		}
+		//	Years of other than 4 digits (or negative ones) are beyond time.Time, so only their lexical forms are checked.
+		if parser := builtinParsers[builtin]; (parser != nil) && (((builtin != "date") && (builtin != "dateTime")) || (value[4] == '-')) {
+			if err := parser().UnmarshalText([]byte(value)); err != nil {
+				return sfmt("%q is not a valid %s", value, builtin)
+			}
+		}
+		if rng, ok := builtinRanges[builtin]; ok {
+			num, ok := new(big.Int).SetString(strings.TrimPrefix(value, "+"), 10)
+			if !ok {
+				return sfmt("%q is not a valid %s", value, builtin)
+			}
+			for i, bound := range rng {
+				if b, ok := new(big.Int).SetString(bound, 10); ok {
+					if c := num.Cmp(b); ((i == 0) && (c < 0)) || ((i == 1) && (c > 0)) {
+						return sfmt("%s is out of range for %s", value, builtin)
+					}
+				}
+			}
+		}
+	}
+	return ""
+}
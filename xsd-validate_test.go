@@ -0,0 +1,178 @@
+package xsd
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestCheckBuiltinValueDatesAndTimes(t *testing.T) {
+	for _, c := range []struct {
+		builtin, value string
+		valid          bool
+	}{
+		{"dateTime", "2020-12-31T23:59:60Z", false},
+		{"dateTime", "2020-13-45T25:61:00", false},
+		{"dateTime", "2020-02-29T24:00:00", true},
+		{"dateTime", "2020-05-30T09:30:10.5+06:00", true},
+		{"dateTime", "12020-05-30T09:30:10", true},
+		{"date", "2021-02-29", false},
+		{"date", "2020-02-29Z", true},
+		{"date", "-0044-03-15", true},
+		{"time", "25:00:00", false},
+		{"time", "13:20:00.5-05:00", true},
+		{"time", "13:20:00+15:00", false},
+		{"duration", "P", false},
+		{"duration", "P1YT", false},
+		{"duration", "-P1Y2M3DT10H30M0.5S", true},
+	} {
+		if msg := checkBuiltinValue(c.builtin, c.value); (len(msg) == 0) != c.valid {
+			t.Errorf("%s %q: expected valid=%v, got %q", c.builtin, c.value, c.valid, msg)
+		}
+	}
+}
+
+func TestValidate(t *testing.T) {
+	sd := loadTestSchema(t, "validate", "order.xsd")
+	item := "<item><sku>ABC-123</sku><qty>2</qty></item>"
+	for _, c := range []struct {
+		doc, path string
+	}{
+		{`<order xmlns="urn:example:validate" id="1"><date>2020-02-29</date>` + item + `</order>`, ""},
+		{`<order xmlns="urn:example:validate"><date>2020-02-29</date>` + item + `</order>`, "/order"},
+		{`<order xmlns="urn:example:validate" id="x"><date>2020-02-29</date>` + item + `</order>`, "/order"},
+		{`<order xmlns="urn:example:validate" id="1"><date>2021-02-29</date>` + item + `</order>`, "/order/date"},
+		{`<order xmlns="urn:example:validate" id="1"><date>2020-02-29</date>` + item + item + item + `</order>`, "/order/item[3]"},
+		{`<order xmlns="urn:example:validate" id="1">` + item + `<date>2020-02-29</date></order>`, "/order/item"},
+		{`<order xmlns="urn:example:validate" id="1"><date>2020-02-29</date><item><sku>abc-123</sku><qty>2</qty></item></order>`, "/order/item/sku"},
+		{`<order xmlns="urn:example:validate" id="1"><date>2020-02-29</date><item><sku>ABC-123</sku><qty>0</qty></item></order>`, "/order/item/qty"},
+	} {
+		errs, err := sd.Validate(strings.NewReader(c.doc))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(c.path) == 0 {
+			for _, verr := range errs {
+				t.Errorf("%s: unexpected %v", c.doc, verr)
+			}
+		} else if (len(errs) == 0) || (errs[0].Path != c.path) {
+			t.Errorf("%s: expected an error at %s, got %v", c.doc, c.path, errs)
+		}
+	}
+	if _, err := sd.Validate(strings.NewReader(`<order xmlns="urn:example:validate" id="1">`)); err == nil {
+		t.Error("expected an error for a document that is not well-formed")
+	}
+}
+
+func TestXsd11AssertsAndAlternatives(t *testing.T) {
+	src, _ := genTestSrc(t, "xsd11", "shapes.xsd", nil)
+	if !strings.Contains(src, "// XSD 1.1 assertion: @min le @max\ntype TRange struct {") {
+		t.Errorf("the assertion is not documented on TRange:\n%s", src)
+	}
+	if !strings.Contains(goTypeDecl(t, src, "XsdGoPkgHasElem_Shape"), "XSD 1.1 type alternative: if @kind = 'circle', the type is TCircle.") {
+		t.Errorf("the type alternative is not documented on the field of shape:\n%s", src)
+	}
+	sd := loadTestSchema(t, "xsd11", "shapes.xsd")
+	for doc, valid := range map[string]bool{
+		`<shape xmlns="urn:example:xsd11" kind="square"/>`:            true,
+		`<shape xmlns="urn:example:xsd11" kind="circle" radius="2"/>`: true,
+		`<shape xmlns="urn:example:xsd11" kind="circle"/>`:            false,
+		`<shape xmlns="urn:example:xsd11" kind="square" radius="2"/>`: false,
+	} {
+		if errs, err := sd.Validate(strings.NewReader(doc)); err != nil {
+			t.Fatal(err)
+		} else if (len(errs) == 0) != valid {
+			t.Errorf("%s: expected valid=%v, got %v", doc, valid, errs)
+		}
+	}
+}
+
+//	Tests that xs:pattern facets are checked in the XSD regular expression dialect by both the validator and the generated Validate() methods,
+//	and that patterns which cannot be translated are reported as ignored by the generator and as unchecked values by the validator.
+func TestXsdPatterns(t *testing.T) {
+	_, diags := genTestSrc(t, "patterns", "doc.xsd", func(opts *GenOptions) { opts.AddValidators = true })
+	if ignored := diags.Ignored(); (len(ignored) != 1) || (ignored[0].Line != 10) || !strings.Contains(ignored[0].Message, `the pattern facet "\\p{IsOldItalic}+" is not enforced by the generated Go code: xs:pattern "\\p{IsOldItalic}+" at offset 0: the Unicode block OldItalic is not supported`) {
+		t.Errorf("expected the untranslatable pattern to be reported as ignored, got %v", ignored)
+	}
+	sd := loadTestSchema(t, "patterns", "doc.xsd")
+	for doc, msg := range map[string]string{
+		`<doc xmlns="urn:example:patterns" code="^AB٣"/>`: "",
+		`<doc xmlns="urn:example:patterns" code="^AI3"/>`: `"^AI3" does not match the pattern`,
+		`<doc xmlns="urn:example:patterns" code="AB3"/>`:  `"AB3" does not match the pattern`,
+		`<doc xmlns="urn:example:patterns" runes="x"/>`:   `"x" cannot be checked: xs:pattern "\\p{IsOldItalic}+" at offset 0`,
+	} {
+		if errs, err := sd.Validate(strings.NewReader(doc)); err != nil {
+			t.Fatal(err)
+		} else if (len(msg) == 0) && (len(errs) > 0) {
+			t.Errorf("%s: unexpected %v", doc, errs)
+		} else if (len(msg) > 0) && ((len(errs) != 1) || !strings.Contains(errs[0].Error(), msg)) {
+			t.Errorf("%s: expected an error containing %s, got %v", doc, msg, errs)
+		}
+	}
+	gopath, goOutFilePaths := genTestPkgs(t, "patterns", func(opts *GenOptions) { opts.AddValidators = true })
+	goTestPkg(t, gopath, goOutFilePaths[0], `package go_Doc
+
+import "testing"
+
+func TestPatterns(t *testing.T) {
+	for code, valid := range map[string]bool{"^AB٣": true, "^AI3": false, "AB3": false} {
+		if err := TCode(code).Validate(); (err == nil) != valid {
+			t.Errorf("%s: expected valid=%v, got %v", code, valid, err)
+		}
+	}
+	if err := TRunes("x").Validate(); err != nil {
+		t.Errorf("expected the untranslatable pattern to be disregarded, got %v", err)
+	}
+}
+`)
+}
+
+//	Tests that abstract elements and elements of abstract types without an xsi:type naming a derived type are rejected by the validator,
+//	and by the generated CheckBeforeMarshal() methods with PkgGen.AddMarshalChecks set.
+func TestAbstractElementsAndTypes(t *testing.T) {
+	sd := loadTestSchema(t, "abstract", "doc.xsd")
+	drawing := `<drawing xmlns="urn:example:abstract" xmlns:xsi="http://www.w3.org/2001/XMLSchema-instance">`
+	for doc, msg := range map[string]string{
+		drawing + `<circle r="1"/><item xsi:type="Circle" r="2"/><title>x</title></drawing>`: "",
+		drawing + `<shape/></drawing>`:                                "/drawing/shape (line 1, column 101): element <shape> is abstract and must be replaced by a member of its substitution group",
+		drawing + `<circle r="1"/><label>x</label></drawing>`:         "/drawing/label (line 1, column 115): element <label> is abstract and must be replaced by a member of its substitution group",
+		drawing + `<circle r="1"/><item/></drawing>`:                  "/drawing/item (line 1, column 115): element <item> has an abstract type and requires an xsi:type naming a type derived from it",
+		drawing + `<circle r="1"/><item xsi:type="Shape"/></drawing>`: `/drawing/item (line 1, column 132): type "Shape" in xsi:type is abstract`,
+	} {
+		if errs, err := sd.Validate(strings.NewReader(doc)); err != nil {
+			t.Fatal(err)
+		} else if (len(msg) == 0) && (len(errs) > 0) {
+			t.Errorf("%s: unexpected %v", doc, errs)
+		} else if (len(msg) > 0) && ((len(errs) != 1) || (errs[0].Error() != msg)) {
+			t.Errorf("%s: expected the error %s, got %v", doc, msg, errs)
+		}
+	}
+	gopath, goOutFilePaths := genTestPkgs(t, "abstract", func(opts *GenOptions) { opts.AddMarshalChecks = true })
+	goTestPkg(t, gopath, goOutFilePaths[0], `package go_Doc
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestAbstractChecks(t *testing.T) {
+	var shape TShape
+	if err := shape.CheckBeforeMarshal(); (err == nil) || !strings.Contains(err.Error(), "is of the abstract type Shape") {
+		t.Errorf("expected an error for an instance of the abstract type, got %v", err)
+	}
+	circle := &TCircle{}
+	circle.R = 1
+	if shape.XsdGoPkgXsiType = circle; shape.CheckBeforeMarshal() != nil {
+		t.Errorf("expected an instance of a derived type to pass, got %v", shape.CheckBeforeMarshal())
+	}
+	var drawing TxsdDrawing
+	drawing.Shape = XsdGoPkgSubsts_Shape{circle}
+	if err := drawing.CheckBeforeMarshal(); err != nil {
+		t.Errorf("expected a member of the substitution group to pass, got %v", err)
+	}
+	drawing.Label = "x"
+	if err := drawing.CheckBeforeMarshal(); (err == nil) || !strings.Contains(err.Error(), "label: is abstract and must be replaced by a member of its substitution group") {
+		t.Errorf("expected an error for the abstract label, got %v", err)
+	}
+}
+`)
+}
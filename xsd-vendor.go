@@ -0,0 +1,146 @@
+package xsd
+
+import (
+	"bytes"
+	"context"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"path"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/metaleap/go-util-fs"
+)
+
+//	The name of the OASIS XML Catalog that VendorSchema writes into the vendor directory.
+const VendorCatalogFile = "catalog.xml"
+
+//	Matches the schemaLocation attributes (and their values, in group 3) of the raw schema documents rewritten by VendorSchema.
+var vendorLocationPattern = regexp.MustCompile(`(\sschemaLocation\s*=\s*)(["'])([^"'<>&]*)(["'])`)
+
+//	A schema document fetched for VendorSchema: the uri it was fetched from (with its protocol) and its contents.
+type vendoredDoc struct {
+	remoteUri string
+	raw       []byte
+}
+
+//	An OASIS XML Catalog as written by VendorSchema: it maps the uris of the vendored schema documents to their copies.
+type vendorCatalog struct {
+	XMLName xml.Name            `xml:"urn:oasis:names:tc:entity:xmlns:xml:catalog catalog"`
+	Uris    []*vendorCatalogUri `xml:"uri"`
+}
+
+type vendorCatalogUri struct {
+	Name string `xml:"name,attr"`
+	Uri  string `xml:"uri,attr"`
+}
+
+//	Loads the XML Schema Definition at uri (as LoadSchemaContext does with localCopy being false, but with a SchemaCache of its own, so that none
+//	of its schema documents are taken from DefaultSchemaCache) and writes all the schema documents it includes, imports, redefines and overrides,
+//	transitively, as they were fetched (via PkgGen.Resolver, PkgGen.Catalog or downloads) into the directory dirPath, laid out by their protocol-less
+//	uris, such as "www.w3.org/2001/xml.xsd", so that relative schemaLocations keep referring to the same documents. Also writes the OASIS XML Catalog
+//	VendorCatalogFile into dirPath, mapping the uris of all vendored documents (and those of the catalog written there before, if any) to their copies:
+//	with it loaded as PkgGen.Catalog (or via the -catalog flag of go-xsd-gen), all later loads of the schema need no network access at all.
+//	If rewriteLocations is true, the absolute schemaLocations (such as "http://www.w3.org/2001/xml.xsd") of the copies are rewritten to the relative
+//	paths of the vendored documents they refer to, so that the copies load from dirPath (such as via LoadSchemaDir) even without the catalog.
+//	Returns the file paths of the copies and the catalog written.
+func VendorSchema(ctx context.Context, uri, dirPath string, rewriteLocations bool) (filePaths []string, err error) {
+	var cancel context.CancelFunc
+	var uris []string
+	var catalog vendorCatalog
+	ctx, cancel = context.WithCancel(ctx)
+	defer cancel()
+	loader := newSchemaLoader(ctx, NewSchemaCache(0), LoadOptions{})
+	loader.vendored = map[string]*vendoredDoc{}
+	if _, err = loader.loadUri(uri, "", false); err != nil {
+		return
+	}
+	for docUri, _ := range loader.vendored {
+		uris = append(uris, docUri)
+	}
+	sort.Strings(uris)
+	catalogPath := filepath.Join(dirPath, VendorCatalogFile)
+	if raw, readErr := ioutil.ReadFile(catalogPath); readErr == nil {
+		if err = xml.Unmarshal(raw, &catalog); err != nil {
+			return nil, fmt.Errorf("%s: %v", catalogPath, err)
+		}
+	}
+	for _, docUri := range uris {
+		var doc = loader.vendored[docUri]
+		var localPath = filepath.Join(dirPath, filepath.FromSlash(docUri))
+		var raw = doc.raw
+		if cleaned := path.Clean(docUri); (cleaned == "..") || strings.HasPrefix(cleaned, "../") {
+			return filePaths, fmt.Errorf("cannot vendor %s outside of %s", doc.remoteUri, dirPath)
+		}
+		if rewriteLocations {
+			raw = rewriteVendorLocations(raw, docUri, loader.vendored)
+		}
+		if err = ufs.EnsureDirExists(filepath.Dir(localPath)); err == nil {
+			err = ufs.WriteBinaryFile(localPath, raw)
+		}
+		if err != nil {
+			return
+		}
+		catalog.add(doc.remoteUri, docUri)
+		filePaths = append(filePaths, localPath)
+	}
+	sort.Sort(vendorCatalogUris(catalog.Uris))
+	var buf bytes.Buffer
+	buf.WriteString(xml.Header)
+	enc := xml.NewEncoder(&buf)
+	enc.Indent("", "\t")
+	if err = enc.Encode(&catalog); err == nil {
+		buf.WriteByte('\n')
+		if err = ufs.WriteBinaryFile(catalogPath, buf.Bytes()); err == nil {
+			filePaths = append(filePaths, catalogPath)
+		}
+	}
+	return
+}
+
+//	Adds (or updates) the entry mapping remoteUri to the vendored copy of the schema document at the protocol-less docUri.
+func (me *vendorCatalog) add(remoteUri, docUri string) {
+	for _, entry := range me.Uris {
+		if entry.Name == remoteUri {
+			entry.Uri = docUri
+			return
+		}
+	}
+	me.Uris = append(me.Uris, &vendorCatalogUri{Name: remoteUri, Uri: docUri})
+}
+
+type vendorCatalogUris []*vendorCatalogUri
+
+func (me vendorCatalogUris) Len() int           { return len(me) }
+func (me vendorCatalogUris) Less(i, j int) bool { return me[i].Name < me[j].Name }
+func (me vendorCatalogUris) Swap(i, j int)      { me[i], me[j] = me[j], me[i] }
+
+//	Returns raw (the contents of the schema document vendored from the protocol-less docUri) with those of its absolute schemaLocations that refer
+//	to vendored schema documents replaced by the relative paths of these.
+func rewriteVendorLocations(raw []byte, docUri string, vendored map[string]*vendoredDoc) []byte {
+	return vendorLocationPattern.ReplaceAllFunc(raw, func(match []byte) []byte {
+		parts := vendorLocationPattern.FindSubmatch(match)
+		location := string(parts[3])
+		if _, uri := splitUri(location, docUri); strings.Contains(location, protSep) && (vendored[uri] != nil) {
+			if rel, err := filepath.Rel(filepath.FromSlash(path.Dir("/"+docUri)), filepath.FromSlash(path.Clean("/"+uri))); err == nil {
+				return []byte(string(parts[1]) + string(parts[2]) + filepath.ToSlash(rel) + string(parts[4]))
+			}
+		}
+		return match
+	})
+}
+
+//	Reads the schema document fetched from protocol+uri for VendorSchema, returning a reader of its contents for decoding.
+func (me *schemaLoader) vendor(protocol, uri string, r io.Reader) (io.Reader, error) {
+	raw, err := ioutil.ReadAll(r)
+	if err == nil {
+		me.mutex.Lock()
+		me.vendored[uri] = &vendoredDoc{remoteUri: protocol + uri, raw: raw}
+		me.mutex.Unlock()
+	}
+	return bytes.NewReader(raw), err
+}
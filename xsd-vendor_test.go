@@ -0,0 +1,74 @@
+package xsd
+
+import (
+	"context"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+)
+
+//	Tests that VendorSchema writes the transitive closure of a schema laid out by URI, rewriting absolute schemaLocations if so requested,
+//	and a catalog keeping earlier entries, with which the schema then loads offline.
+func TestVendorSchema(t *testing.T) {
+	var mutex sync.Mutex
+	var resolved []string
+	dir := t.TempDir()
+	resolver := PkgGen.Resolver
+	defer func() { PkgGen.Resolver = resolver }()
+	PkgGen.Resolver = SchemaResolverFunc(func(location, baseUri string) (io.ReadCloser, error) {
+		_, uri := splitUri(location, baseUri)
+		mutex.Lock()
+		resolved = append(resolved, uri)
+		mutex.Unlock()
+		return os.Open(filepath.Join("testdata", "vendor", filepath.FromSlash(strings.TrimPrefix(strings.TrimPrefix(uri, "vendor.example.com/schemas/"), "other.example.com/"))))
+	})
+	if err := ioutil.WriteFile(filepath.Join(dir, VendorCatalogFile), []byte(`<catalog xmlns="urn:oasis:names:tc:entity:xmlns:xml:catalog"><uri name="http://kept.example.com/kept.xsd" uri="kept.example.com/kept.xsd"/></catalog>`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	filePaths, err := VendorSchema(context.Background(), "http://vendor.example.com/schemas/main.xsd", dir, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var written []string
+	for _, filePath := range filePaths {
+		rel, _ := filepath.Rel(dir, filePath)
+		written = append(written, filepath.ToSlash(rel))
+	}
+	if len(resolved) != 3 {
+		t.Errorf("expected each schema document to be fetched once, got %v", resolved)
+	}
+	if sfmt("%v", written) != "[other.example.com/common.xsd vendor.example.com/schemas/main.xsd vendor.example.com/schemas/parts/part.xsd catalog.xml]" {
+		t.Errorf("unexpected files written %v", written)
+	}
+	if raw, err := ioutil.ReadFile(filepath.Join(dir, "vendor.example.com", "schemas", "main.xsd")); err != nil {
+		t.Fatal(err)
+	} else if !strings.Contains(string(raw), `schemaLocation="../../other.example.com/common.xsd"`) || !strings.Contains(string(raw), `schemaLocation='parts/part.xsd'`) {
+		t.Errorf("expected only the absolute schemaLocation to be rewritten in\n%s", raw)
+	}
+	cat, err := LoadCatalog(filepath.Join(dir, VendorCatalogFile))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for uri, expected := range map[string]string{
+		"http://kept.example.com/kept.xsd":                 "kept.example.com/kept.xsd",
+		"http://other.example.com/common.xsd":              "other.example.com/common.xsd",
+		"http://vendor.example.com/schemas/main.xsd":       "vendor.example.com/schemas/main.xsd",
+		"http://vendor.example.com/schemas/parts/part.xsd": "vendor.example.com/schemas/parts/part.xsd",
+	} {
+		if mapped, _ := cat.Lookup(uri); mapped != filepath.Join(dir, filepath.FromSlash(expected)) {
+			t.Errorf("expected the catalog to map %s to %s, got %s", uri, expected, mapped)
+		}
+	}
+	PkgGen.Resolver = nil
+	opts := DefaultGenOptions()
+	opts.Offline, opts.Catalog = true, cat
+	if sd, err := NewSchemaCache(0).LoadSchemaWithOptions(context.Background(), "http://vendor.example.com/schemas/main.xsd", false, LoadOptions{Generator: NewGenerator(opts)}); err != nil {
+		t.Fatal(err)
+	} else if (len(sd.XMLIncludedSchemas) != 1) || (len(sd.XMLImportedSchemas) != 1) {
+		t.Errorf("expected the vendored include and import to be loaded via the catalog")
+	}
+}
@@ -0,0 +1,47 @@
+package xsd
+
+import (
+	"github.com/metaleap/go-util-str"
+)
+
+//	A schema construct visited by Schema.Walk, together with its context in the schema document.
+type SchemaNode struct {
+	//	The schema construct, such as the *Schema itself, or a *ComplexType, *Element, *Sequence or *Attribute.
+	Elem interface{}
+
+	//	The node of the construct directly containing Elem, or nil for the *Schema.
+	Parent *SchemaNode
+
+	//	The nesting depth of Elem: 0 for the *Schema, 1 for its top-level constructs, and so on.
+	Depth int
+}
+
+//	Returns the XSD element name of the construct, such as "complexType" or "sequence".
+func (me SchemaNode) XsdName() string {
+	return me.Elem.(element).base().xsdName.String()
+}
+
+//	Returns the local file path (or else the URI) of the schema document declaring the construct, and the position in it right after its start tag (or 0, 0 if unknown).
+func (me SchemaNode) Position() (file string, line, col int) {
+	var sd *Schema
+	if sd, line, col = me.Elem.(element).base().position(); sd != nil {
+		file = ustr.Ifs(len(sd.loadLocalPath) > 0, sd.loadLocalPath, sd.loadUri)
+	}
+	return
+}
+
+//	Calls fn for this schema document and then, depth-first, for all the constructs it contains (grouped by kind, rather than in document order).
+//	If fn returns false for a node, the constructs contained in it are skipped. Included, redefined, overridden and imported schema documents are not walked,
+//	but can be walked in turn via XMLIncludedSchemas and XMLImportedSchemas.
+func (me *Schema) Walk(fn func(node SchemaNode) bool) {
+	walkElem(me, nil, 0, fn)
+}
+
+func walkElem(el element, parent *SchemaNode, depth int, fn func(node SchemaNode) bool) {
+	node := SchemaNode{Elem: el, Parent: parent, Depth: depth}
+	if fn(node) {
+		for _, kid := range el.base().kids {
+			walkElem(kid, &node, depth+1, fn)
+		}
+	}
+}
@@ -0,0 +1,35 @@
+package xsd
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestWalk(t *testing.T) {
+	var visited []string
+	sd := loadTestSchema(t, "sequence", "seq.xsd")
+	sd.Walk(func(node SchemaNode) bool {
+		if (node.Depth == 0) != (node.Parent == nil) || ((node.Parent != nil) && (node.Depth != node.Parent.Depth+1)) {
+			t.Errorf("%s: inconsistent depth %d and parent %v", node.XsdName(), node.Depth, node.Parent)
+		}
+		visited = append(visited, strings.Repeat(" ", node.Depth)+node.XsdName())
+		if el, _ := node.Elem.(*Element); (el != nil) && (el.Name == "carrier") {
+			if file, line, col := node.Position(); (filepath.Base(file) != "seq.xsd") || (line != 6) || (col == 0) {
+				t.Errorf("unexpected position %s:%d:%d of carrier", file, line, col)
+			}
+		}
+		return true
+	})
+	if s := strings.Join(visited, "|"); s != "schema| element| complexType|  sequence|   element|   element|   element" {
+		t.Errorf("unexpected nodes visited: %s", s)
+	}
+	visited = nil
+	sd.Walk(func(node SchemaNode) bool {
+		visited = append(visited, node.XsdName())
+		return node.XsdName() != "complexType"
+	})
+	if s := strings.Join(visited, "|"); s != "schema|element|complexType" {
+		t.Errorf("the contents of the complexType were not skipped: %s", s)
+	}
+}
@@ -0,0 +1,252 @@
+package xsd
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/metaleap/go-util-str"
+)
+
+//	Writes this schema document (but not those it includes or imports, see Flatten) as an XSD document to w, declaring the namespace prefixes
+//	of XMLNamespaces on its root element. The constructs contained in each construct are written in the order of the schema document they
+//	were loaded from (or, where not loaded from one, in the order the XSD content models prescribe, see xsdKidRanks), indented by tabs.
+//	Only the attributes and constructs known to this package are written, and the text content of xs:documentation is written without
+//	any markup it may have contained. The Schema need not have been loaded: one built or modified in code is written just the same.
+func (me *Schema) WriteXSD(w io.Writer) (err error) {
+	var buf bytes.Buffer
+	var depth int
+	var toks = me.xsdTokens(nil)
+	buf.WriteString(xml.Header)
+	for i, tok := range toks {
+		switch t := tok.(type) {
+		case xml.StartElement:
+			if i > 0 {
+				buf.WriteString("\n" + strings.Repeat("\t", depth))
+			}
+			buf.WriteString("<" + t.Name.Local)
+			for _, att := range t.Attr {
+				buf.WriteString(" " + att.Name.Local + "=\"")
+				xml.EscapeText(&buf, []byte(att.Value))
+				buf.WriteString("\"")
+			}
+			if _, empty := toks[i+1].(xml.EndElement); empty {
+				buf.WriteString("/>")
+			} else {
+				buf.WriteString(">")
+				depth++
+			}
+		case xml.CharData:
+			xml.EscapeText(&buf, t)
+		case xml.EndElement:
+			if _, empty := toks[i-1].(xml.StartElement); !empty {
+				if depth--; !isCharData(toks[i-1]) {
+					buf.WriteString("\n" + strings.Repeat("\t", depth))
+				}
+				buf.WriteString("</" + t.Name.Local + ">")
+			}
+		}
+	}
+	buf.WriteByte('\n')
+	_, err = w.Write(buf.Bytes())
+	return
+}
+
+//	Implements xml.Marshaler, so that xml.Marshal (or an xml.Encoder encoding a document that embeds this Schema, such as the types of a WSDL)
+//	writes this schema document as WriteXSD does, but without the XML declaration. The name of start is disregarded, its attributes are written.
+func (me *Schema) MarshalXML(enc *xml.Encoder, start xml.StartElement) (err error) {
+	for _, tok := range me.xsdTokens(start.Attr) {
+		if err = enc.EncodeToken(tok); err != nil {
+			return
+		}
+	}
+	return enc.Flush()
+}
+
+//	Returns the XML tokens of this schema document, its root element declaring the namespace prefixes of XMLNamespaces and then carrying atts.
+func (me *Schema) xsdTokens(atts []xml.Attr) (toks []xml.Token) {
+	var prefixes []string
+	var namespaces = map[string]string{}
+	var xsdPrefix, xsdBound = me.XSDNamespacePrefix, me.XMLNamespaces[me.XSDNamespacePrefix] == xsdNamespaceUri
+	for prefix, ns := range me.XMLNamespaces {
+		if prefix != "xml" {
+			prefixes, namespaces[prefix] = append(prefixes, prefix), ns
+		}
+		if (ns == xsdNamespaceUri) && !xsdBound {
+			xsdPrefix, xsdBound = prefix, true
+		}
+	}
+	if !xsdBound {
+		for xsdPrefix = "xs"; len(namespaces[xsdPrefix]) > 0; xsdPrefix += "d" {
+		}
+		prefixes, namespaces[xsdPrefix] = append(prefixes, xsdPrefix), xsdNamespaceUri
+	}
+	sort.Strings(prefixes)
+	var nsAtts []xml.Attr
+	for _, prefix := range prefixes {
+		nsAtts = append(nsAtts, xml.Attr{Name: xml.Name{Local: ustr.Ifs(len(prefix) > 0, "xmlns:"+prefix, "xmlns")}, Value: namespaces[prefix]})
+	}
+	return xsdElemTokens(nil, me, "schema", ustr.Ifs(len(xsdPrefix) > 0, xsdPrefix+":", ""), append(nsAtts, atts...))
+}
+
+//	Appends to toks the XML tokens of el as the XSD element named tag (qualified by xsdPrefix), its start tag carrying rootAtts before its own attributes.
+func xsdElemTokens(toks []xml.Token, el element, tag, xsdPrefix string, rootAtts []xml.Attr) []xml.Token {
+	var atts [][2]string
+	var text string
+	var tags = map[element]string{}
+	var kids []element
+	xsdFields(reflect.ValueOf(el).Elem(), &atts, tags, &kids, &text)
+	start := xml.StartElement{Name: xml.Name{Local: xsdPrefix + tag}, Attr: rootAtts}
+	for _, first := range []bool{true, false} {
+		for _, att := range atts {
+			if first == ((att[0] == "name") || (att[0] == "ref")) {
+				start.Attr = append(start.Attr, xml.Attr{Name: xml.Name{Local: att[0]}, Value: att[1]})
+			}
+		}
+	}
+	if toks = append(toks, start); len(text) > 0 {
+		toks = append(toks, xml.CharData(text))
+	}
+	for _, kid := range orderedKids(el, kids, tags) {
+		toks = xsdElemTokens(toks, kid, tags[kid], xsdPrefix, nil)
+	}
+	return append(toks, start.End())
+}
+
+//	Returns the constructs directly contained in el, ordered as WriteXSD writes them.
+func xsdKids(el element) []element {
+	var atts [][2]string
+	var text string
+	var tags = map[element]string{}
+	var kids []element
+	xsdFields(reflect.ValueOf(el).Elem(), &atts, tags, &kids, &text)
+	return orderedKids(el, kids, tags)
+}
+
+func isCharData(tok xml.Token) (is bool) {
+	_, is = tok.(xml.CharData)
+	return
+}
+
+//	Collects the non-zero XML attributes (as name-value pairs, in declaration order, but written with name and ref first), the child constructs
+//	(in declaration order, and mapped to their XSD element names) and the character data of the construct struct v, including those of the structs it embeds.
+func xsdFields(v reflect.Value, atts *[][2]string, tags map[element]string, kids *[]element, text *string) {
+	for i := 0; i < v.NumField(); i++ {
+		f, sf := v.Field(i), v.Type().Field(i)
+		name, opt := sf.Tag.Get("xml"), ""
+		if pos := strings.Index(name, ","); pos >= 0 {
+			name, opt = name[:pos], name[pos+1:]
+		}
+		switch {
+		case sf.Anonymous && (f.Kind() == reflect.Struct):
+			xsdFields(f, atts, tags, kids, text)
+		case (len(name) == 0) && (opt == "chardata"):
+			*text = f.String()
+		case (len(name) == 0) || (name == "-"):
+		case opt == "attr":
+			if !f.IsZero() {
+				*atts = append(*atts, [2]string{ustr.Ifs(name == "lang", "xml:lang", name), fmt.Sprint(f.Interface())})
+			}
+		case (f.Kind() == reflect.Ptr) && !f.IsNil():
+			if kid, ok := f.Interface().(element); ok {
+				tags[kid], *kids = name, append(*kids, kid)
+			}
+		case f.Kind() == reflect.Slice:
+			for j := 0; j < f.Len(); j++ {
+				if kid, ok := f.Index(j).Interface().(element); ok && !f.Index(j).IsNil() {
+					tags[kid], *kids = name, append(*kids, kid)
+				}
+			}
+		}
+	}
+}
+
+type elemsByPosition struct {
+	elems     []element
+	positions [][2]int
+}
+
+func (me *elemsByPosition) Len() int { return len(me.elems) }
+
+func (me *elemsByPosition) Less(i, j int) bool {
+	pi, pj := me.positions[i], me.positions[j]
+	return (pi[0] < pj[0]) || ((pi[0] == pj[0]) && (pi[1] < pj[1]))
+}
+
+func (me *elemsByPosition) Swap(i, j int) {
+	me.elems[i], me.elems[j] = me.elems[j], me.elems[i]
+	me.positions[i], me.positions[j] = me.positions[j], me.positions[i]
+}
+
+//	Ranks the XSD elements whose order their parents' content models prescribe (see orderedKids): those of equal rank (such as the particles
+//	of a sequence) may occur in any order. The components of a schema (and of its redefines and overrides) may too, see kidRank.
+var xsdKidRanks = map[string]int{
+	"annotation": 0,
+	"include":    1, "import": 1, "redefine": 1, "override": 1,
+	"defaultOpenContent": 2,
+	"simpleType":         3, "complexType": 3,
+	"simpleContent": 4, "complexContent": 4, "openContent": 4,
+	"list": 5, "union": 5, "restriction": 5, "extension": 5, "selector": 5,
+	"field": 6, "enumeration": 6, "pattern": 6, "length": 6, "minLength": 6, "maxLength": 6, "whiteSpace": 6, "totalDigits": 6, "fractionDigits": 6,
+	"minInclusive": 6, "maxInclusive": 6, "minExclusive": 6, "maxExclusive": 6, "explicitTimezone": 6, "assertion": 6,
+	"element": 7, "group": 7, "all": 7, "choice": 7, "sequence": 7, "any": 7, "notation": 7,
+	"attribute": 8, "attributeGroup": 8,
+	"anyAttribute": 9,
+	"alternative":  10,
+	"key":          11, "keyref": 11, "unique": 11,
+	"assert": 12,
+}
+
+//	Returns the xsdKidRanks of the XSD element named tag within el.
+func kidRank(el element, tag string) (rank int) {
+	if rank = xsdKidRanks[tag]; rank > xsdKidRanks["simpleType"] {
+		switch el.(type) {
+		case *Schema, *Redefine, *Override:
+			rank = xsdKidRanks["simpleType"]
+		}
+	}
+	return
+}
+
+//	Returns kids (the constructs directly contained in el, see xsdFields) in the order of the schema document they were loaded from if el was
+//	initialized with them (see elemBase.init) and all their positions are known. Any others (such as those built or added in code) are each
+//	inserted right after the last one not of higher kidRank, so that those of equal rank keep the order el was initialized with them in
+//	(such as the document order Flatten initializes it with), followed by the order they are declared in.
+func orderedKids(el element, kids []element, tags map[element]string) (ordered []element) {
+	var byPos = &elemsByPosition{}
+	var inited = map[element]bool{}
+	var positioned = true
+	var rest []element
+	for _, kid := range el.base().kids {
+		if _, ok := tags[kid]; ok && !inited[kid] {
+			_, line, col := kid.base().position()
+			inited[kid], positioned = true, positioned && (line > 0)
+			byPos.elems, byPos.positions = append(byPos.elems, kid), append(byPos.positions, [2]int{line, col})
+		}
+	}
+	if positioned {
+		sort.Stable(byPos)
+		ordered = byPos.elems
+	} else {
+		rest = byPos.elems
+	}
+	for _, kid := range kids {
+		if !inited[kid] {
+			rest = append(rest, kid)
+		}
+	}
+	for _, kid := range rest {
+		at, rank := 0, kidRank(el, tags[kid])
+		for i, k := range ordered {
+			if kidRank(el, tags[k]) <= rank {
+				at = i + 1
+			}
+		}
+		ordered = append(ordered[:at], append([]element{kid}, ordered[at:]...)...)
+	}
+	return
+}
@@ -0,0 +1,42 @@
+package xsd
+
+import (
+	"bytes"
+	"context"
+	"encoding/xml"
+	"strings"
+	"testing"
+)
+
+//	Tests that writing a loaded schema, loading what was written and writing it again yields the same XSD, that xml.Marshal writes an
+//	xs:schema element, and that constructs added in code are written where the content model requires.
+func TestWriteXSDRoundTrip(t *testing.T) {
+	var first, second bytes.Buffer
+	sd := loadTestSchema(t, "validate", "order.xsd")
+	if err := sd.WriteXSD(&first); err != nil {
+		t.Fatal(err)
+	}
+	reloaded, err := NewSchemaCache(0).LoadSchemaReader(context.Background(), bytes.NewReader(first.Bytes()), "order.xsd", LoadOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = reloaded.WriteXSD(&second); err != nil {
+		t.Fatal(err)
+	} else if first.String() != second.String() {
+		t.Errorf("writing the reloaded schema changed\n%s\ninto\n%s", first.String(), second.String())
+	}
+	if raw, err := xml.Marshal(sd); err != nil {
+		t.Fatal(err)
+	} else if !strings.HasPrefix(string(raw), "<xs:schema ") || !strings.Contains(string(raw), `<xs:pattern value="[A-Z]{3}-\d{3}"></xs:pattern>`) {
+		t.Errorf("unexpected xml.Marshal output %s", raw)
+	}
+	att := &Attribute{}
+	att.Name, att.Type = "origin", "xs:string"
+	sd.ComplexTypes[0].Attributes = append(sd.ComplexTypes[0].Attributes, att)
+	first.Reset()
+	if err = sd.WriteXSD(&first); err != nil {
+		t.Fatal(err)
+	} else if s := first.String(); !strings.Contains(s, "</xs:sequence>\n\t\t<xs:attribute name=\"origin\" type=\"xs:string\"/>\n\t</xs:complexType>") {
+		t.Errorf("expected the added attribute after the xs:sequence of Item in\n%s", s)
+	}
+}
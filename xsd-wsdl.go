@@ -0,0 +1,234 @@
+package xsd
+
+import (
+	"bytes"
+	"context"
+	"encoding/xml"
+	"io"
+	"io/ioutil"
+	"sort"
+
+	"github.com/metaleap/go-util-str"
+)
+
+//	A WSDL 1.1 document, reduced to what matters for loading the schemas it carries.
+type wsdlDoc struct {
+	//	The xs:schema elements within its wsdl:types, each made a standalone schema document (see wsdlSchemaSrc).
+	schemas [][]byte
+
+	//	The locations of its wsdl:imports.
+	imports []string
+
+	//	Whether the document is not a WSDL at all, but a schema document (which WSDL 1.1 permits wsdl:import to refer to).
+	isSchema bool
+}
+
+//	Loads the WSDL 1.1 document at the specified uri (protocol prefix defaults to http:// if omitted) and returns the schemas embedded in its
+//	wsdl:types (along with those of the WSDL and schema documents it wsdl:imports, directly or indirectly) in document order, ready for
+//	MakeGoPkgSrcFile or MakeGoPkgSrcFiles. The xs:includes and xs:imports of these schemas are loaded just like for LoadSchema, and their
+//	xs:imports that omit the schemaLocation (as is common in WSDLs) are resolved to the other embedded schemas of the matching target namespace.
+//	The Nth embedded schema of "example.com/svc.wsdl" is named "example.com/svc.wsdl.typesN.xsd" (so its Go package is generated into "svc.wsdl.typesN.xsd_go"),
+//	and inherits the namespace declarations in scope in the WSDL. Diagnostics refer to it by that name, but with the line numbers of the WSDL.
+//	If localCopy is true, the WSDL is only downloaded if it does not yet exist locally (relative to PkgGen.BaseCodePath).
+func LoadWSDL(uri string, localCopy bool) (schemas []*Schema, err error) {
+	return LoadWSDLContext(context.Background(), uri, localCopy)
+}
+
+//	Like LoadWSDL, but any remote fetches are aborted as soon as ctx is done, in which case ctx.Err() is returned.
+func LoadWSDLContext(ctx context.Context, uri string, localCopy bool) (schemas []*Schema, err error) {
+	return DefaultSchemaCache.LoadWSDL(ctx, uri, localCopy)
+}
+
+//	Like LoadWSDLContext, but uses (and populates) this cache instead of DefaultSchemaCache.
+func (me *SchemaCache) LoadWSDL(ctx context.Context, uri string, localCopy bool) (schemas []*Schema, err error) {
+	return me.LoadWSDLWithOptions(ctx, uri, localCopy, LoadOptions{})
+}
+
+//	Like LoadWSDLWithOptions, but uses (and populates) this cache instead of DefaultSchemaCache.
+func (me *SchemaCache) LoadWSDLWithOptions(ctx context.Context, uri string, localCopy bool, opts LoadOptions) (schemas []*Schema, err error) {
+	var cancel context.CancelFunc
+	ctx, cancel = context.WithCancel(ctx)
+	defer cancel()
+	loader := newSchemaLoader(ctx, me, opts)
+	if schemas, err = loader.loadWsdl(uri, "", localCopy, map[string]bool{}); err == nil {
+		linkNamespaceImports(loader.gen, schemas)
+		if err = loader.strictError(schemas); err != nil {
+			return nil, err
+		}
+		attachSoapOperations(soapOperations(loader.wsdlDefs), schemas)
+		for pendingUri, pendingSchema := range loader.pending {
+			me.Put(pendingUri, pendingSchema)
+		}
+	}
+	return
+}
+
+func (me *schemaLoader) loadWsdl(location, baseUri string, localCopy bool, visited map[string]bool) (schemas []*Schema, err error) {
+	var raw []byte
+	var wsdlUri, wsdlLocalPath string
+	var doc *wsdlDoc
+	var sd *Schema
+	var imps []*Schema
+	if _, wsdlUri = splitUri(location, baseUri); visited[wsdlUri] {
+		return
+	}
+	visited[wsdlUri] = true
+	if sd, ok := me.cached(wsdlUri); ok {
+		return []*Schema{sd}, nil
+	}
+	loaded := me.gen.hookSchemaLoad(wsdlUri)
+	err = me.openUri(location, baseUri, localCopy, func(r io.Reader, uri, localPath string) (err error) {
+		wsdlUri, wsdlLocalPath = uri, localPath
+		raw, err = ioutil.ReadAll(r)
+		return
+	})
+	if loaded(wsdlLocalPath, err); err != nil {
+		return
+	}
+	if doc, err = me.gen.parseWsdl(raw); (err != nil) || doc.isSchema {
+		if err == nil {
+			if sd, err = me.loadSchemaFrom(bytes.NewReader(raw), wsdlUri, wsdlLocalPath); err == nil {
+				schemas = append(schemas, sd)
+			}
+		}
+		return
+	}
+	if defs := me.gen.parseWsdlDefinitions(raw); defs != nil {
+		me.wsdlDefs = append(me.wsdlDefs, defs)
+	}
+	for i, src := range doc.schemas {
+		uri, localPath := sfmt("%s.types%d.xsd", wsdlUri, i+1), ""
+		if len(wsdlLocalPath) > 0 {
+			localPath = sfmt("%s.types%d.xsd", wsdlLocalPath, i+1)
+		}
+		if sd, ok := me.cached(uri); ok {
+			schemas = append(schemas, sd)
+		} else if sd, err = me.loadSchemaFrom(bytes.NewReader(src), uri, localPath); err == nil {
+			schemas = append(schemas, sd)
+		} else {
+			return
+		}
+	}
+	for _, loc := range doc.imports {
+		if imps, err = me.loadWsdl(loc, wsdlUri, localCopy, visited); err != nil {
+			return
+		}
+		schemas = append(schemas, imps...)
+	}
+	return
+}
+
+//	Decodes and processes the schema document read from r as if it had been fetched from uri (and stored at localPath).
+func (me *schemaLoader) loadSchemaFrom(r io.Reader, uri, localPath string) (sd *Schema, err error) {
+	var doc *schemaDoc
+	if doc, err = me.load(r, uri, localPath); err == nil {
+		me.unknowns = append(me.unknowns, doc.unknowns...)
+		me.prefetchRefs(doc)
+		if err = doc.sd.onLoad(me, doc.rootAtts, doc.uri, doc.localPath); err == nil {
+			sd = doc.sd
+		}
+	}
+	return
+}
+
+//	Scans the WSDL 1.1 document raw for the xs:schema elements within its wsdl:types and for its wsdl:imports.
+//	Elements are matched by local name only, as WSDLs in the wild are not too particular about their namespaces.
+func (me *Generator) parseWsdl(raw []byte) (doc *wsdlDoc, err error) {
+	var tok xml.Token
+	var names []string      // the local names of the currently open elements
+	var scopes [][]xml.Attr // the namespace declarations of the currently open elements
+	var xd = me.newSchemaDecoder(bytes.NewReader(raw))
+	doc = &wsdlDoc{}
+	for {
+		offset := xd.InputOffset()
+		if tok, err = xd.RawToken(); err != nil {
+			if err == io.EOF {
+				err = nil
+			}
+			return
+		}
+		switch t := tok.(type) {
+		case xml.Directive:
+			me.declareDocTypeEntities(xd.Entity, t)
+		case xml.StartElement:
+			switch {
+			case (len(names) == 0) && (t.Name.Local == "schema"):
+				doc.isSchema = true
+				return
+			case (len(names) == 1) && (t.Name.Local == "import"):
+				for _, att := range t.Attr {
+					if (len(att.Name.Space) == 0) && (att.Name.Local == "location") {
+						doc.imports = append(doc.imports, att.Value)
+					}
+				}
+			case (len(names) == 2) && (names[1] == "types") && (t.Name.Local == "schema"):
+				if err = skipRawElement(xd); err != nil {
+					return
+				}
+				doc.schemas = append(doc.schemas, wsdlSchemaSrc(raw, offset, xd.InputOffset(), t, scopes))
+				continue
+			}
+			names, scopes = append(names, t.Name.Local), append(scopes, t.Attr)
+		case xml.EndElement:
+			if len(names) > 0 {
+				names, scopes = names[:len(names)-1], scopes[:len(scopes)-1]
+			}
+		}
+	}
+}
+
+//	Reads the raw tokens up to and including the end tag of the element whose start tag was just read.
+func skipRawElement(xd *xml.Decoder) (err error) {
+	var tok xml.Token
+	for depth := 0; depth >= 0; {
+		if tok, err = xd.RawToken(); err != nil {
+			return
+		}
+		switch tok.(type) {
+		case xml.StartElement:
+			depth++
+		case xml.EndElement:
+			depth--
+		}
+	}
+	return
+}
+
+//	Returns the xs:schema element start (with start tag el) found between the specified offsets of raw as a standalone schema document:
+//	its start tag gets the namespace declarations in scope from the enclosing WSDL elements (unless it overrides them), and it is preceded by
+//	as many line breaks as precede it in raw, so that the line numbers of its components are those in the WSDL.
+func wsdlSchemaSrc(raw []byte, start, end int64, el xml.StartElement, scopes [][]xml.Attr) []byte {
+	var decls = map[string]string{}
+	var prefixes []string
+	var buf bytes.Buffer
+	for _, atts := range scopes {
+		for _, att := range atts {
+			if att.Name.Space == "xmlns" {
+				decls["xmlns:"+att.Name.Local] = att.Value
+			} else if (len(att.Name.Space) == 0) && (att.Name.Local == "xmlns") {
+				decls["xmlns"] = att.Value
+			}
+		}
+	}
+	for _, att := range el.Attr {
+		if att.Name.Space == "xmlns" {
+			delete(decls, "xmlns:"+att.Name.Local)
+		} else if (len(att.Name.Space) == 0) && (att.Name.Local == "xmlns") {
+			delete(decls, "xmlns")
+		}
+	}
+	for prefix, _ := range decls {
+		prefixes = append(prefixes, prefix)
+	}
+	sort.Strings(prefixes)
+	buf.Write(bytes.Repeat([]byte("\n"), bytes.Count(raw[:start], []byte("\n"))))
+	tagName := ustr.Ifs(len(el.Name.Space) > 0, el.Name.Space+":", "") + el.Name.Local
+	buf.Write(raw[start : start+1+int64(len(tagName))])
+	for _, prefix := range prefixes {
+		buf.WriteString(" " + prefix + "=\"")
+		xml.EscapeText(&buf, []byte(decls[prefix]))
+		buf.WriteString("\"")
+	}
+	buf.Write(raw[start+1+int64(len(tagName)) : end])
+	return buf.Bytes()
+}
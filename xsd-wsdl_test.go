@@ -0,0 +1,42 @@
+package xsd
+
+import (
+	"context"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestLoadWSDL(t *testing.T) {
+	opts := DefaultGenOptions()
+	opts.Offline = true
+	opts.Resolver = SchemaResolverFunc(func(location, baseUri string) (io.ReadCloser, error) {
+		return os.Open(filepath.Join("testdata", "wsdl", path.Base(location)))
+	})
+	gen := NewGenerator(opts)
+	schemas, err := NewSchemaCache(0).LoadWSDLWithOptions(context.Background(), "wsdl.example.com/svc.wsdl", false, LoadOptions{Generator: gen})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(schemas) != 2 {
+		t.Fatalf("expected the 2 embedded schemas, got %d", len(schemas))
+	}
+	for i, ns := range []string{"urn:example:common", "urn:example:svc"} {
+		if sd := schemas[i]; (sd.TargetNamespace.String() != ns) || !strings.HasSuffix(sd.loadUri, sfmt("svc.wsdl.types%d.xsd", i+1)) {
+			t.Errorf("schema %d: unexpected %s from %s", i, sd.TargetNamespace, sd.loadUri)
+		}
+	}
+	if imps := schemas[1].XMLImportedSchemas; (len(imps) != 1) || (imps[0] != schemas[0]) {
+		t.Errorf("the xs:import without schemaLocation was not resolved to the other embedded schema: %v", imps)
+	}
+	srcs, diags, err := gen.GenerateGoSourceAs(schemas[1], "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if src := string(srcs["svc.wsdl.types2.xsd.go"]); !strings.Contains(src, "Quote c.TMoney `") || diags.HasErrors() {
+		t.Errorf("quote is not of the imported TMoney (%v):\n%s", diags, src)
+	}
+}
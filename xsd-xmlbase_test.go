@@ -0,0 +1,38 @@
+package xsd
+
+import (
+	"strings"
+	"testing"
+)
+
+//	Tests that the xml:base of a schema document applies to the schemaLocations of its includes, and that DocLanguage selects the xs:documentation
+//	of the language asked for (by its own xml:lang or that of its schema document, also matching subtags), or else all of them.
+func TestXmlBaseAndLang(t *testing.T) {
+	sd := loadTestSchema(t, "xmlbase", "main.xsd")
+	if (sd.Includes[0].SchemaLocation != "parts/part.xsd") || (len(sd.XMLIncludedSchemas) != 1) || !strings.HasSuffix(sd.XMLIncludedSchemas[0].loadUri, "xmlbase/parts/part.xsd") {
+		t.Fatalf("expected parts/part.xsd to be included, got %s", sd.Includes[0].SchemaLocation)
+	}
+	docComment := func(src, typeName string) string {
+		pos := strings.Index(src, "\ntype "+typeName+" ")
+		if pos < 0 {
+			t.Fatalf("no type %s in\n%s", typeName, src)
+		}
+		return strings.TrimSpace(src[strings.LastIndex(src[:pos], "\n\n"):pos])
+	}
+	for _, c := range []struct {
+		lang, part, order string
+	}{
+		{"", "// Ein Teil.\n// A part.", "// Eine Bestellung.\n// An order."},
+		{"en", "// A part.", "// An order."},
+		{"de", "// Ein Teil.", "// Eine Bestellung."},
+		{"fr", "// Ein Teil.\n// A part.", "// Eine Bestellung.\n// An order."},
+	} {
+		src, _ := genTestSrc(t, "xmlbase", "main.xsd", func(opts *GenOptions) { opts.DocLanguage = c.lang })
+		if doc := docComment(src, "TPart"); doc != c.part {
+			t.Errorf("%q: expected the TPart doc comment %q, got %q", c.lang, c.part, doc)
+		}
+		if doc := docComment(src, "XsdGoPkgHasElem_Order"); doc != c.order {
+			t.Errorf("%q: expected the order doc comment %q, got %q", c.lang, c.order, doc)
+		}
+	}
+}
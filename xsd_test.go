@@ -0,0 +1,122 @@
+package xsd
+
+import (
+	"context"
+	"go/build"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+//	Copies the schemas in testdata/<dir> into src/xsdtest/<dir> of a temporary GOPATH, then loads them (see SchemaCache.LoadSchemaDir, with a
+//	cache of their own) and generates their Go packages next to them, with the default settings as modified by setup (if not nil).
+//	Returns the GOPATH and the paths of the main Go source files written.
+func genTestPkgs(t *testing.T, dir string, setup func(opts *GenOptions)) (gopath string, goOutFilePaths []string) {
+	var set *SchemaSet
+	var err error
+	gopath = t.TempDir()
+	codePath := filepath.Join(gopath, "src", "xsdtest")
+	if err = copyTestdata(filepath.Join("testdata", dir), filepath.Join(codePath, dir)); err != nil {
+		t.Fatal(err)
+	}
+	opts := DefaultGenOptions()
+	opts.BaseCodePath, opts.BasePath = codePath, "xsdtest"
+	if setup != nil {
+		setup(&opts)
+	}
+	if set, err = NewSchemaCache(0).LoadSchemaDir(context.Background(), filepath.Join(codePath, dir), LoadOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	set.Generator = NewGenerator(opts)
+	if goOutFilePaths, _, err = set.MakeGoPkgSrcFiles(); err != nil {
+		t.Fatal(err)
+	}
+	return
+}
+
+//	Loads the schemas in testdata/<dir> (see SchemaCache.LoadSchemaDir, with a cache of their own), returning the one loaded from its file fileName.
+func loadTestSchema(t testing.TB, dir, fileName string) *Schema {
+	set, err := NewSchemaCache(0).LoadSchemaDir(context.Background(), filepath.Join("testdata", dir), LoadOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, sd := range set.Schemas {
+		if filepath.Base(sd.loadUri) == fileName {
+			return sd
+		}
+	}
+	t.Fatalf("%s not loaded from testdata/%s", fileName, dir)
+	return nil
+}
+
+//	Generates the Go source of the schema loaded by loadTestSchema in memory (see Generator.GenerateGoSourceAs), with the default settings as modified
+//	by setup (if not nil), returning the source of its main file and the diagnostics, and failing t if generating fails.
+func genTestSrc(t testing.TB, dir, fileName string, setup func(opts *GenOptions)) (src string, diags Diagnostics) {
+	var srcs map[string][]byte
+	var err error
+	opts := DefaultGenOptions()
+	if setup != nil {
+		setup(&opts)
+	}
+	if srcs, diags, err = NewGenerator(opts).GenerateGoSourceAs(loadTestSchema(t, dir, fileName), ""); err != nil {
+		t.Fatal(err)
+	}
+	return string(srcs[fileName+".go"]), diags
+}
+
+//	Returns the declaration of the Go type named typeName in src, failing t if there is none.
+func goTypeDecl(t testing.TB, src, typeName string) string {
+	pos := strings.Index(src, "\ntype "+typeName+" ")
+	if pos < 0 {
+		t.Fatalf("no type %s in\n%s", typeName, src)
+	}
+	decl := src[pos+1:]
+	if end := strings.Index(decl, "\n}"); strings.HasSuffix(strings.SplitN(decl, "\n", 2)[0], "{") && (end > 0) {
+		return decl[:end+2]
+	}
+	return strings.SplitN(decl, "\n", 2)[0]
+}
+
+//	Copies the files in the directory tree at srcDir to dstDir.
+func copyTestdata(srcDir, dstDir string) error {
+	return filepath.Walk(srcDir, func(filePath string, info os.FileInfo, err error) error {
+		if (err == nil) && info.Mode().IsRegular() {
+			var raw []byte
+			rel, _ := filepath.Rel(srcDir, filePath)
+			dstPath := filepath.Join(dstDir, rel)
+			if err = os.MkdirAll(filepath.Dir(dstPath), 0755); err == nil {
+				if raw, err = ioutil.ReadFile(filePath); err == nil {
+					err = ioutil.WriteFile(dstPath, raw, 0644)
+				}
+			}
+		}
+		return err
+	})
+}
+
+//	Runs the go tool with args in the directory of goOutFilePath (a package generated by genTestPkgs into gopath), failing t with its output
+//	if it fails. The generated package resolves its import of github.com/metaleap/go-xsd/types via the GOPATH of this test.
+//	Skips t if there is no go tool.
+func goTool(t *testing.T, gopath, goOutFilePath string, args ...string) {
+	goCmd, err := exec.LookPath("go")
+	if err != nil {
+		t.Skip(err)
+	}
+	cmd := exec.Command(goCmd, args...)
+	cmd.Dir = filepath.Dir(goOutFilePath)
+	cmd.Env = append(os.Environ(), "GOPATH="+strings.Join([]string{gopath, build.Default.GOPATH}, string(filepath.ListSeparator)), "GO111MODULE=off", "GOFLAGS=")
+	if out, err := cmd.CombinedOutput(); err != nil {
+		t.Fatalf("go %s: %v\n%s", strings.Join(args, " "), err, out)
+	}
+}
+
+//	Writes testSrc as a test file into the package of goOutFilePath (generated by genTestPkgs into gopath) and runs its tests (see goTool).
+func goTestPkg(t *testing.T, gopath, goOutFilePath, testSrc string) {
+	if err := ioutil.WriteFile(filepath.Join(filepath.Dir(goOutFilePath), "xsdtest_test.go"), []byte(testSrc), 0644); err != nil {
+		t.Fatal(err)
+	}
+	goTool(t, gopath, goOutFilePath, "test")
+}